@@ -39,6 +39,8 @@ var (
 	flagMaxSize        = fs.Int("max", 10*1024, "max size of packet") // max 65,507 bytes
 	flagChanSize       = fs.Int("chan-size", 1024, "chunk size for internal data channels")
 	flagLogErrors      = fs.Bool("log-errors", false, "enable verbose packet decoding error logging")
+	flagStrictDecoders = fs.Bool("strict-decoders", false, "do not recover from panics in packet decoders, useful for debugging")
+	flagSummaryReport  = fs.String("summary-report", "", "generate a summary report at the end of the run, format can be text, markdown or html")
 	flagCalcEntropy    = fs.Bool("entropy", false, "enable entropy calculation for Eth,IP,TCP and UDP payloads")
 	flagFileStorage    = fs.String("fileStorage", "", "path to created extracted files (currently only for HTTP)")
 	flagBPF            = fs.String("bpf", "", "supply a BPF filter to use for netcap collection")
@@ -70,6 +72,9 @@ var (
 
 	flagFlushevery           = fs.Int("flushevery", defaults.FlushEvery, "flush assembler every N packets")
 	flagDefragIPv4           = fs.Bool("ip4defrag", defaults.DefragIPv4, "Defragment IPv4 packets")
+	flagDefragPolicy         = fs.String("ip4defrag-policy", defaults.DefragPolicy, "policy for overlapping IPv4 fragments: first, last, bsd or linux")
+	flagNormalizeAddrs       = fs.Bool("normalize-addrs", defaults.NormalizeAddresses, "convert ip and mac addresses into a canonical form")
+	flagExpandIPv6           = fs.Bool("expand-ipv6", false, "render normalized ipv6 addresses fully expanded instead of compressed")
 	flagChecksum             = fs.Bool("checksum", defaults.Checksum, "check TCP checksum")
 	flagNooptcheck           = fs.Bool("nooptcheck", defaults.NoOptCheck, "do not check TCP options (useful to ignore MSS on captures with TSO)")
 	flagIgnorefsmerr         = fs.Bool("ignorefsmerr", defaults.IgnoreFSMErr, "ignore TCP FSM errors")
@@ -77,7 +82,7 @@ var (
 	flagDebug                = fs.Bool("debug", false, "display debug information")
 	flagHexdump              = fs.Bool("hexdump", false, "dump packets used in stream reassembly as hex to the reassembly.log file")
 	flagWaitForConnections   = fs.Bool("wait-conns", true, "wait for all connections to finish processing before cleanup")
-	flagWriteincomplete      = fs.Bool("writeincomplete", false, "write incomplete response")
+	flagWriteincomplete      = fs.Bool("writeincomplete", false, "write incomplete responses and keep connections with missing bytes")
 	flagMemprofile           = fs.String("memprofile", "", "write memory profile")
 	flagConnFlushInterval    = fs.Int("conn-flush-interval", defaults.ConnFlushInterval, "flush connections every X flows")
 	flagConnTimeOut          = fs.Duration("conn-timeout", defaults.ConnTimeOut, "close connections older than X seconds")
@@ -85,4 +90,6 @@ var (
 	flagFlowTimeOut          = fs.Duration("flow-timeout", defaults.FlowTimeOut, "closes flows older than flowTimeout")
 	flagClosePendingTimeout  = fs.Duration("close-pending-timeout", defaults.ClosePendingTimeout, "reassembly: close connections that have pending bytes after X")
 	flagCloseInactiveTimeout = fs.Duration("close-inactive-timeout", defaults.CloseInactiveTimeout, "reassembly: close connections that are inactive after X")
+	flagFlushCoalesceWindow  = fs.Duration("flush-coalesce-window", defaults.FlushCoalesceWindow, "reassembly: extend flush timeouts by up to X for connections with a high recent throughput, 0 disables it")
+	flagClosedGracePeriod    = fs.Duration("closed-grace-period", defaults.ClosedGracePeriod, "reassembly: ignore segments that arrive later than X after a connection has been closed, 0 disables it")
 )
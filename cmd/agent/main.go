@@ -100,6 +100,8 @@ func Run() {
 		Promisc:             *flagPromiscMode,
 		SnapLen:             *flagSnapLen,
 		LogErrors:           *flagLogErrors,
+		StrictDecoders:      *flagStrictDecoders,
+		SummaryReport:       *flagSummaryReport,
 		DecoderConfig: &config.Config{
 			Buffer:               false,
 			Compression:          false,
@@ -115,6 +117,9 @@ func Run() {
 			MemBufferSize:        *flagMemBufferSize,
 			FlushEvery:           *flagFlushevery,
 			DefragIPv4:           *flagDefragIPv4,
+			DefragPolicy:         *flagDefragPolicy,
+			NormalizeAddresses:   *flagNormalizeAddrs,
+			ExpandIPv6:           *flagExpandIPv6,
 			Checksum:             *flagChecksum,
 			NoOptCheck:           *flagNooptcheck,
 			IgnoreFSMerr:         *flagIgnorefsmerr,
@@ -130,6 +135,8 @@ func Run() {
 			FlowTimeOut:          *flagFlowTimeOut,
 			CloseInactiveTimeOut: *flagCloseInactiveTimeout,
 			ClosePendingTimeOut:  *flagClosePendingTimeout,
+			FlushCoalesceWindow:  *flagFlushCoalesceWindow,
+			ClosedGracePeriod:    *flagClosedGracePeriod,
 			FileStorage:          *flagFileStorage,
 			CalculateEntropy:     *flagCalcEntropy,
 		},
@@ -21,6 +21,7 @@ import (
 	"github.com/namsral/flag"
 
 	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/io"
 )
 
 // Flags returns all flags.
@@ -38,8 +39,11 @@ var (
 	flagGenerateElasticIndices = fs.Bool("gen-elastic-indices", false, "generate elastic indices and mapping")
 	_                          = fs.String("config", "", "read configuration from file at path")
 	flagInput                  = fs.String("read", "", "read specified file, can either be a pcap or netcap audit record file")
-	flagMetricsAddr            = fs.String("metrics", "", "serve metrics at")
+	flagMetricsAddr            = fs.String("metrics", "", "serve prometheus metrics about the running capture at the given address")
+	flagWebSocketAddr          = fs.String("websocket", "", "stream audit records as JSON to websocket clients connected at the given address")
+	flagWebSocketBuffer        = fs.Int("websocket-buffer", 1000, "number of audit records queued for each websocket client before records are dropped")
 	flagOutDir                 = fs.String("out", "", "specify output directory, will be created if it does not exist")
+	flagOutDirs                = fs.String("out-dirs", "", "comma separated list of audit record types written to a custom output directory, e.g: HTTP=/mnt/fast,Connection=/mnt/share")
 	flagTimeout                = fs.Duration("timeout", 1*time.Second, "set the timeout for live capture, providing a value of zero will be substituted with pcap.BlockForever.")
 	flagLabels                 = fs.String("labels", "", "path to attacks for labeling audit records")
 
@@ -51,6 +55,22 @@ var (
 	flagInclude = fs.String("include", "", "include specific decoders")
 	flagExclude = fs.String("exclude", "", "exclude specific decoders")
 
+	flagIPProfileAllowList = fs.String("ip-profile-allow", "", "comma separated list of CIDRs, only addresses within these networks will be profiled")
+	flagIPProfileDenyList  = fs.String("ip-profile-deny", "", "comma separated list of CIDRs, addresses within these networks will not be profiled")
+
+	flagHomeNetworks    = fs.String("home-networks", "", "comma separated list of CIDRs that make up the home network, defaults to the private address ranges")
+	flagVolumeAnomalies = fs.Bool("volume-anomalies", false, "track the outbound traffic volume of internal hosts and flag spikes as VolumeAnomaly audit records")
+	flagVolumeBucket    = fs.Duration("volume-bucket", defaults.VolumeBucket, "size of the time buckets for the volume anomaly detection")
+	flagVolumeThreshold = fs.Float64("volume-threshold", defaults.VolumeThreshold, "number of standard deviations above the baseline mean for a bucket to be flagged as volume anomaly")
+	flagVolumeWindow    = fs.Int("volume-window", defaults.VolumeWindow, "number of recent buckets used as baseline for the volume anomaly detection")
+	flagVolumeMinBytes  = fs.Uint64("volume-min-bytes", defaults.VolumeMinBytes, "minimum number of bytes within a bucket to be flagged as volume anomaly")
+
+	flagQUIC = fs.Bool("quic", false, "decrypt the Initial packets of QUIC handshakes to extract the server name from the ClientHello")
+
+	flagPortScanPorts  = fs.Int("port-scan-ports", defaults.PortScanPorts, "number of distinct ports contacted on a single host within the window to flag a port scan, 0 disables the check")
+	flagPortScanHosts  = fs.Int("port-scan-hosts", defaults.PortScanHosts, "number of distinct hosts contacted on a single port within the window to flag a port scan, 0 disables the check")
+	flagPortScanWindow = fs.Duration("port-scan-window", defaults.PortScanWindow, "time window in which the distinct targets of an address are counted for the port scan detection")
+
 	flagDecoders              = fs.Bool("decoders", false, "show all available decoders")
 	flagPrintProtocolOverview = fs.Bool("overview", false, "print a list of all available decoders and fields")
 
@@ -74,27 +94,49 @@ var (
 	flagDecodeOptions = fs.String("opts", "lazy", "select decoding options")
 	flagPayload       = fs.Bool("payload", false, "capture payload for supported layers")
 
-	flagCSV              = fs.Bool("csv", false, "output data as CSV")
-	flagUNIX             = fs.Bool("unix", false, "output data via unix sockets")
-	flagNull             = fs.Bool("null", false, "write no data to disk")
-	flagElastic          = fs.Bool("elastic", false, "write data to elastic db")
-	flagElasticAddrs     = fs.String("elastic-addrs", "", "elastic db endpoints to write data to")
-	flagElasticUser      = fs.String("elastic-user", "", "elastic db username")
-	flagElasticPass      = fs.String("elastic-pass", "", "elastic db password")
-	flagBulkSizeGoPacket = fs.Int("elastic-bulk-gopacket", 2000, "elastic bulk size for gopacket audit records")
-	flagBulkSizeCustom   = fs.Int("elastic-bulk-custom", 1000, "elastic bulk size for custom audit records")
-	flagKibanaEndpoint   = fs.String("kibana", "", "kibana endpoint URL")
-	flagProto            = fs.Bool("proto", true, "output data as protobuf")
-	flagJSON             = fs.Bool("json", false, "output data as JSON")
-	flagContext          = fs.Bool("context", true, "add packet flow context to selected audit records")
-	flagHTTPShutdown     = fs.Bool("http-shutdown", false, "create local endpoint to trigger teardown via HTTP")
+	flagCSV                  = fs.Bool("csv", false, "output data as CSV")
+	flagCSVDelimiter         = fs.String("csv-delimiter", ",", "delimiter for the fields of CSV records, use \\t for tab separated values")
+	flagUNIX                 = fs.Bool("unix", false, "output data via unix sockets")
+	flagNull                 = fs.Bool("null", false, "write no data to disk")
+	flagElastic              = fs.Bool("elastic", false, "write data to elastic db")
+	flagElasticAddrs         = fs.String("elastic-addrs", "", "elastic db endpoints to write data to")
+	flagElasticUser          = fs.String("elastic-user", "", "elastic db username")
+	flagElasticPass          = fs.String("elastic-pass", "", "elastic db password")
+	flagBulkSizeGoPacket     = fs.Int("elastic-bulk-gopacket", 2000, "elastic bulk size for gopacket audit records")
+	flagBulkSizeCustom       = fs.Int("elastic-bulk-custom", 1000, "elastic bulk size for custom audit records")
+	flagElasticIndex         = fs.String("elastic-index", defaults.ElasticIndex, "elastic index name template, {type} is replaced with the audit record type and {date} with the day of the record")
+	flagElasticFlushInterval = fs.Duration("elastic-flush-interval", 0, "interval for sending incomplete batches to elastic, 0 waits for full batches")
+	flagElasticMaxRetries    = fs.Int("elastic-max-retries", defaults.ElasticMaxRetries, "number of retries for a batch when elastic is overloaded or not reachable")
+	flagKibanaEndpoint       = fs.String("kibana", "", "kibana endpoint URL")
+	flagSyslogAddr           = fs.String("syslog", "", "stream audit records as JSON to the syslog collector at the given address")
+	flagSyslogNetwork        = fs.String("syslog-proto", "udp", "transport protocol for the syslog collector: udp, tcp or unix")
+	flagSyslogFacility       = fs.String("syslog-facility", "user", "syslog facility for the emitted messages, e.g. local0")
+	flagSyslogQueueSize      = fs.Int("syslog-queue-size", 10000, "number of audit records queued for the syslog collector before records are dropped")
+	flagKafkaBrokers         = fs.String("kafka", "", "comma separated list of kafka brokers to publish the audit records to")
+	flagKafkaTopic           = fs.String("kafka-topic", defaults.KafkaTopic, "kafka topic name template, {type} is replaced with the audit record type")
+	flagKafkaEncoding        = fs.String("kafka-encoding", io.KafkaEncodingJSON, "encoding of the kafka message values: json or proto")
+	flagKafkaAcks            = fs.Int("kafka-acks", 1, "acknowledgements required from the kafka brokers: 1 for the leader, -1 for all in-sync replicas")
+	flagKafkaBatchSize       = fs.Int("kafka-batch", defaults.KafkaBatchSize, "number of messages sent to kafka per produce request")
+	flagKafkaFlushInterval   = fs.Duration("kafka-flush-interval", time.Second, "interval for sending incomplete batches to kafka, 0 waits for full batches")
+	flagProto                = fs.Bool("proto", true, "output data as protobuf")
+	flagJSON                 = fs.Bool("json", false, "output data as JSON")
+	flagCBOR                 = fs.Bool("cbor", false, "output data as CBOR")
+	flagAvro                 = fs.Bool("avro", false, "output data as avro object container files")
+	flagContext              = fs.Bool("context", true, "add packet flow context to selected audit records")
+	flagHTTPShutdown         = fs.Bool("http-shutdown", false, "create local endpoint to trigger teardown via HTTP")
 
 	flagMemBufferSize  = fs.Int("membuf-size", defaults.BufferSize, "set size for membuf")
+	flagMaxFileSize    = fs.Int64("max-file-size", 0, "rotate audit record files once they exceed the given number of bytes, 0 disables rotation")
 	flagListInterfaces = fs.Bool("interfaces", false, "list all visible network interfaces")
 	flagQuiet          = fs.Bool("quiet", false, "don't print infos to stdout")
 	flagPrintProgress  = fs.Bool("progress", false, "force printing progress to stderr even in quiet mode")
 
-	flagFileStorage = fs.String("fileStorage", "", "path to extracted files")
+	flagFileStorage     = fs.String("fileStorage", "", "path to extracted files")
+	flagHTTPBodyStorage = fs.String("http-body-storage", "", "path to store http response bodies named after their sha256 hash, disabled if empty")
+	flagHTTPBodyMaxSize = fs.Int("http-body-max-size", defaults.HTTPBodyMaxSize, "maximum size in bytes of http response bodies written to the body storage, 0 means no limit")
+	flagHTTPMaxDecoded  = fs.Int("http-max-decoded-size", defaults.HTTPMaxDecodedSize, "maximum size in bytes of http bodies after decompression, larger bodies are truncated, 0 means no limit")
+	flagHTTPDedup       = fs.Bool("http-dedup", false, "collapse consecutive identical http requests of a connection into a single record")
+	flagHTTPDedupMax    = fs.Int("http-dedup-max", defaults.HTTPDedupMax, "maximum number of identical http requests collapsed into a single record, 0 means no limit")
 
 	flagReverseDNS    = fs.Bool("reverse-dns", false, "resolve ips to domains via the operating systems default dns resolver")
 	flagLocalDNS      = fs.Bool("local-dns", false, "resolve DNS locally via hosts file in the database dir")
@@ -110,21 +152,33 @@ var (
 	flagTCPDebug  = fs.Bool("tcp-debug", false, "add debug output for TCP connections to debug.log")
 	flagSaveConns = fs.Bool("conns", false, "save raw TCP connections")
 
-	flagCalcEntropy = fs.Bool("entropy", false, "enable entropy calculation for Eth,IP,TCP and UDP payloads")
-	flagLogErrors   = fs.Bool("log-errors", false, "enable verbose packet decoding error logging")
+	flagConnsClientColor = fs.String("conns-client-color", "red", "color for the client data of saved connections, e.g. yellow, green+b or a 256 color code")
+	flagConnsServerColor = fs.String("conns-server-color", "blue", "color for the server data of saved connections")
+	flagConnsNoColor     = fs.Bool("conns-no-color", false, "save connections without color codes, client and server data can no longer be distinguished")
+
+	flagCalcEntropy    = fs.Bool("entropy", false, "enable entropy calculation for Eth,IP,TCP and UDP payloads")
+	flagLogErrors      = fs.Bool("log-errors", false, "enable verbose packet decoding error logging")
+	flagStrictDecoders = fs.Bool("strict-decoders", false, "do not recover from panics in packet decoders, useful for debugging")
+	flagSummaryReport  = fs.String("summary-report", "", "generate a summary report at the end of the run, format can be text, markdown or html")
 
 	// reassembly.
 	flagFlushevery           = fs.Int("flushevery", defaults.FlushEvery, "flush assembler every N packets")
+	flagFlushInterval        = fs.Duration("flush-interval", 0, "flush assembler when the capture time since the last flush exceeds X, 0 disables it")
 	flagDefragIPv4           = fs.Bool("ip4defrag", defaults.DefragIPv4, "Defragment IPv4 packets")
+	flagDefragPolicy         = fs.String("ip4defrag-policy", defaults.DefragPolicy, "policy for overlapping IPv4 fragments: first, last, bsd or linux")
+	flagNormalizeAddrs       = fs.Bool("normalize-addrs", defaults.NormalizeAddresses, "convert ip and mac addresses into a canonical form")
+	flagExpandIPv6           = fs.Bool("expand-ipv6", false, "render normalized ipv6 addresses fully expanded instead of compressed")
 	flagChecksum             = fs.Bool("checksum", defaults.Checksum, "check TCP checksum")
 	flagNooptcheck           = fs.Bool("nooptcheck", defaults.NoOptCheck, "do not check TCP options (useful to ignore MSS on captures with TSO)")
 	flagIgnorefsmerr         = fs.Bool("ignorefsmerr", defaults.IgnoreFSMErr, "ignore TCP FSM errors")
 	flagAllowmissinginit     = fs.Bool("allowmissinginit", defaults.AllowMissingInit, "support streams without SYN/SYN+ACK/ACK sequence")
 	flagHexdump              = fs.Bool("hexdump", false, "dump packets used in stream reassembly as hex to the reassembly.log file")
 	flagWaitForConnections   = fs.Bool("wait-conns", true, "wait for all connections to finish processing before cleanup")
-	flagWriteincomplete      = fs.Bool("writeincomplete", false, "write incomplete response")
+	flagWriteincomplete      = fs.Bool("writeincomplete", false, "write incomplete responses and keep connections with missing bytes")
 	flagStreamDecoderBufSize = fs.Int("sbuf-size", 1000, "size for channel used to pass data to the stream decoders. default is unbuffered")
 	flagReassemblyDebug      = fs.Bool("reassembly-debug", false, "if true, the reassembly will log verbose debugging information")
+	flagProxyProtocol        = fs.Bool("proxy-protocol", false, "strip PROXY protocol headers from the connections of the trusted proxies and attribute the streams to the original client")
+	flagProxyTrusted         = fs.String("proxy-protocol-trusted", "", "comma separated list of addresses, CIDRs and backend ports of the proxies that are trusted to send PROXY protocol headers")
 
 	flagNoPrompt   = fs.Bool("noprompt", false, "don't prompt for interaction during execution")
 	flagDebug      = fs.Bool("debug", false, "display debug information")
@@ -136,6 +190,8 @@ var (
 	flagFlowTimeOut                    = fs.Duration("flow-timeout", defaults.FlowTimeOut, "closes flows older than flowTimeout")
 	flagClosePendingTimeout            = fs.Duration("close-pending-timeout", defaults.ClosePendingTimeout, "reassembly: close connections that have pending bytes")
 	flagCloseInactiveTimeout           = fs.Duration("close-inactive-timeout", defaults.CloseInactiveTimeout, "reassembly: close connections that are inactive")
+	flagFlushCoalesceWindow            = fs.Duration("flush-coalesce-window", defaults.FlushCoalesceWindow, "reassembly: extend flush timeouts by up to X for connections with a high recent throughput, 0 disables it")
+	flagClosedGracePeriod              = fs.Duration("closed-grace-period", defaults.ClosedGracePeriod, "reassembly: ignore segments that arrive later than X after a connection has been closed, 0 disables it")
 	flagUseRE2                         = fs.Bool("re2", true, "if true uses the default golang re2 regex engine for service detection")
 	flagStopAfterHarvesterMatch        = fs.Bool("stop-after-harvester-match", true, "stop processing the conversation after the first credential harvester returned a result")
 	flagStopAfterServiceProbeMatch     = fs.Bool("stop-after-service-match", true, "stop processing the conversation after the first service probe returned a result")
@@ -143,14 +199,36 @@ var (
 	flagIgnoreInitErrs                 = fs.Bool("ignore-init-errors", true, "ignore errors from initializing custom decoders")
 	flagDisableGenericVersionHarvester = fs.Bool("disable-generic-software-harvester", true, "disable the generic software harvester regex")
 	flagRemoveClosedStreams            = fs.Bool("remove-closed-streams", false, "remove tcp streams that receive a FIN or RST packet from the stream pool")
+	flagMaxStreamReaders               = fs.Int("max-stream-readers", 0, "limit the number of concurrently running tcp stream reader goroutines, 0 means no limit")
+	flagMaxConcurrentConns             = fs.Int("max-concurrent-conns", 0, "limit the number of open tcp connections and drop packets of new connections once reached, 0 means no limit")
+	flagConnLimitWait                  = fs.Duration("conn-limit-wait", 0, "time to wait for open tcp connections to be closed before dropping a packet of a new connection, 0 drops immediately, only used for live captures with more than one worker")
+	flagMaxConversationBytes           = fs.Int("max-conversation-bytes", 0, "limit the number of bytes buffered for a single tcp connection, 0 means no limit")
+	flagCertShortValidityDays          = fs.Int("cert-short-validity", 7, "flag tls certificates that are valid for less than the given number of days")
+	flagIgnoreUnclosedStreams          = fs.Bool("ignore-unclosed-streams", false, "do not decode tcp streams that were closed by a timeout without seeing a FIN or RST packet")
+	flagConnGaps                       = fs.Bool("conn-gaps", false, "report gaps in the reassembled tcp streams on the connection audit records")
+	flagConnEntropy                    = fs.Bool("conn-entropy", false, "report the entropy of the reassembled tcp conversations on the connection audit records")
+	flagConnSNI                        = fs.Bool("conn-sni", false, "report the server name from the tls client hello of reassembled tcp connections on the connection audit records")
+	flagConnPcaps                      = fs.Bool("conn-pcaps", false, "write the packets of each reassembled tcp connection to a separate pcap file")
+	flagDecapGRE                       = fs.Bool("decap-gre", false, "strip the GRE header from tunneled packets and reassemble the inner connections")
+	flagDecapVXLAN                     = fs.Bool("decap-vxlan", false, "strip the VXLAN header from tunneled packets and reassemble the inner connections")
+	flagIgnoreUDPConns                 = fs.Bool("ignore-udp-conns", false, "do not write connection audit records for udp pseudo connections")
+	flagUDPConnTimeout                 = fs.Duration("udp-conn-timeout", defaults.UDPConnTimeout, "idle time after which a udp pseudo connection is written, 0 disables the timeout")
+	flagUDPStreamTimeout               = fs.Duration("udp-stream-timeout", defaults.UDPStreamTimeout, "idle time after which a udp stream is decoded, saved and evicted from memory, 0 keeps all streams until the end")
 	flagEncode                         = fs.Bool("encode", false, "encode data written into CSV file")
 
 	flagBannerSize          = fs.Int("bsize", 256, "size of the stored service banners in bytes")
 	flagHarvesterBannerSize = fs.Int("hbsize", 256, "size of the data passed to the credential harvesters in bytes")
 	flagCustomCredsRegex    = fs.String("reCustom", "", "possibility of passing a custom regex for harvesting credentials")
+	flagScanSecrets         = fs.Bool("scan-secrets", false, "scan all reassembled TCP conversations for cleartext credentials, API keys and tokens")
+	flagSecretPatterns      = fs.String("secret-patterns", "", "path to a JSON file with additional patterns for the secret scanner")
+	flagProtocolSignatures  = fs.String("protocol-signatures", "", "path to a JSON file with payload signatures for detecting the protocol of a stream")
+	flagSignatureSize       = fs.Int("signature-size", 512, "number of bytes at the start of the client and server streams inspected by the protocol signatures")
+	flagDPIProtocols        = fs.String("dpi-protocols", "", "path to a JSON file that maps the protocols identified by DPI to stream decoders")
+	flagTimestampSource     = fs.String("timestamp-source", defaults.TimestampSource, "primary timestamp for protocols that assert their own time, e.g. the HTTP Date header: capture or protocol")
 	flagStreamBufferSize    = fs.Int("stream-buffer", 10000, "input channel size for TCP / UDP stream processors")
 	flagNumStreamWorkers    = fs.Int("stream-workers", 10000, "number of TCP / UDP stream workers")
 
 	flagCompressionBlockSize = fs.Int("compression-block-size", defaults.CompressionBlockSize, "block size used for parallel compression")
 	flagCompressionLevel     = fs.String("compression-level", compressionLevelToString(defaults.CompressionLevel), "level of compression")
+	flagCompressionFormat    = fs.String("compression", string(io.CompressionGzip), "compression algorithm for audit record files: gzip, zstd or none")
 )
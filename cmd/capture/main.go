@@ -41,9 +41,7 @@ import (
 	"github.com/dreadl0ck/netcap"
 	"github.com/dreadl0ck/netcap/collector"
 	"github.com/dreadl0ck/netcap/decoder/packet"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/io"
-	"github.com/dreadl0ck/netcap/metrics"
 	"github.com/dreadl0ck/netcap/reassembly"
 	"github.com/dreadl0ck/netcap/utils"
 )
@@ -163,18 +161,56 @@ func Run() {
 		os.Exit(1)
 	}
 
-	if strings.HasSuffix(*flagInput, defaults.FileExtensionCompressed) || strings.HasSuffix(*flagInput, defaults.FileExtension) {
+	// client and server data of saved connections can only be told apart by their color
+	if !*flagConnsNoColor && ansi.ColorCode(*flagConnsClientColor) == ansi.ColorCode(*flagConnsServerColor) {
+		printHeader()
+		fmt.Println(ansi.Red + "> -conns-client-color and -conns-server-color must differ, use -conns-no-color to save connections without colors" + ansi.Reset)
+		os.Exit(1)
+	}
+
+	// fail before any output is created if the filter expression is invalid
+	if err = collector.ValidateBPF(*flagBPF); err != nil {
+		printHeader()
+		fmt.Println(ansi.Red + "> " + err.Error() + ansi.Reset)
+		os.Exit(1)
+	}
+
+	if utils.IsNetcapFile(*flagInput) {
 		printHeader()
 		fmt.Println(ansi.Red + "> the capture tool is used to create audit records from live traffic or a pcap dumpfile" + ansi.Reset)
 		fmt.Println(ansi.Red + "> use the dump tool to read netcap audit records" + ansi.Reset)
 		os.Exit(1)
 	}
 
+	// the metrics server is started by the collector once the decoders have been initialized
+	// TODO: make the packet metrics configurable separately, for performance analysis it is faster to only use the core metrics
 	var exportMetrics bool
-	if *flagMetricsAddr != "" {
-		metrics.ServeMetricsAt(*flagMetricsAddr, nil)
-		// TODO: make the packet metrics configurable separately, for performance analysis it is faster to only use the core metrics
-		// exportMetrics = true
+
+	if *flagWebSocketAddr != "" {
+		io.ServeWebSocketAt(*flagWebSocketAddr, *flagWebSocketBuffer)
+	}
+
+	// records are sent individually to the syslog collector
+	if *flagSyslogAddr != "" {
+		*flagCompress = false
+		*flagBuffer = false
+	}
+
+	// records are batched into bulk requests to elastic, which rules out compression
+	if *flagElastic {
+		*flagCompress = false
+	}
+
+	// records are batched by the kafka writer, which rules out buffering and compression
+	if *flagKafkaBrokers != "" {
+		*flagCompress = false
+		*flagBuffer = false
+
+		if *flagKafkaAcks != 1 && *flagKafkaAcks != -1 {
+			printHeader()
+			fmt.Println(ansi.Red + "> invalid value for -kafka-acks, use 1 for the leader or -1 for all in-sync replicas" + ansi.Reset)
+			os.Exit(1)
+		}
 	}
 
 	var numEpochs int
@@ -281,12 +317,18 @@ func Run() {
 		}
 	}
 
+	outDirs, err := io.ParseOutDirs(*flagOutDirs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// init collector
 	c := collector.New(collector.Config{
 		Workers:               *flagWorkers,
 		PacketBufferSize:      *flagPacketBuffer,
 		WriteUnknownPackets:   !*flagIgnoreUnknown,
 		Promisc:               *flagPromiscMode,
+		BPFFilter:             *flagBPF,
 		SnapLen:               *flagSnapLen,
 		BaseLayer:             utils.GetBaseLayer(*flagBaseLayer),
 		DecodeOptions:         utils.GetDecodeOptions(*flagDecodeOptions),
@@ -294,8 +336,11 @@ func Run() {
 		ReassembleConnections: *flagReassembleConnections,
 		FreeOSMem:             *flagFreeOSMemory,
 		LogErrors:             *flagLogErrors,
+		StrictDecoders:        *flagStrictDecoders,
+		SummaryReport:         *flagSummaryReport,
 		NoPrompt:              *flagNoPrompt,
 		HTTPShutdownEndpoint:  *flagHTTPShutdown,
+		MetricsAddr:           *flagMetricsAddr,
 		Timeout:               *flagTimeout,
 		Labels:                *flagLabels,
 		Scatter:               *flagScatter,
@@ -305,33 +350,74 @@ func Run() {
 			PrintProgress: *flagPrintProgress,
 			Buffer:        *flagBuffer,
 			MemBufferSize: *flagMemBufferSize,
+			MaxFileSize:   *flagMaxFileSize,
 			Compression:   *flagCompress,
 			CSV:           *flagCSV,
+			CSVDelimiter:  getCSVDelimiter(*flagCSVDelimiter),
 			UnixSocket:    *flagUNIX,
 			Encode:        *flagEncode,
 			Label:         *flagLabels != "",
 			Null:          *flagNull,
 			Elastic:       *flagElastic,
 			ElasticConfig: io.ElasticConfig{
-				ElasticAddrs:   elasticAddrs,
-				ElasticUser:    *flagElasticUser,
-				ElasticPass:    *flagElasticPass,
-				KibanaEndpoint: *flagKibanaEndpoint,
+				ElasticAddrs:         elasticAddrs,
+				ElasticUser:          *flagElasticUser,
+				ElasticPass:          *flagElasticPass,
+				KibanaEndpoint:       *flagKibanaEndpoint,
+				ElasticIndex:         *flagElasticIndex,
+				ElasticFlushInterval: *flagElasticFlushInterval,
+				ElasticMaxRetries:    *flagElasticMaxRetries,
+			},
+			Syslog: *flagSyslogAddr != "",
+			SyslogConfig: io.SyslogConfig{
+				SyslogNetwork:   *flagSyslogNetwork,
+				SyslogAddr:      *flagSyslogAddr,
+				SyslogFacility:  *flagSyslogFacility,
+				SyslogQueueSize: *flagSyslogQueueSize,
+			},
+			Kafka: *flagKafkaBrokers != "",
+			KafkaConfig: io.KafkaConfig{
+				KafkaBrokers:       strings.Split(*flagKafkaBrokers, ","),
+				KafkaTopic:         *flagKafkaTopic,
+				KafkaEncoding:      *flagKafkaEncoding,
+				KafkaAcks:          *flagKafkaAcks,
+				KafkaBatchSize:     *flagKafkaBatchSize,
+				KafkaFlushInterval: *flagKafkaFlushInterval,
 			},
 			BulkSizeGoPacket:               *flagBulkSizeGoPacket,
 			BulkSizeCustom:                 *flagBulkSizeCustom,
 			IncludeDecoders:                *flagInclude,
 			ExcludeDecoders:                *flagExclude,
+			IPProfileAllowList:             *flagIPProfileAllowList,
+			IPProfileDenyList:              *flagIPProfileDenyList,
+			HomeNetworks:                   *flagHomeNetworks,
+			VolumeAnomalies:                *flagVolumeAnomalies,
+			VolumeBucket:                   *flagVolumeBucket,
+			VolumeThreshold:                *flagVolumeThreshold,
+			VolumeWindow:                   *flagVolumeWindow,
+			VolumeMinBytes:                 *flagVolumeMinBytes,
+			QUIC:                           *flagQUIC,
+			PortScanPorts:                  *flagPortScanPorts,
+			PortScanHosts:                  *flagPortScanHosts,
+			PortScanWindow:                 *flagPortScanWindow,
 			Out:                            *flagOutDir,
+			OutDirs:                        outDirs,
 			Proto:                          *flagProto,
 			JSON:                           *flagJSON,
+			CBOR:                           *flagCBOR,
+			Avro:                           *flagAvro,
+			WebSocket:                      *flagWebSocketAddr != "",
 			Chan:                           false,
 			Source:                         source,
 			IncludePayloads:                *flagPayload,
 			ExportMetrics:                  exportMetrics,
 			AddContext:                     *flagContext,
 			FlushEvery:                     *flagFlushevery,
+			FlushInterval:                  *flagFlushInterval,
 			DefragIPv4:                     *flagDefragIPv4,
+			DefragPolicy:                   *flagDefragPolicy,
+			NormalizeAddresses:             *flagNormalizeAddrs,
+			ExpandIPv6:                     *flagExpandIPv6,
 			Checksum:                       *flagChecksum,
 			NoOptCheck:                     *flagNooptcheck,
 			IgnoreFSMerr:                   *flagIgnorefsmerr,
@@ -347,9 +433,19 @@ func Run() {
 			FlowTimeOut:                    *flagFlowTimeOut,
 			CloseInactiveTimeOut:           *flagCloseInactiveTimeout,
 			ClosePendingTimeOut:            *flagClosePendingTimeout,
+			FlushCoalesceWindow:            *flagFlushCoalesceWindow,
+			ClosedGracePeriod:              *flagClosedGracePeriod,
 			FileStorage:                    *flagFileStorage,
+			HTTPBodyStorage:                *flagHTTPBodyStorage,
+			HTTPBodyMaxSize:                *flagHTTPBodyMaxSize,
+			HTTPMaxDecodedSize:             *flagHTTPMaxDecoded,
+			HTTPDedup:                      *flagHTTPDedup,
+			HTTPDedupMax:                   *flagHTTPDedupMax,
 			CalculateEntropy:               *flagCalcEntropy,
 			SaveConns:                      *flagSaveConns,
+			ConnsClientColor:               *flagConnsClientColor,
+			ConnsServerColor:               *flagConnsServerColor,
+			ConnsNoColor:                   *flagConnsNoColor,
 			TCPDebug:                       *flagTCPDebug,
 			UseRE2:                         *flagUseRE2,
 			BannerSize:                     *flagBannerSize,
@@ -359,13 +455,37 @@ func Run() {
 			StopAfterServiceProbeMatch:     *flagStopAfterServiceProbeMatch,
 			StopAfterServiceCategoryMiss:   *flagStopAfterServiceCategoryMiss,
 			CustomRegex:                    *flagCustomCredsRegex,
+			ScanSecrets:                    *flagScanSecrets,
+			SecretPatterns:                 *flagSecretPatterns,
+			ProtocolSignatures:             *flagProtocolSignatures,
+			SignatureSize:                  *flagSignatureSize,
+			DPIProtocols:                   *flagDPIProtocols,
+			TimestampSource:                *flagTimestampSource,
+			ProxyProtocol:                  *flagProxyProtocol,
+			ProxyProtocolTrusted:           *flagProxyTrusted,
 			StreamBufferSize:               *flagStreamBufferSize,
 			NumStreamWorkers:               *flagNumStreamWorkers,
 			IgnoreDecoderInitErrors:        *flagIgnoreInitErrs,
 			DisableGenericVersionHarvester: *flagDisableGenericVersionHarvester,
 			RemoveClosedStreams:            *flagRemoveClosedStreams,
+			IgnoreUnclosedStreams:          *flagIgnoreUnclosedStreams,
+			ConnGaps:                       *flagConnGaps,
+			ConnEntropy:                    *flagConnEntropy,
+			ConnSNI:                        *flagConnSNI,
+			DumpConnectionPcaps:            *flagConnPcaps,
+			DecapGRE:                       *flagDecapGRE,
+			DecapVXLAN:                     *flagDecapVXLAN,
+			IgnoreUDPConnections:           *flagIgnoreUDPConns,
+			UDPConnTimeout:                 *flagUDPConnTimeout,
+			UDPStreamTimeout:               *flagUDPStreamTimeout,
+			MaxStreamReaders:               *flagMaxStreamReaders,
+			MaxConcurrentConnections:       *flagMaxConcurrentConns,
+			ConnectionLimitWait:            *flagConnLimitWait,
+			MaxConversationBytes:           *flagMaxConversationBytes,
+			CertShortValidityDays:          *flagCertShortValidityDays,
 			CompressionBlockSize:           *flagCompressionBlockSize,
 			CompressionLevel:               getCompressionLevel(*flagCompressionLevel),
+			CompressionFormat:              getCompression(*flagCompressionFormat),
 		},
 		ResolverConfig: resolvers.Config{
 			ReverseDNS:    *flagReverseDNS,
@@ -376,7 +496,6 @@ func Run() {
 			GeolocationDB: *flagGeolocationDB,
 		},
 	})
-	c.Bpf = *flagBPF
 	c.InputFile = *flagInput
 	c.PrintTime = *flagTime
 	c.Epochs = numEpochs
@@ -402,17 +521,7 @@ func Run() {
 	// start timer
 	start := time.Now()
 
-	// in case a BPF should be set, the gopacket/pcap version with libpcap bindings needs to be used
-	// setting BPF filters is not yet supported by the pcapgo package
-	if *flagBPF != "" {
-		if err = c.CollectBPF(*flagInput, *flagBPF); err != nil {
-			log.Fatal("failed to set BPF: ", err)
-		}
-
-		return
-	}
-
-	// if not, use native pcapgo version
+	// use native pcapgo version, the BPF is applied by the collector before the packets are decoded
 	isPcap, err := collector.IsPcap(*flagInput)
 	if err != nil {
 		// invalid path
@@ -483,16 +592,21 @@ func makeWriterConfig(name string, typ types.Type, elasticAddrs []string) *io.Wr
 		CSV:        *flagCSV,
 		Proto:      *flagProto,
 		JSON:       *flagJSON,
+		CBOR:       *flagCBOR,
+		Avro:       *flagAvro,
 		Name:       name,
 		Type:       typ,
 		Null:       *flagNull,
 		Elastic:    *flagElastic,
 		ElasticConfig: io.ElasticConfig{
-			ElasticAddrs:   elasticAddrs,
-			ElasticUser:    *flagElasticUser,
-			ElasticPass:    *flagElasticPass,
-			KibanaEndpoint: *flagKibanaEndpoint,
-			BulkSize:       *flagBulkSizeCustom,
+			ElasticAddrs:         elasticAddrs,
+			ElasticUser:          *flagElasticUser,
+			ElasticPass:          *flagElasticPass,
+			KibanaEndpoint:       *flagKibanaEndpoint,
+			BulkSize:             *flagBulkSizeCustom,
+			ElasticIndex:         *flagElasticIndex,
+			ElasticFlushInterval: *flagElasticFlushInterval,
+			ElasticMaxRetries:    *flagElasticMaxRetries,
 		},
 		Buffer:        *flagBuffer,
 		Compress:      *flagCompress,
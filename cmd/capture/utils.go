@@ -15,6 +15,8 @@ package capture
 
 import (
 	"fmt"
+	"log"
+	"unicode/utf8"
 
 	"github.com/klauspost/pgzip"
 
@@ -67,3 +69,27 @@ func compressionLevelToString(in int) string {
 		return "default"
 	}
 }
+
+// getCompression returns the compression algorithm for audit record files.
+func getCompression(in string) io.Compression {
+	c, err := io.ParseCompression(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return c
+}
+
+// getCSVDelimiter returns the delimiter for CSV records, a tab can be passed as \t.
+func getCSVDelimiter(in string) rune {
+	if in == `\t` {
+		return '\t'
+	}
+
+	r, size := utf8.DecodeRuneInString(in)
+	if size == 0 || size != len(in) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		log.Fatal("invalid CSV delimiter: ", in)
+	}
+
+	return r
+}
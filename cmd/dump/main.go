@@ -17,14 +17,12 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/evilsocket/islazy/tui"
 	"github.com/mgutz/ansi"
 
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
 	"github.com/dreadl0ck/netcap/utils"
@@ -89,8 +87,8 @@ func Run() {
 	types.StructureEnd = *flagEnd
 	types.FieldSeparator = *flagStructSeparator
 
-	// read ncap file and print to stdout
-	if filepath.Ext(*flagInput) == defaults.FileExtension || filepath.Ext(*flagInput) == ".gz" {
+	// read ncap file or stdin and print to stdout
+	if *flagInput == "-" || utils.IsNetcapFile(*flagInput) {
 		err = io.Dump(
 			os.Stdout,
 			io.DumpConfig{
@@ -17,7 +17,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"runtime/pprof"
 	"strconv"
 
@@ -27,7 +26,6 @@ import (
 	"github.com/dreadl0ck/netcap"
 	"github.com/dreadl0ck/netcap/collector"
 	"github.com/dreadl0ck/netcap/decoder/config"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/metrics"
 	"github.com/dreadl0ck/netcap/resolvers"
@@ -74,7 +72,7 @@ func Run() {
 	}
 
 	switch {
-	case filepath.Ext(*flagInput) == defaults.FileExtension || filepath.Ext(*flagInput) == ".gz":
+	case utils.IsNetcapFile(*flagInput):
 		metrics.ServeMetricsAt(*flagMetricsAddress, nil)
 		exportFile(*flagInput)
 	case *flagDir != "":
@@ -100,6 +98,11 @@ func Run() {
 			}
 		}
 
+		outDirs, err := io.ParseOutDirs(*flagOutDirs)
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		// it's a file
 		// parse PCAP file or live from interface
 		// init collector
@@ -110,6 +113,8 @@ func Run() {
 			SnapLen:             *flagSnapLen,
 			Promisc:             *flagPromiscMode,
 			LogErrors:           *flagLogErrors,
+			StrictDecoders:      *flagStrictDecoders,
+			SummaryReport:       *flagSummaryReport,
 			DecoderConfig: &config.Config{
 				Buffer:               *flagBuffer,
 				Compression:          *flagCompress,
@@ -117,6 +122,7 @@ func Run() {
 				IncludeDecoders:      *flagInclude,
 				ExcludeDecoders:      *flagExclude,
 				Out:                  *flagOutDir,
+				OutDirs:              outDirs,
 				Source:               source,
 				IncludePayloads:      *flagPayload,
 				ExportMetrics:        true,
@@ -124,6 +130,9 @@ func Run() {
 				MemBufferSize:        *flagMemBufferSize,
 				FlushEvery:           *flagFlushevery,
 				DefragIPv4:           *flagDefragIPv4,
+				DefragPolicy:         *flagDefragPolicy,
+				NormalizeAddresses:   *flagNormalizeAddrs,
+				ExpandIPv6:           *flagExpandIPv6,
 				Checksum:             *flagChecksum,
 				NoOptCheck:           *flagNooptcheck,
 				IgnoreFSMerr:         *flagIgnorefsmerr,
@@ -139,6 +148,8 @@ func Run() {
 				FlowTimeOut:          *flagFlowTimeOut,
 				CloseInactiveTimeOut: *flagCloseInactiveTimeout,
 				ClosePendingTimeOut:  *flagClosePendingTimeout,
+				FlushCoalesceWindow:  *flagFlushCoalesceWindow,
+				ClosedGracePeriod:    *flagClosedGracePeriod,
 				FileStorage:          *flagFileStorage,
 				CalculateEntropy:     *flagCalcEntropy,
 				Quiet:                false,
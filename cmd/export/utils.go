@@ -19,13 +19,13 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
-	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 func printHeader() {
@@ -58,12 +58,9 @@ func exportDir(path string) {
 	)
 
 	for _, f := range files {
-		var (
-			fName = f.Name()
-			ext   = filepath.Ext(fName)
-		)
+		fName := f.Name()
 
-		if ext == defaults.FileExtension || ext == ".gz" {
+		if utils.IsNetcapFile(fName) {
 			if !*flagReplay {
 				fmt.Println("exporting", fName)
 
@@ -66,10 +66,14 @@ var maltegoBaseConfig = collector.Config{
 		MemProfile:                     "",
 		ConnFlushInterval:              1000,
 		ConnTimeOut:                    defaults.ConnTimeOut,
+		UDPConnTimeout:                 defaults.UDPConnTimeout,
+		UDPStreamTimeout:               defaults.UDPStreamTimeout,
 		FlowFlushInterval:              2000,
 		FlowTimeOut:                    defaults.FlowTimeOut,
 		CloseInactiveTimeOut:           defaults.CloseInactiveTimeout,
 		ClosePendingTimeOut:            defaults.ClosePendingTimeout,
+		FlushCoalesceWindow:            defaults.FlushCoalesceWindow,
+		ClosedGracePeriod:              defaults.ClosedGracePeriod,
 		FileStorage:                    defaults.FileStorage,
 		CalculateEntropy:               false,
 		SaveConns:                      true,
@@ -160,12 +164,12 @@ func writeAuditRecords(trx *maltego.Transform, outDir string) {
 	}
 
 	for _, f := range files {
-		if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) {
+		if !utils.IsNetcapFile(f.Name()) {
 			continue
 		}
 
 		path := filepath.Join(outDir, f.Name())
-		name := strings.TrimSuffix(f.Name(), defaults.FileExtensionCompressed)
+		name := utils.TrimFileExtension(f.Name())
 
 		if f.IsDir() {
 			log.Println("not a file: ", err)
@@ -183,7 +187,7 @@ func writeAuditRecords(trx *maltego.Transform, outDir string) {
 
 		ent := addEntityWithPath(trx, "netcap."+name+"AuditRecords", utils.Pluralize(name), path)
 
-		ent.AddProperty("description", "Description", maltego.Strict, name+defaults.FileExtensionCompressed)
+		ent.AddProperty("description", "Description", maltego.Strict, f.Name())
 		ent.SetLinkLabel(strconv.Itoa(int(numRecords)))
 
 		// add notes for specific audit records here
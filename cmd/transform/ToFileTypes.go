@@ -14,29 +14,103 @@
 package transform
 
 import (
-	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/dustin/go-humanize"
+
 	"github.com/dreadl0ck/maltego"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
 	"github.com/dreadl0ck/netcap/types"
 )
 
+// maxFileTypeExamples is the number of file names shown in the display information of a file type.
+const maxFileTypeExamples = 10
+
+// fileType summarizes the extracted files with the same MIME type.
+type fileType struct {
+	files    uint64
+	bytes    uint64
+	examples []string
+}
+
+// toFileTypes emits the distinct MIME types of the files extracted for the selected host,
+// with the number of files and their total size as properties.
+// If no host has been selected, the files of all hosts are summarized.
 func toFileTypes() {
+	var (
+		mimeTypes = make(map[string]*fileType)
+		order     []string
+		pathName  string
+		ip        string
+	)
+
 	netmaltego.FilesTransform(
 		nil,
 		func(lt maltego.LocalTransform, trx *maltego.Transform, file *types.File, min, max uint64, path string, ipaddr string) {
+			if pathName == "" {
+				pathName = path
+				ip = ipaddr
+			}
+
+			if ipaddr != "" && file.SrcIP != ipaddr && file.DstIP != ipaddr {
+				return
+			}
+
 			typ := file.ContentTypeDetected
-			parts := strings.Split(file.ContentTypeDetected, ";")
-			if len(parts) > 1 {
-				typ = parts[0]
+			if typ == "" {
+				typ = file.ContentType
 			}
 
-			ent := addEntityWithPath(trx, "netcap.ContentType", typ, path)
+			// ignore the encoding value
+			typ = strings.TrimSpace(strings.Split(typ, ";")[0])
+			if typ == "" {
+				typ = "unknown"
+			}
 
-			//// di := "<h3>File</h3><p>Timestamp: " + file.Timestamp + "</p><p>Source: " + file.Source + "</p><p>MD5: " + file.Hash + "</p><p>ContentType: " + file.ContentType + "</p><p>Length: " + strconv.Itoa(int(file.Length)) + "</p><p>Ident: " + file.Ident + "</p><p>SrcIP: " + file.Context.SrcIP + "</p><p>DstIP: " + file.Context.DstIP + "</p><p>SrcPort: " + file.Context.SrcPort + "</p><p>DstPort: " + file.Context.DstPort + "</p><p>Location: " + file.Location + "</p>"
-			//// ent.AddDisplayInformation(di, "Netcap Info")
+			f, ok := mimeTypes[typ]
+			if !ok {
+				f = new(fileType)
+				mimeTypes[typ] = f
+				order = append(order, typ)
+			}
 
-			ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Strict, ipaddr)
+			f.files++
+			f.bytes += uint64(file.Length)
+			f.examples = appendUniqueLimit(f.examples, file.Name, maxFileTypeExamples)
 		},
+		true,
 	)
+
+	var (
+		trx       = &maltego.Transform{}
+		thickness linkThickness
+	)
+
+	for _, f := range mimeTypes {
+		thickness.add(f.files)
+	}
+
+	for _, typ := range order {
+		f := mimeTypes[typ]
+
+		ent := addEntityWithPath(trx, "netcap.FileType", typ, pathName)
+		ent.AddProperty("files", "Files", maltego.Strict, strconv.FormatUint(f.files, 10))
+		ent.AddProperty("bytes", "Bytes", maltego.Strict, strconv.FormatUint(f.bytes, 10))
+		ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Strict, ip)
+
+		var di strings.Builder
+		di.WriteString("<h3>Example Files</h3>")
+		for _, name := range f.examples {
+			di.WriteString("<p>" + maltego.EscapeText(name) + "</p>")
+		}
+		ent.AddDisplayInformation(di.String(), "Netcap Info")
+
+		ent.SetLinkLabel(strconv.FormatUint(f.files, 10) + " files\n" + humanize.Bytes(f.bytes))
+		ent.SetLinkThickness(thickness.get(f.files))
+	}
+
+	trx.AddUIMessage("completed!", maltego.UIMessageInform)
+	fmt.Println(trx.ReturnOutput())
 }
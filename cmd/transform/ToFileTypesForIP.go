@@ -37,5 +37,6 @@ func toFileTypesForIP() {
 
 			}
 		},
+		false,
 	)
 }
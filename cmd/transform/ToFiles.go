@@ -57,5 +57,6 @@ func toFiles() {
 				}
 			}
 		},
+		false,
 	)
 }
@@ -69,5 +69,6 @@ func toFilesForContentType() {
 				}
 			}
 		},
+		false,
 	)
 }
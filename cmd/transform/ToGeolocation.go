@@ -38,6 +38,10 @@ func toGeolocation() {
 
 func addGeolocation(trx *maltego.Transform, profile *types.IPProfile, min, max uint64, path string) {
 	ent := addEntityWithPath(trx, "netcap.Location", profile.Geolocation, path)
+	if profile.Latitude != 0 || profile.Longitude != 0 {
+		ent.AddProperty("latitude", "Latitude", maltego.Strict, formatCoordinate(profile.Latitude))
+		ent.AddProperty("longitude", "Longitude", maltego.Strict, formatCoordinate(profile.Longitude))
+	}
 	ent.SetLinkLabel(strconv.FormatInt(profile.NumPackets, 10) + " pkts")
 	ent.SetLinkThickness(maltego.GetThickness(uint64(profile.NumPackets), min, max))
 }
@@ -0,0 +1,164 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"encoding/xml"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/dreadl0ck/maltego"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"github.com/dreadl0ck/netcap/resolvers"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// name of the KML file that is written next to the audit records.
+const geolocationMapFile = "geolocations.kml"
+
+// kmlDocument is the root element of a KML file.
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Namespace  string         `xml:"xmlns,attr"`
+	Name       string         `xml:"Document>name"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+// kmlPlacemark is a single location on the map.
+type kmlPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Coordinates string `xml:"Point>coordinates"`
+}
+
+func toGeolocationMap() {
+	var (
+		profiles map[string]*types.IPProfile
+		written  bool
+	)
+
+	netmaltego.IPProfileTransform(
+		// keep a reference to the profiles loaded by the transform, to avoid reading the audit records again
+		func(profile *types.IPProfile, mac string, min, max *uint64, ips map[string]*types.IPProfile) {
+			profiles = ips
+			netmaltego.CountIPPackets(profile, mac, min, max, ips)
+		},
+		func(lt maltego.LocalTransform, trx *maltego.Transform, profile *types.IPProfile, min, max uint64, path string, mac string, ipaddr string) {
+			// the map contains all external addresses, write it once the location of the audit records is known
+			if !written {
+				written = true
+
+				kmlPath := filepath.Join(filepath.Dir(path), geolocationMapFile)
+				if err := writeGeolocationMapFile(kmlPath, profiles); err != nil {
+					log.Println("failed to write KML file:", err)
+					trx.AddUIMessage("failed to write KML file: "+err.Error(), maltego.UIMessagePartialError)
+				} else {
+					trx.AddUIMessage("wrote KML file: "+kmlPath, maltego.UIMessageInform)
+				}
+			}
+
+			if !hasGeolocationCoordinates(profile) {
+				return
+			}
+
+			ent := addEntityWithPath(trx, "netcap.Location", profile.Addr+"\n"+profile.Geolocation, path)
+			ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Strict, profile.Addr)
+			ent.AddProperty("geolocation", "Geolocation", maltego.Strict, profile.Geolocation)
+			ent.AddProperty("latitude", "Latitude", maltego.Strict, formatCoordinate(profile.Latitude))
+			ent.AddProperty("longitude", "Longitude", maltego.Strict, formatCoordinate(profile.Longitude))
+
+			ent.SetLinkLabel(strconv.FormatInt(profile.NumPackets, 10) + " pkts\n" + humanize.Bytes(profile.Bytes))
+			ent.SetLinkThickness(maltego.GetThickness(uint64(profile.NumPackets), min, max))
+		},
+	)
+}
+
+// hasGeolocationCoordinates checks if the profile belongs to an external address with known coordinates.
+// Private addresses are skipped, since the geolocation database has no meaningful location for them.
+func hasGeolocationCoordinates(profile *types.IPProfile) bool {
+	if profile.Latitude == 0 && profile.Longitude == 0 {
+		return false
+	}
+
+	ip := net.ParseIP(profile.Addr)
+
+	return ip != nil && !resolvers.IsPrivateIP(ip)
+}
+
+// writeGeolocationMapFile writes a KML file with a placemark for each external address to the given path.
+func writeGeolocationMapFile(path string, profiles map[string]*types.IPProfile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = writeGeolocationMap(f, profiles)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+
+	return err
+}
+
+// writeGeolocationMap encodes a KML document with a placemark for each external address with known coordinates.
+// The placemarks are sorted by address, to produce the same file for the same input.
+func writeGeolocationMap(w io.Writer, profiles map[string]*types.IPProfile) error {
+	doc := kmlDocument{
+		Namespace: "http://www.opengis.net/kml/2.2",
+		Name:      "NETCAP External IPs",
+	}
+
+	for _, p := range profiles {
+		if !hasGeolocationCoordinates(p) {
+			continue
+		}
+
+		doc.Placemarks = append(doc.Placemarks, kmlPlacemark{
+			Name:        p.Addr,
+			Description: p.Geolocation + "\n" + strconv.FormatInt(p.NumPackets, 10) + " pkts, " + humanize.Bytes(p.Bytes),
+			// KML expects the longitude first
+			Coordinates: formatCoordinate(p.Longitude) + "," + formatCoordinate(p.Latitude),
+		})
+	}
+
+	sort.Slice(doc.Placemarks, func(i, j int) bool {
+		return doc.Placemarks[i].Name < doc.Placemarks[j].Name
+	})
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func formatCoordinate(c float64) string {
+	return strconv.FormatFloat(c, 'f', -1, 64)
+}
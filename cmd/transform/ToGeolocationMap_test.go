@@ -0,0 +1,91 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestWriteGeolocationMap(t *testing.T) {
+	profiles := map[string]*types.IPProfile{
+		"8.8.8.8": {
+			Addr:        "8.8.8.8",
+			Geolocation: "United States",
+			Latitude:    37.751,
+			Longitude:   -97.822,
+			NumPackets:  10,
+			Bytes:       1000,
+		},
+		"1.1.1.1": {
+			Addr:        "1.1.1.1",
+			Geolocation: "Australia",
+			Latitude:    -33.494,
+			Longitude:   143.2104,
+			NumPackets:  5,
+			Bytes:       500,
+		},
+		// private address, skipped despite having coordinates
+		"192.168.1.1": {
+			Addr:      "192.168.1.1",
+			Latitude:  1,
+			Longitude: 2,
+		},
+		// external address without a known location
+		"9.9.9.9": {
+			Addr: "9.9.9.9",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeGeolocationMap(&buf, profiles); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc kmlDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		name        string
+		coordinates string
+	}{
+		// sorted by address, longitude first
+		{"1.1.1.1", "143.2104,-33.494"},
+		{"8.8.8.8", "-97.822,37.751"},
+	}
+
+	if len(doc.Placemarks) != len(expected) {
+		t.Fatal("expected", len(expected), "placemarks, got", len(doc.Placemarks))
+	}
+
+	for i, e := range expected {
+		p := doc.Placemarks[i]
+		if p.Name != e.name {
+			t.Fatal("expected placemark", e.name, "got", p.Name)
+		}
+
+		if p.Coordinates != e.coordinates {
+			t.Fatal("expected coordinates", e.coordinates, "for", p.Name, "got", p.Coordinates)
+		}
+	}
+
+	if doc.Placemarks[1].Description != "United States\n10 pkts, 1.0 kB" {
+		t.Fatalf("unexpected description: %q", doc.Placemarks[1].Description)
+	}
+}
@@ -62,5 +62,6 @@ func toIANAServices() {
 				ent.AddProperty("port", "Port", maltego.Loose, conn.DstPort)
 			}
 		},
+		false,
 	)
 }
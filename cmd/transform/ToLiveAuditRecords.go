@@ -27,7 +27,6 @@ import (
 
 	"github.com/dreadl0ck/maltego"
 	"github.com/dreadl0ck/netcap/decoder/packet"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/utils"
 )
@@ -70,19 +69,11 @@ func writeLiveAuditRecords(outDir string) {
 	// generate maltego transform
 	trx := &maltego.Transform{}
 	for _, name := range allDecoders {
-		path := filepath.Join(outDir, name+defaults.FileExtension)
-
-		// stat generated profiles
-		stat, err := os.Stat(path)
+		// stat generated profiles, with any of the compressed or uncompressed extensions
+		path, stat, err := utils.StatNetcapFile(filepath.Join(outDir, name))
 		if err != nil {
-			log.Println("invalid path:", err, "trying", defaults.FileExtensionCompressed, "extension")
-
-			path = filepath.Join(outDir, name+defaults.FileExtensionCompressed)
-			stat, err = os.Stat(path)
-			if err != nil {
-				log.Println("invalid path:", err)
-				continue
-			}
+			log.Println("invalid path:", err)
+			continue
 		}
 		if stat.IsDir() {
 			log.Println("not a file: ", err)
@@ -106,7 +97,7 @@ func writeLiveAuditRecords(outDir string) {
 		}
 
 		ent := addEntityWithPath(trx, "netcap."+name+"AuditRecords", utils.Pluralize(name), path)
-		ent.AddProperty("description", "Description", maltego.Loose, name+defaults.FileExtension)
+		ent.AddProperty("description", "Description", maltego.Loose, filepath.Base(path))
 		ent.SetLinkLabel(strconv.Itoa(int(numRecords)))
 
 		// add notes for specific audit records here
@@ -13,30 +13,7 @@
 
 package transform
 
-import (
-	"github.com/dreadl0ck/maltego"
-	netmaltego "github.com/dreadl0ck/netcap/maltego"
-	"github.com/dreadl0ck/netcap/types"
-	"github.com/dreadl0ck/netcap/utils"
-)
-
+// toSSHClients emits the distinct SSH client versions with their HASSH fingerprints and negotiated algorithms.
 func toSSHClients() {
-	netmaltego.SSHTransform(
-		nil,
-		func(lt maltego.LocalTransform, trx *maltego.Transform, ssh *types.SSH, min, max uint64, path string, mac string, ipaddr string) {
-			if ssh.IsClient {
-				val := ssh.HASSH
-				if len(ssh.Ident) > 0 {
-					val += "\n" + ssh.Ident
-				}
-
-				ent := addEntityWithPath(trx, "netcap.SSHClient", val, path)
-				ent.AddProperty("timestamp", "Timestamp", maltego.Strict, utils.UnixTimeToUTC(ssh.Timestamp))
-				ent.AddProperty("ident", "Ident", maltego.Strict, ssh.Ident)
-				ent.AddProperty("algorithms", "Algorithms", maltego.Strict, ssh.Algorithms)
-
-				ent.AddDisplayInformation(ssh.Flow+"<br>", "Flows")
-			}
-		},
-	)
+	sshVersionsTransform("netcap.SSHClient", true)
 }
@@ -64,6 +64,8 @@ func toServices() {
 				ent.AddProperty("bytesclient", "BytesClient", maltego.Strict, strconv.Itoa(int(service.BytesClient)))
 				ent.AddProperty("bytesserver", "BytesServer", maltego.Strict, strconv.Itoa(int(service.BytesServer)))
 				ent.AddProperty("vendor", "Vendor", maltego.Strict, service.Vendor)
+				ent.AddProperty("mac", "MAC Address", maltego.Strict, service.MacAddr)
+				ent.AddProperty("manufacturer", "Device Manufacturer", maltego.Strict, service.DeviceManufacturer)
 				ent.AddProperty("name", "Name", maltego.Strict, service.Name)
 
 				ent.SetLinkLabel(humanize.Bytes(uint64(service.BytesServer)) + " server\n" + humanize.Bytes(uint64(service.BytesClient)) + " client")
@@ -50,6 +50,7 @@ func Run() {
 		toSoftwareProducts,
 		toIPProfilesForSoftware,
 		toCredentialsByService,
+		toCredentials,
 		toLoginInformation,
 		toHosts,
 		toUDPHosts,
@@ -62,6 +63,7 @@ func Run() {
 		toSoftwareVulnerabilities,
 		openVulnerability,
 		toDNSQuestions,
+		toDNSQueries,
 		toDevices,
 		toApplicationCategories,
 		toApplications,
@@ -82,14 +84,17 @@ func Run() {
 		toDestinationIPs,
 		toSourceIPs,
 		toSourceDevices,
+		toMACAddresses,
 		toHTTPHostsFiltered,
 		toDestinationPorts,
+		toOpenPorts,
 		toIncomingConnsFiltered,
 		toFileTypes,
 		toFiles,
 		toFileType,
 		toFilesForContentType,
 		toGeolocation,
+		toGeolocationMap,
 		toIPProfiles,
 		toJA3HashesForProfile,
 		toParameterValues,
@@ -118,8 +123,11 @@ func Run() {
 		toServerNameIndicators,
 		toSourcePorts,
 		toOutgoingConnsFiltered,
+		toInternalCommGraph,
 		toVisitorsForURL,
 		toVisitorsForHost,
+		toHostsWithUserAgent,
+		toHostsWithUserAgentSubstring,
 		toProviderIPProfilesForURL,
 		toProviderIPProfilesForHost,
 		openNetcapFolderInTerminal,
@@ -16,20 +16,30 @@ package transform
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/dreadl0ck/maltego"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
 )
 
 func openTrafficForPortInWireshark() {
 	var (
-		lt              = maltego.ParseLocalArguments(os.Args[3:])
-		trx             = &maltego.Transform{}
-		in              = strings.TrimSuffix(filepath.Dir(strings.TrimPrefix(lt.Values["path"], "file://")), ".net")
-		bpf             = makePortBPF(lt)
+		lt       = maltego.ParseLocalArguments(os.Args[3:])
+		trx      = &maltego.Transform{}
+		in       = strings.TrimSuffix(filepath.Dir(strings.TrimPrefix(lt.Values["path"], "file://")), ".net")
+		bpf, err = makePortBPF(lt)
+	)
+
+	if err != nil {
+		maltego.Die(err.Error(), "invalid port")
+	}
+
+	var (
 		outFile, exists = makeOutFilePath(in, bpf, lt, false, "")
 		args            = []string{"-r", in, "-w", outFile, bpf}
 	)
@@ -59,12 +69,30 @@ func openTrafficForPortInWireshark() {
 }
 
 // creates a bpf to filter for traffic of a given port number
-// eg: "port 443"
-func makePortBPF(lt maltego.LocalTransform) string {
-	var b strings.Builder
+// if the entity carries the address of the host and its peer, the filter is narrowed down to the conversation
+// eg: "port 443", "tcp port 443", "host 127.0.0.1 and port 443" or "host 127.0.0.1 and host 10.0.0.1 and tcp port 443"
+func makePortBPF(lt maltego.LocalTransform) (string, error) {
+	var parts []string
 
-	b.WriteString("port ")
-	b.WriteString(lt.Values["port"])
+	for _, prop := range []string{netmaltego.PropertyIpAddr, netmaltego.PropertyPeerIpAddr} {
+		// only accept valid addresses, to prevent injecting arbitrary expressions into the filter
+		if ip := net.ParseIP(strings.TrimSpace(lt.Values[prop])); ip != nil {
+			parts = append(parts, "host "+ip.String())
+		}
+	}
+
+	// the port is validated as well, since it ends up in the filter expression
+	num, err := strconv.Atoi(strings.TrimSpace(lt.Values["port"]))
+	if err != nil || num < 0 || num > 65535 {
+		return "", fmt.Errorf("invalid port number: %q", lt.Values["port"])
+	}
+
+	port := "port " + strconv.Itoa(num)
+
+	switch proto := strings.ToLower(strings.TrimSpace(lt.Values["protocol"])); proto {
+	case "tcp", "udp", "sctp":
+		port = proto + " " + port
+	}
 
-	return b.String()
+	return strings.Join(append(parts, port), " and "), nil
 }
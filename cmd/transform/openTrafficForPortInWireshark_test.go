@@ -0,0 +1,69 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/maltego"
+
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+)
+
+func TestMakePortBPF(t *testing.T) {
+	tests := []struct {
+		values   map[string]string
+		expected string
+		valid    bool
+	}{
+		{map[string]string{"port": "443"}, "port 443", true},
+		{map[string]string{"port": " 53 ", "protocol": "UDP"}, "udp port 53", true},
+		{map[string]string{"port": "443", "protocol": "icmp"}, "port 443", true},
+		{
+			map[string]string{"port": "443", "protocol": "tcp", netmaltego.PropertyIpAddr: "127.0.0.1"},
+			"host 127.0.0.1 and tcp port 443",
+			true,
+		},
+		{
+			map[string]string{
+				"port":                        "443",
+				"protocol":                    "tcp",
+				netmaltego.PropertyIpAddr:     "127.0.0.1",
+				netmaltego.PropertyPeerIpAddr: "10.0.0.1",
+			},
+			"host 127.0.0.1 and host 10.0.0.1 and tcp port 443",
+			true,
+		},
+		// invalid addresses are ignored
+		{
+			map[string]string{"port": "22", netmaltego.PropertyIpAddr: "127.0.0.1 or host 10.0.0.2", netmaltego.PropertyPeerIpAddr: "::1"},
+			"host ::1 and port 22",
+			true,
+		},
+		{map[string]string{"port": "443 or udp"}, "", false},
+		{map[string]string{"port": "65536"}, "", false},
+		{map[string]string{}, "", false},
+	}
+
+	for _, test := range tests {
+		bpf, err := makePortBPF(maltego.LocalTransform{Values: test.values})
+		if (err == nil) != test.valid {
+			t.Errorf("%v: unexpected error: %v", test.values, err)
+		}
+
+		if bpf != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.values, test.expected, bpf)
+		}
+	}
+}
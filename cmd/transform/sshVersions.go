@@ -0,0 +1,220 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dreadl0ck/maltego"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
+)
+
+// sshVersion summarizes the SSH handshakes announcing the same version string.
+type sshVersion struct {
+	count   uint64
+	hasshs  []string
+	kex     []string
+	ciphers []string
+	flows   []string
+}
+
+// linkLabel returns the number of handshakes and the negotiated key exchange and cipher,
+// if only a single one has been seen.
+func (v *sshVersion) linkLabel() string {
+	label := strconv.FormatUint(v.count, 10)
+
+	if len(v.kex) == 1 {
+		label += "\n" + v.kex[0]
+	}
+
+	if len(v.ciphers) == 1 {
+		label += "\n" + v.ciphers[0]
+	}
+
+	return label
+}
+
+// sshAlgorithms splits the algorithm string of an SSH record
+// into the lists for key exchange, ciphers, macs and compression.
+func sshAlgorithms(algorithms string) [][]string {
+	var lists [][]string
+
+	for _, l := range strings.Split(algorithms, ";") {
+		lists = append(lists, strings.Split(l, ","))
+	}
+
+	return lists
+}
+
+// negotiate returns the first algorithm of the client list that is also supported by the server.
+func negotiate(client, server []string) string {
+	for _, c := range client {
+		for _, s := range server {
+			if c != "" && c == s {
+				return c
+			}
+		}
+	}
+
+	return ""
+}
+
+// sshVersionsTransform emits an entity for each distinct version string of the SSH clients or servers
+// that were seen for the selected host, or for all hosts if no address is set.
+// The key exchange and cipher are negotiated from the algorithms offered by both sides of a connection.
+func sshVersionsTransform(entityType string, clients bool) {
+	var (
+		records  []types.SSH
+		pathName string
+		ip       string
+	)
+
+	netmaltego.SSHTransform(
+		nil,
+		func(lt maltego.LocalTransform, trx *maltego.Transform, ssh *types.SSH, min, max uint64, path string, mac string, ipaddr string) {
+			if pathName == "" {
+				pathName = path
+				ip = ipaddr
+			}
+
+			records = append(records, *ssh)
+		},
+		true,
+	)
+
+	// the server records carry the reversed flow identifier, index both sides by the client flow
+	var (
+		clientRecords = make(map[string]*types.SSH)
+		serverRecords = make(map[string]*types.SSH)
+	)
+
+	for i := range records {
+		if records[i].IsClient {
+			clientRecords[records[i].Flow] = &records[i]
+		} else {
+			serverRecords[utils.ReverseFlowIdent(records[i].Flow)] = &records[i]
+		}
+	}
+
+	var (
+		versions = make(map[string]*sshVersion)
+		order    []string
+	)
+
+	for i := range records {
+		r := &records[i]
+		if r.IsClient != clients {
+			continue
+		}
+
+		// the server records flow starts at the server
+		hostIP, _, _, _ := utils.ParseFlowIdent(r.Flow)
+		if ip != "" && hostIP != ip {
+			continue
+		}
+
+		ident := r.Ident
+		if ident == "" {
+			ident = r.HASSH
+		}
+
+		if ident == "" {
+			continue
+		}
+
+		v, ok := versions[ident]
+		if !ok {
+			v = new(sshVersion)
+			versions[ident] = v
+			order = append(order, ident)
+		}
+
+		v.count++
+		v.hasshs = appendUniqueLimit(v.hasshs, r.HASSH, 0)
+		v.flows = appendUniqueLimit(v.flows, r.Flow, 0)
+
+		clientFlow := r.Flow
+		if !clients {
+			clientFlow = utils.ReverseFlowIdent(r.Flow)
+		}
+
+		var (
+			client = clientRecords[clientFlow]
+			server = serverRecords[clientFlow]
+		)
+
+		if client == nil || server == nil {
+			continue
+		}
+
+		var (
+			clientAlgs = sshAlgorithms(client.Algorithms)
+			serverAlgs = sshAlgorithms(server.Algorithms)
+		)
+
+		if len(clientAlgs) < 2 || len(serverAlgs) < 2 {
+			continue
+		}
+
+		v.kex = appendUniqueLimit(v.kex, negotiate(clientAlgs[0], serverAlgs[0]), 0)
+		v.ciphers = appendUniqueLimit(v.ciphers, negotiate(clientAlgs[1], serverAlgs[1]), 0)
+	}
+
+	var (
+		trx       = &maltego.Transform{}
+		thickness linkThickness
+	)
+
+	for _, v := range versions {
+		thickness.add(v.count)
+	}
+
+	for _, ident := range order {
+		v := versions[ident]
+
+		ent := addEntityWithPath(trx, entityType, ident, pathName)
+		ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Strict, ip)
+		ent.AddProperty("ident", "Ident", maltego.Strict, ident)
+		ent.AddProperty("hassh", "HASSH", maltego.Strict, strings.Join(v.hasshs, ","))
+		ent.AddProperty("kex", "Key Exchange", maltego.Strict, strings.Join(v.kex, ","))
+		ent.AddProperty("cipher", "Cipher", maltego.Strict, strings.Join(v.ciphers, ","))
+		ent.AddProperty("count", "Count", maltego.Strict, strconv.FormatUint(v.count, 10))
+
+		var di strings.Builder
+		di.WriteString("<h3>HASSH</h3>")
+		for _, h := range v.hasshs {
+			di.WriteString("<p>" + maltego.EscapeText(h) + "</p>")
+		}
+		if len(v.kex) > 0 || len(v.ciphers) > 0 {
+			di.WriteString("<h3>Negotiated</h3>")
+			di.WriteString("<p>Key Exchange: " + maltego.EscapeText(strings.Join(v.kex, ", ")) + "</p>")
+			di.WriteString("<p>Cipher: " + maltego.EscapeText(strings.Join(v.ciphers, ", ")) + "</p>")
+		}
+		di.WriteString("<h3>Flows</h3>")
+		for _, f := range v.flows {
+			di.WriteString("<p>" + maltego.EscapeText(f) + "</p>")
+		}
+		ent.AddDisplayInformation(di.String(), "Netcap Info")
+
+		ent.SetLinkLabel(v.linkLabel())
+		ent.SetLinkThickness(thickness.get(v.count))
+	}
+
+	trx.AddUIMessage("completed!", maltego.UIMessageInform)
+	fmt.Println(trx.ReturnOutput())
+}
@@ -47,5 +47,5 @@ func toConnectionsForHost() {
 		if conn.SrcIP == ip || conn.DstIP == ip {
 			addConnection(trx, conn, path, min, max, maltego.InputToOutput)
 		}
-	})
+	}, false)
 }
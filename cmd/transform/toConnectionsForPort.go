@@ -55,5 +55,5 @@ func toConnectionsForPort() {
 		if conn.SrcPort == port || conn.DstPort == port {
 			addConnection(trx, conn, path, min, max, maltego.InputToOutput)
 		}
-	})
+	}, false)
 }
@@ -15,6 +15,7 @@ package transform
 
 import (
 	"bufio"
+	"fmt"
 	"html"
 	"io"
 	"log"
@@ -29,6 +30,9 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/dreadl0ck/maltego"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/env"
 	netmaltego "github.com/dreadl0ck/netcap/maltego"
 	"github.com/dreadl0ck/netcap/resolvers"
 	"github.com/dreadl0ck/netcap/types"
@@ -76,7 +80,7 @@ func toConnectionsForService() {
 			service := resolvers.LookupServiceByPort(port, strings.ToLower(conn.TransportProto))
 			addConn(trx, conn, path, min, max, maltego.InputToOutput, service)
 		}
-	})
+	}, false)
 }
 
 func addConn(trx *maltego.Transform, conn *types.Connection, path string, min, max uint64, direction maltego.LinkDirection, service string) {
@@ -94,7 +98,8 @@ func addConn(trx *maltego.Transform, conn *types.Connection, path string, min, m
 	ent.AddProperty("totalsize", "TotalSize", maltego.Strict, strconv.Itoa(int(conn.TotalSize)))
 	ent.AddProperty("apppayloadsize", "AppPayloadSize", maltego.Strict, strconv.Itoa(int(conn.AppPayloadSize)))
 
-	ent.AddDisplayInformation(makeConversationHTML(service, conn, path), "Conversation: Client (Red), Server (Blue)")
+	clientStyle, serverStyle := conversationStyles()
+	ent.AddDisplayInformation(makeConversationHTML(service, conn, path), "Conversation: Client ("+clientStyle+"), Server ("+serverStyle+")")
 }
 
 func addConnection(trx *maltego.Transform, conn *types.Connection, path string, min, max uint64, direction maltego.LinkDirection) {
@@ -162,9 +167,97 @@ retry:
 	}
 
 	str := strings.ReplaceAll(html.EscapeString(strings.TrimSpace(string(buf))), "\n", "<br>")
-	str = strings.ReplaceAll(str, ansi.Red, "<p style='color: red;'>")
-	str = strings.ReplaceAll(str, ansi.Blue, "<p style='color: dodgerblue;'>")
-	str = strings.ReplaceAll(str, ansi.Reset, "</p>")
 
-	return maltego.EscapeText(str)
+	return maltego.EscapeText(conversationColorsToHTML(str))
+}
+
+// conversationStyles returns the styles of the client and server data in saved conversations.
+// They default to the colors used by the capture tool and can be changed in the environment,
+// to match the colors that have been configured for the capture.
+func conversationStyles() (client, server string) {
+	client, server = "red", "blue"
+
+	if style := os.Getenv(env.MaltegoConnsClientColor); style != "" {
+		client = style
+	}
+
+	if style := os.Getenv(env.MaltegoConnsServerColor); style != "" {
+		server = style
+	}
+
+	return client, server
+}
+
+// conversationColorsToHTML replaces the color codes of a saved conversation with paragraphs in the corresponding html colors.
+func conversationColorsToHTML(str string) string {
+	clientStyle, serverStyle := conversationStyles()
+
+	client, server, reset := streamutils.ConversationColors(&decoderconfig.Config{
+		ConnsClientColor: clientStyle,
+		ConnsServerColor: serverStyle,
+	})
+
+	// an empty color code would match at every position
+	var replacements []string
+	for _, r := range [][2]string{
+		{client, "<p style='color: " + htmlColor(clientStyle) + ";'>"},
+		{server, "<p style='color: " + htmlColor(serverStyle) + ";'>"},
+		{reset, "</p>"},
+	} {
+		if r[0] != "" {
+			replacements = append(replacements, r[0], r[1])
+		}
+	}
+
+	return strings.NewReplacer(replacements...).Replace(str)
+}
+
+// xtermColors are the css colors for the first 16 codes of the 256 color palette.
+var xtermColors = [16]string{
+	"black", "maroon", "green", "olive", "navy", "purple", "teal", "silver",
+	"gray", "red", "lime", "yellow", "blue", "fuchsia", "aqua", "white",
+}
+
+// htmlColor returns the css color for the foreground of an ansi style, e.g. red, green+b or 208.
+// Unknown styles are displayed in the default text color.
+func htmlColor(style string) string {
+	fg := strings.SplitN(strings.SplitN(style, ":", 2)[0], "+", 2)[0]
+
+	if code, err := strconv.Atoi(fg); err == nil && code >= 0 && code < 256 {
+		switch {
+		case code < 16:
+			return xtermColors[code]
+		case code < 232:
+			// 6x6x6 color cube
+			level := func(v int) int {
+				if v == 0 {
+					return 0
+				}
+
+				return 55 + v*40
+			}
+
+			code -= 16
+
+			return fmt.Sprintf("#%02x%02x%02x", level(code/36), level(code/6%6), level(code%6))
+		default:
+			gray := 8 + (code-232)*10
+
+			return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+		}
+	}
+
+	switch fg {
+	case "blue":
+		// plain blue is hard to read on the dark background of the detail view
+		return "dodgerblue"
+	case "default":
+		return "inherit"
+	}
+
+	if _, ok := ansi.Colors[fg]; ok {
+		return fg
+	}
+
+	return "inherit"
 }
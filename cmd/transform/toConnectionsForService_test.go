@@ -0,0 +1,50 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mgutz/ansi"
+
+	"github.com/dreadl0ck/netcap/env"
+)
+
+func TestConversationColorsToHTML(t *testing.T) {
+	conversation := ansi.Red + "ping" + ansi.Reset + ansi.Blue + "pong" + ansi.Reset
+
+	expected := "<p style='color: red;'>ping</p><p style='color: dodgerblue;'>pong</p>"
+	if html := conversationColorsToHTML(conversation); html != expected {
+		t.Fatalf("expected %q, got %q", expected, html)
+	}
+
+	for k, v := range map[string]string{
+		env.MaltegoConnsClientColor: "yellow+b",
+		env.MaltegoConnsServerColor: "208",
+	} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+
+		defer os.Unsetenv(k)
+	}
+
+	conversation = ansi.ColorCode("yellow+b") + "ping" + ansi.Reset + ansi.ColorCode("208") + "pong" + ansi.Reset
+
+	expected = "<p style='color: yellow;'>ping</p><p style='color: #ff8700;'>pong</p>"
+	if html := conversationColorsToHTML(conversation); html != expected {
+		t.Fatalf("expected %q, got %q", expected, html)
+	}
+}
@@ -51,14 +51,15 @@ func toContactedPorts() {
 			if profile.Addr != ipaddr {
 				return
 			}
+			peer := peerAddr(lt)
 			for _, port := range profile.ContactedPorts {
-				addContactedPort(trx, strconv.FormatInt(int64(port.PortNumber), 10), port, min, max, profile, path)
+				addContactedPort(trx, strconv.FormatInt(int64(port.PortNumber), 10), port, min, max, profile, path, peer)
 			}
 		},
 	)
 }
 
-func addContactedPort(trx *maltego.Transform, portStr string, port *types.Port, min uint64, max uint64, ip *types.IPProfile, path string) {
+func addContactedPort(trx *maltego.Transform, portStr string, port *types.Port, min uint64, max uint64, ip *types.IPProfile, path string, peer string) {
 	np, err := strconv.Atoi(portStr)
 	if err != nil {
 		fmt.Println(err)
@@ -75,6 +76,9 @@ func addContactedPort(trx *maltego.Transform, portStr string, port *types.Port,
 	ent.AddDisplayInformation(di, "Netcap Info")
 	ent.AddProperty("label", "Label", maltego.Strict, portStr+"\n"+serviceName)
 	ent.AddProperty("port", "Port", maltego.Strict, portStr)
+	ent.AddProperty("protocol", "Protocol", maltego.Loose, port.Protocol)
+	ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Loose, ip.Addr)
+	addPeerAddr(ent, peer)
 	ent.SetLinkLabel(strconv.FormatInt(int64(port.Stats.Packets), 10) + " pkts")
 	ent.SetLinkThickness(maltego.GetThickness(port.Stats.Packets, min, max))
 }
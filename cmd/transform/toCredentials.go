@@ -0,0 +1,90 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"strings"
+
+	"github.com/dreadl0ck/maltego"
+
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
+)
+
+// toCredentials emits the credentials that have been used by or presented to the selected host.
+// Passwords are masked, the full credentials can be retrieved with the ToLoginInformation transform.
+func toCredentials() {
+	netmaltego.CredentialsTransform(
+		nil,
+		func(lt maltego.LocalTransform, trx *maltego.Transform, cred *types.Credentials, min, max uint64, path string, mac string, ipaddr string) {
+			srcIP, dstIP := flowAddrs(cred.Flow)
+			if srcIP != ipaddr && dstIP != ipaddr {
+				return
+			}
+
+			var (
+				password  = maskPassword(cred.Password)
+				timestamp = utils.UnixTimeToUTC(cred.Timestamp)
+			)
+
+			ent := addEntityWithPath(trx, "netcap.Credentials", cred.User+"\n"+password+"\n"+cred.Service, path)
+			ent.AddProperty("service", "Service", maltego.Strict, cred.Service)
+			ent.AddProperty("user", "User", maltego.Strict, cred.User)
+			ent.AddProperty("password", "Password", maltego.Strict, password)
+			ent.AddProperty("srcip", "SrcIP", maltego.Strict, srcIP)
+			ent.AddProperty("dstip", "DstIP", maltego.Strict, dstIP)
+			ent.AddProperty("timestamp", "Timestamp", maltego.Strict, timestamp)
+
+			di := "<h3>Credentials</h3><p>Protocol: " + cred.Service + "</p><p>Timestamp: " + timestamp + "</p><p>User: " + cred.User + "</p><p>Password: " + password + "</p><p>Flow: " + cred.Flow + "</p><p>Notes: " + cred.Notes + "</p>"
+			ent.AddDisplayInformation(di, "Netcap Info")
+
+			ent.SetLinkLabel(cred.Service)
+		},
+	)
+}
+
+// flowAddrs returns the source and destination address of a flow identifier.
+// The port is split off at the last colon, to support IPv6 addresses as well.
+func flowAddrs(flow string) (srcIP, dstIP string) {
+	arr := strings.Split(flow, "->")
+	if len(arr) != 2 {
+		return "", ""
+	}
+
+	host := func(addr string) string {
+		if i := strings.LastIndex(addr, ":"); i != -1 {
+			return strings.Trim(addr[:i], "[]")
+		}
+
+		return addr
+	}
+
+	return host(arr[0]), host(arr[1])
+}
+
+// maskPassword hides all but the first and the last character of a password,
+// short passwords are masked completely.
+func maskPassword(password string) string {
+	if password == "" {
+		return ""
+	}
+
+	runes := []rune(password)
+	if len(runes) < 6 {
+		return strings.Repeat("*", len(runes))
+	}
+
+	return string(runes[0]) + strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-1])
+}
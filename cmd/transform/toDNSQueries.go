@@ -0,0 +1,107 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dreadl0ck/gopacket/layers"
+	"github.com/dreadl0ck/maltego"
+
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// dnsQuery summarizes the lookups of a single name.
+type dnsQuery struct {
+	queries uint64
+	answers uint64
+	types   []string
+}
+
+// toDNSQueries emits the names queried by the selected host,
+// with the query types and the number of answers the host received as properties.
+func toDNSQueries() {
+	var (
+		names    = make(map[string]*dnsQuery)
+		order    []string
+		pathName string
+	)
+
+	lookup := func(name string) *dnsQuery {
+		q, ok := names[name]
+		if !ok {
+			q = new(dnsQuery)
+			names[name] = q
+			order = append(order, name)
+		}
+
+		return q
+	}
+
+	netmaltego.DNSTransform(
+		nil,
+		func(lt maltego.LocalTransform, trx *maltego.Transform, d *types.DNS, min, max uint64, path string, ipaddr string) {
+			if pathName == "" {
+				pathName = path
+			}
+
+			// queries are sent by the host, responses are sent to it
+			if (!d.QR && d.SrcIP != ipaddr) || (d.QR && d.DstIP != ipaddr) {
+				return
+			}
+
+			for _, question := range d.Questions {
+				if question.Name == "" {
+					continue
+				}
+
+				q := lookup(question.Name)
+				q.types = appendUniqueLimit(q.types, layers.DNSType(question.Type).String(), 0)
+
+				if d.QR {
+					q.answers += uint64(d.ANCount)
+				} else {
+					q.queries++
+				}
+			}
+		},
+		true,
+	)
+
+	var (
+		trx       = &maltego.Transform{}
+		thickness linkThickness
+	)
+
+	for _, q := range names {
+		thickness.add(q.queries)
+	}
+
+	for _, name := range order {
+		q := names[name]
+
+		ent := addEntityWithPath(trx, "netcap.Domain", name, pathName)
+		ent.AddProperty("types", "Query Types", maltego.Strict, strings.Join(q.types, ", "))
+		ent.AddProperty("answers", "Answers", maltego.Strict, strconv.FormatUint(q.answers, 10))
+
+		ent.SetLinkLabel(strconv.FormatUint(q.queries, 10) + " queries")
+		ent.SetLinkThickness(thickness.get(q.queries))
+	}
+
+	trx.AddUIMessage("completed!", maltego.UIMessageInform)
+	fmt.Println(trx.ReturnOutput())
+}
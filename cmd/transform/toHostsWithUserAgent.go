@@ -0,0 +1,96 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dreadl0ck/maltego"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// toHostsWithUserAgent emits all hosts that presented exactly the selected user agent.
+func toHostsWithUserAgent() {
+	hostsWithUserAgent(false)
+}
+
+// toHostsWithUserAgentSubstring emits all hosts that presented a user agent containing the selected value.
+func toHostsWithUserAgentSubstring() {
+	hostsWithUserAgent(true)
+}
+
+// hostsWithUserAgent pivots from a user agent to the source addresses of all HTTP requests that presented it,
+// the links are labeled with the number of requests.
+func hostsWithUserAgent(substring bool) {
+	var (
+		requests = make(map[string]uint64)
+		order    []string
+		pathName string
+		ua       string
+	)
+
+	netmaltego.HTTPTransform(
+		nil,
+		func(lt maltego.LocalTransform, trx *maltego.Transform, http *types.HTTP, min, max uint64, path string, ipaddr string) {
+			if pathName == "" {
+				pathName = path
+				ua = lt.Value
+			}
+
+			// audit records created by older versions have the commas in user agents replaced
+			agent := strings.ReplaceAll(http.UserAgent, "(comma)", ",")
+
+			if agent == "" || ua == "" {
+				return
+			}
+
+			if agent != ua && (!substring || !strings.Contains(agent, ua)) {
+				return
+			}
+
+			if _, ok := requests[http.SrcIP]; !ok {
+				order = append(order, http.SrcIP)
+			}
+
+			requests[http.SrcIP]++
+		},
+		true,
+	)
+
+	var (
+		trx       = &maltego.Transform{}
+		thickness linkThickness
+	)
+
+	for _, n := range requests {
+		thickness.add(n)
+	}
+
+	for _, ip := range order {
+		n := requests[ip]
+
+		ent := addEntityWithPath(trx, "netcap.IPAddr", ip, pathName)
+		ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Strict, ip)
+		ent.AddProperty("requests", "Requests", maltego.Strict, strconv.FormatUint(n, 10))
+
+		ent.SetLinkLabel(strconv.FormatUint(n, 10) + " requests")
+		ent.SetLinkThickness(thickness.get(n))
+	}
+
+	trx.AddUIMessage("completed!", maltego.UIMessageInform)
+	fmt.Println(trx.ReturnOutput())
+}
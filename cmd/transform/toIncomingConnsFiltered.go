@@ -62,6 +62,7 @@ func toIncomingConnsFiltered() {
 				}
 			}
 		},
+		false,
 	)
 }
 
@@ -0,0 +1,237 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/dreadl0ck/maltego"
+	"github.com/dreadl0ck/netcap/env"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"github.com/dreadl0ck/netcap/resolvers"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// commEdge aggregates all connections between the selected host and an internal peer.
+type commEdge struct {
+	peer     string
+	bytes    uint64
+	conns    int
+	outgoing bool
+	incoming bool
+	services map[string]struct{}
+}
+
+// toInternalCommGraph emits the internal hosts that the selected internal host communicated with.
+// Repeated connections are aggregated into a single weighted edge, to reveal east-west traffic that is used for lateral movement.
+func toInternalCommGraph() {
+	resolverLog := zap.New(zapcore.NewNopCore())
+	defer func() {
+		err := resolverLog.Sync()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	resolvers.SetLogger(resolverLog)
+
+	stdOut := os.Stdout
+	os.Stdout = os.Stderr
+	resolvers.InitServiceDB()
+	os.Stdout = stdOut
+
+	homeNets, err := parseHomeNetworks(os.Getenv(env.MaltegoHomeNetworks))
+	if err != nil {
+		maltego.Die(err.Error(), "invalid value for "+env.MaltegoHomeNetworks)
+	}
+
+	var (
+		edges    = make(map[string]*commEdge)
+		pathName string
+		selected string
+		internal bool
+	)
+
+	netmaltego.ConnectionTransform(
+		nil,
+		func(lt maltego.LocalTransform, trx *maltego.Transform, conn *types.Connection, min, max uint64, path string, mac string, ipaddr string, top12 *[]int) {
+			if pathName == "" {
+				pathName = path
+				selected = ipaddr
+				internal = isInternal(ipaddr, homeNets)
+			}
+
+			if !internal {
+				return
+			}
+
+			var peer string
+
+			switch ipaddr {
+			case conn.SrcIP:
+				peer = conn.DstIP
+			case conn.DstIP:
+				peer = conn.SrcIP
+			default:
+				return
+			}
+
+			if peer == ipaddr || !isInternal(peer, homeNets) {
+				return
+			}
+
+			e, ok := edges[peer]
+			if !ok {
+				e = &commEdge{
+					peer:     peer,
+					services: make(map[string]struct{}),
+				}
+				edges[peer] = e
+			}
+
+			e.bytes += uint64(conn.TotalSize)
+			e.conns++
+
+			if conn.SrcIP == ipaddr {
+				e.outgoing = true
+			} else {
+				e.incoming = true
+			}
+
+			e.services[connService(conn)] = struct{}{}
+		},
+		true,
+	)
+
+	trx := &maltego.Transform{}
+
+	if !internal {
+		trx.AddUIMessage("selected host does not belong to the home network", maltego.UIMessageInform)
+		fmt.Println(trx.ReturnOutput())
+
+		return
+	}
+
+	var thickness linkThickness
+
+	for _, e := range edges {
+		thickness.add(e.bytes)
+	}
+
+	for _, e := range edges {
+		var (
+			ent      *maltego.Entity
+			services = make([]string, 0, len(e.services))
+		)
+
+		for s := range e.services {
+			services = append(services, s)
+		}
+
+		sort.Strings(services)
+
+		if e.outgoing {
+			ent = addEntityWithPath(trx, "netcap.InternalDestinationIP", e.peer, pathName)
+		} else {
+			ent = addEntityWithPath(trx, "netcap.InternalSourceIP", e.peer, pathName)
+		}
+
+		// links without a direction are displayed for peers that initiated connections in both directions
+		switch {
+		case e.outgoing && !e.incoming:
+			ent.SetLinkDirection(maltego.InputToOutput)
+		case e.incoming && !e.outgoing:
+			ent.SetLinkDirection(maltego.OutputToInput)
+		}
+
+		ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Strict, e.peer)
+		// the selected host is the peer of the emitted address, to narrow down the port transforms to the conversation
+		addPeerAddr(ent, selected)
+		ent.AddProperty("services", "Services", maltego.Strict, strings.Join(services, ", "))
+		ent.AddProperty("connections", "Connections", maltego.Strict, strconv.Itoa(e.conns))
+		ent.AddProperty("bytes", "Bytes", maltego.Strict, strconv.FormatUint(e.bytes, 10))
+
+		ent.SetLinkLabel(humanize.Bytes(e.bytes) + "\n" + strconv.Itoa(e.conns) + " conns")
+		ent.SetLinkThickness(thickness.get(e.bytes))
+	}
+
+	trx.AddUIMessage("completed!", maltego.UIMessageInform)
+	fmt.Println(trx.ReturnOutput())
+}
+
+// connService returns the destination port and transport protocol of the connection,
+// followed by the IANA service name if known, e.g. 445/tcp (microsoft-ds).
+func connService(conn *types.Connection) string {
+	proto := strings.ToLower(conn.TransportProto)
+	s := conn.DstPort + "/" + proto
+
+	if port, err := strconv.Atoi(conn.DstPort); err == nil {
+		if name := resolvers.LookupServiceByPort(port, proto); name != "" {
+			s += " (" + name + ")"
+		}
+	}
+
+	return s
+}
+
+// parseHomeNetworks parses a comma separated list of CIDRs.
+func parseHomeNetworks(val string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+
+	for _, cidr := range strings.Split(val, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, n)
+	}
+
+	return networks, nil
+}
+
+// isInternal checks whether the address belongs to one of the home networks,
+// or to the private address space if no home networks have been configured.
+func isInternal(addr string, homeNets []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	if len(homeNets) == 0 {
+		return resolvers.IsPrivateIP(ip)
+	}
+
+	for _, n := range homeNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
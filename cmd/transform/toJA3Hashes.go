@@ -14,22 +14,139 @@
 package transform
 
 import (
-	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"fmt"
+	"log"
+	"os"
 	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/dreadl0ck/maltego"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"github.com/dreadl0ck/netcap/resolvers"
 	"github.com/dreadl0ck/netcap/types"
-	"github.com/dreadl0ck/netcap/utils"
 )
 
+// ja3Hash summarizes the client hellos with the same ja3 hash.
+type ja3Hash struct {
+	count uint64
+	snis  []string
+}
+
+// linkLabel returns the number of client hellos and the first server name,
+// followed by the number of other server names.
+func (j *ja3Hash) linkLabel() string {
+	label := strconv.FormatUint(j.count, 10)
+
+	switch len(j.snis) {
+	case 0:
+	case 1:
+		label += "\n" + j.snis[0]
+	default:
+		label += "\n" + j.snis[0] + " (+" + strconv.Itoa(len(j.snis)-1) + ")"
+	}
+
+	return label
+}
+
+// toJA3Hashes emits the distinct ja3 hashes of the client hellos sent by the selected host,
+// annotated with the client resolved from the ja3 database and whether the hash belongs to known malware.
+// If no host has been selected, the client hellos of all hosts are summarized.
 func toJA3Hashes() {
+	resolverLog := zap.New(zapcore.NewNopCore())
+	defer func() {
+		err := resolverLog.Sync()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	resolvers.SetLogger(resolverLog)
+
+	stdOut := os.Stdout
+	os.Stdout = os.Stderr
+	resolvers.InitJa3Resolver()
+	resolvers.InitJa3MalwareDB()
+	os.Stdout = stdOut
+
+	var (
+		hashes   = make(map[string]*ja3Hash)
+		order    []string
+		pathName string
+		ip       string
+	)
+
 	netmaltego.TLSClientHelloTransform(
 		nil,
 		func(lt maltego.LocalTransform, trx *maltego.Transform, hello *types.TLSClientHello, min, max uint64, path string, ipaddr string) {
-			ent := addEntityWithPath(trx, "netcap.TLSClientHello", hello.Ja3, path)
-			ent.AddProperty("ip", "IP", maltego.Strict, hello.SrcIP)
-			ent.AddProperty("port", "Port", maltego.Strict, strconv.Itoa(int(hello.SrcPort)))
-			ent.AddDisplayInformation(utils.CreateFlowIdent(hello.SrcIP, strconv.Itoa(int(hello.SrcPort)), hello.DstIP, strconv.Itoa(int(hello.DstPort)))+"<br>", "Flows")
+			if pathName == "" {
+				pathName = path
+				ip = ipaddr
+			}
+
+			if hello.Ja3 == "" || (ipaddr != "" && hello.SrcIP != ipaddr) {
+				return
+			}
+
+			h, ok := hashes[hello.Ja3]
+			if !ok {
+				h = new(ja3Hash)
+				hashes[hello.Ja3] = h
+				order = append(order, hello.Ja3)
+			}
+
+			h.count++
+			h.snis = appendUniqueLimit(h.snis, hello.SNI, 0)
 		},
+		true,
 	)
+
+	var (
+		trx       = &maltego.Transform{}
+		thickness linkThickness
+	)
+
+	for _, h := range hashes {
+		thickness.add(h.count)
+	}
+
+	for _, hash := range order {
+		var (
+			h              = hashes[hash]
+			client         = resolvers.LookupJa3(hash)
+			malware, isBad = resolvers.LookupJa3Malware(hash)
+		)
+
+		ent := addEntityWithPath(trx, "netcap.JA3", hash, pathName)
+		ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Strict, ip)
+		ent.AddProperty("client", "Client", maltego.Strict, client)
+		ent.AddProperty("malware", "Malware", maltego.Strict, strconv.FormatBool(isBad))
+		ent.AddProperty("count", "Count", maltego.Strict, strconv.FormatUint(h.count, 10))
+
+		var di strings.Builder
+		if client != "" {
+			di.WriteString("<h3>Client</h3><p>" + maltego.EscapeText(client) + "</p>")
+		}
+		if isBad {
+			di.WriteString("<h3>Known Malware</h3><p>" + maltego.EscapeText(malware) + "</p>")
+		}
+		di.WriteString("<h3>Server Names</h3>")
+		for _, sni := range h.snis {
+			di.WriteString("<p>" + maltego.EscapeText(sni) + "</p>")
+		}
+		ent.AddDisplayInformation(di.String(), "Netcap Info")
+
+		if isBad {
+			ent.SetLinkColor("#ff0000")
+			ent.SetBookmark(maltego.BookMarkColorRed)
+		}
+
+		ent.SetLinkLabel(h.linkLabel())
+		ent.SetLinkThickness(thickness.get(h.count))
+	}
+
+	trx.AddUIMessage("completed!", maltego.UIMessageInform)
+	fmt.Println(trx.ReturnOutput())
 }
@@ -51,5 +51,6 @@ func toMD5HashesForFileName() {
 
 			}
 		},
+		false,
 	)
 }
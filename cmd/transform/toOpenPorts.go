@@ -0,0 +1,172 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/dreadl0ck/maltego"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
+	"github.com/dreadl0ck/netcap/resolvers"
+	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
+)
+
+// ports from this number on are considered ephemeral client ports,
+// unless a service is registered for them.
+// this covers the default ephemeral range of linux, windows and the IANA recommendation.
+const ephemeralPortStart = 32768
+
+// openPort is a port the host was seen serving on, with the traffic received on it and sent from it.
+type openPort struct {
+	number   int32
+	protocol string
+	service  string
+	packets  uint64
+	bytes    uint64
+}
+
+func toOpenPorts() {
+	resolverLog := zap.New(zapcore.NewNopCore())
+	defer func() {
+		err := resolverLog.Sync()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	resolvers.SetLogger(resolverLog)
+
+	stdOut := os.Stdout
+	os.Stdout = os.Stderr
+	resolvers.InitServiceDB()
+	os.Stdout = stdOut
+
+	netmaltego.IPProfileTransform(
+		nil,
+		func(lt maltego.LocalTransform, trx *maltego.Transform, profile *types.IPProfile, _, _ uint64, path string, mac string, ipaddr string) {
+			if profile.Addr != ipaddr {
+				return
+			}
+
+			ports := openPorts(profile)
+			if len(ports) == 0 {
+				return
+			}
+
+			var (
+				min = ports[0].packets
+				max = ports[0].packets
+			)
+
+			for _, p := range ports {
+				if p.packets < min {
+					min = p.packets
+				}
+
+				if p.packets > max {
+					max = p.packets
+				}
+			}
+
+			peer := peerAddr(lt)
+			for _, p := range ports {
+				addOpenPort(trx, p, min, max, profile, path, peer)
+			}
+		},
+	)
+}
+
+// openPorts returns the ports the host has been serving on, sorted by protocol and number.
+// A port is considered open if the host received packets on it and also answered from it.
+// Ports in the ephemeral range are ignored if no service is known for them,
+// since they are usually the client side of connections initiated by the host.
+func openPorts(profile *types.IPProfile) []*openPort {
+	type key struct {
+		number   int32
+		protocol string
+	}
+
+	sent := make(map[key]*types.PortStats, len(profile.SrcPorts))
+	for _, p := range profile.SrcPorts {
+		if p.Stats != nil {
+			sent[key{p.PortNumber, p.Protocol}] = p.Stats
+		}
+	}
+
+	var ports []*openPort
+
+	for _, p := range profile.DstPorts {
+		out, ok := sent[key{p.PortNumber, p.Protocol}]
+		if !ok || p.Stats == nil {
+			continue
+		}
+
+		service := resolvers.LookupServiceByPort(int(p.PortNumber), p.Protocol)
+		if p.PortNumber >= ephemeralPortStart && service == "" {
+			continue
+		}
+
+		ports = append(ports, &openPort{
+			number:   p.PortNumber,
+			protocol: p.Protocol,
+			service:  service,
+			packets:  p.Stats.Packets + out.Packets,
+			bytes:    p.Stats.Bytes + out.Bytes,
+		})
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].protocol != ports[j].protocol {
+			return ports[i].protocol < ports[j].protocol
+		}
+
+		return ports[i].number < ports[j].number
+	})
+
+	return ports
+}
+
+func addOpenPort(trx *maltego.Transform, p *openPort, min, max uint64, ip *types.IPProfile, path, peer string) {
+	var (
+		portStr = strconv.FormatInt(int64(p.number), 10)
+		val     = portStr + "/" + p.protocol
+		di      = utils.UnixTimeToUTC(ip.TimestampFirst) + " " + ip.Addr + "<br>"
+	)
+
+	if p.service != "" {
+		val += "\n" + p.service
+	}
+
+	ent := addEntityWithPath(trx, "netcap.Port", val, path)
+
+	ent.AddDisplayInformation(di, "Netcap Info")
+	ent.AddProperty("label", "Label", maltego.Strict, val)
+	ent.AddProperty("port", "Port", maltego.Strict, portStr)
+	ent.AddProperty("protocol", "Protocol", maltego.Loose, p.protocol)
+	ent.AddProperty("service", "Service", maltego.Loose, p.service)
+	ent.AddProperty("packets", "Packets", maltego.Loose, strconv.FormatUint(p.packets, 10))
+	ent.AddProperty("bytes", "Bytes", maltego.Loose, strconv.FormatUint(p.bytes, 10))
+	ent.AddProperty(netmaltego.PropertyIpAddr, netmaltego.PropertyIpAddrLabel, maltego.Loose, ip.Addr)
+	addPeerAddr(ent, peer)
+	ent.SetLinkLabel(p.protocol + "\n" + strconv.FormatUint(p.packets, 10) + " pkts\n" + humanize.Bytes(p.bytes))
+	ent.SetLinkThickness(maltego.GetThickness(p.packets, min, max))
+}
@@ -62,5 +62,6 @@ func toOutgoingConnsFiltered() {
 				}
 			}
 		},
+		false,
 	)
 }
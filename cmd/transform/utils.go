@@ -19,6 +19,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/dreadl0ck/maltego"
 	"github.com/dreadl0ck/netcap/defaults"
+	netmaltego "github.com/dreadl0ck/netcap/maltego"
 	"github.com/dreadl0ck/netcap/types"
 )
 
@@ -247,3 +249,64 @@ func addEntityWithPath(tr *maltego.Transform, enType, value, path string) *malte
 
 	return ent
 }
+
+// peerAddr returns the address of the remote host carried by the input entity,
+// or an empty string if it is missing or not a valid IP address.
+func peerAddr(lt maltego.LocalTransform) string {
+	ip := net.ParseIP(strings.TrimSpace(lt.Values[netmaltego.PropertyPeerIpAddr]))
+	if ip == nil {
+		return ""
+	}
+
+	return ip.String()
+}
+
+// addPeerAddr sets the address of the remote host on the entity, if it is known.
+func addPeerAddr(ent *maltego.Entity, peer string) {
+	if peer == "" {
+		return
+	}
+
+	ent.AddProperty(netmaltego.PropertyPeerIpAddr, netmaltego.PropertyPeerIpAddrLabel, maltego.Loose, peer)
+}
+
+// appendUniqueLimit adds val to the list, unless it is empty, already contained
+// or the list holds limit values already. A limit of zero does not restrict the length of the list.
+func appendUniqueLimit(list []string, val string, limit int) []string {
+	if val == "" || (limit > 0 && len(list) >= limit) {
+		return list
+	}
+
+	for _, v := range list {
+		if v == val {
+			return list
+		}
+	}
+
+	return append(list, val)
+}
+
+// linkThickness tracks the range of the counts that determine the link thickness of the emitted entities.
+type linkThickness struct {
+	min  uint64
+	max  uint64
+	seen bool
+}
+
+// add extends the range with the count.
+func (l *linkThickness) add(count uint64) {
+	if !l.seen || count < l.min {
+		l.min = count
+	}
+
+	if count > l.max {
+		l.max = count
+	}
+
+	l.seen = true
+}
+
+// get returns the link thickness for the count, relative to the range of all added counts.
+func (l *linkThickness) get(count uint64) int {
+	return maltego.GetThickness(count, l.min, l.max)
+}
@@ -0,0 +1,57 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package transform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppendUniqueLimit(t *testing.T) {
+	var list []string
+
+	for _, v := range []string{"a", "", "b", "a", "c", "d"} {
+		list = appendUniqueLimit(list, v, 3)
+	}
+
+	if !reflect.DeepEqual(list, []string{"a", "b", "c"}) {
+		t.Fatal("unexpected list", list)
+	}
+
+	list = nil
+	for _, v := range []string{"a", "b", "a", "c", "d"} {
+		list = appendUniqueLimit(list, v, 0)
+	}
+
+	if !reflect.DeepEqual(list, []string{"a", "b", "c", "d"}) {
+		t.Fatal("unexpected unlimited list", list)
+	}
+}
+
+func TestLinkThickness(t *testing.T) {
+	var thickness linkThickness
+
+	// the range starts with the first count, so counts of any size are tracked
+	for _, c := range []uint64{20000000, 50, 1, 100} {
+		thickness.add(c)
+	}
+
+	if thickness.min != 1 || thickness.max != 20000000 {
+		t.Fatal("unexpected range", thickness.min, thickness.max)
+	}
+
+	if thickness.get(1) >= thickness.get(20000000) {
+		t.Fatal("expected the largest count to get the thickest link")
+	}
+}
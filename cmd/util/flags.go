@@ -16,6 +16,7 @@ package util
 import (
 	"github.com/dreadl0ck/netcap/env"
 	"os"
+	"time"
 
 	"github.com/namsral/flag"
 
@@ -52,4 +53,11 @@ var (
 	flagForce           = fs.Bool("force", false, "disable prompts for user interaction")
 	flagVerbose         = fs.Bool("verbose", false, "enable verbose output")
 	flagDownloadGeolite = fs.Bool("download-geolite", false, "download geolite DB, requires API key in environment: "+env.GeoLiteAPIKey)
+
+	flagReplay        = fs.String("replay", "", "replay the client data of a TCP conversation saved with net capture -conns to the target")
+	flagReplayTarget  = fs.String("target", "", "host:port of the target for the replay, must be set explicitly")
+	flagDryRun        = fs.Bool("dry-run", false, "print the data that would be replayed without connecting to the target")
+	flagReplayTimeout = fs.Duration("replay-timeout", 2*time.Second, "timeout for connecting to the target and waiting for its responses during the replay")
+	flagClientColor   = fs.String("client-color", "red", "color of the client data in the replayed conversation, must match the -conns-client-color used for the capture")
+	flagServerColor   = fs.String("server-color", "blue", "color of the server data in the replayed conversation, must match the -conns-server-color used for the capture")
 )
@@ -103,6 +103,11 @@ func Run() {
 		return
 	}
 
+	if *flagReplay != "" {
+		replayConversation()
+		return
+	}
+
 	if *flagIndex != "" {
 		dbs.IndexData(*flagIndex, resolvers.DataBaseFolderPath, resolvers.DataBaseBuildPath, *flagNVDIndexStart, *flagVerbose)
 		return
@@ -0,0 +1,144 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package util
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/mgutz/ansi"
+
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+)
+
+// errReplayTargetMissing occurs when a conversation shall be replayed without an explicit target.
+var errReplayTargetMissing = errors.New("no replay target specified, set it explicitly with -target host:port")
+
+// replayConversation reads a TCP conversation that has been saved with the -conns flag of net capture,
+// establishes a new TCP connection to the target and sends the data of the client in the original order.
+// After each client segment that has been answered by the server in the original conversation,
+// the responses of the target are read until as much data has been received or the timeout expired.
+// Only a single connection is replayed, nothing is sent in dry run mode.
+func replayConversation() {
+	data, err := ioutil.ReadFile(*flagReplay)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	segments, err := streamutils.ParseConversationColors(data, ansi.ColorCode(*flagClientColor), ansi.ColorCode(*flagServerColor))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *flagDryRun {
+		printReplay(segments)
+
+		return
+	}
+
+	if *flagReplayTarget == "" {
+		log.Fatal(errReplayTargetMissing)
+	}
+
+	conn, err := net.DialTimeout("tcp", *flagReplayTarget, *flagReplayTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if errClose := conn.Close(); errClose != nil {
+			fmt.Println("failed to close connection:", errClose)
+		}
+	}()
+
+	fmt.Println("replaying", *flagReplay, "to", conn.RemoteAddr())
+
+	var sent, received int
+
+	for i, s := range segments {
+		if !s.Client {
+			continue
+		}
+
+		n, errWrite := conn.Write(s.Data)
+		sent += n
+
+		if errWrite != nil {
+			log.Fatal("failed to send client data: ", errWrite)
+		}
+
+		fmt.Println("sent", n, "bytes")
+
+		// wait for the response if the server answered in the original conversation
+		if i+1 < len(segments) && !segments[i+1].Client {
+			n = readResponse(conn, len(segments[i+1].Data))
+			received += n
+
+			fmt.Println("received", n, "bytes, expected", len(segments[i+1].Data))
+		}
+	}
+
+	fmt.Println("done, sent", sent, "bytes and received", received, "bytes")
+}
+
+// readResponse reads from the connection until expected bytes have been received,
+// the timeout expired or the connection has been closed, and returns the number of bytes read.
+func readResponse(conn net.Conn, expected int) (total int) {
+	buf := make([]byte, 4096)
+
+	err := conn.SetReadDeadline(time.Now().Add(*flagReplayTimeout))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for total < expected {
+		n, errRead := conn.Read(buf)
+		total += n
+
+		if *flagVerbose && n > 0 {
+			fmt.Print(hex.Dump(buf[:n]))
+		}
+
+		if errRead != nil {
+			break
+		}
+	}
+
+	return total
+}
+
+// printReplay prints the client data that would be sent to the target.
+func printReplay(segments []streamutils.ConversationSegment) {
+	target := *flagReplayTarget
+	if target == "" {
+		target = "<no target specified>"
+	}
+
+	fmt.Println("dry run, would replay", *flagReplay, "to", target)
+
+	for _, s := range segments {
+		if s.Client {
+			fmt.Println("\nsend", len(s.Data), "bytes:")
+			_, _ = os.Stdout.WriteString(hex.Dump(s.Data))
+		} else {
+			fmt.Println("\nwait for", len(s.Data), "bytes")
+		}
+	}
+}
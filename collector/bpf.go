@@ -14,14 +14,81 @@
 package collector
 
 import (
+	"fmt"
 	"io"
 	"sync/atomic"
 
 	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
 	"github.com/dreadl0ck/gopacket/pcap"
 	"github.com/pkg/errors"
+	"golang.org/x/net/bpf"
+
+	"github.com/dreadl0ck/netcap/decoder/stream/tcp"
 )
 
+// ValidateBPF checks if the filter expression compiles, so an invalid filter can be reported on startup.
+// An empty filter is valid and matches all packets.
+func ValidateBPF(filter string) error {
+	if filter == "" {
+		return nil
+	}
+
+	_, err := compileBPF(filter, layers.LinkTypeEthernet)
+
+	return err
+}
+
+// compileBPF compiles the filter expression for the link type of the input,
+// into a program that is evaluated in user space.
+func compileBPF(filter string, linkType layers.LinkType) (*bpf.VM, error) {
+	raw, err := rawBPFForLinkType(filter, linkType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BPF filter %q: %w", filter, err)
+	}
+
+	instructions, ok := bpf.Disassemble(raw)
+	if !ok {
+		return nil, fmt.Errorf("BPF filter %q contains instructions that are not supported in user space", filter)
+	}
+
+	vm, err := bpf.NewVM(instructions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load BPF filter %q: %w", filter, err)
+	}
+
+	return vm, nil
+}
+
+// initBPF compiles the configured BPFFilter for the link type of the input.
+func (c *Collector) initBPF(linkType layers.LinkType) error {
+	if c.config.BPFFilter == "" {
+		return nil
+	}
+
+	vm, err := compileBPF(c.config.BPFFilter, linkType)
+	if err != nil {
+		return err
+	}
+
+	c.bpf = vm
+	c.printlnStdOut("applying BPF:", c.config.BPFFilter)
+
+	return nil
+}
+
+// matchBPF checks if the packet data matches the configured BPFFilter.
+// All packets match if no filter is configured.
+func (c *Collector) matchBPF(data []byte) bool {
+	if c.bpf == nil {
+		return true
+	}
+
+	n, err := c.bpf.Run(data)
+
+	return err == nil && n > 0
+}
+
 // CollectBPF open the named PCAP file and sets the specified BPF filter.
 func (c *Collector) CollectBPF(path, bpf string) error {
 	// open pcap file at path
@@ -47,6 +114,9 @@ func (c *Collector) CollectBPF(path, bpf string) error {
 	c.isLive = true
 	c.mu.Unlock()
 
+	// only wait for free connection slots if other workers can close connections in the meantime
+	tcp.EnableConnectionLimitWait(c.config.Workers > 1)
+
 	var (
 		data []byte
 		ci   gopacket.CaptureInfo
@@ -0,0 +1,96 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package collector
+
+import (
+	"net"
+	"testing"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+)
+
+// tcpPacket serializes an ethernet frame with a TCP segment for the destination port.
+func tcpPacket(t *testing.T, dstPort layers.TCPPort) []byte {
+	t.Helper()
+
+	var (
+		eth = &layers.Ethernet{
+			SrcMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 5},
+			DstMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 6},
+			EthernetType: layers.EthernetTypeIPv4,
+		}
+		ip = &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: layers.IPProtocolTCP,
+			SrcIP:    net.IP{192, 168, 1, 1},
+			DstIP:    net.IP{192, 168, 1, 2},
+		}
+		tcp = &layers.TCP{
+			SrcPort: 52000,
+			DstPort: dstPort,
+			SYN:     true,
+		}
+		buf = gopacket.NewSerializeBuffer()
+	)
+
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, eth, ip, tcp); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestMatchBPF(t *testing.T) {
+	vm, err := compileBPF("tcp port 80 or 443", layers.LinkTypeEthernet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Collector{bpf: vm}
+
+	for port, expected := range map[layers.TCPPort]bool{
+		80:   true,
+		443:  true,
+		8080: false,
+	} {
+		if got := c.matchBPF(tcpPacket(t, port)); got != expected {
+			t.Errorf("port %d: expected match %t, got %t", port, expected, got)
+		}
+	}
+
+	// without a filter all packets match
+	if !(&Collector{}).matchBPF(tcpPacket(t, 8080)) {
+		t.Error("expected all packets to match without a filter")
+	}
+}
+
+func TestValidateBPF(t *testing.T) {
+	if err := ValidateBPF(""); err != nil {
+		t.Error("expected the empty filter to be valid, got", err)
+	}
+
+	if err := ValidateBPF("tcp port 80 or 443"); err != nil {
+		t.Error("expected the filter to be valid, got", err)
+	}
+
+	if err := ValidateBPF("tcp port http or"); err == nil {
+		t.Error("expected an error for an invalid filter")
+	}
+}
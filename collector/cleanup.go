@@ -22,6 +22,7 @@ import (
 
 	"github.com/dreadl0ck/netcap/decoder/stream/alert"
 	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/label/manager"
 
 	"github.com/dustin/go-humanize"
@@ -87,10 +88,13 @@ func (c *Collector) cleanup(force bool) {
 func (c *Collector) teardown() {
 	c.log.Info("teardown")
 
+	// stop serving metrics before the decoders are flushed and reset
+	c.stopMetrics()
+
 	// flush all gopacket decoders
 	for _, decoders := range c.goPacketDecoders {
 		for _, e := range decoders {
-			name, size := e.Destroy()
+			name, size := c.destroySafe(e)
 			if size != 0 {
 				c.totalBytesWritten += size
 				c.files[name] = humanize.Bytes(uint64(size))
@@ -100,7 +104,7 @@ func (c *Collector) teardown() {
 
 	// flush all custom decoders
 	for _, d := range c.packetDecoders {
-		name, size := d.Destroy()
+		name, size := c.destroySafe(d)
 		if size != 0 {
 			c.totalBytesWritten += size
 			c.files[name] = humanize.Bytes(uint64(size))
@@ -109,7 +113,7 @@ func (c *Collector) teardown() {
 
 	// flush all stream decoders
 	for _, d := range c.streamDecoders {
-		name, size := d.Destroy()
+		name, size := c.destroySafe(d)
 		if size != 0 {
 			c.totalBytesWritten += size
 			c.files[name] = humanize.Bytes(uint64(size))
@@ -118,7 +122,7 @@ func (c *Collector) teardown() {
 
 	// flush all abstract decoders
 	for _, d := range c.abstractDecoders {
-		name, size := d.Destroy()
+		name, size := c.destroySafe(d)
 		if size != 0 {
 			c.totalBytesWritten += size
 			c.files[name] = humanize.Bytes(uint64(size))
@@ -152,6 +156,16 @@ func (c *Collector) teardown() {
 	c.closeErrorLogFile()
 	c.stats()
 
+	if err := netio.WriteManifest(c.config.DecoderConfig.Out, c.config.DecoderConfig.Source); err != nil {
+		fmt.Println("failed to write manifest:", err)
+	}
+
+	if c.config.SummaryReport != "" {
+		if err := c.writeSummaryReport(); err != nil {
+			fmt.Println("failed to write summary report:", err)
+		}
+	}
+
 	if c.config.DecoderConfig.Debug {
 		c.printErrors()
 	}
@@ -230,17 +244,7 @@ func (c *Collector) teardown() {
 			log.Fatal("failed to open logfile:", err)
 		}
 
-		// in case a BPF should be set, the gopacket/pcap version with libpcap bindings needs to be used
-		// setting BPF filters is not yet supported by the pcapgo package
-		if c.Bpf != "" {
-			if err = c.CollectBPF(c.InputFile, c.Bpf); err != nil {
-				log.Fatal("failed to set BPF: ", err)
-			}
-
-			return
-		}
-
-		// if not, use native pcapgo version
+		// use native pcapgo version, the BPFFilter is applied by the collector
 		isPcap, err := IsPcap(c.InputFile)
 		if err != nil {
 			// invalid path
@@ -36,6 +36,7 @@ import (
 	"github.com/evilsocket/islazy/tui"
 	"github.com/mgutz/ansi"
 	"go.uber.org/zap"
+	"golang.org/x/net/bpf"
 
 	"github.com/dreadl0ck/netcap/decoder/core"
 	"github.com/dreadl0ck/netcap/decoder/packet"
@@ -92,6 +93,15 @@ type Collector struct {
 	shutdown                 bool
 	isLive                   bool
 
+	// compiled BPFFilter for the link type of the input, nil if no filter is configured
+	bpf *bpf.VM
+
+	// number of packets that have been dropped by the BPFFilter
+	numFiltered int64
+
+	// serves the prometheus metrics of the current run, if configured
+	metricsServer *http.Server
+
 	// logging
 	log           *zap.Logger // collector.log
 	netcapLog     *log.Logger // netcap.log
@@ -102,7 +112,6 @@ type Collector struct {
 
 	InputFile string
 	PrintTime bool
-	Bpf       string
 
 	Epochs    int
 	numEpochs int
@@ -419,6 +428,10 @@ func (c *Collector) stats() {
 		}
 	}
 
+	if numFiltered := atomic.LoadInt64(&c.numFiltered); numFiltered > 0 {
+		res += "-> " + share(numFiltered, c.numPackets) + " of packets (" + strconv.FormatInt(numFiltered, 10) + ") dropped by the BPF\n"
+	}
+
 	if _, err := fmt.Fprintln(target, res); err != nil {
 		fmt.Println("failed to print stats:", err)
 	}
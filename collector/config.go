@@ -86,6 +86,10 @@ type Config struct {
 	// Attach in promiscuous mode for live capture
 	Promisc bool
 
+	// BPFFilter is a berkeley packet filter expression,
+	// packets that do not match are dropped before they are decoded and reassembled
+	BPFFilter string
+
 	// Controls whether packets that had an unknown layer will get written into a separate file
 	WriteUnknownPackets bool
 
@@ -104,6 +108,14 @@ type Config struct {
 	// LogErrors will log verbose packet decoding errors into the errors.log file
 	LogErrors bool
 
+	// StrictDecoders disables the recovery from panics in packet decoders,
+	// so that the first failure aborts the process with a full stack trace for debugging.
+	StrictDecoders bool
+
+	// SummaryReport is the format of the summary report generated at the end of a run,
+	// can be text, markdown or html. No report is generated if empty.
+	SummaryReport string
+
 	// NoPrompt will disable all human interaction prompts
 	NoPrompt bool
 
@@ -112,6 +124,11 @@ type Config struct {
 	// which can be used as alternative to using OS signals
 	HTTPShutdownEndpoint bool
 
+	// MetricsAddr is the address to serve prometheus metrics about the running capture at,
+	// such as the reassembly counters, the number of records per decoder and the written bytes.
+	// No metrics server is started if empty.
+	MetricsAddr string
+
 	// Timeout for live capture
 	// if you set this to 0, the pcap.BlockForever option will be used
 	// From the macOS docs on libpcap:
@@ -26,6 +26,7 @@ import (
 	"github.com/dreadl0ck/netcap/decoder/packet"
 	"github.com/dreadl0ck/netcap/decoder/stream"
 	"github.com/dreadl0ck/netcap/decoder/stream/tcp"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
 	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
 	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/dpi"
@@ -62,6 +63,28 @@ func (c *Collector) Init() (err error) {
 		SupportMissingEstablishment: c.config.DecoderConfig.AllowMissingInit,
 	}
 
+	// configure the reassembly of overlapping IPv4 fragments
+	if c.config.DecoderConfig.DefragPolicy != "" {
+		err = tcp.SetDefragPolicy(c.config.DecoderConfig.DefragPolicy)
+		if err != nil {
+			return err
+		}
+	}
+
+	// only strip PROXY protocol headers sent by the trusted proxies
+	err = tcp.SetProxyProtocol(c.config.DecoderConfig.ProxyProtocol, c.config.DecoderConfig.ProxyProtocolTrusted)
+	if err != nil {
+		return err
+	}
+
+	// select the primary timestamp for protocols that assert their own time
+	if c.config.DecoderConfig.TimestampSource != "" {
+		err = streamutils.SetTimestampSource(c.config.DecoderConfig.TimestampSource)
+		if err != nil {
+			return err
+		}
+	}
+
 	// handle signals for a clean exit
 	c.handleSignals()
 
@@ -85,6 +108,14 @@ func (c *Collector) Init() (err error) {
 		}
 	}
 
+	// create the output directories for audit record types that are written to a custom location
+	for _, dir := range c.config.DecoderConfig.OutDirs {
+		err = os.MkdirAll(dir, c.config.OutDirPermission)
+		if err != nil {
+			return err
+		}
+	}
+
 	// init deep packet inspection
 	if c.config.DPI {
 		dpi.Init()
@@ -102,6 +133,7 @@ func (c *Collector) Init() (err error) {
 	var (
 		// create paths
 		files, _     = filepath.Glob(filepath.Join(c.config.DecoderConfig.Out, "*.ncap.gz"))
+		filesZstd, _ = filepath.Glob(filepath.Join(c.config.DecoderConfig.Out, "*.ncap.zst"))
 		filesBare, _ = filepath.Glob(filepath.Join(c.config.DecoderConfig.Out, "*.ncap"))
 		udpPath      = filepath.Join(c.config.DecoderConfig.Out, "udp")
 		tcpPath      = filepath.Join(c.config.DecoderConfig.Out, "tcp")
@@ -112,6 +144,7 @@ func (c *Collector) Init() (err error) {
 	)
 
 	// collect files
+	files = append(files, filesZstd...)
 	files = append(files, filesBare...)
 
 	// check
@@ -194,6 +227,11 @@ func (c *Collector) Init() (err error) {
 		wg.Done()
 	}()
 
+	// fail early, instead of discovering an invalid report format at the end of a run
+	if _, ok := reportFiles[c.config.SummaryReport]; c.config.SummaryReport != "" && !ok {
+		log.Fatal(errUnknownReportFormat, ": ", c.config.SummaryReport)
+	}
+
 	// set pointer of collectors atomic counter map in decoder pkg
 	decoderutils.SetErrorMap(c.errorMap)
 
@@ -228,6 +266,11 @@ func (c *Collector) Init() (err error) {
 	c.buildProgressString()
 	c.printlnStdOut("done in", time.Since(start))
 
+	// serve metrics once the decoders have been initialized
+	if c.config.MetricsAddr != "" {
+		c.serveMetrics()
+	}
+
 	return nil
 }
 
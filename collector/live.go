@@ -22,6 +22,8 @@ import (
 	"github.com/dreadl0ck/gopacket"
 	"github.com/dreadl0ck/gopacket/pcap"
 	"github.com/pkg/errors"
+
+	"github.com/dreadl0ck/netcap/decoder/stream/tcp"
 )
 
 // CollectLive starts collection of data from the given interface
@@ -59,6 +61,9 @@ func (c *Collector) CollectLive(iface, bpf string) error {
 	c.isLive = true
 	c.mu.Unlock()
 
+	// only wait for free connection slots if other workers can close connections in the meantime
+	tcp.EnableConnectionLimitWait(c.config.Workers > 1)
+
 	var (
 		data []byte
 		ci   gopacket.CaptureInfo
@@ -0,0 +1,224 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder/stream/tcp"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	netio "github.com/dreadl0ck/netcap/io"
+)
+
+// route for the prometheus metrics.
+const metricsRoute = "/metrics"
+
+// timeout for in-flight scrapes when shutting down the metrics server.
+const metricsShutdownTimeout = 5 * time.Second
+
+// process start time, used for the uptime metric.
+var startTime = time.Now()
+
+var (
+	uptimeDesc = prometheus.NewDesc(
+		"nc_uptime",
+		"Number of seconds since the last restart",
+		nil, nil,
+	)
+	capturePacketsDesc = prometheus.NewDesc(
+		"nc_capture_packets",
+		"Number of packets processed by the current capture",
+		nil, nil,
+	)
+	goroutinesDesc = prometheus.NewDesc(
+		"nc_goroutines",
+		"Number of currently running goroutines",
+		nil, nil,
+	)
+	streamReadersDesc = prometheus.NewDesc(
+		"nc_stream_readers",
+		"Number of currently running TCP stream reader goroutines",
+		nil, nil,
+	)
+	decoderRecordsDesc = prometheus.NewDesc(
+		"nc_decoder_records_total",
+		"Number of audit records produced per decoder",
+		[]string{"decoder", "kind"}, nil,
+	)
+	recordBytesDesc = prometheus.NewDesc(
+		"nc_written_record_bytes_total",
+		"Encoded size of all audit records passed to the writers",
+		nil, nil,
+	)
+)
+
+// reassemblyMetric maps a field of the stream reassembly statistics to a prometheus metric.
+type reassemblyMetric struct {
+	index     int
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}
+
+// captureMetrics is a prometheus.Collector that reads the statistics of a running capture at scrape time,
+// from the same sources that are used for the stats table printed at the end of a run.
+type captureMetrics struct {
+	c          *Collector
+	reassembly []reassemblyMetric
+}
+
+func newCaptureMetrics(c *Collector) *captureMetrics {
+	m := &captureMetrics{c: c}
+
+	t := reflect.TypeOf(streamutils.Statistics{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		// peak values can decrease after an epoch reset, all other fields are monotonic counters
+		valueType := prometheus.CounterValue
+		if strings.HasPrefix(f.Name, "Peak") {
+			valueType = prometheus.GaugeValue
+		}
+
+		m.reassembly = append(m.reassembly, reassemblyMetric{
+			index: i,
+			desc: prometheus.NewDesc(
+				"nc_reassembly_"+strings.ToLower(f.Name),
+				"Stream reassembly statistic "+f.Name,
+				nil, nil,
+			),
+			valueType: valueType,
+		})
+	}
+
+	return m
+}
+
+// Describe implements the prometheus.Collector interface.
+func (m *captureMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- uptimeDesc
+	ch <- capturePacketsDesc
+	ch <- goroutinesDesc
+	ch <- streamReadersDesc
+	ch <- decoderRecordsDesc
+	ch <- recordBytesDesc
+
+	for _, r := range m.reassembly {
+		ch <- r.desc
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (m *captureMetrics) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(uptimeDesc, prometheus.GaugeValue, math.RoundToEven(time.Since(startTime).Seconds()))
+	ch <- prometheus.MustNewConstMetric(capturePacketsDesc, prometheus.GaugeValue, float64(m.c.GetNumPackets()))
+	ch <- prometheus.MustNewConstMetric(goroutinesDesc, prometheus.GaugeValue, float64(runtime.NumGoroutine()))
+	ch <- prometheus.MustNewConstMetric(streamReadersDesc, prometheus.GaugeValue, float64(tcp.NumActiveStreamReaders()))
+	ch <- prometheus.MustNewConstMetric(recordBytesDesc, prometheus.CounterValue, float64(netio.RecordBytesWritten()))
+
+	// the decoders are only collected while the metrics server is running,
+	// which is after they have been initialized and before their teardown.
+	for _, decoders := range m.c.goPacketDecoders {
+		for _, d := range decoders {
+			ch <- prometheus.MustNewConstMetric(decoderRecordsDesc, prometheus.CounterValue, float64(d.NumRecords()), d.GetName(), "gopacket")
+		}
+	}
+
+	for _, d := range m.c.packetDecoders {
+		ch <- prometheus.MustNewConstMetric(decoderRecordsDesc, prometheus.CounterValue, float64(d.NumRecords()), d.GetName(), "packet")
+	}
+
+	for _, d := range m.c.streamDecoders {
+		ch <- prometheus.MustNewConstMetric(decoderRecordsDesc, prometheus.CounterValue, float64(d.NumRecords()), d.GetName(), "stream")
+	}
+
+	for _, d := range m.c.abstractDecoders {
+		ch <- prometheus.MustNewConstMetric(decoderRecordsDesc, prometheus.CounterValue, float64(d.NumRecords()), d.GetName(), "abstract")
+	}
+
+	stats := reflect.ValueOf(tcp.ReassemblyStats())
+	for _, r := range m.reassembly {
+		var (
+			f   = stats.Field(r.index)
+			val float64
+		)
+
+		switch f.Kind() {
+		case reflect.Uint, reflect.Uint64:
+			val = float64(f.Uint())
+		default:
+			val = float64(f.Int())
+		}
+
+		ch <- prometheus.MustNewConstMetric(r.desc, r.valueType, val)
+	}
+}
+
+// serveMetrics starts a HTTP server for the prometheus metrics of the current run.
+// The metrics registered in the default registry, such as the go runtime metrics, are served as well.
+func (c *Collector) serveMetrics() {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(newCaptureMetrics(c))
+
+	netio.CountRecordBytes()
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsRoute, promhttp.HandlerFor(
+		prometheus.Gatherers{prometheus.DefaultGatherer, reg},
+		promhttp.HandlerOpts{},
+	))
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	c.metricsServer = &http.Server{
+		Addr:    c.config.MetricsAddr,
+		Handler: mux,
+	}
+
+	c.printlnStdOut("serving metrics at:", c.config.MetricsAddr+metricsRoute)
+
+	go func(srv *http.Server) {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Println("failed to serve metrics:", err)
+			c.log.Error("failed to serve metrics", zap.Error(err))
+		}
+	}(c.metricsServer)
+}
+
+// stopMetrics shuts down the metrics server, if it is running.
+func (c *Collector) stopMetrics() {
+	if c.metricsServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+	defer cancel()
+
+	if err := c.metricsServer.Shutdown(ctx); err != nil {
+		c.log.Error("failed to shutdown metrics server", zap.Error(err))
+	}
+
+	c.metricsServer = nil
+}
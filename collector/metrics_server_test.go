@@ -0,0 +1,52 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCaptureMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(newCaptureMetrics(New(Config{}))); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for _, f := range families {
+		found[f.GetName()] = true
+	}
+
+	for _, name := range []string{
+		"nc_uptime",
+		"nc_capture_packets",
+		"nc_goroutines",
+		"nc_stream_readers",
+		"nc_written_record_bytes_total",
+		"nc_reassembly_reassembled",
+		"nc_reassembly_peakgoroutines",
+		"nc_reassembly_numerrors",
+	} {
+		if !found[name] {
+			t.Errorf("expected metric %s", name)
+		}
+	}
+}
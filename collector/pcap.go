@@ -147,6 +147,10 @@ func (c *Collector) CollectPcap(path string) error {
 
 	c.handleLinkType(r.LinkType())
 
+	if err = c.initBPF(r.LinkType()); err != nil {
+		return err
+	}
+
 	// initialize collector
 	if err = c.Init(); err != nil {
 		return err
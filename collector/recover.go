@@ -0,0 +1,96 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/dreadl0ck/gopacket"
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/decoder/packet"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// errDecoderPanic is returned when a packet decoder panicked while processing a packet.
+var errDecoderPanic = errors.New("decoder panic")
+
+// decodeSafe invokes the packet decoder and recovers from panics,
+// so that a failure in one decoder does not abort the others.
+// The recovered panic is logged together with the offending packet and returned as an error.
+// Recovery is disabled when running with StrictDecoders.
+func (c *Collector) decodeSafe(d packet.DecoderAPI, p gopacket.Packet) (err error) {
+	if c.config.StrictDecoders {
+		return d.Decode(p)
+	}
+
+	defer c.recoverDecode(d.GetName(), p, &err)
+
+	return d.Decode(p)
+}
+
+// decodeLayerSafe invokes the gopacket decoder for the layer and recovers from panics, like decodeSafe.
+func (c *Collector) decodeLayerSafe(d *packet.GoPacketDecoder, ctx *types.PacketContext, p gopacket.Packet, l gopacket.Layer) (err error) {
+	if c.config.StrictDecoders {
+		return d.Decode(ctx, p, l)
+	}
+
+	defer c.recoverDecode(d.GetName(), p, &err)
+
+	return d.Decode(ctx, p, l)
+}
+
+// recoverDecode must be deferred by the callers that invoke a decoder,
+// it logs a panic of the decoder and sets it as error.
+func (c *Collector) recoverDecode(name string, p gopacket.Packet, err *error) {
+	if r := recover(); r != nil {
+		c.log.Error("recovered from panic in packet decoder",
+			zap.String("decoder", name),
+			zap.String("panic", fmt.Sprint(r)),
+			zap.String("packet", p.Dump()),
+			zap.ByteString("stack", debug.Stack()),
+		)
+
+		*err = fmt.Errorf("%w: %v", errDecoderPanic, r)
+	}
+}
+
+// destroySafe flushes and closes the decoder and recovers from panics,
+// so that the remaining decoders can still be flushed.
+// It is used for all decoder types: gopacket, packet, stream and abstract decoders.
+// Recovery is disabled when running with StrictDecoders.
+func (c *Collector) destroySafe(d core.DecoderAPI) (name string, size int64) {
+	if c.config.StrictDecoders {
+		return d.Destroy()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Error("recovered from panic while flushing decoder",
+				zap.String("decoder", d.GetName()),
+				zap.String("panic", fmt.Sprint(r)),
+				zap.ByteString("stack", debug.Stack()),
+			)
+
+			c.errorMap.Inc("Decoder Error: " + d.GetName() + ": " + errDecoderPanic.Error() + " on flush: " + fmt.Sprint(r))
+
+			name, size = "", 0
+		}
+	}()
+
+	return d.Destroy()
+}
@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder/packet"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestDecodeSafe(t *testing.T) {
+	c := &Collector{
+		config:   &Config{},
+		log:      zap.NewNop(),
+		errorMap: decoderutils.NewAtomicCounterMap(),
+	}
+
+	d := &packet.Decoder{
+		Name: "Broken",
+		Handler: func(p gopacket.Packet) proto.Message {
+			panic("boom")
+		},
+		DeInit: func(*packet.Decoder) error {
+			return errors.New("flush failed")
+		},
+	}
+
+	p := gopacket.NewPacket([]byte{0, 1, 2, 3}, layers.LayerTypeEthernet, gopacket.Default)
+
+	err := c.decodeSafe(d, p)
+	if !errors.Is(err, errDecoderPanic) {
+		t.Fatal("expected errDecoderPanic, got", err)
+	}
+
+	name, size := c.destroySafe(d)
+	if name != "" || size != 0 {
+		t.Fatal("unexpected result after panic on flush:", name, size)
+	}
+
+	if len(c.errorMap.Items) != 1 {
+		t.Fatal("expected one error entry, got", len(c.errorMap.Items))
+	}
+}
+
+func TestDecodeLayerSafe(t *testing.T) {
+	c := &Collector{
+		config:   &Config{},
+		log:      zap.NewNop(),
+		errorMap: decoderutils.NewAtomicCounterMap(),
+	}
+
+	// the writer is not set, so flushing the decoder panics as well
+	d := &packet.GoPacketDecoder{
+		Type:  types.Type_NC_DNS,
+		Layer: layers.LayerTypeDNS,
+		Handler: func(layer gopacket.Layer, timestamp int64) proto.Message {
+			panic("boom")
+		},
+	}
+
+	p := gopacket.NewPacket([]byte{0, 1, 2, 3}, layers.LayerTypeEthernet, gopacket.Default)
+
+	err := c.decodeLayerSafe(d, nil, p, p.Layer(layers.LayerTypeDNS))
+	if !errors.Is(err, errDecoderPanic) {
+		t.Fatal("expected errDecoderPanic, got", err)
+	}
+
+	name, size := c.destroySafe(d)
+	if name != "" || size != 0 {
+		t.Fatal("unexpected result after panic on flush:", name, size)
+	}
+
+	if len(c.errorMap.Items) != 1 {
+		t.Fatal("expected one error entry, got", len(c.errorMap.Items))
+	}
+}
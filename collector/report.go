@@ -0,0 +1,374 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/evilsocket/islazy/tui"
+
+	"github.com/dreadl0ck/netcap/decoder/packet"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// Summary report formats.
+const (
+	ReportText     = "text"
+	ReportMarkdown = "markdown"
+	ReportHTML     = "html"
+)
+
+// number of entries shown in the top talkers and hostnames sections.
+const reportTopN = 10
+
+var errUnknownReportFormat = errors.New("unknown summary report format")
+
+// reportFiles maps the report formats to the filename of the generated report.
+var reportFiles = map[string]string{
+	ReportText:     "summary.txt",
+	ReportMarkdown: "summary.md",
+	ReportHTML:     "summary.html",
+}
+
+// findingTypes are the audit record types that are listed as notable findings.
+var findingTypes = []types.Type{
+	types.Type_NC_Alert,
+	types.Type_NC_CertAnomaly,
+	types.Type_NC_Credentials,
+	types.Type_NC_Exploit,
+	types.Type_NC_Vulnerability,
+}
+
+type reportCount struct {
+	Name  string
+	Count int64
+}
+
+type reportTalker struct {
+	Addr     string
+	Name     string
+	Packets  int64
+	Bytes    string
+	numBytes uint64
+}
+
+// summaryReport is an overview of a capture run, aggregated from the state of the decoders.
+type summaryReport struct {
+	Source     string
+	Generated  time.Time
+	First      time.Time
+	Last       time.Time
+	Duration   time.Duration
+	Size       string
+	NumPackets int64
+	Written    string
+
+	TopTalkers []reportTalker
+	Protocols  []reportCount
+	Records    []reportCount
+	Findings   []reportCount
+	Hostnames  []reportCount
+}
+
+// writeSummaryReport generates the summary report in the configured format
+// and writes it into the output directory.
+func (c *Collector) writeSummaryReport() error {
+	name, ok := reportFiles[c.config.SummaryReport]
+	if !ok {
+		return fmt.Errorf("%w: %s", errUnknownReportFormat, c.config.SummaryReport)
+	}
+
+	f, err := os.Create(filepath.Join(c.config.DecoderConfig.Out, name))
+	if err != nil {
+		return err
+	}
+
+	r := c.summarize()
+
+	switch c.config.SummaryReport {
+	case ReportText:
+		r.writeText(f)
+	case ReportMarkdown:
+		r.writeMarkdown(f)
+	case ReportHTML:
+		err = htmlReport.Execute(f, r)
+	}
+
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// summarize aggregates the summary report from the collector stats and the collected IP profiles.
+func (c *Collector) summarize() *summaryReport {
+	r := &summaryReport{
+		Source:     c.config.DecoderConfig.Source,
+		Generated:  time.Now(),
+		NumPackets: c.numPackets,
+		Written:    humanize.Bytes(uint64(c.totalBytesWritten)),
+	}
+
+	if c.inputSize > 0 {
+		r.Size = humanize.Bytes(uint64(c.inputSize))
+	}
+
+	// protocol distribution
+	c.allProtosAtomic.Lock()
+	for name, count := range c.allProtosAtomic.Items {
+		r.Protocols = append(r.Protocols, reportCount{Name: name, Count: count})
+	}
+	c.allProtosAtomic.Unlock()
+
+	// number of records for each type
+	records := make(map[string]int64)
+
+	for _, decoders := range c.goPacketDecoders {
+		for _, d := range decoders {
+			records[d.GetName()] += d.NumRecords()
+		}
+	}
+
+	for _, d := range c.packetDecoders {
+		records[d.GetName()] += d.NumRecords()
+	}
+
+	for _, d := range c.streamDecoders {
+		records[d.GetName()] += d.NumRecords()
+	}
+
+	for _, d := range c.abstractDecoders {
+		records[d.GetName()] += d.NumRecords()
+	}
+
+	for name, count := range records {
+		if count > 0 {
+			r.Records = append(r.Records, reportCount{Name: name, Count: count})
+		}
+	}
+
+	for _, t := range findingTypes {
+		name := strings.TrimPrefix(t.String(), "NC_")
+		if count := records[name]; count > 0 {
+			r.Findings = append(r.Findings, reportCount{Name: name, Count: count})
+		}
+	}
+
+	// top talkers, hostnames and capture duration
+	hostnames := make(map[string]int64)
+
+	for _, p := range packet.IPProfiles() {
+		var name string
+		if len(p.DNSNames) > 0 {
+			name = p.DNSNames[0]
+		}
+
+		r.TopTalkers = append(r.TopTalkers, reportTalker{
+			Addr:     p.Addr,
+			Name:     name,
+			Packets:  p.NumPackets,
+			Bytes:    humanize.Bytes(p.Bytes),
+			numBytes: p.Bytes,
+		})
+
+		for _, n := range p.DNSNames {
+			hostnames[n]++
+		}
+
+		for sni, count := range p.SNIs {
+			hostnames[sni] += count
+		}
+
+		first, last := time.Unix(0, p.TimestampFirst), time.Unix(0, p.TimestampLast)
+		if r.First.IsZero() || first.Before(r.First) {
+			r.First = first
+		}
+
+		if last.After(r.Last) {
+			r.Last = last
+		}
+	}
+
+	for name, count := range hostnames {
+		r.Hostnames = append(r.Hostnames, reportCount{Name: name, Count: count})
+	}
+
+	if !r.First.IsZero() {
+		r.Duration = r.Last.Sub(r.First)
+	}
+
+	sort.Slice(r.TopTalkers, func(i, j int) bool {
+		return r.TopTalkers[i].numBytes > r.TopTalkers[j].numBytes
+	})
+
+	if len(r.TopTalkers) > reportTopN {
+		r.TopTalkers = r.TopTalkers[:reportTopN]
+	}
+
+	sortCounts(r.Protocols)
+	sortCounts(r.Records)
+	sortCounts(r.Hostnames)
+
+	if len(r.Hostnames) > reportTopN {
+		r.Hostnames = r.Hostnames[:reportTopN]
+	}
+
+	return r
+}
+
+// sortCounts sorts in descending order of the count, and by name for equal counts.
+func sortCounts(counts []reportCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count == counts[j].Count {
+			return counts[i].Name < counts[j].Name
+		}
+
+		return counts[i].Count > counts[j].Count
+	})
+}
+
+// overview returns the key value pairs for the general information section.
+func (r *summaryReport) overview() [][]string {
+	rows := [][]string{
+		{"Source", r.Source},
+		{"Packets", strconv.FormatInt(r.NumPackets, 10)},
+	}
+
+	if r.Size != "" {
+		rows = append(rows, []string{"Input size", r.Size})
+	}
+
+	if !r.First.IsZero() {
+		rows = append(rows,
+			[]string{"First packet", r.First.UTC().String()},
+			[]string{"Last packet", r.Last.UTC().String()},
+			[]string{"Duration", r.Duration.String()},
+		)
+	}
+
+	return append(rows, []string{"Audit records written", r.Written})
+}
+
+func (r *summaryReport) talkerRows() [][]string {
+	rows := make([][]string, 0, len(r.TopTalkers))
+	for _, t := range r.TopTalkers {
+		rows = append(rows, []string{t.Addr, t.Name, strconv.FormatInt(t.Packets, 10), t.Bytes})
+	}
+
+	return rows
+}
+
+func countRows(counts []reportCount) [][]string {
+	rows := make([][]string, 0, len(counts))
+	for _, c := range counts {
+		rows = append(rows, []string{c.Name, strconv.FormatInt(c.Count, 10)})
+	}
+
+	return rows
+}
+
+// reportSection is a titled table of the summary report.
+type reportSection struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// Sections returns the tables of the report in the order they are rendered.
+func (r *summaryReport) Sections() []reportSection {
+	return []reportSection{
+		{"Overview", []string{"Property", "Value"}, r.overview()},
+		{"Notable Findings", []string{"Type", "Count"}, countRows(r.Findings)},
+		{"Top Talkers", []string{"Address", "Name", "Packets", "Bytes"}, r.talkerRows()},
+		{"Protocol Distribution", []string{"Protocol", "Packets"}, countRows(r.Protocols)},
+		{"Audit Records", []string{"Type", "Count"}, countRows(r.Records)},
+		{"Top Hostnames", []string{"Hostname", "Count"}, countRows(r.Hostnames)},
+	}
+}
+
+func (r *summaryReport) writeText(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "NETCAP Summary Report, generated", r.Generated.UTC().Format(time.RFC3339))
+
+	for _, s := range r.Sections() {
+		_, _ = fmt.Fprintln(w, "\n"+s.Title)
+
+		if len(s.Rows) == 0 {
+			_, _ = fmt.Fprintln(w, "none")
+
+			continue
+		}
+
+		tui.Table(w, s.Columns, s.Rows)
+	}
+}
+
+func (r *summaryReport) writeMarkdown(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "# NETCAP Summary Report")
+	_, _ = fmt.Fprintln(w, "\nGenerated", r.Generated.UTC().Format(time.RFC3339))
+
+	for _, s := range r.Sections() {
+		_, _ = fmt.Fprintln(w, "\n## "+s.Title+"\n")
+
+		if len(s.Rows) == 0 {
+			_, _ = fmt.Fprintln(w, "none")
+
+			continue
+		}
+
+		_, _ = fmt.Fprintln(w, "| "+strings.Join(s.Columns, " | ")+" |")
+		_, _ = fmt.Fprintln(w, strings.Repeat("| --- ", len(s.Columns))+"|")
+
+		for _, row := range s.Rows {
+			_, _ = fmt.Fprintln(w, "| "+strings.Join(row, " | ")+" |")
+		}
+	}
+}
+
+var htmlReport = template.Must(template.New("summary").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>NETCAP Summary Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<h1>NETCAP Summary Report</h1>
+<p>Generated {{ .Generated.UTC.Format "2006-01-02T15:04:05Z07:00" }}</p>
+{{ range .Sections }}<h2>{{ .Title }}</h2>
+{{ if .Rows }}<table>
+<tr>{{ range .Columns }}<th>{{ . }}</th>{{ end }}</tr>
+{{ range .Rows }}<tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>
+{{ end }}</table>
+{{ else }}<p>none</p>
+{{ end }}{{ end }}</body>
+</html>
+`))
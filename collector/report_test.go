@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummaryReportMarkdown(t *testing.T) {
+	r := &summaryReport{
+		Source:     "test.pcap",
+		Generated:  time.Now(),
+		NumPackets: 42,
+		Written:    "1.0 kB",
+		Findings:   []reportCount{{Name: "Credentials", Count: 2}},
+		TopTalkers: []reportTalker{{Addr: "192.168.1.1", Name: "gateway", Packets: 42, Bytes: "4.2 kB"}},
+	}
+
+	var buf bytes.Buffer
+	r.writeMarkdown(&buf)
+
+	out := buf.String()
+	for _, expected := range []string{
+		"# NETCAP Summary Report",
+		"| Source | test.pcap |",
+		"| Credentials | 2 |",
+		"| 192.168.1.1 | gateway | 42 | 4.2 kB |",
+		"## Top Hostnames\n\nnone",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in report:\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	if err := htmlReport.Execute(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "<td>192.168.1.1</td>") {
+		t.Error("missing top talker in HTML report")
+	}
+}
@@ -28,6 +28,14 @@ import (
 )
 
 func (c *Collector) handleRawPacketData(data []byte, ci *gopacket.CaptureInfo) {
+	// drop packets that do not match the BPF before decoding them
+	if !c.matchBPF(data) {
+		atomic.AddInt64(&c.numFiltered, 1)
+		c.wg.Done()
+
+		return
+	}
+
 	// when not using lazy here, the packet will be decoded on the main thread!
 	p := gopacket.NewPacket(data, c.config.BaseLayer, c.config.DecodeOptions)
 	p.Metadata().CaptureInfo = *ci
@@ -87,8 +95,12 @@ func share(current, total int64) string {
 }
 
 func rawBPF(filter string) ([]bpf.RawInstruction, error) {
+	return rawBPFForLinkType(filter, layers.LinkTypeEthernet)
+}
+
+func rawBPFForLinkType(filter string, linkType layers.LinkType) ([]bpf.RawInstruction, error) {
 	// use pcap bpf compiler to get raw bpf instruction
-	pcapBPF, err := pcap.CompileBPFFilter(layers.LinkTypeEthernet, 65535, filter)
+	pcapBPF, err := pcap.CompileBPFFilter(linkType, 65535, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +119,7 @@ func (c *Collector) worker(assembler *reassembly.Assembler) chan gopacket.Packet
 				if decoders, ok = c.goPacketDecoders[layer.LayerType()]; ok {
 					for _, dec = range decoders {
 						t := time.Now()
-						err = dec.Decode(ctx, pkt, layer)
+						err = c.decodeLayerSafe(dec, ctx, pkt, layer)
 						gopacketDecoderTime.WithLabelValues(layer.LayerType().String()).Set(float64(time.Since(t).Nanoseconds()))
 						if err != nil {
 							if c.config.DecoderConfig.ExportMetrics {
@@ -154,7 +154,7 @@ func (c *Collector) worker(assembler *reassembly.Assembler) chan gopacket.Packet
 			// call custom decoders
 			for _, customDec = range c.packetDecoders {
 				t := time.Now()
-				err = customDec.Decode(pkt)
+				err = c.decodeSafe(customDec, pkt)
 				customDecoderTime.WithLabelValues(customDec.GetName()).Set(float64(time.Since(t).Nanoseconds()))
 				if err != nil {
 					if c.config.DecoderConfig.ExportMetrics {
@@ -200,6 +200,7 @@ func (c *Collector) initWorkers() []chan gopacket.Packet {
 	// create assemblers
 	for i := range workers {
 		a := reassembly.NewAssembler(tcp.GetStreamPool())
+		a.CoalesceWindow = c.config.DecoderConfig.FlushCoalesceWindow
 		c.assemblers = append(c.assemblers, a)
 		workers[i] = c.worker(a)
 	}
@@ -46,6 +46,10 @@ type (
 
 		// Type of the audit records produced by this decoder
 		Type types.Type
+
+		// StreamDecoders are the names of the stream decoders that extract the data for this decoder,
+		// they are enabled automatically when the decoder is included
+		StreamDecoders []string
 	}
 )
 
@@ -28,10 +28,26 @@ var Instance *Config
 var DefaultConfig = &Config{
 	Buffer:                     true,
 	MemBufferSize:              defaults.BufferSize,
+	MaxFileSize:                0,
 	Compression:                true,
 	CSV:                        false,
+	CSVDelimiter:               ',',
 	IncludeDecoders:            "",
 	ExcludeDecoders:            "",
+	IPProfileAllowList:         "",
+	IPProfileDenyList:          "",
+	HomeNetworks:               "",
+	ProxyProtocol:              false,
+	ProxyProtocolTrusted:       "",
+	VolumeAnomalies:            false,
+	QUIC:                       false,
+	VolumeBucket:               defaults.VolumeBucket,
+	VolumeThreshold:            defaults.VolumeThreshold,
+	VolumeWindow:               defaults.VolumeWindow,
+	VolumeMinBytes:             defaults.VolumeMinBytes,
+	PortScanPorts:              defaults.PortScanPorts,
+	PortScanHosts:              defaults.PortScanHosts,
+	PortScanWindow:             defaults.PortScanWindow,
 	Out:                        "",
 	Chan:                       false,
 	Proto:                      true,
@@ -40,7 +56,11 @@ var DefaultConfig = &Config{
 	ExportMetrics:              false,
 	AddContext:                 true,
 	FlushEvery:                 100,
+	FlushInterval:              0,
 	DefragIPv4:                 false,
+	DefragPolicy:               defaults.DefragPolicy,
+	NormalizeAddresses:         defaults.NormalizeAddresses,
+	ExpandIPv6:                 false,
 	Checksum:                   false,
 	NoOptCheck:                 false,
 	IgnoreFSMerr:               false,
@@ -56,9 +76,19 @@ var DefaultConfig = &Config{
 	FlowTimeOut:                10 * time.Second,
 	CloseInactiveTimeOut:       24 * time.Hour,
 	ClosePendingTimeOut:        5 * time.Second,
+	FlushCoalesceWindow:        defaults.FlushCoalesceWindow,
+	ClosedGracePeriod:          defaults.ClosedGracePeriod,
 	FileStorage:                defaults.FileStorage,
+	HTTPBodyStorage:            "",
+	HTTPBodyMaxSize:            defaults.HTTPBodyMaxSize,
+	HTTPMaxDecodedSize:         defaults.HTTPMaxDecodedSize,
+	HTTPDedup:                  false,
+	HTTPDedupMax:               defaults.HTTPDedupMax,
 	CalculateEntropy:           false,
 	SaveConns:                  false,
+	ConnsClientColor:           "red",
+	ConnsServerColor:           "blue",
+	ConnsNoColor:               false,
 	TCPDebug:                   false,
 	UseRE2:                     true,
 	HarvesterBannerSize:        512,
@@ -67,8 +97,30 @@ var DefaultConfig = &Config{
 	StopAfterServiceProbeMatch: true,
 	IgnoreDecoderInitErrors:    true,
 	RemoveClosedStreams:        false,
+	IgnoreUnclosedStreams:      false,
+	ConnGaps:                   false,
+	ConnEntropy:                false,
+	ConnSNI:                    false,
+	DumpConnectionPcaps:        false,
+	DecapGRE:                   false,
+	DecapVXLAN:                 false,
+	IgnoreUDPConnections:       false,
+	UDPConnTimeout:             defaults.UDPConnTimeout,
+	UDPStreamTimeout:           defaults.UDPStreamTimeout,
+	MaxStreamReaders:           0,
+	MaxConcurrentConnections:   0,
+	ConnectionLimitWait:        0,
+	MaxConversationBytes:       0,
+	CertShortValidityDays:      7,
+	ProtocolSignatures:         "",
+	SignatureSize:              512,
+	DPIProtocols:               "",
+	ScanSecrets:                false,
+	SecretPatterns:             "",
+	TimestampSource:            defaults.TimestampSource,
 	CompressionBlockSize:       defaults.CompressionBlockSize,
 	CompressionLevel:           defaults.CompressionLevel,
+	CompressionFormat:          io.CompressionGzip,
 }
 
 // Config contains configuration parameters
@@ -80,30 +132,115 @@ type Config struct {
 	// Output path
 	Out string
 
+	// OutDirs maps audit record types to a custom output directory, all other types are written to Out
+	OutDirs map[string]string
+
 	// Source of the audit records (pcap, live etc)
 	Source string
 
+	// Color for the client data of saved conversations, any style supported by github.com/mgutz/ansi, e.g. yellow, green+b or 208
+	ConnsClientColor string
+
+	// Color for the server data of saved conversations
+	ConnsServerColor string
+
 	// CustomRegex to use for credentials harvester
 	CustomRegex string
 
+	// SecretPatterns is the path to a JSON file with additional patterns for the secret scanner
+	SecretPatterns string
+
+	// ProtocolSignatures is the path to a JSON file with payload signatures used to select a stream decoder
+	// if empty, the default signature set is used
+	ProtocolSignatures string
+
+	// SignatureSize is the number of bytes at the start of the client and server streams inspected by the protocol signatures
+	SignatureSize int
+
+	// DPIProtocols is the path to a JSON file that maps the protocols identified by DPI to stream decoders
+	// if empty, the default mapping is used
+	DPIProtocols string
+
+	// TimestampSource selects the primary timestamp for audit records of protocols that assert their own time: capture or protocol
+	// the time asserted by the protocol and the clock skew are always recorded in separate fields
+	TimestampSource string
+
 	// Will create a memory dump at the specified path for debugging and profiling
 	MemProfile string
 
+	// DefragPolicy determines which data is used when IPv4 fragments overlap: first, last, bsd or linux
+	DefragPolicy string
+
 	// Comma separated list of decoders to include
 	IncludeDecoders string
 
 	// Comma separated list of decoders to exclude
 	ExcludeDecoders string
 
+	// Comma separated list of CIDRs, if set only addresses within these networks will get an IPProfile
+	IPProfileAllowList string
+
+	// Comma separated list of CIDRs, addresses within these networks will not get an IPProfile
+	IPProfileDenyList string
+
+	// Comma separated list of CIDRs that make up the home network, if empty the private address ranges are used
+	HomeNetworks string
+
+	// ProxyProtocol enables stripping PROXY protocol headers from the connections of the trusted proxies
+	ProxyProtocol bool
+
+	// Comma separated list of addresses, CIDRs and backend ports of the proxies that are trusted to send PROXY protocol headers
+	ProxyProtocolTrusted string
+
+	// Size of the time buckets for the outbound traffic volume of internal hosts
+	VolumeBucket time.Duration
+
+	// Number of standard deviations above the baseline mean for a bucket to be flagged as volume anomaly
+	VolumeThreshold float64
+
+	// Number of recent buckets used as baseline for the volume anomaly detection
+	VolumeWindow int
+
+	// Minimum number of bytes within a bucket to be flagged as volume anomaly
+	VolumeMinBytes uint64
+
+	// Number of distinct ports contacted on a single host within the window to flag a port scan, 0 disables the check
+	PortScanPorts int
+
+	// Number of distinct hosts contacted on a single port within the window to flag a port scan, 0 disables the check
+	PortScanHosts int
+
+	// Time window in which the distinct targets of an address are counted for the port scan detection
+	PortScanWindow time.Duration
+
 	// If a path is set files will be extracted and written to the specified path
 	FileStorage string
 
+	// If a path is set HTTP response bodies will be written to the specified path,
+	// named after the sha256 hash of their decoded contents
+	HTTPBodyStorage string
+
+	// Maximum size in bytes of HTTP response bodies written to the HTTPBodyStorage, zero means no limit
+	HTTPBodyMaxSize int
+
+	// Maximum size in bytes of HTTP bodies after removing the content encoding, larger bodies are truncated, zero means no limit
+	HTTPMaxDecodedSize int
+
+	// Collapse consecutive identical HTTP requests of a connection into a single record
+	HTTPDedup bool
+
+	// Maximum number of identical HTTP requests collapsed into a single record, zero means no limit
+	HTTPDedupMax int
+
 	// Number of packets to arrive until the connections are checked for timeouts
 	ConnFlushInterval int
 
 	// Size of buffer used for writing audit records to disk
 	MemBufferSize int
 
+	// MaxFileSize rotates audit record files once they exceed the given number of bytes, 0 disables rotation
+	MaxFileSize int64
+
 	// Used to flush flows to disk whose last timestamp is flowTimeOut older than current packet
 	FlowTimeOut time.Duration
 
@@ -113,9 +250,12 @@ type Config struct {
 	// Close inactive streams after
 	CloseInactiveTimeOut time.Duration
 
-	// Interval to apply connection flushes
+	// Interval to apply connection flushes, in packets
 	FlushEvery int
 
+	// Interval to apply connection flushes, in capture time since the last flush. 0 disables time based flushing.
+	FlushInterval time.Duration
+
 	// Maximum number of bytes of the client and server conversation to be used for the harvesters
 	HarvesterBannerSize int
 
@@ -125,6 +265,14 @@ type Config struct {
 	// Close streams with pending bytes after
 	ClosePendingTimeOut time.Duration
 
+	// Extend the flush timeouts by up to this duration for connections that had a high throughput recently,
+	// to avoid splitting bursty transfers during short idle gaps. 0 disables burst coalescing.
+	FlushCoalesceWindow time.Duration
+
+	// Segments that arrive later than this after a connection has been closed are ignored,
+	// to prevent late retransmissions from polluting the connection. 0 disables the check.
+	ClosedGracePeriod time.Duration
+
 	// Number of packets to arrive until the flows are checked for timeouts
 	FlowFlushInterval int
 
@@ -147,7 +295,8 @@ type Config struct {
 	// Buffer data before writing it to disk
 	Buffer bool
 
-	// Write incomplete HTTP responses to disk when extracting files
+	// Write incomplete HTTP responses to disk when extracting files,
+	// and keep TCP connections with missing bytes instead of dropping the data after a gap
 	WriteIncomplete bool
 
 	// Write into channel (used for distributed collection)
@@ -159,6 +308,9 @@ type Config struct {
 	// Generate CSV instead of audit records
 	CSV bool
 
+	// CSVDelimiter separates the fields of CSV records
+	CSVDelimiter rune
+
 	// UnixSocket will send data over unix sockets
 	UnixSocket bool
 
@@ -177,6 +329,18 @@ type Config struct {
 	// Additional elastic configuration options
 	io.ElasticConfig
 
+	// Output data to a syslog collector
+	Syslog bool
+
+	// Syslog collector configuration
+	io.SyslogConfig
+
+	// Output data to kafka
+	Kafka bool
+
+	// Kafka broker and topic configuration
+	io.KafkaConfig
+
 	// Elastic bulk sizes
 	BulkSizeGoPacket int
 	BulkSizeCustom   int
@@ -184,6 +348,15 @@ type Config struct {
 	// Output JSON
 	JSON bool
 
+	// Output CBOR
+	CBOR bool
+
+	// Output Avro object container files
+	Avro bool
+
+	// Stream audit records to the clients of the websocket server
+	WebSocket bool
+
 	// Discard all data and write nothing to disk
 	Null bool
 
@@ -208,9 +381,22 @@ type Config struct {
 	// Calculate entropy for payloads in Ethernet and IP audit records
 	CalculateEntropy bool
 
+	// Scan all reassembled TCP conversations for cleartext credentials, API keys and tokens
+	ScanSecrets bool
+
+	// Track the outbound traffic volume of internal hosts and flag spikes as VolumeAnomaly audit records
+	VolumeAnomalies bool
+
+	// Decrypt the Initial packets of QUIC handshakes to extract the server name from the ClientHello
+	QUIC bool
+
 	// Save the entire raw TCP conversations for all tracked connections to disk
 	SaveConns bool
 
+	// Write the saved conversations without ANSI color codes, e.g. for processing them with other tools
+	// the client and server data can no longer be distinguished when colors are disabled
+	ConnsNoColor bool
+
 	// Enable verbose TCP debug log messages in debug.log
 	TCPDebug bool
 
@@ -223,6 +409,12 @@ type Config struct {
 	// Defragment IPv4 packets
 	DefragIPv4 bool
 
+	// NormalizeAddresses converts IP and MAC addresses on audit records into a canonical textual form
+	NormalizeAddresses bool
+
+	// ExpandIPv6 renders normalized IPv6 addresses fully expanded, instead of compressed as described in RFC 5952
+	ExpandIPv6 bool
+
 	// ExportMetrics will export prometheus metrics
 	ExportMetrics bool
 
@@ -250,6 +442,25 @@ type Config struct {
 	// TCP/UDP StreamProcessors number of workers
 	NumStreamWorkers int
 
+	// MaxStreamReaders limits the number of concurrently running TCP stream reader goroutines, 0 means no limit
+	// streams opened after the limit has been reached are read on the assembler goroutine
+	MaxStreamReaders int
+
+	// MaxConcurrentConnections limits the number of open TCP connections, 0 means no limit
+	// packets of new connections are dropped once the limit has been reached
+	MaxConcurrentConnections int
+
+	// ConnectionLimitWait is the time to wait for open connections to be closed
+	// before dropping a packet of a new connection, 0 drops it immediately
+	ConnectionLimitWait time.Duration
+
+	// MaxConversationBytes limits the number of bytes buffered for a single TCP connection, 0 means no limit
+	// data exceeding the limit is dropped and the connection is decoded with the data collected so far
+	MaxConversationBytes int
+
+	// CertShortValidityDays is the validity period in days below which a TLS certificate is flagged as anomalous
+	CertShortValidityDays int
+
 	// DisableGenericVersionHarvester will not use the generic version string regex for the software harvester
 	DisableGenericVersionHarvester bool
 
@@ -257,9 +468,50 @@ type Config struct {
 	// if set to false it allows to witness further packets for the stream, e.g. FIN-ACK
 	RemoveClosedStreams bool
 
+	// IgnoreUnclosedStreams will not decode TCP streams that never received a FIN or RST packet
+	// and have been closed because of the inactivity timeout or when flushing at the end of the capture
+	IgnoreUnclosedStreams bool
+
+	// ConnGaps will track missing data in the reassembled TCP streams
+	// and report the number and size of the gaps on the Connection audit records
+	ConnGaps bool
+
+	// ConnEntropy will calculate the Shannon entropy of the reassembled TCP conversations
+	// and report it on the Connection audit records
+	ConnEntropy bool
+
+	// ConnSNI will extract the server name from the TLS ClientHello of reassembled TCP connections
+	// and report it on the Connection audit records
+	ConnSNI bool
+
+	// DumpConnectionPcaps will write the original packets of each reassembled TCP connection
+	// to a separate pcap file named after the connection
+	DumpConnectionPcaps bool
+
+	// DecapGRE strips the GRE header from tunneled packets before the reassembly,
+	// so the inner connection is reassembled instead of the tunnel
+	DecapGRE bool
+
+	// DecapVXLAN strips the VXLAN header from tunneled packets before the reassembly
+	DecapVXLAN bool
+
+	// IgnoreUDPConnections disables Connection audit records for UDP pseudo connections
+	IgnoreUDPConnections bool
+
+	// UDPConnTimeout is the idle time after which a UDP pseudo connection is written
+	// the next packet for the same 5-tuple starts a new connection, zero disables the timeout
+	UDPConnTimeout time.Duration
+
+	// UDPStreamTimeout is the idle time in capture time after which a UDP stream is decoded, saved and evicted
+	// zero keeps all UDP streams in memory until the end of the capture
+	UDPStreamTimeout time.Duration
+
 	// CompressionBlockSize is the block size used for parallel compression
 	CompressionBlockSize int
 
 	// CompressionLevel is the compression level to use by default
 	CompressionLevel int
+
+	// CompressionFormat is the algorithm used to compress audit record files: gzip, zstd or none
+	CompressionFormat io.Compression
 }
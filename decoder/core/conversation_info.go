@@ -27,4 +27,8 @@ type ConversationInfo struct {
 	ServerIP   string
 	ClientPort int32
 	ServerPort int32
+
+	// Incomplete is set if bytes are missing in the conversation,
+	// it can only be true when WriteIncomplete is enabled
+	Incomplete bool
 }
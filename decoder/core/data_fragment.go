@@ -22,9 +22,11 @@ import (
 // dataFragment describes functionality of encapsulation structures for network data fragments.
 type dataFragment interface {
 	Raw() []byte
+	SetRaw([]byte)
 	Context() reassembly.AssemblerContext
 	Direction() reassembly.TCPFlowDirection
 	SetDirection(reassembly.TCPFlowDirection)
+	Gap() int
 	CaptureInfo() gopacket.CaptureInfo
 	Network() gopacket.Flow
 	Transport() gopacket.Flow
@@ -16,6 +16,8 @@ package core
 import (
 	"bytes"
 	"io"
+
+	"github.com/dreadl0ck/netcap/reassembly"
 )
 
 // DataFragments implements sort.Interface to sort data fragments based on their timestamps.
@@ -40,15 +42,69 @@ func (d DataFragments) bytes() []byte {
 	return b.Bytes()
 }
 
+// DirectionBytes returns the data of all fragments sent in the given direction.
+func (d DataFragments) DirectionBytes(dir reassembly.TCPFlowDirection) []byte {
+	var b bytes.Buffer
+
+	for _, dt := range d {
+		if dt.Direction() == dir {
+			b.Write(dt.Raw())
+		}
+	}
+
+	return b.Bytes()
+}
+
+// Head returns up to n bytes from the start of the fragments.
+func (d DataFragments) Head(n int) []byte {
+	var b bytes.Buffer
+
+	for _, dt := range d {
+		if b.Len() >= n {
+			break
+		}
+
+		b.Write(dt.Raw())
+	}
+
+	if b.Len() > n {
+		return b.Bytes()[:n]
+	}
+
+	return b.Bytes()
+}
+
+// TrimStart removes n bytes from the start of the fragments.
+// Fragments that have been consumed entirely are kept with an empty payload.
+func (d DataFragments) TrimStart(n int) {
+	for _, dt := range d {
+		if n <= 0 {
+			return
+		}
+
+		raw := dt.Raw()
+		if len(raw) > n {
+			dt.SetRaw(raw[n:])
+
+			return
+		}
+
+		dt.SetRaw(nil)
+		n -= len(raw)
+	}
+}
+
 // TODO: implement a read that does not duplicate the data, but instead iterates over the fragments when being read from
 func (d DataFragments) reader() io.Reader {
 	return bytes.NewReader(d.bytes())
 }
 
-// First returns the first fragment.
+// First returns the data of the first fragment, fragments without data are skipped.
 func (d DataFragments) First() []byte {
-	if len(d) > 0 {
-		return d[0].Raw()
+	for _, dt := range d {
+		if len(dt.Raw()) > 0 {
+			return dt.Raw()
+		}
 	}
 	return nil
 }
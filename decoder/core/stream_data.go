@@ -29,6 +29,10 @@ type StreamData struct {
 	AssemblerContext reassembly.AssemblerContext
 	Dir              reassembly.TCPFlowDirection
 
+	// number of bytes missing in the stream before this fragment,
+	// -1 if the amount is unknown and 0 if the fragment is contiguous
+	MissingBytes int
+
 	// udp specific fields
 	CaptureInformation gopacket.CaptureInfo
 	Net                gopacket.Flow
@@ -42,6 +46,11 @@ func (s *StreamData) Raw() []byte {
 	return s.RawData
 }
 
+// SetRaw replaces the raw data of the fragment.
+func (s *StreamData) SetRaw(data []byte) {
+	s.RawData = data
+}
+
 // Context returns the assembler context.
 func (s *StreamData) Context() reassembly.AssemblerContext {
 	return s.AssemblerContext
@@ -57,6 +66,11 @@ func (s *StreamData) SetDirection(d reassembly.TCPFlowDirection) {
 	s.Dir = d
 }
 
+// Gap returns the number of bytes missing before the fragment, -1 if unknown.
+func (s *StreamData) Gap() int {
+	return s.MissingBytes
+}
+
 // CaptureInfo returns the capture information from gopacket
 func (s *StreamData) CaptureInfo() gopacket.CaptureInfo {
 	return s.CaptureInformation
@@ -0,0 +1,137 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"math"
+	"time"
+
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+)
+
+const (
+	// number of recent intervals kept for each destination.
+	beaconIntervals = 16
+
+	// minimum number of intervals to a destination before it can be scored.
+	beaconMinIntervals = 5
+
+	// packets sent after a shorter pause belong to the same callback,
+	// so that continuous streams do not appear as regular callbacks.
+	beaconMinGap = time.Second
+
+	// maximum number of destinations tracked per address.
+	maxBeaconDestinations = 1024
+)
+
+// beaconTracker keeps the intervals between the callbacks to a destination in a ring buffer.
+type beaconTracker struct {
+	last      int64
+	intervals [beaconIntervals]int64
+	num       int
+}
+
+// add records a packet to the destination.
+func (b *beaconTracker) add(ts int64) {
+	if ts < b.last {
+		return
+	}
+
+	if b.last == 0 {
+		b.last = ts
+
+		return
+	}
+
+	gap := ts - b.last
+	b.last = ts
+
+	if gap < int64(beaconMinGap) {
+		return
+	}
+
+	b.intervals[b.num%beaconIntervals] = gap
+	b.num++
+}
+
+// score returns 1 minus the coefficient of variation of the recent intervals,
+// regular callbacks with a low jitter score close to 1.
+func (b *beaconTracker) score() float64 {
+	n := b.num
+	if n > beaconIntervals {
+		n = beaconIntervals
+	}
+
+	if n < beaconMinIntervals {
+		return 0
+	}
+
+	var sum, variance float64
+
+	for _, v := range b.intervals[:n] {
+		sum += float64(v)
+	}
+
+	mean := sum / float64(n)
+
+	for _, v := range b.intervals[:n] {
+		variance += math.Pow(float64(v)-mean, 2)
+	}
+
+	cv := math.Sqrt(variance/float64(n)) / mean
+	if cv >= 1 {
+		return 0
+	}
+
+	return 1 - cv
+}
+
+// trackBeacon records a packet sent by the profiled address for the beaconing detection.
+// The score is only computed when the profile is written, via beaconScore.
+func trackBeacon(p *ipProfile, i *decoderutils.PacketInfo) {
+	if connectionAttempt(i) == nil {
+		return
+	}
+
+	dst := decoderutils.NormalizeIP(i.DstIP)
+
+	b, ok := p.beacons[dst]
+	if !ok {
+		if len(p.beacons) >= maxBeaconDestinations {
+			return
+		}
+
+		if p.beacons == nil {
+			p.beacons = make(map[string]*beaconTracker)
+		}
+
+		b = &beaconTracker{}
+		p.beacons[dst] = b
+	}
+
+	b.add(i.Timestamp)
+}
+
+// beaconScore returns the highest score over all destinations of the profile.
+func (p *ipProfile) beaconScore() float64 {
+	var score float64
+
+	for _, b := range p.beacons {
+		if s := b.score(); s > score {
+			score = s
+		}
+	}
+
+	return score
+}
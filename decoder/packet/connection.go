@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"github.com/dreadl0ck/gopacket/layers"
 	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
 	"github.com/dreadl0ck/netcap/utils"
 	"log"
 	"strconv"
@@ -48,6 +49,15 @@ type connection struct {
 	*types.Connection
 	clientIP string
 
+	// flow hashes to look up information from the stream reassembly
+	id connectionID
+
+	// collects round trip time samples for TCP connections
+	rtt *rttTracker
+
+	// tracks the TCP control flags to determine the connection state
+	state connStateTracker
+
 	// to break the initialization loop when accessing the connectionDecoder variable within the connection processor
 	// we simply set a reference to it when passing connections to the workers.
 	decoder *Decoder
@@ -75,7 +85,12 @@ var connectionDecoder = newPacketDecoder(
 	types.Type_NC_Connection,
 	"Connection",
 	"A connection represents bi-directional network communication between two hosts based on the combined link-, network- and transport layer identifiers",
-	nil,
+	func(decoder *Decoder) error {
+		// collect information from the stream reassembly for the connection records
+		decoderutils.ConnectionInfos.Enable()
+
+		return nil
+	},
 	func(p gopacket.Packet) proto.Message {
 		return handlePacket(p)
 	},
@@ -93,6 +108,9 @@ var connectionDecoder = newPacketDecoder(
 		conns.Unlock()
 		cp.wg.Wait()
 
+		// all records have been written, information that has not been consumed is no longer needed
+		decoderutils.ConnectionInfos.Disable()
+
 		return nil
 	},
 )
@@ -115,114 +133,196 @@ func handlePacket(p gopacket.Packet) proto.Message {
 		connID.TransportFlowID = tl.TransportFlow().FastHash()
 	}
 
+	isUDP := tl != nil && tl.LayerType() == layers.LayerTypeUDP
+	if isUDP && conf.IgnoreUDPConnections {
+		return nil
+	}
+
+	expired := trackConnection(connID, p, isUDP)
+	if expired != nil {
+		finishConn(expired)
+
+		return expired.Connection
+	}
+
+	return nil
+}
+
+// trackConnection updates the connection the packet belongs to, or creates a new one.
+// An expired UDP pseudo connection for the same flow is returned, so that it can be written.
+// The locks are released via defer, so that a decoder panic recovered by the collector does not leave them held.
+func trackConnection(connID connectionID, p gopacket.Packet, isUDP bool) (expired *connection) {
 	// lookup connection
 	conns.Lock()
+	defer conns.Unlock()
+
+	conn, ok := conns.Items[connID.String()]
+	if ok && isUDP && udpConnExpired(conn, p.Metadata().Timestamp) {
+		// UDP has no connection teardown, the pseudo connection ends after being idle for the configured timeout.
+		// write the expired connection and start a new one for the current packet.
+		delete(conns.Items, connID.String())
+		expired = conn
+		ok = false
+	}
 
-	if conn, ok := conns.Items[connID.String()]; ok {
+	if ok {
+		updateConnection(conn, p)
 
-		conn.Lock()
+		return expired
+	}
 
-		// check if received packet from the same connection
-		// was captured BEFORE the connections FIRST seen timestamp
-		if p.Metadata().Timestamp.Before(time.Unix(0, conn.TimestampFirst).UTC()) {
+	conns.Items[connID.String()] = newConnection(connID, p, expired != nil)
 
-			// rewrite timestamp
-			conn.TimestampFirst = p.Metadata().Timestamp.UnixNano()
+	// TODO: add dedicated stats structure for decoder pkg
+	// conns := atomic.AddInt64(&stream.stats.numConns, 1)
 
-			// rewrite source and destination parameters
-			// since the first packet decides about the connection direction
-			if ll != nil {
-				conn.SrcMAC = ll.LinkFlow().Src().String()
-				conn.DstMAC = ll.LinkFlow().Dst().String()
-			}
+	// flush
+	//if conf.ConnFlushInterval != 0 && conns%int64(conf.ConnFlushInterval) == 0 {
+	//	cd.flushConns(p)
+	//}
 
-			if nl != nil {
-				conn.SrcIP = nl.NetworkFlow().Src().String()
-				conn.DstIP = nl.NetworkFlow().Dst().String()
-			}
+	return expired
+}
 
-			if tl != nil {
-				// TODO: change field type to int and use binary.LittleEndian.Uint16(...Src().Raw())
-				conn.SrcPort = tl.TransportFlow().Src().String()
-				conn.DstPort = tl.TransportFlow().Dst().String()
-			}
-		}
+// updateConnection applies the packet to an existing connection.
+func updateConnection(conn *connection, p gopacket.Packet) {
+	var (
+		ll = p.LinkLayer()
+		nl = p.NetworkLayer()
+		tl = p.TransportLayer()
+	)
 
-		// track amount of transferred bytes
-		if al := p.ApplicationLayer(); al != nil {
-			conn.AppPayloadSize += int32(len(al.LayerPayload()))
-		}
+	conn.Lock()
+	defer conn.Unlock()
 
-		if nl != nil {
-			if conn.clientIP == nl.NetworkFlow().Src().String() {
-				conn.BytesClientToServer += int64(p.Metadata().Length)
-			} else {
-				conn.BytesServerToClient += int64(p.Metadata().Length)
-			}
-		}
-		conn.NumPackets++
-		trackTCPStats(conn.Connection, p)
-		conn.TotalSize += int32(p.Metadata().Length)
-
-		// check if LAST timestamp was before the current packet
-		if conn.TimestampLast < p.Metadata().Timestamp.UnixNano() {
-			// current packet is newer
-			// update last seen timestamp
-			conn.TimestampLast = p.Metadata().Timestamp.UnixNano()
-
-			// the duration will be calculated once the connection is written to the audit record writer
-			// so there is no need to calculate it in real-time
-		} // else: do nothing, timestamp is still the oldest one
-
-		conn.Unlock()
-	} else { // create a new Connection
-		co := &types.Connection{}
-		co.UID = calcMd5(connID.String())
-		co.TimestampFirst = p.Metadata().Timestamp.UnixNano()
-		co.TimestampLast = p.Metadata().Timestamp.UnixNano()
-		co.TotalSize = int32(p.Metadata().Length)
-		co.NumPackets = 1
-		trackTCPStats(co, p)
+	// check if received packet from the same connection
+	// was captured BEFORE the connections FIRST seen timestamp
+	if p.Metadata().Timestamp.Before(time.Unix(0, conn.TimestampFirst).UTC()) {
 
+		// rewrite timestamp
+		conn.TimestampFirst = p.Metadata().Timestamp.UnixNano()
+
+		// rewrite source and destination parameters
+		// since the first packet decides about the connection direction
 		if ll != nil {
-			co.LinkProto = ll.LayerType().String()
-			co.SrcMAC = ll.LinkFlow().Src().String()
-			co.DstMAC = ll.LinkFlow().Dst().String()
+			conn.SrcMAC = ll.LinkFlow().Src().String()
+			conn.DstMAC = ll.LinkFlow().Dst().String()
 		}
+
 		if nl != nil {
-			co.NetworkProto = nl.LayerType().String()
-			co.SrcIP = nl.NetworkFlow().Src().String()
-			co.DstIP = nl.NetworkFlow().Dst().String()
+			conn.SrcIP = nl.NetworkFlow().Src().String()
+			conn.DstIP = nl.NetworkFlow().Dst().String()
 		}
+
 		if tl != nil {
-			co.TransportProto = tl.LayerType().String()
-			co.SrcPort = tl.TransportFlow().Src().String()
-			co.DstPort = tl.TransportFlow().Dst().String()
-		}
-		if al := p.ApplicationLayer(); al != nil {
-			co.ApplicationProto = al.LayerType().String()
-			co.AppPayloadSize = int32(len(al.LayerPayload()))
+			// TODO: change field type to int and use binary.LittleEndian.Uint16(...Src().Raw())
+			conn.SrcPort = tl.TransportFlow().Src().String()
+			conn.DstPort = tl.TransportFlow().Dst().String()
 		}
+	}
 
-		// track amount of transferred bytes
-		co.BytesClientToServer += int64(p.Metadata().Length)
+	// track amount of transferred bytes
+	if al := p.ApplicationLayer(); al != nil {
+		conn.AppPayloadSize += int32(len(al.LayerPayload()))
 
-		conns.Items[connID.String()] = &connection{
-			Connection: co,
-			clientIP:   co.SrcIP,
+		// the first packets of a connection might not carry a known application layer
+		if conn.ApplicationProto == "" || conn.ApplicationProto == gopacket.LayerTypePayload.String() {
+			conn.ApplicationProto = al.LayerType().String()
 		}
+	}
 
-		// TODO: add dedicated stats structure for decoder pkg
-		// conns := atomic.AddInt64(&stream.stats.numConns, 1)
+	dir := dirClientToServer
+	if nl != nil {
+		if conn.clientIP == nl.NetworkFlow().Src().String() {
+			conn.BytesClientToServer += int64(p.Metadata().Length)
+		} else {
+			conn.BytesServerToClient += int64(p.Metadata().Length)
+			dir = dirServerToClient
+		}
+	}
+	conn.NumPackets++
+	trackTCPStats(conn.Connection, p)
+	conn.rtt.trackRTT(conn.Connection, p, dir)
+	conn.state.trackState(p, dir)
+	conn.TotalSize += int32(p.Metadata().Length)
+
+	// check if LAST timestamp was before the current packet
+	if conn.TimestampLast < p.Metadata().Timestamp.UnixNano() {
+		// current packet is newer
+		// update last seen timestamp
+		conn.TimestampLast = p.Metadata().Timestamp.UnixNano()
+
+		// the duration will be calculated once the connection is written to the audit record writer
+		// so there is no need to calculate it in real-time
+	} // else: do nothing, timestamp is still the oldest one
+}
 
-		// flush
-		//if conf.ConnFlushInterval != 0 && conns%int64(conf.ConnFlushInterval) == 0 {
-		//	cd.flushConns(p)
-		//}
+// newConnection creates a connection for the first packet of a flow.
+// Subsequent UDP pseudo connections for the same 5-tuple get a distinct UID.
+func newConnection(connID connectionID, p gopacket.Packet, subsequent bool) *connection {
+	var (
+		ll = p.LinkLayer()
+		nl = p.NetworkLayer()
+		tl = p.TransportLayer()
+	)
+
+	co := &types.Connection{}
+	co.UID = calcMd5(connID.String())
+	co.TimestampFirst = p.Metadata().Timestamp.UnixNano()
+	co.TimestampLast = p.Metadata().Timestamp.UnixNano()
+	if subsequent {
+		// distinguish subsequent UDP pseudo connections for the same 5-tuple
+		co.UID = calcMd5(connID.String() + strconv.FormatInt(co.TimestampFirst, 10))
 	}
-	conns.Unlock()
+	co.TotalSize = int32(p.Metadata().Length)
+	co.NumPackets = 1
+	trackTCPStats(co, p)
 
-	return nil
+	if ll != nil {
+		co.LinkProto = ll.LayerType().String()
+		co.SrcMAC = ll.LinkFlow().Src().String()
+		co.DstMAC = ll.LinkFlow().Dst().String()
+	}
+	if nl != nil {
+		co.NetworkProto = nl.LayerType().String()
+		co.SrcIP = nl.NetworkFlow().Src().String()
+		co.DstIP = nl.NetworkFlow().Dst().String()
+	}
+	if tl != nil {
+		co.TransportProto = tl.LayerType().String()
+		co.SrcPort = tl.TransportFlow().Src().String()
+		co.DstPort = tl.TransportFlow().Dst().String()
+	}
+	if al := p.ApplicationLayer(); al != nil {
+		co.ApplicationProto = al.LayerType().String()
+		co.AppPayloadSize = int32(len(al.LayerPayload()))
+	}
+
+	// track amount of transferred bytes
+	co.BytesClientToServer += int64(p.Metadata().Length)
+
+	conn := &connection{
+		Connection: co,
+		clientIP:   co.SrcIP,
+		id:         connID,
+		rtt:        newRTTTracker(),
+	}
+	conn.rtt.trackRTT(co, p, dirClientToServer)
+	conn.state.trackState(p, dirClientToServer)
+
+	return conn
+}
+
+// udpConnExpired checks if the UDP pseudo connection has been idle for longer than the configured timeout.
+func udpConnExpired(c *connection, ts time.Time) bool {
+	if conf.UDPConnTimeout <= 0 {
+		return false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	return ts.Sub(time.Unix(0, c.TimestampLast)) > conf.UDPConnTimeout
 }
 
 func trackTCPStats(co *types.Connection, p gopacket.Packet) {
@@ -290,30 +390,84 @@ func movingAverage(current int32, newValue int32, n int32) int32 {
 }*/
 
 // writeConn writes the connection.
-func (d *Decoder) writeConn(conn *types.Connection, clientIP string) {
+func (d *Decoder) writeConn(c *connection) {
+	finishConn(c)
+
+	conn := c.Connection
+
+	if conf.ExportMetrics {
+		conn.Inc()
+	}
+
+	atomic.AddInt64(&d.NumRecordsWritten, 1)
+
+	err := d.Writer.Write(conn)
+	if err != nil {
+		log.Fatal("failed to write proto: ", err)
+	}
+}
+
+// finishConn calculates the summary fields of the connection before it is written.
+func finishConn(c *connection) {
+	conn := c.Connection
 
 	// calculate duration
 	conn.Duration = time.Unix(0, conn.TimestampLast).Sub(time.Unix(0, conn.TimestampFirst)).Nanoseconds()
 
 	// check if client IP for connection is still correct
-	if clientIP != conn.SrcIP {
+	swap := c.clientIP != conn.SrcIP
+	if swap {
 
 		// update client address
-		clientIP = conn.SrcIP
+		c.clientIP = conn.SrcIP
 
 		// swap num bytes tracked
 		conn.BytesClientToServer, conn.BytesServerToClient = conn.BytesServerToClient, conn.BytesClientToServer
 	}
 
-	if conf.ExportMetrics {
-		conn.Inc()
+	conn.ConnState = c.state.connState(swap)
+	conn.DstHostname = resolvedNames.lookup(conn.DstIP, conn.TimestampFirst)
+
+	// information from the stream reassembly
+	if info, ok := decoderutils.ConnectionInfos.Consume(c.id.NetworkFlowID, c.id.TransportFlowID); ok {
+		setConnectionInfo(conn, info)
 	}
 
-	atomic.AddInt64(&d.NumRecordsWritten, 1)
+	conn.SrcIP = decoderutils.NormalizeIP(conn.SrcIP)
+	conn.DstIP = decoderutils.NormalizeIP(conn.DstIP)
+	conn.SrcMAC = decoderutils.NormalizeMAC(conn.SrcMAC)
+	conn.DstMAC = decoderutils.NormalizeMAC(conn.DstMAC)
+}
 
-	err := d.Writer.Write(conn)
-	if err != nil {
-		log.Fatal("failed to write proto: ", err)
+// setConnectionInfo sets the information collected by the stream reassembly on the connection.
+func setConnectionInfo(conn *types.Connection, info decoderutils.ConnectionInfo) {
+	if conf.ConnGaps && info.Gaps != nil {
+		conn.NumGaps = info.Gaps.NumGaps
+		conn.GapBytes = info.Gaps.MissingBytes
+		conn.Completeness = info.Gaps.Completeness()
+	}
+
+	if conf.ConnEntropy && info.HasPayloadEntropy {
+		conn.PayloadEntropy = info.PayloadEntropy
+	}
+
+	if conf.ConnSNI {
+		conn.SNI = info.SNI
+	}
+
+	conn.CloseReason = info.CloseReason
+	conn.Incomplete = info.Incomplete
+
+	// original addresses of connections forwarded by a load balancer
+	if h := info.ProxyHeader; h != nil {
+		conn.ProxyProtocol = h.Version
+
+		if h.SrcIP != "" {
+			conn.OriginalSrcIP = h.SrcIP
+			conn.OriginalSrcPort = strconv.Itoa(int(h.SrcPort))
+			conn.OriginalDstIP = h.DstIP
+			conn.OriginalDstPort = strconv.Itoa(int(h.DstPort))
+		}
 	}
 }
 
@@ -365,7 +519,7 @@ func (cp *connectionProcessor) connectionWorker(wg *sync.WaitGroup) chan *connec
 				return
 			}
 
-			conn.decoder.writeConn(conn.Connection, conn.clientIP)
+			conn.decoder.writeConn(conn)
 
 			cp.Lock()
 			cp.numDone++
@@ -0,0 +1,173 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"encoding/binary"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// maxPendingRTTSamples limits the number of unanswered segments tracked per direction,
+// once reached the pending segments are discarded to bound the memory used per connection.
+const maxPendingRTTSamples = 1024
+
+const (
+	dirClientToServer = 0
+	dirServerToClient = 1
+)
+
+// rttTracker collects round trip time samples for a TCP connection.
+// Samples are taken from the TCP timestamp option (TSval / TSecr) if both sides use it,
+// otherwise the time between a data segment and the ACK that covers it is used.
+// RTTs are measured from the point of capture.
+type rttTracker struct {
+
+	// capture timestamps of the TSval values seen for each direction
+	tsVals [2]map[uint32]int64
+
+	// capture timestamps of data segments for each direction, keyed by the expected ACK number
+	pendingAcks [2]map[uint32]int64
+
+	// set once an echoed timestamp has been seen, disables the data / ACK fallback
+	useTimestamps bool
+
+	sum  int64
+	last int64
+}
+
+func newRTTTracker() *rttTracker {
+	return &rttTracker{
+		tsVals:      [2]map[uint32]int64{make(map[uint32]int64), make(map[uint32]int64)},
+		pendingAcks: [2]map[uint32]int64{make(map[uint32]int64), make(map[uint32]int64)},
+	}
+}
+
+// trackRTT updates the round trip time statistics of the connection with the given packet.
+// the direction must be dirClientToServer or dirServerToClient.
+func (r *rttTracker) trackRTT(co *types.Connection, p gopacket.Packet, dir int) {
+	t, ok := p.TransportLayer().(*layers.TCP)
+	if !ok {
+		return
+	}
+
+	var (
+		ts    = p.Metadata().Timestamp.UnixNano()
+		other = dir ^ 1
+	)
+
+	if tsVal, tsEcr, found := tcpTimestamps(t); found {
+
+		// the echoed timestamp answers a TSval that was sent by the other side
+		if sent, exists := r.tsVals[other][tsEcr]; exists && tsEcr != 0 {
+			r.useTimestamps = true
+			r.addSample(co, ts-sent)
+			delete(r.tsVals[other], tsEcr)
+		}
+
+		// multiple segments can carry the same TSval, only the first one is relevant
+		if _, exists := r.tsVals[dir][tsVal]; !exists {
+			if len(r.tsVals[dir]) >= maxPendingRTTSamples {
+				r.tsVals[dir] = make(map[uint32]int64)
+			}
+
+			r.tsVals[dir][tsVal] = ts
+		}
+	}
+
+	// if both sides use the timestamp option, there is no need for the fallback
+	if r.useTimestamps {
+		return
+	}
+
+	// fallback: match ACKs against the segments sent by the other side
+	if t.ACK {
+		if sent, exists := r.pendingAcks[other][t.Ack]; exists {
+			r.addSample(co, ts-sent)
+			delete(r.pendingAcks[other], t.Ack)
+		}
+	}
+
+	segLen := uint32(len(t.Payload))
+	if t.SYN || t.FIN {
+		segLen++
+	}
+
+	if segLen == 0 {
+		return
+	}
+
+	expectedAck := t.Seq + segLen
+	if _, exists := r.pendingAcks[dir][expectedAck]; exists {
+		// retransmission: the ACK would be ambiguous, so skip the sample (Karn's algorithm)
+		delete(r.pendingAcks[dir], expectedAck)
+
+		return
+	}
+
+	if len(r.pendingAcks[dir]) >= maxPendingRTTSamples {
+		r.pendingAcks[dir] = make(map[uint32]int64)
+	}
+
+	r.pendingAcks[dir][expectedAck] = ts
+}
+
+// addSample adds a round trip time sample and updates min, max, average and jitter of the connection.
+// The jitter is computed as in RFC 3550, as smoothed mean deviation between consecutive samples.
+func (r *rttTracker) addSample(co *types.Connection, rtt int64) {
+	// ignore out of order packets
+	if rtt < 0 {
+		return
+	}
+
+	co.NumRTTSamples++
+	r.sum += rtt
+
+	if co.NumRTTSamples == 1 {
+		co.RTTMin = rtt
+		co.RTTMax = rtt
+	} else {
+		if rtt < co.RTTMin {
+			co.RTTMin = rtt
+		}
+
+		if rtt > co.RTTMax {
+			co.RTTMax = rtt
+		}
+
+		d := rtt - r.last
+		if d < 0 {
+			d = -d
+		}
+
+		co.RTTJitter += (d - co.RTTJitter) / 16
+	}
+
+	co.RTTAvg = r.sum / int64(co.NumRTTSamples)
+	r.last = rtt
+}
+
+// tcpTimestamps returns the TSval and TSecr values from the TCP timestamp option, if present.
+func tcpTimestamps(t *layers.TCP) (tsVal, tsEcr uint32, found bool) {
+	for _, o := range t.Options {
+		if o.OptionType == layers.TCPOptionKindTimestamps && len(o.OptionData) == 8 {
+			return binary.BigEndian.Uint32(o.OptionData[:4]), binary.BigEndian.Uint32(o.OptionData[4:]), true
+		}
+	}
+
+	return 0, 0, false
+}
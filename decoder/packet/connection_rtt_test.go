@@ -0,0 +1,180 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// rttSegment is a TCP segment sent in the given direction after the offset.
+type rttSegment struct {
+	dir     int
+	tcp     layers.TCP
+	payload int
+	offset  time.Duration
+}
+
+func tcpTimestampOption(tsVal, tsEcr uint32) layers.TCPOption {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[:4], tsVal)
+	binary.BigEndian.PutUint32(data[4:], tsEcr)
+
+	return layers.TCPOption{
+		OptionType:   layers.TCPOptionKindTimestamps,
+		OptionLength: 10,
+		OptionData:   data,
+	}
+}
+
+func (s rttSegment) packet(t *testing.T, start time.Time) gopacket.Packet {
+	t.Helper()
+
+	var (
+		tcp = s.tcp
+		ip  = &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: layers.IPProtocolTCP,
+			SrcIP:    []byte{192, 168, 1, 1},
+			DstIP:    []byte{192, 168, 1, 2},
+		}
+	)
+
+	if s.dir == dirServerToClient {
+		ip.SrcIP, ip.DstIP = ip.DstIP, ip.SrcIP
+	}
+
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ip, &tcp, gopacket.Payload(make([]byte, s.payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+	p.Metadata().Timestamp = start.Add(s.offset)
+
+	return p
+}
+
+func TestTrackRTT(t *testing.T) {
+	ms := time.Millisecond
+
+	tests := []struct {
+		name     string
+		segments []rttSegment
+		samples  int32
+		min      time.Duration
+		max      time.Duration
+		avg      time.Duration
+		jitter   time.Duration
+	}{
+		{
+			name: "data and ack",
+			segments: []rttSegment{
+				{dirClientToServer, layers.TCP{Seq: 100, ACK: true, PSH: true}, 10, 0},
+				{dirServerToClient, layers.TCP{Ack: 110, ACK: true}, 0, 20 * ms},
+			},
+			samples: 1, min: 20 * ms, max: 20 * ms, avg: 20 * ms,
+		},
+		{
+			name: "handshake",
+			segments: []rttSegment{
+				{dirClientToServer, layers.TCP{Seq: 0, SYN: true}, 0, 0},
+				{dirServerToClient, layers.TCP{Seq: 500, Ack: 1, SYN: true, ACK: true}, 0, 10 * ms},
+				{dirClientToServer, layers.TCP{Seq: 1, Ack: 501, ACK: true}, 0, 15 * ms},
+			},
+			samples: 2, min: 5 * ms, max: 10 * ms, avg: 7500 * time.Microsecond, jitter: 5 * ms / 16,
+		},
+		{
+			name: "retransmission",
+			segments: []rttSegment{
+				{dirClientToServer, layers.TCP{Seq: 100, ACK: true, PSH: true}, 10, 0},
+				{dirClientToServer, layers.TCP{Seq: 100, ACK: true, PSH: true}, 10, 50 * ms},
+				{dirServerToClient, layers.TCP{Ack: 110, ACK: true}, 0, 60 * ms},
+			},
+			samples: 0,
+		},
+		{
+			name: "ack without data",
+			segments: []rttSegment{
+				{dirServerToClient, layers.TCP{Ack: 110, ACK: true}, 0, 0},
+				{dirClientToServer, layers.TCP{Seq: 100, ACK: true}, 0, 10 * ms},
+			},
+			samples: 0,
+		},
+		{
+			name: "timestamps",
+			segments: []rttSegment{
+				{dirClientToServer, layers.TCP{Seq: 0, SYN: true, Options: []layers.TCPOption{tcpTimestampOption(1000, 0)}}, 0, 0},
+				{dirServerToClient, layers.TCP{Seq: 500, Ack: 1, SYN: true, ACK: true, Options: []layers.TCPOption{tcpTimestampOption(5000, 1000)}}, 0, 30 * ms},
+				{dirClientToServer, layers.TCP{Seq: 1, Ack: 501, ACK: true, Options: []layers.TCPOption{tcpTimestampOption(1001, 5000)}}, 0, 32 * ms},
+				// the data / ACK fallback is disabled once echoed timestamps have been seen
+				{dirClientToServer, layers.TCP{Seq: 1, Ack: 501, ACK: true, PSH: true, Options: []layers.TCPOption{tcpTimestampOption(1001, 5000)}}, 10, 40 * ms},
+				{dirServerToClient, layers.TCP{Seq: 501, Ack: 11, ACK: true}, 0, 45 * ms},
+			},
+			samples: 2, min: 2 * ms, max: 30 * ms, avg: 16 * ms, jitter: 28 * ms / 16,
+		},
+	}
+
+	start := time.Unix(1600000000, 0)
+
+	for _, test := range tests {
+		var (
+			co = &types.Connection{}
+			r  = newRTTTracker()
+		)
+
+		for _, s := range test.segments {
+			r.trackRTT(co, s.packet(t, start), s.dir)
+		}
+
+		if co.NumRTTSamples != test.samples {
+			t.Fatal(test.name, ": expected", test.samples, "samples, got", co.NumRTTSamples)
+		}
+
+		if co.RTTMin != int64(test.min) || co.RTTMax != int64(test.max) || co.RTTAvg != int64(test.avg) || co.RTTJitter != int64(test.jitter) {
+			t.Fatal(test.name, ": unexpected statistics: min", co.RTTMin, "max", co.RTTMax, "avg", co.RTTAvg, "jitter", co.RTTJitter)
+		}
+	}
+}
+
+func TestTrackRTTPendingLimit(t *testing.T) {
+	var (
+		co    = &types.Connection{}
+		r     = newRTTTracker()
+		start = time.Unix(1600000000, 0)
+	)
+
+	// unanswered segments are discarded once the limit has been reached
+	for i := 0; i <= maxPendingRTTSamples; i++ {
+		s := rttSegment{dirClientToServer, layers.TCP{Seq: uint32(i * 10), ACK: true}, 10, time.Duration(i) * time.Millisecond}
+		r.trackRTT(co, s.packet(t, start), s.dir)
+	}
+
+	if n := len(r.pendingAcks[dirClientToServer]); n != 1 {
+		t.Fatal("expected the pending segments to be reset, got", n)
+	}
+}
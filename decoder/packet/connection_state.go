@@ -0,0 +1,165 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+)
+
+// Connection states, using the same codes as the conn_state field of the zeek conn.log.
+// UDP pseudo connections are either S0 (only the originator sent data) or SF (both directions seen).
+const (
+	// connection attempt seen, no reply
+	connStateS0 = "S0"
+	// connection established, not terminated
+	connStateS1 = "S1"
+	// normal establishment and termination
+	connStateSF = "SF"
+	// connection attempt rejected
+	connStateREJ = "REJ"
+	// connection established and close attempt by originator seen, but no reply from responder
+	connStateS2 = "S2"
+	// connection established and close attempt by responder seen, but no reply from originator
+	connStateS3 = "S3"
+	// connection established, originator aborted (sent a RST)
+	connStateRSTO = "RSTO"
+	// responder sent a RST
+	connStateRSTR = "RSTR"
+	// originator sent a SYN followed by a RST, no SYN-ACK from the responder was seen
+	connStateRSTOS0 = "RSTOS0"
+	// responder sent a SYN-ACK followed by a RST, no SYN from the originator was seen
+	connStateRSTRH = "RSTRH"
+	// originator sent a SYN followed by a FIN, no SYN-ACK from the responder was seen
+	connStateSH = "SH"
+	// responder sent a SYN-ACK followed by a FIN, no SYN from the originator was seen
+	connStateSHR = "SHR"
+	// no SYN seen, just midstream traffic
+	connStateOTH = "OTH"
+)
+
+// connStateFlags holds the control flags seen for one direction of a TCP connection.
+type connStateFlags struct {
+	seen   bool
+	syn    bool
+	synAck bool
+	fin    bool
+	rst    bool
+}
+
+// connStateTracker records the TCP control flags for both directions of a connection,
+// to determine whether a connection was closed normally, reset or just stopped without a FIN or RST.
+// For UDP only the directions that have been seen are recorded.
+type connStateTracker struct {
+	isTCP bool
+	isUDP bool
+	flags [2]connStateFlags
+}
+
+// trackState updates the control flags of the connection with the given packet.
+// the direction must be dirClientToServer or dirServerToClient.
+func (s *connStateTracker) trackState(p gopacket.Packet, dir int) {
+	if _, ok := p.TransportLayer().(*layers.UDP); ok {
+		s.isUDP = true
+		s.flags[dir].seen = true
+
+		return
+	}
+
+	t, ok := p.TransportLayer().(*layers.TCP)
+	if !ok {
+		return
+	}
+
+	s.isTCP = true
+
+	f := &s.flags[dir]
+	f.seen = true
+
+	if t.SYN {
+		if t.ACK {
+			f.synAck = true
+		} else {
+			f.syn = true
+		}
+	}
+
+	if t.FIN {
+		f.fin = true
+	}
+
+	if t.RST {
+		f.rst = true
+	}
+}
+
+// connState returns the state of the connection, or an empty string for connections that are neither TCP nor UDP.
+// If swap is set, the originator and responder have changed since tracking started.
+func (s *connStateTracker) connState(swap bool) string {
+	if !s.isTCP && !s.isUDP {
+		return ""
+	}
+
+	orig, resp := s.flags[dirClientToServer], s.flags[dirServerToClient]
+	if swap {
+		orig, resp = resp, orig
+	}
+
+	if s.isUDP {
+		if orig.seen && resp.seen {
+			return connStateSF
+		}
+
+		return connStateS0
+	}
+
+	switch {
+	case orig.syn && resp.synAck:
+		switch {
+		case orig.rst:
+			return connStateRSTO
+		case resp.rst:
+			return connStateRSTR
+		case orig.fin && resp.fin:
+			return connStateSF
+		case orig.fin:
+			return connStateS2
+		case resp.fin:
+			return connStateS3
+		default:
+			// neither FIN nor RST: the connection timed out or the capture ended
+			return connStateS1
+		}
+	case orig.syn && resp.rst:
+		return connStateREJ
+	case orig.syn && !resp.seen:
+		switch {
+		case orig.rst:
+			return connStateRSTOS0
+		case orig.fin:
+			return connStateSH
+		default:
+			return connStateS0
+		}
+	case !orig.syn && resp.synAck:
+		switch {
+		case resp.rst:
+			return connStateRSTRH
+		case resp.fin:
+			return connStateSHR
+		}
+	}
+
+	return connStateOTH
+}
@@ -0,0 +1,106 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+)
+
+func tcpPacket(t *testing.T, tcp *layers.TCP) gopacket.Packet {
+	t.Helper()
+
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    []byte{192, 168, 1, 1},
+		DstIP:    []byte{192, 168, 1, 2},
+	}
+
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ip, tcp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+}
+
+func TestConnState(t *testing.T) {
+	var (
+		syn    = &layers.TCP{SYN: true}
+		synAck = &layers.TCP{SYN: true, ACK: true}
+		ack    = &layers.TCP{ACK: true}
+		fin    = &layers.TCP{FIN: true, ACK: true}
+		rst    = &layers.TCP{RST: true}
+	)
+
+	type step struct {
+		tcp *layers.TCP
+		dir int
+	}
+
+	tests := []struct {
+		name     string
+		steps    []step
+		expected string
+	}{
+		{"no reply", []step{{syn, dirClientToServer}}, connStateS0},
+		{"established", []step{{syn, dirClientToServer}, {synAck, dirServerToClient}, {ack, dirClientToServer}}, connStateS1},
+		{"normal close", []step{{syn, dirClientToServer}, {synAck, dirServerToClient}, {fin, dirClientToServer}, {fin, dirServerToClient}}, connStateSF},
+		{"half close", []step{{syn, dirClientToServer}, {synAck, dirServerToClient}, {fin, dirClientToServer}}, connStateS2},
+		{"rejected", []step{{syn, dirClientToServer}, {rst, dirServerToClient}}, connStateREJ},
+		{"responder reset", []step{{syn, dirClientToServer}, {synAck, dirServerToClient}, {rst, dirServerToClient}}, connStateRSTR},
+		{"midstream", []step{{ack, dirClientToServer}, {ack, dirServerToClient}}, connStateOTH},
+	}
+
+	for _, test := range tests {
+		s := &connStateTracker{}
+		for _, st := range test.steps {
+			s.trackState(tcpPacket(t, st.tcp), st.dir)
+		}
+
+		if state := s.connState(false); state != test.expected {
+			t.Fatal(test.name, ": got", state, "expected", test.expected)
+		}
+	}
+
+	// originator and responder swapped
+	s := &connStateTracker{}
+	s.trackState(tcpPacket(t, synAck), dirClientToServer)
+	s.trackState(tcpPacket(t, syn), dirServerToClient)
+
+	if state := s.connState(true); state != connStateS1 {
+		t.Fatal("swapped: got", state, "expected", connStateS1)
+	}
+}
+
+func TestConnStateUDP(t *testing.T) {
+	buf := gopacket.NewSerializeBuffer()
+
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, &layers.UDP{SrcPort: 53000, DstPort: 53})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeUDP, gopacket.Default)
+
+	s := &connStateTracker{}
+	s.trackState(p, dirClientToServer)
+
+	if state := s.connState(false); state != connStateS0 {
+		t.Fatal("request only: got", state, "expected", connStateS0)
+	}
+
+	s.trackState(p, dirServerToClient)
+
+	if state := s.connState(false); state != connStateSF {
+		t.Fatal("request and reply: got", state, "expected", connStateSF)
+	}
+}
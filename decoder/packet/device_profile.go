@@ -86,13 +86,14 @@ var (
 func updateDeviceProfile(i *decoderutils.PacketInfo) {
 	// lookup profile
 	DeviceProfiles.Lock()
+	defer DeviceProfiles.Unlock()
+
 	if p, ok := DeviceProfiles.Items[i.SrcMAC]; ok {
 		applyDeviceProfileUpdate(p, i)
 	} else {
 		DeviceProfiles.Items[i.SrcMAC] = newDeviceProfile(i)
 		deviceProfiles++
 	}
-	DeviceProfiles.Unlock()
 }
 
 // newDeviceProfile creates a new device specific profile.
@@ -109,7 +110,7 @@ func newDeviceProfile(i *decoderutils.PacketInfo) *deviceProfile {
 
 	return &deviceProfile{
 		DeviceProfile: &types.DeviceProfile{
-			MacAddr:            i.SrcMAC,
+			MacAddr:            decoderutils.NormalizeMAC(i.SrcMAC),
 			DeviceManufacturer: resolvers.LookupManufacturer(i.SrcMAC),
 			DeviceIPs:          devices,
 			Contacts:           contacts,
@@ -122,14 +123,21 @@ func newDeviceProfile(i *decoderutils.PacketInfo) *deviceProfile {
 
 func applyDeviceProfileUpdate(p *deviceProfile, i *decoderutils.PacketInfo) {
 	p.Lock()
+	defer p.Unlock()
+
+	// the addresses are stored in their normalized form
+	var (
+		srcIP = decoderutils.NormalizeIP(i.SrcIP)
+		dstIP = decoderutils.NormalizeIP(i.DstIP)
+	)
 
 	// deviceIPs
 	var found bool
 
 	for _, addr := range p.DeviceIPs {
-		if addr == i.SrcIP {
+		if addr == srcIP {
 			// update existing ip profile
-			_ = getIPProfile(i.SrcIP, i, true).IPProfile
+			getIPProfile(i.SrcIP, i, true)
 			found = true
 		}
 	}
@@ -148,9 +156,9 @@ func applyDeviceProfileUpdate(p *deviceProfile, i *decoderutils.PacketInfo) {
 	found = false
 
 	for _, addr := range p.Contacts {
-		if addr == i.DstIP {
+		if addr == dstIP {
 			// update existing ip profile
-			_ = getIPProfile(i.DstIP, i, false).IPProfile
+			getIPProfile(i.DstIP, i, false)
 			found = true
 		}
 	}
@@ -168,7 +176,6 @@ func applyDeviceProfileUpdate(p *deviceProfile, i *decoderutils.PacketInfo) {
 
 	p.Bytes += uint64(len(i.Packet.Data()))
 	p.NumPackets++
-	p.Unlock()
 }
 
 var deviceProfileDecoder = newPacketDecoder(
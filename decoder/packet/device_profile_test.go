@@ -0,0 +1,92 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+)
+
+func newIPv6TestPacket(t *testing.T, src, dst string, ts time.Time) *decoderutils.PacketInfo {
+	t.Helper()
+
+	var (
+		buf = gopacket.NewSerializeBuffer()
+		eth = &layers.Ethernet{
+			SrcMAC:       net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+			DstMAC:       net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x66},
+			EthernetType: layers.EthernetTypeIPv6,
+		}
+		ip = &layers.IPv6{
+			Version:    6,
+			HopLimit:   64,
+			NextHeader: layers.IPProtocolUDP,
+			SrcIP:      net.ParseIP(src),
+			DstIP:      net.ParseIP(dst),
+		}
+		udp = &layers.UDP{SrcPort: 40000, DstPort: 9999}
+	)
+
+	if err := udp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, eth, ip, udp, gopacket.Payload(make([]byte, 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	p.Metadata().Timestamp = ts
+
+	return decoderutils.NewPacketInfo(p)
+}
+
+func TestDeviceProfileExpandedIPv6(t *testing.T) {
+	defer func(c *decoderconfig.Config) {
+		decoderconfig.Instance = c
+	}(decoderconfig.Instance)
+
+	decoderconfig.Instance = &decoderconfig.Config{NormalizeAddresses: true, ExpandIPv6: true}
+
+	var (
+		start = time.Unix(1600000000, 0)
+		src   = "2001:db8::1"
+		dst   = "2001:db8::2"
+		p     = newDeviceProfile(newIPv6TestPacket(t, src, dst, start))
+	)
+
+	for n := 1; n <= 3; n++ {
+		applyDeviceProfileUpdate(p, newIPv6TestPacket(t, src, dst, start.Add(time.Duration(n)*time.Second)))
+	}
+
+	if len(p.DeviceIPs) != 1 || p.DeviceIPs[0] != "2001:0db8:0000:0000:0000:0000:0000:0001" {
+		t.Fatal("expected a single expanded device ip, got", p.DeviceIPs)
+	}
+
+	if len(p.Contacts) != 1 || p.Contacts[0] != "2001:0db8:0000:0000:0000:0000:0000:0002" {
+		t.Fatal("expected a single expanded contact, got", p.Contacts)
+	}
+
+	if p.NumPackets != 4 {
+		t.Fatal("expected 4 packets, got", p.NumPackets)
+	}
+}
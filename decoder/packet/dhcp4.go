@@ -14,6 +14,7 @@
 package packet
 
 import (
+	"net"
 	"strconv"
 	"strings"
 
@@ -48,6 +49,8 @@ var dhcpv4Decoder = newGoPacketDecoder(
 				}
 			}
 
+			msgType, requestedIP, hostname := dhcpv4OptionValues(dhcp4.Options)
+
 			return &types.DHCPv4{
 				Timestamp:    timestamp,
 				Operation:    int32(dhcp4.Operation),
@@ -66,9 +69,33 @@ var dhcpv4Decoder = newGoPacketDecoder(
 				File:         dhcp4.File,
 				Options:      opts,
 				Fingerprint:  fp.String(),
+				MessageType:  msgType,
+				RequestedIP:  requestedIP,
+				Hostname:     hostname,
 			}
 		}
 
 		return nil
 	},
 )
+
+// dhcpv4OptionValues extracts the message type, the address requested by the client and its host name from the options.
+// Options with an invalid length are ignored.
+func dhcpv4OptionValues(opts layers.DHCPOptions) (msgType, requestedIP, hostname string) {
+	for _, o := range opts {
+		switch o.Type {
+		case layers.DHCPOptMessageType:
+			if len(o.Data) == 1 {
+				msgType = layers.DHCPMsgType(o.Data[0]).String()
+			}
+		case layers.DHCPOptRequestIP:
+			if len(o.Data) == net.IPv4len {
+				requestedIP = net.IP(o.Data).String()
+			}
+		case layers.DHCPOptHostname:
+			hostname = strings.TrimRight(string(o.Data), "\x00")
+		}
+	}
+
+	return msgType, requestedIP, hostname
+}
@@ -0,0 +1,50 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/gopacket/layers"
+)
+
+func TestDHCPv4OptionValues(t *testing.T) {
+	opts := layers.DHCPOptions{
+		layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(layers.DHCPMsgTypeRequest)}),
+		layers.NewDHCPOption(layers.DHCPOptRequestIP, []byte{192, 168, 1, 23}),
+		layers.NewDHCPOption(layers.DHCPOptHostname, []byte("workstation\x00")),
+	}
+
+	msgType, requestedIP, hostname := dhcpv4OptionValues(opts)
+	if msgType != "Request" || requestedIP != "192.168.1.23" || hostname != "workstation" {
+		t.Fatal("unexpected values:", msgType, requestedIP, hostname)
+	}
+
+	// options with an invalid length must be ignored
+	malformed := layers.DHCPOptions{
+		layers.NewDHCPOption(layers.DHCPOptMessageType, nil),
+		layers.NewDHCPOption(layers.DHCPOptRequestIP, []byte{192, 168}),
+	}
+
+	msgType, requestedIP, hostname = dhcpv4OptionValues(malformed)
+	if msgType != "" || requestedIP != "" || hostname != "" {
+		t.Fatal("unexpected values for malformed options:", msgType, requestedIP, hostname)
+	}
+}
+
+func TestNTPReferenceSource(t *testing.T) {
+	tests := []struct {
+		stratum  layers.NTPStratum
+		id       layers.NTPReferenceID
+		expected string
+	}{
+		{1, 0x47505300, "GPS"},
+		{0, 0x52415445, "RATE"},
+		{2, 0xc0a80101, "192.168.1.1"},
+		{1, 0x01020304, ""},
+	}
+
+	for _, tt := range tests {
+		if res := ntpReferenceSource(tt.stratum, tt.id); res != tt.expected {
+			t.Errorf("ntpReferenceSource(%d, %x) = %q, expected %q", tt.stratum, tt.id, res, tt.expected)
+		}
+	}
+}
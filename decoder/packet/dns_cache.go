@@ -0,0 +1,237 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/dreadl0ck/gopacket/layers"
+)
+
+const (
+	// answers with a shorter TTL are kept for at least this duration,
+	// clients often connect shortly after a record with a TTL of a few seconds expired.
+	dnsCacheMinTTL = time.Minute
+
+	// limits the number of names remembered for a single address, e.g. for shared hosting and CDNs
+	dnsCacheMaxNamesPerIP = 16
+
+	// limits the number of addresses, the least recently resolved addresses are evicted first
+	dnsCacheMaxAddresses = 100000
+
+	// answers are kept for this duration after their TTL expired,
+	// since connection audit records are written after the connection ended.
+	dnsCacheRetention = time.Hour
+)
+
+// dnsCacheEntry is a name that resolved to an address, valid until the TTL of the answer expires.
+type dnsCacheEntry struct {
+	name    string
+	seen    int64
+	expires int64
+}
+
+// dnsCacheAddress contains the names that resolved to an address.
+type dnsCacheAddress struct {
+	ip      string
+	entries []dnsCacheEntry
+}
+
+// dnsCache remembers A and AAAA answers from the DNS decoder,
+// to annotate connections with the hostname that was resolved before connecting to the address.
+// The cache is bounded by the number of addresses and drops answers that expired longer than dnsCacheRetention ago.
+type dnsCache struct {
+	sync.Mutex
+	items map[string]*list.Element
+
+	// addresses ordered by the time they have been resolved, the most recent at the front
+	lru          *list.List
+	maxAddresses int
+
+	// timestamp of the answer that triggered the last removal of expired answers
+	lastSweep int64
+}
+
+// newDNSCache returns a dnsCache that keeps at most maxAddresses addresses.
+func newDNSCache(maxAddresses int) *dnsCache {
+	return &dnsCache{
+		items:        make(map[string]*list.Element),
+		lru:          list.New(),
+		maxAddresses: maxAddresses,
+	}
+}
+
+// resolvedNames is populated by the DNS decoder and consulted when writing connection audit records.
+var resolvedNames = newDNSCache(dnsCacheMaxAddresses)
+
+// addAnswers stores the addresses from the A and AAAA answers of a DNS response.
+// The name queried by the client is used, so that for CNAME chains the initially requested name is preserved.
+func (c *dnsCache) addAnswers(dns *layers.DNS, timestamp int64) {
+	if !dns.QR || dns.ResponseCode != layers.DNSResponseCodeNoErr {
+		return
+	}
+
+	var query string
+	if len(dns.Questions) > 0 {
+		query = string(dns.Questions[0].Name)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.sweep(timestamp)
+
+	for _, a := range dns.Answers {
+		if a.Type != layers.DNSTypeA && a.Type != layers.DNSTypeAAAA || a.IP == nil {
+			continue
+		}
+
+		name := query
+		if name == "" {
+			name = string(a.Name)
+		}
+
+		ttl := time.Duration(a.TTL) * time.Second
+		if ttl < dnsCacheMinTTL {
+			ttl = dnsCacheMinTTL
+		}
+
+		c.add(a.IP.String(), dnsCacheEntry{
+			name:    name,
+			seen:    timestamp,
+			expires: timestamp + ttl.Nanoseconds(),
+		})
+	}
+}
+
+// add stores the entry for the ip, the caller must hold the lock.
+func (c *dnsCache) add(ip string, e dnsCacheEntry) {
+	elem, ok := c.items[ip]
+	if ok {
+		c.lru.MoveToFront(elem)
+	} else {
+		elem = c.lru.PushFront(&dnsCacheAddress{ip: ip})
+		c.items[ip] = elem
+
+		if c.lru.Len() > c.maxAddresses {
+			c.remove(c.lru.Back())
+		}
+	}
+
+	var (
+		addr    = elem.Value.(*dnsCacheAddress)
+		entries = addr.entries
+	)
+
+	// refresh the entry if the name is already known,
+	// the time the name has been resolved first is kept, so connections started in between still match
+	for i, existing := range entries {
+		if existing.name == e.name {
+			if e.expires > existing.expires {
+				entries[i].expires = e.expires
+			}
+
+			return
+		}
+	}
+
+	if len(entries) >= dnsCacheMaxNamesPerIP {
+		// evict the entry that expires first
+		oldest := 0
+		for i, existing := range entries {
+			if existing.expires < entries[oldest].expires {
+				oldest = i
+			}
+		}
+
+		entries = append(entries[:oldest], entries[oldest+1:]...)
+	}
+
+	addr.entries = append(entries, e)
+}
+
+// remove deletes the address from the cache, the caller must hold the lock.
+func (c *dnsCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.items, elem.Value.(*dnsCacheAddress).ip)
+}
+
+// sweep removes the answers that expired longer than dnsCacheRetention before the given time,
+// at most once per retention interval. The caller must hold the lock.
+func (c *dnsCache) sweep(timestamp int64) {
+	retention := dnsCacheRetention.Nanoseconds()
+	if timestamp-c.lastSweep < retention {
+		return
+	}
+
+	c.lastSweep = timestamp
+
+	for elem := c.lru.Front(); elem != nil; {
+		var (
+			next = elem.Next()
+			addr = elem.Value.(*dnsCacheAddress)
+			keep = addr.entries[:0]
+		)
+
+		for _, e := range addr.entries {
+			if e.expires+retention >= timestamp {
+				keep = append(keep, e)
+			}
+		}
+
+		addr.entries = keep
+		if len(keep) == 0 {
+			c.remove(elem)
+		}
+
+		elem = next
+	}
+}
+
+// lookup returns the most recently resolved name for the ip that was valid at the given time,
+// or an empty string if no matching DNS answer was seen.
+// Since connection audit records are written after processing finished, DNS answers that were decoded after
+// the first packet of the connection are considered as well, as long as the answer was captured before the connection started.
+func (c *dnsCache) lookup(ip string, timestamp int64) string {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, ok := c.items[ip]
+	if !ok {
+		return ""
+	}
+
+	var (
+		match   *dnsCacheEntry
+		entries = elem.Value.(*dnsCacheAddress).entries
+	)
+
+	for i, e := range entries {
+		if e.seen > timestamp || e.expires < timestamp {
+			continue
+		}
+
+		if match == nil || e.seen > match.seen {
+			match = &entries[i]
+		}
+	}
+
+	if match == nil {
+		return ""
+	}
+
+	return match.name
+}
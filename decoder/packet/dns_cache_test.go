@@ -0,0 +1,165 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket/layers"
+)
+
+func TestDNSCacheLookup(t *testing.T) {
+	var (
+		c     = newDNSCache(dnsCacheMaxAddresses)
+		start = time.Unix(1600000000, 0).UnixNano()
+		ip    = net.ParseIP("1.2.3.4")
+	)
+
+	c.addAnswers(&layers.DNS{
+		QR: true,
+		Questions: []layers.DNSQuestion{
+			{Name: []byte("evil.com"), Type: layers.DNSTypeA},
+		},
+		Answers: []layers.DNSResourceRecord{
+			{Name: []byte("evil.com"), Type: layers.DNSTypeCNAME, CNAME: []byte("cdn.example.net"), TTL: 300},
+			{Name: []byte("cdn.example.net"), Type: layers.DNSTypeA, IP: ip, TTL: 300},
+		},
+	}, start)
+
+	// queries are ignored
+	c.addAnswers(&layers.DNS{
+		Questions: []layers.DNSQuestion{
+			{Name: []byte("other.com"), Type: layers.DNSTypeA},
+		},
+	}, start)
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		expected  string
+	}{
+		{"before answer", start - int64(time.Second), ""},
+		{"within ttl", start + int64(time.Minute), "evil.com"},
+		{"expired", start + int64(10*time.Minute), ""},
+	}
+
+	for _, test := range tests {
+		if name := c.lookup(ip.String(), test.timestamp); name != test.expected {
+			t.Fatal(test.name, ": expected ", test.expected, " got: ", name)
+		}
+	}
+
+	// a newer answer for another name takes precedence
+	c.addAnswers(&layers.DNS{
+		QR: true,
+		Questions: []layers.DNSQuestion{
+			{Name: []byte("good.com"), Type: layers.DNSTypeA},
+		},
+		Answers: []layers.DNSResourceRecord{
+			{Name: []byte("good.com"), Type: layers.DNSTypeA, IP: ip, TTL: 1},
+		},
+	}, start+int64(2*time.Minute))
+
+	if name := c.lookup(ip.String(), start+int64(2*time.Minute+time.Second)); name != "good.com" {
+		t.Fatal("expected good.com, got: ", name)
+	}
+
+	if name := c.lookup(ip.String(), start+int64(time.Minute)); name != "evil.com" {
+		t.Fatal("expected evil.com, got: ", name)
+	}
+}
+
+func TestDNSCacheRefresh(t *testing.T) {
+	var (
+		c     = newDNSCache(dnsCacheMaxAddresses)
+		start = time.Unix(1600000000, 0).UnixNano()
+		ip    = net.ParseIP("5.6.7.8")
+	)
+
+	answer := func(ts int64) {
+		c.addAnswers(&layers.DNS{
+			QR: true,
+			Questions: []layers.DNSQuestion{
+				{Name: []byte("example.com"), Type: layers.DNSTypeA},
+			},
+			Answers: []layers.DNSResourceRecord{
+				{Name: []byte("example.com"), Type: layers.DNSTypeA, IP: ip, TTL: 60},
+			},
+		}, ts)
+	}
+
+	answer(start)
+
+	// the connection starts, then the client resolves the name again
+	connStart := start + int64(10*time.Second)
+	answer(start + int64(30*time.Second))
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		expected  string
+	}{
+		{"connection start", connStart, "example.com"},
+		{"extended ttl", start + int64(80*time.Second), "example.com"},
+		{"expired", start + int64(2*time.Minute), ""},
+	}
+
+	for _, test := range tests {
+		if name := c.lookup(ip.String(), test.timestamp); name != test.expected {
+			t.Fatal(test.name, ": expected ", test.expected, " got: ", name)
+		}
+	}
+}
+
+func TestDNSCacheBounds(t *testing.T) {
+	var (
+		c     = newDNSCache(2)
+		start = time.Unix(1600000000, 0).UnixNano()
+	)
+
+	answer := func(name, ip string, ts int64) {
+		c.addAnswers(&layers.DNS{
+			QR: true,
+			Questions: []layers.DNSQuestion{
+				{Name: []byte(name), Type: layers.DNSTypeA},
+			},
+			Answers: []layers.DNSResourceRecord{
+				{Name: []byte(name), Type: layers.DNSTypeA, IP: net.ParseIP(ip), TTL: 60},
+			},
+		}, ts)
+	}
+
+	answer("a.com", "1.1.1.1", start)
+	answer("b.com", "2.2.2.2", start)
+	answer("a.com", "1.1.1.1", start+int64(time.Second))
+	answer("c.com", "3.3.3.3", start+int64(2*time.Second))
+
+	// the least recently resolved address has been evicted
+	if len(c.items) != 2 || c.lookup("2.2.2.2", start+int64(3*time.Second)) != "" {
+		t.Fatal("expected 2.2.2.2 to be evicted, got", len(c.items), "addresses")
+	}
+
+	if c.lookup("1.1.1.1", start+int64(3*time.Second)) != "a.com" {
+		t.Fatal("expected a.com to be kept")
+	}
+
+	// answers that expired longer than the retention period ago are removed
+	answer("d.com", "4.4.4.4", start+int64(2*dnsCacheRetention))
+
+	if len(c.items) != 1 || c.lru.Len() != 1 {
+		t.Fatal("expected expired answers to be removed, got", len(c.items), "addresses")
+	}
+}
@@ -135,9 +135,104 @@ var dot11Decoder = newGoPacketDecoder(
 				Checksum:       dot11.Checksum,
 				QOS:            qos,
 				HTControl:      htcontrol,
+				SSID:           dot11SSID(dot11),
+				BSSID:          dot11BSSID(dot11),
+				StationMAC:     dot11StationMAC(dot11),
 			}
 		}
 
 		return nil
 	},
 )
+
+// dot11SSID extracts the SSID information element from beacons, probe requests and responses and (re)association requests.
+// Probe requests can leak the SSIDs of networks a client has been connected to.
+func dot11SSID(dot11 *layers.Dot11) string {
+	var offset int
+
+	// skip the fixed parameters of the management frame body
+	switch dot11.Type {
+	case layers.Dot11TypeMgmtBeacon, layers.Dot11TypeMgmtProbeResp:
+		// timestamp, beacon interval and capability info
+		offset = 12
+	case layers.Dot11TypeMgmtProbeReq:
+		offset = 0
+	case layers.Dot11TypeMgmtAssociationReq:
+		// capability info and listen interval
+		offset = 4
+	case layers.Dot11TypeMgmtReassociationReq:
+		// capability info, listen interval and current AP address
+		offset = 10
+	default:
+		return ""
+	}
+
+	data := dot11.LayerPayload()
+
+	// walk the information elements: 1 byte id, 1 byte length, data
+	for offset+2 <= len(data) {
+		var (
+			id     = layers.Dot11InformationElementID(data[offset])
+			length = int(data[offset+1])
+		)
+
+		offset += 2
+		if offset+length > len(data) {
+			break
+		}
+
+		if id == layers.Dot11InformationElementIDSSID {
+			return string(data[offset : offset+length])
+		}
+
+		offset += length
+	}
+
+	return ""
+}
+
+// dot11BSSID returns the BSSID for management and data frames, based on the distribution system flags.
+func dot11BSSID(dot11 *layers.Dot11) string {
+	switch dot11.Type.MainType() {
+	case layers.Dot11TypeMgmt, layers.Dot11TypeData:
+	default:
+		return ""
+	}
+
+	switch {
+	case dot11.Flags.ToDS() && dot11.Flags.FromDS():
+		// wireless distribution system, no BSSID
+		return ""
+	case dot11.Flags.ToDS():
+		return dot11.Address1.String()
+	case dot11.Flags.FromDS():
+		return dot11.Address2.String()
+	default:
+		return dot11.Address3.String()
+	}
+}
+
+// dot11StationMAC returns the address of the client station, if the frame was sent by or to a client.
+func dot11StationMAC(dot11 *layers.Dot11) string {
+	switch dot11.Type {
+	case layers.Dot11TypeMgmtProbeReq, layers.Dot11TypeMgmtAssociationReq, layers.Dot11TypeMgmtReassociationReq:
+		return dot11.Address2.String()
+	case layers.Dot11TypeMgmtProbeResp, layers.Dot11TypeMgmtAssociationResp, layers.Dot11TypeMgmtReassociationResp:
+		return dot11.Address1.String()
+	}
+
+	if dot11.Type.MainType() != layers.Dot11TypeData {
+		return ""
+	}
+
+	switch {
+	case dot11.Flags.ToDS() && dot11.Flags.FromDS():
+		return ""
+	case dot11.Flags.ToDS():
+		return dot11.Address2.String()
+	case dot11.Flags.FromDS():
+		return dot11.Address1.String()
+	}
+
+	return ""
+}
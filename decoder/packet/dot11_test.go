@@ -0,0 +1,166 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"net"
+	"testing"
+
+	"github.com/dreadl0ck/gopacket/layers"
+)
+
+// infoElement returns an information element with the given id and data.
+func infoElement(id layers.Dot11InformationElementID, data string) []byte {
+	return append([]byte{byte(id), byte(len(data))}, data...)
+}
+
+func TestDot11Addresses(t *testing.T) {
+	var (
+		client = net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+		ap     = net.HardwareAddr{0x00, 0xaa, 0xbb, 0xcc, 0xdd, 0xee}
+		other  = net.HardwareAddr{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}
+		bcast  = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+		// fixed parameters of beacons and probe responses: timestamp, beacon interval and capability info
+		beaconFixed = make([]byte, 12)
+	)
+
+	tests := []struct {
+		name    string
+		dot11   *layers.Dot11
+		ssid    string
+		bssid   string
+		station string
+	}{
+		{
+			name: "beacon",
+			dot11: &layers.Dot11{
+				Type:      layers.Dot11TypeMgmtBeacon,
+				Address1:  bcast,
+				Address2:  ap,
+				Address3:  ap,
+				BaseLayer: layers.BaseLayer{Payload: append(append([]byte{}, beaconFixed...), infoElement(layers.Dot11InformationElementIDSSID, "home")...)},
+			},
+			ssid:  "home",
+			bssid: ap.String(),
+		},
+		{
+			name: "probe request",
+			dot11: &layers.Dot11{
+				Type:     layers.Dot11TypeMgmtProbeReq,
+				Address1: bcast,
+				Address2: client,
+				Address3: bcast,
+				// the SSID follows another element
+				BaseLayer: layers.BaseLayer{Payload: append(infoElement(layers.Dot11InformationElementIDRates, "\x02\x04"), infoElement(layers.Dot11InformationElementIDSSID, "office")...)},
+			},
+			ssid:    "office",
+			bssid:   bcast.String(),
+			station: client.String(),
+		},
+		{
+			name: "probe response",
+			dot11: &layers.Dot11{
+				Type:      layers.Dot11TypeMgmtProbeResp,
+				Address1:  client,
+				Address2:  ap,
+				Address3:  ap,
+				BaseLayer: layers.BaseLayer{Payload: append(append([]byte{}, beaconFixed...), infoElement(layers.Dot11InformationElementIDSSID, "home")...)},
+			},
+			ssid:    "home",
+			bssid:   ap.String(),
+			station: client.String(),
+		},
+		{
+			name: "association request",
+			dot11: &layers.Dot11{
+				Type:      layers.Dot11TypeMgmtAssociationReq,
+				Address1:  ap,
+				Address2:  client,
+				Address3:  ap,
+				BaseLayer: layers.BaseLayer{Payload: append(make([]byte, 4), infoElement(layers.Dot11InformationElementIDSSID, "home")...)},
+			},
+			ssid:    "home",
+			bssid:   ap.String(),
+			station: client.String(),
+		},
+		{
+			name: "truncated element",
+			dot11: &layers.Dot11{
+				Type:      layers.Dot11TypeMgmtProbeReq,
+				Address1:  bcast,
+				Address2:  client,
+				Address3:  bcast,
+				BaseLayer: layers.BaseLayer{Payload: []byte{byte(layers.Dot11InformationElementIDSSID), 10, 'a'}},
+			},
+			bssid:   bcast.String(),
+			station: client.String(),
+		},
+		{
+			name: "data to ds",
+			dot11: &layers.Dot11{
+				Type:     layers.Dot11TypeData,
+				Flags:    layers.Dot11FlagsToDS,
+				Address1: ap,
+				Address2: client,
+				Address3: other,
+			},
+			bssid:   ap.String(),
+			station: client.String(),
+		},
+		{
+			name: "data from ds",
+			dot11: &layers.Dot11{
+				Type:     layers.Dot11TypeData,
+				Flags:    layers.Dot11FlagsFromDS,
+				Address1: client,
+				Address2: ap,
+				Address3: other,
+			},
+			bssid:   ap.String(),
+			station: client.String(),
+		},
+		{
+			name: "wireless distribution system",
+			dot11: &layers.Dot11{
+				Type:     layers.Dot11TypeData,
+				Flags:    layers.Dot11FlagsToDS | layers.Dot11FlagsFromDS,
+				Address1: ap,
+				Address2: other,
+				Address3: client,
+			},
+		},
+		{
+			name: "control frame",
+			dot11: &layers.Dot11{
+				Type:     layers.Dot11TypeCtrlAck,
+				Address1: client,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if ssid := dot11SSID(test.dot11); ssid != test.ssid {
+			t.Fatal(test.name, ": expected SSID", test.ssid, "got", ssid)
+		}
+
+		if bssid := dot11BSSID(test.dot11); bssid != test.bssid {
+			t.Fatal(test.name, ": expected BSSID", test.bssid, "got", bssid)
+		}
+
+		if station := dot11StationMAC(test.dot11); station != test.station {
+			t.Fatal(test.name, ": expected station", test.station, "got", station)
+		}
+	}
+}
@@ -172,33 +172,47 @@ func InitGoPacketDecoders(c *config.Config) (decoders map[gopacket.LayerType][]*
 
 			// hookup writer
 			dec.writer = io.NewAuditRecordWriter(&io.WriterConfig{
-				UnixSocket: c.UnixSocket,
-				CSV:        c.CSV,
-				Encode:     c.Encode,
-				Label:      c.Label,
-				Proto:      c.Proto,
-				JSON:       c.JSON,
-				Chan:       c.Chan,
-				Null:       c.Null,
-				Elastic:    c.Elastic,
+				UnixSocket:   c.UnixSocket,
+				CSV:          c.CSV,
+				CSVDelimiter: c.CSVDelimiter,
+				Encode:       c.Encode,
+				Label:        c.Label,
+				Proto:        c.Proto,
+				JSON:         c.JSON,
+				CBOR:         c.CBOR,
+				Avro:         c.Avro,
+				Chan:         c.Chan,
+				Null:         c.Null,
+				Elastic:      c.Elastic,
+				WebSocket:    c.WebSocket,
+				Syslog:       c.Syslog,
+				SyslogConfig: c.SyslogConfig,
+				Kafka:        c.Kafka,
+				KafkaConfig:  c.KafkaConfig,
 				ElasticConfig: io.ElasticConfig{
-					ElasticAddrs:   c.ElasticAddrs,
-					ElasticUser:    c.ElasticUser,
-					ElasticPass:    c.ElasticPass,
-					KibanaEndpoint: c.KibanaEndpoint,
-					BulkSize:       c.BulkSizeGoPacket,
+					ElasticAddrs:         c.ElasticAddrs,
+					ElasticUser:          c.ElasticUser,
+					ElasticPass:          c.ElasticPass,
+					KibanaEndpoint:       c.KibanaEndpoint,
+					BulkSize:             c.BulkSizeGoPacket,
+					ElasticIndex:         c.ElasticIndex,
+					ElasticFlushInterval: c.ElasticFlushInterval,
+					ElasticMaxRetries:    c.ElasticMaxRetries,
 				},
 				Name:                 filename,
 				Buffer:               c.Buffer,
 				Compress:             c.Compression,
 				Out:                  c.Out,
+				OutDirs:              c.OutDirs,
 				MemBufferSize:        c.MemBufferSize,
+				MaxFileSize:          c.MaxFileSize,
 				Source:               c.Source,
 				Version:              netcap.Version,
 				IncludesPayloads:     c.IncludePayloads,
 				StartTime:            time.Now(),
 				CompressionBlockSize: c.CompressionBlockSize,
 				CompressionLevel:     c.CompressionLevel,
+				CompressionFormat:    c.CompressionFormat,
 			})
 
 			// write netcap header
@@ -17,6 +17,7 @@ import (
 	"log"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/dreadl0ck/gopacket"
 	"github.com/dreadl0ck/ja3"
@@ -60,6 +61,12 @@ var ipProfiles = &atomicIPProfileMap{
 type ipProfile struct {
 	sync.Mutex
 	*types.IPProfile
+
+	// distinct targets contacted by the address, for the port scan detection
+	scans *portScanTracker
+
+	// intervals between the packets sent to each destination, for the beaconing detection
+	beacons map[string]*beaconTracker
 }
 
 var ipProfileDecoder = newPacketDecoder(
@@ -76,6 +83,7 @@ var ipProfileDecoder = newPacketDecoder(
 		// flush writer
 		for _, item := range ipProfiles.Items {
 			item.Lock()
+			item.BeaconScore = item.beaconScore()
 			d.writeIPProfile(item.IPProfile)
 			item.Unlock()
 		}
@@ -84,73 +92,44 @@ var ipProfileDecoder = newPacketDecoder(
 	},
 )
 
-// GetIPProfile fetches a known profile and updates it or returns a new one.
-func getIPProfile(ipAddr string, i *decoderutils.PacketInfo, source bool) *ipProfile {
-	if ipAddr == "" {
-		return nil
-	}
-
+// IPProfiles returns copies of all IP profiles that have been collected so far.
+func IPProfiles() []*types.IPProfile {
 	ipProfiles.Lock()
-	if p, ok := ipProfiles.Items[ipAddr]; ok {
-		ipProfiles.Unlock()
+	defer ipProfiles.Unlock()
 
-		p.Lock()
+	profiles := make([]*types.IPProfile, 0, len(ipProfiles.Items))
 
-		p.NumPackets++
-		p.TimestampLast = i.Timestamp
+	for _, p := range ipProfiles.Items {
+		p.Lock()
+		profiles = append(profiles, proto.Clone(p.IPProfile).(*types.IPProfile))
+		p.Unlock()
+	}
 
-		dataLen := uint64(len(i.Packet.Data()))
-		p.Bytes += dataLen
-
-		// Transport Layer
-		if tl := i.Packet.TransportLayer(); tl != nil {
-			if source {
-				doSrcPortUpdate(p, utils.DecodePort(tl.TransportFlow().Src().Raw()), tl.LayerType().String(), dataLen)
-				doContactedPortUpdate(p, utils.DecodePort(tl.TransportFlow().Dst().Raw()), tl.LayerType().String(), dataLen)
-			} else {
-				doDstPortUpdate(p, utils.DecodePort(tl.TransportFlow().Dst().Raw()), tl.LayerType().String(), dataLen)
-				doContactedPortUpdate(p, utils.DecodePort(tl.TransportFlow().Src().Raw()), tl.LayerType().String(), dataLen)
-			}
-		}
+	return profiles
+}
 
-		// Session Layer: TLS
-		ch := tlsx.GetClientHelloBasic(i.Packet)
-		if ch != nil {
-			if ch.SNI != "" {
-				p.SNIs[ch.SNI]++
-			}
-		}
+// GetIPProfile fetches a known profile and updates it or returns a new one.
+func getIPProfile(ipAddr string, i *decoderutils.PacketInfo, source bool) *ipProfile {
+	if ipAddr == "" {
+		return nil
+	}
 
-		ja3Hash := ja3.DigestHexPacket(i.Packet)
-		if ja3Hash == "" {
-			ja3Hash = ja3.DigestHexPacketJa3s(i.Packet)
-		}
+	ipAddr = decoderutils.NormalizeIP(ipAddr)
 
-		if ja3Hash != "" {
-			// add hash to profile if not already present
-			if _, ok = p.Ja3Hashes[ja3Hash]; !ok {
-				p.Ja3Hashes[ja3Hash] = resolvers.LookupJa3(ja3Hash)
-			}
-		}
+	// check the configured allow and deny lists
+	if !profileFilter.allowed(ipAddr) {
+		return nil
+	}
 
-		// Application Layer: DPI
-		uniqueResults := dpi.GetProtocols(i.Packet)
-		for protocol, res := range uniqueResults {
-			// check if proto exists already
-			var prot *types.Protocol
-			if prot, ok = p.Protocols[protocol]; ok {
-				prot.Packets++
-			} else {
-				// add new
-				p.Protocols[protocol] = dpi.NewProto(&res)
-			}
-		}
+	ipProfiles.Lock()
+	p, ok := ipProfiles.Items[ipAddr]
+	ipProfiles.Unlock()
 
-		p.Unlock()
+	if ok {
+		updateIPProfile(p, ipAddr, i, source)
 
 		return p
 	}
-	ipProfiles.Unlock()
 
 	var (
 		protos  = make(map[string]*types.Protocol)
@@ -159,8 +138,22 @@ func getIPProfile(ipAddr string, i *decoderutils.PacketInfo, source bool) *ipPro
 		sniMap  = make(map[string]int64)
 	)
 
+	var bytesSent, bytesRcvd uint64
+	if sentBy(ipAddr, i) {
+		bytesSent = dataLen
+	} else {
+		bytesRcvd = dataLen
+	}
+
+	// Link Layer: hardware address and vendor
+	mac := i.DstMAC
+	if source {
+		mac = i.SrcMAC
+	}
+
 	// Network Layer: IP Geolocation
-	loc, _ := resolvers.LookupGeolocation(ipAddr)
+	loc, _, lat, long := resolvers.LookupGeolocation(ipAddr)
+	asn, asOrg, _ := resolvers.LookupASN(ipAddr)
 
 	// Transport Layer: Port information
 	srcPorts, dstPorts, contactedPorts := initPorts(i, source)
@@ -197,23 +190,37 @@ func getIPProfile(ipAddr string, i *decoderutils.PacketInfo, source bool) *ipPro
 	}
 
 	// create new profile
-	p := &ipProfile{
+	p = &ipProfile{
 		IPProfile: &types.IPProfile{
-			Addr:           ipAddr,
-			NumPackets:     1,
-			Geolocation:    loc,
-			DNSNames:       names,
-			TimestampFirst: i.Timestamp,
-			Ja3Hashes:      ja3Map,
-			Protocols:      protos,
-			Bytes:          dataLen,
-			SrcPorts:       srcPorts,
-			DstPorts:       dstPorts,
-			ContactedPorts: contactedPorts,
-			SNIs:           sniMap,
+			Addr:               ipAddr,
+			NumPackets:         1,
+			Geolocation:        loc,
+			DNSNames:           names,
+			TimestampFirst:     i.Timestamp,
+			TimestampLast:      i.Timestamp,
+			Ja3Hashes:          ja3Map,
+			Protocols:          protos,
+			Bytes:              dataLen,
+			SrcPorts:           srcPorts,
+			DstPorts:           dstPorts,
+			ContactedPorts:     contactedPorts,
+			SNIs:               sniMap,
+			MacAddr:            mac,
+			DeviceManufacturer: resolvers.LookupManufacturer(mac),
+			BytesSent:          bytesSent,
+			BytesRcvd:          bytesRcvd,
+			ASN:                asn,
+			ASOrg:              asOrg,
+			Latitude:           lat,
+			Longitude:          long,
 		},
 	}
 
+	if source {
+		trackPortScan(p, i)
+		trackBeacon(p, i)
+	}
+
 	ipProfiles.Lock()
 	ipProfiles.Items[ipAddr] = p
 	ipProfiles.Unlock()
@@ -221,6 +228,78 @@ func getIPProfile(ipAddr string, i *decoderutils.PacketInfo, source bool) *ipPro
 	return p
 }
 
+// updateIPProfile applies the packet to an existing profile.
+// The lock is released via defer, so that a panic in one of the lookups
+// that is recovered by the collector does not leave the profile locked.
+func updateIPProfile(p *ipProfile, ipAddr string, i *decoderutils.PacketInfo, source bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.NumPackets++
+	p.TimestampLast = i.Timestamp
+
+	dataLen := uint64(len(i.Packet.Data()))
+	p.Bytes += dataLen
+
+	if sentBy(ipAddr, i) {
+		p.BytesSent += dataLen
+	} else {
+		p.BytesRcvd += dataLen
+	}
+
+	p.DurationSeconds = time.Duration(p.TimestampLast - p.TimestampFirst).Seconds()
+
+	// Transport Layer
+	if tl := i.Packet.TransportLayer(); tl != nil {
+		if source {
+			doSrcPortUpdate(p, utils.DecodePort(tl.TransportFlow().Src().Raw()), tl.LayerType().String(), dataLen)
+			doContactedPortUpdate(p, utils.DecodePort(tl.TransportFlow().Dst().Raw()), tl.LayerType().String(), dataLen)
+			trackPortScan(p, i)
+			trackBeacon(p, i)
+		} else {
+			doDstPortUpdate(p, utils.DecodePort(tl.TransportFlow().Dst().Raw()), tl.LayerType().String(), dataLen)
+			doContactedPortUpdate(p, utils.DecodePort(tl.TransportFlow().Src().Raw()), tl.LayerType().String(), dataLen)
+		}
+	}
+
+	// Session Layer: TLS
+	ch := tlsx.GetClientHelloBasic(i.Packet)
+	if ch != nil {
+		if ch.SNI != "" {
+			p.SNIs[ch.SNI]++
+		}
+	}
+
+	ja3Hash := ja3.DigestHexPacket(i.Packet)
+	if ja3Hash == "" {
+		ja3Hash = ja3.DigestHexPacketJa3s(i.Packet)
+	}
+
+	if ja3Hash != "" {
+		// add hash to profile if not already present
+		if _, ok := p.Ja3Hashes[ja3Hash]; !ok {
+			p.Ja3Hashes[ja3Hash] = resolvers.LookupJa3(ja3Hash)
+		}
+	}
+
+	// Application Layer: DPI
+	uniqueResults := dpi.GetProtocols(i.Packet)
+	for protocol, res := range uniqueResults {
+		// check if proto exists already
+		if prot, ok := p.Protocols[protocol]; ok {
+			prot.Packets++
+		} else {
+			// add new
+			p.Protocols[protocol] = dpi.NewProto(&res)
+		}
+	}
+}
+
+// sentBy checks whether the packet has been sent by the profiled address.
+func sentBy(ipAddr string, i *decoderutils.PacketInfo) bool {
+	return decoderutils.NormalizeIP(i.SrcIP) == ipAddr
+}
+
 func doSrcPortUpdate(p *ipProfile, srcPort int32, layerType string, dataLen uint64) {
 	var found bool
 
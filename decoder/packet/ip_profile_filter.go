@@ -0,0 +1,121 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// errInvalidNetwork occurs when an entry of the IPProfile allow or deny list can not be parsed.
+var errInvalidNetwork = errors.New("invalid network")
+
+// ipProfileFilter decides which addresses get an IPProfile.
+// If the allow list is not empty, only addresses contained in it are profiled.
+// Addresses contained in the deny list are never profiled.
+type ipProfileFilter struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// profileFilter is initialized from the configuration when the packet decoders are initialized.
+var profileFilter = &ipProfileFilter{}
+
+// newIPProfileFilter parses the comma separated lists of CIDRs or single IPv4 / IPv6 addresses.
+func newIPProfileFilter(allow, deny string) (*ipProfileFilter, error) {
+	var (
+		f   = &ipProfileFilter{}
+		err error
+	)
+
+	f.allow, err = parseNetworks(allow)
+	if err != nil {
+		return nil, err
+	}
+
+	f.deny, err = parseNetworks(deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// parseNetworks parses a comma separated list of CIDRs.
+// Addresses without a prefix length are treated as a network containing only this address.
+func parseNetworks(list string) (networks []*net.IPNet, err error) {
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, errors.Wrap(errInvalidNetwork, entry)
+			}
+
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, n, errParse := net.ParseCIDR(entry)
+		if errParse != nil {
+			return nil, errors.Wrap(errInvalidNetwork, entry)
+		}
+
+		networks = append(networks, n)
+	}
+
+	return networks, nil
+}
+
+// allowed checks whether a profile shall be created for the given address.
+func (f *ipProfileFilter) allowed(ipAddr string) bool {
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(ipAddr)
+	if ip == nil {
+		return false
+	}
+
+	if len(f.allow) > 0 && !containsIP(f.allow, ip) {
+		return false
+	}
+
+	return !containsIP(f.deny, ip)
+}
+
+// containsIP checks if any of the networks contains the address.
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,89 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestNewIPProfileFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow string
+		deny  string
+		err   bool
+	}{
+		{name: "empty"},
+		{name: "networks", allow: "10.0.0.0/8, 192.168.1.0/24", deny: "fe80::/10"},
+		{name: "single addresses", allow: "10.0.0.1", deny: "::1"},
+		{name: "empty entries", allow: ",10.0.0.0/8,,", deny: " , "},
+		{name: "invalid address", allow: "10.0.0.256", err: true},
+		{name: "invalid network", deny: "10.0.0.0/33", err: true},
+		{name: "hostname", allow: "localhost", err: true},
+	}
+
+	for _, test := range tests {
+		_, err := newIPProfileFilter(test.allow, test.deny)
+		if test.err {
+			if errors.Cause(err) != errInvalidNetwork {
+				t.Fatal(test.name, ": expected", errInvalidNetwork, "got", err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(test.name, ":", err)
+		}
+	}
+}
+
+func TestIPProfileFilterAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allow   string
+		deny    string
+		addr    string
+		allowed bool
+	}{
+		{name: "no lists", addr: "8.8.8.8", allowed: true},
+		{name: "no lists invalid address", addr: "invalid", allowed: true},
+		{name: "allow list match", allow: "10.0.0.0/8", addr: "10.1.2.3", allowed: true},
+		{name: "allow list miss", allow: "10.0.0.0/8", addr: "11.1.2.3"},
+		{name: "allow single address", allow: "10.0.0.1", addr: "10.0.0.1", allowed: true},
+		{name: "allow single address miss", allow: "10.0.0.1", addr: "10.0.0.2"},
+		{name: "deny list match", deny: "192.168.0.0/16", addr: "192.168.1.1"},
+		{name: "deny list miss", deny: "192.168.0.0/16", addr: "10.0.0.1", allowed: true},
+		{name: "deny overrides allow", allow: "10.0.0.0/8", deny: "10.0.0.0/24", addr: "10.0.0.5"},
+		{name: "allowed but not denied", allow: "10.0.0.0/8", deny: "10.0.0.0/24", addr: "10.0.1.5", allowed: true},
+		{name: "ipv6 allow", allow: "2001:db8::/32", addr: "2001:db8::1", allowed: true},
+		{name: "ipv6 expanded notation", allow: "2001:db8::/32", addr: "2001:0db8:0000:0000:0000:0000:0000:0001", allowed: true},
+		{name: "ipv6 deny single address", deny: "::1", addr: "::1"},
+		{name: "ipv4 address against ipv6 allow list", allow: "2001:db8::/32", addr: "10.0.0.1"},
+		{name: "invalid address", allow: "10.0.0.0/8", addr: "invalid"},
+	}
+
+	for _, test := range tests {
+		f, err := newIPProfileFilter(test.allow, test.deny)
+		if err != nil {
+			t.Fatal(test.name, ":", err)
+		}
+
+		if allowed := f.allowed(test.addr); allowed != test.allowed {
+			t.Fatal(test.name, ": expected", test.allowed, "got", allowed)
+		}
+	}
+}
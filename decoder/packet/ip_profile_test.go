@@ -0,0 +1,154 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+)
+
+func newProfileTestPacket(t *testing.T, src, dst string, ts time.Time, payload int) *decoderutils.PacketInfo {
+	t.Helper()
+
+	var (
+		buf = gopacket.NewSerializeBuffer()
+		ip  = &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: layers.IPProtocolUDP,
+			SrcIP:    net.ParseIP(src),
+			DstIP:    net.ParseIP(dst),
+		}
+		udp = &layers.UDP{SrcPort: 40000, DstPort: 9999}
+	)
+
+	if err := udp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, ip, udp, gopacket.Payload(make([]byte, payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+	p.Metadata().Timestamp = ts
+
+	return decoderutils.NewPacketInfo(p)
+}
+
+func TestIPProfileDirection(t *testing.T) {
+	var (
+		start   = time.Unix(1600000000, 0)
+		request = newProfileTestPacket(t, "10.1.1.1", "10.1.1.2", start, 100)
+		reply   = newProfileTestPacket(t, "10.1.1.2", "10.1.1.1", start.Add(90*time.Second), 1000)
+	)
+
+	getIPProfile(request.SrcIP, request, true)
+	getIPProfile(request.DstIP, request, false)
+	getIPProfile(reply.SrcIP, reply, true)
+	p := getIPProfile(reply.DstIP, reply, false)
+
+	if p.BytesSent != 128 || p.BytesRcvd != 1028 || p.Bytes != p.BytesSent+p.BytesRcvd {
+		t.Fatal("unexpected bytes", p.BytesSent, p.BytesRcvd, p.Bytes)
+	}
+
+	if p.DurationSeconds != 90 {
+		t.Fatal("expected a duration of 90 seconds, got", p.DurationSeconds)
+	}
+}
+
+func TestIPProfilePortScanScore(t *testing.T) {
+	defer func(th *portScanThresholds) {
+		scanThresholds = th
+	}(scanThresholds)
+
+	scanThresholds = &portScanThresholds{ports: 10, hosts: 4, window: time.Minute}
+
+	var (
+		start = time.Unix(1600000000, 0)
+		p     *ipProfile
+	)
+
+	// the same host is contacted twice, and the last host after the window expired
+	for i, dst := range []string{"10.3.0.1", "10.3.0.2", "10.3.0.2", "10.3.0.3", "10.3.0.4", "10.3.0.5", "10.3.0.6"} {
+		ts := start.Add(time.Duration(i) * time.Second)
+		if i == 6 {
+			ts = start.Add(2 * time.Minute)
+		}
+
+		pkt := newProfileTestPacket(t, "10.3.1.1", dst, ts, 10)
+		p = getIPProfile(pkt.SrcIP, pkt, true)
+	}
+
+	if p.PortScanScore != 1.25 {
+		t.Fatal("expected a port scan score of 1.25, got", p.PortScanScore)
+	}
+
+	if len(p.scans.targets) != 1 {
+		t.Fatal("expected the targets to be reset for the new window, got", len(p.scans.targets))
+	}
+
+	// distinct ports on a single host
+	s := &portScanTracker{}
+	for port := int32(1); port <= 5; port++ {
+		s.add(start.UnixNano(), scanTarget{host: "10.3.0.1", port: port}, scanThresholds)
+	}
+
+	if score := s.add(start.UnixNano(), scanTarget{host: "10.3.0.1", port: 6}, scanThresholds); score != 0.6 {
+		t.Fatal("expected a score of 0.6, got", score)
+	}
+}
+
+func TestIPProfileBeaconScore(t *testing.T) {
+	var (
+		start = time.Unix(1600000000, 0)
+		p     *ipProfile
+	)
+
+	// irregular traffic to the first destination
+	for _, offset := range []int{0, 3, 70, 75, 300, 302, 900} {
+		pkt := newProfileTestPacket(t, "10.4.1.1", "10.4.0.1", start.Add(time.Duration(offset)*time.Second), 10)
+		p = getIPProfile(pkt.SrcIP, pkt, true)
+	}
+
+	if score := p.beaconScore(); score > 0.5 {
+		t.Fatal("expected a low score for irregular traffic, got", score)
+	}
+
+	// callbacks to the second destination every minute with a small jitter,
+	// each consisting of a burst of packets
+	for i := 0; i < 8; i++ {
+		ts := start.Add(time.Duration(i)*time.Minute + time.Duration(i%3)*time.Second)
+
+		for j := 0; j < 3; j++ {
+			pkt := newProfileTestPacket(t, "10.4.1.1", "10.4.0.2", ts.Add(time.Duration(j)*100*time.Millisecond), 10)
+			getIPProfile(pkt.SrcIP, pkt, true)
+		}
+	}
+
+	if n := p.beacons["10.4.0.2"].num; n != 7 {
+		t.Fatal("expected 7 intervals, got", n)
+	}
+
+	if score := p.beaconScore(); score < 0.95 {
+		t.Fatal("expected a high score for periodic callbacks, got", score)
+	}
+}
@@ -14,6 +14,10 @@
 package packet
 
 import (
+	"encoding/binary"
+	"net"
+	"strings"
+
 	"github.com/dreadl0ck/gopacket"
 	"github.com/dreadl0ck/gopacket/layers"
 	"github.com/gogo/protobuf/proto"
@@ -43,9 +47,32 @@ var ntpDecoder = newGoPacketDecoder(
 				ReceiveTimestamp:   uint64(ntp.ReceiveTimestamp),
 				TransmitTimestamp:  uint64(ntp.TransmitTimestamp),
 				ExtensionBytes:     ntp.ExtensionBytes,
+				ReferenceSource:    ntpReferenceSource(ntp.Stratum, ntp.ReferenceID),
 			}
 		}
 
 		return nil
 	},
 )
+
+// ntpReferenceSource returns the source of the time of the sender.
+// For stratum 0 and 1 the reference ID is a code of up to four ASCII characters,
+// the kiss code of a kiss-o'-death packet or the type of the reference clock, e.g. GPS.
+// For higher strata it is the IPv4 address of the upstream server, or the first bytes of the MD5 hash of its IPv6 address.
+func ntpReferenceSource(stratum layers.NTPStratum, id layers.NTPReferenceID) string {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(id))
+
+	if stratum > 1 {
+		return net.IP(b).String()
+	}
+
+	code := strings.TrimRight(string(b), "\x00")
+	for _, c := range code {
+		if c < 0x20 || c > 0x7e {
+			return ""
+		}
+	}
+
+	return code
+}
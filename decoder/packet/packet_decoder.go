@@ -177,6 +177,18 @@ func InitPacketDecoders(c *config.Config) (decoders []DecoderAPI, err error) {
 		}
 	}
 
+	// parse the allow and deny lists for IPProfiles
+	profileFilter, err = newIPProfileFilter(c.IPProfileAllowList, c.IPProfileDenyList)
+	if err != nil {
+		return nil, err
+	}
+
+	scanThresholds = &portScanThresholds{
+		ports:  c.PortScanPorts,
+		hosts:  c.PortScanHosts,
+		window: c.PortScanWindow,
+	}
+
 	var (
 		wg sync.WaitGroup
 		mu sync.Mutex
@@ -192,35 +204,49 @@ func InitPacketDecoders(c *config.Config) (decoders []DecoderAPI, err error) {
 
 		go func(dec DecoderAPI) {
 			w := io.NewAuditRecordWriter(&io.WriterConfig{
-				UnixSocket: c.UnixSocket,
-				CSV:        c.CSV,
-				Label:      c.Label,
-				Encode:     c.Encode,
-				Proto:      c.Proto,
-				JSON:       c.JSON,
-				Name:       dec.GetName(),
-				Type:       dec.GetType(),
-				Null:       c.Null,
-				Elastic:    c.Elastic,
+				UnixSocket:   c.UnixSocket,
+				CSV:          c.CSV,
+				CSVDelimiter: c.CSVDelimiter,
+				Label:        c.Label,
+				Encode:       c.Encode,
+				Proto:        c.Proto,
+				JSON:         c.JSON,
+				CBOR:         c.CBOR,
+				Avro:         c.Avro,
+				Name:         dec.GetName(),
+				Type:         dec.GetType(),
+				Null:         c.Null,
+				Elastic:      c.Elastic,
+				WebSocket:    c.WebSocket,
+				Syslog:       c.Syslog,
+				SyslogConfig: c.SyslogConfig,
+				Kafka:        c.Kafka,
+				KafkaConfig:  c.KafkaConfig,
 				ElasticConfig: io.ElasticConfig{
-					ElasticAddrs:   c.ElasticAddrs,
-					ElasticUser:    c.ElasticUser,
-					ElasticPass:    c.ElasticPass,
-					KibanaEndpoint: c.KibanaEndpoint,
-					BulkSize:       c.BulkSizeCustom,
+					ElasticAddrs:         c.ElasticAddrs,
+					ElasticUser:          c.ElasticUser,
+					ElasticPass:          c.ElasticPass,
+					KibanaEndpoint:       c.KibanaEndpoint,
+					BulkSize:             c.BulkSizeCustom,
+					ElasticIndex:         c.ElasticIndex,
+					ElasticFlushInterval: c.ElasticFlushInterval,
+					ElasticMaxRetries:    c.ElasticMaxRetries,
 				},
 				Buffer:               c.Buffer,
 				Compress:             c.Compression,
 				Out:                  c.Out,
+				OutDirs:              c.OutDirs,
 				Chan:                 c.Chan,
 				ChanSize:             c.ChanSize,
 				MemBufferSize:        c.MemBufferSize,
+				MaxFileSize:          c.MaxFileSize,
 				Source:               c.Source,
 				Version:              netcap.Version,
 				IncludesPayloads:     c.IncludePayloads,
 				StartTime:            time.Now(),
 				CompressionBlockSize: c.CompressionBlockSize,
 				CompressionLevel:     c.CompressionLevel,
+				CompressionFormat:    c.CompressionFormat,
 			})
 			dec.SetWriter(w)
 
@@ -15,6 +15,11 @@ package packet
 
 import (
 	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket/layers"
+
+	"github.com/dreadl0ck/netcap/types"
 )
 
 // Overwriting the package level conf when executing other tests in parallel is a bad idea...
@@ -82,3 +87,135 @@ func TestPacketDecoder_Decode(t *testing.T) {
 
 func TestPacketDecoder_Destroy(t *testing.T) {
 }
+
+// mustPanic fails the test if fn does not panic.
+func mustPanic(t *testing.T, fn func()) {
+	t.Helper()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+
+	fn()
+}
+
+// mustComplete fails the test if fn does not return in time, e.g. because a lock is still held.
+func mustComplete(t *testing.T, fn func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("call did not complete, a lock is still held after the panic")
+	}
+}
+
+// the collector recovers from decoder panics, the locks held by a decoder must be released in that case,
+// otherwise the next packet blocks the worker.
+// The entries are only removed if the test passed, since a failure leaves the maps locked.
+func TestDecoderPanicReleasesLocks(t *testing.T) {
+	t.Run("Connection", func(t *testing.T) {
+		var (
+			p      = tcpPacket(t, &layers.TCP{SYN: true, SrcPort: 41000, DstPort: 8080})
+			connID = connectionID{
+				NetworkFlowID:   p.NetworkLayer().NetworkFlow().FastHash(),
+				TransportFlowID: p.TransportLayer().TransportFlow().FastHash(),
+			}
+		)
+
+		handlePacket(p)
+
+		conns.Lock()
+		conn := conns.Items[connID.String()]
+		conns.Unlock()
+
+		co := conn.Connection
+		conn.Connection = nil
+
+		mustPanic(t, func() {
+			handlePacket(p)
+		})
+
+		conn.Connection = co
+
+		mustComplete(t, func() {
+			handlePacket(p)
+		})
+
+		if conn.NumPackets != 2 {
+			t.Fatal("expected 2 packets, got", conn.NumPackets)
+		}
+
+		conns.Lock()
+		delete(conns.Items, connID.String())
+		conns.Unlock()
+	})
+
+	t.Run("IPProfile", func(t *testing.T) {
+		var (
+			addr = "10.10.0.2"
+			pkt  = newProfileTestPacket(t, "10.10.0.1", addr, time.Unix(1600000000, 0), 10)
+			p    = &ipProfile{}
+		)
+
+		ipProfiles.Lock()
+		ipProfiles.Items[addr] = p
+		ipProfiles.Unlock()
+
+		mustPanic(t, func() {
+			getIPProfile(addr, pkt, false)
+		})
+
+		p.IPProfile = &types.IPProfile{Addr: addr}
+
+		mustComplete(t, func() {
+			getIPProfile(addr, pkt, false)
+		})
+
+		if p.NumPackets != 1 {
+			t.Fatal("expected 1 packet, got", p.NumPackets)
+		}
+
+		ipProfiles.Lock()
+		delete(ipProfiles.Items, addr)
+		ipProfiles.Unlock()
+	})
+
+	t.Run("DeviceProfile", func(t *testing.T) {
+		var (
+			pkt = newIPv6TestPacket(t, "2001:db8::10", "2001:db8::20", time.Unix(1600000000, 0))
+			p   = &deviceProfile{}
+		)
+
+		DeviceProfiles.Lock()
+		DeviceProfiles.Items[pkt.SrcMAC] = p
+		DeviceProfiles.Unlock()
+
+		mustPanic(t, func() {
+			updateDeviceProfile(pkt)
+		})
+
+		p.DeviceProfile = &types.DeviceProfile{}
+
+		mustComplete(t, func() {
+			updateDeviceProfile(pkt)
+		})
+
+		if p.NumPackets != 1 {
+			t.Fatal("expected 1 packet, got", p.NumPackets)
+		}
+
+		DeviceProfiles.Lock()
+		delete(DeviceProfiles.Items, pkt.SrcMAC)
+		DeviceProfiles.Unlock()
+	})
+}
@@ -0,0 +1,148 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/utils"
+)
+
+// maxScanTargets limits the number of distinct targets that are tracked for an address within a single window,
+// to keep the memory bounded for hosts that contact the entire network.
+const maxScanTargets = 1 << 16
+
+// portScanThresholds determine when an address is considered to be scanning.
+type portScanThresholds struct {
+	// distinct ports contacted on a single host
+	ports int
+
+	// distinct hosts contacted on a single port
+	hosts int
+
+	// time window in which the distinct targets are counted
+	window time.Duration
+}
+
+// scanThresholds is initialized from the configuration when the packet decoders are initialized.
+var scanThresholds = &portScanThresholds{
+	ports:  defaults.PortScanPorts,
+	hosts:  defaults.PortScanHosts,
+	window: defaults.PortScanWindow,
+}
+
+// enabled checks if at least one of the thresholds is set.
+func (t *portScanThresholds) enabled() bool {
+	return t.ports > 0 || t.hosts > 0
+}
+
+// score returns the highest ratio of the number of distinct targets to the corresponding threshold.
+func (t *portScanThresholds) score(ports, hosts int) float64 {
+	var score float64
+
+	if t.ports > 0 {
+		score = float64(ports) / float64(t.ports)
+	}
+
+	if t.hosts > 0 {
+		if s := float64(hosts) / float64(t.hosts); s > score {
+			score = s
+		}
+	}
+
+	return score
+}
+
+type scanTarget struct {
+	host string
+	port int32
+}
+
+// portScanTracker counts the distinct targets an address has contacted within the current window.
+// Only the counters per host and per port are kept besides the set of targets,
+// which is discarded when the window expires.
+type portScanTracker struct {
+	windowStart  int64
+	targets      map[scanTarget]struct{}
+	portsPerHost map[string]int
+	hostsPerPort map[int32]int
+}
+
+// add records a connection attempt to the target and returns the score for the current window.
+func (s *portScanTracker) add(ts int64, target scanTarget, t *portScanThresholds) float64 {
+	if s.targets == nil || (t.window > 0 && ts-s.windowStart >= int64(t.window)) {
+		s.windowStart = ts
+		s.targets = make(map[scanTarget]struct{})
+		s.portsPerHost = make(map[string]int)
+		s.hostsPerPort = make(map[int32]int)
+	}
+
+	if _, ok := s.targets[target]; ok || len(s.targets) >= maxScanTargets {
+		return 0
+	}
+
+	s.targets[target] = struct{}{}
+	s.portsPerHost[target.host]++
+	s.hostsPerPort[target.port]++
+
+	return t.score(s.portsPerHost[target.host], s.hostsPerPort[target.port])
+}
+
+// trackPortScan updates the port scan score of the profile with a packet sent by the profiled address.
+func trackPortScan(p *ipProfile, i *decoderutils.PacketInfo) {
+	if !scanThresholds.enabled() {
+		return
+	}
+
+	tl := connectionAttempt(i)
+	if tl == nil {
+		return
+	}
+
+	if p.scans == nil {
+		p.scans = &portScanTracker{}
+	}
+
+	score := p.scans.add(i.Timestamp, scanTarget{
+		host: decoderutils.NormalizeIP(i.DstIP),
+		port: utils.DecodePort(tl.TransportFlow().Dst().Raw()),
+	}, scanThresholds)
+
+	if score > p.PortScanScore {
+		p.PortScanScore = score
+	}
+}
+
+// connectionAttempt returns the transport layer of TCP packets that initiate a connection and of UDP packets,
+// the traffic of established TCP connections is ignored.
+func connectionAttempt(i *decoderutils.PacketInfo) gopacket.TransportLayer {
+	tl := i.Packet.TransportLayer()
+
+	switch l := tl.(type) {
+	case *layers.TCP:
+		if !l.SYN || l.ACK {
+			return nil
+		}
+	case *layers.UDP:
+	default:
+		return nil
+	}
+
+	return tl
+}
@@ -0,0 +1,641 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+	"github.com/dreadl0ck/ja3"
+	"github.com/dreadl0ck/tlsx"
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+const (
+	// quicPort is the UDP port used by HTTP/3.
+	// Initial packets of unknown QUIC versions are only reported for this port,
+	// since their header does not allow to tell QUIC apart from random UDP payloads.
+	quicPort = 443
+
+	// quicMaxHandshakes limits the number of handshakes tracked at the same time.
+	quicMaxHandshakes = 10000
+
+	// quicMaxCryptoData limits the amount of CRYPTO frame data buffered for a single handshake.
+	quicMaxCryptoData = 1 << 16
+
+	// quicIdleTimeout is the time after which an incomplete handshake is reported with the information seen so far,
+	// once the maximum number of handshakes is reached.
+	quicIdleTimeout = time.Minute
+)
+
+var (
+	errQUICHeader     = errors.New("invalid QUIC long header")
+	errQUICProtection = errors.New("failed to remove QUIC packet protection")
+	errQUICFrame      = errors.New("unexpected frame in QUIC Initial packet")
+)
+
+// quicVersion holds the parameters used to protect the Initial packets of a QUIC version.
+type quicVersion struct {
+	name        string
+	salt        []byte
+	labelPrefix string
+	initialType byte
+}
+
+var (
+	quicV1 = &quicVersion{
+		name:        "v1",
+		salt:        mustDecodeHex("38762cf7f55934b34d179ae6a4c80cadccbb7f0a"),
+		labelPrefix: "quic",
+		initialType: 0,
+	}
+	quicDraft29 = &quicVersion{
+		salt:        mustDecodeHex("afbfec289993d24c9e9786f19c6111e04390a899"),
+		labelPrefix: "quic",
+		initialType: 0,
+	}
+	quicV2 = &quicVersion{
+		name:        "v2",
+		salt:        mustDecodeHex("0dede3def700a6db819381be6e269dcbf9bd2ed9"),
+		labelPrefix: "quicv2",
+		initialType: 1,
+	}
+)
+
+// lookupQUICVersion returns the version parameters and a name for the version number.
+// The parameters are nil for versions whose Initial packets can not be decrypted.
+func lookupQUICVersion(v uint32) (*quicVersion, string) {
+	switch {
+	case v == 0x00000001:
+		return quicV1, quicV1.name
+	case v == 0x6b3343cf:
+		return quicV2, quicV2.name
+	case v >= 0xff00001d && v <= 0xff000020:
+		// drafts 29 to 32 share the same salt
+		return quicDraft29, "draft-" + strconv.Itoa(int(v&0xff))
+	default:
+		return nil, fmt.Sprintf("0x%08x", v)
+	}
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// quicHandshakes is initialized when the decoder is enabled via the configuration.
+var quicHandshakes *quicTracker
+
+var quicDecoder = newPacketDecoder(
+	types.Type_NC_QUIC,
+	"QUIC",
+	"The client side of a QUIC handshake, including the server name from the ClientHello of the Initial packets",
+	func(d *Decoder) error {
+		if !conf.QUIC {
+			quicHandshakes = nil
+
+			return nil
+		}
+
+		quicHandshakes = newQUICTracker(d.write)
+
+		return nil
+	},
+	func(p gopacket.Packet) proto.Message {
+		if quicHandshakes == nil {
+			return nil
+		}
+
+		udp, ok := p.Layer(layers.LayerTypeUDP).(*layers.UDP)
+		if !ok || len(udp.Payload) == 0 || udp.Payload[0]&0xc0 != 0xc0 {
+			// not a long header packet
+			return nil
+		}
+
+		nl := p.NetworkLayer()
+		if nl == nil {
+			return nil
+		}
+
+		if r := quicHandshakes.add(
+			p.Metadata().Timestamp.UnixNano(),
+			nl.NetworkFlow().Src().String(),
+			nl.NetworkFlow().Dst().String(),
+			int32(udp.SrcPort),
+			int32(udp.DstPort),
+			udp.Payload,
+		); r != nil {
+			return r
+		}
+
+		return nil
+	},
+	func(d *Decoder) error {
+		if quicHandshakes == nil {
+			return nil
+		}
+
+		// report the handshakes that are still incomplete
+		for _, r := range quicHandshakes.flush() {
+			d.write(r)
+		}
+
+		return nil
+	},
+)
+
+// quicHandshake collects the CRYPTO frames sent by a client in its Initial packets.
+type quicHandshake struct {
+	record *types.QUIC
+	crypto map[uint64][]byte
+	size   int
+	last   int64
+	done   bool
+}
+
+// clientHello returns the ClientHello handshake message, once all of its CRYPTO frames have been received.
+func (h *quicHandshake) clientHello() []byte {
+	var buf []byte
+
+	for progress := true; progress; {
+		progress = false
+
+		for off, data := range h.crypto {
+			n := uint64(len(buf))
+			if off <= n && off+uint64(len(data)) > n {
+				buf = append(buf, data[n-off:]...)
+				progress = true
+			}
+		}
+	}
+
+	// handshake type 1 is the ClientHello, followed by the 24bit message length
+	if len(buf) < 4 || buf[0] != 1 {
+		return nil
+	}
+
+	n := int(buf[1])<<16 | int(buf[2])<<8 | int(buf[3])
+	if len(buf) < 4+n {
+		return nil
+	}
+
+	return buf[:4+n]
+}
+
+// quicTracker keeps the state of the QUIC handshakes until their ClientHello is complete.
+type quicTracker struct {
+	sync.Mutex
+
+	handshakes map[string]*quicHandshake
+	write      func(types.AuditRecord)
+}
+
+func newQUICTracker(write func(types.AuditRecord)) *quicTracker {
+	return &quicTracker{
+		handshakes: make(map[string]*quicHandshake),
+		write:      write,
+	}
+}
+
+// add processes the long header packets in a UDP datagram,
+// and returns the audit record for the handshake once its ClientHello is complete.
+func (t *quicTracker) add(ts int64, srcIP, dstIP string, srcPort, dstPort int32, data []byte) *types.QUIC {
+	t.Lock()
+	defer t.Unlock()
+
+	var (
+		key = srcIP + ":" + strconv.Itoa(int(srcPort)) + "-" + dstIP + ":" + strconv.Itoa(int(dstPort))
+		h   *quicHandshake
+	)
+
+	// a datagram can contain multiple coalesced packets
+	for len(data) > 0 && data[0]&0xc0 == 0xc0 {
+		hdr, err := parseQUICLongHeader(data)
+		if err != nil || hdr.version == 0 {
+			// malformed or version negotiation
+			return nil
+		}
+
+		version, name := lookupQUICVersion(hdr.version)
+		r := &types.QUIC{
+			Timestamp: ts,
+			SrcIP:     srcIP,
+			SrcPort:   srcPort,
+			DstIP:     dstIP,
+			DstPort:   dstPort,
+			Version:   name,
+			DCID:      hex.EncodeToString(hdr.dcid),
+			SCID:      hex.EncodeToString(hdr.scid),
+		}
+
+		if version == nil {
+			if srcPort != quicPort && dstPort != quicPort {
+				return nil
+			}
+
+			// the keys can not be derived for unknown versions, only the version independent fields are visible
+			h = t.handshake(key, hdr.dcid, ts, r)
+			if h == nil || h.done {
+				return nil
+			}
+
+			h.done = true
+
+			return h.record
+		}
+
+		initial := (hdr.first>>4)&0x3 == version.initialType
+		if err = hdr.parseLength(data, initial); err != nil {
+			return nil
+		}
+
+		if !initial {
+			data = data[hdr.end:]
+
+			continue
+		}
+
+		// the server protects its Initial packets with different keys,
+		// so only packets sent by the client can be decrypted
+		payload, err := newQUICInitialKeys(version, hdr.dcid).open(data[:hdr.end], hdr.pnOffset)
+		if err != nil {
+			return nil
+		}
+
+		if h == nil {
+			h = t.handshake(key, hdr.dcid, ts, r)
+			if h == nil || h.done {
+				return nil
+			}
+		}
+
+		h.last = ts
+		h.record.Decrypted = true
+		h.record.NumPackets++
+		h.record.TokenLength = int32(hdr.tokenLength)
+
+		err = quicCryptoFrames(payload, func(offset uint64, frame []byte) {
+			if h.size+len(frame) > quicMaxCryptoData {
+				return
+			}
+
+			h.crypto[offset] = append([]byte(nil), frame...)
+			h.size += len(frame)
+		})
+		if err != nil {
+			return nil
+		}
+
+		data = data[hdr.end:]
+	}
+
+	if h == nil || !h.record.Decrypted {
+		return nil
+	}
+
+	hello := h.clientHello()
+	if hello == nil {
+		return nil
+	}
+
+	setQUICClientHello(h.record, hello)
+
+	h.done = true
+	h.crypto = nil
+
+	return h.record
+}
+
+// handshake returns the state for a handshake and creates it if it does not exist yet.
+// If the maximum number of handshakes is reached, idle handshakes are removed first, and nil is returned if there is no space left.
+// Must be called with the lock held.
+func (t *quicTracker) handshake(key string, dcid []byte, ts int64, r *types.QUIC) *quicHandshake {
+	key += "/" + string(dcid)
+
+	if h, ok := t.handshakes[key]; ok {
+		return h
+	}
+
+	if len(t.handshakes) >= quicMaxHandshakes {
+		for k, h := range t.handshakes {
+			if ts-h.last > int64(quicIdleTimeout) {
+				if !h.done && h.record.Decrypted {
+					t.write(h.record)
+				}
+
+				delete(t.handshakes, k)
+			}
+		}
+
+		if len(t.handshakes) >= quicMaxHandshakes {
+			return nil
+		}
+	}
+
+	h := &quicHandshake{
+		record: r,
+		crypto: make(map[uint64][]byte),
+		last:   ts,
+	}
+	t.handshakes[key] = h
+
+	return h
+}
+
+// flush returns the records for all handshakes whose ClientHello is incomplete, and resets the tracker.
+func (t *quicTracker) flush() (records []*types.QUIC) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, h := range t.handshakes {
+		if !h.done && h.record.Decrypted {
+			records = append(records, h.record)
+		}
+	}
+
+	t.handshakes = make(map[string]*quicHandshake)
+
+	return records
+}
+
+// setQUICClientHello populates the record with the information from a ClientHello handshake message.
+func setQUICClientHello(r *types.QUIC, hello []byte) {
+	if len(hello) > 0xffff {
+		return
+	}
+
+	// the parser expects a TLS record, which QUIC does not use
+	rec := make([]byte, 5, 5+len(hello))
+	rec[0] = 22
+	rec[1], rec[2] = 3, 1
+	binary.BigEndian.PutUint16(rec[3:], uint16(len(hello)))
+
+	var ch tlsx.ClientHello
+	if err := ch.Unmarshal(append(rec, hello...)); err != nil {
+		return
+	}
+
+	r.SNI = ch.SNI
+	r.ALPNs = ch.ALPNs
+	r.Ja3 = ja3.DigestHex(&ch.ClientHelloBasic)
+}
+
+// quicLongHeader contains the fields of a long header packet.
+type quicLongHeader struct {
+	first       byte
+	version     uint32
+	dcid        []byte
+	scid        []byte
+	tokenLength uint64
+
+	// offset of the first byte after the connection ids, of the packet number and of the end of the packet
+	offset   int
+	pnOffset int
+	end      int
+}
+
+// parseQUICLongHeader parses the version independent fields of a long header packet.
+func parseQUICLongHeader(data []byte) (*quicLongHeader, error) {
+	if len(data) < 7 {
+		return nil, errQUICHeader
+	}
+
+	hdr := &quicLongHeader{
+		first:   data[0],
+		version: binary.BigEndian.Uint32(data[1:5]),
+	}
+
+	off := 5
+
+	for _, cid := range []*[]byte{&hdr.dcid, &hdr.scid} {
+		if off >= len(data) {
+			return nil, errQUICHeader
+		}
+
+		n := int(data[off])
+		off++
+
+		if n > 20 || off+n > len(data) {
+			return nil, errQUICHeader
+		}
+
+		*cid = data[off : off+n]
+		off += n
+	}
+
+	hdr.offset = off
+
+	return hdr, nil
+}
+
+// parseLength parses the token of an Initial packet and the length field,
+// to determine the offset of the packet number and the end of the packet.
+func (hdr *quicLongHeader) parseLength(data []byte, initial bool) error {
+	off := hdr.offset
+
+	if initial {
+		n, l := quicVarint(data[off:])
+		if l == 0 || uint64(len(data)-off-l) < n {
+			return errQUICHeader
+		}
+
+		hdr.tokenLength = n
+		off += l + int(n)
+	}
+
+	n, l := quicVarint(data[off:])
+	if l == 0 || uint64(len(data)-off-l) < n {
+		return errQUICHeader
+	}
+
+	hdr.pnOffset = off + l
+	hdr.end = hdr.pnOffset + int(n)
+
+	return nil
+}
+
+// quicVarint decodes a variable length integer and returns the value and the number of bytes read,
+// or zero bytes if the data is too short.
+func quicVarint(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+
+	l := 1 << (data[0] >> 6)
+	if len(data) < l {
+		return 0, 0
+	}
+
+	v := uint64(data[0] & 0x3f)
+	for _, b := range data[1:l] {
+		v = v<<8 | uint64(b)
+	}
+
+	return v, l
+}
+
+// quicInitialKeys are the keys protecting the Initial packets sent by the client.
+type quicInitialKeys struct {
+	aead cipher.AEAD
+	iv   []byte
+	hp   cipher.Block
+}
+
+// newQUICInitialKeys derives the client Initial keys from the destination connection id of the first Initial packet, see RFC 9001 section 5.2.
+func newQUICInitialKeys(v *quicVersion, dcid []byte) *quicInitialKeys {
+	var (
+		initial = hkdf.Extract(sha256.New, dcid, v.salt)
+		client  = hkdfExpandLabel(initial, "client in", sha256.Size)
+		key     = hkdfExpandLabel(client, v.labelPrefix+" key", 16)
+		hp      = hkdfExpandLabel(client, v.labelPrefix+" hp", 16)
+	)
+
+	// errors can only occur for invalid key sizes
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	hpBlock, _ := aes.NewCipher(hp)
+
+	return &quicInitialKeys{
+		aead: aead,
+		iv:   hkdfExpandLabel(client, v.labelPrefix+" iv", 12),
+		hp:   hpBlock,
+	}
+}
+
+// hkdfExpandLabel implements HKDF-Expand-Label from RFC 8446 section 7.1 with an empty context.
+func hkdfExpandLabel(secret []byte, label string, length int) []byte {
+	label = "tls13 " + label
+
+	info := make([]byte, 0, 4+len(label))
+	info = append(info, byte(length>>8), byte(length), byte(len(label)))
+	info = append(info, label...)
+	info = append(info, 0)
+
+	out := make([]byte, length)
+	_, _ = hkdf.Expand(sha256.New, secret, info).Read(out)
+
+	return out
+}
+
+// open removes the header protection and decrypts the payload of a packet.
+// The packet is copied, since removing the header protection modifies it.
+func (k *quicInitialKeys) open(packet []byte, pnOffset int) ([]byte, error) {
+	// the sample for the header protection starts 4 bytes after the packet number offset
+	if len(packet) < pnOffset+4+aes.BlockSize {
+		return nil, errQUICProtection
+	}
+
+	pkt := append([]byte(nil), packet...)
+
+	mask := make([]byte, aes.BlockSize)
+	k.hp.Encrypt(mask, pkt[pnOffset+4:pnOffset+4+aes.BlockSize])
+
+	pkt[0] ^= mask[0] & 0x0f
+	pnLen := int(pkt[0]&0x3) + 1
+
+	var pn uint64
+	for i := 0; i < pnLen; i++ {
+		pkt[pnOffset+i] ^= mask[1+i]
+		pn = pn<<8 | uint64(pkt[pnOffset+i])
+	}
+
+	nonce := append([]byte(nil), k.iv...)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(pn >> (8 * i))
+	}
+
+	payload, err := k.aead.Open(nil, nonce, pkt[pnOffset+pnLen:], pkt[:pnOffset+pnLen])
+	if err != nil {
+		return nil, errQUICProtection
+	}
+
+	return payload, nil
+}
+
+// quicCryptoFrames calls fn for all CRYPTO frames in the decrypted payload of an Initial packet.
+func quicCryptoFrames(payload []byte, fn func(offset uint64, data []byte)) error {
+	for len(payload) > 0 {
+		typ := payload[0]
+		payload = payload[1:]
+
+		switch typ {
+		case 0x00, 0x01: // PADDING and PING
+		case 0x02, 0x03: // ACK
+			// largest acknowledged, delay, range count and first range
+			var fields [4]uint64
+			for i := range fields {
+				v, l := quicVarint(payload)
+				if l == 0 {
+					return errQUICFrame
+				}
+
+				fields[i] = v
+				payload = payload[l:]
+			}
+
+			// gap and length of each additional range, followed by three ECN counts for ACK_ECN
+			n := 2 * fields[2]
+			if typ == 0x03 {
+				n += 3
+			}
+
+			for i := uint64(0); i < n; i++ {
+				_, l := quicVarint(payload)
+				if l == 0 {
+					return errQUICFrame
+				}
+
+				payload = payload[l:]
+			}
+		case 0x06: // CRYPTO
+			offset, l := quicVarint(payload)
+			if l == 0 {
+				return errQUICFrame
+			}
+
+			payload = payload[l:]
+
+			n, l := quicVarint(payload)
+			if l == 0 || uint64(len(payload)-l) < n {
+				return errQUICFrame
+			}
+
+			fn(offset, payload[l:l+int(n)])
+			payload = payload[l+int(n):]
+		case 0x1c: // CONNECTION_CLOSE
+			return nil
+		default:
+			return errQUICFrame
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestQUICInitialKeys(t *testing.T) {
+	// test vectors from RFC 9001 appendix A.1 and RFC 9369 appendix A.1
+	tests := []struct {
+		version *quicVersion
+		key     string
+		iv      string
+		hp      string
+	}{
+		{quicV1, "1f369613dd76d5467730efcbe3b1a22d", "fa044b2f42a3fd3b46fb255c", "9f50449e04a0e810283a1e9933adedd2"},
+		{quicV2, "8b1a0bc121284290a29e0971b5cd045d", "91f73e2351d8fa91660e909f", "45b95e15235d6f45a6b19cbcb0294ba9"},
+	}
+
+	dcid := mustDecodeHex("8394c8f03e515708")
+
+	for _, test := range tests {
+		var (
+			initial = hkdf.Extract(sha256.New, dcid, test.version.salt)
+			client  = hkdfExpandLabel(initial, "client in", 32)
+			prefix  = test.version.labelPrefix
+		)
+
+		for _, c := range []struct{ label, expected string }{
+			{prefix + " key", test.key},
+			{prefix + " iv", test.iv},
+			{prefix + " hp", test.hp},
+		} {
+			if out := hex.EncodeToString(hkdfExpandLabel(client, c.label, len(c.expected)/2)); out != c.expected {
+				t.Errorf("%s %s: expected %s, got %s", test.version.name, c.label, c.expected, out)
+			}
+		}
+	}
+}
+
+func TestQUICTracker(t *testing.T) {
+	var (
+		dcid    = mustDecodeHex("8394c8f03e515708")
+		scid    = mustDecodeHex("c6b336557d3b7b09")
+		hello   = testClientHello("example.com", "h3")
+		tracker = newQUICTracker(func(types.AuditRecord) {})
+	)
+
+	// the ClientHello is split across two Initial packets
+	first := sealQUICInitial(t, quicV1, 1, dcid, scid, 0, append(testCryptoFrame(0, hello[:40]), 0x01))
+	second := sealQUICInitial(t, quicV1, 1, dcid, scid, 1, testCryptoFrame(40, hello[40:]))
+
+	if r := tracker.add(1, "10.0.0.1", "1.1.1.1", 50000, 443, first); r != nil {
+		t.Fatal("expected no record for an incomplete ClientHello", r)
+	}
+
+	// packets that are not sent by the client can not be decrypted and are ignored
+	if r := tracker.add(2, "1.1.1.1", "10.0.0.1", 443, 50000, second[:len(second)-1]); r != nil {
+		t.Fatal("expected no record for a corrupted packet", r)
+	}
+
+	r := tracker.add(3, "10.0.0.1", "1.1.1.1", 50000, 443, second)
+	if r == nil {
+		t.Fatal("expected a record once the ClientHello is complete")
+	}
+
+	if r.Version != "v1" || r.DCID != "8394c8f03e515708" || r.SCID != "c6b336557d3b7b09" || !r.Decrypted || r.NumPackets != 2 || r.Timestamp != 1 {
+		t.Fatal("unexpected record", r)
+	}
+
+	if r.SNI != "example.com" || len(r.ALPNs) != 1 || r.ALPNs[0] != "h3" || r.Ja3 == "" {
+		t.Fatal("unexpected ClientHello fields", r)
+	}
+
+	// retransmissions of a completed handshake are not reported again
+	if r = tracker.add(4, "10.0.0.1", "1.1.1.1", 50000, 443, second); r != nil {
+		t.Fatal("expected no record for a retransmission", r)
+	}
+
+	// an incomplete handshake is reported when flushing
+	tracker.add(5, "10.0.0.2", "1.1.1.1", 50001, 443, sealQUICInitial(t, quicV2, 0x6b3343cf, dcid, scid, 0, testCryptoFrame(0, hello[:40])))
+
+	records := tracker.flush()
+	if len(records) != 1 || records[0].Version != "v2" || records[0].SNI != "" || !records[0].Decrypted {
+		t.Fatal("unexpected records after flush", records)
+	}
+
+	// only the header is visible for unknown versions
+	unknown := []byte{0xc0, 0x0a, 0x1a, 0x2a, 0x3a, 0x01, 0xaa, 0x00, 0x00}
+	if r = tracker.add(6, "10.0.0.3", "1.1.1.1", 50002, 8443, unknown); r != nil {
+		t.Fatal("expected no record for unknown versions on other ports", r)
+	}
+
+	r = tracker.add(7, "10.0.0.3", "1.1.1.1", 50002, 443, unknown)
+	if r == nil || r.Version != "0x0a1a2a3a" || r.DCID != "aa" || r.Decrypted {
+		t.Fatal("unexpected record for unknown version", r)
+	}
+}
+
+func TestQUICVarint(t *testing.T) {
+	for _, test := range []struct {
+		in     string
+		value  uint64
+		length int
+	}{
+		// examples from RFC 9000 appendix A.1
+		{"c2197c5eff14e88c", 151288809941952652, 8},
+		{"9d7f3e7d", 494878333, 4},
+		{"7bbd", 15293, 2},
+		{"25", 37, 1},
+		{"7b", 0, 0},
+	} {
+		v, l := quicVarint(mustDecodeHex(test.in))
+		if v != test.value || l != test.length {
+			t.Errorf("%s: expected %d (%d bytes), got %d (%d bytes)", test.in, test.value, test.length, v, l)
+		}
+	}
+}
+
+// sealQUICInitial creates a client Initial packet with the given frames, padded to the minimum size of 1200 bytes.
+func sealQUICInitial(t *testing.T, v *quicVersion, version uint32, dcid, scid []byte, pn uint16, frames []byte) []byte {
+	t.Helper()
+
+	payload := make([]byte, 1200-64)
+	copy(payload, frames)
+
+	hdr := []byte{0xc0 | v.initialType<<4 | 0x01, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(hdr[1:], version)
+	hdr = append(hdr, byte(len(dcid)))
+	hdr = append(hdr, dcid...)
+	hdr = append(hdr, byte(len(scid)))
+	hdr = append(hdr, scid...)
+
+	// empty token, 2 byte length and packet number
+	length := 2 + len(payload) + 16
+	hdr = append(hdr, 0x00, 0x40|byte(length>>8), byte(length), byte(pn>>8), byte(pn))
+	pnOffset := len(hdr) - 2
+
+	k := newQUICInitialKeys(v, dcid)
+
+	nonce := append([]byte(nil), k.iv...)
+	nonce[len(nonce)-1] ^= byte(pn)
+	nonce[len(nonce)-2] ^= byte(pn >> 8)
+
+	packet := k.aead.Seal(hdr, nonce, payload, hdr)
+
+	mask := make([]byte, aes.BlockSize)
+	k.hp.Encrypt(mask, packet[pnOffset+4:pnOffset+4+aes.BlockSize])
+
+	packet[0] ^= mask[0] & 0x0f
+	packet[pnOffset] ^= mask[1]
+	packet[pnOffset+1] ^= mask[2]
+
+	return packet
+}
+
+func testCryptoFrame(offset int, data []byte) []byte {
+	return append([]byte{0x06, 0x40 | byte(offset>>8), byte(offset), 0x40 | byte(len(data)>>8), byte(len(data))}, data...)
+}
+
+// testClientHello returns a ClientHello handshake message with the server name and ALPN extensions.
+func testClientHello(sni, alpn string) []byte {
+	var ext bytes.Buffer
+
+	// server name
+	ext.Write([]byte{0x00, 0x00, 0x00, byte(len(sni) + 5), 0x00, byte(len(sni) + 3), 0x00, 0x00, byte(len(sni))})
+	ext.WriteString(sni)
+
+	// application layer protocol negotiation
+	ext.Write([]byte{0x00, 0x10, 0x00, byte(len(alpn) + 3), 0x00, byte(len(alpn) + 1), byte(len(alpn))})
+	ext.WriteString(alpn)
+
+	var body bytes.Buffer
+
+	body.Write([]byte{0x03, 0x03})
+	body.Write(make([]byte, 32))               // random
+	body.Write([]byte{0x00})                   // session id
+	body.Write([]byte{0x00, 0x02, 0x13, 0x01}) // cipher suites
+	body.Write([]byte{0x01, 0x00})             // compression methods
+	body.Write([]byte{0x00, byte(ext.Len())})  // extensions length
+	body.Write(ext.Bytes())
+
+	return append([]byte{0x01, 0x00, byte(body.Len() >> 8), byte(body.Len())}, body.Bytes()...)
+}
@@ -0,0 +1,262 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/dreadl0ck/netcap/resolvers"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// minVolumeBaseline is the number of buckets that must have been observed for a host,
+// before its outbound volume is compared against the baseline.
+const minVolumeBaseline = 10
+
+// volumes is initialized when the decoder is enabled via the configuration.
+var volumes *volumeTracker
+
+var volumeAnomalyDecoder = newPacketDecoder(
+	types.Type_NC_VolumeAnomaly,
+	"VolumeAnomaly",
+	"A VolumeAnomaly is a time bucket in which an internal host sent significantly more data than in its recent baseline",
+	func(d *Decoder) error {
+		if !conf.VolumeAnomalies {
+			volumes = nil
+
+			return nil
+		}
+
+		homeNets, err := parseNetworks(conf.HomeNetworks)
+		if err != nil {
+			return err
+		}
+
+		volumes = newVolumeTracker(homeNets, conf.VolumeBucket, conf.VolumeThreshold, conf.VolumeWindow, conf.VolumeMinBytes)
+
+		return nil
+	},
+	func(p gopacket.Packet) proto.Message {
+		if volumes == nil {
+			return nil
+		}
+
+		nl := p.NetworkLayer()
+		if nl == nil {
+			return nil
+		}
+
+		flow := nl.NetworkFlow()
+
+		if a := volumes.add(
+			net.IP(flow.Src().Raw()),
+			net.IP(flow.Dst().Raw()),
+			p.Metadata().Timestamp.UnixNano(),
+			len(p.Data()),
+		); a != nil {
+			return a
+		}
+
+		return nil
+	},
+	func(d *Decoder) error {
+		if volumes == nil {
+			return nil
+		}
+
+		// evaluate the buckets that are still open
+		for _, a := range volumes.flush() {
+			d.write(a)
+		}
+
+		return nil
+	},
+)
+
+// hostVolume is the outbound traffic volume of a single host.
+type hostVolume struct {
+	// index of the current bucket
+	bucket int64
+
+	// outbound bytes and packets in the current bucket
+	bytes   uint64
+	packets int64
+
+	// outbound bytes of the previous buckets, oldest first
+	history []float64
+}
+
+// volumeTracker keeps rolling statistics of the outbound traffic volume per internal host,
+// and flags buckets that exceed the baseline of the host by the configured number of standard deviations.
+type volumeTracker struct {
+	sync.Mutex
+
+	hosts    map[string]*hostVolume
+	homeNets []*net.IPNet
+
+	bucket    int64
+	threshold float64
+	window    int
+	minBytes  uint64
+}
+
+func newVolumeTracker(homeNets []*net.IPNet, bucket time.Duration, threshold float64, window int, minBytes uint64) *volumeTracker {
+	if bucket <= 0 {
+		bucket = time.Minute
+	}
+
+	if window < minVolumeBaseline {
+		window = minVolumeBaseline
+	}
+
+	return &volumeTracker{
+		hosts:     make(map[string]*hostVolume),
+		homeNets:  homeNets,
+		bucket:    int64(bucket),
+		threshold: threshold,
+		window:    window,
+		minBytes:  minBytes,
+	}
+}
+
+// isInternal checks whether the address belongs to one of the home networks,
+// or to the private address space if no home networks have been configured.
+func (v *volumeTracker) isInternal(ip net.IP) bool {
+	if len(v.homeNets) == 0 {
+		return resolvers.IsPrivateIP(ip)
+	}
+
+	return containsIP(v.homeNets, ip)
+}
+
+// add accounts a packet to the outbound volume of its source, if it is sent from an internal host to an external one.
+// If the packet starts a new bucket for the host, the previous bucket is evaluated
+// and an audit record is returned if it is anomalous.
+func (v *volumeTracker) add(src, dst net.IP, ts int64, size int) *types.VolumeAnomaly {
+	if dst.IsMulticast() || dst.Equal(net.IPv4bcast) || !v.isInternal(src) || v.isInternal(dst) {
+		return nil
+	}
+
+	var (
+		addr   = src.String()
+		bucket = ts / v.bucket
+		a      *types.VolumeAnomaly
+	)
+
+	v.Lock()
+	defer v.Unlock()
+
+	h, ok := v.hosts[addr]
+	if !ok {
+		h = &hostVolume{bucket: bucket}
+		v.hosts[addr] = h
+	}
+
+	// packets processed out of order by the workers are accounted to the current bucket
+	if bucket > h.bucket {
+		a = v.closeBucket(addr, h)
+
+		// buckets without outbound traffic are part of the baseline as well
+		for i := h.bucket + 1; i < bucket && i <= h.bucket+int64(v.window); i++ {
+			v.push(h, 0)
+		}
+
+		h.bucket = bucket
+	}
+
+	h.bytes += uint64(size)
+	h.packets++
+
+	return a
+}
+
+// flush evaluates the current buckets of all hosts.
+func (v *volumeTracker) flush() (anomalies []*types.VolumeAnomaly) {
+	v.Lock()
+	defer v.Unlock()
+
+	for addr, h := range v.hosts {
+		if a := v.closeBucket(addr, h); a != nil {
+			anomalies = append(anomalies, a)
+		}
+	}
+
+	return anomalies
+}
+
+// closeBucket compares the current bucket of the host against its baseline,
+// adds it to the baseline and resets the counters.
+func (v *volumeTracker) closeBucket(addr string, h *hostVolume) (a *types.VolumeAnomaly) {
+	if len(h.history) >= minVolumeBaseline && h.bytes >= v.minBytes {
+		mean, stdDev := meanStdDev(h.history)
+
+		// a perfectly constant baseline would flag every deviation,
+		// so a standard deviation of at least one byte is assumed.
+		score := (float64(h.bytes) - mean) / math.Max(stdDev, 1)
+
+		if score > v.threshold {
+			a = &types.VolumeAnomaly{
+				Timestamp:  h.bucket * v.bucket,
+				SrcIP:      addr,
+				Duration:   v.bucket,
+				Bytes:      h.bytes,
+				NumPackets: h.packets,
+				Mean:       mean,
+				StdDev:     stdDev,
+				Score:      score,
+				NumBuckets: int32(len(h.history)),
+			}
+		}
+	}
+
+	v.push(h, float64(h.bytes))
+
+	h.bytes = 0
+	h.packets = 0
+
+	return a
+}
+
+// push adds the volume of a bucket to the baseline and drops the oldest bucket once the window is full.
+func (v *volumeTracker) push(h *hostVolume, bytes float64) {
+	h.history = append(h.history, bytes)
+	if len(h.history) > v.window {
+		h.history = h.history[len(h.history)-v.window:]
+	}
+}
+
+// meanStdDev returns the mean and the population standard deviation of the values.
+func meanStdDev(values []float64) (mean, stdDev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	for _, val := range values {
+		mean += val
+	}
+
+	mean /= float64(len(values))
+
+	for _, val := range values {
+		stdDev += (val - mean) * (val - mean)
+	}
+
+	return mean, math.Sqrt(stdDev / float64(len(values)))
+}
@@ -0,0 +1,69 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package packet
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestVolumeTracker(t *testing.T) {
+	var (
+		v        = newVolumeTracker(nil, time.Minute, 3, 60, 1024)
+		host     = net.ParseIP("192.168.1.10")
+		external = net.ParseIP("8.8.8.8")
+		minute   = int64(time.Minute)
+	)
+
+	// a stable baseline of roughly 10KB per minute
+	for i := int64(0); i < 30; i++ {
+		size := 10000 + int(i%3)*100
+		if a := v.add(host, external, i*minute, size); a != nil {
+			t.Fatal("unexpected anomaly in bucket", i, a)
+		}
+	}
+
+	// internal and inbound traffic is not accounted
+	if a := v.add(host, net.ParseIP("192.168.1.20"), 30*minute, 1<<30); a != nil {
+		t.Fatal("unexpected anomaly for internal traffic", a)
+	}
+
+	if a := v.add(external, host, 30*minute, 1<<30); a != nil {
+		t.Fatal("unexpected anomaly for inbound traffic", a)
+	}
+
+	// exfiltration spike
+	v.add(host, external, 30*minute, 5<<20)
+
+	a := v.add(host, external, 31*minute, 10000)
+	if a == nil {
+		t.Fatal("expected volume anomaly")
+	}
+
+	if a.SrcIP != "192.168.1.10" || a.Bytes != 5<<20 || a.Timestamp != 30*minute || a.NumBuckets != 30 {
+		t.Fatal("unexpected anomaly", a)
+	}
+
+	if a.Score <= 3 {
+		t.Fatal("expected a score above the threshold, got", a.Score)
+	}
+
+	// the current bucket is evaluated when flushing
+	v.add(host, external, 31*minute, 50<<20)
+
+	if anomalies := v.flush(); len(anomalies) != 1 {
+		t.Fatal("expected one anomaly on flush, got", len(anomalies))
+	}
+}
@@ -12,9 +12,14 @@ import (
 	"github.com/dreadl0ck/netcap/decoder/stream/credentials"
 	"github.com/dreadl0ck/netcap/decoder/stream/exploit"
 	"github.com/dreadl0ck/netcap/decoder/stream/file"
+	"github.com/dreadl0ck/netcap/decoder/stream/http"
 	"github.com/dreadl0ck/netcap/decoder/stream/mail"
+	"github.com/dreadl0ck/netcap/decoder/stream/ntlm"
+	"github.com/dreadl0ck/netcap/decoder/stream/secrets"
 	"github.com/dreadl0ck/netcap/decoder/stream/service"
 	"github.com/dreadl0ck/netcap/decoder/stream/software"
+	"github.com/dreadl0ck/netcap/decoder/stream/tls"
+	"github.com/dreadl0ck/netcap/decoder/stream/tunnel"
 	"github.com/dreadl0ck/netcap/decoder/stream/vulnerability"
 
 	"github.com/mgutz/ansi"
@@ -43,13 +48,26 @@ var DefaultAbstractDecoders = []core.DecoderAPI{
 	vulnerability.Decoder,
 	credentials.Decoder,
 	alert.Decoder,
+	secrets.Decoder,
+	tls.CertificateDecoder,
+	http.WebSocketDecoder,
+	tunnel.Decoder,
+	ntlm.Decoder,
 } // contains all available abstract decoders
 
+// abstractDecoderStreamDecoders maps the names of abstract decoders
+// to the stream decoders that must be enabled to feed them.
+var abstractDecoderStreamDecoders = make(map[string][]string)
+
 // package level init.
 func init() {
 	// collect all names for stream decoders on startup
 	for _, d := range DefaultAbstractDecoders {
 		decoderutils.AllDecoderNames[d.GetName()] = struct{}{}
+
+		if ad, ok := d.(*decoder.AbstractDecoder); ok && len(ad.StreamDecoders) > 0 {
+			abstractDecoderStreamDecoders[ad.Name] = ad.StreamDecoders
+		}
 	}
 }
 
@@ -152,34 +170,48 @@ func InitAbstractDecoders(c *config.Config) (decoders []core.DecoderAPI, err err
 
 		func(d core.DecoderAPI) {
 			w := netio.NewAuditRecordWriter(&netio.WriterConfig{
-				CSV:     c.CSV,
-				Encode:  c.Encode,
-				Label:   c.Label,
-				Proto:   c.Proto,
-				JSON:    c.JSON,
-				Name:    d.GetName(),
-				Type:    d.GetType(),
-				Null:    c.Null,
-				Elastic: c.Elastic,
+				CSV:          c.CSV,
+				CSVDelimiter: c.CSVDelimiter,
+				Encode:       c.Encode,
+				Label:        c.Label,
+				Proto:        c.Proto,
+				JSON:         c.JSON,
+				CBOR:         c.CBOR,
+				Avro:         c.Avro,
+				Name:         d.GetName(),
+				Type:         d.GetType(),
+				Null:         c.Null,
+				Elastic:      c.Elastic,
+				WebSocket:    c.WebSocket,
+				Syslog:       c.Syslog,
+				SyslogConfig: c.SyslogConfig,
+				Kafka:        c.Kafka,
+				KafkaConfig:  c.KafkaConfig,
 				ElasticConfig: netio.ElasticConfig{
-					ElasticAddrs:   c.ElasticAddrs,
-					ElasticUser:    c.ElasticUser,
-					ElasticPass:    c.ElasticPass,
-					KibanaEndpoint: c.KibanaEndpoint,
-					BulkSize:       c.BulkSizeCustom,
+					ElasticAddrs:         c.ElasticAddrs,
+					ElasticUser:          c.ElasticUser,
+					ElasticPass:          c.ElasticPass,
+					KibanaEndpoint:       c.KibanaEndpoint,
+					BulkSize:             c.BulkSizeCustom,
+					ElasticIndex:         c.ElasticIndex,
+					ElasticFlushInterval: c.ElasticFlushInterval,
+					ElasticMaxRetries:    c.ElasticMaxRetries,
 				},
 				Buffer:               c.Buffer,
 				Compress:             c.Compression,
 				Out:                  c.Out,
+				OutDirs:              c.OutDirs,
 				Chan:                 c.Chan,
 				ChanSize:             c.ChanSize,
 				MemBufferSize:        c.MemBufferSize,
+				MaxFileSize:          c.MaxFileSize,
 				Source:               c.Source,
 				Version:              netcap.Version,
 				IncludesPayloads:     c.IncludePayloads,
 				StartTime:            time.Now(),
 				CompressionBlockSize: c.CompressionBlockSize,
 				CompressionLevel:     c.CompressionLevel,
+				CompressionFormat:    c.CompressionFormat,
 			})
 			d.SetWriter(w)
 
@@ -0,0 +1,124 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package stream
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+
+	"github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/decoder/stream/tls"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+// certificateHandshake creates a TLS record with a Certificate handshake message for a self signed certificate.
+func certificateHandshake(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u24 := func(n int) []byte {
+		return []byte{byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+
+	certs := append(u24(len(der)), der...)
+	msg := append(append([]byte{11}, u24(len(certs)+3)...), u24(len(certs))...)
+	msg = append(msg, certs...)
+
+	return append([]byte{22, 0x03, 0x03, byte(len(msg) >> 8), byte(len(msg))}, msg...)
+}
+
+func TestIncludeAbstractDecoderOnly(t *testing.T) {
+	out, err := ioutil.TempDir("", "netcap-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+
+	streamDecoders, abstractDecoders := DefaultStreamDecoders, DefaultAbstractDecoders
+	defer func() {
+		DefaultStreamDecoders, DefaultAbstractDecoders = streamDecoders, abstractDecoders
+	}()
+
+	// restore a copy, the init functions replace the package level selections
+	DefaultStreamDecoders = make(map[int32]core.StreamDecoderAPI, len(streamDecoders))
+	for port, d := range streamDecoders {
+		DefaultStreamDecoders[port] = d
+	}
+
+	DefaultAbstractDecoders = append([]core.DecoderAPI(nil), abstractDecoders...)
+
+	c := &config.Config{
+		IncludeDecoders: tls.CertificateDecoder.Name,
+		Null:            true,
+		Out:             out,
+	}
+	config.Instance = c
+
+	decoders, err := InitDecoders(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoders) != 1 || decoders[0].GetName() != tls.Decoder.Name {
+		t.Fatal("expected the TLS stream decoder to be enabled, got", decoders)
+	}
+
+	if _, err = InitAbstractDecoders(c); err != nil {
+		t.Fatal(err)
+	}
+
+	conv := &core.ConversationInfo{
+		Data: core.DataFragments{
+			&core.StreamData{
+				RawData:            certificateHandshake(t),
+				Dir:                reassembly.TCPDirServerToClient,
+				CaptureInformation: gopacket.CaptureInfo{Timestamp: time.Now()},
+			},
+		},
+		FirstServerPacket: time.Now(),
+	}
+
+	decoders[0].GetReaderFactory().New(conv).Decode()
+
+	if n := tls.CertificateDecoder.NumRecords(); n != 1 {
+		t.Fatal("expected one server certificate record, got", n)
+	}
+}
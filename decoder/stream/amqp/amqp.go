@@ -0,0 +1,89 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package amqp
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var amqpLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_AMQP,
+	Name:        "AMQP",
+	Description: "The Advanced Message Queuing Protocol is used by clients to publish and consume messages on message brokers such as RabbitMQ",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		amqpLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"amqp",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return bytes.HasPrefix(client, protocolHeader)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return amqpLog.Sync()
+	},
+	Factory: &amqpReader{},
+	Typ:     core.TCP,
+}
+
+// protocolHeader is sent by the client to start a connection with protocol version 0-9-1.
+var protocolHeader = []byte{'A', 'M', 'Q', 'P', 0, 0, 9, 1}
+
+const (
+	// length of the frame header: the frame type, the channel and the payload size.
+	headerSize = 7
+
+	// every frame is terminated with this byte.
+	frameEnd = 0xCE
+)
+
+// frame types.
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+)
+
+// methods are identified by the class id in the upper and the method id in the lower 16 bits.
+const (
+	connectionStart = 10<<16 | 10
+	connectionOpen  = 10<<16 | 40
+	channelOpen     = 20<<16 | 10
+	basicConsume    = 60<<16 | 20
+	basicPublish    = 60<<16 | 40
+)
+
+// names of the methods that are recorded.
+var methodNames = map[uint32]string{
+	connectionStart: "connection.start",
+	connectionOpen:  "connection.open",
+	channelOpen:     "channel.open",
+	basicConsume:    "basic.consume",
+	basicPublish:    "basic.publish",
+}
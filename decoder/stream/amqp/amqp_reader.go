@@ -0,0 +1,368 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package amqp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// frame is an AMQP frame without the frame end byte.
+type frame struct {
+	typ       byte
+	channel   uint16
+	payload   []byte
+	timestamp int64
+}
+
+// parseFrames parses the frames of one direction of the conversation, beginning at the offset.
+// The timestamp of a frame is taken from the fragment it starts in.
+func parseFrames(data []byte, offset int, fragments streamutils.Fragments) (frames []*frame) {
+	for offset+headerSize <= len(data) {
+		var (
+			typ  = data[offset]
+			size = int(binary.BigEndian.Uint32(data[offset+3:]))
+			end  = offset + headerSize + size
+		)
+
+		if typ != frameMethod && typ != frameHeader && typ != frameBody && typ != frameHeartbeat {
+			amqpLog.Debug("invalid frame type", zap.Int("offset", offset), zap.Uint8("type", typ))
+
+			break
+		}
+
+		if end >= len(data) {
+			amqpLog.Debug("incomplete frame", zap.Int("offset", offset), zap.Int("size", size))
+
+			break
+		}
+
+		// a missing frame end means the data is not framed as expected
+		if data[end] != frameEnd {
+			amqpLog.Debug("invalid frame end", zap.Int("offset", offset), zap.Uint8("end", data[end]))
+
+			break
+		}
+
+		frames = append(frames, &frame{
+			typ:       typ,
+			channel:   binary.BigEndian.Uint16(data[offset+1:]),
+			payload:   data[offset+headerSize : end],
+			timestamp: fragments.Timestamp(offset),
+		})
+
+		offset = end + 1
+	}
+
+	return frames
+}
+
+// fieldReader reads the fields of a method frame.
+// Reading beyond the end of the data sets the failed flag and returns zero values.
+type fieldReader struct {
+	data   []byte
+	failed bool
+}
+
+func (r *fieldReader) next(n int) []byte {
+	if r.failed || n > len(r.data) {
+		r.failed = true
+
+		return nil
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b
+}
+
+func (r *fieldReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+
+	return 0
+}
+
+func (r *fieldReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+// shortString reads a string prefixed with its length as a single byte.
+func (r *fieldReader) shortString() string {
+	b := r.next(1)
+	if b == nil {
+		return ""
+	}
+
+	return string(r.next(int(b[0])))
+}
+
+// longString reads a string prefixed with its length as 32-bit integer.
+func (r *fieldReader) longString() string {
+	return string(r.next(int(r.uint32())))
+}
+
+// sizes of the field values with a fixed length.
+var fieldSizes = map[byte]int{
+	't': 1, // boolean
+	'b': 1, // signed 8-bit
+	'B': 1, // unsigned 8-bit
+	's': 2, // signed 16-bit
+	'u': 2, // unsigned 16-bit
+	'I': 4, // signed 32-bit
+	'i': 4, // unsigned 32-bit
+	'l': 8, // signed 64-bit
+	'f': 4, // float
+	'd': 8, // double
+	'D': 5, // decimal
+	'T': 8, // timestamp
+	'V': 0, // void
+}
+
+// table reads a field table and returns its values of the long string type.
+// Nested tables and arrays are skipped, parsing stops at the first unknown value type.
+func (r *fieldReader) table() map[string]string {
+	var (
+		t      = &fieldReader{data: r.next(int(r.uint32()))}
+		values = make(map[string]string)
+	)
+
+	for len(t.data) > 0 && !t.failed {
+		name := t.shortString()
+
+		typ := t.next(1)
+		if typ == nil {
+			break
+		}
+
+		switch typ[0] {
+		case 'S':
+			values[name] = t.longString()
+		case 'x', 'A', 'F':
+			t.next(int(t.uint32()))
+		default:
+			size, ok := fieldSizes[typ[0]]
+			if !ok {
+				amqpLog.Debug("unknown field type in table", zap.String("name", name), zap.Uint8("type", typ[0]))
+
+				return values
+			}
+
+			t.next(size)
+		}
+	}
+
+	return values
+}
+
+// session tracks the state of the connection.
+type session struct {
+	product     string
+	version     string
+	virtualHost string
+}
+
+// parseMethod parses a method frame and returns the record for it, or nil if the method is not recorded.
+func (s *session) parseMethod(f *frame) *types.AMQP {
+	r := &fieldReader{data: f.payload}
+
+	id := r.uint32()
+	if r.failed {
+		return nil
+	}
+
+	name, ok := methodNames[id]
+	if !ok {
+		return nil
+	}
+
+	record := &types.AMQP{
+		Timestamp: f.timestamp,
+		Channel:   int32(f.channel),
+		Method:    name,
+	}
+
+	switch id {
+	case connectionStart:
+		// protocol version major and minor
+		r.next(2)
+
+		props := r.table()
+		s.product = props["product"]
+		s.version = props["version"]
+
+		record.Mechanisms = r.longString()
+	case connectionOpen:
+		s.virtualHost = r.shortString()
+	case basicConsume:
+		// reserved ticket
+		r.uint16()
+
+		record.Queue = r.shortString()
+		record.ConsumerTag = r.shortString()
+	case basicPublish:
+		// reserved ticket
+		r.uint16()
+
+		record.Exchange = r.shortString()
+		record.RoutingKey = r.shortString()
+	}
+
+	if r.failed {
+		amqpLog.Debug("truncated method", zap.String("method", name))
+	}
+
+	record.Product = s.product
+	record.Version = s.version
+	record.VirtualHost = s.virtualHost
+
+	return record
+}
+
+// methodRecord is a recorded method along with the direction it was sent in.
+type methodRecord struct {
+	*types.AMQP
+	fromServer bool
+}
+
+type amqpReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new AMQP reader.
+func (h *amqpReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &amqpReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the method frames of both directions and writes an audit record for each recorded method.
+func (h *amqpReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	for _, m := range decode(h.conversation.Data) {
+		m.Flow = h.conversation.Ident
+
+		if m.fromServer {
+			m.SrcIP = h.conversation.ServerIP
+			m.SrcPort = h.conversation.ServerPort
+			m.DstIP = h.conversation.ClientIP
+			m.DstPort = h.conversation.ClientPort
+		} else {
+			m.SrcIP = h.conversation.ClientIP
+			m.SrcPort = h.conversation.ClientPort
+			m.DstIP = h.conversation.ServerIP
+			m.DstPort = h.conversation.ServerPort
+		}
+
+		writeAMQP(m.AMQP)
+	}
+}
+
+// decode reassembles both directions of the conversation and returns the recorded methods ordered by time.
+// The server properties of connection.start and the virtual host of connection.open
+// are added to all methods that follow them.
+func decode(data core.DataFragments) []*methodRecord {
+	var (
+		client, server                   []byte
+		clientFragments, serverFragments streamutils.Fragments
+		s                                = new(session)
+		methods                          []*methodRecord
+	)
+
+	for _, d := range data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			clientFragments = append(clientFragments, streamutils.Fragment{
+				Offset:    len(client),
+				Timestamp: d.CaptureInfo().Timestamp.UnixNano(),
+			})
+			client = append(client, d.Raw()...)
+		} else {
+			serverFragments = append(serverFragments, streamutils.Fragment{
+				Offset:    len(server),
+				Timestamp: d.CaptureInfo().Timestamp.UnixNano(),
+			})
+			server = append(server, d.Raw()...)
+		}
+	}
+
+	// the protocol header is skipped if the conversation starts after it
+	var clientOffset int
+	if bytes.HasPrefix(client, protocolHeader) {
+		clientOffset = len(protocolHeader)
+	}
+
+	// a server that does not support the requested version answers with its own protocol header and closes the connection,
+	// otherwise the server starts with connection.start, which is parsed first so its properties are known for the client methods
+	if !bytes.HasPrefix(server, protocolHeader[:4]) {
+		for _, f := range parseFrames(server, 0, serverFragments) {
+			if f.typ != frameMethod {
+				continue
+			}
+
+			if r := s.parseMethod(f); r != nil {
+				methods = append(methods, &methodRecord{AMQP: r, fromServer: true})
+			}
+		}
+	}
+
+	for _, f := range parseFrames(client, clientOffset, clientFragments) {
+		if f.typ != frameMethod {
+			continue
+		}
+
+		if r := s.parseMethod(f); r != nil {
+			methods = append(methods, &methodRecord{AMQP: r})
+		}
+	}
+
+	sort.SliceStable(methods, func(i, j int) bool {
+		return methods[i].Timestamp < methods[j].Timestamp
+	})
+
+	return methods
+}
+
+// writeAMQP writes the audit record and updates the metrics if enabled.
+func writeAMQP(r *types.AMQP) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		amqpLog.Error("failed to write AMQP audit record", zap.Error(err))
+	}
+}
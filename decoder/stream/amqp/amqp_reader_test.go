@@ -0,0 +1,209 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package amqp
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+var (
+	client = reassembly.TCPDirClientToServer
+	server = reassembly.TCPDirServerToClient
+)
+
+type segment struct {
+	dir  reassembly.TCPFlowDirection
+	data []byte
+}
+
+// fragments creates the stream data for the segments, one second apart.
+func fragments(segments ...segment) (data core.DataFragments) {
+	start := time.Unix(1600000000, 0)
+
+	for i, s := range segments {
+		data = append(data, &core.StreamData{
+			RawData: s.data,
+			Dir:     s.dir,
+			CaptureInformation: gopacket.CaptureInfo{
+				Timestamp: start.Add(time.Duration(i) * time.Second),
+			},
+		})
+	}
+
+	return data
+}
+
+// amqpFrame adds the frame header and the frame end to the payload.
+func amqpFrame(typ byte, channel uint16, payload []byte) []byte {
+	f := make([]byte, headerSize, headerSize+len(payload)+1)
+	f[0] = typ
+	binary.BigEndian.PutUint16(f[1:], channel)
+	binary.BigEndian.PutUint32(f[3:], uint32(len(payload)))
+
+	return append(append(f, payload...), frameEnd)
+}
+
+// methodFrame creates a method frame with the encoded arguments.
+func methodFrame(channel uint16, id uint32, args ...[]byte) []byte {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, id)
+
+	for _, a := range args {
+		payload = append(payload, a...)
+	}
+
+	return amqpFrame(frameMethod, channel, payload)
+}
+
+func shortString(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func longString(s string) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(len(s)))
+
+	return append(b, s...)
+}
+
+func table(fields ...[]byte) []byte {
+	var data []byte
+	for _, f := range fields {
+		data = append(data, f...)
+	}
+
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(len(data)))
+
+	return append(b, data...)
+}
+
+func TestDecode(t *testing.T) {
+	var (
+		start = methodFrame(0, connectionStart,
+			[]byte{0, 9},
+			table(
+				// nested tables and other value types are skipped
+				append(append(shortString("capabilities"), 'F'), table(append(shortString("publisher_confirms"), 't', 1))...),
+				append(append(shortString("product"), 'S'), longString("RabbitMQ")...),
+				append(append(shortString("port"), 'I'), 0, 0, 0x16, 0x28),
+				append(append(shortString("version"), 'S'), longString("3.8.9")...),
+			),
+			longString("AMQPLAIN PLAIN"),
+			longString("en_US"),
+		)
+		publish = methodFrame(1, basicPublish, []byte{0, 0}, shortString("orders"), shortString("order.created"), []byte{0})
+		header  = amqpFrame(frameHeader, 1, make([]byte, 14))
+		body    = amqpFrame(frameBody, 1, []byte(`{"id":1}`))
+	)
+
+	methods := decode(fragments(
+		segment{client, protocolHeader},
+		segment{server, start},
+		segment{client, methodFrame(0, 10<<16|11, table(), shortString("PLAIN"), longString("\x00guest\x00guest"), shortString("en_US"))},
+		segment{client, methodFrame(0, connectionOpen, shortString("/shop"), shortString(""), []byte{0})},
+		segment{client, methodFrame(1, channelOpen, shortString(""))},
+		// the method frame is split over two segments
+		segment{client, publish[:9]},
+		segment{client, append(append(publish[9:], header...), body...)},
+		segment{client, methodFrame(1, basicConsume, []byte{0, 0}, shortString("invoices"), shortString("worker-1"), []byte{0}, table())},
+		segment{client, amqpFrame(frameHeartbeat, 0, nil)},
+	))
+
+	expected := []struct {
+		method, vhost, exchange, routingKey, queue, consumerTag string
+		channel                                                 int32
+		fromServer                                              bool
+	}{
+		{method: "connection.start", fromServer: true},
+		{method: "connection.open", vhost: "/shop"},
+		{method: "channel.open", vhost: "/shop", channel: 1},
+		{method: "basic.publish", vhost: "/shop", exchange: "orders", routingKey: "order.created", channel: 1},
+		{method: "basic.consume", vhost: "/shop", queue: "invoices", consumerTag: "worker-1", channel: 1},
+	}
+
+	if len(methods) != len(expected) {
+		t.Fatal("expected", len(expected), "records, got", len(methods))
+	}
+
+	for i, m := range methods {
+		e := expected[i]
+		if m.Method != e.method || m.VirtualHost != e.vhost || m.Exchange != e.exchange || m.RoutingKey != e.routingKey ||
+			m.Queue != e.queue || m.ConsumerTag != e.consumerTag || m.Channel != e.channel || m.fromServer != e.fromServer {
+			t.Errorf("record %d: expected %+v, got %+v (from server: %t)", i, e, m.AMQP, m.fromServer)
+		}
+
+		if m.Product != "RabbitMQ" || m.Version != "3.8.9" {
+			t.Errorf("record %d: unexpected server properties: %q %q", i, m.Product, m.Version)
+		}
+	}
+
+	if methods[0].Mechanisms != "AMQPLAIN PLAIN" {
+		t.Errorf("unexpected mechanisms: %q", methods[0].Mechanisms)
+	}
+
+	// the timestamp of the split frame is taken from the segment it starts in
+	if methods[3].Timestamp != time.Unix(1600000005, 0).UnixNano() {
+		t.Errorf("unexpected timestamp of the publish: %d", methods[3].Timestamp)
+	}
+}
+
+func TestDecodeInvalidFrame(t *testing.T) {
+	publish := methodFrame(1, basicPublish, []byte{0, 0}, shortString("logs"), shortString("app.error"), []byte{0})
+
+	invalid := methodFrame(1, basicPublish, []byte{0, 0}, shortString("other"), shortString(""), []byte{0})
+	invalid[len(invalid)-1] = 0
+
+	methods := decode(fragments(
+		// the conversation starts after the protocol header
+		segment{client, publish},
+		segment{client, invalid},
+		segment{client, publish},
+	))
+
+	if len(methods) != 1 || methods[0].Exchange != "logs" || methods[0].RoutingKey != "app.error" {
+		t.Fatalf("expected a single record before the invalid frame, got %d", len(methods))
+	}
+}
+
+func TestDecodeUnsupportedVersion(t *testing.T) {
+	methods := decode(fragments(
+		segment{client, protocolHeader},
+		// the server answers with the version it supports
+		segment{server, []byte{'A', 'M', 'Q', 'P', 0, 0, 9, 0}},
+	))
+
+	if len(methods) != 0 {
+		t.Fatalf("expected no records, got %d", len(methods))
+	}
+}
+
+func TestParseFramesIncomplete(t *testing.T) {
+	f := methodFrame(0, connectionOpen, shortString("/"), shortString(""), []byte{0})
+
+	if frames := parseFrames(f[:len(f)-1], 0, nil); len(frames) != 0 {
+		t.Fatalf("expected no frames without the frame end, got %d", len(frames))
+	}
+
+	if frames := parseFrames(append(f, f[:5]...), 0, nil); len(frames) != 1 {
+		t.Fatalf("expected a single complete frame, got %d", len(frames))
+	}
+}
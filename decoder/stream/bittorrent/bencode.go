@@ -0,0 +1,122 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package bittorrent
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// limits the nesting of lists and dictionaries, DHT messages are at most three levels deep.
+const maxBencodeDepth = 16
+
+var errInvalidBencode = errors.New("invalid bencoded data")
+
+// bdecode parses a single bencoded value from data and returns it along with the remaining bytes.
+// Dictionaries are returned as map[string]interface{}, lists as []interface{},
+// integers as int64 and byte strings as string.
+func bdecode(data []byte, depth int) (interface{}, []byte, error) {
+	if len(data) == 0 || depth > maxBencodeDepth {
+		return nil, nil, errInvalidBencode
+	}
+
+	switch data[0] {
+	case 'i':
+		end := bytes.IndexByte(data, 'e')
+		if end < 0 {
+			return nil, nil, errInvalidBencode
+		}
+
+		i, err := strconv.ParseInt(string(data[1:end]), 10, 64)
+		if err != nil {
+			return nil, nil, errInvalidBencode
+		}
+
+		return i, data[end+1:], nil
+	case 'l':
+		var (
+			list []interface{}
+			rest = data[1:]
+		)
+
+		for len(rest) > 0 && rest[0] != 'e' {
+			var (
+				v   interface{}
+				err error
+			)
+
+			v, rest, err = bdecode(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			list = append(list, v)
+		}
+
+		if len(rest) == 0 {
+			return nil, nil, errInvalidBencode
+		}
+
+		return list, rest[1:], nil
+	case 'd':
+		var (
+			dict = make(map[string]interface{})
+			rest = data[1:]
+		)
+
+		for len(rest) > 0 && rest[0] != 'e' {
+			var (
+				k, v interface{}
+				err  error
+			)
+
+			k, rest, err = bdecode(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			key, ok := k.(string)
+			if !ok {
+				return nil, nil, errInvalidBencode
+			}
+
+			v, rest, err = bdecode(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			dict[key] = v
+		}
+
+		if len(rest) == 0 {
+			return nil, nil, errInvalidBencode
+		}
+
+		return dict, rest[1:], nil
+	default:
+		// byte string: <length>:<contents>
+		sep := bytes.IndexByte(data, ':')
+		if sep <= 0 {
+			return nil, nil, errInvalidBencode
+		}
+
+		n, err := strconv.Atoi(string(data[:sep]))
+		if err != nil || n < 0 || len(data[sep+1:]) < n {
+			return nil, nil, errInvalidBencode
+		}
+
+		return string(data[sep+1 : sep+1+n]), data[sep+1+n:], nil
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package bittorrent
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var bittorrentLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_BitTorrent,
+	Name:        "BitTorrent",
+	Description: "BitTorrent is a peer-to-peer file sharing protocol, peers are located via the distributed hash table (DHT) and exchange data over TCP or uTP",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		bittorrentLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"bittorrent",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		// BitTorrent uses random ports, so the conversations are identified by their contents
+		return isHandshake(client) ||
+			isHandshake(server) ||
+			isDHTMessage(client) ||
+			isUTPPacket(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return bittorrentLog.Sync()
+	},
+	Factory: &bittorrentReader{},
+	Typ:     core.All,
+}
+
+const (
+	// the peer handshake starts with the length of the protocol identifier, followed by the identifier itself.
+	protocolIdentifier = "\x13BitTorrent protocol"
+
+	// handshake: identifier (20 bytes), reserved (8 bytes), info_hash (20 bytes), peer_id (20 bytes)
+	handshakeSize = 68
+	infoHashSize  = 20
+	peerIDSize    = 20
+
+	// minimum size of an uTP packet header
+	utpHeaderSize = 20
+
+	// uTP version and packet types
+	utpVersion = 1
+	utpStData  = 0
+	utpStSyn   = 4
+)
+
+// isHandshake checks whether the data starts with the peer handshake.
+func isHandshake(data []byte) bool {
+	return len(data) >= len(protocolIdentifier) && bytes.HasPrefix(data, []byte(protocolIdentifier))
+}
+
+// isDHTMessage checks whether the data is a bencoded DHT query, response or error.
+func isDHTMessage(data []byte) bool {
+	if len(data) < 12 || !bytes.HasPrefix(data, []byte("d1:")) {
+		return false
+	}
+
+	return bytes.Contains(data, []byte("1:y1:q")) ||
+		bytes.Contains(data, []byte("1:y1:r")) ||
+		bytes.Contains(data, []byte("1:y1:e"))
+}
+
+// isUTPPacket checks whether the data starts with an uTP connection setup, or a data packet carrying the peer handshake.
+func isUTPPacket(data []byte) bool {
+	if len(data) < utpHeaderSize || data[0]&0x0f != utpVersion {
+		return false
+	}
+
+	switch data[0] >> 4 {
+	case utpStSyn:
+		return data[1] <= 2 && len(data) == utpHeaderSize
+	case utpStData:
+		return isHandshake(utpPayload(data))
+	}
+
+	return false
+}
+
+// utpPayload returns the payload of an uTP packet, after the header and the chain of extensions.
+func utpPayload(data []byte) []byte {
+	if len(data) < utpHeaderSize {
+		return nil
+	}
+
+	var (
+		ext     = data[1]
+		payload = data[utpHeaderSize:]
+	)
+
+	for ext != 0 {
+		if len(payload) < 2 || len(payload) < 2+int(payload[1]) {
+			return nil
+		}
+
+		ext = payload[0]
+		payload = payload[2+int(payload[1]):]
+	}
+
+	return payload
+}
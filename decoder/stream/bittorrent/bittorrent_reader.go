@@ -0,0 +1,329 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package bittorrent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/dreadl0ck/gopacket/layers"
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// limits the number of distinct values collected for each field of a single conversation
+const maxEntries = 100
+
+// protocols identified in a conversation.
+const (
+	protocolPeer = "Peer"
+	protocolUTP  = "uTP"
+	protocolDHT  = "DHT"
+)
+
+// clients maps the two letter codes of azureus style peer ids to the client software.
+var clients = map[string]string{
+	"AZ": "Vuze",
+	"BC": "BitComet",
+	"BT": "BitTorrent",
+	"DE": "Deluge",
+	"FD": "Free Download Manager",
+	"KT": "KTorrent",
+	"LT": "libtorrent",
+	"lt": "libTorrent",
+	"qB": "qBittorrent",
+	"TR": "Transmission",
+	"UT": "µTorrent",
+	"UM": "µTorrent Mac",
+	"WW": "WebTorrent",
+}
+
+type bittorrentReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new bittorrent reader.
+func (h *bittorrentReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &bittorrentReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the peer handshakes and DHT messages of the conversation
+// and writes an audit record with the info hashes and peers.
+func (h *bittorrentReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil || len(h.conversation.Data) == 0 {
+		return
+	}
+
+	var (
+		clientBuf   bytes.Buffer
+		serverBuf   bytes.Buffer
+		bytesClient int
+		bytesServer int
+		udp         = h.conversation.Data[0].Transport().EndpointType() == layers.EndpointUDPPort
+		b           = &types.BitTorrent{
+			Timestamp: h.conversation.FirstClientPacket.UnixNano(),
+			Flow:      h.conversation.Ident,
+			SrcIP:     h.conversation.ClientIP,
+			SrcPort:   h.conversation.ClientPort,
+			DstIP:     h.conversation.ServerIP,
+			DstPort:   h.conversation.ServerPort,
+			Transport: "TCP",
+		}
+	)
+
+	if udp {
+		b.Transport = "UDP"
+	}
+
+	for _, d := range h.conversation.Data {
+		var (
+			raw        = d.Raw()
+			fromClient = d.Direction() == reassembly.TCPDirClientToServer
+		)
+
+		if fromClient {
+			bytesClient += len(raw)
+		} else {
+			bytesServer += len(raw)
+		}
+
+		if udp {
+			h.parseDatagram(b, raw, fromClient)
+
+			continue
+		}
+
+		// only the handshake at the start of each direction is of interest
+		if fromClient {
+			if clientBuf.Len() < handshakeSize {
+				clientBuf.Write(raw)
+			}
+		} else if serverBuf.Len() < handshakeSize {
+			serverBuf.Write(raw)
+		}
+	}
+
+	if !udp && (parseHandshake(b, clientBuf.Bytes()) || parseHandshake(b, serverBuf.Bytes())) {
+		b.Protocol = protocolPeer
+	}
+
+	if b.Protocol == "" {
+		return
+	}
+
+	// peers that exchanged a handshake
+	if b.Protocol != protocolDHT {
+		b.Peers = appendUnique(b.Peers, peerAddr(b.SrcIP, b.SrcPort), maxEntries)
+		b.Peers = appendUnique(b.Peers, peerAddr(b.DstIP, b.DstPort), maxEntries)
+	}
+
+	b.BytesClient = int32(bytesClient)
+	b.BytesServer = int32(bytesServer)
+
+	bittorrentLog.Info("bittorrent conversation",
+		zap.String("ident", h.conversation.Ident),
+		zap.String("protocol", b.Protocol),
+		zap.Strings("infoHashes", b.InfoHashes),
+	)
+
+	writeBitTorrent(b)
+}
+
+// parseDatagram handles a single DHT message or uTP packet.
+func (h *bittorrentReader) parseDatagram(b *types.BitTorrent, data []byte, fromClient bool) {
+	if isDHTMessage(data) {
+		sender, senderPort := h.conversation.ServerIP, h.conversation.ServerPort
+		if fromClient {
+			sender, senderPort = h.conversation.ClientIP, h.conversation.ClientPort
+		}
+
+		if parseDHT(b, data, sender, senderPort) {
+			b.Protocol = protocolDHT
+		}
+
+		return
+	}
+
+	if len(data) < utpHeaderSize || data[0]&0x0f != utpVersion {
+		return
+	}
+
+	if parseHandshake(b, utpPayload(data)) || (b.Protocol == "" && isUTPPacket(data)) {
+		b.Protocol = protocolUTP
+	}
+}
+
+// parseHandshake collects the info hash, peer id and client from a peer handshake.
+func parseHandshake(b *types.BitTorrent, data []byte) bool {
+	if len(data) < handshakeSize || !isHandshake(data) {
+		return false
+	}
+
+	var (
+		offset = len(protocolIdentifier) + 8 // skip reserved bytes
+		hash   = data[offset : offset+infoHashSize]
+		peerID = data[offset+infoHashSize : offset+infoHashSize+peerIDSize]
+	)
+
+	b.InfoHashes = appendUnique(b.InfoHashes, hex.EncodeToString(hash), maxEntries)
+	b.PeerIDs = appendUnique(b.PeerIDs, hex.EncodeToString(peerID), maxEntries)
+
+	if c := clientFromPeerID(peerID); c != "" {
+		b.Clients = appendUnique(b.Clients, c, maxEntries)
+	}
+
+	return true
+}
+
+// clientFromPeerID identifies the client software from an azureus style peer id, e.g: -qB4250-.
+func clientFromPeerID(id []byte) string {
+	if len(id) < 8 || id[0] != '-' || id[7] != '-' {
+		return ""
+	}
+
+	var (
+		code    = string(id[1:3])
+		version = string(id[3:7])
+	)
+
+	for _, c := range id[1:7] {
+		if c < 0x20 || c > 0x7e {
+			return ""
+		}
+	}
+
+	if name, ok := clients[code]; ok {
+		return name + " " + version
+	}
+
+	return "-" + code + version + "-"
+}
+
+// parseDHT collects the query methods, info hashes and announced peers from a DHT message.
+func parseDHT(b *types.BitTorrent, data []byte, sender string, senderPort int32) bool {
+	v, _, err := bdecode(data, 0)
+	if err != nil {
+		return false
+	}
+
+	msg, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	switch msg["y"] {
+	case "q":
+		q, _ := msg["q"].(string)
+		if q == "" {
+			return false
+		}
+
+		b.DHTQueries = appendUnique(b.DHTQueries, q, maxEntries)
+
+		args, _ := msg["a"].(map[string]interface{})
+
+		if hash, isString := args["info_hash"].(string); isString && len(hash) == infoHashSize {
+			b.InfoHashes = appendUnique(b.InfoHashes, hex.EncodeToString([]byte(hash)), maxEntries)
+		}
+
+		// the sender announces that it is downloading the torrent
+		if q == "announce_peer" {
+			port, _ := args["port"].(int64)
+			if implied, _ := args["implied_port"].(int64); implied == 1 || port == 0 {
+				port = int64(senderPort)
+			}
+
+			b.Peers = appendUnique(b.Peers, peerAddr(sender, int32(port)), maxEntries)
+		}
+	case "r":
+		r, _ := msg["r"].(map[string]interface{})
+		values, _ := r["values"].([]interface{})
+
+		// peers for the requested info hash, in compact peer format
+		for _, val := range values {
+			if p, isString := val.(string); isString {
+				if addr := compactPeer([]byte(p)); addr != "" {
+					b.Peers = appendUnique(b.Peers, addr, maxEntries)
+				}
+			}
+		}
+	case "e":
+		// errors carry no information of interest, but identify the conversation as DHT
+	default:
+		return false
+	}
+
+	return true
+}
+
+// compactPeer decodes an IPv4 or IPv6 address and port in compact peer format.
+func compactPeer(data []byte) string {
+	switch len(data) {
+	case net.IPv4len + 2, net.IPv6len + 2:
+	default:
+		return ""
+	}
+
+	var (
+		ip   = net.IP(data[:len(data)-2])
+		port = binary.BigEndian.Uint16(data[len(data)-2:])
+	)
+
+	return peerAddr(ip.String(), int32(port))
+}
+
+// peerAddr formats an ip address and port.
+func peerAddr(ip string, port int32) string {
+	return net.JoinHostPort(ip, strconv.Itoa(int(port)))
+}
+
+// appendUnique appends the value if it is not yet present and the list has less than max elements.
+func appendUnique(list []string, val string, max int) []string {
+	if len(list) >= max {
+		return list
+	}
+
+	for _, v := range list {
+		if v == val {
+			return list
+		}
+	}
+
+	return append(list, val)
+}
+
+// writeBitTorrent writes the audit record and updates the metrics if enabled.
+func writeBitTorrent(b *types.BitTorrent) {
+	if decoderconfig.Instance.ExportMetrics {
+		b.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(b)
+	if err != nil {
+		bittorrentLog.Error("failed to write bittorrent audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package bittorrent
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var infoHash = bytes.Repeat([]byte{0xab}, infoHashSize)
+
+func handshake(peerID string) []byte {
+	h := append([]byte(protocolIdentifier), make([]byte, 8)...)
+	h = append(h, infoHash...)
+
+	return append(h, peerID...)
+}
+
+func TestParseHandshake(t *testing.T) {
+	var (
+		b    = new(types.BitTorrent)
+		data = handshake("-qB4250-abcdefghijkl")
+	)
+
+	if !isHandshake(data) || !parseHandshake(b, data) {
+		t.Fatal("expected handshake")
+	}
+
+	if !reflect.DeepEqual(b.InfoHashes, []string{"abababababababababababababababababababab"}) {
+		t.Fatal("unexpected info hashes", b.InfoHashes)
+	}
+
+	if !reflect.DeepEqual(b.Clients, []string{"qBittorrent 4250"}) {
+		t.Fatal("unexpected clients", b.Clients)
+	}
+
+	if parseHandshake(b, data[:handshakeSize-1]) {
+		t.Fatal("expected truncated handshake to be rejected")
+	}
+}
+
+func TestUTPHandshake(t *testing.T) {
+	// data packet with a selective ack extension of 4 bytes, followed by the handshake
+	packet := make([]byte, utpHeaderSize)
+	packet[0] = utpStData<<4 | utpVersion
+	packet[1] = 1
+	packet = append(packet, 0, 4, 0, 0, 0, 0)
+	packet = append(packet, handshake("-TR2940-abcdefghijkl")...)
+
+	if !isUTPPacket(packet) {
+		t.Fatal("expected uTP packet")
+	}
+
+	b := new(types.BitTorrent)
+	if !parseHandshake(b, utpPayload(packet)) || b.Clients[0] != "Transmission 2940" {
+		t.Fatal("unexpected result", b)
+	}
+
+	syn := make([]byte, utpHeaderSize)
+	syn[0] = utpStSyn<<4 | utpVersion
+
+	if !isUTPPacket(syn) || isUTPPacket(append(syn, 1)) {
+		t.Fatal("unexpected uTP syn detection")
+	}
+}
+
+func TestParseDHT(t *testing.T) {
+	var (
+		b        = new(types.BitTorrent)
+		getPeers = []byte("d1:ad2:id20:aaaaaaaaaaaaaaaaaaaa9:info_hash20:" + string(infoHash) + "e1:q9:get_peers1:t2:aa1:y1:qe")
+		response = []byte("d1:rd2:id20:bbbbbbbbbbbbbbbbbbbb5:token4:abcd6:valuesl6:\x0a\x00\x00\x01\x1a\xe16:\x0a\x00\x00\x02\x1a\xe2ee1:t2:aa1:y1:re")
+		announce = []byte("d1:ad2:id20:aaaaaaaaaaaaaaaaaaaa12:implied_porti1e9:info_hash20:" + string(infoHash) + "4:porti6881e5:token4:abcde1:q13:announce_peer1:t2:ab1:y1:qe")
+	)
+
+	for _, msg := range [][]byte{getPeers, response, announce} {
+		if !isDHTMessage(msg) {
+			t.Fatal("expected DHT message", string(msg))
+		}
+
+		if !parseDHT(b, msg, "192.168.1.2", 51413) {
+			t.Fatal("failed to parse DHT message", string(msg))
+		}
+	}
+
+	if !reflect.DeepEqual(b.DHTQueries, []string{"get_peers", "announce_peer"}) {
+		t.Fatal("unexpected queries", b.DHTQueries)
+	}
+
+	if len(b.InfoHashes) != 1 {
+		t.Fatal("expected a single info hash, got", b.InfoHashes)
+	}
+
+	if !reflect.DeepEqual(b.Peers, []string{"10.0.0.1:6881", "10.0.0.2:6882", "192.168.1.2:51413"}) {
+		t.Fatal("unexpected peers", b.Peers)
+	}
+}
+
+func TestBdecodeInvalid(t *testing.T) {
+	for _, data := range []string{"", "d1:a", "i12", "5:abc", "l", "di1ei2ee", "x"} {
+		if _, _, err := bdecode([]byte(data), 0); err == nil {
+			t.Error("expected error for", data)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package dns
+
+import (
+	"encoding/binary"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var dnsLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+// DNS over UDP is handled by the packet decoder, this decoder reassembles DNS messages sent over TCP,
+// which is used for large responses and zone transfers.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_DNS,
+	Name:        "DNSTCP",
+	Description: "The Domain Name System uses TCP for responses that exceed the UDP message size and for zone transfers, each message is prefixed with its length",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		dnsLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"dnstcp",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isQuery(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return dnsLog.Sync()
+	},
+	Factory: &dnsTCPReader{},
+	Typ:     core.TCP,
+}
+
+const (
+	// size of the length field that precedes each message.
+	lengthPrefixSize = 2
+
+	// size of the fixed DNS header.
+	headerSize = 12
+)
+
+// highest assigned opcode (DSO).
+const maxOpCode = 6
+
+// isQuery checks if the data starts with a length prefixed DNS query.
+// Queries carry exactly one question in practice, which makes the check more selective.
+func isQuery(data []byte) bool {
+	if len(data) < lengthPrefixSize+headerSize {
+		return false
+	}
+
+	var (
+		size    = int(binary.BigEndian.Uint16(data))
+		msg     = data[lengthPrefixSize:]
+		qr      = msg[2]&0x80 != 0
+		opCode  = msg[2] >> 3 & 0x0f
+		qdCount = binary.BigEndian.Uint16(msg[4:])
+	)
+
+	return size >= headerSize && !qr && opCode <= maxOpCode && qdCount == 1
+}
@@ -0,0 +1,163 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package dns
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// messageBuffer holds the data of a direction that has not yet been decoded,
+// along with the time when the first byte of it has been seen.
+type messageBuffer struct {
+	data      []byte
+	timestamp int64
+}
+
+type dnsTCPReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new DNS over TCP reader.
+func (h *dnsTCPReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &dnsTCPReader{
+		conversation: conversation,
+	}
+}
+
+// Decode splits both directions of the stream into the length prefixed DNS messages
+// and writes an audit record for each of them.
+func (h *dnsTCPReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	// messages and their length prefixes can be split over multiple fragments,
+	// incomplete messages are buffered for each direction
+	var client, server messageBuffer
+
+	for _, d := range h.conversation.Data {
+		var (
+			ts  = d.CaptureInfo().Timestamp.UnixNano()
+			dir = d.Direction()
+			buf = &server
+		)
+
+		if dir == reassembly.TCPDirClientToServer {
+			buf = &client
+		}
+
+		if len(buf.data) == 0 {
+			buf.timestamp = ts
+		}
+
+		numBuffered := len(buf.data)
+
+		buf.data = splitMessages(append(buf.data, d.Raw()...), func(msg []byte, offset int) {
+			// messages that start within the current fragment use its timestamp
+			if offset >= numBuffered {
+				buf.timestamp = ts
+			}
+
+			h.decodeMessage(msg, buf.timestamp, dir)
+		})
+
+		// the remaining data started within the current fragment
+		if len(buf.data) <= len(d.Raw()) {
+			buf.timestamp = ts
+		}
+	}
+
+	if len(client.data) > 0 || len(server.data) > 0 {
+		dnsLog.Debug("incomplete DNS message at the end of the stream",
+			zap.String("ident", h.conversation.Ident),
+			zap.Int("client", len(client.data)),
+			zap.Int("server", len(server.data)),
+		)
+	}
+}
+
+// splitMessages passes all complete length prefixed messages in data to handle, along with their offset in data,
+// and returns the remaining data.
+func splitMessages(data []byte, handle func(msg []byte, offset int)) []byte {
+	var offset int
+
+	for len(data) >= lengthPrefixSize {
+		size := int(binary.BigEndian.Uint16(data))
+		if len(data) < lengthPrefixSize+size {
+			return data
+		}
+
+		if size > 0 {
+			handle(data[lengthPrefixSize:lengthPrefixSize+size], offset)
+		}
+
+		data = data[lengthPrefixSize+size:]
+		offset += lengthPrefixSize + size
+	}
+
+	return data
+}
+
+// decodeMessage decodes a single DNS message and writes it as audit record.
+func (h *dnsTCPReader) decodeMessage(msg []byte, timestamp int64, dir reassembly.TCPFlowDirection) {
+	var dns layers.DNS
+
+	err := dns.DecodeFromBytes(msg, gopacket.NilDecodeFeedback)
+	if err != nil {
+		dnsLog.Debug("failed to decode DNS message",
+			zap.String("ident", h.conversation.Ident),
+			zap.Int("length", len(msg)),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	record := decoderutils.NewDNSRecord(&dns, timestamp)
+
+	if dir == reassembly.TCPDirClientToServer {
+		record.SrcIP, record.SrcPort = h.conversation.ClientIP, h.conversation.ClientPort
+		record.DstIP, record.DstPort = h.conversation.ServerIP, h.conversation.ServerPort
+	} else {
+		record.SrcIP, record.SrcPort = h.conversation.ServerIP, h.conversation.ServerPort
+		record.DstIP, record.DstPort = h.conversation.ClientIP, h.conversation.ClientPort
+	}
+
+	writeDNS(record)
+}
+
+func writeDNS(d *types.DNS) {
+	if decoderconfig.Instance.ExportMetrics {
+		d.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(d)
+	if err != nil {
+		dnsLog.Error("failed to write DNS audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package dns
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+)
+
+func serialize(t *testing.T, dns *layers.DNS) []byte {
+	t.Helper()
+
+	buf := gopacket.NewSerializeBuffer()
+
+	err := dns.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := make([]byte, lengthPrefixSize)
+	binary.BigEndian.PutUint16(prefix, uint16(len(buf.Bytes())))
+
+	return append(prefix, buf.Bytes()...)
+}
+
+func TestSplitMessages(t *testing.T) {
+	query := serialize(t, &layers.DNS{
+		ID:        1,
+		OpCode:    layers.DNSOpCodeQuery,
+		Questions: []layers.DNSQuestion{{Name: []byte("example.com"), Type: layers.DNSType(252), Class: layers.DNSClassIN}},
+	})
+
+	if !isQuery(query) {
+		t.Fatal("expected query to be detected")
+	}
+
+	// a zone transfer (AXFR, type 252) is answered with a sequence of messages
+	var stream []byte
+	for i := 0; i < 3; i++ {
+		stream = append(stream, serialize(t, &layers.DNS{
+			ID:     1,
+			QR:     true,
+			OpCode: layers.DNSOpCodeQuery,
+			AA:     true,
+			Answers: []layers.DNSResourceRecord{{
+				Name:  []byte("host.example.com"),
+				Type:  layers.DNSTypeA,
+				Class: layers.DNSClassIN,
+				TTL:   300,
+				IP:    net.IPv4(10, 0, 0, byte(i)).To4(),
+			}},
+		})...)
+	}
+
+	if isQuery(stream) {
+		t.Fatal("expected response not to be detected as query")
+	}
+
+	// feed the data in segments that split the length prefix and the message bodies
+	var (
+		buf  []byte
+		msgs []*layers.DNS
+	)
+
+	for _, size := range []int{1, 2, 20, 7, len(stream)} {
+		if size > len(stream) {
+			size = len(stream)
+		}
+
+		buf = splitMessages(append(buf, stream[:size]...), func(msg []byte, offset int) {
+			dns := new(layers.DNS)
+			if err := dns.DecodeFromBytes(msg, gopacket.NilDecodeFeedback); err != nil {
+				t.Fatal(err)
+			}
+
+			msgs = append(msgs, dns)
+		})
+
+		stream = stream[size:]
+	}
+
+	if len(buf) != 0 {
+		t.Fatal("expected all data to be consumed, got", len(buf))
+	}
+
+	if len(msgs) != 3 {
+		t.Fatal("expected 3 messages, got", len(msgs))
+	}
+
+	for i, m := range msgs {
+		if len(m.Answers) != 1 || !m.Answers[0].IP.Equal(net.IPv4(10, 0, 0, byte(i))) {
+			t.Fatal("unexpected answers", m.Answers)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package stream
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+)
+
+// defaultDPIProtocols maps the protocol names reported by the deep packet inspection engines
+// to the name of the stream decoder that shall be used for the stream.
+var defaultDPIProtocols = map[string]string{
+	"HTTP":          "HTTP",
+	"HTTP_BADPORT":  "HTTP",
+	"HTTP_DOWNLOAD": "HTTP",
+	"HTTP_PROXY":    "HTTP",
+	"SSH":           "SSH",
+	"MAIL_SMTP":     "SMTP",
+	"MAIL_POP":      "POP3",
+	"TLS":           "CertAnomaly",
+	"SSL":           "CertAnomaly",
+	"HTTPS":         "CertAnomaly",
+	"MEMCACHED":     "Memcached",
+	"BITTORRENT":    "BitTorrent",
+	"WIREGUARD":     "WireGuard",
+	"ORACLE":        "TNS",
+	"FTP_CONTROL":   "FTP",
+	"DNS":           "DNSTCP",
+	"REDIS":         "Redis",
+	"POSTGRES":      "PostgreSQL",
+	"AMQP":          "AMQP",
+}
+
+// dpiProtocols is used to select a stream decoder based on the DPI results for a stream.
+var dpiProtocols = defaultDPIProtocols
+
+// initDPIProtocols loads the mapping of DPI protocol names to stream decoders from the JSON file at path.
+// If the path is empty, the default mapping is used.
+func initDPIProtocols(path string) error {
+	if path == "" {
+		dpiProtocols = defaultDPIProtocols
+
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read DPI protocol mapping")
+	}
+
+	var mapping map[string]string
+
+	err = json.Unmarshal(data, &mapping)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse DPI protocol mapping")
+	}
+
+	dpiProtocols = mapping
+
+	return nil
+}
+
+// MatchDPIProtocols returns the first loaded stream decoder for the given transport protocol
+// that is mapped to one of the protocols identified by DPI.
+// Nil is returned if none of the protocols is mapped to a loaded stream decoder.
+func MatchDPIProtocols(protocols []string, transport core.TransportProtocol) core.StreamDecoderAPI {
+	for _, p := range protocols {
+		name, ok := dpiProtocols[p]
+		if !ok {
+			continue
+		}
+
+		for _, sd := range DefaultStreamDecoders {
+			if sd.GetName() != name || sd.GetReaderFactory() == nil {
+				continue
+			}
+
+			if sd.Transport() == transport || sd.Transport() == core.All {
+				return sd
+			}
+		}
+	}
+
+	return nil
+}
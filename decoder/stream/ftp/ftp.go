@@ -0,0 +1,76 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ftp
+
+import (
+	"regexp"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var ftpLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_FTP,
+	Name:        "FTP",
+	Description: "The File Transfer Protocol is used to transfer files between a client and a server, the control channel is unencrypted and carries the credentials in cleartext",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		ftpLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"ftp",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return reBanner.Match(server)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return ftpLog.Sync()
+	},
+	Factory: &ftpReader{},
+	Typ:     core.TCP,
+}
+
+// the server greets the client with a 220 reply that usually names the protocol.
+var reBanner = regexp.MustCompile(`^220[ -][^\r\n]*FTP`)
+
+// FTP commands of interest.
+const (
+	cmdUser = "USER"
+	cmdPass = "PASS"
+	cmdPort = "PORT"
+	cmdEprt = "EPRT"
+	cmdRetr = "RETR"
+	cmdStor = "STOR"
+	cmdStou = "STOU"
+	cmdAppe = "APPE"
+)
+
+// FTP reply codes of interest.
+const (
+	replyServiceReady    = "220"
+	replyPassiveMode     = "227"
+	replyExtendedPassive = "229"
+	replyLoggedIn        = "230"
+	replyCodeLength      = 3
+)
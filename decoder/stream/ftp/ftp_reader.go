@@ -0,0 +1,234 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ftp
+
+import (
+	"bytes"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var (
+	// address and port in the format h1,h2,h3,h4,p1,p2 used by PORT and the 227 reply to PASV
+	reHostPort = regexp.MustCompile(`(\d{1,3}),(\d{1,3}),(\d{1,3}),(\d{1,3}),(\d{1,3}),(\d{1,3})`)
+
+	// port in the 229 reply to EPSV: Entering Extended Passive Mode (|||6446|)
+	reExtendedPassive = regexp.MustCompile(`\((.)(.)(.)(\d+)(.)\)`)
+)
+
+type ftpReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new FTP reader.
+func (h *ftpReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &ftpReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the commands and replies on the control channel,
+// and writes an audit record with the credentials and the negotiated data connection.
+func (h *ftpReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	f := &types.FTP{
+		Timestamp: h.conversation.FirstClientPacket.UnixNano(),
+		Flow:      h.conversation.Ident,
+		SrcIP:     h.conversation.ClientIP,
+		SrcPort:   h.conversation.ClientPort,
+		DstIP:     h.conversation.ServerIP,
+		DstPort:   h.conversation.ServerPort,
+	}
+
+	// lines can be split over multiple fragments, incomplete lines are buffered for each direction
+	var client, server []byte
+
+	for _, d := range h.conversation.Data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			client = parseLines(append(client, d.Raw()...), f, parseCommand)
+		} else {
+			server = parseLines(append(server, d.Raw()...), f, parseReply)
+		}
+	}
+
+	// the connection might have been reset before the final line was terminated
+	if len(client) > 0 {
+		parseCommand(f, string(client))
+	}
+
+	if len(server) > 0 {
+		parseReply(f, string(server))
+	}
+
+	if f.Banner == "" && len(f.Commands) == 0 {
+		return
+	}
+
+	writeFTP(f)
+}
+
+// parseLines passes all complete lines in data to parse and returns the remaining data.
+func parseLines(data []byte, f *types.FTP, parse func(f *types.FTP, line string)) []byte {
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			return data
+		}
+
+		parse(f, string(data[:i]))
+		data = data[i+1:]
+	}
+}
+
+// parseCommand updates the audit record with a command sent by the client.
+func parseCommand(f *types.FTP, line string) {
+	line = strings.TrimRight(line, "\r")
+	if line == "" {
+		return
+	}
+
+	cmd, arg := line, ""
+	if i := strings.IndexByte(line, ' '); i != -1 {
+		cmd, arg = line[:i], line[i+1:]
+	}
+
+	cmd = strings.ToUpper(cmd)
+
+	switch cmd {
+	case cmdUser:
+		f.User = arg
+	case cmdPass:
+		f.Password = arg
+
+		// do not repeat the password in the list of commands
+		f.Commands = append(f.Commands, cmd)
+
+		return
+	case cmdPort:
+		if ip, port, ok := parseHostPort(arg); ok {
+			f.DataIP, f.DataPort, f.Passive = ip, port, false
+		}
+	case cmdEprt:
+		if ip, port, ok := parseExtendedPort(arg); ok {
+			f.DataIP, f.DataPort, f.Passive = ip, port, false
+		}
+	case cmdRetr, cmdStor, cmdStou, cmdAppe:
+		if arg != "" {
+			f.Files = append(f.Files, arg)
+		}
+	}
+
+	if arg != "" {
+		cmd += " " + arg
+	}
+
+	f.Commands = append(f.Commands, cmd)
+}
+
+// parseReply updates the audit record with a reply sent by the server.
+func parseReply(f *types.FTP, line string) {
+	line = strings.TrimRight(line, "\r")
+	if len(line) < replyCodeLength {
+		return
+	}
+
+	switch line[:replyCodeLength] {
+	case replyServiceReady:
+		if f.Banner == "" {
+			f.Banner = strings.TrimLeft(line[replyCodeLength:], " -")
+		}
+	case replyLoggedIn:
+		f.LoggedIn = true
+	case replyPassiveMode:
+		if ip, port, ok := parseHostPort(line); ok {
+			f.DataIP, f.DataPort, f.Passive = ip, port, true
+		}
+	case replyExtendedPassive:
+		// the extended reply only contains the port, the client connects to the server address
+		if m := reExtendedPassive.FindStringSubmatch(line); len(m) > 4 {
+			if port, err := strconv.Atoi(m[4]); err == nil && port <= 65535 {
+				f.DataIP, f.DataPort, f.Passive = f.DstIP, int32(port), true
+			}
+		}
+	}
+}
+
+// parseHostPort parses the address and port of a PORT command or a 227 reply.
+func parseHostPort(s string) (ip string, port int32, ok bool) {
+	m := reHostPort.FindStringSubmatch(s)
+	if len(m) != 7 {
+		return "", 0, false
+	}
+
+	var n [6]int
+
+	for i := range n {
+		v, err := strconv.Atoi(m[i+1])
+		if err != nil || v > 255 {
+			return "", 0, false
+		}
+
+		n[i] = v
+	}
+
+	return net.IPv4(byte(n[0]), byte(n[1]), byte(n[2]), byte(n[3])).String(), int32(n[4]<<8 | n[5]), true
+}
+
+// parseExtendedPort parses the argument of an EPRT command: |1|132.235.1.2|6275|
+// the first character is used as delimiter.
+func parseExtendedPort(s string) (ip string, port int32, ok bool) {
+	if s == "" {
+		return "", 0, false
+	}
+
+	parts := strings.Split(s, s[:1])
+	if len(parts) != 5 || net.ParseIP(parts[2]) == nil {
+		return "", 0, false
+	}
+
+	p, err := strconv.Atoi(parts[3])
+	if err != nil || p > 65535 {
+		return "", 0, false
+	}
+
+	return parts[2], int32(p), true
+}
+
+// writeFTP writes the audit record and updates the metrics if enabled.
+func writeFTP(f *types.FTP) {
+	if decoderconfig.Instance.ExportMetrics {
+		f.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(f)
+	if err != nil {
+		ftpLog.Error("failed to write FTP audit record", zap.Error(err))
+	}
+}
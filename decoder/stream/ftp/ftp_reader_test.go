@@ -0,0 +1,96 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ftp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func parse(f *types.FTP, client, server string) {
+	if rest := parseLines([]byte(server), f, parseReply); len(rest) > 0 {
+		parseReply(f, string(rest))
+	}
+
+	if rest := parseLines([]byte(client), f, parseCommand); len(rest) > 0 {
+		parseCommand(f, string(rest))
+	}
+}
+
+func TestParseSession(t *testing.T) {
+	f := &types.FTP{DstIP: "10.0.0.5"}
+
+	parse(f,
+		"USER alice\r\nPASS secret\r\nPASV\r\nRETR backup.tar.gz\r\nPORT 10,0,0,1,19,137\r\nQUIT\r\n",
+		"220 ProFTPD Server (Debian)\r\n331 Password required\r\n230 User alice logged in\r\n227 Entering Passive Mode (10,0,0,5,195,80).\r\n150 Opening\r\n226 Done\r\n",
+	)
+
+	if !reBanner.MatchString("220 ProFTPD Server (Debian)\r\n") {
+		t.Fatal("expected banner to match")
+	}
+
+	if f.Banner != "ProFTPD Server (Debian)" || f.User != "alice" || f.Password != "secret" || !f.LoggedIn {
+		t.Fatal("unexpected login", f.Banner, f.User, f.Password, f.LoggedIn)
+	}
+
+	expected := []string{"USER alice", "PASS", "PASV", "RETR backup.tar.gz", "PORT 10,0,0,1,19,137", "QUIT"}
+	if !reflect.DeepEqual(f.Commands, expected) {
+		t.Fatal("unexpected commands", f.Commands)
+	}
+
+	if len(f.Files) != 1 || f.Files[0] != "backup.tar.gz" {
+		t.Fatal("unexpected files", f.Files)
+	}
+
+	// the PORT command is parsed after the replies in this test
+	if f.DataIP != "10.0.0.1" || f.DataPort != 5001 || f.Passive {
+		t.Fatal("unexpected data connection", f.DataIP, f.DataPort, f.Passive)
+	}
+}
+
+func TestParseDataConnection(t *testing.T) {
+	tests := []struct {
+		client, server string
+		ip             string
+		port           int32
+		passive        bool
+	}{
+		{"", "227 Entering Passive Mode (192,168,1,2,4,1)", "192.168.1.2", 1025, true},
+		{"", "229 Entering Extended Passive Mode (|||6446|)", "10.0.0.5", 6446, true},
+		{"EPRT |2|1080::8:800:200C:417A|5282|", "", "1080::8:800:200C:417A", 5282, false},
+		{"PORT 300,0,0,1,1,1", "", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		f := &types.FTP{DstIP: "10.0.0.5"}
+		parse(f, tt.client, tt.server)
+
+		if f.DataIP != tt.ip || f.DataPort != tt.port || f.Passive != tt.passive {
+			t.Error(tt.client, tt.server, ": got", f.DataIP, f.DataPort, f.Passive)
+		}
+	}
+}
+
+func TestParsePartialLogin(t *testing.T) {
+	f := &types.FTP{}
+
+	// connection reset after the USER command
+	parse(f, "USER admin\r\n", "220 (vsFTPd 3.0.3)\r\n331 Please specify the password.\r\n")
+
+	if f.User != "admin" || f.Password != "" || f.LoggedIn {
+		t.Fatal("unexpected login", f.User, f.Password, f.LoggedIn)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/stream/file"
+	"github.com/dreadl0ck/netcap/defaults"
+)
+
+// saveBody writes a decoded response body into the body storage, named after the sha256 hash of its contents.
+// Identical bodies are stored only once. The location is empty if the body exceeds
+// the configured maximum size or could not be written.
+func saveBody(body []byte, contentType string) (hash, location string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+
+	sum := sha256.Sum256(body)
+	hash = hex.EncodeToString(sum[:])
+
+	if max := decoderconfig.Instance.HTTPBodyMaxSize; max > 0 && len(body) > max {
+		httpLog.Debug("response body exceeds the maximum size, not saving it",
+			zap.String("hash", hash),
+			zap.Int("length", len(body)),
+			zap.Int("max", max),
+		)
+
+		return hash, ""
+	}
+
+	root := path.Join(decoderconfig.Instance.Out, decoderconfig.Instance.HTTPBodyStorage)
+
+	err := os.MkdirAll(root, defaults.DirectoryPermission)
+	if err != nil {
+		httpLog.Error("failed to create body storage directory",
+			zap.String("path", root),
+			zap.Error(err),
+		)
+
+		return hash, ""
+	}
+
+	location = path.Join(root, hash+file.ExtensionForContentType(contentType))
+
+	// the exclusive flag makes sure each body is only written once, even if it is seen on several streams concurrently
+	f, err := os.OpenFile(location, os.O_WRONLY|os.O_CREATE|os.O_EXCL, defaults.FilePermission)
+	if os.IsExist(err) {
+		return hash, location
+	} else if err != nil {
+		httpLog.Error("failed to create response body file",
+			zap.String("location", location),
+			zap.Error(err),
+		)
+
+		return hash, ""
+	}
+
+	_, err = f.Write(body)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+
+	if err != nil {
+		httpLog.Error("failed to write response body file",
+			zap.String("location", location),
+			zap.Error(err),
+		)
+
+		// remove the partial file, so that it can be written again when the body is seen the next time
+		_ = os.Remove(location)
+
+		return hash, ""
+	}
+
+	return hash, location
+}
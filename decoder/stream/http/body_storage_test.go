@@ -0,0 +1,63 @@
+package http
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+)
+
+func TestSaveBody(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcap-http-bodies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	decoderconfig.Instance = &decoderconfig.Config{
+		Out:             dir,
+		HTTPBodyStorage: "bodies",
+		HTTPBodyMaxSize: len(testBody),
+	}
+
+	hash, location := saveBody([]byte(testBody), "text/html; charset=utf-8")
+	if len(hash) != 64 {
+		t.Fatal("expected sha256 hash, got", hash)
+	}
+
+	if location != filepath.Join(dir, "bodies", hash+".html") {
+		t.Fatal("unexpected location", location)
+	}
+
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != testBody {
+		t.Fatal("unexpected file contents", string(data))
+	}
+
+	// identical bodies are stored only once
+	hashDup, locationDup := saveBody([]byte(testBody), "text/html; charset=utf-8")
+	if hashDup != hash || locationDup != location {
+		t.Fatal("expected duplicate body to be mapped to the same file, got", locationDup)
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "bodies"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 {
+		t.Fatal("expected 1 file in body storage, got", len(files))
+	}
+
+	// oversized bodies are hashed but not written
+	hash, location = saveBody([]byte(testBody+"!"), "text/html")
+	if hash == "" || location != "" {
+		t.Fatal("expected oversized body to be skipped, got", hash, location)
+	}
+}
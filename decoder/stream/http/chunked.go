@@ -0,0 +1,164 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+var errInvalidChunkSize = errors.New("invalid chunk size")
+
+type chunkState int
+
+const (
+	chunkSize chunkState = iota
+	chunkData
+	chunkDataEnd
+	chunkTrailer
+	chunkDone
+)
+
+// chunkedBody decodes a response body with chunked transfer encoding.
+// The state is kept between calls to read, so that a body which is split
+// over several reads of the server side of a conversation can be continued.
+type chunkedBody struct {
+	res *http.Response
+
+	// capture time of the response header
+	timestamp int64
+
+	data      bytes.Buffer
+	trailer   http.Header
+	state     chunkState
+	remaining int64
+
+	// incomplete line at the end of the previous read
+	partial string
+}
+
+func isChunked(res *http.Response) bool {
+	return len(res.TransferEncoding) > 0 && res.TransferEncoding[0] == "chunked" && res.Body != http.NoBody
+}
+
+// read decodes chunks from b until the body and its trailers have been consumed.
+// io.ErrUnexpectedEOF is returned if the data ends before that.
+func (c *chunkedBody) read(b *bufio.Reader) error {
+	for {
+		switch c.state {
+		case chunkSize:
+			line, err := c.readLine(b)
+			if err != nil {
+				return err
+			}
+
+			// chunk extensions are ignored
+			if i := strings.IndexByte(line, ';'); i != -1 {
+				line = line[:i]
+			}
+
+			size, err := strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+			if err != nil || size < 0 {
+				return errInvalidChunkSize
+			}
+
+			if size == 0 {
+				c.state = chunkTrailer
+			} else {
+				c.state = chunkData
+				c.remaining = size
+			}
+		case chunkData:
+			n, err := io.CopyN(&c.data, b, c.remaining)
+			c.remaining -= n
+
+			if err != nil {
+				return io.ErrUnexpectedEOF
+			}
+
+			c.state = chunkDataEnd
+		case chunkDataEnd:
+			line, err := c.readLine(b)
+			if err != nil {
+				return err
+			}
+
+			if line != "" {
+				return errInvalidChunkSize
+			}
+
+			c.state = chunkSize
+		case chunkTrailer:
+			line, err := c.readLine(b)
+			if err != nil {
+				return err
+			}
+
+			if line == "" {
+				c.state = chunkDone
+
+				continue
+			}
+
+			if i := strings.IndexByte(line, ':'); i > 0 {
+				if c.trailer == nil {
+					c.trailer = make(http.Header)
+				}
+
+				c.trailer.Add(textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(line[:i])), strings.TrimSpace(line[i+1:]))
+			}
+		case chunkDone:
+			return nil
+		}
+	}
+}
+
+// readLine reads a CRLF terminated line.
+// A line that is cut off at the end of the data is completed on the next read.
+func (c *chunkedBody) readLine(b *bufio.Reader) (string, error) {
+	line, err := b.ReadString('\n')
+	if err != nil {
+		c.partial += line
+
+		return "", io.ErrUnexpectedEOF
+	}
+
+	line, c.partial = c.partial+line, ""
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// interrupted checks if the next data is the start of a new response instead of the next chunk,
+// which happens when the end of the body has not been captured.
+func (c *chunkedBody) interrupted(b *bufio.Reader) bool {
+	if c.state == chunkData || c.partial != "" {
+		return false
+	}
+
+	start, _ := b.Peek(len("HTTP/"))
+
+	return string(start) == "HTTP/"
+}
+
+// complete returns true if the terminal chunk has been read and no body data is missing.
+// Trailers after the terminal chunk are optional.
+func (c *chunkedBody) complete() bool {
+	return c.state >= chunkTrailer
+}
@@ -0,0 +1,100 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package http
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+)
+
+const (
+	chunkedHeader   = "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\nTrailer: X-Checksum\r\n\r\n"
+	chunkedBodyData = "5;name=value\r\nhello\r\n6\r\n world\r\n0\r\nX-Checksum: abc\r\n\r\n"
+)
+
+// readServerData parses each part as the HTTP reader does for consecutive data of the server side.
+func readServerData(parts ...string) *httpReader {
+	decoderconfig.Instance = &decoderconfig.Config{}
+
+	h := &httpReader{conversation: &core.ConversationInfo{}}
+
+	for _, p := range parts {
+		var (
+			b   = bufio.NewReader(strings.NewReader(p))
+			err error
+		)
+
+		for !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			err = h.readResponse(b)
+		}
+	}
+
+	if h.chunked != nil {
+		h.flushChunked()
+	}
+
+	return h
+}
+
+func TestChunkedResponse(t *testing.T) {
+	raw := chunkedHeader + chunkedBodyData
+
+	// split the body at every position, the final chunk and the trailer included
+	for i := len(chunkedHeader); i <= len(raw); i++ {
+		h := readServerData(raw[:i], raw[i:]+"HTTP/1.1 204 No Content\r\n\r\n")
+		if len(h.responses) != 2 {
+			t.Fatalf("split at %d: expected 2 responses, got %d", i, len(h.responses))
+		}
+
+		ht := newHTTPFromResponse(h.responses[0])
+		if ht.ResContentLength != 11 || ht.ResDecodedLength != 11 || ht.ResBodyIncomplete {
+			t.Fatal("split at", i, "unexpected lengths", ht.ResContentLength, ht.ResDecodedLength, ht.ResBodyIncomplete)
+		}
+
+		if ht.ResponseHeader["X-Checksum"] != "abc" {
+			t.Fatal("split at", i, "missing trailer", ht.ResponseHeader)
+		}
+
+		if h.responses[1].response.StatusCode != 204 {
+			t.Fatal("split at", i, "unexpected second response", h.responses[1].response.Status)
+		}
+	}
+}
+
+func TestChunkedResponseIncomplete(t *testing.T) {
+	// the terminal chunk has not been captured
+	h := readServerData(chunkedHeader+"5\r\nhello\r\n", "HTTP/1.1 204 No Content\r\n\r\n")
+	if len(h.responses) != 2 {
+		t.Fatal("expected 2 responses, got", len(h.responses))
+	}
+
+	ht := newHTTPFromResponse(h.responses[0])
+	if ht.ResDecodedLength != 5 || !ht.ResBodyIncomplete {
+		t.Fatal("expected incomplete body", ht.ResDecodedLength, ht.ResBodyIncomplete)
+	}
+
+	// the stream ends in the middle of a chunk
+	h = readServerData(chunkedHeader + "5\r\nhel")
+
+	ht = newHTTPFromResponse(h.responses[0])
+	if ht.ResDecodedLength != 3 || !ht.ResBodyIncomplete {
+		t.Fatal("expected incomplete body", ht.ResDecodedLength, ht.ResBodyIncomplete)
+	}
+}
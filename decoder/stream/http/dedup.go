@@ -0,0 +1,69 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package http
+
+import "github.com/dreadl0ck/netcap/types"
+
+// requestDedup collapses consecutive identical requests of a connection,
+// for example from clients polling the same endpoint over a keep-alive connection.
+// Only the first record of a series is written, with the number of requests in the RepeatCount field.
+type requestDedup struct {
+	// pending record of the current series
+	last *types.HTTP
+
+	// maximum number of requests collapsed into a single record, zero means no limit
+	limit int32
+
+	write func(h *types.HTTP)
+}
+
+func newRequestDedup(limit int, write func(h *types.HTTP)) *requestDedup {
+	return &requestDedup{
+		limit: int32(limit),
+		write: write,
+	}
+}
+
+// add records a request, the previous series is written once a different request arrives or the limit has been reached.
+func (d *requestDedup) add(h *types.HTTP) {
+	if d.last != nil && sameRequest(d.last, h) && (d.limit == 0 || d.last.RepeatCount < d.limit) {
+		d.last.RepeatCount++
+
+		return
+	}
+
+	d.flush()
+
+	h.RepeatCount = 1
+	d.last = h
+}
+
+// flush writes the pending record.
+func (d *requestDedup) flush() {
+	if d.last == nil {
+		return
+	}
+
+	d.write(d.last)
+	d.last = nil
+}
+
+// sameRequest checks if both requests have been issued by the same client for the same resource.
+func sameRequest(a, b *types.HTTP) bool {
+	return a.Method == b.Method &&
+		a.URL == b.URL &&
+		a.Host == b.Host &&
+		a.SrcIP == b.SrcIP &&
+		a.ClientIP == b.ClientIP
+}
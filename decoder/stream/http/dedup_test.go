@@ -0,0 +1,42 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestRequestDedup(t *testing.T) {
+	var written []*types.HTTP
+
+	d := newRequestDedup(3, func(h *types.HTTP) {
+		written = append(written, h)
+	})
+
+	poll := func() *types.HTTP {
+		return &types.HTTP{Method: methodGET, Host: "example.com", URL: "/status", SrcIP: "10.0.0.1"}
+	}
+
+	for i := 0; i < 4; i++ {
+		d.add(poll())
+	}
+
+	d.add(&types.HTTP{Method: methodPOST, Host: "example.com", URL: "/status", SrcIP: "10.0.0.1"})
+	d.add(poll())
+	d.flush()
+
+	expected := []int32{3, 1, 1, 1}
+	if len(written) != len(expected) {
+		t.Fatal("unexpected number of records, got:", len(written), "expected:", len(expected))
+	}
+
+	for i, h := range written {
+		if h.RepeatCount != expected[i] {
+			t.Fatal("unexpected repeat count for record", i, "got:", h.RepeatCount, "expected:", expected[i])
+		}
+	}
+
+	if written[1].Method != methodGET || written[2].Method != methodPOST {
+		t.Fatal("unexpected order of records")
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package http
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	headerForwarded     = "Forwarded"
+	headerXForwardedFor = "X-Forwarded-For"
+	headerXRealIP       = "X-Real-Ip"
+)
+
+// forwardedFor returns the chain of client addresses added by proxies and load balancers,
+// from the X-Forwarded-For and the RFC 7239 Forwarded headers, or the X-Real-IP header if both are missing.
+// The originating client is the first element, followed by the proxies that forwarded the request.
+func forwardedFor(header http.Header) (chain []string) {
+	for _, value := range header[headerXForwardedFor] {
+		for _, addr := range strings.Split(value, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				chain = append(chain, stripPort(addr))
+			}
+		}
+	}
+
+	// Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"
+	for _, value := range header[headerForwarded] {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				i := strings.Index(pair, "=")
+				if i == -1 || !strings.EqualFold(strings.TrimSpace(pair[:i]), "for") {
+					continue
+				}
+
+				if addr := strings.Trim(strings.TrimSpace(pair[i+1:]), "\""); addr != "" {
+					chain = append(chain, stripPort(addr))
+				}
+			}
+		}
+	}
+
+	if len(chain) == 0 {
+		if addr := strings.TrimSpace(header.Get(headerXRealIP)); addr != "" {
+			chain = append(chain, stripPort(addr))
+		}
+	}
+
+	return chain
+}
+
+// originatingClient returns the leftmost address of the chain that does not belong to a private network.
+// If all addresses are private, the leftmost valid address is returned.
+// Obfuscated identifiers like "unknown" or "_hidden" are ignored.
+func originatingClient(chain []string) string {
+	var first string
+
+	for _, addr := range chain {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+
+		if first == "" {
+			first = addr
+		}
+
+		if !isPrivateIP(ip) {
+			return addr
+		}
+	}
+
+	return first
+}
+
+// stripPort removes the port and the brackets around IPv6 addresses.
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
+var privateNetworks = func() (networks []*net.IPNet) {
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"} {
+		_, n, _ := net.ParseCIDR(cidr)
+		networks = append(networks, n)
+	}
+
+	return networks
+}()
+
+// isPrivateIP checks if the address is a loopback, link local or private address.
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
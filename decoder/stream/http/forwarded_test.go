@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestForwardedFor(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		chain  []string
+		client string
+	}{
+		{
+			header: http.Header{headerXForwardedFor: {"203.0.113.195, 70.41.3.18, 150.172.238.178"}},
+			chain:  []string{"203.0.113.195", "70.41.3.18", "150.172.238.178"},
+			client: "203.0.113.195",
+		},
+		{
+			header: http.Header{headerXForwardedFor: {"10.1.1.1", "198.51.100.7:8080"}},
+			chain:  []string{"10.1.1.1", "198.51.100.7"},
+			client: "198.51.100.7",
+		},
+		{
+			header: http.Header{headerForwarded: {`for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"`}},
+			chain:  []string{"192.0.2.60", "2001:db8:cafe::17"},
+			client: "192.0.2.60",
+		},
+		{
+			header: http.Header{headerForwarded: {`For=unknown, for=192.168.1.10`}},
+			chain:  []string{"unknown", "192.168.1.10"},
+			client: "192.168.1.10",
+		},
+		{
+			header: http.Header{headerXRealIP: {"198.51.100.1"}},
+			chain:  []string{"198.51.100.1"},
+			client: "198.51.100.1",
+		},
+		{
+			header: http.Header{},
+		},
+	}
+
+	for _, test := range tests {
+		chain := forwardedFor(test.header)
+		if !reflect.DeepEqual(chain, test.chain) {
+			t.Fatal("unexpected chain, got:", chain, "expected:", test.chain)
+		}
+
+		if client := originatingClient(chain); client != test.client {
+			t.Fatal("unexpected client, got:", client, "expected:", test.client)
+		}
+	}
+}
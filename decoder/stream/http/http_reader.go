@@ -23,12 +23,14 @@ import (
 	"path"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 
 	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
 	"github.com/dreadl0ck/netcap/decoder/core"
 	"github.com/dreadl0ck/netcap/decoder/stream/credentials"
+	"github.com/dreadl0ck/netcap/decoder/stream/ntlm"
 	"github.com/dreadl0ck/netcap/decoder/stream/software"
 	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
 	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
@@ -38,6 +40,9 @@ import (
 const (
 	headerContentType     = "Content-Type"
 	headerContentEncoding = "Content-Encoding"
+	headerDate            = "Date"
+	headerAuthorization   = "Authorization"
+	headerAuthenticate    = "Www-Authenticate"
 
 	methodCONNECT = "CONNECT"
 	methodDELETE  = "DELETE"
@@ -66,6 +71,9 @@ type httpResponse struct {
 	timestamp int64
 	clientIP  string
 	serverIP  string
+
+	// the body has been truncated, e.g. because of a missed segment
+	incomplete bool
 }
 
 type httpReader struct {
@@ -73,6 +81,15 @@ type httpReader struct {
 
 	requests  []*httpRequest
 	responses []*httpResponse
+
+	// chunked response body that continues in the next data of the server
+	chunked *chunkedBody
+
+	// frame decoder for connections that have been upgraded to the WebSocket protocol
+	websocket *websocketReader
+
+	// capture time of the fragment in which the message that is currently being read starts
+	timestamp time.Time
 }
 
 // New constructs a new http stream decoder.
@@ -89,24 +106,52 @@ func (h *httpReader) Decode() {
 		return
 	}
 
-	streamutils.DecodeConversation(
+	streamutils.DecodeConversationTimestamps(
 		h.conversation.Ident,
 		h.conversation.Data,
-		func(b *bufio.Reader) error {
+		func(b *bufio.Reader, ts time.Time) error {
+			h.timestamp = ts
+
 			return h.readRequest(b)
 		},
-		func(b *bufio.Reader) error {
+		func(b *bufio.Reader, ts time.Time) error {
+			h.timestamp = ts
+
 			return h.readResponse(b)
 		},
 	)
 
+	// the terminal chunk of the last response is missing
+	if h.chunked != nil {
+		h.flushChunked()
+	}
+
+	var (
+		dedup *requestDedup
+		write = func(ht *types.HTTP) {
+			writeHTTP(ht, h.conversation.Ident)
+		}
+		auth = &ntlm.Session{Protocol: Decoder.Name}
+	)
+
+	if decoderconfig.Instance.HTTPDedup {
+		dedup = newRequestDedup(decoderconfig.Instance.HTTPDedupMax, write)
+		write = dedup.add
+	}
+
 	// iterate over responses
 	for _, res := range h.responses { // populate types.HTTP with all infos from response
-		ht := newHTTPFromResponse(res.response)
+		ht := newHTTPFromResponse(res)
 
-		_ = h.findRequest(res.response)
+		req := h.findRequest(res.response)
+
+		if ntlm.Decoder.Writer != nil {
+			h.searchForNTLM(auth, res)
+		}
 
-		atomic.AddInt64(&streamutils.Stats.NumResponses, 1)
+		streamutils.Stats.Lock()
+		streamutils.Stats.NumResponses++
+		streamutils.Stats.Unlock()
 
 		// now add request information
 		if res.response.Request != nil {
@@ -115,22 +160,28 @@ func (h *httpReader) Decode() {
 				h.searchForBasicAuth(res.response.Request)
 			}
 
-			atomic.AddInt64(&streamutils.Stats.NumRequests, 1)
+			streamutils.Stats.Lock()
+			streamutils.Stats.NumRequests++
+			streamutils.Stats.Unlock()
 			setRequest(ht, &httpRequest{
 				request:   res.response.Request,
 				timestamp: res.timestamp,
 				clientIP:  res.clientIP,
 				serverIP:  res.serverIP,
 			})
+			setProtocolTime(ht, res)
+			setLatency(ht, req, res)
 		} else {
 			// response without matching request
 			// don't add to output for now
-			atomic.AddInt64(&streamutils.Stats.NumUnmatchedResp, 1)
+			streamutils.Stats.Lock()
+			streamutils.Stats.NumUnmatchedResp++
+			streamutils.Stats.Unlock()
 
 			continue
 		}
 
-		writeHTTP(ht, h.conversation.Ident)
+		write(ht)
 	}
 
 	// iterate over unanswered requests
@@ -144,14 +195,22 @@ func (h *httpReader) Decode() {
 				h.searchForBasicAuth(req.request)
 			}
 
-			atomic.AddInt64(&streamutils.Stats.NumRequests, 1)
-			atomic.AddInt64(&streamutils.Stats.NumUnansweredRequests, 1)
+			streamutils.Stats.Lock()
+			streamutils.Stats.NumRequests++
+			streamutils.Stats.NumUnansweredRequests++
+			streamutils.Stats.Unlock()
 
-			writeHTTP(ht, h.conversation.Ident)
+			write(ht)
 		} else {
-			atomic.AddInt64(&streamutils.Stats.NumNilRequests, 1)
+			streamutils.Stats.Lock()
+			streamutils.Stats.NumNilRequests++
+			streamutils.Stats.Unlock()
 		}
 	}
+
+	if dedup != nil {
+		dedup.flush()
+	}
 }
 
 // search request header field for HTTP basic auth.
@@ -169,6 +228,27 @@ func (h *httpReader) searchForBasicAuth(req *http.Request) {
 	}
 }
 
+// searchForNTLM passes the NTLM messages in the authorization headers of the request and the response to the session,
+// and writes an audit record for each authentication of the client.
+func (h *httpReader) searchForNTLM(auth *ntlm.Session, res *httpResponse) {
+	if req := res.response.Request; req != nil {
+		if a := auth.Add(ntlm.ParseHTTP(req.Header.Get(headerAuthorization)), res.timestamp); a != nil {
+			a.Flow = h.conversation.Ident
+			a.SrcIP = h.conversation.ClientIP
+			a.SrcPort = h.conversation.ClientPort
+			a.DstIP = h.conversation.ServerIP
+			a.DstPort = h.conversation.ServerPort
+
+			ntlm.WriteNTLM(a)
+		}
+	}
+
+	// the server can offer several authentication schemes, the challenge is only sent for NTLM or Negotiate
+	for _, v := range res.response.Header[headerAuthenticate] {
+		auth.Add(ntlm.ParseHTTP(v), res.timestamp)
+	}
+}
+
 // search for user name and password in http url params and body params.
 func (h *httpReader) searchForLoginParams(req *http.Request) {
 	for name, values := range req.Form {
@@ -248,6 +328,19 @@ func writeHTTP(h *types.HTTP, ident string) {
 // HTTP Response
 
 func (h *httpReader) readResponse(b *bufio.Reader) error {
+	if h.websocket != nil {
+		return h.websocket.read(b, false, h.timestamp)
+	}
+
+	if h.chunked != nil {
+		// a new response starts before the previous chunked body has been completed
+		if h.chunked.interrupted(b) {
+			h.flushChunked()
+		} else {
+			return h.readChunked(b)
+		}
+	}
+
 	// try to read HTTP response from the buffered reader
 	res, err := http.ReadResponse(b, nil)
 	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
@@ -261,7 +354,65 @@ func (h *httpReader) readResponse(b *bufio.Reader) error {
 		return err
 	}
 
+	// the chunked transfer encoding is decoded manually,
+	// to continue bodies that are split over multiple reads of the server side
+	if isChunked(res) {
+		h.chunked = &chunkedBody{
+			res:       res,
+			timestamp: h.messageTime(h.conversation.FirstServerPacket),
+		}
+
+		return h.readChunked(b)
+	}
+
+	// the data following the response consists of WebSocket frames
+	if isWebSocketUpgrade(res) {
+		h.websocket = &websocketReader{conversation: h.conversation}
+	}
+
 	body, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+
+	return h.addResponse(res, h.messageTime(h.conversation.FirstServerPacket), body, err)
+}
+
+// readChunked decodes the body of the pending chunked response.
+// If the data ends before the terminal chunk, the response stays pending
+// and the next read of the server side continues with the remaining chunks.
+func (h *httpReader) readChunked(b *bufio.Reader) error {
+	err := h.chunked.read(b)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return io.EOF
+	}
+
+	c := h.chunked
+	h.chunked = nil
+	c.res.Trailer = c.trailer
+
+	return h.addResponse(c.res, c.timestamp, c.data.Bytes(), err)
+}
+
+// flushChunked adds the pending chunked response with the data that has been decoded so far.
+func (h *httpReader) flushChunked() {
+	var (
+		c   = h.chunked
+		err error
+	)
+
+	h.chunked = nil
+	c.res.Trailer = c.trailer
+
+	if !c.complete() {
+		err = io.ErrUnexpectedEOF
+	}
+
+	_ = h.addResponse(c.res, c.timestamp, c.data.Bytes(), err)
+}
+
+// addResponse stores the response after the body has been read,
+// timestamp is the capture time of the response header and
+// err is the error that occurred while reading the body.
+func (h *httpReader) addResponse(res *http.Response, timestamp int64, body []byte, err error) error {
 	s := len(body)
 	if err != nil {
 		httpLog.Debug(
@@ -270,12 +421,10 @@ func (h *httpReader) readResponse(b *bufio.Reader) error {
 			zap.Error(err),
 			zap.Int("length", s),
 		)
-	} else {
-		_ = res.Body.Close()
-
-		// Restore body so it can be read again
-		res.Body = ioutil.NopCloser(bytes.NewBuffer(body))
 	}
+
+	// Restore body so it can be read again, a truncated body is kept with the data that has been read
+	res.Body = ioutil.NopCloser(bytes.NewBuffer(body))
 	//if h.parent.hexdump {
 	//	logReassemblyInfo("Body(%d/0x%x)\n%s\n", len(body), len(body), hex.Dump(body))
 	//}
@@ -308,10 +457,11 @@ func (h *httpReader) readResponse(b *bufio.Reader) error {
 	streamutils.Stats.Unlock()
 
 	h.responses = append(h.responses, &httpResponse{
-		response:  res,
-		timestamp: h.conversation.FirstServerPacket.UnixNano(),
-		clientIP:  h.conversation.ClientIP,
-		serverIP:  h.conversation.ServerIP,
+		response:   res,
+		timestamp:  timestamp,
+		clientIP:   h.conversation.ClientIP,
+		serverIP:   h.conversation.ServerIP,
+		incomplete: err != nil || (res.ContentLength > 0 && int64(s) < res.ContentLength),
 	})
 
 	// write responses to disk if configured
@@ -345,31 +495,44 @@ func (h *httpReader) readResponse(b *bufio.Reader) error {
 	return nil
 }
 
-func (h *httpReader) findRequest(res *http.Response) string {
-	// try to find the matching HTTP request for the response
-	var (
-		req    *http.Request
-		reqURL string
-	)
+// findRequest returns the matching HTTP request for the response.
+// Requests are answered in order, so pipelined requests are paired with the responses in the order they have been sent.
+func (h *httpReader) findRequest(res *http.Response) *httpRequest {
+	var req *httpRequest
 
 	if len(h.requests) != 0 {
 		// take the request from the parent stream and delete it from there
-		req, h.requests = h.requests[0].request, h.requests[1:]
-		reqURL = req.URL.String()
+		req, h.requests = h.requests[0], h.requests[1:]
 	}
 
 	// set request instance on response
 	if req != nil {
-		res.Request = req
-		atomic.AddInt64(&streamutils.Stats.NumFoundRequests, 1)
+		res.Request = req.request
+		streamutils.Stats.Lock()
+		streamutils.Stats.NumFoundRequests++
+		streamutils.Stats.Unlock()
+	}
+
+	return req
+}
+
+// messageTime returns the capture time of the message that is currently being read,
+// the first packet of the side is used if it is unknown.
+func (h *httpReader) messageTime(first time.Time) int64 {
+	if h.timestamp.IsZero() {
+		return first.UnixNano()
 	}
 
-	return reqURL
+	return h.timestamp.UnixNano()
 }
 
 // HTTP Request
 
 func (h *httpReader) readRequest(b *bufio.Reader) error {
+	if h.websocket != nil {
+		return h.websocket.read(b, true, h.timestamp)
+	}
+
 	req, err := http.ReadRequest(b)
 	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
 		return err
@@ -409,7 +572,7 @@ func (h *httpReader) readRequest(b *bufio.Reader) error {
 		zap.Int("bodyLength", s),
 	)
 
-	t := h.conversation.FirstClientPacket.UnixNano()
+	t := h.messageTime(h.conversation.FirstClientPacket)
 
 	request := &httpRequest{
 		request:   req,
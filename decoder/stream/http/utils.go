@@ -15,12 +15,20 @@ package http
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
 	"github.com/dreadl0ck/netcap/types"
 )
 
@@ -45,33 +53,55 @@ func setRequest(h *types.HTTP, req *httpRequest) {
 		h.ContentTypeDetected = http.DetectContentType(body)
 
 		// decompress if required
-		if h.ReqContentEncoding == "gzip" {
-			r, errReader := gzip.NewReader(bytes.NewReader(body))
-			if errReader == nil {
-				body, err = ioutil.ReadAll(r)
-				if err == nil {
-					h.ContentTypeDetected = http.DetectContentType(body)
-				}
+		if h.ReqContentEncoding != "" {
+			decoded, errDecode := decodeContent(body, h.ReqContentEncoding, decoderconfig.Instance.HTTPMaxDecodedSize)
+			if errDecode == nil || len(decoded) > 0 {
+				h.ContentTypeDetected = http.DetectContentType(decoded)
 			}
 		}
 	}
 
-	// manually replace commas, to avoid breaking them the CSV
-	// use the -check flag to validate the generated CSV output and find errors quickly if other fields slipped through
-	h.UserAgent = removeCommas(req.request.UserAgent())
-	h.Referer = removeCommas(req.request.Referer())
-	h.URL = removeCommas(req.request.URL.String())
+	h.UserAgent = req.request.UserAgent()
+	h.Referer = req.request.Referer()
+	h.URL = req.request.URL.String()
 
 	// retrieve ip addresses set on the request while processing
-	h.SrcIP = req.clientIP
-	h.DstIP = req.serverIP
+	h.SrcIP = decoderutils.NormalizeIP(req.clientIP)
+	h.DstIP = decoderutils.NormalizeIP(req.serverIP)
+
+	// behind proxies and load balancers the source address is the proxy, not the client
+	h.ForwardedFor = forwardedFor(req.request.Header)
+	for i, addr := range h.ForwardedFor {
+		h.ForwardedFor[i] = decoderutils.NormalizeIP(addr)
+	}
+
+	h.ClientIP = originatingClient(h.ForwardedFor)
 
 	h.ReqCookies = readCookies(req.request.Cookies())
 	h.Parameters = readParameters(req.request.Form)
 }
 
-func removeCommas(s string) string {
-	return strings.Replace(s, ",", "(comma)", -1)
+// set the time asserted by the server in the Date header of the response on types.HTTP,
+// along with its deviation from the time the response has been captured.
+func setProtocolTime(h *types.HTTP, res *httpResponse) {
+	t, err := http.ParseTime(res.response.Header.Get(headerDate))
+	if err != nil {
+		return
+	}
+
+	h.ProtocolTime = t.UnixNano()
+	h.ClockSkew = streamutils.ClockSkew(res.timestamp, h.ProtocolTime)
+	h.Timestamp = streamutils.SelectTimestamp(h.Timestamp, h.ProtocolTime)
+}
+
+// set the time between the request and its response in milliseconds on types.HTTP.
+// The latency stays zero if a timestamp is unknown or the response has not been captured after the request.
+func setLatency(h *types.HTTP, req *httpRequest, res *httpResponse) {
+	if req == nil || req.timestamp == 0 || res.timestamp <= req.timestamp {
+		return
+	}
+
+	h.LatencyMS = float64(res.timestamp-req.timestamp) / float64(time.Millisecond)
 }
 
 // readCookies transforms an array of *http.Cookie to an array of *types.HTTPCookie.
@@ -97,9 +127,14 @@ func readCookies(cookies []*http.Cookie) []*types.HTTPCookie {
 	return cks
 }
 
-func newHTTPFromResponse(res *http.Response) *types.HTTP {
+func newHTTPFromResponse(r *httpResponse) *types.HTTP {
 	var (
+		res           = r.response
 		detected      string
+		decodedLength int32
+		incomplete    = r.incomplete
+		hash          string
+		location      string
 		contentLength = int32(res.ContentLength)
 	)
 
@@ -113,29 +148,89 @@ func newHTTPFromResponse(res *http.Response) *types.HTTP {
 		}
 
 		// decompress payload if required
-		if res.Header.Get(headerContentEncoding) == "gzip" {
-			r, errReader := gzip.NewReader(bytes.NewReader(body))
-			if errReader == nil {
-				body, err = ioutil.ReadAll(r)
-				if err == nil {
-					detected = http.DetectContentType(body)
-				}
-			}
-		} else {
-			detected = http.DetectContentType(body)
+		decoded, errDecode := decodeContent(body, res.Header.Get(headerContentEncoding), decoderconfig.Instance.HTTPMaxDecodedSize)
+		if errDecode != nil {
+			// a truncated body is decoded up to the missing data,
+			// a body that exceeds the maximum decoded size up to the limit
+			incomplete = true
+		}
+
+		if errDecode == nil || len(decoded) > 0 {
+			detected = http.DetectContentType(decoded)
+		}
+
+		decodedLength = int32(len(decoded))
+
+		// write the response body to disk if configured
+		if decoderconfig.Instance.HTTPBodyStorage != "" && (!incomplete || decoderconfig.Instance.WriteIncomplete) {
+			hash, location = saveBody(decoded, detected)
 		}
 	}
 
+	// trailers sent after the terminal chunk are added to the header fields
+	header := readHeader(res.Header)
+	for k, v := range readHeader(res.Trailer) {
+		header[k] = v
+	}
+
 	return &types.HTTP{
 		ResContentLength:       contentLength,
+		ResDecodedLength:       decodedLength,
+		ResBodyIncomplete:      incomplete,
+		ResBodyHash:            hash,
+		ResBodyLocation:        location,
 		ResContentType:         res.Header.Get(headerContentType),
 		StatusCode:             int32(res.StatusCode),
 		ServerName:             res.Header.Get("Server"),
 		ResContentEncoding:     res.Header.Get(headerContentEncoding),
 		ResContentTypeDetected: detected,
 		ResCookies:             readCookies(res.Cookies()),
-		ResponseHeader:         readHeader(res.Header),
+		ResponseHeader:         header,
+	}
+}
+
+// errDecodedBodyTooLarge occurs when a body exceeds the maximum size after removing the content encoding.
+var errDecodedBodyTooLarge = errors.New("decoded body exceeds the maximum size")
+
+// decodeContent removes the gzip or deflate content encoding from the body.
+// If the compressed data has been truncated, the data decoded so far is returned along with the error.
+// The decoded data is limited to maxSize bytes, to protect against decompression bombs,
+// if the limit is exceeded the data up to the limit is returned along with errDecodedBodyTooLarge.
+// A maxSize of zero disables the limit.
+func decodeContent(body []byte, encoding string, maxSize int) ([]byte, error) {
+	var r io.Reader
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		r = gr
+	case "deflate":
+		// deflate should be zlib wrapped, but some servers send raw deflate data
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			r = flate.NewReader(bytes.NewReader(body))
+		} else {
+			r = zr
+		}
+	default:
+		return body, nil
+	}
+
+	if maxSize <= 0 {
+		return ioutil.ReadAll(r)
+	}
+
+	// read one byte more than allowed, to detect bodies exceeding the limit
+	decoded, err := ioutil.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if len(decoded) > maxSize {
+		return decoded[:maxSize], errDecodedBodyTooLarge
 	}
+
+	return decoded, err
 }
 
 func readHeader(h http.Header) map[string]string {
@@ -0,0 +1,173 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+const testBody = "<html><body>" + "compressed response body " + "</body></html>"
+
+func compress(t *testing.T, encoding string) []byte {
+	t.Helper()
+
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+		err error
+	)
+
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	default:
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = w.Write([]byte(strings.Repeat(testBody, 20))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecodeContent(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "raw"} {
+		body, err := decodeContent(compress(t, encoding), strings.Replace(encoding, "raw", "deflate", 1), 0)
+		if err != nil {
+			t.Fatal(encoding, err)
+		}
+
+		if string(body) != strings.Repeat(testBody, 20) {
+			t.Fatal(encoding, "unexpected body", string(body))
+		}
+	}
+}
+
+func TestDecodeContentLimit(t *testing.T) {
+	// 64 MB of zeros compress to about 64 KB
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(make([]byte, 64*1024*1024)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := decodeContent(buf.Bytes(), "gzip", 1024)
+	if !errors.Is(err, errDecodedBodyTooLarge) {
+		t.Fatal("expected errDecodedBodyTooLarge, got", err)
+	}
+
+	if len(body) != 1024 {
+		t.Fatal("expected body to be truncated to the limit, got", len(body))
+	}
+
+	// the response is marked as incomplete
+	decoderconfig.Instance = &decoderconfig.Config{HTTPMaxDecodedSize: 1024}
+	defer func() {
+		decoderconfig.Instance = &decoderconfig.Config{}
+	}()
+
+	ht := newHTTPFromResponse(&httpResponse{
+		response: &http.Response{
+			Header:        http.Header{headerContentEncoding: []string{"gzip"}},
+			Body:          ioutil.NopCloser(bytes.NewReader(buf.Bytes())),
+			ContentLength: int64(buf.Len()),
+		},
+	})
+	if !ht.ResBodyIncomplete || ht.ResDecodedLength != 1024 {
+		t.Fatal("expected truncated, incomplete body", ht.ResDecodedLength, ht.ResBodyIncomplete)
+	}
+}
+
+// readTestResponse parses a response with the given body, as the HTTP reader does for the server side of a stream.
+func readTestResponse(t *testing.T, body []byte, contentLength int) *httpResponse {
+	t.Helper()
+
+	raw := "HTTP/1.1 200 OK\r\nContent-Encoding: gzip\r\nContent-Type: text/html\r\nContent-Length: " + strconv.Itoa(contentLength) + "\r\n\r\n" + string(body)
+
+	decoderconfig.Instance = &decoderconfig.Config{}
+
+	h := &httpReader{conversation: &core.ConversationInfo{}}
+	if err := h.readResponse(bufio.NewReader(strings.NewReader(raw))); err != nil {
+		t.Fatal(err)
+	}
+
+	return h.responses[0]
+}
+
+func TestNewHTTPFromResponse(t *testing.T) {
+	body := compress(t, "gzip")
+
+	ht := newHTTPFromResponse(readTestResponse(t, body, len(body)))
+	if ht.ResContentLength != int32(len(body)) || ht.ResDecodedLength != int32(len(testBody)*20) || ht.ResBodyIncomplete {
+		t.Fatal("unexpected lengths", ht.ResContentLength, ht.ResDecodedLength, ht.ResBodyIncomplete)
+	}
+
+	if !strings.HasPrefix(ht.ResContentTypeDetected, "text/html") {
+		t.Fatal("unexpected content type", ht.ResContentTypeDetected)
+	}
+
+	// the end of the body is missing
+	ht = newHTTPFromResponse(readTestResponse(t, body[:len(body)/2], len(body)))
+	if !ht.ResBodyIncomplete || ht.ResDecodedLength == 0 {
+		t.Fatal("expected partially decoded, incomplete body", ht.ResDecodedLength, ht.ResBodyIncomplete)
+	}
+}
+
+func TestSetLatency(t *testing.T) {
+	h := &httpReader{
+		requests: []*httpRequest{
+			{request: &http.Request{}, timestamp: int64(time.Second)},
+			{request: &http.Request{}, timestamp: int64(2 * time.Second)},
+		},
+	}
+
+	// pipelined requests are answered in order, the last response has no matching request
+	for _, test := range []struct {
+		timestamp time.Duration
+		expected  float64
+	}{
+		{timestamp: 2500 * time.Millisecond, expected: 1500},
+		{timestamp: 2750 * time.Millisecond, expected: 750},
+		{timestamp: 3 * time.Second, expected: 0},
+	} {
+		var (
+			res = &httpResponse{response: &http.Response{}, timestamp: int64(test.timestamp)}
+			ht  = &types.HTTP{}
+		)
+
+		setLatency(ht, h.findRequest(res.response), res)
+
+		if ht.LatencyMS != test.expected {
+			t.Fatal("unexpected latency", ht.LatencyMS, "expected", test.expected)
+		}
+	}
+}
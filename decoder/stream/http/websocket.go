@@ -0,0 +1,324 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package http
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// WebSocketDecoder writes the frames of HTTP connections that have been upgraded to the WebSocket protocol.
+// The frames are decoded by the HTTP stream decoder.
+var WebSocketDecoder = &decoder.AbstractDecoder{
+	Type:        types.Type_NC_WebSocketFrame,
+	Name:        "WebSocketFrame",
+	Description: "A frame exchanged after a HTTP connection has been upgraded to the WebSocket protocol",
+}
+
+// maxWebSocketPreview is the maximum number of payload bytes kept for each frame.
+const maxWebSocketPreview = 64
+
+// maxWebSocketHeader is the size of a frame header with a 64 bit length and a masking key.
+const maxWebSocketHeader = 14
+
+// WebSocket opcodes, see RFC 6455.
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+)
+
+var wsMessageTypes = map[byte]string{
+	wsOpText:   "Text",
+	wsOpBinary: "Binary",
+	wsOpClose:  "Close",
+	wsOpPing:   "Ping",
+	wsOpPong:   "Pong",
+}
+
+// isWebSocketUpgrade checks if the response switches the connection to the WebSocket protocol.
+func isWebSocketUpgrade(res *http.Response) bool {
+	return res.StatusCode == http.StatusSwitchingProtocols && strings.EqualFold(strings.TrimSpace(res.Header.Get("Upgrade")), "websocket")
+}
+
+// websocketFrame is a decoded frame header along with the unmasked start of the payload.
+type websocketFrame struct {
+	fin     bool
+	opcode  byte
+	masked  bool
+	length  int64
+	preview []byte
+}
+
+// parseWebSocketFrame decodes the frame header and the payload preview at the start of data.
+// n is the number of bytes consumed, or 0 if more data is needed.
+// The remaining payload bytes of the frame are not consumed.
+func parseWebSocketFrame(data []byte) (f *websocketFrame, n int, valid bool) {
+	if len(data) < 2 {
+		return nil, 0, true
+	}
+
+	f = &websocketFrame{
+		fin:    data[0]&0x80 != 0,
+		opcode: data[0] & 0x0f,
+		masked: data[1]&0x80 != 0,
+		length: int64(data[1] & 0x7f),
+	}
+
+	if _, ok := wsMessageTypes[f.opcode]; !ok && f.opcode != wsOpContinuation {
+		return nil, 0, false
+	}
+
+	// control frames must not be fragmented and carry at most 125 bytes
+	if f.opcode >= wsOpClose && (!f.fin || f.length > 125) {
+		return nil, 0, false
+	}
+
+	n = 2
+
+	switch f.length {
+	case 126:
+		if len(data) < n+2 {
+			return nil, 0, true
+		}
+
+		f.length = int64(binary.BigEndian.Uint16(data[n:]))
+		n += 2
+	case 127:
+		if len(data) < n+8 {
+			return nil, 0, true
+		}
+
+		length := binary.BigEndian.Uint64(data[n:])
+		if length>>63 != 0 {
+			return nil, 0, false
+		}
+
+		f.length = int64(length)
+		n += 8
+	}
+
+	var key []byte
+
+	if f.masked {
+		if len(data) < n+4 {
+			return nil, 0, true
+		}
+
+		key = data[n : n+4]
+		n += 4
+	}
+
+	size := f.length
+	if size > maxWebSocketPreview {
+		size = maxWebSocketPreview
+	}
+
+	if int64(len(data)-n) < size {
+		return nil, 0, true
+	}
+
+	f.preview = make([]byte, size)
+	copy(f.preview, data[n:])
+
+	for i := range f.preview {
+		if key != nil {
+			f.preview[i] ^= key[i%4]
+		}
+	}
+
+	return f, n + int(size), true
+}
+
+// websocketDirection holds the decoding state for the frames sent in one direction.
+type websocketDirection struct {
+	// incomplete frame header at the end of the previous data
+	pending []byte
+
+	// capture time of the fragment in which the pending frame header starts
+	pendingTime time.Time
+
+	// payload bytes of the current frame that have not been seen yet
+	skip int64
+
+	// opcode of the fragmented message that is currently transferred
+	message byte
+
+	// decoding stops after invalid data
+	invalid bool
+}
+
+// websocketReader decodes the frames of a connection after the switch to the WebSocket protocol.
+type websocketReader struct {
+	conversation *core.ConversationInfo
+
+	client websocketDirection
+	server websocketDirection
+}
+
+// read decodes the next frame from b, ts is the capture time of the fragment in which the frame starts.
+// Each call consumes at most one frame header, so that the caller can pass the timestamp for the next frame.
+// Frames that continue in the next data for the same direction are completed on the next read.
+func (w *websocketReader) read(b *bufio.Reader, fromClient bool, ts time.Time) error {
+	dir := &w.server
+	if fromClient {
+		dir = &w.client
+	}
+
+	if dir.invalid {
+		_, _ = io.Copy(ioutil.Discard, b)
+
+		return io.EOF
+	}
+
+	// skip the remaining payload of the current frame
+	if dir.skip > 0 {
+		for dir.skip > 0 {
+			n := dir.skip
+			if n > math.MaxInt32 {
+				n = math.MaxInt32
+			}
+
+			discarded, err := b.Discard(int(n))
+			dir.skip -= int64(discarded)
+
+			if err != nil {
+				return io.EOF
+			}
+		}
+
+		return nil
+	}
+
+	// a frame header with the maximum payload preview fits into the default buffer
+	data, _ := b.Peek(maxWebSocketHeader + maxWebSocketPreview)
+	if len(data) == 0 {
+		return io.EOF
+	}
+
+	if len(dir.pending) == 0 {
+		dir.pendingTime = ts
+	}
+
+	f, n, valid := parseWebSocketFrame(append(dir.pending, data...))
+	if !valid {
+		httpLog.Debug("invalid websocket frame", zap.String("ident", w.conversation.Ident))
+
+		dir.invalid = true
+		dir.pending = nil
+
+		_, _ = io.Copy(ioutil.Discard, b)
+
+		return io.EOF
+	}
+
+	if n == 0 {
+		// the data ends inside the frame header
+		dir.pending = append(dir.pending, data...)
+		_, _ = b.Discard(len(data))
+
+		return io.EOF
+	}
+
+	_, _ = b.Discard(n - len(dir.pending))
+	dir.pending = nil
+	dir.skip = f.length - int64(len(f.preview))
+
+	w.write(f, dir, fromClient)
+
+	return nil
+}
+
+// write creates the audit record for the frame and tracks fragmented messages.
+func (w *websocketReader) write(f *websocketFrame, dir *websocketDirection, fromClient bool) {
+	typ := wsMessageTypes[f.opcode]
+
+	switch {
+	case f.opcode == wsOpContinuation:
+		// continuation frames belong to the message started by the last data frame
+		typ = wsMessageTypes[dir.message]
+		if f.fin {
+			dir.message = wsOpContinuation
+		}
+	case f.opcode < wsOpClose && !f.fin:
+		dir.message = f.opcode
+	}
+
+	if WebSocketDecoder.Writer == nil {
+		return
+	}
+
+	frame := &types.WebSocketFrame{
+		Timestamp:     frameTime(dir.pendingTime, w.conversation.FirstServerPacket),
+		Flow:          w.conversation.Ident,
+		SrcIP:         w.conversation.ServerIP,
+		SrcPort:       w.conversation.ServerPort,
+		DstIP:         w.conversation.ClientIP,
+		DstPort:       w.conversation.ClientPort,
+		FromClient:    fromClient,
+		OpCode:        int32(f.opcode),
+		MessageType:   typ,
+		Fin:           f.fin,
+		Masked:        f.masked,
+		PayloadLength: f.length,
+		Preview:       f.preview,
+	}
+
+	if fromClient {
+		frame.Timestamp = frameTime(dir.pendingTime, w.conversation.FirstClientPacket)
+		frame.SrcIP, frame.DstIP = frame.DstIP, frame.SrcIP
+		frame.SrcPort, frame.DstPort = frame.DstPort, frame.SrcPort
+	}
+
+	if f.opcode == wsOpClose && len(f.preview) >= 2 {
+		frame.CloseCode = int32(binary.BigEndian.Uint16(f.preview))
+	}
+
+	if decoderconfig.Instance.ExportMetrics {
+		frame.Inc()
+	}
+
+	atomic.AddInt64(&WebSocketDecoder.NumRecordsWritten, 1)
+
+	err := WebSocketDecoder.Writer.Write(frame)
+	if err != nil {
+		httpLog.Error("failed to write websocket frame audit record", zap.Error(err))
+	}
+}
+
+// frameTime returns the capture time of the frame, or the first packet of the direction if it is unknown.
+func frameTime(ts, first time.Time) int64 {
+	if ts.IsZero() {
+		return first.UnixNano()
+	}
+
+	return ts.UnixNano()
+}
@@ -0,0 +1,265 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/gogo/protobuf/proto"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// frameRecorder collects the written WebSocket frames.
+type frameRecorder struct {
+	frames []*types.WebSocketFrame
+}
+
+func (r *frameRecorder) Write(msg proto.Message) error {
+	r.frames = append(r.frames, msg.(*types.WebSocketFrame))
+
+	return nil
+}
+
+func (r *frameRecorder) WriteHeader(types.Type) error { return nil }
+
+func (r *frameRecorder) Close(int64) (string, int64) { return "", 0 }
+
+// wsFrame encodes a frame, client frames are masked.
+func wsFrame(fin bool, opcode byte, masked bool, payload []byte) []byte {
+	var b bytes.Buffer
+
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+
+	b.WriteByte(first)
+
+	var mask byte
+	if masked {
+		mask = 0x80
+	}
+
+	switch {
+	case len(payload) < 126:
+		b.WriteByte(mask | byte(len(payload)))
+	case len(payload) <= 0xffff:
+		b.WriteByte(mask | 126)
+		_ = binary.Write(&b, binary.BigEndian, uint16(len(payload)))
+	default:
+		b.WriteByte(mask | 127)
+		_ = binary.Write(&b, binary.BigEndian, uint64(len(payload)))
+	}
+
+	if !masked {
+		b.Write(payload)
+
+		return b.Bytes()
+	}
+
+	key := []byte{0x12, 0x34, 0x56, 0x78}
+	b.Write(key)
+
+	for i, c := range payload {
+		b.WriteByte(c ^ key[i%4])
+	}
+
+	return b.Bytes()
+}
+
+func readAll(t *testing.T, read func(b *bufio.Reader) error, data []byte) {
+	t.Helper()
+
+	var (
+		b   = bufio.NewReader(bytes.NewReader(data))
+		err error
+	)
+
+	for !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		err = read(b)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWebSocketFrames(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{}
+
+	rec := &frameRecorder{}
+	WebSocketDecoder.Writer = rec
+
+	defer func() {
+		WebSocketDecoder.Writer = nil
+	}()
+
+	h := &httpReader{conversation: &core.ConversationInfo{
+		ClientIP:   "10.0.0.1",
+		ServerIP:   "10.0.0.2",
+		ClientPort: 50000,
+		ServerPort: 80,
+	}}
+
+	readAll(t, h.readRequest, []byte("GET /chat HTTP/1.1\r\nHost: example.com\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"))
+
+	var server []byte
+	server = append(server, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: WebSocket\r\nConnection: Upgrade\r\n\r\n"...)
+	server = append(server, wsFrame(true, wsOpBinary, false, bytes.Repeat([]byte{0xab}, 300))...)
+
+	readAll(t, h.readResponse, server)
+
+	if h.websocket == nil {
+		t.Fatal("expected connection to be upgraded")
+	}
+
+	if len(h.responses) != 1 {
+		t.Fatal("expected the upgrade response, got", len(h.responses))
+	}
+
+	// fragmented text message with an interleaved ping, followed by a close frame
+	var client []byte
+	client = append(client, wsFrame(false, wsOpText, true, []byte("Hel"))...)
+	client = append(client, wsFrame(true, wsOpPing, true, []byte("p"))...)
+	client = append(client, wsFrame(true, wsOpContinuation, true, []byte("lo"))...)
+	client = append(client, wsFrame(true, wsOpClose, true, []byte{0x03, 0xe8, 'b', 'y', 'e'})...)
+
+	// split inside the header of the ping frame, to continue it on the next read
+	readAll(t, h.readRequest, client[:10])
+	readAll(t, h.readRequest, client[10:])
+
+	expected := []struct {
+		fromClient bool
+		opcode     int32
+		typ        string
+		fin        bool
+		length     int64
+		preview    string
+		closeCode  int32
+	}{
+		{false, wsOpBinary, "Binary", true, 300, string(bytes.Repeat([]byte{0xab}, maxWebSocketPreview)), 0},
+		{true, wsOpText, "Text", false, 3, "Hel", 0},
+		{true, wsOpPing, "Ping", true, 1, "p", 0},
+		{true, wsOpContinuation, "Text", true, 2, "lo", 0},
+		{true, wsOpClose, "Close", true, 5, "\x03\xe8bye", 1000},
+	}
+
+	if len(rec.frames) != len(expected) {
+		t.Fatal("expected", len(expected), "frames, got", len(rec.frames))
+	}
+
+	for i, e := range expected {
+		f := rec.frames[i]
+		if f.FromClient != e.fromClient || f.OpCode != e.opcode || f.MessageType != e.typ || f.Fin != e.fin ||
+			f.PayloadLength != e.length || string(f.Preview) != e.preview || f.CloseCode != e.closeCode {
+			t.Errorf("frame %d: unexpected values: %+v", i, f)
+		}
+
+		if f.Masked != e.fromClient {
+			t.Errorf("frame %d: expected masked %v", i, e.fromClient)
+		}
+
+		if e.fromClient && (f.SrcIP != "10.0.0.1" || f.DstPort != 80) {
+			t.Errorf("frame %d: expected client to be the source: %+v", i, f)
+		}
+	}
+}
+
+func wsFragment(dir reassembly.TCPFlowDirection, sec int64, data []byte) *core.StreamData {
+	return &core.StreamData{
+		RawData:            data,
+		Dir:                dir,
+		CaptureInformation: gopacket.CaptureInfo{Timestamp: time.Unix(sec, 0)},
+	}
+}
+
+func TestWebSocketFrameTimestamps(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{}
+
+	rec := &frameRecorder{}
+	WebSocketDecoder.Writer = rec
+
+	defer func() {
+		WebSocketDecoder.Writer = nil
+	}()
+
+	h := &httpReader{conversation: &core.ConversationInfo{
+		FirstClientPacket: time.Unix(1, 0),
+		FirstServerPacket: time.Unix(2, 0),
+	}}
+
+	var (
+		upgrade = []byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		first   = wsFrame(true, wsOpText, false, []byte("first"))
+		second  = wsFrame(true, wsOpBinary, false, bytes.Repeat([]byte{0xab}, 200))
+		third   = wsFrame(true, wsOpText, false, []byte("third"))
+		fourth  = wsFrame(true, wsOpText, false, []byte("fourth"))
+	)
+
+	var server []byte
+	server = append(server, upgrade...)
+	server = append(server, first...)
+	server = append(server, second[:3]...)
+
+	var rest []byte
+	rest = append(rest, second[3:]...)
+	rest = append(rest, third...)
+
+	streamutils.DecodeConversationTimestamps(
+		"test",
+		core.DataFragments{
+			wsFragment(reassembly.TCPDirClientToServer, 1, []byte("GET /chat HTTP/1.1\r\nHost: example.com\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")),
+			// the header of the second frame is split between the fragments
+			wsFragment(reassembly.TCPDirServerToClient, 3, server),
+			wsFragment(reassembly.TCPDirServerToClient, 4, rest),
+			wsFragment(reassembly.TCPDirClientToServer, 5, wsFrame(true, wsOpText, true, []byte("client"))),
+			// the payload of a frame sent in a single fragment continues in the next one
+			wsFragment(reassembly.TCPDirServerToClient, 6, fourth[:4]),
+			wsFragment(reassembly.TCPDirServerToClient, 7, fourth[4:]),
+		},
+		func(b *bufio.Reader, ts time.Time) error {
+			h.timestamp = ts
+
+			return h.readRequest(b)
+		},
+		func(b *bufio.Reader, ts time.Time) error {
+			h.timestamp = ts
+
+			return h.readResponse(b)
+		},
+	)
+
+	expected := []int64{3, 3, 4, 5, 6}
+
+	if len(rec.frames) != len(expected) {
+		t.Fatal("expected", len(expected), "frames, got", len(rec.frames))
+	}
+
+	for i, sec := range expected {
+		if rec.frames[i].Timestamp != time.Unix(sec, 0).UnixNano() {
+			t.Errorf("frame %d: expected timestamp %d, got %d", i, sec, time.Unix(0, rec.frames[i].Timestamp).Unix())
+		}
+	}
+}
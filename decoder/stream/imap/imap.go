@@ -0,0 +1,97 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package imap
+
+import (
+	"regexp"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var imapLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_IMAP,
+	Name:        serviceIMAP,
+	Description: "The Internet Message Access Protocol is used to access and manage the mailboxes on a mail server",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		imapLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"imap",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return reGreeting.Match(server)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return imapLog.Sync()
+	},
+	Factory: &imapReader{},
+	Typ:     core.TCP,
+}
+
+const serviceIMAP = "IMAP"
+
+// the server greets the client with an untagged OK, or PREAUTH if the connection is already authenticated.
+var reGreeting = regexp.MustCompile(`^\* (?i)(OK|PREAUTH)[ \r\n]`)
+
+// status of the tagged completion responses.
+const (
+	statusOK  = "OK"
+	statusNo  = "NO"
+	statusBad = "BAD"
+)
+
+// commands that are handled specifically.
+const (
+	cmdLogin = "LOGIN"
+	cmdUID   = "UID"
+)
+
+// mailboxArgument maps the commands that operate on a mailbox to the position of the mailbox in their arguments.
+// A negative position refers to the last argument.
+var mailboxArgument = map[string]int{
+	"APPEND":      0,
+	"COPY":        -1,
+	"CREATE":      0,
+	"DELETE":      0,
+	"EXAMINE":     0,
+	"LIST":        1,
+	"LSUB":        1,
+	"MOVE":        -1,
+	"RENAME":      0,
+	"SELECT":      0,
+	"STATUS":      0,
+	"SUBSCRIBE":   0,
+	"UID COPY":    -1,
+	"UID MOVE":    -1,
+	"UNSUBSCRIBE": 0,
+}
+
+// switchingCommands change the encoding of the connection after a successful completion,
+// the remaining data can not be parsed as IMAP.
+var switchingCommands = map[string]struct{}{
+	"COMPRESS": {},
+	"STARTTLS": {},
+}
@@ -0,0 +1,405 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package imap
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/decoder/stream/credentials"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var (
+	errIncomplete = errors.New("incomplete line")
+	errInvalid    = errors.New("invalid literal")
+)
+
+// literals exceeding this size, e.g. messages uploaded via APPEND, are not kept as argument.
+const maxLiteralArgument = 1024
+
+// command issued by the client.
+type command struct {
+	timestamp int64
+	tag       string
+	name      string
+	args      []string
+}
+
+// response is the tagged completion response of a command.
+type response struct {
+	status string
+	text   string
+}
+
+type imapReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new IMAP reader.
+func (h *imapReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &imapReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the commands and completion responses of the conversation and writes an audit record for each command.
+// Responses are assigned to the commands by their tag, since the server may complete commands in any order.
+func (h *imapReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	var (
+		client, server []byte
+		fragments      streamutils.Fragments
+	)
+
+	for _, d := range h.conversation.Data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			fragments = append(fragments, streamutils.Fragment{
+				Offset:    len(client),
+				Timestamp: d.CaptureInfo().Timestamp.UnixNano(),
+			})
+			client = append(client, d.Raw()...)
+		} else {
+			server = append(server, d.Raw()...)
+		}
+	}
+
+	commands := parseCommands(client, fragments)
+
+	for _, r := range newRecords(commands, parseResponses(server, switchTag(commands))) {
+		r.Flow = h.conversation.Ident
+		r.SrcIP = h.conversation.ClientIP
+		r.SrcPort = h.conversation.ClientPort
+		r.DstIP = h.conversation.ServerIP
+		r.DstPort = h.conversation.ServerPort
+
+		writeIMAP(r)
+
+		if r.Command == cmdLogin && r.Status != statusNo && r.Status != statusBad && credentials.Decoder.Writer != nil {
+			credentials.WriteCredentials(&types.Credentials{
+				Timestamp: r.Timestamp,
+				Service:   serviceIMAP,
+				Flow:      r.Flow,
+				User:      r.User,
+				Password:  r.Password,
+			})
+		}
+	}
+}
+
+// newRecords creates an audit record for each command and assigns the completion response with the same tag.
+// Clients should use unique tags, if a tag is reused the responses are assigned in order.
+func newRecords(commands []*command, responses map[string][]*response) []*types.IMAP {
+	records := make([]*types.IMAP, 0, len(commands))
+
+	for _, c := range commands {
+		r := &types.IMAP{
+			Timestamp: c.timestamp,
+			Tag:       c.tag,
+			Command:   c.name,
+		}
+
+		if pos, ok := mailboxArgument[c.name]; ok && len(c.args) > 0 {
+			if pos < 0 {
+				pos = len(c.args) - 1
+			}
+
+			if pos < len(c.args) {
+				r.Mailbox = c.args[pos]
+			}
+		}
+
+		if c.name == cmdLogin && len(c.args) == 2 {
+			r.User, r.Password = c.args[0], c.args[1]
+		}
+
+		if res := responses[c.tag]; len(res) > 0 {
+			r.Status, r.StatusText = res[0].status, res[0].text
+			responses[c.tag] = res[1:]
+		}
+
+		records = append(records, r)
+	}
+
+	return records
+}
+
+// switchTag returns the tag of the command after which the connection switches to another encoding.
+func switchTag(commands []*command) string {
+	if len(commands) == 0 {
+		return ""
+	}
+
+	if c := commands[len(commands)-1]; isSwitchingCommand(c) {
+		return c.tag
+	}
+
+	return ""
+}
+
+func isSwitchingCommand(c *command) bool {
+	_, ok := switchingCommands[c.name]
+
+	return ok
+}
+
+// parseCommands parses all complete commands in data.
+// Lines without a command, e.g. the responses to an AUTHENTICATE challenge or the DONE of IDLE, are skipped.
+// The timestamp of a command is taken from the fragment it starts in.
+func parseCommands(data []byte, fragments streamutils.Fragments) []*command {
+	var (
+		commands []*command
+		offset   int
+	)
+
+	for offset < len(data) {
+		tokens, _, n, err := parseLine(data[offset:])
+		if err != nil {
+			if errors.Is(err, errInvalid) {
+				imapLog.Debug("failed to parse command", zap.Int("offset", offset))
+			}
+
+			break
+		}
+
+		if c := newCommand(tokens); c != nil {
+			c.timestamp = fragments.Timestamp(offset)
+
+			commands = append(commands, c)
+
+			if isSwitchingCommand(c) {
+				break
+			}
+		}
+
+		offset += n
+	}
+
+	return commands
+}
+
+// newCommand creates a command from the tokens of a line, nil is returned if the line does not contain a command.
+func newCommand(tokens []string) *command {
+	if len(tokens) < 2 || !isCommandName(tokens[1]) {
+		return nil
+	}
+
+	c := &command{
+		tag:  tokens[0],
+		name: strings.ToUpper(tokens[1]),
+		args: tokens[2:],
+	}
+
+	if c.name == cmdUID && len(c.args) > 0 && isCommandName(c.args[0]) {
+		c.name += " " + strings.ToUpper(c.args[0])
+		c.args = c.args[1:]
+	}
+
+	return c
+}
+
+func isCommandName(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// parseResponses parses the tagged completion responses in data and groups them by tag.
+// Untagged responses and continuation requests are skipped, including the literals they contain.
+// Parsing stops after the response for stopTag, since the following data uses another encoding.
+func parseResponses(data []byte, stopTag string) map[string][]*response {
+	var (
+		responses = make(map[string][]*response)
+		offset    int
+	)
+
+	for offset < len(data) {
+		_, text, n, err := parseLine(data[offset:])
+		if err != nil {
+			if errors.Is(err, errInvalid) {
+				imapLog.Debug("failed to parse response", zap.Int("offset", offset))
+			}
+
+			break
+		}
+
+		offset += n
+
+		parts := strings.SplitN(string(text), " ", 3)
+		if len(parts) < 2 || parts[0] == "*" || parts[0] == "+" {
+			continue
+		}
+
+		status := strings.ToUpper(parts[1])
+		if status != statusOK && status != statusNo && status != statusBad {
+			continue
+		}
+
+		res := &response{status: status}
+		if len(parts) == 3 {
+			res.text = parts[2]
+		}
+
+		responses[parts[0]] = append(responses[parts[0]], res)
+
+		if stopTag != "" && parts[0] == stopTag {
+			break
+		}
+	}
+
+	return responses
+}
+
+// parseLine tokenizes the line at the start of data, along with the literals announced with {n} at the end of its segments.
+// The text of the line up to the first literal is returned as well, and the number of consumed bytes.
+func parseLine(data []byte) (tokens []string, text []byte, n int, err error) {
+	for {
+		line, l, errLine := readLine(data[n:])
+		if errLine != nil {
+			return nil, nil, 0, errLine
+		}
+
+		n += l
+
+		segment, size, isLiteral, errLiteral := literal(line)
+		if errLiteral != nil {
+			return nil, nil, 0, errLiteral
+		}
+
+		if text == nil {
+			text = segment
+		}
+
+		tokens = append(tokens, splitTokens(segment)...)
+
+		if !isLiteral {
+			return tokens, text, n, nil
+		}
+
+		if len(data)-n < size {
+			return nil, nil, 0, errIncomplete
+		}
+
+		if size <= maxLiteralArgument {
+			tokens = append(tokens, string(data[n:n+size]))
+		} else {
+			tokens = append(tokens, "")
+		}
+
+		n += size
+	}
+}
+
+// literal checks if the line ends with the announcement of a literal, e.g. {12} or the non synchronizing {12+},
+// and returns the line without the announcement and the size of the literal.
+func literal(line []byte) (segment []byte, size int, ok bool, err error) {
+	if len(line) == 0 || line[len(line)-1] != '}' {
+		return line, 0, false, nil
+	}
+
+	start := bytes.LastIndexByte(line, '{')
+	if start == -1 {
+		return line, 0, false, nil
+	}
+
+	num := bytes.TrimRight(line[start+1:len(line)-1], "+-")
+
+	size, err = strconv.Atoi(string(num))
+	if err != nil || size < 0 {
+		return nil, 0, false, errInvalid
+	}
+
+	// binary literals of RFC 3516 are prefixed with a tilde
+	segment = bytes.TrimSuffix(line[:start], []byte("~"))
+
+	return segment, size, true, nil
+}
+
+// splitTokens splits a segment of a line into atoms and quoted strings.
+// Parenthesized lists are not parsed, their elements are returned as atoms.
+func splitTokens(s []byte) (tokens []string) {
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case ' ':
+			i++
+		case '"':
+			var b strings.Builder
+
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+
+				b.WriteByte(s[i])
+			}
+
+			// skip the closing quote
+			i++
+
+			tokens = append(tokens, b.String())
+		default:
+			end := bytes.IndexByte(s[i:], ' ')
+			if end == -1 {
+				end = len(s) - i
+			}
+
+			tokens = append(tokens, string(s[i:i+end]))
+			i += end
+		}
+	}
+
+	return tokens
+}
+
+// readLine returns the line at the start of data without the line terminator,
+// and the number of bytes including the terminator.
+func readLine(data []byte) (line []byte, n int, err error) {
+	i := bytes.IndexByte(data, '\n')
+	if i == -1 {
+		return nil, 0, errIncomplete
+	}
+
+	return bytes.TrimRight(data[:i], "\r"), i + 1, nil
+}
+
+// writeIMAP writes the audit record and updates the metrics if enabled.
+func writeIMAP(r *types.IMAP) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		imapLog.Error("failed to write IMAP audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package imap
+
+import (
+	"testing"
+
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func decode(client, server string) []*types.IMAP {
+	commands := parseCommands([]byte(client), streamutils.Fragments{{Offset: 0, Timestamp: 1}})
+
+	return newRecords(commands, parseResponses([]byte(server), switchTag(commands)))
+}
+
+func TestCommands(t *testing.T) {
+	records := decode(
+		"a1 LOGIN {4}\r\nuser \"pa\\\"ss\"\r\n"+
+			"a2 SELECT INBOX\r\n"+
+			"a3 FETCH 1:* (FLAGS)\r\n"+
+			"a4 UID FETCH 1 BODY[]\r\n"+
+			"a5 UID COPY 1 \"Sent Items\"\r\n"+
+			"a6 APPEND Drafts (\\Seen) {11+}\r\nhello world\r\n"+
+			"a7 IDLE\r\nDONE\r\n"+
+			"a8 NOOP\r\n",
+		"* OK [CAPABILITY IMAP4rev1] Dovecot ready.\r\n"+
+			"+ Ready for literal data\r\n"+
+			"a1 OK Logged in\r\n"+
+			"* 2 EXISTS\r\n"+
+			"a2 OK [READ-WRITE] Select completed\r\n"+
+			"* 1 FETCH (FLAGS (\\Seen))\r\n"+
+			"a3 OK Fetch completed\r\n"+
+			"* 1 FETCH (UID 1 BODY[] {17}\r\na4 NO not a tag\r\n)\r\n"+
+			// completion responses in a different order than the commands
+			"a6 OK Append completed\r\n"+
+			"a5 NO [TRYCREATE] Mailbox doesn't exist\r\n"+
+			"a4 OK Fetch completed\r\n"+
+			"+ idling\r\n"+
+			"a7 OK Idle completed\r\n",
+	)
+
+	expected := []types.IMAP{
+		{Timestamp: 1, Tag: "a1", Command: "LOGIN", User: "user", Password: "pa\"ss", Status: statusOK, StatusText: "Logged in"},
+		{Timestamp: 1, Tag: "a2", Command: "SELECT", Mailbox: "INBOX", Status: statusOK, StatusText: "[READ-WRITE] Select completed"},
+		{Timestamp: 1, Tag: "a3", Command: "FETCH", Status: statusOK, StatusText: "Fetch completed"},
+		{Timestamp: 1, Tag: "a4", Command: "UID FETCH", Status: statusOK, StatusText: "Fetch completed"},
+		{Timestamp: 1, Tag: "a5", Command: "UID COPY", Mailbox: "Sent Items", Status: statusNo, StatusText: "[TRYCREATE] Mailbox doesn't exist"},
+		{Timestamp: 1, Tag: "a6", Command: "APPEND", Mailbox: "Drafts", Status: statusOK, StatusText: "Append completed"},
+		{Timestamp: 1, Tag: "a7", Command: "IDLE", Status: statusOK, StatusText: "Idle completed"},
+		{Timestamp: 1, Tag: "a8", Command: "NOOP"},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatal("expected", len(expected), "records, got", len(records))
+	}
+
+	for i, r := range records {
+		if *r != expected[i] {
+			t.Errorf("record %d: expected %+v, got %+v", i, expected[i], *r)
+		}
+	}
+}
+
+func TestStartTLS(t *testing.T) {
+	records := decode(
+		"a1 CAPABILITY\r\na2 STARTTLS\r\n\x16\x03\x01 \x02\x00\r\n",
+		"* OK IMAP4rev1 ready\r\na1 OK done\r\na2 OK Begin TLS negotiation now\r\n\x16\x03\x03 OK\r\n",
+	)
+
+	if len(records) != 2 {
+		t.Fatal("expected 2 records, got", len(records))
+	}
+
+	if records[1].Command != "STARTTLS" || records[1].Status != statusOK {
+		t.Fatal("unexpected record for STARTTLS", records[1])
+	}
+
+	// incomplete literal
+	if records = decode("a1 LOGIN {10}\r\nuser", ""); len(records) != 0 {
+		t.Fatal("expected no records for an incomplete command, got", len(records))
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ldap
+
+// element is a BER encoded value, LDAP messages only use single byte tags and definite lengths.
+type element struct {
+	tag     byte
+	content []byte
+}
+
+// readLength parses the length field at the start of data,
+// and returns the length of the content and the size of the length field.
+func readLength(data []byte) (length uint64, size int, ok bool) {
+	if len(data) == 0 {
+		return 0, 0, false
+	}
+
+	if data[0] < 0x80 {
+		return uint64(data[0]), 1, true
+	}
+
+	// indefinite lengths are not allowed in LDAP, and no message exceeds four length bytes
+	num := int(data[0] & 0x7f)
+	if num == 0 || num > 4 || len(data) < 1+num {
+		return 0, 0, false
+	}
+
+	for _, b := range data[1 : 1+num] {
+		length = length<<8 | uint64(b)
+	}
+
+	return length, 1 + num, true
+}
+
+// readElement parses the element at the start of data and returns the data following it.
+// ok is false if the element is incomplete, e.g. because the rest of it has not been captured.
+func readElement(data []byte) (e element, rest []byte, ok bool) {
+	// multi byte tags are not used by LDAP
+	if len(data) < 2 || data[0]&0x1f == 0x1f {
+		return e, nil, false
+	}
+
+	length, size, ok := readLength(data[1:])
+	if !ok {
+		return e, nil, false
+	}
+
+	start := 1 + size
+	if uint64(len(data)-start) < length {
+		return e, nil, false
+	}
+
+	end := start + int(length)
+
+	return element{tag: data[0], content: data[start:end]}, data[end:], true
+}
+
+// integer decodes the content of an INTEGER or ENUMERATED element.
+func (e element) integer() (int64, bool) {
+	if len(e.content) == 0 || len(e.content) > 8 {
+		return 0, false
+	}
+
+	// two's complement, sign extend the first byte
+	v := int64(int8(e.content[0]))
+	for _, b := range e.content[1:] {
+		v = v<<8 | int64(b)
+	}
+
+	return v, true
+}
+
+// berReader reads the elements of a constructed value in order.
+// Once a read failed all following reads fail as well,
+// so the result only needs to be checked after a group of reads.
+type berReader struct {
+	data []byte
+	ok   bool
+}
+
+func newBERReader(data []byte) *berReader {
+	return &berReader{data: data, ok: true}
+}
+
+// more checks if there are elements left to read.
+func (r *berReader) more() bool {
+	return r.ok && len(r.data) > 0
+}
+
+// next reads the next element.
+func (r *berReader) next() element {
+	if !r.ok {
+		return element{}
+	}
+
+	e, rest, ok := readElement(r.data)
+	if !ok {
+		r.ok = false
+
+		return element{}
+	}
+
+	r.data = rest
+
+	return e
+}
+
+// expect reads the next element, which must have the given tag.
+func (r *berReader) expect(tag byte) element {
+	e := r.next()
+	if e.tag != tag {
+		r.ok = false
+	}
+
+	return e
+}
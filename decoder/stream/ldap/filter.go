@@ -0,0 +1,208 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ldap
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// filter choices, tagged with the context specific class.
+const (
+	filterAnd             = 0xa0
+	filterOr              = 0xa1
+	filterNot             = 0xa2
+	filterEqualityMatch   = 0xa3
+	filterSubstrings      = 0xa4
+	filterGreaterOrEqual  = 0xa5
+	filterLessOrEqual     = 0xa6
+	filterPresent         = 0x87
+	filterApproxMatch     = 0xa8
+	filterExtensibleMatch = 0xa9
+)
+
+// parts of a substring filter.
+const (
+	substringInitial = 0x80
+	substringAny     = 0x81
+	substringFinal   = 0x82
+)
+
+// fields of an extensible match.
+const (
+	matchingRule = 0x81
+	matchingType = 0x82
+	matchValue   = 0x83
+	dnAttributes = 0x84
+)
+
+// maxFilterDepth limits the nesting of filters, to prevent exhausting the stack with crafted messages.
+const maxFilterDepth = 32
+
+var assertionOperators = map[byte]string{
+	filterEqualityMatch:  "=",
+	filterGreaterOrEqual: ">=",
+	filterLessOrEqual:    "<=",
+	filterApproxMatch:    "~=",
+}
+
+// filterString returns the string representation of a search filter as defined in RFC 4515,
+// e.g. (&(objectClass=user)(servicePrincipalName=*)).
+// An empty string is returned if the filter is malformed.
+func filterString(e element) string {
+	var b strings.Builder
+
+	if !writeFilter(&b, e, 0) {
+		return ""
+	}
+
+	return b.String()
+}
+
+func writeFilter(b *strings.Builder, e element, depth int) bool {
+	if depth > maxFilterDepth {
+		return false
+	}
+
+	r := newBERReader(e.content)
+
+	switch e.tag {
+	case filterAnd, filterOr:
+		if e.tag == filterAnd {
+			b.WriteString("(&")
+		} else {
+			b.WriteString("(|")
+		}
+
+		for r.more() {
+			if !writeFilter(b, r.next(), depth+1) {
+				return false
+			}
+		}
+
+		b.WriteString(")")
+
+		return r.ok
+	case filterNot:
+		b.WriteString("(!")
+
+		if !writeFilter(b, r.next(), depth+1) {
+			return false
+		}
+
+		b.WriteString(")")
+
+		return r.ok
+	case filterEqualityMatch, filterGreaterOrEqual, filterLessOrEqual, filterApproxMatch:
+		attr := r.expect(tagOctetString)
+		value := r.expect(tagOctetString)
+
+		b.WriteString("(" + string(attr.content) + assertionOperators[e.tag] + escape(value.content) + ")")
+
+		return r.ok
+	case filterSubstrings:
+		attr := r.expect(tagOctetString)
+		parts := newBERReader(r.expect(tagSequence).content)
+
+		if !r.ok {
+			return false
+		}
+
+		// initial*any*any*final
+		var initial, final string
+
+		middle := "*"
+
+		for parts.more() {
+			p := parts.next()
+
+			switch p.tag {
+			case substringInitial:
+				initial = escape(p.content)
+			case substringAny:
+				middle += escape(p.content) + "*"
+			case substringFinal:
+				final = escape(p.content)
+			default:
+				return false
+			}
+		}
+
+		b.WriteString("(" + string(attr.content) + "=" + initial + middle + final + ")")
+
+		return parts.ok
+	case filterPresent:
+		b.WriteString("(" + string(e.content) + "=*)")
+
+		return true
+	case filterExtensibleMatch:
+		var rule, typ, value string
+
+		dn := false
+
+		for r.more() {
+			f := r.next()
+
+			switch f.tag {
+			case matchingRule:
+				rule = string(f.content)
+			case matchingType:
+				typ = string(f.content)
+			case matchValue:
+				value = escape(f.content)
+			case dnAttributes:
+				dn = len(f.content) == 1 && f.content[0] != 0
+			}
+		}
+
+		b.WriteString("(" + typ)
+
+		if dn {
+			b.WriteString(":dn")
+		}
+
+		if rule != "" {
+			b.WriteString(":" + rule)
+		}
+
+		b.WriteString(":=" + value + ")")
+
+		return r.ok
+	}
+
+	return false
+}
+
+// escape escapes the special characters of an assertion value as defined in RFC 4515.
+// Binary values, like object SIDs, are escaped entirely.
+func escape(value []byte) string {
+	var (
+		b      strings.Builder
+		binary = !utf8.Valid(value)
+	)
+
+	for _, c := range value {
+		switch {
+		case c == '*' || c == '(' || c == ')' || c == '\\' || c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\%02x", c)
+		case binary && c >= 0x80:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,134 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ldap
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var ldapLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_LDAP,
+	Name:        serviceLDAP,
+	Description: "The Lightweight Directory Access Protocol is used to query and modify directory services such as Active Directory",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		ldapLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"ldap",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isLDAP(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return ldapLog.Sync()
+	},
+	Factory: &ldapReader{},
+	Typ:     core.TCP,
+}
+
+const serviceLDAP = "LDAP"
+
+// BER tags of the universal class.
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+)
+
+// protocol operations, tagged with the application class.
+const (
+	opBindRequest    = 0x60
+	opBindResponse   = 0x61
+	opSearchRequest  = 0x63
+	opSearchResEntry = 0x64
+	opSearchResDone  = 0x65
+)
+
+// authentication choices of a bind request, tagged with the context specific class.
+const (
+	authSimple = 0x80
+	authSASL   = 0xa3
+)
+
+// names of the decoded operations and authentication types.
+const (
+	operationBind   = "bind"
+	operationSearch = "search"
+
+	authTypeSimple = "simple"
+	authTypeSASL   = "SASL"
+)
+
+var scopeNames = map[int64]string{
+	0: "baseObject",
+	1: "singleLevel",
+	2: "wholeSubtree",
+}
+
+// result codes defined in RFC 4511.
+var resultNames = map[int64]string{
+	0:  "success",
+	1:  "operationsError",
+	2:  "protocolError",
+	3:  "timeLimitExceeded",
+	4:  "sizeLimitExceeded",
+	5:  "compareFalse",
+	6:  "compareTrue",
+	7:  "authMethodNotSupported",
+	8:  "strongerAuthRequired",
+	10: "referral",
+	11: "adminLimitExceeded",
+	12: "unavailableCriticalExtension",
+	13: "confidentialityRequired",
+	14: "saslBindInProgress",
+	16: "noSuchAttribute",
+	17: "undefinedAttributeType",
+	18: "inappropriateMatching",
+	19: "constraintViolation",
+	20: "attributeOrValueExists",
+	21: "invalidAttributeSyntax",
+	32: "noSuchObject",
+	33: "aliasProblem",
+	34: "invalidDNSyntax",
+	36: "aliasDereferencingProblem",
+	48: "inappropriateAuthentication",
+	49: "invalidCredentials",
+	50: "insufficientAccessRights",
+	51: "busy",
+	52: "unavailable",
+	53: "unwillingToPerform",
+	54: "loopDetect",
+	64: "namingViolation",
+	65: "objectClassViolation",
+	66: "notAllowedOnNonLeaf",
+	67: "notAllowedOnRDN",
+	68: "entryAlreadyExists",
+	69: "objectClassModsProhibited",
+	71: "affectsMultipleDSAs",
+	80: "other",
+}
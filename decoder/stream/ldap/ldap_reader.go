@@ -0,0 +1,366 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ldap
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/decoder/stream/credentials"
+	"github.com/dreadl0ck/netcap/decoder/stream/ntlm"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// isLDAP checks if the data starts with the header of a LDAP message:
+// a sequence with the message identifier, followed by a protocol operation of the application class.
+// Only the header is checked, the rest of the message may follow in later segments.
+func isLDAP(data []byte) bool {
+	if len(data) < 2 || data[0] != tagSequence {
+		return false
+	}
+
+	_, size, ok := readLength(data[1:])
+	if !ok {
+		return false
+	}
+
+	id, rest, ok := readElement(data[1+size:])
+	if !ok || id.tag != tagInteger {
+		return false
+	}
+
+	return len(rest) > 0 && rest[0]&0xc0 == 0x40
+}
+
+// message is a LDAP message with the protocol operation.
+type message struct {
+	id        int32
+	op        element
+	timestamp int64
+}
+
+// parseMessages parses the consecutive LDAP messages in data.
+// Parsing stops at the first incomplete or invalid message,
+// e.g. once the connection switched to TLS or SASL wrapped messages.
+// The timestamp of a message is taken from the fragment it starts in.
+func parseMessages(data []byte, fragments streamutils.Fragments) (messages []*message) {
+	rest := data
+
+	for len(rest) > 0 {
+		offset := len(data) - len(rest)
+
+		msg, next, ok := readElement(rest)
+		if !ok || msg.tag != tagSequence {
+			ldapLog.Debug("incomplete or invalid message", zap.Int("offset", offset))
+
+			break
+		}
+
+		r := newBERReader(msg.content)
+		id, _ := r.expect(tagInteger).integer()
+		op := r.next()
+
+		if !r.ok {
+			ldapLog.Debug("invalid message", zap.Int("offset", offset))
+
+			break
+		}
+
+		messages = append(messages, &message{
+			id:        int32(id),
+			op:        op,
+			timestamp: fragments.Timestamp(offset),
+		})
+
+		rest = next
+	}
+
+	return messages
+}
+
+// result of an operation, as returned by the server.
+type result struct {
+	code    string
+	entries int32
+
+	// NTLM challenge of the server in a bind response
+	ntlm      *ntlm.Message
+	timestamp int64
+}
+
+// results collects the results of the responses of the server for each message identifier.
+// Identifiers can be reused once an operation completed, so the results are kept in the order of the responses.
+func results(messages []*message) map[int32][]*result {
+	var (
+		res     = make(map[int32][]*result)
+		entries = make(map[int32]int32)
+	)
+
+	for _, m := range messages {
+		switch m.op.tag {
+		case opSearchResEntry:
+			entries[m.id]++
+		case opBindResponse, opSearchResDone:
+			code, ok := newBERReader(m.op.content).expect(tagEnumerated).integer()
+			if !ok {
+				continue
+			}
+
+			r := &result{
+				code:    resultName(code),
+				entries: entries[m.id],
+			}
+
+			if m.op.tag == opBindResponse {
+				r.ntlm = ntlm.Parse(m.op.content)
+				r.timestamp = m.timestamp
+			}
+
+			res[m.id] = append(res[m.id], r)
+
+			delete(entries, m.id)
+		}
+	}
+
+	return res
+}
+
+// resultName returns the name for a result code, or the code if it is unknown.
+func resultName(code int64) string {
+	if n, ok := resultNames[code]; ok {
+		return n
+	}
+
+	return strconv.FormatInt(code, 10)
+}
+
+// decode parses the LDAP messages of the conversation and creates an audit record for each bind and search request.
+// Requests are paired with the responses by the message identifier, since several operations can be outstanding at the same time.
+// For simple binds with a password, credentials are returned as well,
+// and NTLM authentications for binds that use SASL or the sicily authentication of Active Directory.
+func decode(data core.DataFragments) (records []*types.LDAP, creds []*types.Credentials, auths []*types.NTLM) {
+	var (
+		client, server  []byte
+		clientFragments streamutils.Fragments
+		serverFragments streamutils.Fragments
+		auth            = &ntlm.Session{Protocol: serviceLDAP}
+	)
+
+	for _, d := range data {
+		f := streamutils.Fragment{Timestamp: d.CaptureInfo().Timestamp.UnixNano()}
+
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			f.Offset = len(client)
+			clientFragments = append(clientFragments, f)
+			client = append(client, d.Raw()...)
+		} else {
+			f.Offset = len(server)
+			serverFragments = append(serverFragments, f)
+			server = append(server, d.Raw()...)
+		}
+	}
+
+	res := results(parseMessages(server, serverFragments))
+
+	for _, m := range parseMessages(client, clientFragments) {
+		var (
+			r        *types.LDAP
+			password string
+		)
+
+		switch m.op.tag {
+		case opBindRequest:
+			r, password = decodeBind(m.op)
+		case opSearchRequest:
+			r = decodeSearch(m.op)
+		default:
+			continue
+		}
+
+		if r == nil {
+			ldapLog.Debug("invalid request", zap.Int32("messageID", m.id), zap.Uint8("operation", m.op.tag))
+
+			continue
+		}
+
+		r.Timestamp = m.timestamp
+		r.MessageID = m.id
+
+		if m.op.tag == opBindRequest {
+			if a := auth.Add(ntlm.Parse(m.op.content), m.timestamp); a != nil {
+				auths = append(auths, a)
+			}
+		}
+
+		if pending := res[m.id]; len(pending) > 0 {
+			r.Result = pending[0].code
+			r.Entries = pending[0].entries
+			res[m.id] = pending[1:]
+
+			auth.Add(pending[0].ntlm, pending[0].timestamp)
+		}
+
+		records = append(records, r)
+
+		// an empty password is an unauthenticated bind
+		if password != "" {
+			creds = append(creds, &types.Credentials{
+				Timestamp: m.timestamp,
+				Service:   serviceLDAP,
+				User:      r.BindDN,
+				Password:  password,
+				Notes:     "Simple Bind, Result: " + r.Result,
+			})
+		}
+	}
+
+	return records, creds, auths
+}
+
+// decodeBind parses a bind request, the password is returned for simple binds.
+func decodeBind(op element) (*types.LDAP, string) {
+	r := newBERReader(op.content)
+	r.expect(tagInteger) // version
+	name := r.expect(tagOctetString)
+	auth := r.next()
+
+	if !r.ok {
+		return nil, ""
+	}
+
+	rec := &types.LDAP{
+		Operation: operationBind,
+		BindDN:    string(name.content),
+	}
+
+	var password string
+
+	switch auth.tag {
+	case authSimple:
+		rec.AuthType = authTypeSimple
+		password = string(auth.content)
+	case authSASL:
+		rec.AuthType = authTypeSASL
+
+		mechanism := newBERReader(auth.content).expect(tagOctetString)
+		rec.Mechanism = string(mechanism.content)
+	}
+
+	return rec, password
+}
+
+// decodeSearch parses a search request.
+func decodeSearch(op element) *types.LDAP {
+	r := newBERReader(op.content)
+	base := r.expect(tagOctetString)
+	scope := r.expect(tagEnumerated)
+	r.expect(tagEnumerated) // derefAliases
+	r.expect(tagInteger)    // sizeLimit
+	r.expect(tagInteger)    // timeLimit
+	r.expect(tagBoolean)    // typesOnly
+	f := r.next()
+
+	if !r.ok {
+		return nil
+	}
+
+	rec := &types.LDAP{
+		Operation: operationSearch,
+		BaseDN:    string(base.content),
+		Filter:    filterString(f),
+	}
+
+	if s, ok := scope.integer(); ok {
+		rec.Scope = scopeNames[s]
+	}
+
+	attrs := newBERReader(r.expect(tagSequence).content)
+	for attrs.more() {
+		if a := attrs.expect(tagOctetString); attrs.ok {
+			rec.Attributes = append(rec.Attributes, string(a.content))
+		}
+	}
+
+	return rec
+}
+
+type ldapReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new LDAP reader.
+func (h *ldapReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &ldapReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the LDAP messages of the conversation and writes an audit record for each bind and search request.
+func (h *ldapReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	records, creds, auths := decode(h.conversation.Data)
+
+	for _, r := range records {
+		r.Flow = h.conversation.Ident
+		r.SrcIP = h.conversation.ClientIP
+		r.SrcPort = h.conversation.ClientPort
+		r.DstIP = h.conversation.ServerIP
+		r.DstPort = h.conversation.ServerPort
+
+		writeLDAP(r)
+	}
+
+	for _, a := range auths {
+		a.Flow = h.conversation.Ident
+		a.SrcIP = h.conversation.ClientIP
+		a.SrcPort = h.conversation.ClientPort
+		a.DstIP = h.conversation.ServerIP
+		a.DstPort = h.conversation.ServerPort
+
+		ntlm.WriteNTLM(a)
+	}
+
+	if credentials.Decoder.Writer == nil {
+		return
+	}
+
+	for _, c := range creds {
+		c.Flow = h.conversation.Ident
+		credentials.WriteCredentials(c)
+	}
+}
+
+// writeLDAP writes the audit record and updates the metrics if enabled.
+func writeLDAP(r *types.LDAP) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		ldapLog.Error("failed to write LDAP audit record", zap.Error(err))
+	}
+}
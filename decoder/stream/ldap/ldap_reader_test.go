@@ -0,0 +1,213 @@
+package ldap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// tlv encodes a BER element, lengths above 127 bytes use the long form.
+func tlv(tag byte, content ...[]byte) []byte {
+	var c []byte
+	for _, b := range content {
+		c = append(c, b...)
+	}
+
+	if len(c) < 0x80 {
+		return append([]byte{tag, byte(len(c))}, c...)
+	}
+
+	return append([]byte{tag, 0x82, byte(len(c) >> 8), byte(len(c))}, c...)
+}
+
+func str(tag byte, s string) []byte {
+	return tlv(tag, []byte(s))
+}
+
+func integer(tag byte, v byte) []byte {
+	return tlv(tag, []byte{v})
+}
+
+func msg(id byte, op []byte) []byte {
+	return tlv(tagSequence, integer(tagInteger, id), op)
+}
+
+func search(base string, filter []byte, attrs ...string) []byte {
+	var a [][]byte
+	for _, attr := range attrs {
+		a = append(a, str(tagOctetString, attr))
+	}
+
+	return tlv(opSearchRequest,
+		str(tagOctetString, base),
+		integer(tagEnumerated, 2),
+		integer(tagEnumerated, 0),
+		integer(tagInteger, 0),
+		integer(tagInteger, 0),
+		integer(tagBoolean, 0),
+		filter,
+		tlv(tagSequence, a...),
+	)
+}
+
+func ldapResult(tag byte, code byte) []byte {
+	return tlv(tag, integer(tagEnumerated, code), str(tagOctetString, ""), str(tagOctetString, ""))
+}
+
+func equality(attr, value string) []byte {
+	return tlv(filterEqualityMatch, str(tagOctetString, attr), str(tagOctetString, value))
+}
+
+func TestIsLDAP(t *testing.T) {
+	bind := msg(1, tlv(opBindRequest, integer(tagInteger, 3), str(tagOctetString, ""), str(authSimple, "")))
+
+	if !isLDAP(bind) {
+		t.Fatal("bind request not detected")
+	}
+
+	// only the header is needed
+	if !isLDAP(bind[:6]) {
+		t.Fatal("truncated bind request not detected")
+	}
+
+	if isLDAP([]byte("GET / HTTP/1.1\r\n")) || isLDAP([]byte{tagSequence, 0x03, tagOctetString, 0x01, 0x00}) {
+		t.Fatal("unexpected detection")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var (
+		// the kerberoasting search is larger than 127 bytes, so the message uses the long length form
+		spn = search(
+			"DC=corp,DC=local",
+			tlv(filterAnd,
+				equality("objectCategory", "CN=Person,CN=Schema,CN=Configuration,DC=corp,DC=local"),
+				str(filterPresent, "servicePrincipalName"),
+			),
+			"servicePrincipalName", "sAMAccountName",
+		)
+		pipelined = append(
+			msg(2, search("DC=corp,DC=local", str(filterPresent, "objectClass"), "cn")),
+			msg(3, spn)...,
+		)
+	)
+
+	var data core.DataFragments
+
+	for _, d := range []struct {
+		dir  reassembly.TCPFlowDirection
+		data []byte
+	}{
+		{reassembly.TCPDirClientToServer, msg(1, tlv(opBindRequest, integer(tagInteger, 3), str(tagOctetString, "CN=svc,DC=corp,DC=local"), str(authSimple, "Summer2020!")))},
+		{reassembly.TCPDirServerToClient, msg(1, ldapResult(opBindResponse, 0))},
+		// two requests in flight, the second one is split within the length field
+		{reassembly.TCPDirClientToServer, pipelined[:len(pipelined)-len(msg(3, spn))+3]},
+		{reassembly.TCPDirClientToServer, pipelined[len(pipelined)-len(msg(3, spn))+3:]},
+		// the responses arrive interleaved
+		{reassembly.TCPDirServerToClient, msg(3, tlv(opSearchResEntry, str(tagOctetString, "CN=svc,DC=corp,DC=local"), tlv(tagSequence)))},
+		{reassembly.TCPDirServerToClient, msg(2, tlv(opSearchResEntry, str(tagOctetString, "DC=corp,DC=local"), tlv(tagSequence)))},
+		{reassembly.TCPDirServerToClient, msg(3, tlv(opSearchResEntry, str(tagOctetString, "CN=sql,DC=corp,DC=local"), tlv(tagSequence)))},
+		{reassembly.TCPDirServerToClient, append(msg(3, ldapResult(opSearchResDone, 0)), msg(2, ldapResult(opSearchResDone, 32))...)},
+		{reassembly.TCPDirClientToServer, msg(4, tlv(opBindRequest, integer(tagInteger, 3), str(tagOctetString, ""), tlv(authSASL, str(tagOctetString, "GSS-SPNEGO"), str(tagOctetString, "token"))))},
+		{reassembly.TCPDirServerToClient, msg(4, ldapResult(opBindResponse, 49))},
+	} {
+		data = append(data, &core.StreamData{RawData: d.data, Dir: d.dir})
+	}
+
+	records, creds, auths := decode(data)
+
+	expected := []*types.LDAP{
+		{MessageID: 1, Operation: "bind", BindDN: "CN=svc,DC=corp,DC=local", AuthType: "simple", Result: "success"},
+		{MessageID: 2, Operation: "search", BaseDN: "DC=corp,DC=local", Scope: "wholeSubtree", Filter: "(objectClass=*)", Attributes: []string{"cn"}, Result: "noSuchObject", Entries: 1},
+		{MessageID: 3, Operation: "search", BaseDN: "DC=corp,DC=local", Scope: "wholeSubtree", Filter: "(&(objectCategory=CN=Person,CN=Schema,CN=Configuration,DC=corp,DC=local)(servicePrincipalName=*))", Attributes: []string{"servicePrincipalName", "sAMAccountName"}, Result: "success", Entries: 2},
+		{MessageID: 4, Operation: "bind", AuthType: "SASL", Mechanism: "GSS-SPNEGO", Result: "invalidCredentials"},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatal("expected", len(expected), "records, got", len(records))
+	}
+
+	for i, r := range records {
+		// the fragments have no capture info
+		r.Timestamp = 0
+
+		if !reflect.DeepEqual(r, expected[i]) {
+			t.Errorf("record %d: expected %+v, got %+v", i, expected[i], r)
+		}
+	}
+
+	if len(creds) != 1 || creds[0].User != "CN=svc,DC=corp,DC=local" || creds[0].Password != "Summer2020!" {
+		t.Fatal("unexpected credentials:", creds)
+	}
+
+	// the SASL token does not contain a NTLM message
+	if len(auths) != 0 {
+		t.Fatal("unexpected NTLM authentications:", auths)
+	}
+}
+
+func TestFilterString(t *testing.T) {
+	tests := []struct {
+		filter   []byte
+		expected string
+	}{
+		{
+			filter:   equality("sAMAccountName", "admin"),
+			expected: "(sAMAccountName=admin)",
+		},
+		{
+			filter: tlv(filterOr,
+				tlv(filterNot, str(filterPresent, "mail")),
+				tlv(filterGreaterOrEqual, str(tagOctetString, "pwdLastSet"), str(tagOctetString, "0")),
+			),
+			expected: "(|(!(mail=*))(pwdLastSet>=0))",
+		},
+		{
+			filter: tlv(filterSubstrings, str(tagOctetString, "cn"), tlv(tagSequence,
+				str(substringInitial, "adm"),
+				str(substringAny, "in"),
+				str(substringFinal, "01"),
+			)),
+			expected: "(cn=adm*in*01)",
+		},
+		{
+			filter:   tlv(filterSubstrings, str(tagOctetString, "cn"), tlv(tagSequence, str(substringAny, "svc"))),
+			expected: "(cn=*svc*)",
+		},
+		{
+			filter: tlv(filterExtensibleMatch,
+				str(matchingRule, "1.2.840.113556.1.4.803"),
+				str(matchingType, "userAccountControl"),
+				str(matchValue, "4194304"),
+			),
+			expected: "(userAccountControl:1.2.840.113556.1.4.803:=4194304)",
+		},
+		{
+			filter:   equality("description", "a*(b)\\"),
+			expected: `(description=a\2a\28b\29\5c)`,
+		},
+		{
+			filter:   tlv(filterEqualityMatch, str(tagOctetString, "objectSid"), tlv(tagOctetString, []byte{0x01, 0x05, 0xff})),
+			expected: `(objectSid=\01\05\ff)`,
+		},
+		{
+			// truncated assertion
+			filter:   tlv(filterEqualityMatch, str(tagOctetString, "cn")),
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		e, _, ok := readElement(test.filter)
+		if !ok {
+			t.Fatal("invalid test filter", test.expected)
+		}
+
+		if f := filterString(e); f != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, f)
+		}
+	}
+}
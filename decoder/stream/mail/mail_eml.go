@@ -0,0 +1,202 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package mail
+
+import (
+	"bytes"
+	"encoding/hex"
+	netmail "net/mail"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dreadl0ck/cryptoutils"
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/decoder/stream/file"
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+const (
+	contentTypeRFC822 = "message/rfc822"
+
+	// number of messages remembered to avoid storing duplicates, the oldest are forgotten first
+	maxSavedMails = 10000
+)
+
+// savedMails contains the Message-IDs or hashes of the messages that have been written to disk,
+// to avoid storing a message twice, e.g. when it was seen via SMTP and later retrieved via POP3.
+var savedMails = struct {
+	sync.Mutex
+	items map[string]struct{}
+
+	// keys in the order they have been added
+	order []string
+}{
+	items: make(map[string]struct{}),
+}
+
+// emlFileNameReplacer removes characters that are problematic in filenames.
+var emlFileNameReplacer = strings.NewReplacer("/", "_", "\\", "_", ":", "_", " ", "_", "<", "", ">", "", "\"", "")
+
+// saveEML writes the raw message as RFC822 .eml file into the file storage and emits a file audit record for it.
+func saveEML(conv *core.ConversationInfo, buf []byte, m *types.Mail, origin string) {
+	var (
+		data = toEML(buf)
+		hash = hex.EncodeToString(cryptoutils.MD5Data(data))
+		key  = strings.TrimSpace(m.MessageID)
+	)
+
+	if key == "" {
+		key = hash
+	}
+
+	if !markMailSaved(key) {
+		return
+	}
+
+	ts := conv.FirstClientPacket
+	if m.Timestamp != 0 {
+		ts = time.Unix(0, m.Timestamp)
+	}
+
+	root := path.Join(decoderconfig.Instance.Out, decoderconfig.Instance.FileStorage, contentTypeRFC822)
+
+	err := os.MkdirAll(root, defaults.DirectoryPermission)
+	if err != nil {
+		mailLog.Error("failed to create directory", zap.String("path", root), zap.Error(err))
+
+		return
+	}
+
+	target, err := writeEMLFile(root, emlFileName(m.From, ts), data)
+	if err != nil {
+		mailLog.Error("failed to save eml file", zap.String("root", root), zap.Error(err))
+
+		return
+	}
+
+	file.WriteFile(&types.File{
+		Timestamp:           ts.UnixNano(),
+		Name:                path.Base(target),
+		Length:              int64(len(data)),
+		Hash:                hash,
+		Location:            target,
+		Ident:               conv.Ident,
+		Source:              origin,
+		ContentType:         contentTypeRFC822,
+		ContentTypeDetected: contentTypeRFC822,
+		SrcIP:               conv.ClientIP,
+		DstIP:               conv.ServerIP,
+		SrcPort:             conv.ClientPort,
+		DstPort:             conv.ServerPort,
+		Host:                conv.ServerIP + ":" + strconv.Itoa(int(conv.ServerPort)),
+	})
+}
+
+// markMailSaved remembers the key of a message that is written to disk,
+// false is returned if the message has been saved before.
+func markMailSaved(key string) bool {
+	savedMails.Lock()
+	defer savedMails.Unlock()
+
+	if _, ok := savedMails.items[key]; ok {
+		return false
+	}
+
+	savedMails.items[key] = struct{}{}
+	savedMails.order = append(savedMails.order, key)
+
+	if len(savedMails.order) > maxSavedMails {
+		delete(savedMails.items, savedMails.order[0])
+		savedMails.order = savedMails.order[1:]
+	}
+
+	return true
+}
+
+// emlFileName returns the name for the message file, based on the sender address and the timestamp.
+func emlFileName(from string, ts time.Time) string {
+	sender := "unknown"
+	if addr, err := netmail.ParseAddress(from); err == nil && addr.Address != "" {
+		sender = addr.Address
+	} else if from != "" {
+		sender = from
+	}
+
+	return emlFileNameReplacer.Replace(sender) + "-" + ts.UTC().Format("20060102-150405") + ".eml"
+}
+
+// writeEMLFile writes the message into a new file in root and returns its path.
+// The files are created exclusively, so that messages with the same sender and timestamp
+// do not overwrite each other, even if they are saved by several streams concurrently.
+func writeEMLFile(root, name string, data []byte) (string, error) {
+	target := path.Join(root, name)
+
+	for n := 0; ; n++ {
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, defaults.FilePermission)
+		if os.IsExist(err) {
+			target = path.Join(root, strings.TrimSuffix(name, ".eml")+"-"+strconv.Itoa(n)+".eml")
+
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		_, err = f.Write(data)
+		if errClose := f.Close(); err == nil {
+			err = errClose
+		}
+
+		if err != nil {
+			// remove the partial file
+			_ = os.Remove(target)
+
+			return "", err
+		}
+
+		return target, nil
+	}
+}
+
+// toEML restores the wire format of a message: lines are terminated with CRLF
+// and the dot stuffing applied by SMTP and POP3 is removed.
+func toEML(buf []byte) []byte {
+	var (
+		out   bytes.Buffer
+		lines = strings.Split(strings.ReplaceAll(string(buf), "\r\n", "\n"), "\n")
+	)
+
+	// drop the trailing empty element produced by a final newline
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "..") {
+			line = line[1:]
+		}
+
+		out.WriteString(line)
+		out.WriteString("\r\n")
+	}
+
+	return out.Bytes()
+}
@@ -0,0 +1,147 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package mail
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestToEML(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"lf", "Subject: test\n\nhello\n", "Subject: test\r\n\r\nhello\r\n"},
+		{"crlf", "Subject: test\r\n\r\nhello\r\n", "Subject: test\r\n\r\nhello\r\n"},
+		{"no final newline", "Subject: test\n\nhello", "Subject: test\r\n\r\nhello\r\n"},
+		{"dot stuffing", "Subject: test\n\n..hidden\n.\n", "Subject: test\r\n\r\n.hidden\r\n.\r\n"},
+	}
+
+	for _, test := range tests {
+		if out := string(toEML([]byte(test.in))); out != test.expected {
+			t.Fatalf("%s: expected %q, got %q", test.name, test.expected, out)
+		}
+	}
+}
+
+func TestEMLFileName(t *testing.T) {
+	ts := time.Date(2020, 5, 17, 12, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		from     string
+		expected string
+	}{
+		{"Alice <alice@example.com>", "alice@example.com-20200517-123045.eml"},
+		{"bob@example.com", "bob@example.com-20200517-123045.eml"},
+		{"not an address: a/b", "not_an_address__a_b-20200517-123045.eml"},
+		{"", "unknown-20200517-123045.eml"},
+	}
+
+	for _, test := range tests {
+		if name := emlFileName(test.from, ts); name != test.expected {
+			t.Fatal("expected", test.expected, "for", test.from, "got", name)
+		}
+	}
+}
+
+func TestMarkMailSaved(t *testing.T) {
+	if !markMailSaved("<1@example.com>") {
+		t.Fatal("expected the first message to be saved")
+	}
+
+	if markMailSaved("<1@example.com>") {
+		t.Fatal("expected the duplicate message to be skipped")
+	}
+
+	// the oldest keys are forgotten once the limit has been reached
+	for i := 0; i < maxSavedMails; i++ {
+		markMailSaved("<" + strconv.Itoa(i) + "@example.net>")
+	}
+
+	if !markMailSaved("<1@example.com>") {
+		t.Fatal("expected the evicted message to be saved again")
+	}
+
+	savedMails.Lock()
+	defer savedMails.Unlock()
+
+	if len(savedMails.items) != maxSavedMails || len(savedMails.order) != maxSavedMails {
+		t.Fatal("expected", maxSavedMails, "remembered messages, got", len(savedMails.items), len(savedMails.order))
+	}
+}
+
+func TestWriteEMLFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcap-eml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	const num = 10
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		targets = make(map[string]string)
+	)
+
+	// messages with the same name, saved concurrently
+	for i := 0; i < num; i++ {
+		wg.Add(1)
+
+		go func(data string) {
+			defer wg.Done()
+
+			target, errWrite := writeEMLFile(dir, "alice@example.com-20200517-123045.eml", []byte(data))
+			if errWrite != nil {
+				t.Error(errWrite)
+
+				return
+			}
+
+			mu.Lock()
+			targets[target] = data
+			mu.Unlock()
+		}("message " + strconv.Itoa(i))
+	}
+
+	wg.Wait()
+
+	if len(targets) != num {
+		t.Fatal("expected", num, "distinct files, got", len(targets))
+	}
+
+	for target, data := range targets {
+		content, errRead := ioutil.ReadFile(target)
+		if errRead != nil {
+			t.Fatal(errRead)
+		}
+
+		if string(content) != data {
+			t.Fatal("file", filepath.Base(target), "was overwritten, got", string(content), "expected", data)
+		}
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "alice@example.com-20200517-123045-0.eml")); err != nil {
+		t.Fatal("expected a numbered file for the second message", err)
+	}
+}
@@ -88,6 +88,34 @@ func splitMailHeaderAndBody(buf []byte) (map[string]string, string) {
 	}
 }
 
+// mailTime returns the time asserted by the mail headers in nanoseconds, or zero if none could be parsed.
+// The Delivery-Date header is preferred, followed by the date of the Received header that was set by a mail server,
+// and the Date header that was set by the client of the sender.
+func mailTime(hdr map[string]string) int64 {
+	var received string
+	if i := strings.LastIndex(hdr["Received"], ";"); i != -1 {
+		received = hdr["Received"][i+1:]
+	}
+
+	for _, value := range []string{hdr["Delivery-Date"], received, hdr["Date"]} {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		ts, err := dateparse.ParseAny(value)
+		if err != nil {
+			mailLog.Debug("failed to parse date string from mail header", zap.String("value", value), zap.Error(err))
+
+			continue
+		}
+
+		return ts.UnixNano()
+	}
+
+	return 0
+}
+
 func newMailID() string {
 	s, err := cryptoutils.RandomString(20)
 	if err != nil {
@@ -102,17 +130,11 @@ func Parse(conv *core.ConversationInfo, buf []byte, from, to string, logger *zap
 	logger.Info(ansi.Yellow, "parseMail, from:", from, "to:", to, conv.Ident, "\n", string(buf), ansi.Reset)
 
 	var (
-		hdr, body = splitMailHeaderAndBody(buf)
-		ti        int64
+		hdr, body    = splitMailHeaderAndBody(buf)
+		captureTime  = conv.FirstClientPacket.UnixNano()
+		protocolTime = mailTime(hdr)
 	)
 
-	ts, err := dateparse.ParseAny(hdr["Delivery-Date"])
-	if err != nil {
-		mailLog.Error("failed to parse delivery date string from mail header", zap.Error(err))
-	} else {
-		ti = ts.UnixNano()
-	}
-
 	// if no values provided, look in the mail header
 	if from == "" || to == "" {
 		from = hdr["From"]
@@ -120,7 +142,7 @@ func Parse(conv *core.ConversationInfo, buf []byte, from, to string, logger *zap
 	}
 
 	mail := &types.Mail{
-		Timestamp:       ti,
+		Timestamp:       streamutils.SelectTimestamp(captureTime, protocolTime),
 		ReturnPath:      hdr["Return-Path"],
 		DeliveryDate:    hdr["Delivery-Date"],
 		From:            from,
@@ -138,6 +160,13 @@ func Parse(conv *core.ConversationInfo, buf []byte, from, to string, logger *zap
 		Body:            parseMailParts(conv, body, logger),
 		ID:              newMailID(),
 		Origin:          origin,
+		ProtocolTime:    protocolTime,
+		ClockSkew:       streamutils.ClockSkew(captureTime, protocolTime),
+	}
+
+	// store the raw message, so it can be opened with a mail client or other tools
+	if decoderconfig.Instance.FileStorage != "" {
+		saveEML(conv, buf, mail, origin)
 	}
 
 	for _, p := range mail.Body {
@@ -145,7 +174,7 @@ func Parse(conv *core.ConversationInfo, buf []byte, from, to string, logger *zap
 			mail.HasAttachments = true
 
 			if decoderconfig.Instance.FileStorage != "" {
-				err = streamutils.SaveFile(conv, origin, p.Filename, nil, []byte(p.Content), []string{p.Header["Content-Transfer-Encoding"]}, conv.ServerIP+":"+strconv.Itoa(int(conv.ServerPort)), "")
+				err := streamutils.SaveFile(conv, origin, p.Filename, nil, []byte(p.Content), []string{p.Header["Content-Transfer-Encoding"]}, conv.ServerIP+":"+strconv.Itoa(int(conv.ServerPort)), "")
 				if err != nil {
 					mailLog.Error("failed to save attachment", zap.Error(err), zap.String("origin", origin))
 				}
@@ -173,7 +202,7 @@ func Parse(conv *core.ConversationInfo, buf []byte, from, to string, logger *zap
 			software.WriteSoftware([]*software.AtomicSoftware{
 				{
 					Software: &types.Software{
-						Timestamp: ti,
+						Timestamp: mail.Timestamp,
 						Product:   userInfo.Product,
 						Vendor:    userInfo.Vendor,
 						Version:   userInfo.Version,
@@ -196,7 +225,7 @@ func Parse(conv *core.ConversationInfo, buf []byte, from, to string, logger *zap
 			software.WriteSoftware([]*software.AtomicSoftware{
 				{
 					Software: &types.Software{
-						Timestamp:  ti,
+						Timestamp:  mail.Timestamp,
 						Product:    strings.TrimSpace(matches[1]),
 						Vendor:     strings.Split(matches[1], " ")[0],
 						Version:    strings.TrimPrefix(matches[0], matches[1]),
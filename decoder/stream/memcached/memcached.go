@@ -0,0 +1,112 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package memcached
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var memcachedLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_Memcached,
+	Name:        "Memcached",
+	Description: "Memcached is a distributed in-memory key-value store, that is frequently exposed and abused for amplification attacks",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		memcachedLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"memcached",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		if isUDPFrame(client) {
+			client = client[udpFrameHeaderSize:]
+		}
+
+		return isBinaryRequest(client) || isTextRequest(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return memcachedLog.Sync()
+	},
+	Factory: &memcachedReader{},
+	Typ:     core.All,
+}
+
+const (
+	// every memcached UDP datagram starts with a frame header:
+	// request id (2 bytes), sequence number (2 bytes), total number of datagrams (2 bytes), reserved (2 bytes).
+	udpFrameHeaderSize = 8
+
+	// magic bytes of the binary protocol
+	magicRequest  = 0x80
+	magicResponse = 0x81
+
+	binaryHeaderSize = 24
+)
+
+// isUDPFrame checks whether the data starts with a valid frame header for the first datagram of a request.
+func isUDPFrame(data []byte) bool {
+	if len(data) <= udpFrameHeaderSize {
+		return false
+	}
+
+	var (
+		seq      = binary.BigEndian.Uint16(data[2:4])
+		total    = binary.BigEndian.Uint16(data[4:6])
+		reserved = binary.BigEndian.Uint16(data[6:8])
+	)
+
+	return seq < total && reserved == 0
+}
+
+// isBinaryRequest checks for the magic byte and a valid opcode of a binary protocol request.
+func isBinaryRequest(data []byte) bool {
+	if len(data) < binaryHeaderSize || data[0] != magicRequest {
+		return false
+	}
+
+	_, ok := binaryOpcodes[data[1]]
+
+	return ok
+}
+
+// isTextRequest checks whether the data starts with a complete command line of the text protocol.
+func isTextRequest(data []byte) bool {
+	i := bytes.Index(data, crlf)
+	if i <= 0 {
+		return false
+	}
+
+	cmd := data[:i]
+	if j := bytes.IndexByte(cmd, ' '); j > 0 {
+		cmd = cmd[:j]
+	}
+
+	_, ok := textCommands[string(cmd)]
+
+	return ok
+}
@@ -0,0 +1,420 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package memcached
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dreadl0ck/gopacket/layers"
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+const (
+	// limits the amount of data that is buffered for parsing in each direction
+	maxBufferSize = 1024 * 1024
+
+	// limits the number of distinct keys collected for a single conversation
+	maxKeys = 100
+
+	// UDP responses that are larger than the request by at least this factor are flagged as potential amplification
+	amplificationThreshold = 10
+)
+
+var crlf = []byte("\r\n")
+
+// commands of the text protocol, including the meta commands.
+var textCommands = map[string]struct{}{
+	"get":            {},
+	"gets":           {},
+	"gat":            {},
+	"gats":           {},
+	"set":            {},
+	"add":            {},
+	"replace":        {},
+	"append":         {},
+	"prepend":        {},
+	"cas":            {},
+	"delete":         {},
+	"incr":           {},
+	"decr":           {},
+	"touch":          {},
+	"stats":          {},
+	"version":        {},
+	"verbosity":      {},
+	"flush_all":      {},
+	"quit":           {},
+	"shutdown":       {},
+	"cache_memlimit": {},
+	"lru_crawler":    {},
+	"slabs":          {},
+	"watch":          {},
+	"mg":             {},
+	"ms":             {},
+	"md":             {},
+	"ma":             {},
+	"mn":             {},
+	"me":             {},
+}
+
+// opcodes of the binary protocol.
+var binaryOpcodes = map[byte]string{
+	0x00: "get",
+	0x01: "set",
+	0x02: "add",
+	0x03: "replace",
+	0x04: "delete",
+	0x05: "incr",
+	0x06: "decr",
+	0x07: "quit",
+	0x08: "flush_all",
+	0x09: "getq",
+	0x0a: "noop",
+	0x0b: "version",
+	0x0c: "getk",
+	0x0d: "getkq",
+	0x0e: "append",
+	0x0f: "prepend",
+	0x10: "stats",
+	0x11: "setq",
+	0x12: "addq",
+	0x13: "replaceq",
+	0x14: "deleteq",
+	0x15: "incrq",
+	0x16: "decrq",
+	0x17: "quitq",
+	0x18: "flushq",
+	0x19: "appendq",
+	0x1a: "prependq",
+	0x1b: "verbosity",
+	0x1c: "touch",
+	0x1d: "gat",
+	0x1e: "gatq",
+	0x20: "sasl_list_mechs",
+	0x21: "sasl_auth",
+	0x22: "sasl_step",
+}
+
+const (
+	opcodeVersion = 0x0b
+	opcodeStat    = 0x10
+)
+
+type memcachedReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new memcached reader.
+func (h *memcachedReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &memcachedReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the memcached conversation and writes an audit record
+// with the issued commands, the accessed keys and whether server statistics were exposed.
+func (h *memcachedReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil || len(h.conversation.Data) == 0 {
+		return
+	}
+
+	var (
+		clientBuf   bytes.Buffer
+		serverBuf   bytes.Buffer
+		bytesClient int
+		bytesServer int
+		udp         = h.conversation.Data[0].Transport().EndpointType() == layers.EndpointUDPPort
+	)
+
+	for _, d := range h.conversation.Data {
+		raw := d.Raw()
+
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			bytesClient += len(raw)
+		} else {
+			bytesServer += len(raw)
+		}
+
+		// strip the frame header from each datagram
+		if udp {
+			if len(raw) < udpFrameHeaderSize {
+				continue
+			}
+
+			raw = raw[udpFrameHeaderSize:]
+		}
+
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			if clientBuf.Len() < maxBufferSize {
+				clientBuf.Write(raw)
+			}
+		} else if serverBuf.Len() < maxBufferSize {
+			serverBuf.Write(raw)
+		}
+	}
+
+	m := &types.Memcached{
+		Timestamp:   h.conversation.FirstClientPacket.UnixNano(),
+		Flow:        h.conversation.Ident,
+		SrcIP:       h.conversation.ClientIP,
+		SrcPort:     h.conversation.ClientPort,
+		DstIP:       h.conversation.ServerIP,
+		DstPort:     h.conversation.ServerPort,
+		Transport:   "TCP",
+		BytesClient: int32(bytesClient),
+		BytesServer: int32(bytesServer),
+	}
+
+	if udp {
+		m.Transport = "UDP"
+	}
+
+	if isBinaryRequest(clientBuf.Bytes()) {
+		m.Binary = true
+		parseBinary(clientBuf.Bytes(), m)
+		parseBinary(serverBuf.Bytes(), m)
+	} else {
+		parseTextRequests(clientBuf.Bytes(), m)
+		parseTextResponses(serverBuf.Bytes(), m)
+	}
+
+	if len(m.Commands) == 0 {
+		return
+	}
+
+	if bytesClient > 0 {
+		m.AmplificationFactor = float64(bytesServer) / float64(bytesClient)
+	}
+
+	// memcached over UDP is a popular vector for reflection attacks, since tiny requests can trigger huge responses
+	m.PotentialAmplification = udp && m.AmplificationFactor >= amplificationThreshold
+
+	if m.StatsExposed || m.PotentialAmplification {
+		memcachedLog.Info("exposed memcached server",
+			zap.String("ident", h.conversation.Ident),
+			zap.String("transport", m.Transport),
+			zap.Bool("statsExposed", m.StatsExposed),
+			zap.Float64("amplificationFactor", m.AmplificationFactor),
+		)
+	}
+
+	writeMemcached(m)
+}
+
+// parseTextRequests collects the commands and keys from the client side of a text protocol conversation.
+func parseTextRequests(data []byte, m *types.Memcached) {
+	for len(data) > 0 {
+		i := bytes.Index(data, crlf)
+		if i < 0 {
+			return
+		}
+
+		fields := strings.Fields(string(data[:i]))
+		data = data[i+len(crlf):]
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		cmd := fields[0]
+		if _, ok := textCommands[cmd]; !ok {
+			continue
+		}
+
+		m.Commands = appendUnique(m.Commands, cmd, len(textCommands))
+
+		switch cmd {
+		case "get", "gets":
+			addKeys(m, fields[1:]...)
+		case "gat", "gats":
+			if len(fields) > 2 {
+				addKeys(m, fields[2:]...)
+			}
+		case "set", "add", "replace", "append", "prepend", "cas":
+			// <command> <key> <flags> <exptime> <bytes> [noreply]
+			if len(fields) > 4 {
+				addKeys(m, fields[1])
+				data = skipDataBlock(data, fields[4])
+			}
+		case "ms":
+			// ms <key> <datalen> <flags>*
+			if len(fields) > 2 {
+				addKeys(m, fields[1])
+				data = skipDataBlock(data, fields[2])
+			}
+		case "delete", "incr", "decr", "touch", "mg", "md", "ma":
+			if len(fields) > 1 {
+				addKeys(m, fields[1])
+			}
+		}
+	}
+}
+
+// parseTextResponses checks the server side of a text protocol conversation for disclosed statistics and the version.
+func parseTextResponses(data []byte, m *types.Memcached) {
+	for len(data) > 0 {
+		i := bytes.Index(data, crlf)
+		if i < 0 {
+			return
+		}
+
+		fields := strings.Fields(string(data[:i]))
+		data = data[i+len(crlf):]
+
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "VALUE":
+			// VALUE <key> <flags> <bytes> [<cas unique>]
+			if len(fields) > 3 {
+				data = skipDataBlock(data, fields[3])
+			}
+		case "VA":
+			// VA <size> <flags>*
+			data = skipDataBlock(data, fields[1])
+		case "VERSION":
+			m.Version = fields[1]
+		case "STAT":
+			m.StatsExposed = true
+
+			if fields[1] == "version" && len(fields) > 2 {
+				m.Version = fields[2]
+			}
+		}
+	}
+}
+
+// parseBinary collects the commands and keys from binary protocol requests,
+// and checks responses for disclosed statistics and the version.
+func parseBinary(data []byte, m *types.Memcached) {
+	for len(data) >= binaryHeaderSize {
+		var (
+			magic     = data[0]
+			opcode    = data[1]
+			keyLen    = int(binary.BigEndian.Uint16(data[2:4]))
+			extLen    = int(data[4])
+			status    = binary.BigEndian.Uint16(data[6:8])
+			bodyLen   = int(binary.BigEndian.Uint32(data[8:12]))
+			keyStart  = binaryHeaderSize + extLen
+			valueStop = binaryHeaderSize + bodyLen
+		)
+
+		if (magic != magicRequest && magic != magicResponse) || bodyLen < extLen+keyLen {
+			return
+		}
+
+		// the last packet might be truncated
+		if valueStop > len(data) {
+			valueStop = len(data)
+		}
+
+		var key, value string
+
+		if keyStart+keyLen <= valueStop {
+			key = string(data[keyStart : keyStart+keyLen])
+			value = string(data[keyStart+keyLen : valueStop])
+		}
+
+		if magic == magicRequest {
+			if name, ok := binaryOpcodes[opcode]; ok {
+				m.Commands = appendUnique(m.Commands, name, len(binaryOpcodes))
+			}
+
+			if key != "" && opcode != opcodeStat {
+				addKeys(m, key)
+			}
+		} else if status == 0 {
+			switch opcode {
+			case opcodeVersion:
+				m.Version = value
+			case opcodeStat:
+				// the stats response is terminated by a packet with an empty key
+				if key != "" {
+					m.StatsExposed = true
+				}
+
+				if key == "version" {
+					m.Version = value
+				}
+			}
+		}
+
+		if binaryHeaderSize+bodyLen >= len(data) {
+			return
+		}
+
+		data = data[binaryHeaderSize+bodyLen:]
+	}
+}
+
+// skipDataBlock skips a data block with the given size and the trailing CRLF.
+func skipDataBlock(data []byte, size string) []byte {
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 {
+		return data
+	}
+
+	if n+len(crlf) >= len(data) {
+		return nil
+	}
+
+	return data[n+len(crlf):]
+}
+
+// addKeys adds keys to the audit record, until the maximum number of keys is reached.
+func addKeys(m *types.Memcached, keys ...string) {
+	for _, k := range keys {
+		m.Keys = appendUnique(m.Keys, k, maxKeys)
+	}
+}
+
+// appendUnique appends the value if it is not yet present and the list has less than max elements.
+func appendUnique(list []string, val string, max int) []string {
+	if len(list) >= max {
+		return list
+	}
+
+	for _, v := range list {
+		if v == val {
+			return list
+		}
+	}
+
+	return append(list, val)
+}
+
+// writeMemcached writes the audit record and updates the metrics if enabled.
+func writeMemcached(m *types.Memcached) {
+	if decoderconfig.Instance.ExportMetrics {
+		m.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(m)
+	if err != nil {
+		memcachedLog.Error("failed to write memcached audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package memcached
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestParseText(t *testing.T) {
+	var (
+		m      = new(types.Memcached)
+		client = []byte("set user:1 0 900 9\r\nset 1 2 3\r\nget user:1 user:2\r\ndelete session\r\nstats\r\n")
+		server = []byte("STORED\r\nVALUE user:1 0 5\r\nSTAT x\r\nEND\r\nDELETED\r\nSTAT pid 1\r\nSTAT version 1.6.9\r\nEND\r\n")
+	)
+
+	if !isTextRequest(client) {
+		t.Fatal("expected text request")
+	}
+
+	parseTextRequests(client, m)
+	parseTextResponses(server, m)
+
+	if !reflect.DeepEqual(m.Commands, []string{"set", "get", "delete", "stats"}) {
+		t.Fatal("unexpected commands", m.Commands)
+	}
+
+	if !reflect.DeepEqual(m.Keys, []string{"user:1", "user:2", "session"}) {
+		t.Fatal("unexpected keys", m.Keys)
+	}
+
+	if !m.StatsExposed || m.Version != "1.6.9" {
+		t.Fatal("expected exposed stats and version, got", m.StatsExposed, m.Version)
+	}
+}
+
+func binaryPacket(magic, opcode byte, key, value string) []byte {
+	p := make([]byte, binaryHeaderSize, binaryHeaderSize+len(key)+len(value))
+	p[0] = magic
+	p[1] = opcode
+	binary.BigEndian.PutUint16(p[2:4], uint16(len(key)))
+	binary.BigEndian.PutUint32(p[8:12], uint32(len(key)+len(value)))
+
+	return append(append(p, key...), value...)
+}
+
+func TestParseBinary(t *testing.T) {
+	var (
+		m      = new(types.Memcached)
+		client []byte
+		server []byte
+	)
+
+	client = append(client, binaryPacket(magicRequest, 0x00, "secret", "")...)
+	client = append(client, binaryPacket(magicRequest, opcodeStat, "", "")...)
+	server = append(server, binaryPacket(magicResponse, 0x00, "", "value")...)
+	server = append(server, binaryPacket(magicResponse, opcodeStat, "version", "1.4.15")...)
+	server = append(server, binaryPacket(magicResponse, opcodeStat, "", "")...)
+
+	if !isBinaryRequest(client) {
+		t.Fatal("expected binary request")
+	}
+
+	parseBinary(client, m)
+	parseBinary(server, m)
+
+	if !reflect.DeepEqual(m.Commands, []string{"get", "stats"}) {
+		t.Fatal("unexpected commands", m.Commands)
+	}
+
+	if !reflect.DeepEqual(m.Keys, []string{"secret"}) {
+		t.Fatal("unexpected keys", m.Keys)
+	}
+
+	if !m.StatsExposed || m.Version != "1.4.15" {
+		t.Fatal("expected exposed stats and version, got", m.StatsExposed, m.Version)
+	}
+}
+
+func TestCanDecodeUDP(t *testing.T) {
+	request := append([]byte{0, 1, 0, 0, 0, 1, 0, 0}, "stats\r\n"...)
+
+	if !Decoder.CanDecode(request, nil) {
+		t.Fatal("expected UDP request to be detected")
+	}
+
+	if Decoder.CanDecode([]byte("GET / HTTP/1.1\r\n"), nil) {
+		t.Fatal("unexpected match for HTTP request")
+	}
+}
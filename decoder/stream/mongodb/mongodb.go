@@ -0,0 +1,79 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package mongodb
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var mongoLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_MongoDB,
+	Name:        "MongoDB",
+	Description: "The MongoDB wire protocol is used by clients to issue commands and queries to a MongoDB server",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		mongoLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"mongodb",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isRequest(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return mongoLog.Sync()
+	},
+	Factory: &mongoReader{},
+	Typ:     core.TCP,
+}
+
+const (
+	// length of the message header: message length, request id, response to and the opcode.
+	headerSize = 16
+
+	// maximum message size accepted by the server.
+	maxMessageSize = 48000000
+)
+
+// opcodes of the client messages.
+const (
+	opQuery      = 2004
+	opCompressed = 2012
+	opMsg        = 2013
+)
+
+// names of the opcodes that are recorded.
+var opCodeNames = map[int32]string{
+	opQuery: "OP_QUERY",
+	opMsg:   "OP_MSG",
+}
+
+// OP_MSG flag bits and section kinds.
+const (
+	flagChecksumPresent = 1 << 0
+
+	sectionBody             = 0
+	sectionDocumentSequence = 1
+)
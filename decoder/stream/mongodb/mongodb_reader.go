@@ -0,0 +1,487 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package mongodb
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// documents with more top level keys are truncated.
+const maxKeys = 100
+
+var errInvalidDocument = errors.New("invalid BSON document")
+
+// isRequest checks if the data starts with the header of a client message.
+func isRequest(data []byte) bool {
+	if len(data) < headerSize {
+		return false
+	}
+
+	var (
+		length     = int32(binary.LittleEndian.Uint32(data))
+		responseTo = binary.LittleEndian.Uint32(data[8:])
+		opCode     = int32(binary.LittleEndian.Uint32(data[12:]))
+	)
+
+	if length < headerSize || length > maxMessageSize || responseTo != 0 {
+		return false
+	}
+
+	return opCode == opMsg || opCode == opQuery || opCode == opCompressed
+}
+
+// message is a MongoDB wire protocol message.
+type message struct {
+	requestID int32
+	opCode    int32
+	body      []byte
+	timestamp int64
+}
+
+// parseMessages splits one direction of the conversation into messages, based on the length prefix of the header.
+// The timestamp of a message is taken from the fragment it starts in.
+func parseMessages(data []byte, fragments streamutils.Fragments) (messages []*message) {
+	var offset int
+
+	for offset+headerSize <= len(data) {
+		length := int(int32(binary.LittleEndian.Uint32(data[offset:])))
+
+		if length < headerSize || length > maxMessageSize {
+			mongoLog.Debug("invalid message length", zap.Int("offset", offset), zap.Int("length", length))
+
+			break
+		}
+
+		end := offset + length
+		if end > len(data) {
+			mongoLog.Debug("incomplete message", zap.Int("offset", offset), zap.Int("length", length))
+
+			break
+		}
+
+		m := &message{
+			requestID: int32(binary.LittleEndian.Uint32(data[offset+4:])),
+			opCode:    int32(binary.LittleEndian.Uint32(data[offset+12:])),
+			body:      data[offset+headerSize : end],
+			timestamp: fragments.Timestamp(offset),
+		}
+
+		messages = append(messages, m)
+		offset = end
+	}
+
+	return messages
+}
+
+// parseMsg parses an OP_MSG message.
+// The operation is taken from the body section, documents of the document sequence sections are only counted.
+func parseMsg(m *message) *types.MongoDB {
+	if len(m.body) < 4 {
+		return nil
+	}
+
+	var (
+		flags = binary.LittleEndian.Uint32(m.body)
+		data  = m.body[4:]
+		r     = newRecord(m)
+	)
+
+	if flags&flagChecksumPresent != 0 && len(data) >= 4 {
+		data = data[:len(data)-4]
+	}
+
+	for len(data) > 0 {
+		kind := data[0]
+		data = data[1:]
+
+		switch kind {
+		case sectionBody:
+			elements, n, err := parseDocument(data)
+			if err != nil {
+				mongoLog.Debug("failed to parse OP_MSG body", zap.Error(err))
+
+				return nil
+			}
+
+			setCommand(r, elements)
+			data = data[n:]
+		case sectionDocumentSequence:
+			if len(data) < 4 {
+				return r
+			}
+
+			size := int(int32(binary.LittleEndian.Uint32(data)))
+			if size < 4 || size > len(data) {
+				mongoLog.Debug("invalid document sequence size", zap.Int("size", size))
+
+				return r
+			}
+
+			// skip the identifier of the sequence
+			_, n, _ := streamutils.CString(data[4:size])
+			seq := data[4+n : size]
+
+			for len(seq) > 0 {
+				_, l, err := parseDocument(seq)
+				if err != nil {
+					break
+				}
+
+				r.NumDocuments++
+				seq = seq[l:]
+			}
+
+			data = data[size:]
+		default:
+			mongoLog.Debug("unknown OP_MSG section kind", zap.Uint8("kind", kind))
+
+			return r
+		}
+	}
+
+	return r
+}
+
+// parseQuery parses a legacy OP_QUERY message, which is still used for the initial handshake of many drivers.
+// Queries on the $cmd collection are commands, all other queries search the given collection.
+func parseQuery(m *message) *types.MongoDB {
+	if len(m.body) < 4 {
+		return nil
+	}
+
+	name, n, ok := streamutils.CString(m.body[4:])
+	if !ok {
+		return nil
+	}
+
+	// skip the flags, the collection name, the number of documents to skip and to return
+	rest := m.body[4+n:]
+	if len(rest) < 8 {
+		return nil
+	}
+
+	elements, _, err := parseDocument(rest[8:])
+	if err != nil {
+		mongoLog.Debug("failed to parse OP_QUERY document", zap.Error(err))
+
+		return nil
+	}
+
+	r := newRecord(m)
+
+	db, coll := name, ""
+	if i := strings.IndexByte(name, '.'); i != -1 {
+		db, coll = name[:i], name[i+1:]
+	}
+
+	if coll == "$cmd" {
+		setCommand(r, elements)
+	} else {
+		r.Operation = "query"
+		r.Collection = coll
+
+		// the filter can be wrapped together with query modifiers
+		if len(elements) > 0 && (elements[0].name == "$query" || elements[0].name == "query") && elements[0].typ == bsonDocument {
+			if inner, _, errInner := parseDocument(elements[0].value); errInner == nil {
+				elements = inner
+			}
+		}
+
+		r.Keys = keys(elements)
+	}
+
+	if r.Database == "" {
+		r.Database = db
+	}
+
+	return r
+}
+
+func newRecord(m *message) *types.MongoDB {
+	return &types.MongoDB{
+		Timestamp: m.timestamp,
+		OpCode:    opCodeNames[m.opCode],
+		RequestID: m.requestID,
+	}
+}
+
+// setCommand sets the operation, the collection and the database of a command document.
+// The name of a command is the first key, its value is the collection for most commands operating on one.
+func setCommand(r *types.MongoDB, elements []element) {
+	r.Keys = keys(elements)
+
+	if len(elements) == 0 {
+		return
+	}
+
+	r.Operation = elements[0].name
+
+	if s, ok := elements[0].string(); ok {
+		r.Collection = s
+	}
+
+	for _, e := range elements[1:] {
+		switch e.name {
+		case "$db":
+			r.Database, _ = e.string()
+		case "collection":
+			// e.g. getMore, where the first value is the cursor id
+			if r.Collection == "" {
+				r.Collection, _ = e.string()
+			}
+		}
+	}
+}
+
+// keys returns the names of the elements, without their values.
+func keys(elements []element) []string {
+	if len(elements) > maxKeys {
+		elements = elements[:maxKeys]
+	}
+
+	names := make([]string, len(elements))
+	for i, e := range elements {
+		names[i] = e.name
+	}
+
+	return names
+}
+
+// BSON element types.
+const (
+	bsonDouble     = 0x01
+	bsonString     = 0x02
+	bsonDocument   = 0x03
+	bsonArray      = 0x04
+	bsonBinary     = 0x05
+	bsonUndefined  = 0x06
+	bsonObjectID   = 0x07
+	bsonBool       = 0x08
+	bsonDateTime   = 0x09
+	bsonNull       = 0x0a
+	bsonRegex      = 0x0b
+	bsonDBPointer  = 0x0c
+	bsonJavaScript = 0x0d
+	bsonSymbol     = 0x0e
+	bsonCodeScope  = 0x0f
+	bsonInt32      = 0x10
+	bsonTimestamp  = 0x11
+	bsonInt64      = 0x12
+	bsonDecimal128 = 0x13
+	bsonMinKey     = 0xff
+	bsonMaxKey     = 0x7f
+)
+
+// element is a top level element of a BSON document.
+type element struct {
+	name  string
+	typ   byte
+	value []byte
+}
+
+// string returns the value of a string element.
+func (e element) string() (string, bool) {
+	if e.typ != bsonString || len(e.value) < 5 {
+		return "", false
+	}
+
+	return string(e.value[4 : len(e.value)-1]), true
+}
+
+// parseDocument returns the top level elements of the BSON document at the start of data and its size.
+// Nested documents are not parsed.
+func parseDocument(data []byte) (elements []element, size int, err error) {
+	if len(data) < 5 {
+		return nil, 0, errInvalidDocument
+	}
+
+	size = int(int32(binary.LittleEndian.Uint32(data)))
+	if size < 5 || size > len(data) || data[size-1] != 0 {
+		return nil, 0, errInvalidDocument
+	}
+
+	doc := data[4 : size-1]
+
+	for len(doc) > 0 {
+		typ := doc[0]
+
+		name, n, ok := streamutils.CString(doc[1:])
+		if !ok {
+			return nil, 0, errInvalidDocument
+		}
+
+		doc = doc[1+n:]
+
+		l, ok := valueSize(typ, doc)
+		if !ok || l > len(doc) {
+			return nil, 0, errInvalidDocument
+		}
+
+		elements = append(elements, element{
+			name:  name,
+			typ:   typ,
+			value: doc[:l],
+		})
+
+		doc = doc[l:]
+	}
+
+	return elements, size, nil
+}
+
+// valueSize returns the size of the value of the given type at the start of data.
+func valueSize(typ byte, data []byte) (int, bool) {
+	length := func(extra int) (int, bool) {
+		if len(data) < 4 {
+			return 0, false
+		}
+
+		l := int(int32(binary.LittleEndian.Uint32(data)))
+		if l < 0 {
+			return 0, false
+		}
+
+		return l + extra, true
+	}
+
+	switch typ {
+	case bsonUndefined, bsonNull, bsonMinKey, bsonMaxKey:
+		return 0, true
+	case bsonBool:
+		return 1, true
+	case bsonInt32:
+		return 4, true
+	case bsonDouble, bsonDateTime, bsonTimestamp, bsonInt64:
+		return 8, true
+	case bsonObjectID:
+		return 12, true
+	case bsonDecimal128:
+		return 16, true
+	case bsonString, bsonJavaScript, bsonSymbol:
+		return length(4)
+	case bsonDBPointer:
+		return length(4 + 12)
+	case bsonDocument, bsonArray, bsonCodeScope:
+		return length(0)
+	case bsonBinary:
+		return length(4 + 1)
+	case bsonRegex:
+		_, pattern, ok := streamutils.CString(data)
+		if !ok {
+			return 0, false
+		}
+
+		_, options, ok := streamutils.CString(data[pattern:])
+		if !ok {
+			return 0, false
+		}
+
+		return pattern + options, true
+	default:
+		return 0, false
+	}
+}
+
+type mongoReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new MongoDB reader.
+func (h *mongoReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &mongoReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the messages of the client and writes an audit record for each operation.
+func (h *mongoReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	for _, r := range decode(h.conversation.Data) {
+		r.Flow = h.conversation.Ident
+		r.SrcIP = h.conversation.ClientIP
+		r.SrcPort = h.conversation.ClientPort
+		r.DstIP = h.conversation.ServerIP
+		r.DstPort = h.conversation.ServerPort
+
+		writeMongoDB(r)
+	}
+}
+
+// decode processes the messages of the client in order, the replies of the server are ignored.
+func decode(data core.DataFragments) (records []*types.MongoDB) {
+	var (
+		client    []byte
+		fragments streamutils.Fragments
+	)
+
+	for _, d := range data {
+		if d.Direction() != reassembly.TCPDirClientToServer {
+			continue
+		}
+
+		fragments = append(fragments, streamutils.Fragment{
+			Offset:    len(client),
+			Timestamp: d.CaptureInfo().Timestamp.UnixNano(),
+		})
+		client = append(client, d.Raw()...)
+	}
+
+	for _, m := range parseMessages(client, fragments) {
+		var r *types.MongoDB
+
+		switch m.opCode {
+		case opMsg:
+			r = parseMsg(m)
+		case opQuery:
+			r = parseQuery(m)
+		case opCompressed:
+			mongoLog.Debug("skipping compressed message", zap.Int32("requestID", m.requestID))
+		}
+
+		if r != nil {
+			records = append(records, r)
+		}
+	}
+
+	return records
+}
+
+// writeMongoDB writes the audit record and updates the metrics if enabled.
+func writeMongoDB(r *types.MongoDB) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		mongoLog.Error("failed to write MongoDB audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,192 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package mongodb
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+func le32(i int) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(i))
+
+	return b
+}
+
+// doc encodes the given elements as a BSON document.
+func doc(elements ...[]byte) []byte {
+	var body []byte
+	for _, e := range elements {
+		body = append(body, e...)
+	}
+
+	return append(append(le32(len(body)+5), body...), 0)
+}
+
+func str(name, value string) []byte {
+	e := append([]byte{bsonString}, name...)
+	e = append(e, 0)
+	e = append(e, le32(len(value)+1)...)
+
+	return append(append(e, value...), 0)
+}
+
+func int32Elem(name string, value int) []byte {
+	e := append([]byte{bsonInt32}, name...)
+
+	return append(append(e, 0), le32(value)...)
+}
+
+func sub(name string, typ byte, d []byte) []byte {
+	e := append([]byte{typ}, name...)
+
+	return append(append(e, 0), d...)
+}
+
+// msg encodes a wire protocol message with the given opcode.
+func msg(requestID, opCode int, body []byte) []byte {
+	m := le32(headerSize + len(body))
+	m = append(m, le32(requestID)...)
+	m = append(m, le32(0)...)
+	m = append(m, le32(opCode)...)
+
+	return append(m, body...)
+}
+
+func opMsgBody(flags int, sections ...[]byte) []byte {
+	body := le32(flags)
+	for _, s := range sections {
+		body = append(body, s...)
+	}
+
+	return body
+}
+
+func documentSequence(identifier string, docs ...[]byte) []byte {
+	var data []byte
+	for _, d := range docs {
+		data = append(data, d...)
+	}
+
+	return append(append([]byte{sectionDocumentSequence}, le32(4+len(identifier)+1+len(data))...), append(append([]byte(identifier), 0), data...)...)
+}
+
+func TestDecode(t *testing.T) {
+	var (
+		hello = append(le32(0), "admin.$cmd\x00"...)
+		find  = msg(2, opMsg, opMsgBody(0, append([]byte{sectionBody}, doc(
+			str("find", "users"),
+			sub("filter", bsonDocument, doc(str("email", "alice@example.com"))),
+			str("$db", "shop"),
+		)...)))
+		insert = msg(3, opMsg, opMsgBody(flagChecksumPresent,
+			append([]byte{sectionBody}, doc(str("insert", "orders"), str("$db", "shop"))...),
+			documentSequence("documents", doc(int32Elem("total", 42)), doc(int32Elem("total", 7))),
+			le32(0xdeadbeef),
+		))
+		query = append(append(le32(0), "shop.users\x00"...), append(le32(0), le32(1)...)...)
+		data  core.DataFragments
+	)
+
+	hello = append(hello, append(le32(0), le32(-1)...)...)
+	hello = append(hello, doc(int32Elem("isMaster", 1), sub("client", bsonDocument, doc(str("application", "app"))))...)
+	query = append(query, doc(sub("$query", bsonDocument, doc(str("name", "bob"), int32Elem("age", 30))), sub("$orderby", bsonDocument, doc(int32Elem("age", 1))))...)
+
+	if !isRequest(msg(1, opQuery, hello)) || isRequest([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n")) {
+		t.Fatal("unexpected request detection result")
+	}
+
+	var (
+		client = reassembly.TCPDirClientToServer
+		server = reassembly.TCPDirServerToClient
+	)
+
+	for _, f := range []struct {
+		dir  reassembly.TCPFlowDirection
+		data []byte
+	}{
+		{client, msg(1, opQuery, hello)},
+		{server, msg(10, 1, []byte("reply"))},
+		// the length prefix is split over two segments
+		{client, find[:2]},
+		{client, find[2:]},
+		{client, insert},
+		{client, msg(4, opQuery, query)},
+		{client, msg(5, opCompressed, []byte{1, 2, 3, 4})},
+		// incomplete message at the end of the stream
+		{client, msg(6, opMsg, opMsgBody(0))[:10]},
+	} {
+		data = append(data, &core.StreamData{RawData: f.data, Dir: f.dir})
+	}
+
+	records := decode(data)
+
+	type operation struct {
+		opCode, database, collection, operation string
+		keys                                    []string
+		numDocuments                            int32
+	}
+
+	var got []operation
+	for _, r := range records {
+		got = append(got, operation{r.OpCode, r.Database, r.Collection, r.Operation, r.Keys, r.NumDocuments})
+	}
+
+	expected := []operation{
+		{"OP_QUERY", "admin", "", "isMaster", []string{"isMaster", "client"}, 0},
+		{"OP_MSG", "shop", "users", "find", []string{"find", "filter", "$db"}, 0},
+		{"OP_MSG", "shop", "orders", "insert", []string{"insert", "$db"}, 2},
+		{"OP_QUERY", "shop", "users", "query", []string{"name", "age"}, 0},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	if records[1].RequestID != 2 {
+		t.Fatal("unexpected request id", records[1].RequestID)
+	}
+}
+
+func TestParseDocument(t *testing.T) {
+	d := doc(
+		str("a", "b"),
+		sub("arr", bsonArray, doc(int32Elem("0", 1))),
+		append([]byte{bsonRegex}, "re\x00^a\x00i\x00"...),
+		append([]byte{bsonBinary}, append([]byte("bin\x00"), append(le32(2), 0, 1, 2)...)...),
+		append([]byte{bsonNull}, "nil\x00"...),
+	)
+
+	elements, size, err := parseDocument(append(d, "trailing"...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size != len(d) {
+		t.Fatal("expected size", len(d), "got", size)
+	}
+
+	if k := keys(elements); !reflect.DeepEqual(k, []string{"a", "arr", "re", "bin", "nil"}) {
+		t.Fatal("unexpected keys", k)
+	}
+
+	if _, _, err = parseDocument(d[:len(d)-1]); err == nil {
+		t.Fatal("expected an error for a truncated document")
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package mysql
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var mysqlLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_MySQLQuery,
+	Name:        "MySQL",
+	Description: "The MySQL client server protocol is used by MySQL and MariaDB clients to authenticate and issue SQL statements",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		mysqlLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"mysql",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isGreeting(server)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return mysqlLog.Sync()
+	},
+	Factory: &mysqlReader{},
+	Typ:     core.TCP,
+}
+
+const (
+	// length of the packet header: a three byte payload length and the sequence number.
+	headerSize = 4
+
+	// payloads of this size are continued in the next packet.
+	maxPayloadSize = 0xffffff
+
+	// protocol version of the initial handshake packet since MySQL 3.21.
+	protocolVersion = 10
+
+	// statements exceeding this size, e.g. inserts of large blobs, are truncated.
+	maxQuerySize = 64 * 1024
+)
+
+// client capability flags.
+const (
+	clientConnectWithDB              = 0x00000008
+	clientCompress                   = 0x00000020
+	clientProtocol41                 = 0x00000200
+	clientSSL                        = 0x00000800
+	clientSecureConnection           = 0x00008000
+	clientPluginAuthLenencClientData = 0x00200000
+	clientQueryAttributes            = 0x08000000
+)
+
+// commands of the client.
+const (
+	comQuit        = 0x01
+	comInitDB      = 0x02
+	comQuery       = 0x03
+	comChangeUser  = 0x11
+	comStmtPrepare = 0x16
+)
+
+// names of the commands that are recorded.
+var commandNames = map[byte]string{
+	comQuery:       "COM_QUERY",
+	comStmtPrepare: "COM_STMT_PREPARE",
+}
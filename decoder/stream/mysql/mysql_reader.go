@@ -0,0 +1,431 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package mysql
+
+import (
+	"encoding/binary"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// isGreeting checks if the data starts with the initial handshake packet of the server.
+func isGreeting(data []byte) bool {
+	if len(data) < headerSize+2 || data[3] != 0 || data[4] != protocolVersion {
+		return false
+	}
+
+	// the server version starts with the major version number
+	return data[5] >= '0' && data[5] <= '9'
+}
+
+// packet is a MySQL packet, the payloads of packets that have been split because of their size are joined.
+type packet struct {
+	seq       byte
+	payload   []byte
+	timestamp int64
+}
+
+// parsePackets parses the packets of one direction of the conversation.
+// The timestamp of a packet is taken from the fragment it starts in.
+func parsePackets(data []byte, fragments streamutils.Fragments) (packets []*packet) {
+	var (
+		offset    int
+		continued *packet
+	)
+
+	for offset+headerSize <= len(data) {
+		size := int(data[offset]) | int(data[offset+1])<<8 | int(data[offset+2])<<16
+		end := offset + headerSize + size
+
+		if end > len(data) {
+			mysqlLog.Debug("incomplete packet", zap.Int("offset", offset), zap.Int("size", size))
+
+			break
+		}
+
+		payload := data[offset+headerSize : end]
+
+		if continued != nil {
+			continued.payload = append(continued.payload, payload...)
+		} else {
+			p := &packet{
+				seq:       data[offset+3],
+				payload:   payload,
+				timestamp: fragments.Timestamp(offset),
+			}
+
+			packets = append(packets, p)
+			continued = p
+		}
+
+		// a payload of the maximum size is continued in the next packet
+		if size != maxPayloadSize {
+			continued = nil
+		} else if len(continued.payload) == size {
+			// copy the first part, so appending the next parts does not modify the conversation data
+			continued.payload = append([]byte(nil), payload...)
+		}
+
+		offset = end
+	}
+
+	return packets
+}
+
+// session tracks the state of the connection.
+type session struct {
+	serverVersion string
+	user          string
+	database      string
+	capabilities  uint32
+}
+
+// parseGreeting parses the server version from the initial handshake packet.
+func (s *session) parseGreeting(p *packet) {
+	if p.seq != 0 || len(p.payload) < 2 || p.payload[0] != protocolVersion {
+		return
+	}
+
+	s.serverVersion, _, _ = streamutils.CString(p.payload[1:])
+}
+
+// parseHandshakeResponse parses the user name and the default database from the handshake response of the client.
+// It returns false if the client requested TLS or compression, since the following packets can not be parsed.
+func (s *session) parseHandshakeResponse(p *packet) bool {
+	data := p.payload
+	if len(data) < 2 {
+		return true
+	}
+
+	// clients using the protocol before MySQL 4.1 send a shorter handshake response
+	if binary.LittleEndian.Uint16(data)&clientProtocol41 == 0 {
+		if len(data) > 5 {
+			s.user, _, _ = streamutils.CString(data[5:])
+		}
+
+		return true
+	}
+
+	if len(data) < 32 {
+		return true
+	}
+
+	s.capabilities = binary.LittleEndian.Uint32(data)
+
+	// a handshake response without user name requests to continue with TLS
+	if len(data) == 32 && s.capabilities&clientSSL != 0 {
+		return false
+	}
+
+	user, n, _ := streamutils.CString(data[32:])
+	s.user = user
+	rest := data[32+n:]
+
+	// skip the authentication response
+	switch {
+	case s.capabilities&clientPluginAuthLenencClientData != 0:
+		size, l := lengthEncodedInt(rest)
+		if l == 0 || uint64(len(rest)-l) < size {
+			return true
+		}
+
+		rest = rest[uint64(l)+size:]
+	case s.capabilities&clientSecureConnection != 0:
+		if len(rest) == 0 || len(rest) < 1+int(rest[0]) {
+			return true
+		}
+
+		rest = rest[1+int(rest[0]):]
+	default:
+		_, n, _ = streamutils.CString(rest)
+		rest = rest[n:]
+	}
+
+	if s.capabilities&clientConnectWithDB != 0 {
+		s.database, _, _ = streamutils.CString(rest)
+	}
+
+	return s.capabilities&clientCompress == 0
+}
+
+// command processes a command of the client and returns the records for the statements it contains.
+// It returns false if the connection ends with the command.
+func (s *session) command(p *packet) ([]*types.MySQLQuery, bool) {
+	if len(p.payload) == 0 {
+		return nil, true
+	}
+
+	var (
+		cmd  = p.payload[0]
+		data = p.payload[1:]
+	)
+
+	switch cmd {
+	case comQuit:
+		return nil, false
+	case comInitDB:
+		s.database = string(data)
+	case comChangeUser:
+		s.user, _, _ = streamutils.CString(data)
+	case comQuery:
+		query, ok := s.queryText(data)
+		if !ok {
+			mysqlLog.Debug("skipping query with attributes")
+
+			return nil, true
+		}
+
+		var records []*types.MySQLQuery
+		for _, stmt := range splitStatements(query) {
+			records = append(records, s.newRecord(p, cmd, stmt))
+		}
+
+		return records, true
+	case comStmtPrepare:
+		return []*types.MySQLQuery{s.newRecord(p, cmd, strings.TrimSpace(string(data)))}, true
+	}
+
+	return nil, true
+}
+
+// queryText returns the statement of a COM_QUERY.
+// Queries that carry attributes are only supported without parameters.
+func (s *session) queryText(data []byte) (string, bool) {
+	if s.capabilities&clientQueryAttributes != 0 {
+		numParams, n := lengthEncodedInt(data)
+		if n == 0 || numParams != 0 {
+			return "", false
+		}
+
+		// the parameter set count is always one
+		_, m := lengthEncodedInt(data[n:])
+		if m == 0 {
+			return "", false
+		}
+
+		data = data[n+m:]
+	}
+
+	return string(data), true
+}
+
+func (s *session) newRecord(p *packet, cmd byte, query string) *types.MySQLQuery {
+	if len(query) > maxQuerySize {
+		query = query[:maxQuerySize]
+	}
+
+	return &types.MySQLQuery{
+		Timestamp:     p.timestamp,
+		ServerVersion: s.serverVersion,
+		User:          s.user,
+		Database:      s.database,
+		Command:       commandNames[cmd],
+		Query:         query,
+	}
+}
+
+// splitStatements splits the text of a query into the statements separated by semicolons.
+// Semicolons in quoted strings, identifiers and comments are ignored.
+func splitStatements(query string) (statements []string) {
+	var (
+		start int
+		quote byte
+	)
+
+	add := func(end int) {
+		if stmt := strings.TrimSpace(query[start:end]); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		if quote != 0 {
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+
+			continue
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '#' || strings.HasPrefix(query[i:], "-- "):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				i = len(query)
+			} else {
+				i += end
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			add(i)
+			start = i + 1
+		}
+	}
+
+	add(len(query))
+
+	return statements
+}
+
+// lengthEncodedInt decodes a length encoded integer and returns its value and size.
+// A size of zero indicates an invalid or incomplete integer.
+func lengthEncodedInt(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+
+	switch data[0] {
+	case 0xfc:
+		if len(data) < 3 {
+			return 0, 0
+		}
+
+		return uint64(binary.LittleEndian.Uint16(data[1:])), 3
+	case 0xfd:
+		if len(data) < 4 {
+			return 0, 0
+		}
+
+		return uint64(data[1]) | uint64(data[2])<<8 | uint64(data[3])<<16, 4
+	case 0xfe:
+		if len(data) < 9 {
+			return 0, 0
+		}
+
+		return binary.LittleEndian.Uint64(data[1:]), 9
+	case 0xfb, 0xff:
+		return 0, 0
+	default:
+		return uint64(data[0]), 1
+	}
+}
+
+type mysqlReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new MySQL reader.
+func (h *mysqlReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &mysqlReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the handshake and the commands of the conversation and writes an audit record for each SQL statement.
+func (h *mysqlReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	for _, r := range decode(h.conversation.Data) {
+		r.Flow = h.conversation.Ident
+		r.SrcIP = h.conversation.ClientIP
+		r.SrcPort = h.conversation.ClientPort
+		r.DstIP = h.conversation.ServerIP
+		r.DstPort = h.conversation.ServerPort
+
+		writeMySQLQuery(r)
+	}
+}
+
+// decode processes the packets of the client in order.
+// Packets with a sequence number of zero start a new command,
+// the remaining packets belong to the authentication or to the upload of local files and are skipped.
+func decode(data core.DataFragments) (records []*types.MySQLQuery) {
+	var (
+		client, server  []byte
+		clientFragments streamutils.Fragments
+		s               = new(session)
+	)
+
+	for _, d := range data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			clientFragments = append(clientFragments, streamutils.Fragment{
+				Offset:    len(client),
+				Timestamp: d.CaptureInfo().Timestamp.UnixNano(),
+			})
+			client = append(client, d.Raw()...)
+		} else {
+			server = append(server, d.Raw()...)
+		}
+	}
+
+	// only the greeting is needed from the server, avoid parsing result sets
+	if isGreeting(server) {
+		size := int(server[0]) | int(server[1])<<8 | int(server[2])<<16
+		if headerSize+size <= len(server) {
+			s.parseGreeting(&packet{payload: server[headerSize : headerSize+size]})
+		}
+	}
+
+	handshake := true
+
+	for _, p := range parsePackets(client, clientFragments) {
+		switch {
+		case p.seq == 0:
+			// the handshake response is skipped if the conversation starts after the login
+			handshake = false
+
+			r, ok := s.command(p)
+			records = append(records, r...)
+
+			if !ok {
+				return records
+			}
+		case p.seq == 1 && handshake:
+			handshake = false
+
+			if !s.parseHandshakeResponse(p) {
+				return records
+			}
+		}
+	}
+
+	return records
+}
+
+// writeMySQLQuery writes the audit record and updates the metrics if enabled.
+func writeMySQLQuery(r *types.MySQLQuery) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		mysqlLog.Error("failed to write MySQLQuery audit record", zap.Error(err))
+	}
+}
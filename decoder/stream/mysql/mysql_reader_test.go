@@ -0,0 +1,111 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package mysql
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+// mysqlPacket prepends the packet header to the payload.
+func mysqlPacket(seq byte, payload []byte) []byte {
+	return append([]byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}, payload...)
+}
+
+func command(cmd byte, arg string) []byte {
+	return mysqlPacket(0, append([]byte{cmd}, arg...))
+}
+
+func handshakeResponse(user, database string) []byte {
+	payload := make([]byte, 32)
+	binary.LittleEndian.PutUint32(payload, clientProtocol41|clientSecureConnection|clientConnectWithDB)
+
+	payload = append(payload, user...)
+	payload = append(payload, 0, 3, 'a', 'b', 'c')
+	payload = append(payload, database...)
+
+	return mysqlPacket(1, append(payload, 0))
+}
+
+func TestDecode(t *testing.T) {
+	var (
+		greeting = mysqlPacket(0, []byte("\x0a8.0.32\x00\x01\x00\x00\x00"))
+		query    = command(comQuery, "SELECT 1; SELECT 'a;b' -- comment; \n ;/* ; */ UPDATE t SET x = \"\\\";\"")
+		large    = "INSERT INTO blobs VALUES ('" + strings.Repeat("x", maxPayloadSize) + "')"
+		split    = append([]byte{comQuery}, large...)
+		data     core.DataFragments
+	)
+
+	var (
+		client = reassembly.TCPDirClientToServer
+		server = reassembly.TCPDirServerToClient
+	)
+
+	for _, f := range []struct {
+		dir  reassembly.TCPFlowDirection
+		data []byte
+	}{
+		{server, greeting},
+		{client, handshakeResponse("app", "shop")},
+		{server, mysqlPacket(2, []byte{0, 0, 0, 2, 0, 0, 0})},
+		// the query is split over two segments
+		{client, query[:10]},
+		{client, query[10:]},
+		{server, mysqlPacket(1, []byte{0, 0, 0, 2, 0, 0, 0})},
+		{client, append(command(comInitDB, "logs"), command(comStmtPrepare, "INSERT INTO t VALUES (?)")...)},
+		// the query exceeds the maximum payload size and is split into two packets
+		{client, append(mysqlPacket(0, split[:maxPayloadSize]), mysqlPacket(1, split[maxPayloadSize:])...)},
+		{client, command(comQuit, "")},
+		{client, command(comQuery, "SELECT 'after quit'")},
+	} {
+		data = append(data, &core.StreamData{RawData: f.data, Dir: f.dir})
+	}
+
+	records := decode(data)
+
+	type statement struct {
+		user, database, command, query string
+	}
+
+	var got []statement
+	for _, r := range records {
+		if r.ServerVersion != "8.0.32" {
+			t.Errorf("unexpected server version: %q", r.ServerVersion)
+		}
+
+		got = append(got, statement{r.User, r.Database, r.Command, r.Query})
+	}
+
+	expected := []statement{
+		{"app", "shop", "COM_QUERY", "SELECT 1"},
+		{"app", "shop", "COM_QUERY", "SELECT 'a;b' -- comment;"},
+		{"app", "shop", "COM_QUERY", "/* ; */ UPDATE t SET x = \"\\\";\""},
+		{"app", "logs", "COM_STMT_PREPARE", "INSERT INTO t VALUES (?)"},
+		{"app", "logs", "COM_QUERY", large[:maxQuerySize]},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatal("expected", len(expected), "records, got", len(got))
+	}
+
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("record %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,291 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+// Package ntlm parses the NTLM authentication messages that are carried by HTTP, SMB and LDAP,
+// and extracts the challenge response of the client for offline cracking.
+package ntlm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync/atomic"
+	"unicode/utf16"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.AbstractDecoder{
+	Type:        types.Type_NC_NTLM,
+	Name:        "NTLM",
+	Description: "A NTLM challenge response authentication, extracted from HTTP, SMB and LDAP",
+}
+
+// Signature starts each NTLM message.
+var Signature = []byte("NTLMSSP\x00")
+
+// message types.
+const (
+	TypeNegotiate    = 1
+	TypeChallenge    = 2
+	TypeAuthenticate = 3
+)
+
+// NegotiateUnicode is the flag that indicates UTF-16 encoded strings.
+const NegotiateUnicode = 0x1
+
+// minimum sizes of the fixed part of the messages.
+const (
+	challengeSize    = 32
+	authenticateSize = 64
+)
+
+// size of the response to the challenge in NTLMv1, the NTLMv2 response is longer since it contains a blob from the client.
+const ntlmV1ResponseSize = 24
+
+// versions of the challenge response.
+const (
+	versionNTLMv1 = "NTLMv1"
+	versionNTLMv2 = "NTLMv2"
+)
+
+// Message is a parsed NTLM message, only the fields for its type are set.
+type Message struct {
+	Type  uint32
+	Flags uint32
+
+	// challenge
+	ServerChallenge []byte
+
+	// authenticate
+	Domain      string
+	User        string
+	Workstation string
+	LMResponse  []byte
+	NTResponse  []byte
+}
+
+// Parse searches data for a NTLM message and parses it.
+// The token wrapping the message, e.g. the GSS-API token in SMB and LDAP, is not parsed.
+// Nil is returned if there is no complete message.
+func Parse(data []byte) *Message {
+	i := bytes.Index(data, Signature)
+	if i == -1 {
+		return nil
+	}
+
+	msg := data[i:]
+	if len(msg) < 12 {
+		return nil
+	}
+
+	m := &Message{
+		Type: binary.LittleEndian.Uint32(msg[8:12]),
+	}
+
+	switch m.Type {
+	case TypeNegotiate:
+		if len(msg) >= 16 {
+			m.Flags = binary.LittleEndian.Uint32(msg[12:16])
+		}
+	case TypeChallenge:
+		if len(msg) < challengeSize {
+			return nil
+		}
+
+		m.Flags = binary.LittleEndian.Uint32(msg[20:24])
+		m.ServerChallenge = msg[24:32]
+	case TypeAuthenticate:
+		if len(msg) < authenticateSize {
+			return nil
+		}
+
+		m.Flags = binary.LittleEndian.Uint32(msg[60:64])
+
+		str := func(pos int) string {
+			b := field(msg, pos)
+			if m.Flags&NegotiateUnicode != 0 {
+				return decodeUTF16(b)
+			}
+
+			return string(b)
+		}
+
+		m.LMResponse = field(msg, 12)
+		m.NTResponse = field(msg, 20)
+		m.Domain = str(28)
+		m.User = str(36)
+		m.Workstation = str(44)
+	default:
+		return nil
+	}
+
+	return m
+}
+
+// ParseHTTP parses the NTLM message in the value of a HTTP Authorization or WWW-Authenticate header,
+// which is base64 encoded and prefixed with the NTLM or Negotiate scheme.
+// Nil is returned if the header does not contain a NTLM message.
+func ParseHTTP(value string) *Message {
+	parts := strings.Fields(value)
+	if len(parts) != 2 {
+		return nil
+	}
+
+	if !strings.EqualFold(parts[0], "NTLM") && !strings.EqualFold(parts[0], "Negotiate") {
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil
+	}
+
+	return Parse(data)
+}
+
+// field returns the data of the field described by the length, maximum length and offset at pos.
+func field(msg []byte, pos int) []byte {
+	var (
+		length = int(binary.LittleEndian.Uint16(msg[pos : pos+2]))
+		offset = int(binary.LittleEndian.Uint32(msg[pos+4 : pos+8]))
+	)
+
+	if length == 0 || offset+length > len(msg) {
+		return nil
+	}
+
+	return msg[offset : offset+length]
+}
+
+// decodeUTF16 decodes the little endian UTF-16 strings.
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+
+	return string(utf16.Decode(u))
+}
+
+// Version returns the version of the challenge response in an authenticate message,
+// or an empty string for anonymous authentications.
+func (m *Message) Version() string {
+	switch {
+	case len(m.NTResponse) > ntlmV1ResponseSize:
+		return versionNTLMv2
+	case len(m.NTResponse) == ntlmV1ResponseSize:
+		return versionNTLMv1
+	default:
+		return ""
+	}
+}
+
+// Hashcat formats the challenge response of an authenticate message for hashcat,
+// as mode 5600 for NTLMv2 and as mode 5500 for NTLMv1.
+// An empty string is returned if the challenge of the server is unknown or the authentication is anonymous.
+func (m *Message) Hashcat(challenge []byte) string {
+	if len(challenge) == 0 || m.User == "" {
+		return ""
+	}
+
+	switch m.Version() {
+	case versionNTLMv2:
+		// the first 16 bytes are the NTProofStr, followed by the blob of the client
+		return strings.Join([]string{
+			m.User,
+			"",
+			m.Domain,
+			hex.EncodeToString(challenge),
+			hex.EncodeToString(m.NTResponse[:16]),
+			hex.EncodeToString(m.NTResponse[16:]),
+		}, ":")
+	case versionNTLMv1:
+		return strings.Join([]string{
+			m.User,
+			"",
+			m.Domain,
+			hex.EncodeToString(m.LMResponse),
+			hex.EncodeToString(m.NTResponse),
+			hex.EncodeToString(challenge),
+		}, ":")
+	default:
+		return ""
+	}
+}
+
+// Session pairs the challenges of the server with the authenticate messages of the client on a connection.
+type Session struct {
+	Protocol  string
+	challenge []byte
+}
+
+// Add processes the next message of the authentication, in the order they have been exchanged.
+// The challenge of the server is remembered, and an audit record is returned for an authenticate message.
+// Addresses and the flow have to be set by the caller.
+func (s *Session) Add(m *Message, timestamp int64) *types.NTLM {
+	if m == nil {
+		return nil
+	}
+
+	switch m.Type {
+	case TypeChallenge:
+		s.challenge = m.ServerChallenge
+	case TypeAuthenticate:
+		r := &types.NTLM{
+			Timestamp:   timestamp,
+			Protocol:    s.Protocol,
+			Domain:      m.Domain,
+			User:        m.User,
+			Workstation: m.Workstation,
+			Version:     m.Version(),
+			Hash:        m.Hashcat(s.challenge),
+		}
+
+		if len(s.challenge) > 0 {
+			r.ServerChallenge = hex.EncodeToString(s.challenge)
+		}
+
+		// a challenge is only used for a single authentication
+		s.challenge = nil
+
+		return r
+	}
+
+	return nil
+}
+
+// WriteNTLM writes the authentication, if the decoder has been initialized.
+func WriteNTLM(r *types.NTLM) {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		log.Fatal("failed to write proto: ", err)
+	}
+}
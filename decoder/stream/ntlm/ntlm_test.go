@@ -0,0 +1,104 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ntlm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func utf16LE(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(u))
+
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+
+	return b
+}
+
+func challengeMessage(challenge []byte) []byte {
+	m := make([]byte, 48)
+	copy(m, Signature)
+	binary.LittleEndian.PutUint32(m[8:], TypeChallenge)
+	binary.LittleEndian.PutUint32(m[20:], NegotiateUnicode)
+	copy(m[24:], challenge)
+
+	return m
+}
+
+func authenticateMessage(lm, nt []byte, domain, user, workstation string) []byte {
+	m := make([]byte, authenticateSize)
+	copy(m, Signature)
+	binary.LittleEndian.PutUint32(m[8:], TypeAuthenticate)
+	binary.LittleEndian.PutUint32(m[60:], NegotiateUnicode)
+
+	for i, v := range [][]byte{lm, nt, utf16LE(domain), utf16LE(user), utf16LE(workstation)} {
+		binary.LittleEndian.PutUint16(m[12+8*i:], uint16(len(v)))
+		binary.LittleEndian.PutUint16(m[14+8*i:], uint16(len(v)))
+		binary.LittleEndian.PutUint32(m[16+8*i:], uint32(len(m)))
+		m = append(m, v...)
+	}
+
+	return m
+}
+
+func TestSession(t *testing.T) {
+	var (
+		challenge = []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+		proof     = bytes.Repeat([]byte{0xaa}, 16)
+		blob      = append([]byte{0x01, 0x01, 0x00, 0x00}, bytes.Repeat([]byte{0xbb}, 24)...)
+		s         = &Session{Protocol: "HTTP"}
+	)
+
+	// HTTP wraps the messages in base64
+	header := func(scheme string, m []byte) string {
+		return scheme + " " + base64.StdEncoding.EncodeToString(m)
+	}
+
+	if r := s.Add(ParseHTTP(header("Negotiate", challengeMessage(challenge))), 1); r != nil {
+		t.Fatal("unexpected record for challenge", r)
+	}
+
+	r := s.Add(ParseHTTP(header("NTLM", authenticateMessage(make([]byte, 24), append(proof, blob...), "CORP", "alice", "WS1"))), 2)
+	if r == nil {
+		t.Fatal("expected record")
+	}
+
+	expected := "alice::CORP:1122334455667788:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa:01010000" + strings.Repeat("bb", 24)
+	if r.Hash != expected {
+		t.Fatalf("expected hash %q, got %q", expected, r.Hash)
+	}
+
+	if r.Timestamp != 2 || r.Protocol != "HTTP" || r.Version != versionNTLMv2 || r.ServerChallenge != "1122334455667788" || r.Workstation != "WS1" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	// SMB uses the raw binary form, wrapped in a GSS-API token, the challenge has been consumed
+	raw := append([]byte{0xa1, 0x81, 0x80, 0x30}, authenticateMessage(make([]byte, 24), make([]byte, 24), "CORP", "bob", "WS2")...)
+
+	r = s.Add(Parse(raw), 3)
+	if r == nil || r.User != "bob" || r.Version != versionNTLMv1 || r.Hash != "" || r.ServerChallenge != "" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	if ParseHTTP("Basic dXNlcjpwYXNz") != nil || ParseHTTP("NTLM invalid!") != nil {
+		t.Fatal("expected no message")
+	}
+}
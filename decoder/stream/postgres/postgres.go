@@ -0,0 +1,91 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package postgres
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var postgresLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_PostgresQuery,
+	Name:        "PostgreSQL",
+	Description: "The PostgreSQL frontend backend protocol is used by clients to authenticate and issue SQL statements to a PostgreSQL server",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		postgresLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"postgres",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isStartup(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return postgresLog.Sync()
+	},
+	Factory: &postgresReader{},
+	Typ:     core.TCP,
+}
+
+const (
+	// length of the message header: the message type and the message length, which includes itself.
+	headerSize = 5
+
+	// startup messages have no message type, only the length and the protocol version or request code.
+	startupHeaderSize = 8
+
+	// protocol version 3.0 of the startup message.
+	protocolVersion = 3 << 16
+
+	// codes of the startup messages sent instead of a protocol version.
+	cancelRequestCode = 80877102
+	sslRequestCode    = 80877103
+	gssEncRequestCode = 80877104
+
+	// the server rejects startup packets larger than this.
+	maxStartupSize = 10000
+
+	// statements exceeding this size, e.g. inserts of large blobs, are truncated.
+	maxQuerySize = 64 * 1024
+)
+
+// message types of the client.
+const (
+	msgQuery     = 'Q'
+	msgParse     = 'P'
+	msgTerminate = 'X'
+)
+
+// message types of the server.
+const (
+	msgParameterStatus = 'S'
+	msgReadyForQuery   = 'Z'
+)
+
+// names of the message types that are recorded.
+var commandNames = map[byte]string{
+	msgQuery: "Query",
+	msgParse: "Parse",
+}
@@ -0,0 +1,295 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package postgres
+
+import (
+	"encoding/binary"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// isStartup checks if the data starts with a startup message, or with a request to encrypt the connection.
+func isStartup(data []byte) bool {
+	if len(data) < startupHeaderSize {
+		return false
+	}
+
+	size := binary.BigEndian.Uint32(data)
+
+	switch binary.BigEndian.Uint32(data[4:]) {
+	case sslRequestCode, gssEncRequestCode:
+		return size == startupHeaderSize
+	case cancelRequestCode:
+		return size == startupHeaderSize+8
+	case protocolVersion:
+		return size > startupHeaderSize && size <= maxStartupSize
+	}
+
+	return false
+}
+
+// message is a PostgreSQL message after the startup phase.
+type message struct {
+	typ       byte
+	payload   []byte
+	timestamp int64
+}
+
+// parseMessages parses the messages of one direction of the conversation, beginning at the offset.
+// The timestamp of a message is taken from the fragment it starts in.
+func parseMessages(data []byte, offset int, fragments streamutils.Fragments) (messages []*message) {
+	for offset+headerSize <= len(data) {
+		var (
+			typ  = data[offset]
+			size = int(binary.BigEndian.Uint32(data[offset+1:]))
+			end  = offset + 1 + size
+		)
+
+		// message types are ASCII letters, anything else means the data is not framed as expected
+		if size < 4 || !(typ >= 'A' && typ <= 'Z' || typ >= 'a' && typ <= 'z') {
+			postgresLog.Debug("invalid message", zap.Int("offset", offset), zap.Uint8("type", typ), zap.Int("size", size))
+
+			break
+		}
+
+		if end > len(data) {
+			postgresLog.Debug("incomplete message", zap.Int("offset", offset), zap.Int("size", size))
+
+			break
+		}
+
+		messages = append(messages, &message{
+			typ:       typ,
+			payload:   data[offset+headerSize : end],
+			timestamp: fragments.Timestamp(offset),
+		})
+
+		offset = end
+	}
+
+	return messages
+}
+
+// session tracks the state of the connection.
+type session struct {
+	serverVersion string
+	user          string
+	database      string
+	application   string
+}
+
+// parseStartup parses the user, database and application name from the parameters of the startup message.
+func (s *session) parseStartup(data []byte) {
+	for len(data) > 0 && data[0] != 0 {
+		name, n, _ := streamutils.CString(data)
+		value, m, _ := streamutils.CString(data[n:])
+		data = data[n+m:]
+
+		switch name {
+		case "user":
+			s.user = value
+		case "database":
+			s.database = value
+		case "application_name":
+			s.application = value
+		}
+	}
+
+	// the database defaults to the user name
+	if s.database == "" {
+		s.database = s.user
+	}
+}
+
+// parseServer parses the server version from the parameter status messages the server sends after the authentication.
+func (s *session) parseServer(messages []*message) {
+	for _, m := range messages {
+		switch m.typ {
+		case msgParameterStatus:
+			name, n, _ := streamutils.CString(m.payload)
+			if name == "server_version" {
+				s.serverVersion, _, _ = streamutils.CString(m.payload[n:])
+			}
+		case msgReadyForQuery:
+			// all parameters are reported before the server is ready for the first query
+			return
+		}
+	}
+}
+
+// command processes a message of the client and returns the record for the statement it contains.
+// It returns false if the connection ends with the message.
+func (s *session) command(m *message) (*types.PostgresQuery, bool) {
+	switch m.typ {
+	case msgTerminate:
+		return nil, false
+	case msgQuery:
+		query, _, _ := streamutils.CString(m.payload)
+
+		return s.newRecord(m, "", query), true
+	case msgParse:
+		name, n, _ := streamutils.CString(m.payload)
+		query, _, _ := streamutils.CString(m.payload[n:])
+
+		return s.newRecord(m, name, query), true
+	}
+
+	return nil, true
+}
+
+func (s *session) newRecord(m *message, statement, query string) *types.PostgresQuery {
+	query = strings.TrimSpace(query)
+	if len(query) > maxQuerySize {
+		query = query[:maxQuerySize]
+	}
+
+	return &types.PostgresQuery{
+		Timestamp:     m.timestamp,
+		ServerVersion: s.serverVersion,
+		User:          s.user,
+		Database:      s.database,
+		Application:   s.application,
+		Command:       commandNames[m.typ],
+		Statement:     statement,
+		Query:         query,
+	}
+}
+
+type postgresReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new PostgreSQL reader.
+func (h *postgresReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &postgresReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the startup and the messages of the client and writes an audit record for each SQL statement.
+func (h *postgresReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	for _, r := range decode(h.conversation.Data) {
+		r.Flow = h.conversation.Ident
+		r.SrcIP = h.conversation.ClientIP
+		r.SrcPort = h.conversation.ClientPort
+		r.DstIP = h.conversation.ServerIP
+		r.DstPort = h.conversation.ServerPort
+
+		writePostgresQuery(r)
+	}
+}
+
+// decode processes the startup phase and the messages of the client in order.
+// If the client requested encryption and the server accepted it,
+// a single record marking the connection as encrypted is returned.
+func decode(data core.DataFragments) (records []*types.PostgresQuery) {
+	var (
+		client, server  []byte
+		clientFragments streamutils.Fragments
+		s               = new(session)
+	)
+
+	for _, d := range data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			clientFragments = append(clientFragments, streamutils.Fragment{
+				Offset:    len(client),
+				Timestamp: d.CaptureInfo().Timestamp.UnixNano(),
+			})
+			client = append(client, d.Raw()...)
+		} else {
+			server = append(server, d.Raw()...)
+		}
+	}
+
+	var clientOffset, serverOffset int
+
+	// the startup phase is skipped if the conversation starts after it
+	for isStartup(client[clientOffset:]) {
+		size := int(binary.BigEndian.Uint32(client[clientOffset:]))
+		if clientOffset+size > len(client) {
+			postgresLog.Debug("incomplete startup message", zap.Int("size", size))
+
+			return nil
+		}
+
+		switch binary.BigEndian.Uint32(client[clientOffset+4:]) {
+		case sslRequestCode, gssEncRequestCode:
+			// the server answers with a single byte, N refuses the encryption.
+			// If the answer is missing, the client continues with a startup message after a refusal.
+			var accepted bool
+			if serverOffset < len(server) {
+				accepted = server[serverOffset] != 'N'
+				serverOffset++
+			} else {
+				accepted = !isStartup(client[clientOffset+size:])
+			}
+
+			if accepted {
+				return []*types.PostgresQuery{{
+					Timestamp: clientFragments.Timestamp(clientOffset),
+					Encrypted: true,
+				}}
+			}
+		case cancelRequestCode:
+			// cancel requests are sent on a separate connection, which is closed afterwards
+			return nil
+		case protocolVersion:
+			s.parseStartup(client[clientOffset+startupHeaderSize : clientOffset+size])
+		}
+
+		clientOffset += size
+	}
+
+	s.parseServer(parseMessages(server, serverOffset, nil))
+
+	for _, m := range parseMessages(client, clientOffset, clientFragments) {
+		r, ok := s.command(m)
+		if r != nil {
+			records = append(records, r)
+		}
+
+		if !ok {
+			break
+		}
+	}
+
+	return records
+}
+
+// writePostgresQuery writes the audit record and updates the metrics if enabled.
+func writePostgresQuery(r *types.PostgresQuery) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		postgresLog.Error("failed to write PostgresQuery audit record", zap.Error(err))
+	}
+}
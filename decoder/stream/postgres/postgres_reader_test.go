@@ -0,0 +1,188 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package postgres
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+var (
+	client = reassembly.TCPDirClientToServer
+	server = reassembly.TCPDirServerToClient
+)
+
+type segment struct {
+	dir  reassembly.TCPFlowDirection
+	data []byte
+}
+
+func fragments(segments ...segment) (data core.DataFragments) {
+	for _, s := range segments {
+		data = append(data, &core.StreamData{RawData: s.data, Dir: s.dir})
+	}
+
+	return data
+}
+
+// pgMessage prepends the message type and length to the payload.
+func pgMessage(typ byte, fields ...string) []byte {
+	var payload []byte
+	for _, f := range fields {
+		payload = append(payload, f...)
+		payload = append(payload, 0)
+	}
+
+	msg := []byte{typ, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(payload)+4))
+
+	return append(msg, payload...)
+}
+
+func startupMessage(code uint32, params ...string) []byte {
+	msg := make([]byte, startupHeaderSize)
+	binary.BigEndian.PutUint32(msg[4:], code)
+
+	for _, p := range params {
+		msg = append(msg, p...)
+		msg = append(msg, 0)
+	}
+
+	if len(params) > 0 {
+		msg = append(msg, 0)
+	}
+
+	binary.BigEndian.PutUint32(msg, uint32(len(msg)))
+
+	return msg
+}
+
+func TestDecode(t *testing.T) {
+	var (
+		query = pgMessage(msgQuery, "SELECT * FROM users; SELECT 1")
+		parse = pgMessage(msgParse, "stmt1", "INSERT INTO t VALUES ($1)", "")
+		large = "INSERT INTO blobs VALUES ('" + strings.Repeat("x", maxQuerySize) + "')"
+	)
+
+	// the parameter count of the parse message is appended after the query
+	parse = append(parse[:len(parse)-1], 0, 0)
+	binary.BigEndian.PutUint32(parse[1:], uint32(len(parse)-1))
+
+	records := decode(fragments(
+		segment{client, startupMessage(sslRequestCode)},
+		segment{server, []byte{'N'}},
+		segment{client, startupMessage(protocolVersion, "user", "app", "database", "shop", "application_name", "psql")},
+		segment{server, pgMessage('R', "\x00\x00\x00")},
+		segment{server, append(pgMessage(msgParameterStatus, "server_version", "13.4"), pgMessage(msgReadyForQuery, "I")...)},
+		// the query is split over two segments
+		segment{client, query[:10]},
+		segment{client, query[10:]},
+		segment{client, append(parse, pgMessage('B', "", "stmt1")...)},
+		segment{client, pgMessage(msgQuery, large)},
+		segment{client, pgMessage(msgTerminate)},
+		segment{client, pgMessage(msgQuery, "SELECT 'after terminate'")},
+	))
+
+	type statement struct {
+		command, name, query string
+	}
+
+	var got []statement
+	for _, r := range records {
+		if r.ServerVersion != "13.4" || r.User != "app" || r.Database != "shop" || r.Application != "psql" || r.Encrypted {
+			t.Errorf("unexpected session: %+v", r)
+		}
+
+		got = append(got, statement{r.Command, r.Statement, r.Query})
+	}
+
+	expected := []statement{
+		{"Query", "", "SELECT * FROM users; SELECT 1"},
+		{"Parse", "stmt1", "INSERT INTO t VALUES ($1)"},
+		{"Query", "", large[:maxQuerySize]},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatal("expected", len(expected), "records, got", len(got), got)
+	}
+
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("record %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestDecodeEncrypted(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		segments []segment
+	}{
+		{
+			name: "SSLRequest",
+			segments: []segment{
+				{client, startupMessage(sslRequestCode)},
+				{server, []byte{'S'}},
+				{client, []byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01}},
+			},
+		},
+		{
+			name: "GSSENCRequest",
+			segments: []segment{
+				{client, startupMessage(gssEncRequestCode)},
+				{server, []byte{'G'}},
+			},
+		},
+		{
+			name: "missing answer",
+			segments: []segment{
+				{client, startupMessage(sslRequestCode)},
+				{client, []byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01}},
+			},
+		},
+	} {
+		records := decode(fragments(c.segments...))
+		if len(records) != 1 || !records[0].Encrypted || records[0].Query != "" {
+			t.Errorf("%s: expected a single encrypted record, got %+v", c.name, records)
+		}
+	}
+}
+
+func TestDecodeWithoutStartup(t *testing.T) {
+	records := decode(fragments(
+		segment{client, pgMessage(msgQuery, "SELECT now()")},
+	))
+
+	if len(records) != 1 || records[0].Query != "SELECT now()" || records[0].User != "" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+}
+
+func TestIsStartup(t *testing.T) {
+	if !isStartup(startupMessage(protocolVersion, "user", "app")) {
+		t.Error("startup message not detected")
+	}
+
+	if !isStartup(startupMessage(sslRequestCode)) {
+		t.Error("SSLRequest not detected")
+	}
+
+	if isStartup(pgMessage(msgQuery, "SELECT 1")) || isStartup([]byte("GET / HTTP/1.1\r\n")) {
+		t.Error("unexpected startup message")
+	}
+}
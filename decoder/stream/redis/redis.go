@@ -0,0 +1,162 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package redis
+
+import (
+	"regexp"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var redisLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_Redis,
+	Name:        "Redis",
+	Description: "Redis is an in-memory key-value store, clients issue commands via the REdis Serialization Protocol (RESP)",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		redisLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"redis",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return reArrayCommand.Match(client) || reInlineCommand.Match(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return redisLog.Sync()
+	},
+	Factory: &redisReader{},
+	Typ:     core.TCP,
+}
+
+var (
+	// clients send commands as an array of bulk strings: *2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n
+	reArrayCommand = regexp.MustCompile(`^\*[1-9][0-9]*\r\n\$[0-9]+\r\n`)
+
+	// inline commands are typed by humans via telnet or netcat,
+	// only commands that are unlikely to start another text protocol are considered.
+	reInlineCommand = regexp.MustCompile(`^(?i)(PING|AUTH|INFO|CONFIG|KEYS|FLUSHALL|SLAVEOF|REPLICAOF|MODULE)( [^\r\n]*)?\r?\n`)
+)
+
+// RESP data type prefixes.
+const (
+	prefixSimpleString = '+'
+	prefixError        = '-'
+	prefixInteger      = ':'
+	prefixBulkString   = '$'
+	prefixArray        = '*'
+
+	// RESP3 types, used after the client switched the protocol with HELLO 3
+	prefixNull           = '_'
+	prefixDouble         = ','
+	prefixBoolean        = '#'
+	prefixBlobError      = '!'
+	prefixVerbatimString = '='
+	prefixBigNumber      = '('
+	prefixMap            = '%'
+	prefixSet            = '~'
+	prefixAttribute      = '|'
+	prefixPush           = '>'
+)
+
+// reply types stored in the audit records.
+const (
+	replySimpleString = "SimpleString"
+	replyError        = "Error"
+	replyInteger      = "Integer"
+	replyBulkString   = "BulkString"
+	replyArray        = "Array"
+	replyNull         = "Null"
+	replyDouble       = "Double"
+	replyBoolean      = "Boolean"
+	replyBigNumber    = "BigNumber"
+	replyMap          = "Map"
+	replySet          = "Set"
+	replyPush         = "Push"
+)
+
+// commands that influence how replies are assigned to the commands.
+const (
+	cmdMulti      = "MULTI"
+	cmdExec       = "EXEC"
+	cmdDiscard    = "DISCARD"
+	cmdSubscribe  = "SUBSCRIBE"
+	cmdPSubscribe = "PSUBSCRIBE"
+	cmdSSubscribe = "SSUBSCRIBE"
+	cmdMonitor    = "MONITOR"
+)
+
+// keylessCommands do not take a key as first argument.
+// AUTH is listed here, to make sure the password never ends up in the audit records.
+var keylessCommands = map[string]struct{}{
+	"ACL":          {},
+	"AUTH":         {},
+	"BGREWRITEAOF": {},
+	"BGSAVE":       {},
+	"CLIENT":       {},
+	"CLUSTER":      {},
+	"COMMAND":      {},
+	"CONFIG":       {},
+	"DBSIZE":       {},
+	"DEBUG":        {},
+	"DISCARD":      {},
+	"ECHO":         {},
+	"EVAL":         {},
+	"EVALSHA":      {},
+	"EXEC":         {},
+	"FLUSHALL":     {},
+	"FLUSHDB":      {},
+	"HELLO":        {},
+	"INFO":         {},
+	"KEYS":         {},
+	"LASTSAVE":     {},
+	"MIGRATE":      {},
+	"MODULE":       {},
+	"MONITOR":      {},
+	"MULTI":        {},
+	"PING":         {},
+	"PSUBSCRIBE":   {},
+	"PSYNC":        {},
+	"PUBLISH":      {},
+	"PUNSUBSCRIBE": {},
+	"QUIT":         {},
+	"REPLICAOF":    {},
+	"ROLE":         {},
+	"SAVE":         {},
+	"SCAN":         {},
+	"SCRIPT":       {},
+	"SELECT":       {},
+	"SHUTDOWN":     {},
+	"SLAVEOF":      {},
+	"SLOWLOG":      {},
+	"SSUBSCRIBE":   {},
+	"SUBSCRIBE":    {},
+	"SWAPDB":       {},
+	"SYNC":         {},
+	"TIME":         {},
+	"UNSUBSCRIBE":  {},
+	"UNWATCH":      {},
+	"WAIT":         {},
+}
@@ -0,0 +1,448 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package redis
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var (
+	errIncomplete = errors.New("incomplete message")
+	errInvalid    = errors.New("invalid message")
+)
+
+// replies can be nested, deeper structures are considered invalid.
+const maxNestingDepth = 32
+
+// command issued by the client.
+type command struct {
+	timestamp int64
+	name      string
+	firstArg  string
+	numArgs   int
+	inline    bool
+}
+
+// reply sent by the server.
+type reply struct {
+	typ string
+	err string
+
+	// elements of a top level aggregate, used to resolve the results of EXEC
+	elements []*reply
+}
+
+type redisReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new Redis reader.
+func (h *redisReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &redisReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the commands and replies of the conversation and writes an audit record for each command.
+// Pipelined commands are answered in order, so the replies are assigned to the commands in sequence.
+func (h *redisReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	var (
+		client, server []byte
+		fragments      streamutils.Fragments
+	)
+
+	for _, d := range h.conversation.Data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			fragments = append(fragments, streamutils.Fragment{
+				Offset:    len(client),
+				Timestamp: d.CaptureInfo().Timestamp.UnixNano(),
+			})
+			client = append(client, d.Raw()...)
+		} else {
+			server = append(server, d.Raw()...)
+		}
+	}
+
+	for _, r := range newRecords(parseCommands(client, fragments), parseReplies(server)) {
+		r.Flow = h.conversation.Ident
+		r.SrcIP = h.conversation.ClientIP
+		r.SrcPort = h.conversation.ClientPort
+		r.DstIP = h.conversation.ServerIP
+		r.DstPort = h.conversation.ServerPort
+
+		writeRedis(r)
+	}
+}
+
+// newRecords creates an audit record for each command and assigns the replies.
+func newRecords(commands []*command, replies []*reply) []*types.Redis {
+	var (
+		records = make([]*types.Redis, 0, len(commands))
+		queued  []*types.Redis
+		inMulti bool
+
+		// after subscribing to a channel or issuing MONITOR the server pushes messages,
+		// that can no longer be related to the commands.
+		streaming bool
+	)
+
+	for _, c := range commands {
+		r := &types.Redis{
+			Timestamp:   c.timestamp,
+			Command:     c.name,
+			NumArgs:     int32(c.numArgs),
+			Transaction: inMulti && c.name != cmdExec && c.name != cmdDiscard,
+			Inline:      c.inline,
+		}
+
+		if _, ok := keylessCommands[c.name]; !ok {
+			r.Key = c.firstArg
+		}
+
+		var rep *reply
+		if !streaming && len(replies) > 0 {
+			rep, replies = replies[0], replies[1:]
+			setReply(r, rep)
+		}
+
+		switch {
+		case c.name == cmdMulti:
+			inMulti, queued = true, nil
+		case c.name == cmdExec:
+			// the results of the queued commands are returned as elements of an array
+			if rep != nil && len(rep.elements) == len(queued) {
+				for i, q := range queued {
+					setReply(q, rep.elements[i])
+				}
+			}
+
+			inMulti, queued = false, nil
+		case c.name == cmdDiscard:
+			inMulti, queued = false, nil
+		case c.name == cmdSubscribe || c.name == cmdPSubscribe || c.name == cmdSSubscribe || c.name == cmdMonitor:
+			streaming = true
+		case r.Transaction && r.ReplyType != replyError:
+			queued = append(queued, r)
+		}
+
+		records = append(records, r)
+	}
+
+	return records
+}
+
+// setReply stores the type of the reply in the audit record.
+func setReply(r *types.Redis, rep *reply) {
+	r.ReplyType = rep.typ
+	r.Error = rep.err
+}
+
+// parseCommands parses all complete commands in data.
+// The timestamp of a command is taken from the fragment it starts in.
+func parseCommands(data []byte, fragments streamutils.Fragments) []*command {
+	var (
+		commands []*command
+		offset   int
+	)
+
+	for offset < len(data) {
+		c, n, err := parseCommand(data[offset:])
+		if err != nil {
+			if errors.Is(err, errInvalid) {
+				redisLog.Debug("failed to parse command", zap.Int("offset", offset))
+			}
+
+			break
+		}
+
+		if c != nil {
+			c.timestamp = fragments.Timestamp(offset)
+
+			commands = append(commands, c)
+		}
+
+		offset += n
+	}
+
+	return commands
+}
+
+// parseCommand parses a single command at the start of data and returns the number of consumed bytes.
+// A nil command is returned for empty lines and arrays.
+func parseCommand(data []byte) (*command, int, error) {
+	if data[0] != prefixArray {
+		return parseInlineCommand(data)
+	}
+
+	line, n, err := readLine(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := strconv.Atoi(string(line[1:]))
+	if err != nil || count < 0 {
+		return nil, 0, errInvalid
+	}
+
+	c := &command{}
+
+	for i := 0; i < count; i++ {
+		line, l, errLine := readLine(data[n:])
+		if errLine != nil {
+			return nil, 0, errLine
+		}
+
+		if len(line) == 0 || line[0] != prefixBulkString {
+			return nil, 0, errInvalid
+		}
+
+		size, errSize := strconv.Atoi(string(line[1:]))
+		if errSize != nil || size < 0 {
+			return nil, 0, errInvalid
+		}
+
+		n += l
+
+		// compare without adding to the size, a huge length would overflow
+		if size > len(data)-n-2 {
+			return nil, 0, errIncomplete
+		}
+
+		// only the command name and the first argument are kept
+		switch i {
+		case 0:
+			c.name = strings.ToUpper(string(data[n : n+size]))
+		case 1:
+			c.firstArg = string(data[n : n+size])
+		}
+
+		n += size + 2
+	}
+
+	if count == 0 {
+		return nil, n, nil
+	}
+
+	c.numArgs = count - 1
+
+	return c, n, nil
+}
+
+// parseInlineCommand parses a command that has been sent as a line of space separated arguments.
+func parseInlineCommand(data []byte) (*command, int, error) {
+	line, n, err := readLine(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args := strings.Fields(string(line))
+	if len(args) == 0 {
+		return nil, n, nil
+	}
+
+	c := &command{
+		name:    strings.ToUpper(args[0]),
+		numArgs: len(args) - 1,
+		inline:  true,
+	}
+
+	if len(args) > 1 {
+		c.firstArg = args[1]
+	}
+
+	return c, n, nil
+}
+
+// parseReplies parses all complete replies in data.
+// Out of band push messages of RESP3 are skipped, since they do not answer a command.
+func parseReplies(data []byte) []*reply {
+	var (
+		replies []*reply
+		offset  int
+	)
+
+	for offset < len(data) {
+		r, n, err := parseReply(data[offset:], 0)
+		if err != nil {
+			if errors.Is(err, errInvalid) {
+				redisLog.Debug("failed to parse reply", zap.Int("offset", offset))
+			}
+
+			break
+		}
+
+		if r.typ != replyPush {
+			replies = append(replies, r)
+		}
+
+		offset += n
+	}
+
+	return replies
+}
+
+// parseReply parses a single reply at the start of data and returns the number of consumed bytes.
+func parseReply(data []byte, depth int) (*reply, int, error) {
+	if depth > maxNestingDepth {
+		return nil, 0, errInvalid
+	}
+
+	line, n, err := readLine(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(line) == 0 {
+		return nil, 0, errInvalid
+	}
+
+	r := &reply{}
+
+	switch line[0] {
+	case prefixSimpleString:
+		r.typ = replySimpleString
+	case prefixError:
+		r.typ, r.err = replyError, string(line[1:])
+	case prefixInteger:
+		r.typ = replyInteger
+	case prefixNull:
+		r.typ = replyNull
+	case prefixDouble:
+		r.typ = replyDouble
+	case prefixBoolean:
+		r.typ = replyBoolean
+	case prefixBigNumber:
+		r.typ = replyBigNumber
+	case prefixBulkString, prefixVerbatimString, prefixBlobError:
+		size, errSize := strconv.Atoi(string(line[1:]))
+		if errSize != nil || size < -1 {
+			return nil, 0, errInvalid
+		}
+
+		// null bulk string of RESP2
+		if size == -1 {
+			r.typ = replyNull
+
+			break
+		}
+
+		// compare without adding to the size, a huge length would overflow
+		if size > len(data)-n-2 {
+			return nil, 0, errIncomplete
+		}
+
+		if line[0] == prefixBlobError {
+			r.typ, r.err = replyError, string(data[n:n+size])
+		} else {
+			r.typ = replyBulkString
+		}
+
+		n += size + 2
+	case prefixArray, prefixMap, prefixSet, prefixPush, prefixAttribute:
+		count, errCount := strconv.Atoi(string(line[1:]))
+		if errCount != nil || count < -1 {
+			return nil, 0, errInvalid
+		}
+
+		// null array of RESP2
+		if count == -1 {
+			r.typ = replyNull
+
+			break
+		}
+
+		// maps and attributes consist of key value pairs
+		if line[0] == prefixMap || line[0] == prefixAttribute {
+			count *= 2
+		}
+
+		for i := 0; i < count; i++ {
+			e, l, errElem := parseReply(data[n:], depth+1)
+			if errElem != nil {
+				return nil, 0, errElem
+			}
+
+			if depth == 0 {
+				r.elements = append(r.elements, e)
+			}
+
+			n += l
+		}
+
+		switch line[0] {
+		case prefixArray:
+			r.typ = replyArray
+		case prefixMap:
+			r.typ = replyMap
+		case prefixSet:
+			r.typ = replySet
+		case prefixPush:
+			r.typ = replyPush
+		case prefixAttribute:
+			// attributes carry auxiliary data and are followed by the actual reply
+			actual, l, errActual := parseReply(data[n:], depth)
+			if errActual != nil {
+				return nil, 0, errActual
+			}
+
+			return actual, n + l, nil
+		}
+	default:
+		return nil, 0, errInvalid
+	}
+
+	return r, n, nil
+}
+
+// readLine returns the line at the start of data without the line terminator,
+// and the number of bytes including the terminator.
+func readLine(data []byte) (line []byte, n int, err error) {
+	i := bytes.IndexByte(data, '\n')
+	if i == -1 {
+		return nil, 0, errIncomplete
+	}
+
+	return bytes.TrimRight(data[:i], "\r"), i + 1, nil
+}
+
+// writeRedis writes the audit record and updates the metrics if enabled.
+func writeRedis(r *types.Redis) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		redisLog.Error("failed to write Redis audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package redis
+
+import (
+	"testing"
+
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func decode(client, server string) []*types.Redis {
+	return newRecords(
+		parseCommands([]byte(client), streamutils.Fragments{{Offset: 0, Timestamp: 1}}),
+		parseReplies([]byte(server)),
+	)
+}
+
+func TestPipelinedCommands(t *testing.T) {
+	records := decode(
+		"*2\r\n$4\r\nAUTH\r\n$6\r\nsecret\r\n*3\r\n$3\r\nset\r\n$3\r\nfoo\r\n$3\r\nbar\r\n*2\r\n$3\r\nGET\r\n$7\r\nmissing\r\nPING\r\n*1\r\n$3\r\nDEL\r\n",
+		"+OK\r\n+OK\r\n$-1\r\n+PONG\r\n-ERR wrong number of arguments for 'del' command\r\n",
+	)
+
+	expected := []types.Redis{
+		{Timestamp: 1, Command: "AUTH", NumArgs: 1, ReplyType: replySimpleString},
+		{Timestamp: 1, Command: "SET", NumArgs: 2, Key: "foo", ReplyType: replySimpleString},
+		{Timestamp: 1, Command: "GET", NumArgs: 1, Key: "missing", ReplyType: replyNull},
+		{Timestamp: 1, Command: "PING", ReplyType: replySimpleString, Inline: true},
+		{Timestamp: 1, Command: "DEL", ReplyType: replyError, Error: "ERR wrong number of arguments for 'del' command"},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatal("expected", len(expected), "records, got", len(records))
+	}
+
+	for i, r := range records {
+		if *r != expected[i] {
+			t.Errorf("record %d: expected %+v, got %+v", i, expected[i], *r)
+		}
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	records := decode(
+		"MULTI\r\nINCR counter\r\nLPUSH list a\r\nEXEC\r\n*2\r\n$3\r\nGET\r\n$7\r\ncounter",
+		"+OK\r\n+QUEUED\r\n+QUEUED\r\n*2\r\n:1\r\n-WRONGTYPE Operation against a key holding the wrong kind of value\r\n$1\r\n1",
+	)
+
+	// the final command and reply are incomplete
+	if len(records) != 4 {
+		t.Fatal("expected 4 records, got", len(records))
+	}
+
+	if records[0].Transaction || records[3].Transaction || !records[1].Transaction || !records[2].Transaction {
+		t.Fatal("unexpected transaction flags")
+	}
+
+	if records[1].Key != "counter" || records[1].ReplyType != replyInteger {
+		t.Fatal("unexpected result for INCR", records[1])
+	}
+
+	if records[2].ReplyType != replyError || records[2].Error == "" {
+		t.Fatal("unexpected result for LPUSH", records[2])
+	}
+
+	if records[3].Command != cmdExec || records[3].ReplyType != replyArray {
+		t.Fatal("unexpected result for EXEC", records[3])
+	}
+}
+
+func TestHugeBulkLength(t *testing.T) {
+	// the lengths must not overflow when checking for the end of the data
+	for _, data := range []string{
+		"*1\r\n$9223372036854775807\r\nGET\r\n",
+		"*1\r\n$9223372036854775806\r\nGET\r\n",
+	} {
+		if _, _, err := parseCommand([]byte(data)); err != errIncomplete {
+			t.Fatal("expected errIncomplete for command, got", err)
+		}
+	}
+
+	for _, data := range []string{
+		"$9223372036854775807\r\nOK\r\n",
+		"!9223372036854775806\r\nERR\r\n",
+	} {
+		if _, _, err := parseReply([]byte(data), 0); err != errIncomplete {
+			t.Fatal("expected errIncomplete for reply, got", err)
+		}
+	}
+
+	if records := decode("*1\r\n$9223372036854775807\r\nGET\r\n", "$9223372036854775807\r\nOK\r\n"); len(records) != 0 {
+		t.Fatal("expected no records, got", len(records))
+	}
+}
+
+func TestCanDecode(t *testing.T) {
+	tests := []struct {
+		client string
+		ok     bool
+	}{
+		{"*1\r\n$4\r\nPING\r\n", true},
+		{"info server\r\n", true},
+		{"PING\n", true},
+		{"GET / HTTP/1.1\r\n", false},
+		{"*0\r\n", false},
+	}
+
+	for _, tt := range tests {
+		if ok := Decoder.CanDecode([]byte(tt.client), nil); ok != tt.ok {
+			t.Errorf("CanDecode(%q) = %v, want %v", tt.client, ok, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+// Package secrets implements a generic scanner for cleartext credentials, API keys and tokens in reassembled streams.
+package secrets
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/pkg/errors"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
+)
+
+// maxMatchesPerPattern limits the number of audit records per pattern and stream direction.
+const maxMatchesPerPattern = 10
+
+// errInvalidPattern occurs when a secret pattern can not be compiled.
+var errInvalidPattern = errors.New("invalid secret pattern")
+
+// enabled is set after the decoder has been initialized, if scanning has been enabled in the configuration.
+var enabled bool
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.AbstractDecoder{
+	Type:        types.Type_NC_SecretLeak,
+	Name:        "SecretLeak",
+	Description: "A SecretLeak is a credential, API key or token that has been transferred in cleartext",
+	PostInit: func(d *decoder.AbstractDecoder) error {
+		enabled = decoderconfig.Instance.ScanSecrets
+		if !enabled {
+			return nil
+		}
+
+		return initPatterns(decoderconfig.Instance.SecretPatterns)
+	},
+}
+
+// pattern describes a regular expression that matches a secret.
+// If the expression contains a capture group, only the first group is considered secret and redacted,
+// otherwise the entire match is redacted.
+type pattern struct {
+	Name  string `json:"name"`
+	Regex string `json:"regex"`
+
+	re *regexp.Regexp
+}
+
+func (p *pattern) compile() error {
+	if p.Name == "" {
+		return errors.Wrap(errInvalidPattern, "missing name")
+	}
+
+	re, err := regexp.Compile(p.Regex)
+	if err != nil {
+		return errors.Wrap(err, "invalid regex for "+p.Name)
+	}
+
+	p.re = re
+
+	return nil
+}
+
+// defaultPatterns are always loaded when scanning is enabled.
+var defaultPatterns = []*pattern{
+	{Name: "Password", Regex: `(?i)\b(?:password|passwd|passwort|pwd|pass)\s*[=:]\s*"?([^\s&"';,<>]{3,})`},
+	{Name: "BasicAuth", Regex: `(?i)Authorization:\s*Basic\s+([A-Za-z0-9+/]{8,}=*)`},
+	{Name: "BearerToken", Regex: `(?i)Authorization:\s*Bearer\s+([A-Za-z0-9\-._~+/]{16,}=*)`},
+	{Name: "AWSAccessKey", Regex: `\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`},
+	{Name: "AWSSecretKey", Regex: `(?i)aws_?secret_?access_?key\s*[=:]\s*"?([A-Za-z0-9/+=]{40})`},
+	{Name: "GitHubToken", Regex: `\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36})\b`},
+	{Name: "SlackToken", Regex: `\b(xox[abprs]-[A-Za-z0-9-]{10,})`},
+	{Name: "GoogleAPIKey", Regex: `\b(AIza[0-9A-Za-z\-_]{35})`},
+	{Name: "JWT", Regex: `\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`},
+	{Name: "PrivateKey", Regex: `-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP )?PRIVATE KEY(?: BLOCK)?-----`},
+	{Name: "APIKey", Regex: `(?i)\b(?:api[_-]?key|apikey|access[_-]?token|client[_-]?secret)\s*[=:]\s*"?([A-Za-z0-9\-_.]{16,})`},
+}
+
+// compile the default patterns on startup.
+func init() {
+	for _, p := range defaultPatterns {
+		if err := p.compile(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// patterns are matched against both directions of each stream.
+var patterns = defaultPatterns
+
+// initPatterns loads additional patterns from the JSON file at path and adds them to the default patterns.
+func initPatterns(path string) error {
+	patterns = defaultPatterns
+
+	if path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read secret patterns")
+	}
+
+	var custom []*pattern
+
+	err = json.Unmarshal(data, &custom)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse secret patterns")
+	}
+
+	for _, p := range custom {
+		if err = p.compile(); err != nil {
+			return err
+		}
+	}
+
+	patterns = append(append([]*pattern{}, defaultPatterns...), custom...)
+
+	return nil
+}
+
+// Scan searches both directions of the conversation for secrets and writes an audit record for each unique match.
+func Scan(conversation core.DataFragments, ident string, firstPacket time.Time, net, transport gopacket.Flow) {
+	if !enabled || len(conversation) == 0 {
+		return
+	}
+
+	var (
+		srcIP, dstIP     = net.Src().String(), net.Dst().String()
+		srcPort, dstPort = utils.DecodePort(transport.Src().Raw()), utils.DecodePort(transport.Dst().Raw())
+		ts               = firstPacket.UnixNano()
+	)
+
+	for _, l := range scan(conversation.DirectionBytes(reassembly.TCPDirClientToServer)) {
+		writeSecretLeak(&types.SecretLeak{
+			Timestamp: ts,
+			Flow:      ident,
+			SrcIP:     srcIP,
+			SrcPort:   srcPort,
+			DstIP:     dstIP,
+			DstPort:   dstPort,
+			Type:      l.Type,
+			Preview:   l.Preview,
+		})
+	}
+
+	for _, l := range scan(conversation.DirectionBytes(reassembly.TCPDirServerToClient)) {
+		writeSecretLeak(&types.SecretLeak{
+			Timestamp: ts,
+			Flow:      ident,
+			SrcIP:     dstIP,
+			SrcPort:   dstPort,
+			DstIP:     srcIP,
+			DstPort:   srcPort,
+			Type:      l.Type,
+			Preview:   l.Preview,
+		})
+	}
+}
+
+// scan matches all patterns against the data and returns the unique matches with the secrets redacted.
+func scan(data []byte) (leaks []*types.SecretLeak) {
+	if len(data) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+
+	for _, p := range patterns {
+		for _, m := range p.re.FindAllSubmatchIndex(data, maxMatchesPerPattern) {
+			match := string(data[m[0]:m[1]])
+			if _, ok := seen[p.Name+match]; ok {
+				continue
+			}
+
+			seen[p.Name+match] = struct{}{}
+
+			// redact the first capture group, or the entire match if there is none
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+
+			leaks = append(leaks, &types.SecretLeak{
+				Type:    p.Name,
+				Preview: string(data[m[0]:start]) + redact(string(data[start:end])) + string(data[end:m[1]]),
+			})
+		}
+	}
+
+	return leaks
+}
+
+// redact keeps the first characters of longer secrets to allow correlation and masks the rest.
+func redact(secret string) string {
+	const (
+		keep    = 4
+		maxMask = 16
+	)
+
+	if len(secret) <= 2*keep {
+		return strings.Repeat("*", len(secret))
+	}
+
+	n := len(secret) - keep
+	if n > maxMask {
+		n = maxMask
+	}
+
+	return secret[:keep] + strings.Repeat("*", n)
+}
+
+func writeSecretLeak(s *types.SecretLeak) {
+	if decoderconfig.Instance.ExportMetrics {
+		s.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(s)
+	if err != nil {
+		log.Fatal("failed to write proto: ", err)
+	}
+}
@@ -187,7 +187,7 @@ func TestClassifyBanners(t *testing.T) {
 
 func (b bannerTest) testClassifyBanner(t *testing.T) {
 	// make dummy service
-	serv := NewService(time.Now().UnixNano(), 0, 0, "")
+	serv := NewService(time.Now().UnixNano(), 0, 0, "", "")
 	serv.IP = "127.0.0.1"
 	serv.Port = 21
 	ident := "127.0.0.1:4322->127.0.0.1:21"
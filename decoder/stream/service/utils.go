@@ -69,7 +69,8 @@ func addInfo(old string, new string) string {
 }
 
 // NewService creates a new network service.
-func NewService(ts int64, numBytesServer, numBytesClient int, ip string) *service {
+// The vendor of the hardware address the service was reached at is resolved via the MAC database.
+func NewService(ts int64, numBytesServer, numBytesClient int, ip, mac string) *service {
 	var host string
 	if resolvers.CurrentConfig.ReverseDNS {
 		host = strings.Join(resolvers.LookupDNSNames(ip), "; ")
@@ -79,10 +80,12 @@ func NewService(ts int64, numBytesServer, numBytesClient int, ip string) *servic
 
 	return &service{
 		Service: &types.Service{
-			Timestamp:   ts,
-			BytesServer: int32(numBytesServer),
-			BytesClient: int32(numBytesClient),
-			Hostname:    host,
+			Timestamp:          ts,
+			BytesServer:        int32(numBytesServer),
+			BytesClient:        int32(numBytesClient),
+			Hostname:           host,
+			MacAddr:            mac,
+			DeviceManufacturer: resolvers.LookupMACVendor(mac),
 		},
 	}
 }
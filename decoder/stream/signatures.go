@@ -0,0 +1,282 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package stream
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+)
+
+// signature directions.
+const (
+	signatureDirectionClient = "client"
+	signatureDirectionServer = "server"
+	signatureDirectionAny    = "any"
+)
+
+// errInvalidSignature occurs when a protocol signature can not be compiled.
+var errInvalidSignature = errors.New("invalid protocol signature")
+
+// ProtocolSignature describes a payload pattern that identifies an application layer protocol.
+// The pattern is matched against the beginning of the reassembled client or server stream,
+// and the stream decoder with the configured name is selected upon a match.
+type ProtocolSignature struct {
+
+	// Protocol is the name of the stream decoder to use when the signature matches.
+	Protocol string `json:"protocol"`
+
+	// Direction of the data to match against: client, server or any.
+	Direction string `json:"direction"`
+
+	// Regex is a regular expression matched against the inspected data.
+	Regex string `json:"regex,omitempty"`
+
+	// Bytes is a hex encoded byte pattern matched against the inspected data.
+	Bytes string `json:"bytes,omitempty"`
+
+	// Offset is the position in the stream where the inspection starts.
+	Offset int `json:"offset,omitempty"`
+
+	// Depth limits the number of bytes inspected after the offset, 0 means no limit.
+	// If no depth is set, byte patterns must be located exactly at the offset.
+	Depth int `json:"depth,omitempty"`
+
+	re      *regexp.Regexp
+	pattern []byte
+}
+
+// compile prepares the regular expression or byte pattern of the signature.
+func (s *ProtocolSignature) compile() error {
+	if s.Protocol == "" {
+		return errors.Wrap(errInvalidSignature, "missing protocol name")
+	}
+
+	switch s.Direction {
+	case signatureDirectionClient, signatureDirectionServer, signatureDirectionAny:
+	case "":
+		s.Direction = signatureDirectionAny
+	default:
+		return errors.Wrap(errInvalidSignature, "invalid direction for "+s.Protocol+": "+s.Direction)
+	}
+
+	if s.Offset < 0 || s.Depth < 0 {
+		return errors.Wrap(errInvalidSignature, "negative offset or depth for "+s.Protocol)
+	}
+
+	switch {
+	case s.Regex != "":
+		re, err := regexp.Compile(s.Regex)
+		if err != nil {
+			return errors.Wrap(err, "invalid regex for "+s.Protocol)
+		}
+
+		s.re = re
+	case s.Bytes != "":
+		pattern, err := hex.DecodeString(s.Bytes)
+		if err != nil {
+			return errors.Wrap(err, "invalid byte pattern for "+s.Protocol)
+		}
+
+		s.pattern = pattern
+	default:
+		return errors.Wrap(errInvalidSignature, "no regex or byte pattern for "+s.Protocol)
+	}
+
+	return nil
+}
+
+// matchData checks if the signature pattern matches the given data, respecting offset and depth constraints.
+func (s *ProtocolSignature) matchData(data []byte) bool {
+	if len(data) <= s.Offset {
+		return false
+	}
+
+	data = data[s.Offset:]
+	if s.Depth > 0 && len(data) > s.Depth {
+		data = data[:s.Depth]
+	}
+
+	if s.re != nil {
+		return s.re.Match(data)
+	}
+
+	if s.Depth == 0 {
+		return bytes.HasPrefix(data, s.pattern)
+	}
+
+	return bytes.Contains(data, s.pattern)
+}
+
+// match checks the signature against the client and server data, according to the configured direction.
+func (s *ProtocolSignature) match(client, server []byte) bool {
+	switch s.Direction {
+	case signatureDirectionClient:
+		return s.matchData(client)
+	case signatureDirectionServer:
+		return s.matchData(server)
+	default:
+		return s.matchData(client) || s.matchData(server)
+	}
+}
+
+// defaultProtocolSignatures are used when no signature file has been configured.
+var defaultProtocolSignatures = []*ProtocolSignature{
+	{
+		Protocol:  "HTTP",
+		Direction: signatureDirectionClient,
+		Regex:     `^(GET|POST|HEAD|PUT|DELETE|OPTIONS|CONNECT|TRACE|PATCH) [^ ]+ HTTP/1\.[01]\r?\n`,
+	},
+	{
+		Protocol:  "HTTP",
+		Direction: signatureDirectionServer,
+		Bytes:     hex.EncodeToString([]byte("HTTP/1.")),
+	},
+	{
+		Protocol:  "SSH",
+		Direction: signatureDirectionAny,
+		Bytes:     hex.EncodeToString([]byte("SSH-")),
+	},
+	{
+		Protocol:  "SMTP",
+		Direction: signatureDirectionServer,
+		Regex:     `^220[ -][^\r\n]*E?SMTP`,
+	},
+	{
+		Protocol:  "SMTP",
+		Direction: signatureDirectionClient,
+		Regex:     `^(?i)(EHLO|HELO) [^\r\n]+\r?\n`,
+	},
+	{
+		Protocol:  "POP3",
+		Direction: signatureDirectionServer,
+		Regex:     `^\+OK[^\r\n]*POP`,
+	},
+	{
+		Protocol:  "FTP",
+		Direction: signatureDirectionServer,
+		Regex:     `^220[ -][^\r\n]*FTP`,
+	},
+	{
+		Protocol:  "BitTorrent",
+		Direction: signatureDirectionAny,
+		Bytes:     hex.EncodeToString([]byte("\x13BitTorrent protocol")),
+	},
+	{
+		Protocol:  "IMAP",
+		Direction: signatureDirectionServer,
+		Regex:     `^\* (OK|PREAUTH)[^\r\n]*IMAP`,
+	},
+	{
+		Protocol:  "Redis",
+		Direction: signatureDirectionClient,
+		Regex:     `^\*[1-9][0-9]*\r\n\$[0-9]+\r\n`,
+	},
+}
+
+// compile the default signatures on startup.
+func init() {
+	for _, s := range defaultProtocolSignatures {
+		if err := s.compile(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// protocolSignatures are matched against the beginning of each stream to select a stream decoder.
+var protocolSignatures = defaultProtocolSignatures
+
+// registeredProtocolSignatures have been added via RegisterProtocolSignatures,
+// they are kept separately to be merged with the signatures loaded by initProtocolSignatures.
+var registeredProtocolSignatures []*ProtocolSignature
+
+// RegisterProtocolSignatures compiles the signatures and appends them to the active signature set,
+// this allows to detect additional protocols without replacing the default signatures.
+// Signatures registered before the decoders are initialized are added to the loaded signature set.
+// It must be called before the streams are processed.
+func RegisterProtocolSignatures(sigs ...*ProtocolSignature) error {
+	for _, s := range sigs {
+		if err := s.compile(); err != nil {
+			return err
+		}
+	}
+
+	registeredProtocolSignatures = append(registeredProtocolSignatures, sigs...)
+
+	// copy the active set, so the default signatures remain unchanged
+	protocolSignatures = append(append([]*ProtocolSignature(nil), protocolSignatures...), sigs...)
+
+	return nil
+}
+
+// initProtocolSignatures loads the protocol signatures from the JSON file at path.
+// If the path is empty, the default signature set is used.
+// Previously registered signatures are appended to the loaded set.
+func initProtocolSignatures(path string) error {
+	sigs := defaultProtocolSignatures
+
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "failed to read protocol signatures")
+		}
+
+		sigs = nil
+
+		err = json.Unmarshal(data, &sigs)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse protocol signatures")
+		}
+
+		for _, s := range sigs {
+			if err = s.compile(); err != nil {
+				return err
+			}
+		}
+	}
+
+	protocolSignatures = append(append([]*ProtocolSignature(nil), sigs...), registeredProtocolSignatures...)
+
+	return nil
+}
+
+// MatchSignatures checks the protocol signatures against the start of the client and server streams
+// and returns the first loaded stream decoder for the given transport protocol whose signature matches.
+// Nil is returned if no signature matched.
+func MatchSignatures(client, server []byte, transport core.TransportProtocol) core.StreamDecoderAPI {
+	for _, s := range protocolSignatures {
+		if !s.match(client, server) {
+			continue
+		}
+
+		for _, sd := range DefaultStreamDecoders {
+			if sd.GetName() != s.Protocol || sd.GetReaderFactory() == nil {
+				continue
+			}
+
+			if sd.Transport() == transport || sd.Transport() == core.All {
+				return sd
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+)
+
+func TestDefaultProtocolSignatures(t *testing.T) {
+	tests := []struct {
+		client   string
+		server   string
+		expected string
+	}{
+		{"GET /index.html HTTP/1.1\r\nHost: example.com\r\n\r\n", "", "HTTP"},
+		{"", "HTTP/1.1 200 OK\r\n", "HTTP"},
+		{"SSH-2.0-OpenSSH_8.1\r\n", "", "SSH"},
+		{"", "220 mail.example.com ESMTP Postfix\r\n", "SMTP"},
+		{"", "+OK POP3 server ready\r\n", "POP3"},
+		{"", "* OK [CAPABILITY IMAP4rev1] Dovecot ready.\r\n", "IMAP"},
+		{"*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n", "", "Redis"},
+		{"hello", "world", ""},
+	}
+
+	for _, test := range tests {
+		sd := MatchSignatures([]byte(test.client), []byte(test.server), core.TCP)
+		if test.expected == "" {
+			if sd != nil {
+				t.Fatal("expected no match, got:", sd.GetName())
+			}
+
+			continue
+		}
+
+		if sd == nil {
+			t.Fatal("no match for", test.expected)
+		}
+
+		if sd.GetName() != test.expected {
+			t.Fatal("incorrect match, got:", sd.GetName(), "expected:", test.expected)
+		}
+	}
+}
+
+func TestProtocolSignatureOffset(t *testing.T) {
+	s := &ProtocolSignature{
+		Protocol:  "SSH",
+		Direction: signatureDirectionServer,
+		Bytes:     "5353482d",
+		Offset:    2,
+		Depth:     8,
+	}
+
+	if err := s.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.match(nil, []byte("xxxxSSH-2.0")) {
+		t.Fatal("expected pattern within depth to match")
+	}
+
+	if s.match(nil, []byte("xxxxxxxxSSH-2.0")) {
+		t.Fatal("expected pattern outside of depth not to match")
+	}
+
+	if s.match([]byte("xxxxSSH-2.0"), nil) {
+		t.Fatal("expected client data not to match a server signature")
+	}
+}
+
+func TestRegisterProtocolSignatures(t *testing.T) {
+	defer func() {
+		protocolSignatures = defaultProtocolSignatures
+		registeredProtocolSignatures = nil
+	}()
+
+	err := RegisterProtocolSignatures(&ProtocolSignature{
+		Protocol:  "Telnet",
+		Direction: signatureDirectionServer,
+		Bytes:     "fffd18",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sd := MatchSignatures(nil, []byte("\xff\xfd\x18\xff\xfd\x20"), core.TCP)
+	if sd == nil || sd.GetName() != "Telnet" {
+		t.Fatal("expected the registered signature to match")
+	}
+
+	// the default signatures remain active
+	if sd = MatchSignatures([]byte("SSH-2.0-OpenSSH_8.1\r\n"), nil, core.TCP); sd == nil || sd.GetName() != "SSH" {
+		t.Fatal("expected the default signatures to match")
+	}
+
+	if len(defaultProtocolSignatures) == len(protocolSignatures) {
+		t.Fatal("expected the default signature set to be unchanged")
+	}
+
+	if err = RegisterProtocolSignatures(&ProtocolSignature{Protocol: "Telnet"}); err == nil {
+		t.Fatal("expected an error for a signature without pattern")
+	}
+
+	// signatures registered before the decoders are initialized are kept
+	if err = initProtocolSignatures(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if sd = MatchSignatures(nil, []byte("\xff\xfd\x18\xff\xfd\x20"), core.TCP); sd == nil || sd.GetName() != "Telnet" {
+		t.Fatal("expected the registered signature to match after initialization")
+	}
+
+	if len(protocolSignatures) != len(defaultProtocolSignatures)+1 {
+		t.Fatal("expected the default and the registered signatures, got", len(protocolSignatures))
+	}
+}
+
+func TestMatchDPIProtocols(t *testing.T) {
+	tests := []struct {
+		protocols []string
+		transport core.TransportProtocol
+		expected  string
+	}{
+		{[]string{"HTTP"}, core.TCP, "HTTP"},
+		{[]string{"UNKNOWN_PROTO", "SSH"}, core.TCP, "SSH"},
+		{[]string{"MAIL_SMTP"}, core.TCP, "SMTP"},
+		{[]string{"WIREGUARD"}, core.UDP, "WireGuard"},
+		{[]string{"WIREGUARD"}, core.TCP, ""},
+		{[]string{"NTP"}, core.UDP, ""},
+	}
+
+	for _, test := range tests {
+		sd := MatchDPIProtocols(test.protocols, test.transport)
+		if test.expected == "" {
+			if sd != nil {
+				t.Fatal("expected no match for", test.protocols, "got:", sd.GetName())
+			}
+
+			continue
+		}
+
+		if sd == nil || sd.GetName() != test.expected {
+			t.Fatal("incorrect match for", test.protocols, "expected:", test.expected)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package smb
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var smbLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_SMB,
+	Name:        serviceSMB,
+	Description: "The Server Message Block protocol provides shared access to files, printers and named pipes",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		smbLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"smb",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isSMB(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return smbLog.Sync()
+	},
+	Factory: &smbReader{},
+	Typ:     core.TCP,
+}
+
+const serviceSMB = "SMB"
+
+// length of the direct TCP transport header and the SMB2 header.
+const (
+	transportHeaderSize = 4
+	headerSize          = 64
+)
+
+// protocol identifiers at the start of a SMB message.
+var (
+	protocolSMB1 = []byte{0xff, 'S', 'M', 'B'}
+	protocolSMB2 = []byte{0xfe, 'S', 'M', 'B'}
+)
+
+// SMB2 header flags.
+const (
+	flagServerToRedir = 0x1
+	flagAsync         = 0x2
+	flagRelated       = 0x4
+)
+
+// operations of related requests in a compound refer to the session, tree and file of the previous operation,
+// by using these values as identifier.
+const (
+	relatedSessionID = 0xffffffffffffffff
+	relatedTreeID    = 0xffffffff
+)
+
+// relatedFileID refers to the file opened by the previous operation of a compound.
+var relatedFileID = [16]byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+}
+
+// SMB2 commands.
+const (
+	cmdNegotiate    uint16 = 0x0000
+	cmdSessionSetup uint16 = 0x0001
+	cmdTreeConnect  uint16 = 0x0003
+	cmdCreate       uint16 = 0x0005
+	cmdRead         uint16 = 0x0008
+	cmdWrite        uint16 = 0x0009
+)
+
+// operations maps the decoded commands to their name,
+// other commands are skipped.
+var operations = map[uint16]string{
+	cmdNegotiate:    "NEGOTIATE",
+	cmdSessionSetup: "SESSION_SETUP",
+	cmdTreeConnect:  "TREE_CONNECT",
+	cmdCreate:       "CREATE",
+	cmdRead:         "READ",
+	cmdWrite:        "WRITE",
+}
+
+// NT status codes.
+const (
+	statusSuccess  uint32 = 0x00000000
+	statusPending  uint32 = 0x00000103
+	statusMoreAuth uint32 = 0xc0000016
+)
+
+// statusNames contains the names of the common NT status codes.
+var statusNames = map[uint32]string{
+	statusSuccess:  "STATUS_SUCCESS",
+	statusPending:  "STATUS_PENDING",
+	statusMoreAuth: "STATUS_MORE_PROCESSING_REQUIRED",
+	0x80000006:     "STATUS_NO_MORE_FILES",
+	0xc000000d:     "STATUS_INVALID_PARAMETER",
+	0xc0000011:     "STATUS_END_OF_FILE",
+	0xc0000022:     "STATUS_ACCESS_DENIED",
+	0xc0000034:     "STATUS_OBJECT_NAME_NOT_FOUND",
+	0xc0000035:     "STATUS_OBJECT_NAME_COLLISION",
+	0xc000003a:     "STATUS_OBJECT_PATH_NOT_FOUND",
+	0xc0000043:     "STATUS_SHARING_VIOLATION",
+	0xc0000061:     "STATUS_PRIVILEGE_NOT_HELD",
+	0xc0000064:     "STATUS_NO_SUCH_USER",
+	0xc000006a:     "STATUS_WRONG_PASSWORD",
+	0xc000006d:     "STATUS_LOGON_FAILURE",
+	0xc0000072:     "STATUS_ACCOUNT_DISABLED",
+	0xc00000ba:     "STATUS_FILE_IS_A_DIRECTORY",
+	0xc00000bb:     "STATUS_NOT_SUPPORTED",
+	0xc00000cc:     "STATUS_BAD_NETWORK_NAME",
+	0xc0000103:     "STATUS_NOT_A_DIRECTORY",
+	0xc0000203:     "STATUS_USER_SESSION_DELETED",
+	0xc0000234:     "STATUS_ACCOUNT_LOCKED_OUT",
+}
+
+// dialects contains the names of the SMB2 dialect revisions.
+var dialects = map[uint16]string{
+	0x0202: "2.0.2",
+	0x0210: "2.1",
+	0x02ff: "2.???",
+	0x0300: "3.0",
+	0x0302: "3.0.2",
+	0x0311: "3.1.1",
+}
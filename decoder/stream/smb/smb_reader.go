@@ -0,0 +1,441 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package smb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"unicode/utf16"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/decoder/stream/ntlm"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// isSMB checks if the data starts with a SMB message in a direct TCP transport frame.
+// SMB1 is accepted as well, since clients often start with a SMB1 negotiation to upgrade to SMB2.
+func isSMB(data []byte) bool {
+	if len(data) < transportHeaderSize+len(protocolSMB2) || data[0] != 0 {
+		return false
+	}
+
+	protocol := data[transportHeaderSize : transportHeaderSize+len(protocolSMB2)]
+
+	return bytes.Equal(protocol, protocolSMB2) || bytes.Equal(protocol, protocolSMB1)
+}
+
+// header of a SMB2 message.
+type header struct {
+	command   uint16
+	status    uint32
+	flags     uint32
+	next      uint32
+	messageID uint64
+	treeID    uint32
+	sessionID uint64
+}
+
+// message is a SMB2 message, the offsets in the message body are relative to the start of data.
+type message struct {
+	header
+	timestamp int64
+	data      []byte
+}
+
+// body returns the data following the header.
+func (m *message) body() []byte {
+	return m.data[headerSize:]
+}
+
+// parseHeader parses the SMB2 header at the start of data.
+func parseHeader(data []byte) header {
+	h := header{
+		command:   binary.LittleEndian.Uint16(data[12:14]),
+		status:    binary.LittleEndian.Uint32(data[8:12]),
+		flags:     binary.LittleEndian.Uint32(data[16:20]),
+		next:      binary.LittleEndian.Uint32(data[20:24]),
+		messageID: binary.LittleEndian.Uint64(data[24:32]),
+		sessionID: binary.LittleEndian.Uint64(data[40:48]),
+	}
+
+	// asynchronous messages carry an async identifier instead of the tree identifier
+	if h.flags&flagAsync == 0 {
+		h.treeID = binary.LittleEndian.Uint32(data[36:40])
+	}
+
+	return h
+}
+
+// parseMessages parses the SMB2 messages in the direct TCP transport frames of data.
+// Compound messages, that are chained in a single frame, are returned as separate messages.
+// Frames that do not contain SMB2, e.g. a SMB1 negotiation or encrypted messages, are skipped.
+// The timestamp of a message is taken from the fragment its frame starts in.
+func parseMessages(data []byte, fragments streamutils.Fragments) (messages []*message) {
+	var offset int
+
+	for offset+transportHeaderSize <= len(data) {
+		size := int(data[offset+1])<<16 | int(data[offset+2])<<8 | int(data[offset+3])
+		end := offset + transportHeaderSize + size
+
+		if end > len(data) {
+			smbLog.Debug("incomplete frame", zap.Int("offset", offset), zap.Int("size", size))
+
+			break
+		}
+
+		timestamp := fragments.Timestamp(offset)
+
+		// only session messages carry SMB, other NetBIOS frames like keep alives are skipped
+		if data[offset] == 0 {
+			for _, m := range splitCompound(data[offset+transportHeaderSize : end]) {
+				m.timestamp = timestamp
+				messages = append(messages, m)
+			}
+		}
+
+		offset = end
+	}
+
+	return messages
+}
+
+// splitCompound splits a frame into the chained SMB2 messages.
+func splitCompound(frame []byte) (messages []*message) {
+	for len(frame) >= headerSize && bytes.HasPrefix(frame, protocolSMB2) {
+		m := &message{
+			header: parseHeader(frame),
+			data:   frame,
+		}
+
+		if m.next == 0 {
+			messages = append(messages, m)
+
+			break
+		}
+
+		if m.next < headerSize || int(m.next) > len(frame) {
+			smbLog.Debug("invalid next command offset", zap.Uint32("next", m.next))
+
+			break
+		}
+
+		m.data = frame[:m.next]
+		messages = append(messages, m)
+		frame = frame[m.next:]
+	}
+
+	return messages
+}
+
+// responses maps the message identifiers to the final response.
+// Interim responses of asynchronous operations are replaced by the final response.
+func responses(messages []*message) map[uint64]*message {
+	res := make(map[uint64]*message, len(messages))
+
+	for _, m := range messages {
+		if m.flags&flagServerToRedir == 0 {
+			continue
+		}
+
+		if _, ok := res[m.messageID]; ok && m.status == statusPending {
+			continue
+		}
+
+		res[m.messageID] = m
+	}
+
+	return res
+}
+
+// session tracks the state of the conversation, that is needed to annotate the operations.
+type session struct {
+	dialect    string
+	trees      map[uint32]string
+	files      map[[16]byte]string
+	identities map[uint64]*ntlm.Message
+	auth       *ntlm.Session
+	ntlm       []*types.NTLM
+
+	// identifiers of the previous request, for related operations of a compound
+	prevSessionID uint64
+	prevTreeID    uint32
+	prevFile      string
+}
+
+// decode processes the SMB2 requests of the conversation in order and creates an audit record for each decoded operation.
+// NTLM authentications in the session setups are returned as well.
+func decode(data core.DataFragments) ([]*types.SMB, []*types.NTLM) {
+	var (
+		client, server  []byte
+		clientFragments streamutils.Fragments
+		serverFragments streamutils.Fragments
+		records         []*types.SMB
+		s               = &session{
+			trees:      make(map[uint32]string),
+			files:      make(map[[16]byte]string),
+			identities: make(map[uint64]*ntlm.Message),
+			auth:       &ntlm.Session{Protocol: serviceSMB},
+		}
+	)
+
+	for _, d := range data {
+		f := streamutils.Fragment{Timestamp: d.CaptureInfo().Timestamp.UnixNano()}
+
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			f.Offset = len(client)
+			clientFragments = append(clientFragments, f)
+			client = append(client, d.Raw()...)
+		} else {
+			f.Offset = len(server)
+			serverFragments = append(serverFragments, f)
+			server = append(server, d.Raw()...)
+		}
+	}
+
+	res := responses(parseMessages(server, serverFragments))
+
+	for _, m := range parseMessages(client, clientFragments) {
+		if m.flags&flagServerToRedir != 0 {
+			continue
+		}
+
+		if r := s.request(m, res[m.messageID]); r != nil {
+			records = append(records, r)
+		}
+	}
+
+	return records, s.ntlm
+}
+
+// request creates the audit record for a request and its response,
+// nil is returned for operations that are not decoded.
+func (s *session) request(m, res *message) *types.SMB {
+	if m.flags&flagRelated != 0 {
+		if m.sessionID == relatedSessionID {
+			m.sessionID = s.prevSessionID
+		}
+
+		if m.treeID == relatedTreeID {
+			m.treeID = s.prevTreeID
+		}
+	}
+
+	s.prevSessionID, s.prevTreeID = m.sessionID, m.treeID
+
+	op, ok := operations[m.command]
+	if !ok {
+		return nil
+	}
+
+	r := &types.SMB{
+		Timestamp: m.timestamp,
+		Operation: op,
+		MessageID: m.messageID,
+		SessionID: m.sessionID,
+		TreeID:    m.treeID,
+	}
+
+	if res != nil {
+		r.Status = statusName(res.status)
+
+		// the session identifier is assigned by the server in the response to the first session setup
+		if r.SessionID == 0 {
+			r.SessionID = res.sessionID
+		}
+	}
+
+	succeeded := res != nil && res.status == statusSuccess
+	body := m.body()
+
+	switch m.command {
+	case cmdNegotiate:
+		if res != nil && len(res.body()) >= 6 {
+			s.dialect = dialectName(binary.LittleEndian.Uint16(res.body()[4:6]))
+			r.Dialect = s.dialect
+		}
+	case cmdSessionSetup:
+		if len(body) >= 16 {
+			msg := ntlm.Parse(field(m.data, body[12:14], body[14:16]))
+			if msg != nil && msg.Type == ntlm.TypeAuthenticate {
+				r.Domain, r.User, r.Workstation = msg.Domain, msg.User, msg.Workstation
+
+				if succeeded {
+					s.identities[r.SessionID] = msg
+				}
+			}
+
+			if a := s.auth.Add(msg, m.timestamp); a != nil {
+				s.ntlm = append(s.ntlm, a)
+			}
+		}
+
+		// the challenge of the server is in the security buffer of the response
+		if res != nil && len(res.body()) >= 8 {
+			s.auth.Add(ntlm.Parse(field(res.data, res.body()[4:6], res.body()[6:8])), res.timestamp)
+		}
+	case cmdTreeConnect:
+		if len(body) >= 8 {
+			r.Tree = decodeUTF16(field(m.data, body[4:6], body[6:8]))
+		}
+
+		// the tree identifier is assigned by the server in the response
+		if succeeded {
+			r.TreeID = res.treeID
+			s.trees[r.TreeID] = r.Tree
+		}
+	case cmdCreate:
+		if len(body) >= 48 {
+			r.Filename = decodeUTF16(field(m.data, body[44:46], body[46:48]))
+		}
+
+		s.prevFile = r.Filename
+
+		if succeeded && len(res.body()) >= 80 {
+			var id [16]byte
+
+			copy(id[:], res.body()[64:80])
+			s.files[id] = r.Filename
+		}
+	case cmdRead, cmdWrite:
+		// the length, offset and file identifier are at the same position for both requests
+		if len(body) >= 32 {
+			var id [16]byte
+
+			r.Length = binary.LittleEndian.Uint32(body[4:8])
+			r.Offset = binary.LittleEndian.Uint64(body[8:16])
+
+			copy(id[:], body[16:32])
+
+			if m.flags&flagRelated != 0 && id == relatedFileID {
+				r.Filename = s.prevFile
+			} else {
+				r.Filename = s.files[id]
+			}
+		}
+	}
+
+	if r.Tree == "" {
+		r.Tree = s.trees[r.TreeID]
+	}
+
+	if id, ok := s.identities[r.SessionID]; ok && r.User == "" {
+		r.Domain, r.User, r.Workstation = id.Domain, id.User, id.Workstation
+	}
+
+	return r
+}
+
+// field returns the variable length field of a message, addressed by the little endian offset and length values.
+// Offsets are relative to the start of the message. If the field exceeds the message, nil is returned.
+func field(data, offset, length []byte) []byte {
+	var (
+		start = int(binary.LittleEndian.Uint16(offset))
+		end   = start + int(binary.LittleEndian.Uint16(length))
+	)
+
+	if start > end || end > len(data) {
+		return nil
+	}
+
+	return data[start:end]
+}
+
+// decodeUTF16 decodes the little endian UTF-16 strings used in SMB2 and NTLM.
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+
+	return string(utf16.Decode(u))
+}
+
+func statusName(status uint32) string {
+	if name, ok := statusNames[status]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%08x", status)
+}
+
+func dialectName(dialect uint16) string {
+	if name, ok := dialects[dialect]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%04x", dialect)
+}
+
+type smbReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new SMB reader.
+func (h *smbReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &smbReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the SMB2 messages of the conversation and writes an audit record for each decoded operation.
+// Requests are paired with their responses by the message identifier.
+func (h *smbReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	records, auths := decode(h.conversation.Data)
+
+	for _, r := range records {
+		r.Flow = h.conversation.Ident
+		r.SrcIP = h.conversation.ClientIP
+		r.SrcPort = h.conversation.ClientPort
+		r.DstIP = h.conversation.ServerIP
+		r.DstPort = h.conversation.ServerPort
+
+		writeSMB(r)
+	}
+
+	for _, a := range auths {
+		a.Flow = h.conversation.Ident
+		a.SrcIP = h.conversation.ClientIP
+		a.SrcPort = h.conversation.ClientPort
+		a.DstIP = h.conversation.ServerIP
+		a.DstPort = h.conversation.ServerPort
+
+		ntlm.WriteNTLM(a)
+	}
+}
+
+// writeSMB writes the audit record and updates the metrics if enabled.
+func writeSMB(r *types.SMB) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		smbLog.Error("failed to write SMB audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package smb
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/decoder/stream/ntlm"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func utf16LE(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(u))
+
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+
+	return b
+}
+
+// msg creates a SMB2 message with the given header values, followed by the body.
+func msg(command uint16, flags uint32, messageID uint64, treeID uint32, sessionID uint64, status uint32, body []byte) []byte {
+	h := make([]byte, headerSize)
+	copy(h, protocolSMB2)
+	binary.LittleEndian.PutUint16(h[4:], headerSize)
+	binary.LittleEndian.PutUint32(h[8:], status)
+	binary.LittleEndian.PutUint16(h[12:], command)
+	binary.LittleEndian.PutUint32(h[16:], flags)
+	binary.LittleEndian.PutUint64(h[24:], messageID)
+	binary.LittleEndian.PutUint32(h[36:], treeID)
+	binary.LittleEndian.PutUint64(h[40:], sessionID)
+
+	return append(h, body...)
+}
+
+// frame chains the messages into a compound and prepends the direct TCP transport header.
+func frame(messages ...[]byte) []byte {
+	var data []byte
+
+	for i, m := range messages {
+		if i < len(messages)-1 {
+			for len(m)%8 != 0 {
+				m = append(m, 0)
+			}
+
+			binary.LittleEndian.PutUint32(m[20:], uint32(len(m)))
+		}
+
+		data = append(data, m...)
+	}
+
+	return append([]byte{0, byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}, data...)
+}
+
+// variable creates a request body with a variable length field appended to the fixed size part,
+// the offset and length of the field are written at pos.
+func variable(fixed int, pos int, value []byte) []byte {
+	body := make([]byte, fixed)
+	binary.LittleEndian.PutUint16(body[pos:], uint16(headerSize+fixed))
+	binary.LittleEndian.PutUint16(body[pos+2:], uint16(len(value)))
+
+	return append(body, value...)
+}
+
+func ntlmAuthenticateMessage(domain, user, workstation string) []byte {
+	m := make([]byte, 64)
+	copy(m, ntlm.Signature)
+	binary.LittleEndian.PutUint32(m[8:], ntlm.TypeAuthenticate)
+	binary.LittleEndian.PutUint32(m[60:], ntlm.NegotiateUnicode)
+
+	for i, s := range []string{domain, user, workstation} {
+		v := utf16LE(s)
+		binary.LittleEndian.PutUint16(m[28+8*i:], uint16(len(v)))
+		binary.LittleEndian.PutUint32(m[32+8*i:], uint32(len(m)))
+		m = append(m, v...)
+	}
+
+	// the message is wrapped in a GSS-API token
+	return append([]byte{0xa1, 0x81, 0x80, 0x30}, m...)
+}
+
+func readWrite(length uint32, offset uint64, fileID [16]byte) []byte {
+	body := make([]byte, 48)
+	binary.LittleEndian.PutUint32(body[4:], length)
+	binary.LittleEndian.PutUint64(body[8:], offset)
+	copy(body[16:], fileID[:])
+
+	return body
+}
+
+func TestDecode(t *testing.T) {
+	var (
+		fileID     = [16]byte{1, 2, 3, 4}
+		negotiate  = make([]byte, 64)
+		createResp = make([]byte, 88)
+		session    = uint64(0x10)
+	)
+
+	binary.LittleEndian.PutUint16(negotiate[4:], 0x0311)
+	copy(createResp[64:], fileID[:])
+
+	var data core.DataFragments
+
+	for i, d := range [][]byte{
+		frame(msg(cmdNegotiate, 0, 0, 0, 0, 0, make([]byte, 36))),
+		frame(msg(cmdNegotiate, flagServerToRedir, 0, 0, 0, statusSuccess, negotiate)),
+		frame(msg(cmdSessionSetup, 0, 1, 0, 0, 0, variable(24, 12, []byte("NTLMSSP\x00\x01\x00\x00\x00")))),
+		frame(msg(cmdSessionSetup, flagServerToRedir, 1, 0, session, statusMoreAuth, make([]byte, 8))),
+		frame(msg(cmdSessionSetup, 0, 2, 0, session, 0, variable(24, 12, ntlmAuthenticateMessage("CORP", "alice", "WS1")))),
+		frame(msg(cmdSessionSetup, flagServerToRedir, 2, 0, session, statusSuccess, make([]byte, 8))),
+		frame(msg(cmdTreeConnect, 0, 3, 0, session, 0, variable(8, 4, utf16LE(`\\srv\share`)))),
+		frame(msg(cmdTreeConnect, flagServerToRedir, 3, 5, session, statusSuccess, make([]byte, 16))),
+		frame(
+			msg(cmdCreate, 0, 4, 5, session, 0, variable(56, 44, utf16LE(`docs\a.txt`))),
+			msg(cmdRead, flagRelated, 5, relatedTreeID, relatedSessionID, 0, readWrite(100, 0, relatedFileID)),
+		),
+		frame(
+			msg(cmdCreate, flagServerToRedir, 4, 5, session, statusSuccess, createResp),
+			msg(cmdRead, flagServerToRedir|flagRelated, 5, 5, session, statusSuccess, make([]byte, 16)),
+		),
+		append(
+			frame(msg(cmdWrite, 0, 6, 5, session, 0, readWrite(10, 200, fileID))),
+			// keep alive
+			0x85, 0, 0, 0,
+		),
+		append(
+			frame(msg(cmdWrite, flagServerToRedir|flagAsync, 6, 0, session, statusPending, make([]byte, 8))),
+			frame(msg(cmdWrite, flagServerToRedir|flagAsync, 6, 0, session, 0xc0000022, make([]byte, 8)))...,
+		),
+	} {
+		dir := reassembly.TCPDirClientToServer
+		if i%2 == 1 {
+			dir = reassembly.TCPDirServerToClient
+		}
+
+		data = append(data, &core.StreamData{RawData: d, Dir: dir})
+	}
+
+	records, auths := decode(data)
+
+	if len(auths) != 1 || auths[0].Protocol != serviceSMB || auths[0].Domain != "CORP" || auths[0].User != "alice" || auths[0].Workstation != "WS1" {
+		t.Fatalf("unexpected NTLM authentications: %+v", auths)
+	}
+
+	expected := []types.SMB{
+		{Operation: "NEGOTIATE", Status: "STATUS_SUCCESS", Dialect: "3.1.1"},
+		{Operation: "SESSION_SETUP", Status: "STATUS_MORE_PROCESSING_REQUIRED", MessageID: 1, SessionID: session},
+		{Operation: "SESSION_SETUP", Status: "STATUS_SUCCESS", MessageID: 2, SessionID: session, Domain: "CORP", User: "alice", Workstation: "WS1"},
+		{Operation: "TREE_CONNECT", Status: "STATUS_SUCCESS", MessageID: 3, SessionID: session, TreeID: 5, Tree: `\\srv\share`, Domain: "CORP", User: "alice", Workstation: "WS1"},
+		{Operation: "CREATE", Status: "STATUS_SUCCESS", MessageID: 4, SessionID: session, TreeID: 5, Tree: `\\srv\share`, Filename: `docs\a.txt`, Domain: "CORP", User: "alice", Workstation: "WS1"},
+		{Operation: "READ", Status: "STATUS_SUCCESS", MessageID: 5, SessionID: session, TreeID: 5, Tree: `\\srv\share`, Filename: `docs\a.txt`, Domain: "CORP", User: "alice", Workstation: "WS1", Length: 100},
+		{Operation: "WRITE", Status: "STATUS_ACCESS_DENIED", MessageID: 6, SessionID: session, TreeID: 5, Tree: `\\srv\share`, Filename: `docs\a.txt`, Domain: "CORP", User: "alice", Workstation: "WS1", Offset: 200, Length: 10},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatal("expected", len(expected), "records, got", len(records))
+	}
+
+	for i, r := range records {
+		// the fragments have no capture info
+		expected[i].Timestamp = time.Time{}.UnixNano()
+
+		if *r != expected[i] {
+			t.Errorf("record %d: expected %+v, got %+v", i, expected[i], *r)
+		}
+	}
+}
@@ -15,6 +15,7 @@ package smtp
 
 import (
 	"bufio"
+	"encoding/base64"
 	"errors"
 	"io"
 	"net/textproto"
@@ -61,6 +62,7 @@ const (
 	smtpNOOP      = "NOOP"
 	smtpQUIT      = "QUIT"
 	smtpEHLO      = "EHLO"
+	smtpAUTH      = "AUTH"
 	smtpAUTHLOGIN = "AUTH LOGIN"
 	smtpSTARTTLS  = "STARTTLS"
 	smtpSITE      = "SITE"
@@ -79,6 +81,8 @@ const (
 	smtpMailActionCompleted     = 250 // Requested mail action okay, completed
 	smtpUserNotLocal            = 251 // User not local; will forward to <forward-path>
 	smtpCannotVerify            = 252 // Cannot VRFY user, but will accept message and attempt delivery
+	smtpAuthSuccessful          = 235 // Authentication successful (rfc4954)
+	smtpAuthChallenge           = 334 // Server challenge for the authentication exchange (rfc4954)
 	smtpStartMail               = 354 // Start mail input; end with <CRLF>.<CRLF>
 	smtpServiceUnavailable      = 421 // <domain> Service not available, closing transmission channel
 	smtpMailboxUnavailable      = 450 // Requested mail action not taken: mailbox unavailable
@@ -107,8 +111,26 @@ type smtpReader struct {
 	resIndex      int
 
 	user, pass, token string
+
+	// next line expected from the client during an AUTH LOGIN or AUTH PLAIN exchange
+	auth authStep
+
+	// envelope of the mail transactions and the extensions announced by the server
+	from       string
+	rcpts      []string
+	extensions []string
 }
 
+// authStep describes the next credential expected from the client during authentication.
+type authStep int
+
+const (
+	authNone authStep = iota
+	authLoginUser
+	authLoginPassword
+	authPlain
+)
+
 func validSMTPCommand(cmd string) bool {
 	switch cmd {
 	case smtpDot,
@@ -121,6 +143,7 @@ func validSMTPCommand(cmd string) bool {
 		smtpNOOP,
 		smtpQUIT,
 		smtpEHLO,
+		smtpAUTH,
 		smtpAUTHLOGIN,
 		smtpSTARTTLS,
 		smtpSITE,
@@ -167,13 +190,18 @@ func (h *smtpReader) Decode() {
 	mails := h.processSMTPConversation()
 
 	smtpMsg := &types.SMTP{
-		Timestamp: h.conversation.FirstClientPacket.UnixNano(),
-		SrcIP:     h.conversation.ClientIP,
-		DstIP:     h.conversation.ServerIP,
-		SrcPort:   h.conversation.ClientPort,
-		DstPort:   h.conversation.ServerPort,
-		MailIDs:   mails,
-		Commands:  commands,
+		Timestamp:  h.conversation.FirstClientPacket.UnixNano(),
+		SrcIP:      h.conversation.ClientIP,
+		DstIP:      h.conversation.ServerIP,
+		SrcPort:    h.conversation.ClientPort,
+		DstPort:    h.conversation.ServerPort,
+		MailIDs:    mails,
+		Commands:   commands,
+		MailFrom:   h.from,
+		RcptTo:     h.rcpts,
+		Extensions: h.extensions,
+		User:       h.user,
+		Password:   h.pass,
 	}
 
 	// export metrics if configured
@@ -217,6 +245,13 @@ nextLine:
 
 	smtpDebug(ansi.Red, h.conversation.Ident, "readSMTPRequest", line, ansi.Reset)
 
+	// credentials sent in response to the challenges of the server
+	if h.auth != authNone && len(data) == 0 {
+		h.readCredentials(strings.TrimSpace(line))
+
+		return nil
+	}
+
 	cmd, args := getSMTPCommand(line)
 
 	if cmd == smtpDot {
@@ -251,6 +286,10 @@ nextLine:
 		goto nextLine
 	}
 
+	if cmd == smtpAUTH {
+		h.startAuth(args)
+	}
+
 	if cmd == smtpQUIT {
 		return io.EOF
 	}
@@ -258,21 +297,103 @@ nextLine:
 	return nil
 }
 
+// startAuth handles the AUTH command, the initial response can be sent along with the mechanism.
+func (h *smtpReader) startAuth(args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "LOGIN":
+		if len(args) > 1 {
+			h.user = decodeBase64(args[1])
+			h.auth = authLoginPassword
+		} else {
+			h.auth = authLoginUser
+		}
+	case "PLAIN":
+		if len(args) > 1 {
+			h.user, h.pass = decodeAuthPlain(args[1])
+		} else {
+			h.auth = authPlain
+		}
+	}
+}
+
+// readCredentials handles a line sent by the client during the authentication exchange.
+func (h *smtpReader) readCredentials(line string) {
+	// the client cancelled the authentication
+	if line == "*" {
+		h.auth = authNone
+
+		return
+	}
+
+	switch h.auth {
+	case authLoginUser:
+		h.user = decodeBase64(line)
+		h.auth = authLoginPassword
+	case authLoginPassword:
+		h.pass = decodeBase64(line)
+		h.auth = authNone
+	case authPlain:
+		h.user, h.pass = decodeAuthPlain(line)
+		h.auth = authNone
+	}
+}
+
+// decodeBase64 decodes a credential, if the data is not valid base64 it is returned as is.
+func decodeBase64(s string) string {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return s
+	}
+
+	return string(b)
+}
+
+// decodeAuthPlain decodes the message of the PLAIN mechanism: [authzid] NUL authcid NUL passwd (rfc4616).
+func decodeAuthPlain(s string) (user, pass string) {
+	parts := strings.Split(decodeBase64(s), "\x00")
+	if len(parts) != 3 {
+		return "", ""
+	}
+
+	return parts[1], parts[2]
+}
+
 // cuts the line into command and arguments.
 func getSMTPCommand(line string) (string, []string) {
 	line = strings.Trim(line, "\r \n")
 	cmd := strings.Split(line, " ")
 
 	if strings.ToUpper(cmd[0]) == "MAIL" || strings.ToUpper(cmd[0]) == "RCPT" {
-		cmd = strings.Split(line, ": ")
-		if len(cmd) > 1 {
-			cmd[1] = strings.Trim(cmd[1], "<>")
+		// the address follows the colon, with or without a space: MAIL FROM:<alice@example.com> SIZE=1024
+		if i := strings.IndexByte(line, ':'); i != -1 {
+			return strings.ToUpper(strings.TrimSpace(line[:i])), []string{envelopeAddress(line[i+1:])}
 		}
 	}
 
 	return strings.ToUpper(cmd[0]), cmd[1:]
 }
 
+// envelopeAddress returns the address from the argument of a MAIL or RCPT command, without ESMTP parameters.
+func envelopeAddress(arg string) string {
+	arg = strings.TrimSpace(arg)
+
+	if strings.HasPrefix(arg, "<") {
+		if i := strings.IndexByte(arg, '>'); i != -1 {
+			return arg[1:i]
+		}
+	}
+
+	if i := strings.IndexByte(arg, ' '); i != -1 {
+		arg = arg[:i]
+	}
+
+	return strings.Trim(arg, "<>")
+}
+
 func (h *smtpReader) readResponse(b *bufio.Reader) error {
 	var (
 		tp   = textproto.NewReader(b)
@@ -355,8 +476,21 @@ func (h *smtpReader) processSMTPConversation() (mailIDs []string) {
 
 		switch r.Command {
 		case smtpEHLO, smtpHELO:
+			if r.Command == smtpEHLO && len(h.smtpResponses) > h.resIndex+1 {
+				h.extensions = ehloExtensions(h.smtpResponses[h.resIndex+1])
+			}
+
 			h.resIndex += 2 // skip greeting and helo confirmation replies
 
+			continue
+		case smtpAUTH:
+			// skip the challenges until the final reply of the server
+			for h.resIndex < len(h.smtpResponses) && h.smtpResponses[h.resIndex].ResponseCode == smtpAuthChallenge {
+				h.resIndex++
+			}
+
+			h.resIndex++
+
 			continue
 		case smtpDATA:
 
@@ -364,7 +498,7 @@ func (h *smtpReader) processSMTPConversation() (mailIDs []string) {
 			mail.WriteMail(m)
 			mailIDs = append(mailIDs, m.ID)
 			numMails++
-			h.resIndex++
+			h.resIndex += 2 // skip the start mail input and the final reply
 
 			continue
 
@@ -376,6 +510,7 @@ func (h *smtpReader) processSMTPConversation() (mailIDs []string) {
 			reply := h.smtpResponses[h.resIndex]
 			if reply.ResponseCode == smtpMailActionCompleted {
 				from = r.Argument
+				h.from = from
 			}
 
 			h.resIndex++
@@ -389,8 +524,9 @@ func (h *smtpReader) processSMTPConversation() (mailIDs []string) {
 
 			reply := h.smtpResponses[h.resIndex]
 
-			if reply.ResponseCode == smtpMailActionCompleted {
+			if reply.ResponseCode == smtpMailActionCompleted || reply.ResponseCode == smtpUserNotLocal {
 				to = r.Argument
+				h.rcpts = append(h.rcpts, to)
 			}
 
 			h.resIndex++
@@ -404,3 +540,23 @@ func (h *smtpReader) processSMTPConversation() (mailIDs []string) {
 		}
 	}
 }
+
+// ehloExtensions returns the extensions announced in the multiline reply to EHLO.
+// The first line contains the domain of the server and is skipped.
+func ehloExtensions(reply *types.SMTPResponse) (extensions []string) {
+	if reply.ResponseCode != smtpMailActionCompleted || reply.Data == "" {
+		return nil
+	}
+
+	for _, line := range strings.Split(reply.Data, "\n")[1:] {
+		if len(line) > 4 {
+			extensions = append(extensions, line[4:])
+		}
+	}
+
+	if reply.Parameter != "" {
+		extensions = append(extensions, reply.Parameter)
+	}
+
+	return extensions
+}
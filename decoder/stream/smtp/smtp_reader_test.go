@@ -13,6 +13,19 @@
 
 package smtp
 
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+)
+
 // 220 smtp-gw11.han.skanova.net ESMTP Service ready
 // HELO passwordnedxp
 // 250 smtp-gw11.han.skanova.net
@@ -75,3 +88,66 @@ package smtp
 // 250 <54E6F832004A05C2> Mail accepted
 // QUIT
 // 221 smtp-gw11.han.skanova.net QUIT
+
+func TestPipelinedEnvelope(t *testing.T) {
+	smtpLog = zap.NewNop()
+	smtpLogSugared = smtpLog.Sugar()
+
+	var (
+		h = &smtpReader{conversation: &core.ConversationInfo{}}
+
+		// the commands of the transaction are sent in a single segment
+		client = "EHLO client.example.com\r\n" +
+			"AUTH LOGIN\r\n" +
+			"YWxpY2U=\r\n" +
+			"c2VjcmV0\r\n" +
+			"MAIL FROM:<alice@example.com> SIZE=1024\r\nRCPT TO:<bob@example.com>\r\nRCPT TO: <carol@example.com>\r\n" +
+			"QUIT\r\n"
+		server = "220 mail.example.com ESMTP Postfix\r\n" +
+			"250-mail.example.com\r\n250-PIPELINING\r\n250-SIZE 10240000\r\n250 AUTH LOGIN PLAIN\r\n" +
+			"334 VXNlcm5hbWU6\r\n334 UGFzc3dvcmQ6\r\n235 2.7.0 Authentication successful\r\n" +
+			"250 2.1.0 Ok\r\n250 2.1.5 Ok\r\n250 2.1.5 Ok\r\n" +
+			"221 2.0.0 Bye\r\n"
+	)
+
+	read := func(data string, f func(b *bufio.Reader) error) {
+		b := bufio.NewReader(strings.NewReader(data))
+		for {
+			if err := f(b); errors.Is(err, io.EOF) {
+				return
+			}
+		}
+	}
+
+	read(client, h.readRequest)
+	read(server, h.readResponse)
+
+	if mails := h.processSMTPConversation(); len(mails) != 0 {
+		t.Fatal("unexpected mails", mails)
+	}
+
+	if h.from != "alice@example.com" {
+		t.Fatal("unexpected sender", h.from)
+	}
+
+	if !reflect.DeepEqual(h.rcpts, []string{"bob@example.com", "carol@example.com"}) {
+		t.Fatal("unexpected recipients", h.rcpts)
+	}
+
+	if !reflect.DeepEqual(h.extensions, []string{"PIPELINING", "SIZE 10240000", "AUTH LOGIN PLAIN"}) {
+		t.Fatal("unexpected extensions", h.extensions)
+	}
+
+	if h.user != "alice" || h.pass != "secret" {
+		t.Fatal("unexpected credentials", h.user, h.pass)
+	}
+}
+
+func TestAuthPlain(t *testing.T) {
+	h := &smtpReader{}
+	h.startAuth([]string{"PLAIN", "AGFsaWNlAHNlY3JldA=="})
+
+	if h.user != "alice" || h.pass != "secret" || h.auth != authNone {
+		t.Fatal("unexpected credentials", h.user, h.pass)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package socks
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var socksLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_SOCKS,
+	Name:        "SOCKS",
+	Description: "SOCKS is a protocol to relay TCP connections and UDP datagrams through a proxy server",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		socksLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"socks",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isSOCKS4(client, server) || isSOCKS5(client, server)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return socksLog.Sync()
+	},
+	Factory: &socksReader{},
+	Typ:     core.TCP,
+}
+
+// protocol versions.
+const (
+	version4 = 4
+	version5 = 5
+
+	// version of the username/password sub-negotiation defined in RFC 1929.
+	versionUserPass = 1
+)
+
+// commands of the client.
+const (
+	cmdConnect      = 1
+	cmdBind         = 2
+	cmdUDPAssociate = 3
+)
+
+// SOCKS5 authentication methods.
+const (
+	methodNoAuth       = 0x00
+	methodGSSAPI       = 0x01
+	methodUserPass     = 0x02
+	methodNoAcceptable = 0xff
+)
+
+// SOCKS5 address types.
+const (
+	atypIPv4   = 1
+	atypDomain = 3
+	atypIPv6   = 4
+)
+
+// SOCKS4 reply codes.
+const (
+	socks4Granted = 90
+	socks4Identd  = 93
+)
+
+var commandNames = map[byte]string{
+	cmdConnect:      "CONNECT",
+	cmdBind:         "BIND",
+	cmdUDPAssociate: "UDP ASSOCIATE",
+}
+
+var methodNames = map[byte]string{
+	methodNoAuth:       "NO AUTHENTICATION REQUIRED",
+	methodGSSAPI:       "GSSAPI",
+	methodUserPass:     "USERNAME/PASSWORD",
+	methodNoAcceptable: "NO ACCEPTABLE METHODS",
+}
+
+var socks4Replies = map[byte]string{
+	90: "request granted",
+	91: "request rejected or failed",
+	92: "identd not reachable",
+	93: "identd user mismatch",
+}
+
+var socks5Replies = map[byte]string{
+	0: "succeeded",
+	1: "general SOCKS server failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
@@ -0,0 +1,342 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package socks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// isSOCKS4 checks if the conversation starts with a SOCKS4 request and the reply of the proxy.
+func isSOCKS4(client, server []byte) bool {
+	if len(client) < 9 || client[0] != version4 || (client[1] != cmdConnect && client[1] != cmdBind) {
+		return false
+	}
+
+	// the user id is terminated by a null byte
+	if bytes.IndexByte(client[8:], 0) == -1 {
+		return false
+	}
+
+	return len(server) >= 8 && server[0] == 0 && server[1] >= socks4Granted && server[1] <= socks4Identd
+}
+
+// isSOCKS5 checks if the conversation starts with a SOCKS5 greeting and the method selection of the proxy.
+func isSOCKS5(client, server []byte) bool {
+	if len(client) < 3 || client[0] != version5 || client[1] == 0 || len(client) < 2+int(client[1]) {
+		return false
+	}
+
+	if len(server) < 2 || server[0] != version5 {
+		return false
+	}
+
+	// the selected method must have been offered by the client
+	return server[1] == methodNoAcceptable || bytes.IndexByte(client[2:2+int(client[1])], server[1]) != -1
+}
+
+// buffer reads the fields of the handshake messages.
+// All reads are bounds checked, so truncated or malformed messages stop the parsing instead of panicking.
+type buffer struct {
+	data []byte
+	ok   bool
+}
+
+func newBuffer(data []byte) *buffer {
+	return &buffer{data: data, ok: true}
+}
+
+func (b *buffer) next(n int) []byte {
+	if !b.ok || n < 0 || len(b.data) < n {
+		b.ok = false
+
+		return nil
+	}
+
+	d := b.data[:n]
+	b.data = b.data[n:]
+
+	return d
+}
+
+func (b *buffer) byte() byte {
+	if d := b.next(1); d != nil {
+		return d[0]
+	}
+
+	return 0
+}
+
+func (b *buffer) uint16() uint16 {
+	if d := b.next(2); d != nil {
+		return binary.BigEndian.Uint16(d)
+	}
+
+	return 0
+}
+
+// cString reads a string terminated by a null byte.
+func (b *buffer) cString() string {
+	i := bytes.IndexByte(b.data, 0)
+	if !b.ok || i == -1 {
+		b.ok = false
+
+		return ""
+	}
+
+	s := string(b.data[:i])
+	b.data = b.data[i+1:]
+
+	return s
+}
+
+// address reads a SOCKS5 address of the given type followed by the port.
+func (b *buffer) address() (host string, port int32) {
+	switch b.byte() {
+	case atypIPv4:
+		if ip := b.next(net.IPv4len); ip != nil {
+			host = net.IP(ip).String()
+		}
+	case atypIPv6:
+		if ip := b.next(net.IPv6len); ip != nil {
+			host = net.IP(ip).String()
+		}
+	case atypDomain:
+		host = string(b.next(int(b.byte())))
+	default:
+		b.ok = false
+	}
+
+	port = int32(b.uint16())
+
+	return host, port
+}
+
+// name returns the name for the code, or the code in hex if it is unknown.
+func name(names map[byte]string, code byte) string {
+	if n, ok := names[code]; ok {
+		return n
+	}
+
+	return "0x" + strconv.FormatUint(uint64(code), 16)
+}
+
+// decodeSOCKS4 parses a SOCKS4 or SOCKS4a request and the reply.
+func decodeSOCKS4(client, server *buffer) *types.SOCKS {
+	r := &types.SOCKS{Version: version4}
+
+	client.byte()
+	r.Command = name(commandNames, client.byte())
+	r.Port = int32(client.uint16())
+	ip := client.next(net.IPv4len)
+	r.User = client.cString()
+
+	if !client.ok {
+		return nil
+	}
+
+	// SOCKS4a: an address of 0.0.0.x with x != 0 indicates that the domain name follows the user id
+	if ip[0] == 0 && ip[1] == 0 && ip[2] == 0 && ip[3] != 0 {
+		r.Host = client.cString()
+	} else {
+		r.Host = net.IP(ip).String()
+	}
+
+	server.byte()
+	reply := server.byte()
+	bindPort := server.uint16()
+	bindIP := server.next(net.IPv4len)
+
+	if server.ok {
+		r.Reply = name(socks4Replies, reply)
+		r.BindHost = net.IP(bindIP).String()
+		r.BindPort = int32(bindPort)
+	}
+
+	return r
+}
+
+// decodeSOCKS5 parses the method negotiation, the optional username/password authentication,
+// the request and the reply of a SOCKS5 handshake.
+func decodeSOCKS5(client, server *buffer) *types.SOCKS {
+	r := &types.SOCKS{Version: version5}
+
+	// greeting with the offered methods
+	client.byte()
+	client.next(int(client.byte()))
+
+	// method selection
+	server.byte()
+	method := server.byte()
+
+	if !client.ok || !server.ok {
+		return nil
+	}
+
+	r.AuthMethod = name(methodNames, method)
+
+	switch method {
+	case methodNoAuth:
+	case methodUserPass:
+		if client.byte() != versionUserPass {
+			return r
+		}
+
+		r.User = string(client.next(int(client.byte())))
+		r.Password = string(client.next(int(client.byte())))
+
+		server.byte()
+
+		status := server.byte()
+		if !server.ok {
+			return r
+		}
+
+		// any status other than zero indicates a failure, the proxy closes the connection
+		if status != 0 {
+			r.AuthFailed = true
+
+			return r
+		}
+	default:
+		// no acceptable methods, or the following messages are encapsulated by the method, e.g. GSSAPI
+		return r
+	}
+
+	if client.byte() != version5 {
+		return r
+	}
+
+	cmd := client.byte()
+	client.byte() // reserved
+
+	host, port := client.address()
+	if !client.ok {
+		return r
+	}
+
+	r.Command = name(commandNames, cmd)
+	r.Host = host
+	r.Port = port
+
+	if server.byte() != version5 {
+		return r
+	}
+
+	reply := server.byte()
+	server.byte() // reserved
+
+	bindHost, bindPort := server.address()
+	if server.ok {
+		r.Reply = name(socks5Replies, reply)
+		r.BindHost = bindHost
+		r.BindPort = bindPort
+	}
+
+	return r
+}
+
+type socksReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new SOCKS reader.
+func (h *socksReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &socksReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the handshake of the conversation and writes an audit record for the requested destination.
+func (h *socksReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	r := decode(h.conversation.Data)
+	if r == nil {
+		socksLog.Debug("no SOCKS handshake found", zap.String("flow", h.conversation.Ident))
+
+		return
+	}
+
+	r.Flow = h.conversation.Ident
+	r.SrcIP = h.conversation.ClientIP
+	r.SrcPort = h.conversation.ClientPort
+	r.DstIP = h.conversation.ServerIP
+	r.DstPort = h.conversation.ServerPort
+
+	writeSOCKS(r)
+}
+
+// decode parses the handshake at the start of the conversation.
+// The tunneled data that follows the handshake is ignored.
+func decode(data core.DataFragments) *types.SOCKS {
+	var (
+		client, server []byte
+		timestamp      int64
+	)
+
+	for _, d := range data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			if len(client) == 0 {
+				timestamp = d.CaptureInfo().Timestamp.UnixNano()
+			}
+
+			client = append(client, d.Raw()...)
+		} else {
+			server = append(server, d.Raw()...)
+		}
+	}
+
+	var r *types.SOCKS
+
+	switch {
+	case isSOCKS4(client, server):
+		r = decodeSOCKS4(newBuffer(client), newBuffer(server))
+	case isSOCKS5(client, server):
+		r = decodeSOCKS5(newBuffer(client), newBuffer(server))
+	}
+
+	if r != nil {
+		r.Timestamp = timestamp
+	}
+
+	return r
+}
+
+// writeSOCKS writes the audit record and updates the metrics if enabled.
+func writeSOCKS(r *types.SOCKS) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		socksLog.Error("failed to write SOCKS audit record", zap.Error(err))
+	}
+}
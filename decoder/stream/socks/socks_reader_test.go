@@ -0,0 +1,166 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package socks
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+type segment struct {
+	dir  reassembly.TCPFlowDirection
+	data string
+}
+
+var (
+	client = reassembly.TCPDirClientToServer
+	server = reassembly.TCPDirServerToClient
+)
+
+func conversation(segments ...segment) (data core.DataFragments) {
+	for _, s := range segments {
+		data = append(data, &core.StreamData{RawData: []byte(s.data), Dir: s.dir})
+	}
+
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     core.DataFragments
+		expected *types.SOCKS
+	}{
+		{
+			name: "SOCKS4",
+			data: conversation(
+				segment{client, "\x04\x01\x00\x50\x5d\xb8\xd8\x22bob\x00"},
+				segment{server, "\x00\x5a\x00\x00\x00\x00\x00\x00"},
+				segment{client, "GET / HTTP/1.1\r\n\r\n"},
+			),
+			expected: &types.SOCKS{Version: 4, User: "bob", Command: "CONNECT", Host: "93.184.216.34", Port: 80, Reply: "request granted", BindHost: "0.0.0.0"},
+		},
+		{
+			name: "SOCKS4a",
+			data: conversation(
+				segment{client, "\x04\x01\x01\xbb\x00\x00\x00\x01\x00example.com\x00"},
+				segment{server, "\x00\x5b\x00\x00\x00\x00\x00\x00"},
+			),
+			expected: &types.SOCKS{Version: 4, Command: "CONNECT", Host: "example.com", Port: 443, Reply: "request rejected or failed", BindHost: "0.0.0.0"},
+		},
+		{
+			name: "SOCKS5 domain name",
+			data: conversation(
+				segment{client, "\x05\x01\x00"},
+				segment{server, "\x05\x00"},
+				segment{client, "\x05\x01\x00\x03\x0bexample.com\x01\xbb"},
+				segment{server, "\x05\x00\x00\x01\x0a\x00\x00\x01\x9c\x40"},
+			),
+			expected: &types.SOCKS{Version: 5, AuthMethod: "NO AUTHENTICATION REQUIRED", Command: "CONNECT", Host: "example.com", Port: 443, Reply: "succeeded", BindHost: "10.0.0.1", BindPort: 40000},
+		},
+		{
+			name: "SOCKS5 username/password",
+			data: conversation(
+				// greeting and authentication sent without waiting for the proxy
+				segment{client, "\x05\x02\x00\x02\x01\x05alice\x06secret"},
+				segment{server, "\x05\x02\x01\x00"},
+				segment{client, "\x05\x02\x00\x04\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x15"},
+				segment{server, "\x05\x05\x00\x01\x00\x00\x00\x00\x00\x00"},
+			),
+			expected: &types.SOCKS{Version: 5, AuthMethod: "USERNAME/PASSWORD", User: "alice", Password: "secret", Command: "BIND", Host: "2001:db8::1", Port: 21, Reply: "connection refused", BindHost: "0.0.0.0"},
+		},
+		{
+			name: "SOCKS5 authentication failed",
+			data: conversation(
+				segment{client, "\x05\x01\x02"},
+				segment{server, "\x05\x02"},
+				segment{client, "\x01\x05alice\x05wrong"},
+				segment{server, "\x01\x01"},
+			),
+			expected: &types.SOCKS{Version: 5, AuthMethod: "USERNAME/PASSWORD", User: "alice", Password: "wrong", AuthFailed: true},
+		},
+		{
+			name: "SOCKS5 no acceptable methods",
+			data: conversation(
+				segment{client, "\x05\x01\x01"},
+				segment{server, "\x05\xff"},
+			),
+			expected: &types.SOCKS{Version: 5, AuthMethod: "NO ACCEPTABLE METHODS"},
+		},
+		{
+			name: "SOCKS5 truncated request",
+			data: conversation(
+				segment{client, "\x05\x01\x00"},
+				segment{server, "\x05\x00"},
+				segment{client, "\x05\x01\x00\x03\xffexample.com"},
+			),
+			expected: &types.SOCKS{Version: 5, AuthMethod: "NO AUTHENTICATION REQUIRED"},
+		},
+		{
+			name: "HTTP",
+			data: conversation(
+				segment{client, "GET / HTTP/1.1\r\n\r\n"},
+				segment{server, "HTTP/1.1 200 OK\r\n\r\n"},
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		r := decode(tt.data)
+
+		if tt.expected == nil {
+			if r != nil {
+				t.Errorf("%s: expected no record, got %v", tt.name, r)
+			}
+
+			continue
+		}
+
+		if r == nil {
+			t.Errorf("%s: expected a record", tt.name)
+
+			continue
+		}
+
+		// the test data has no capture info
+		r.Timestamp = 0
+
+		if r.String() != tt.expected.String() {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, r)
+		}
+	}
+}
+
+func TestCanDecode(t *testing.T) {
+	tests := []struct {
+		client, server string
+		ok             bool
+	}{
+		{"\x04\x01\x00\x50\x7f\x00\x00\x01\x00", "\x00\x5a\x00\x00\x00\x00\x00\x00", true},
+		{"\x04\x01\x00\x50\x7f\x00\x00\x01", "\x00\x5a\x00\x00\x00\x00\x00\x00", false},
+		{"\x05\x02\x00\x02", "\x05\x02", true},
+		{"\x05\x01\x00", "\x05\x02", false},
+		{"\x05\x00", "\x05\x00", false},
+		{"SSH-2.0-OpenSSH_8.4\r\n", "SSH-2.0-OpenSSH_8.4\r\n", false},
+	}
+
+	for _, tt := range tests {
+		if ok := Decoder.CanDecode([]byte(tt.client), []byte(tt.server)); ok != tt.ok {
+			t.Errorf("CanDecode(%q, %q) = %v, want %v", tt.client, tt.server, ok, tt.ok)
+		}
+	}
+}
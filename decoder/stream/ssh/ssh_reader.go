@@ -14,14 +14,11 @@
 package ssh
 
 import (
-	"bufio"
 	"bytes"
 	"crypto/md5"
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"regexp"
 	"strings"
 	"sync"
@@ -41,6 +38,20 @@ import (
  * SSH - The Secure Shell Protocol
  */
 
+const (
+	// maxPacketSize is the largest packet an implementation must be able to process, see RFC 4253 section 6.1.
+	maxPacketSize = 35000
+
+	// maxHandshakeSize limits the data collected per direction to the ident, lines sent before it and the KEXINIT.
+	maxHandshakeSize = 8192 + maxPacketSize
+)
+
+var (
+	errNoIdent           = errors.New("no SSH ident found")
+	errIncompleteKexInit = errors.New("incomplete KEXINIT")
+	errInvalidPacket     = errors.New("invalid SSH packet length")
+)
+
 type sshReader struct {
 	conversation *core.ConversationInfo
 
@@ -65,27 +76,10 @@ func (h *sshReader) Decode() {
 		return
 	}
 
-	var (
-		serverBuf bytes.Buffer
-		clientBuf bytes.Buffer
-	)
+	clientData, serverData := handshakes(h.conversation.Data)
 
-	for _, d := range h.conversation.Data {
-		if d.Direction() == reassembly.TCPDirClientToServer {
-			// 2255k bytes should be enough to capture ident (max 255 bytes) + kexInit (usually ~1200-1700 bytes)
-			if clientBuf.Len() < 2255 {
-				clientBuf.Write(d.Raw())
-			}
-		} else {
-			// 2255k bytes should be enough to capture ident (max 255 bytes) + kexInit (usually ~1200-1700 bytes)
-			if serverBuf.Len() < 2255 {
-				serverBuf.Write(d.Raw())
-			}
-		}
-	}
-
-	h.searchKexInit(bufio.NewReader(&clientBuf), reassembly.TCPDirClientToServer)
-	h.searchKexInit(bufio.NewReader(&serverBuf), reassembly.TCPDirServerToClient)
+	h.processHandshake(clientData, reassembly.TCPDirClientToServer)
+	h.processHandshake(serverData, reassembly.TCPDirServerToClient)
 
 	if len(h.software) == 0 {
 		return
@@ -122,165 +116,171 @@ func (h *sshReader) processSSHIdent(ident string, entity string) {
 					Service:    serviceSSH,
 					Flows:      []string{h.conversation.Ident},
 					Notes:      "SSH version: " + i.sshVersion + " OS: " + i.os,
-					SourceData: h.serverIdent,
+					SourceData: ident,
 				},
 			},
 		}, nil)
 	}
 }
 
-func (h *sshReader) searchKexInit(r *bufio.Reader, dir reassembly.TCPFlowDirection) {
-	if h.serverKexInit != nil && h.clientKexInit != nil {
-		return
-	}
-
-	data, err := ioutil.ReadAll(r)
-	if err != nil && !errors.Is(err, io.EOF) {
-		fmt.Println(err)
+// handshakes collects the beginning of the client and server streams,
+// which contains the ident and the KEXINIT that may be split over several segments.
+func handshakes(data core.DataFragments) (client, server []byte) {
+	var clientBuf, serverBuf bytes.Buffer
 
-		return
+	for _, d := range data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			if clientBuf.Len() < maxHandshakeSize {
+				clientBuf.Write(d.Raw())
+			}
+		} else {
+			if serverBuf.Len() < maxHandshakeSize {
+				serverBuf.Write(d.Raw())
+			}
+		}
 	}
-	// fmt.Println(dir, len(data), "\n", hex.Dump(data))
 
-	if len(data) == 0 {
-		return
-	}
+	return clientBuf.Bytes(), serverBuf.Bytes()
+}
 
-	// length of the ident if it was found
-	offset := 0
+// parseHandshake reads the protocol version exchange and the following KEXINIT message
+// from the beginning of a stream, see RFC 4253 sections 4.2 and 7.1.
+// The ident is returned even if the KEXINIT could not be parsed.
+func parseHandshake(data []byte) (ident string, init *KexInitMsg, err error) {
+	for ident == "" {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			return "", nil, errNoIdent
+		}
 
-	if h.clientIdent == "" || h.serverIdent == "" { // read the SSH ident from the buffer
-		var (
-			br       = bytes.NewReader(data)
-			b        byte
-			ident    []byte
-			lastByte byte
-		)
+		line := strings.TrimSpace(string(data[:end]))
+		data = data[end+1:]
 
-		for {
-			b, err = br.ReadByte()
-			if errors.Is(err, io.EOF) {
-				break
-			}
+		// the server may send other lines of data before the version string
+		if strings.HasPrefix(line, "SSH-") {
+			ident = line
+		}
+	}
 
-			if lastByte == 0x0d && b == 0x0a {
-				offset = len(ident) + 1
+	if len(data) < 5 {
+		return ident, nil, errIncompleteKexInit
+	}
 
-				break
-			}
+	var (
+		length  = int(binary.BigEndian.Uint32(data[:4]))
+		padding = int(data[4])
+	)
 
-			lastByte = b
-			ident = append(ident, b)
-		}
+	if length > maxPacketSize || padding+1 >= length {
+		return ident, nil, errInvalidPacket
+	}
 
-		if dir == reassembly.TCPDirClientToServer {
-			h.clientIdent = strings.TrimSpace(string(ident))
-			h.processSSHIdent(h.clientIdent, "client")
-		} else {
-			h.serverIdent = strings.TrimSpace(string(ident))
-			h.processSSHIdent(h.serverIdent, "server")
-		}
+	// the payload follows the length and padding length fields
+	end := 4 + length - padding
+	if len(data) < end {
+		return ident, nil, errIncompleteKexInit
 	}
 
-	// search the entire data fragment for the KexInit
-	for i, b := range data {
-		// 0x14 marks the beginning of the SSH KexInitMsg
-		if !(b == 0x14) {
-			continue
-		}
+	payload := data[5:end]
+	if payload[0] != msgKexInit {
+		return ident, nil, unexpectedMessageError(msgKexInit, payload[0])
+	}
 
-		// fmt.Println(dir, offset, len(data), i-1, "data[",offset,":",i-1,"]")
-		// fmt.Println(hex.Dump(data))
+	init = new(KexInitMsg)
 
-		// check if length would have correct length
-		if (i-1)-offset != 4 {
-			break
-		}
+	err = Unmarshal(payload, init)
+	if err != nil {
+		return ident, nil, err
+	}
 
-		// check if array access is safe
-		if offset > i-1 || len(data) <= i-1 {
-			break
-		}
+	return ident, init, nil
+}
 
-		length := int(binary.BigEndian.Uint32(data[offset : i-1]))
-		padding := int(data[i-1])
+func (h *sshReader) processHandshake(data []byte, dir reassembly.TCPFlowDirection) {
+	if len(data) == 0 {
+		return
+	}
 
-		if len(data) < i+length-padding-1 {
-			// fmt.Println("break: len(data) < i+length-padding-1")
-			break
+	ident, init, err := parseHandshake(data)
+
+	isClient := dir == reassembly.TCPDirClientToServer
+	if ident != "" {
+		if isClient {
+			h.clientIdent = ident
+			h.processSSHIdent(ident, "client")
+		} else {
+			h.serverIdent = ident
+			h.processSSHIdent(ident, "server")
 		}
+	}
 
-		// fmt.Println("padding", padding, "length", length)
-		// fmt.Println(hex.Dump(data[i:i+length-padding-1]))
+	if err != nil {
+		sshLog.Debug("failed to parse KEXINIT",
+			zap.String("ident", h.conversation.Ident),
+			zap.Bool("client", isClient),
+			zap.Error(err),
+		)
 
-		var init KexInitMsg
+		return
+	}
 
-		err = Unmarshal(data[i:i+length-padding-1], &init)
+	hash, raw := computeHASSH(init, isClient)
+
+	if isClient {
+		err = Decoder.Writer.Write(&types.SSH{
+			Timestamp:  h.conversation.FirstClientPacket.UnixNano(),
+			HASSH:      hash,
+			Flow:       h.conversation.Ident,
+			Ident:      h.clientIdent,
+			Algorithms: raw,
+			IsClient:   true,
+		})
 		if err != nil {
-			fmt.Println(err)
+			sshLog.Error("failed to flush ssh audit record", zap.Error(err))
 		}
 
-		// spew.Dump("found SSH KexInit", h.parent.ident, init)
-		hash, raw := computeHASSH(init)
-
-		if dir == reassembly.TCPDirClientToServer {
-			err = Decoder.Writer.Write(&types.SSH{
-				Timestamp:  h.conversation.FirstClientPacket.UnixNano(),
-				HASSH:      hash,
-				Flow:       h.conversation.Ident,
-				Ident:      h.clientIdent,
-				Algorithms: raw,
-				IsClient:   true,
-			})
-			if err != nil {
-				sshLog.Error("failed to flush ssh audit record", zap.Error(err))
-			}
+		atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
 
-			atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
-
-			h.clientKexInit = &init
-
-			sshLog.Info("found clientKexInit", zap.String("ident", h.conversation.Ident))
-		} else {
-			err = Decoder.Writer.Write(&types.SSH{
-				Timestamp:  h.conversation.FirstServerPacket.UnixNano(),
-				HASSH:      hash,
-				Flow:       utils.ReverseFlowIdent(h.conversation.Ident),
-				Ident:      h.serverIdent,
-				Algorithms: raw,
-				IsClient:   false,
-			})
-			if err != nil {
-				sshLog.Error("failed to flush ssh audit record", zap.Error(err))
-			}
+		h.clientKexInit = init
 
-			atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+		sshLog.Info("found clientKexInit", zap.String("ident", h.conversation.Ident))
+	} else {
+		err = Decoder.Writer.Write(&types.SSH{
+			Timestamp:  h.conversation.FirstServerPacket.UnixNano(),
+			HASSH:      hash,
+			Flow:       utils.ReverseFlowIdent(h.conversation.Ident),
+			Ident:      h.serverIdent,
+			Algorithms: raw,
+			IsClient:   false,
+		})
+		if err != nil {
+			sshLog.Error("failed to flush ssh audit record", zap.Error(err))
+		}
 
-			h.serverKexInit = &init
+		atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
 
-			sshLog.Info("found serverKexInit", zap.String("ident", h.conversation.Ident))
-		}
+		h.serverKexInit = init
 
-		// TODO fetch device profile
-		for _, soft := range software.HashDBMap[hash] {
-			sshVersion, product, version, os := parseSSHInfoFromHasshDB(soft.Version)
-
-			h.software = append(h.software, &types.Software{
-				Timestamp: h.conversation.FirstClientPacket.UnixNano(),
-				Product:   product,
-				Vendor:    "", // do not set the vendor for now
-				Version:   version,
-				// DeviceProfiles: []string{dpIdent},
-				SourceName: "HASSH Lookup",
-				SourceData: hash,
-				Service:    serviceSSH,
-				// DPIResults:     protos,
-				Flows: []string{h.conversation.Ident},
-				Notes: "Likelihood: " + soft.Likelihood + " Possible OS: " + os + "SSH Version: " + sshVersion,
-			})
-		}
+		sshLog.Info("found serverKexInit", zap.String("ident", h.conversation.Ident))
+	}
 
-		break
+	// TODO fetch device profile
+	for _, soft := range software.HashDBMap[hash] {
+		sshVersion, product, version, os := parseSSHInfoFromHasshDB(soft.Version)
+
+		h.software = append(h.software, &types.Software{
+			Timestamp: h.conversation.FirstClientPacket.UnixNano(),
+			Product:   product,
+			Vendor:    "", // do not set the vendor for now
+			Version:   version,
+			// DeviceProfiles: []string{dpIdent},
+			SourceName: "HASSH Lookup",
+			SourceData: hash,
+			Service:    serviceSSH,
+			// DPIResults:     protos,
+			Flows: []string{h.conversation.Ident},
+			Notes: "Likelihood: " + soft.Likelihood + " Possible OS: " + os + "SSH Version: " + sshVersion,
+		})
 	}
 }
 
@@ -332,18 +332,30 @@ func parseSSHIdent(ident string) *sshVersionInfo {
 	return nil
 }
 
-// HASSH SSH Fingerprint
+// computeHASSH returns the HASSH fingerprint of a client KEXINIT, or the HASSHServer fingerprint of a server KEXINIT,
+// along with the algorithm string it was computed from. Both use the algorithms for the direction the sender transmits in.
 // TODO: move this functionality into standalone package.
-func computeHASSH(init KexInitMsg) (hash string, raw string) {
-	var b strings.Builder
+func computeHASSH(init *KexInitMsg, client bool) (hash string, raw string) {
+	var (
+		b            strings.Builder
+		ciphers      = init.CiphersClientServer
+		macs         = init.MACsClientServer
+		compressions = init.CompressionClientServer
+	)
+
+	if !client {
+		ciphers = init.CiphersServerClient
+		macs = init.MACsServerClient
+		compressions = init.CompressionServerClient
+	}
 
 	b.WriteString(strings.Join(init.KexAlgos, ","))
 	b.WriteString(";")
-	b.WriteString(strings.Join(init.CiphersClientServer, ","))
+	b.WriteString(strings.Join(ciphers, ","))
 	b.WriteString(";")
-	b.WriteString(strings.Join(init.MACsClientServer, ","))
+	b.WriteString(strings.Join(macs, ","))
 	b.WriteString(";")
-	b.WriteString(strings.Join(init.CompressionClientServer, ","))
+	b.WriteString(strings.Join(compressions, ","))
 
 	return fmt.Sprintf("%x", md5.Sum([]byte(b.String()))), b.String()
 }
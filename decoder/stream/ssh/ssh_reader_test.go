@@ -14,8 +14,14 @@
 package ssh
 
 import (
+	"crypto/md5"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
 )
 
 func TestParseSSHInfoFromHasshDB(t *testing.T) {
@@ -99,3 +105,86 @@ func TestParseSSHKexInitMsgPadding(t *testing.T) {
 		t.Fatal("the value should be 6")
 	}
 }
+
+// kexInitPacket returns the KEXINIT wrapped into an unencrypted binary packet.
+func kexInitPacket(init *KexInitMsg) []byte {
+	var (
+		payload = Marshal(init)
+		padding = 8 - (len(payload)+5)%8
+	)
+
+	if padding < 4 {
+		padding += 8
+	}
+
+	packet := appendInt(nil, len(payload)+padding+1)
+	packet = append(packet, byte(padding))
+	packet = append(packet, payload...)
+
+	return append(packet, make([]byte, padding)...)
+}
+
+func TestParseHandshake(t *testing.T) {
+	init := &KexInitMsg{
+		KexAlgos:                []string{"curve25519-sha256", "diffie-hellman-group14-sha256"},
+		ServerHostKeyAlgos:      []string{"ssh-ed25519"},
+		CiphersClientServer:     []string{"chacha20-poly1305@openssh.com", "aes128-ctr"},
+		CiphersServerClient:     []string{"aes256-gcm@openssh.com"},
+		MACsClientServer:        []string{"hmac-sha2-256"},
+		MACsServerClient:        []string{"hmac-sha2-512"},
+		CompressionClientServer: []string{"none", "zlib@openssh.com"},
+		CompressionServerClient: []string{"none"},
+	}
+
+	var (
+		client = append([]byte("SSH-2.0-OpenSSH_8.2p1 Ubuntu-4ubuntu0.1\r\n"), kexInitPacket(init)...)
+		server = append([]byte("banner line\r\nSSH-2.0-dropbear_2019.78\r\n"), kexInitPacket(init)...)
+		data   = core.DataFragments{
+			// the KEXINIT is split over several segments of both directions
+			&core.StreamData{RawData: client[:30], Dir: reassembly.TCPDirClientToServer},
+			&core.StreamData{RawData: server[:60], Dir: reassembly.TCPDirServerToClient},
+			&core.StreamData{RawData: client[30:50], Dir: reassembly.TCPDirClientToServer},
+			&core.StreamData{RawData: client[50:], Dir: reassembly.TCPDirClientToServer},
+			&core.StreamData{RawData: server[60:], Dir: reassembly.TCPDirServerToClient},
+		}
+	)
+
+	clientData, serverData := handshakes(data)
+
+	ident, clientInit, err := parseHandshake(clientData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ident != "SSH-2.0-OpenSSH_8.2p1 Ubuntu-4ubuntu0.1" {
+		t.Fatal("unexpected client ident", ident)
+	}
+
+	hash, raw := computeHASSH(clientInit, true)
+	if raw != "curve25519-sha256,diffie-hellman-group14-sha256;chacha20-poly1305@openssh.com,aes128-ctr;hmac-sha2-256;none,zlib@openssh.com" {
+		t.Fatal("unexpected client algorithms", raw)
+	}
+
+	if hash != fmt.Sprintf("%x", md5.Sum([]byte(raw))) {
+		t.Fatal("unexpected HASSH", hash)
+	}
+
+	ident, serverInit, err := parseHandshake(serverData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ident != "SSH-2.0-dropbear_2019.78" {
+		t.Fatal("unexpected server ident", ident)
+	}
+
+	if _, raw = computeHASSH(serverInit, false); raw != "curve25519-sha256,diffie-hellman-group14-sha256;aes256-gcm@openssh.com;hmac-sha2-512;none" {
+		t.Fatal("unexpected server algorithms", raw)
+	}
+
+	// the ident is returned if the stream ends within the KEXINIT
+	ident, _, err = parseHandshake(clientData[:len(clientData)-100])
+	if !errors.Is(err, errIncompleteKexInit) || ident == "" {
+		t.Fatal("expected ident and incomplete KEXINIT, got", ident, err)
+	}
+}
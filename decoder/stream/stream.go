@@ -8,10 +8,27 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dreadl0ck/netcap/decoder/stream/amqp"
+	"github.com/dreadl0ck/netcap/decoder/stream/bittorrent"
+	"github.com/dreadl0ck/netcap/decoder/stream/dns"
+	"github.com/dreadl0ck/netcap/decoder/stream/ftp"
 	"github.com/dreadl0ck/netcap/decoder/stream/http"
+	"github.com/dreadl0ck/netcap/decoder/stream/imap"
+	"github.com/dreadl0ck/netcap/decoder/stream/ldap"
+	"github.com/dreadl0ck/netcap/decoder/stream/memcached"
+	"github.com/dreadl0ck/netcap/decoder/stream/mongodb"
+	"github.com/dreadl0ck/netcap/decoder/stream/mysql"
 	"github.com/dreadl0ck/netcap/decoder/stream/pop3"
+	"github.com/dreadl0ck/netcap/decoder/stream/postgres"
+	"github.com/dreadl0ck/netcap/decoder/stream/redis"
+	"github.com/dreadl0ck/netcap/decoder/stream/smb"
 	"github.com/dreadl0ck/netcap/decoder/stream/smtp"
+	"github.com/dreadl0ck/netcap/decoder/stream/socks"
 	"github.com/dreadl0ck/netcap/decoder/stream/ssh"
+	"github.com/dreadl0ck/netcap/decoder/stream/telnet"
+	"github.com/dreadl0ck/netcap/decoder/stream/tls"
+	"github.com/dreadl0ck/netcap/decoder/stream/tns"
+	"github.com/dreadl0ck/netcap/decoder/stream/wireguard"
 
 	"github.com/mgutz/ansi"
 	"github.com/pkg/errors"
@@ -33,10 +50,27 @@ var Debug bool
 // DefaultStreamDecoders contains stream decoders mapped to their protocols default port
 // int32 is used to avoid casting when looking up values
 var DefaultStreamDecoders = map[int32]core.StreamDecoderAPI{
-	80:  http.Decoder,
-	110: pop3.Decoder,
-	22:  ssh.Decoder,
-	25:  smtp.Decoder,
+	21:    ftp.Decoder,
+	53:    dns.Decoder,
+	80:    http.Decoder,
+	110:   pop3.Decoder,
+	143:   imap.Decoder,
+	389:   ldap.Decoder,
+	22:    ssh.Decoder,
+	23:    telnet.Decoder,
+	25:    smtp.Decoder,
+	443:   tls.Decoder,
+	445:   smb.Decoder,
+	11211: memcached.Decoder,
+	1080:  socks.Decoder,
+	1521:  tns.Decoder,
+	3306:  mysql.Decoder,
+	5432:  postgres.Decoder,
+	5672:  amqp.Decoder,
+	6379:  redis.Decoder,
+	6881:  bittorrent.Decoder,
+	27017: mongodb.Decoder,
+	51820: wireguard.Decoder,
 } // contains all available stream decoders
 
 // package level init.
@@ -98,6 +132,11 @@ func InitDecoders(c *config.Config) (decoders []core.StreamDecoderAPI, err error
 
 				// add to include map
 				inMap[name] = true
+
+				// abstract decoders only produce records if the stream decoders feeding them are running
+				for _, feeder := range abstractDecoderStreamDecoders[name] {
+					inMap[feeder] = true
+				}
 			}
 		}
 
@@ -131,6 +170,18 @@ func InitDecoders(c *config.Config) (decoders []core.StreamDecoderAPI, err error
 		}
 	}
 
+	// load the payload signatures used for protocol detection
+	err = initProtocolSignatures(c.ProtocolSignatures)
+	if err != nil {
+		return nil, err
+	}
+
+	// load the mapping of DPI results to stream decoders
+	err = initDPIProtocols(c.DPIProtocols)
+	if err != nil {
+		return nil, err
+	}
+
 	var (
 		wg sync.WaitGroup
 		mu sync.Mutex
@@ -146,34 +197,48 @@ func InitDecoders(c *config.Config) (decoders []core.StreamDecoderAPI, err error
 
 		go func(dec core.StreamDecoderAPI) {
 			w := netio.NewAuditRecordWriter(&netio.WriterConfig{
-				CSV:     c.CSV,
-				Encode:  c.Encode,
-				Label:   c.Label,
-				Proto:   c.Proto,
-				JSON:    c.JSON,
-				Name:    dec.GetName(),
-				Type:    dec.GetType(),
-				Null:    c.Null,
-				Elastic: c.Elastic,
+				CSV:          c.CSV,
+				CSVDelimiter: c.CSVDelimiter,
+				Encode:       c.Encode,
+				Label:        c.Label,
+				Proto:        c.Proto,
+				JSON:         c.JSON,
+				CBOR:         c.CBOR,
+				Avro:         c.Avro,
+				Name:         dec.GetName(),
+				Type:         dec.GetType(),
+				Null:         c.Null,
+				Elastic:      c.Elastic,
+				WebSocket:    c.WebSocket,
+				Syslog:       c.Syslog,
+				SyslogConfig: c.SyslogConfig,
+				Kafka:        c.Kafka,
+				KafkaConfig:  c.KafkaConfig,
 				ElasticConfig: netio.ElasticConfig{
-					ElasticAddrs:   c.ElasticAddrs,
-					ElasticUser:    c.ElasticUser,
-					ElasticPass:    c.ElasticPass,
-					KibanaEndpoint: c.KibanaEndpoint,
-					BulkSize:       c.BulkSizeCustom,
+					ElasticAddrs:         c.ElasticAddrs,
+					ElasticUser:          c.ElasticUser,
+					ElasticPass:          c.ElasticPass,
+					KibanaEndpoint:       c.KibanaEndpoint,
+					BulkSize:             c.BulkSizeCustom,
+					ElasticIndex:         c.ElasticIndex,
+					ElasticFlushInterval: c.ElasticFlushInterval,
+					ElasticMaxRetries:    c.ElasticMaxRetries,
 				},
 				Buffer:               c.Buffer,
 				Compress:             c.Compression,
 				Out:                  c.Out,
+				OutDirs:              c.OutDirs,
 				Chan:                 c.Chan,
 				ChanSize:             c.ChanSize,
 				MemBufferSize:        c.MemBufferSize,
+				MaxFileSize:          c.MaxFileSize,
 				Source:               c.Source,
 				Version:              netcap.Version,
 				IncludesPayloads:     c.IncludePayloads,
 				StartTime:            time.Now(),
 				CompressionBlockSize: c.CompressionBlockSize,
 				CompressionLevel:     c.CompressionLevel,
+				CompressionFormat:    c.CompressionFormat,
 			})
 			dec.SetWriter(w)
 
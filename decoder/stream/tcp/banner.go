@@ -65,7 +65,7 @@ func saveTCPServiceBanner(s streamReader) {
 	service.Store.Unlock()
 
 	// nope. lets create a new one
-	serv := service.NewService(s.FirstPacket().UnixNano(), s.NumBytes(), s.Client().NumBytes(), s.Network().Dst().String())
+	serv := service.NewService(s.FirstPacket().UnixNano(), s.NumBytes(), s.Client().NumBytes(), s.Network().Dst().String(), s.ServerMAC())
 	serv.Banner = string(banner)
 	serv.IP = s.Network().Dst().String()
 	serv.Port = utils.DecodePort(s.Transport().Dst().Raw())
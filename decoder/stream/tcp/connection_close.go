@@ -0,0 +1,52 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// closeReason classifies how the connection ended.
+// The reason passed to ReassemblyComplete only refers to the last closed half of the connection,
+// so the FIN and RST flags seen for any of the directions take precedence.
+func (t *tcpConnection) closeReason(reason string) types.CloseReason {
+	switch {
+	case t.rstSeen:
+		return types.CloseReason_CloseReset
+	case t.endSeen || reason == reassembly.ReasonEndSignal:
+		return types.CloseReason_CloseGraceful
+	default:
+		// flushed due to inactivity or when closing all connections
+		return types.CloseReason_CloseTimeout
+	}
+}
+
+// addCloseReason counts the close reason and stores it for the Connection audit record.
+func (t *tcpConnection) addCloseReason(reason types.CloseReason) {
+	streamutils.Stats.Lock()
+	switch reason {
+	case types.CloseReason_CloseGraceful:
+		streamutils.Stats.GracefulTCPConns++
+	case types.CloseReason_CloseReset:
+		streamutils.Stats.ResetTCPConns++
+	default:
+		streamutils.Stats.TimedOutTCPConns++
+	}
+	streamutils.Stats.Unlock()
+
+	decoderutils.ConnectionInfos.SetCloseReason(t.net, t.transport, reason)
+}
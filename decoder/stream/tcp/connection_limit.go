@@ -0,0 +1,94 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+)
+
+// connectionLimitPoll is the interval for checking whether a connection has been closed,
+// while waiting for a free slot.
+const connectionLimitPoll = 5 * time.Millisecond
+
+// connectionLimitWait is set to 1 if packets of new connections may wait for a free slot.
+// Waiting only helps when other workers can close connections in the meantime,
+// when reading a file or with a single worker, the packet would be dropped after the full wait anyway.
+var connectionLimitWait int32
+
+// EnableConnectionLimitWait allows waiting for ConnectionLimitWait once the connection limit has been reached.
+// The collector enables it only for live captures with more than one worker.
+func EnableConnectionLimitWait(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+
+	atomic.StoreInt32(&connectionLimitWait, v)
+}
+
+// admitConnection decides whether a TCP packet is passed to the assembler, once MaxConcurrentConnections has been reached.
+// Packets of connections that are already tracked are always admitted. For new connections,
+// it waits up to ConnectionLimitWait for other connections to be closed if waiting has been enabled,
+// and drops the packet afterwards.
+func admitConnection(netFlow gopacket.Flow, tcp *layers.TCP) bool {
+	limit := int64(decoderconfig.Instance.MaxConcurrentConnections)
+	if limit <= 0 || StreamFactory.numOpenConnections() < limit {
+		return true
+	}
+
+	tcpFlow := tcp.TransportFlow()
+	if StreamFactory.StreamPool.Contains(netFlow, tcpFlow) {
+		return true
+	}
+
+	if atomic.LoadInt32(&connectionLimitWait) == 1 {
+		deadline := time.Now().Add(decoderconfig.Instance.ConnectionLimitWait)
+
+		for time.Now().Before(deadline) {
+			time.Sleep(connectionLimitPoll)
+
+			if StreamFactory.numOpenConnections() < limit {
+				return true
+			}
+		}
+	}
+
+	streamutils.Stats.Lock()
+	streamutils.Stats.DroppedConnPackets++
+
+	// connection attempts are counted by their SYN packet, so each one is only counted once
+	if tcp.SYN && !tcp.ACK {
+		streamutils.Stats.DroppedConnections++
+	}
+	streamutils.Stats.Unlock()
+
+	return false
+}
+
+// numOpenConnections returns the number of connections that have not been closed yet.
+func (factory *connectionFactory) numOpenConnections() int64 {
+	return atomic.LoadInt64(&factory.numOpen)
+}
+
+// connectionClosed frees the slot of a connection that has been closed.
+func (factory *connectionFactory) connectionClosed() {
+	atomic.AddInt64(&factory.numOpen, -1)
+}
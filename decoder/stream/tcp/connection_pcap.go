@@ -0,0 +1,162 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+	"github.com/dreadl0ck/gopacket/pcapgo"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/utils"
+)
+
+// connectionPcapDir is the directory in the output folder that holds the pcap files of the connections.
+const connectionPcapDir = "pcaps"
+
+// capturedPacket is an original packet of a connection as read from the capture.
+type capturedPacket struct {
+	ci   gopacket.CaptureInfo
+	data []byte
+}
+
+// RecordPacket keeps a copy of the original packet bytes from the assembler context,
+// so the connection can be written to its own pcap file once it has been closed.
+// It implements the reassembly.PacketRecorder interface.
+func (t *tcpConnection) RecordPacket(ac reassembly.AssemblerContext, _ reassembly.TCPFlowDirection) {
+	c, ok := ac.(*context)
+	if !ok || len(c.Data) == 0 {
+		return
+	}
+
+	// stop recording once the buffer limit for the connection would be exceeded
+	if max := decoderconfig.Instance.MaxConversationBytes; max > 0 && t.packetBytes+len(c.Data) > max {
+		return
+	}
+
+	if len(t.packets) == 0 {
+		t.linkType = c.LinkType
+	}
+
+	// the packet data can be reused by the packet source, so copy it
+	data := make([]byte, len(c.Data))
+	copy(data, c.Data)
+
+	ci := gopacket.CaptureInfo{
+		Timestamp:      c.CaptureInfo.Timestamp,
+		CaptureLength:  len(data),
+		Length:         c.CaptureInfo.Length,
+		InterfaceIndex: c.CaptureInfo.InterfaceIndex,
+	}
+
+	if ci.Length < ci.CaptureLength {
+		ci.Length = ci.CaptureLength
+	}
+
+	t.packets = append(t.packets, capturedPacket{ci: ci, data: data})
+	t.packetBytes += len(data)
+}
+
+// writePcap writes the recorded packets of the connection to a pcap file named after the connection ident.
+func (t *tcpConnection) writePcap() error {
+	if len(t.packets) == 0 {
+		return nil
+	}
+
+	// release the packets once they have been written
+	defer func() {
+		t.packets = nil
+		t.packetBytes = 0
+	}()
+
+	root := filepath.Join(decoderconfig.Instance.Out, connectionPcapDir)
+
+	err := os.MkdirAll(root, defaults.DirectoryPermission)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(root, filepath.Base(utils.CleanIdent(t.ident))+".pcap"))
+	if err != nil {
+		return err
+	}
+
+	var (
+		w       = bufio.NewWriter(f)
+		pw      = pcapgo.NewWriter(w)
+		snapLen = defaults.SnapLen
+	)
+
+	for _, p := range t.packets {
+		if p.ci.CaptureLength > snapLen {
+			snapLen = p.ci.CaptureLength
+		}
+	}
+
+	err = pw.WriteFileHeader(uint32(snapLen), t.linkType)
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	for _, p := range t.packets {
+		err = pw.WritePacket(p.ci, p.data)
+		if err != nil {
+			_ = f.Close()
+
+			return err
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// linkType returns the link type of the first layer of a packet, as needed for the pcap file header.
+func linkType(p gopacket.Packet) layers.LinkType {
+	l := p.Layers()
+	if len(l) == 0 {
+		return layers.LinkTypeEthernet
+	}
+
+	switch l[0].LayerType() {
+	case layers.LayerTypeIPv4, layers.LayerTypeIPv6:
+		return layers.LinkTypeRaw
+	case layers.LayerTypeLoopback:
+		return layers.LinkTypeNull
+	case layers.LayerTypeLinuxSLL:
+		return layers.LinkTypeLinuxSLL
+	case layers.LayerTypeRadioTap:
+		return layers.LinkTypeIEEE80211Radio
+	case layers.LayerTypeDot11:
+		return layers.LinkTypeIEEE802_11
+	case layers.LayerTypePPP:
+		return layers.LinkTypePPP
+	default:
+		return layers.LinkTypeEthernet
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+	"github.com/dreadl0ck/gopacket/pcapgo"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+func TestWritePcap(t *testing.T) {
+	out, err := ioutil.TempDir("", "netcap-conn-pcap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+
+	decoderconfig.Instance = &decoderconfig.Config{Out: out, MaxConversationBytes: 10}
+
+	var (
+		ts   = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		conn = &tcpConnection{ident: "192.168.1.1:4242->10.0.0.1:80"}
+	)
+
+	for i, data := range [][]byte{{1, 2, 3}, {4, 5, 6, 7}, {8, 9, 10, 11, 12}, nil} {
+		conn.RecordPacket(&context{
+			CaptureInfo: gopacket.CaptureInfo{Timestamp: ts.Add(time.Duration(i) * time.Second), Length: len(data)},
+			Data:        data,
+			LinkType:    layers.LinkTypeRaw,
+		}, reassembly.TCPDirClientToServer)
+	}
+
+	// the third packet exceeds the buffer limit and the last one has no data
+	if len(conn.packets) != 2 {
+		t.Fatal("expected 2 recorded packets, got", len(conn.packets))
+	}
+
+	if err = conn.writePcap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.packets != nil {
+		t.Fatal("packets must be released after writing")
+	}
+
+	f, err := os.Open(filepath.Join(out, connectionPcapDir, "192.168.1.1-4242--10.0.0.1-80.pcap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := pcapgo.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.LinkType() != layers.LinkTypeRaw {
+		t.Fatal("unexpected link type", r.LinkType())
+	}
+
+	for i, expected := range [][]byte{{1, 2, 3}, {4, 5, 6, 7}} {
+		data, ci, errRead := r.ReadPacketData()
+		if errRead != nil {
+			t.Fatal(errRead)
+		}
+
+		if !bytes.Equal(data, expected) || !ci.Timestamp.Equal(ts.Add(time.Duration(i)*time.Second)) {
+			t.Fatal("unexpected packet", i, data, ci.Timestamp)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"github.com/dreadl0ck/tlsx"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+)
+
+// length of the TLS record header: content type, version and length.
+const tlsRecordHeaderSize = 5
+
+// addSNI stores the server name from the ClientHello of the client for the Connection audit record.
+func (t *tcpConnection) addSNI() {
+	if sni := clientHelloSNI(t.client.DataSlice()); sni != "" {
+		decoderutils.ConnectionInfos.SetSNI(t.net, t.transport, sni)
+	}
+}
+
+// clientHelloSNI returns the server name from a TLS ClientHello at the start of the client data.
+// If the handshake record is not complete in the first segment, the data of the second segment is appended.
+func clientHelloSNI(client core.DataFragments) string {
+	var data []byte
+
+	for _, d := range client {
+		raw := d.Raw()
+
+		// fragments can be empty, e.g. after removing a PROXY protocol header
+		if len(raw) == 0 {
+			continue
+		}
+
+		if data != nil {
+			data = append(append(make([]byte, 0, len(data)+len(raw)), data...), raw...)
+
+			break
+		}
+
+		if !isClientHelloRecord(raw) {
+			return ""
+		}
+
+		data = raw
+		if len(data) >= tlsRecordSize(data) {
+			break
+		}
+	}
+
+	if data == nil {
+		return ""
+	}
+
+	// remove the records that follow the ClientHello
+	if size := tlsRecordSize(data); len(data) > size {
+		data = data[:size]
+	}
+
+	var ch tlsx.ClientHelloBasic
+	if err := ch.Unmarshal(data); err != nil {
+		return ""
+	}
+
+	return ch.SNI
+}
+
+// isClientHelloRecord checks if the data starts with a TLS handshake record containing a ClientHello.
+func isClientHelloRecord(data []byte) bool {
+	return len(data) > tlsRecordHeaderSize && data[0] == 0x16 && data[1] == 0x03 && data[tlsRecordHeaderSize] == 0x01
+}
+
+// tlsRecordSize returns the size of the TLS record at the start of data, including the header.
+func tlsRecordSize(data []byte) int {
+	return tlsRecordHeaderSize + (int(data[3])<<8 | int(data[4]))
+}
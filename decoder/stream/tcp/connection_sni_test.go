@@ -0,0 +1,74 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+)
+
+// clientHello returns the first flight of a TLS client for the given server name.
+func clientHello(t *testing.T, serverName string) []byte {
+	t.Helper()
+
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		_ = tls.Client(client, &tls.Config{ServerName: serverName}).Handshake()
+	}()
+
+	buf := make([]byte, 4096)
+
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = client.Close()
+
+	return buf[:n]
+}
+
+func TestClientHelloSNI(t *testing.T) {
+	hello := clientHello(t, "example.com")
+
+	fragments := func(chunks ...[]byte) (d core.DataFragments) {
+		for _, c := range chunks {
+			d = append(d, &core.StreamData{RawData: c})
+		}
+
+		return d
+	}
+
+	for name, c := range map[string]struct {
+		data     core.DataFragments
+		expected string
+	}{
+		"single segment": {fragments(hello), "example.com"},
+		"split":          {fragments(hello[:20], hello[20:]), "example.com"},
+		"trailing data":  {fragments(append(append([]byte(nil), hello...), 0x17, 0x03, 0x03, 0x00, 0x01, 0x00)), "example.com"},
+		"proxy header":   {fragments([]byte{}, hello), "example.com"},
+		"plaintext":      {fragments([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")), ""},
+		"incomplete":     {fragments(hello[:20], hello[20:40], hello[40:]), ""},
+		"empty":          {nil, ""},
+	} {
+		if sni := clientHelloSNI(c.data); sni != c.expected {
+			t.Errorf("%s: expected %q, got %q", name, c.expected, sni)
+		}
+	}
+}
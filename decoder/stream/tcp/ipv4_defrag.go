@@ -0,0 +1,400 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/stream/alert"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// defragPolicy determines which data is used when IPv4 fragments overlap.
+// Different operating systems resolve overlaps differently, which can be abused to evade detection,
+// by sending overlapping fragments that are reassembled differently by the monitoring system and the target.
+type defragPolicy int
+
+// reassembly policies for overlapping fragments, as described by Novak in "Target-Based Fragmentation Reassembly".
+const (
+	// data from the fragment that was received first is kept
+	defragPolicyFirst defragPolicy = iota
+	// data from the fragment that was received last is used
+	defragPolicyLast
+	// data from the original fragment is kept, unless the new fragment starts at a lower offset
+	defragPolicyBSD
+	// like BSD, but new fragments starting at the same offset replace the original data
+	defragPolicyLinux
+)
+
+var defragPolicies = map[string]defragPolicy{
+	"first": defragPolicyFirst,
+	"last":  defragPolicyLast,
+	"bsd":   defragPolicyBSD,
+	"linux": defragPolicyLinux,
+}
+
+// errInvalidDefragPolicy occurs when an unknown reassembly policy for IPv4 fragments has been configured.
+var errInvalidDefragPolicy = errors.New("invalid ipv4 defragmentation policy")
+
+const (
+	// fragments of incomplete datagrams are discarded after this time,
+	// the default timeout for the linux kernel is 30 seconds.
+	fragmentTimeout = 30 * time.Second
+
+	// limits the number of fragments stored for a single datagram.
+	maxFragmentsPerDatagram = 8192
+
+	// maximum size of an IPv4 datagram.
+	maxIPv4Size = 65535
+)
+
+// fragmentKey identifies the fragments of a datagram.
+type fragmentKey struct {
+	flow     gopacket.Flow
+	id       uint16
+	protocol layers.IPProtocol
+}
+
+// fragment is the payload of a single fragment and its position in the datagram.
+type fragment struct {
+	offset int
+	data   []byte
+}
+
+// end returns the offset of the first byte after the fragment.
+func (f *fragment) end() int {
+	return f.offset + len(f.data)
+}
+
+// datagram collects the fragments of a datagram in the order they have been received.
+type datagram struct {
+	fragments []*fragment
+	total     int // size of the datagram payload, known once the last fragment has been received
+	lastSeen  time.Time
+}
+
+// fragmentOverlap describes the overlapping fragments seen for a single fragment.
+type fragmentOverlap struct {
+	// number of previously received fragments the new fragment overlaps with
+	overlaps int
+	// number of overlapping fragments with differing data in the overlapping region
+	conflicts int
+}
+
+// ipv4Defragmenter reassembles IPv4 fragments according to the configured policy.
+type ipv4Defragmenter struct {
+	sync.Mutex
+	policy    defragPolicy
+	datagrams map[fragmentKey]*datagram
+}
+
+// newIPv4Defragmenter returns a new defragmenter that uses the linux reassembly policy.
+func newIPv4Defragmenter() *ipv4Defragmenter {
+	return &ipv4Defragmenter{
+		policy:    defragPolicyLinux,
+		datagrams: make(map[fragmentKey]*datagram),
+	}
+}
+
+// setPolicy sets the reassembly policy by name.
+func (d *ipv4Defragmenter) setPolicy(name string) error {
+	p, ok := defragPolicies[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", errInvalidDefragPolicy, name)
+	}
+
+	d.Lock()
+	d.policy = p
+	d.Unlock()
+
+	return nil
+}
+
+// defrag takes an IPv4 packet and returns it unmodified if it is not a fragment.
+// For fragments, nil is returned until all fragments of the datagram have been received,
+// then a new IPv4 layer with the reassembled payload is returned.
+// Overlaps of the fragment with previously received fragments of the same datagram are reported.
+func (d *ipv4Defragmenter) defrag(in *layers.IPv4, t time.Time) (*layers.IPv4, fragmentOverlap, error) {
+	var overlap fragmentOverlap
+
+	// not fragmented
+	if in.Flags&layers.IPv4MoreFragments == 0 && in.FragOffset == 0 {
+		return in, overlap, nil
+	}
+
+	size := int(in.Length) - int(in.IHL)*4
+	if size > len(in.Payload) {
+		size = len(in.Payload)
+	}
+
+	f := &fragment{
+		offset: int(in.FragOffset) * 8,
+		data:   in.Payload[:size],
+	}
+
+	if size <= 0 {
+		return nil, overlap, errors.New("defrag: empty fragment")
+	}
+
+	if f.end() > maxIPv4Size {
+		return nil, overlap, fmt.Errorf("defrag: fragment exceeds maximum datagram size: %d", f.end())
+	}
+
+	key := fragmentKey{
+		flow:     in.NetworkFlow(),
+		id:       in.Id,
+		protocol: in.Protocol,
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	dg, ok := d.datagrams[key]
+	if !ok {
+		dg = new(datagram)
+		d.datagrams[key] = dg
+	}
+
+	dg.lastSeen = t
+
+	// check for overlaps with previously received fragments
+	for _, e := range dg.fragments {
+		start, end := maxInt(e.offset, f.offset), minInt(e.end(), f.end())
+		if start >= end {
+			continue
+		}
+
+		overlap.overlaps++
+
+		if !bytes.Equal(e.data[start-e.offset:end-e.offset], f.data[start-f.offset:end-f.offset]) {
+			overlap.conflicts++
+		}
+	}
+
+	// copy the payload, it might be reused by the packet source
+	f.data = append([]byte(nil), f.data...)
+	dg.fragments = append(dg.fragments, f)
+
+	if in.Flags&layers.IPv4MoreFragments == 0 {
+		dg.total = f.end()
+	}
+
+	if len(dg.fragments) > maxFragmentsPerDatagram {
+		delete(d.datagrams, key)
+
+		return nil, overlap, fmt.Errorf("defrag: more than %d fragments for datagram %d", maxFragmentsPerDatagram, in.Id)
+	}
+
+	payload := dg.build(d.policy)
+	if payload == nil {
+		return nil, overlap, nil
+	}
+
+	delete(d.datagrams, key)
+
+	out := &layers.IPv4{
+		Version:    in.Version,
+		IHL:        in.IHL,
+		TOS:        in.TOS,
+		Length:     uint16(int(in.IHL)*4 + len(payload)),
+		Id:         in.Id,
+		Flags:      0,
+		FragOffset: 0,
+		TTL:        in.TTL,
+		Protocol:   in.Protocol,
+		SrcIP:      in.SrcIP,
+		DstIP:      in.DstIP,
+		Options:    in.Options,
+		Padding:    in.Padding,
+	}
+	out.Payload = payload
+
+	return out, overlap, nil
+}
+
+// defragment reassembles fragmented IPv4 packets, if enabled in the configuration.
+// gopacket decodes fragments as gopacket.LayerTypeFragment, so they do not have a transport layer.
+// Once a datagram is complete, its payload is decoded into the packet and the transport layer becomes available.
+// False is returned if the packet is a fragment of an incomplete datagram, or could not be reassembled.
+func defragment(packet gopacket.Packet) bool {
+	ip4Layer := packet.Layer(layers.LayerTypeIPv4)
+	if ip4Layer == nil || !decoderconfig.Instance.DefragIPv4 {
+		return true
+	}
+
+	var (
+		ip4                  = ip4Layer.(*layers.IPv4)
+		newip4, overlap, err = StreamFactory.defragger.defrag(ip4, packet.Metadata().Timestamp)
+	)
+
+	if overlap.overlaps > 0 {
+		streamutils.Stats.Lock()
+		streamutils.Stats.IPFragmentOverlaps += int64(overlap.overlaps)
+		streamutils.Stats.IPFragmentConflicts += int64(overlap.conflicts)
+		streamutils.Stats.Unlock()
+
+		if overlap.conflicts > 0 {
+			writeFragmentOverlapAlert(ip4, packet.Metadata().Timestamp, overlap)
+		}
+	}
+
+	if err != nil {
+		reassemblyLog.Error("error while de-fragmenting", zap.Error(err))
+
+		return false
+	} else if newip4 == nil {
+		reassemblyLog.Debug("fragment received...")
+
+		return false
+	}
+
+	if newip4 == ip4 {
+		return true
+	}
+
+	streamutils.Stats.Lock()
+	streamutils.Stats.IPdefrag++
+	streamutils.Stats.Unlock()
+
+	reassemblyLog.Debug("decoding re-assembled packet", zap.String("layer", newip4.NextLayerType().String()))
+
+	pb, ok := packet.(gopacket.PacketBuilder)
+	if !ok {
+		panic("Not a PacketBuilder")
+	}
+
+	// the decoded layers are added to the packet, after the fragment layer of the final fragment
+	if err = newip4.NextLayerType().Decode(newip4.Payload, pb); err != nil {
+		reassemblyLog.Error("failed to decode re-assembled packet", zap.Error(err))
+
+		return false
+	}
+
+	return true
+}
+
+// build returns the reassembled payload, or nil if the datagram is not yet complete.
+// Overlapping data is resolved according to the policy.
+func (dg *datagram) build(policy defragPolicy) []byte {
+	// the last fragment is still missing
+	if dg.total == 0 {
+		return nil
+	}
+
+	var (
+		payload = make([]byte, dg.total)
+		// index of the fragment that provided each byte of the payload, -1 for missing data
+		owner   = make([]int, dg.total)
+		covered int
+	)
+
+	for i := range owner {
+		owner[i] = -1
+	}
+
+	for i, f := range dg.fragments {
+		for pos := f.offset; pos < f.end() && pos < dg.total; pos++ {
+			if o := owner[pos]; o == -1 {
+				covered++
+			} else if !policy.replaces(f, dg.fragments[o]) {
+				continue
+			}
+
+			owner[pos] = i
+			payload[pos] = f.data[pos-f.offset]
+		}
+	}
+
+	if covered != dg.total {
+		return nil
+	}
+
+	return payload
+}
+
+// replaces checks whether data from the new fragment replaces the data from the original fragment for overlapping bytes.
+func (p defragPolicy) replaces(n, orig *fragment) bool {
+	switch p {
+	case defragPolicyLast:
+		return true
+	case defragPolicyBSD:
+		return n.offset < orig.offset
+	case defragPolicyLinux:
+		return n.offset <= orig.offset
+	default:
+		return false
+	}
+}
+
+// discardOlderThan removes the fragments of all incomplete datagrams that have not been updated since t
+// and returns the number of discarded datagrams.
+func (d *ipv4Defragmenter) discardOlderThan(t time.Time) (n int) {
+	d.Lock()
+	defer d.Unlock()
+
+	for k, dg := range d.datagrams {
+		if dg.lastSeen.Before(t) {
+			delete(d.datagrams, k)
+			n++
+		}
+	}
+
+	return n
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// writeFragmentOverlapAlert emits an alert for fragments that overlap previously received data with different content.
+// This is a strong indicator for an attempt to evade detection by exploiting differences in reassembly policies.
+func writeFragmentOverlapAlert(ip4 *layers.IPv4, ts time.Time, overlap fragmentOverlap) {
+	if alert.Decoder.Writer == nil {
+		return
+	}
+
+	alert.WriteAlert(&types.Alert{
+		Timestamp: ts.UnixNano(),
+		Name:      "IPv4FragmentOverlap",
+		Description: fmt.Sprintf(
+			"fragment of datagram %d overlaps %d previous fragment(s), %d with conflicting data (policy: %s)",
+			ip4.Id, overlap.overlaps, overlap.conflicts, decoderconfig.Instance.DefragPolicy,
+		),
+		SrcIP:    ip4.SrcIP.String(),
+		DstIP:    ip4.DstIP.String(),
+		Protocol: ip4.Protocol.String(),
+	})
+}
@@ -0,0 +1,288 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+func newFragment(offset uint16, more bool, data string) *layers.IPv4 {
+	ip := &layers.IPv4{
+		Version:    4,
+		IHL:        5,
+		Length:     uint16(20 + len(data)),
+		Id:         42,
+		FragOffset: offset,
+		TTL:        64,
+		Protocol:   layers.IPProtocolUDP,
+		SrcIP:      net.IP{10, 0, 0, 1},
+		DstIP:      net.IP{10, 0, 0, 2},
+	}
+	if more {
+		ip.Flags = layers.IPv4MoreFragments
+	}
+	ip.Payload = []byte(data)
+
+	return ip
+}
+
+func TestDefragPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   string
+	}{
+		{"first", "AAAAAAAABBBBBBBBCCCCCCCC"},
+		{"last", "AAAAAAAAXXXXXXXXYYYYYYYY"},
+		{"bsd", "AAAAAAAABBBBBBBBCCCCCCCC"},
+		{"linux", "AAAAAAAAXXXXXXXXYYYYYYYY"},
+	}
+
+	for _, tt := range tests {
+		d := newIPv4Defragmenter()
+		if err := d.setPolicy(tt.policy); err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Now()
+
+		// second fragment, covering bytes 8-24
+		out, overlap, err := d.defrag(newFragment(1, true, "BBBBBBBBCCCCCCCC"), now)
+		if err != nil || out != nil || overlap.overlaps != 0 {
+			t.Fatal(tt.policy, "unexpected result for first fragment:", out, overlap, err)
+		}
+
+		// last fragment, overlapping the first one at the same offset
+		out, overlap, err = d.defrag(newFragment(1, false, "XXXXXXXXYYYYYYYY"), now)
+		if err != nil || out != nil {
+			t.Fatal(tt.policy, "unexpected result for second fragment:", out, err)
+		}
+
+		if overlap.overlaps != 1 || overlap.conflicts != 1 {
+			t.Fatal(tt.policy, "expected a conflicting overlap, got", overlap)
+		}
+
+		// first fragment completes the datagram
+		out, overlap, err = d.defrag(newFragment(0, true, "AAAAAAAA"), now)
+		if err != nil || out == nil {
+			t.Fatal(tt.policy, "expected reassembled datagram:", err)
+		}
+
+		if overlap.overlaps != 0 {
+			t.Fatal(tt.policy, "unexpected overlap", overlap)
+		}
+
+		if got := string(out.Payload); got != tt.want {
+			t.Fatal(tt.policy, "expected", tt.want, "got", got)
+		}
+
+		if out.Length != uint16(20+len(tt.want)) {
+			t.Fatal(tt.policy, "unexpected length", out.Length)
+		}
+	}
+}
+
+func TestDefragPolicyLowerOffset(t *testing.T) {
+	// a new fragment starting at a lower offset replaces the original data for the bsd policy
+	d := newIPv4Defragmenter()
+	if err := d.setPolicy("bsd"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+
+	if _, _, err := d.defrag(newFragment(1, false, "BBBBBBBB"), now); err != nil {
+		t.Fatal(err)
+	}
+
+	out, overlap, err := d.defrag(newFragment(0, true, "AAAAAAAAXXXXXXXX"), now)
+	if err != nil || out == nil {
+		t.Fatal("expected reassembled datagram:", err)
+	}
+
+	if overlap.conflicts != 1 {
+		t.Fatal("expected a conflicting overlap, got", overlap)
+	}
+
+	if got := string(out.Payload); got != "AAAAAAAAXXXXXXXX" {
+		t.Fatal("unexpected payload", got)
+	}
+}
+
+func TestDefragInvalidPolicy(t *testing.T) {
+	if err := newIPv4Defragmenter().setPolicy("windows"); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
+
+func TestDefragDiscard(t *testing.T) {
+	d := newIPv4Defragmenter()
+	now := time.Now()
+
+	if _, _, err := d.defrag(newFragment(0, true, "AAAAAAAA"), now); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := d.discardOlderThan(now.Add(fragmentTimeout)); n != 1 {
+		t.Fatal("expected one discarded datagram, got", n)
+	}
+
+	if len(d.datagrams) != 0 {
+		t.Fatal("expected no remaining datagrams")
+	}
+}
+
+// recordingStream collects the reassembled data of a connection.
+type recordingStream struct {
+	data []byte
+}
+
+func (s *recordingStream) Accept(*layers.TCP, gopacket.CaptureInfo, reassembly.TCPFlowDirection, reassembly.Sequence) bool {
+	return true
+}
+
+func (s *recordingStream) ReassembledSG(sg reassembly.ScatterGather, _ reassembly.AssemblerContext) {
+	length, _ := sg.Lengths()
+	s.data = append(s.data, sg.Fetch(length)...)
+}
+
+func (s *recordingStream) ReassemblyComplete(reassembly.AssemblerContext, gopacket.Flow, string) bool {
+	return false
+}
+
+type recordingStreamFactory struct {
+	stream *recordingStream
+}
+
+func (f *recordingStreamFactory) New(gopacket.Flow, gopacket.Flow, reassembly.AssemblerContext) reassembly.Stream {
+	return f.stream
+}
+
+// tcpPacket serializes a TCP segment and splits the IPv4 payload into fragments of the given size.
+func tcpPacket(t *testing.T, tcp *layers.TCP, payload string, fragmentSize int, ts time.Time) []gopacket.Packet {
+	t.Helper()
+
+	var (
+		eth = &layers.Ethernet{
+			SrcMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 5},
+			DstMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 6},
+			EthernetType: layers.EthernetTypeIPv4,
+		}
+		ip = &layers.IPv4{
+			Version:  4,
+			IHL:      5,
+			Id:       7,
+			TTL:      64,
+			Protocol: layers.IPProtocolTCP,
+			SrcIP:    net.IP{10, 0, 0, 1},
+			DstIP:    net.IP{10, 0, 0, 2},
+		}
+		opts = gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+		buf  = gopacket.NewSerializeBuffer()
+	)
+
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gopacket.SerializeLayers(buf, opts, tcp, gopacket.Payload(payload)); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		segment = buf.Bytes()
+		packets []gopacket.Packet
+	)
+
+	if fragmentSize <= 0 {
+		fragmentSize = len(segment)
+	}
+
+	for offset := 0; offset < len(segment); offset += fragmentSize {
+		end := offset + fragmentSize
+		if end > len(segment) {
+			end = len(segment)
+		}
+
+		frag := *ip
+		frag.FragOffset = uint16(offset / 8)
+
+		if end < len(segment) {
+			frag.Flags = layers.IPv4MoreFragments
+		}
+
+		out := gopacket.NewSerializeBuffer()
+		if err := gopacket.SerializeLayers(out, opts, eth, &frag, gopacket.Payload(segment[offset:end])); err != nil {
+			t.Fatal(err)
+		}
+
+		p := gopacket.NewPacket(out.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+		p.Metadata().Timestamp = ts
+		p.Metadata().CaptureLength = len(out.Bytes())
+		p.Metadata().Length = len(out.Bytes())
+
+		packets = append(packets, p)
+	}
+
+	return packets
+}
+
+func TestReassembleFragmentedPacket(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{DefragIPv4: true}
+
+	var (
+		stream    = new(recordingStream)
+		assembler = reassembly.NewAssembler(reassembly.NewStreamPool(&recordingStreamFactory{stream: stream}))
+		ts        = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		payload   = "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	)
+
+	syn := tcpPacket(t, &layers.TCP{SrcPort: 4242, DstPort: 80, Seq: 100, SYN: true, Window: 1024}, "", 0, ts)
+	if len(syn) != 1 {
+		t.Fatal("expected a single packet for the SYN")
+	}
+
+	// the TCP header and the payload are spread across the fragments,
+	// only the first one contains the TCP header
+	fragments := tcpPacket(t, &layers.TCP{SrcPort: 4242, DstPort: 80, Seq: 101, ACK: true, PSH: true, Window: 1024}, payload, 16, ts)
+	if len(fragments) < 3 {
+		t.Fatal("expected multiple fragments, got", len(fragments))
+	}
+
+	for _, f := range fragments {
+		if f.Layer(layers.LayerTypeTCP) != nil {
+			t.Fatal("fragments must not be decoded as TCP")
+		}
+	}
+
+	ReassemblePacket(syn[0], assembler)
+
+	// deliver the fragments out of order
+	ReassemblePacket(fragments[len(fragments)-1], assembler)
+
+	for _, f := range fragments[:len(fragments)-1] {
+		ReassemblePacket(f, assembler)
+	}
+
+	if string(stream.data) != payload {
+		t.Fatalf("expected reassembled stream %q, got %q", payload, stream.data)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"math"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+)
+
+// addPayloadEntropy stores the entropy of the conversation for the Connection audit record.
+func (t *tcpConnection) addPayloadEntropy() {
+	if len(t.merged) == 0 {
+		return
+	}
+
+	decoderutils.ConnectionInfos.SetPayloadEntropy(t.net, t.transport, conversationEntropy(t.merged))
+}
+
+// conversationEntropy calculates the Shannon entropy over the payload of both directions,
+// in a single pass over the fragments without merging their data.
+func conversationEntropy(conversation core.DataFragments) (entropy float64) {
+	var (
+		counts [256]int
+		total  int
+	)
+
+	for _, d := range conversation {
+		data := d.Raw()
+		total += len(data)
+
+		for _, b := range data {
+			counts[b]++
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(total)
+			entropy -= p * math.Log2(p)
+		}
+	}
+
+	return entropy
+}
@@ -0,0 +1,78 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"net"
+
+	"go.uber.org/zap"
+
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+	"github.com/dreadl0ck/netcap/utils"
+)
+
+// trustedProxies contains the proxies whose PROXY protocol headers are stripped,
+// nil if the PROXY protocol is disabled.
+var trustedProxies *decoderutils.ProxyAllowList
+
+// SetProxyProtocol enables stripping PROXY protocol headers from the connections of the trusted proxies,
+// a comma separated list of addresses, CIDRs and ports of the backend services.
+// Since the header allows a client to claim any address, it is only accepted from the configured proxies.
+func SetProxyProtocol(enable bool, trusted string) error {
+	if !enable {
+		trustedProxies = nil
+
+		return nil
+	}
+
+	l, err := decoderutils.ParseProxyAllowList(trusted)
+	if err != nil {
+		return err
+	}
+
+	trustedProxies = l
+
+	return nil
+}
+
+// stripProxyHeader checks if a trusted load balancer prepended a PROXY protocol header to the client data,
+// and removes it, so the stream decoders only see the actual protocol data.
+// The original addresses are stored for the Connection audit record.
+func (t *tcpConnection) stripProxyHeader() {
+	if trustedProxies == nil || !trustedProxies.Trusted(
+		net.IP(t.client.Network().Src().Raw()),
+		utils.DecodePort(t.client.Transport().Dst().Raw()),
+	) {
+		return
+	}
+
+	client := t.client.DataSlice()
+
+	h, size, ok := decoderutils.ParseProxyHeader(client.Head(decoderutils.MaxProxyHeaderSize))
+	if !ok {
+		return
+	}
+
+	client.TrimStart(size)
+
+	t.proxyHeader = h
+	decoderutils.ConnectionInfos.SetProxyHeader(t.client.Network(), t.client.Transport(), h)
+
+	reassemblyLog.Debug("stripped PROXY protocol header",
+		zap.String("ident", t.ident),
+		zap.Int32("version", h.Version),
+		zap.String("src", h.SrcIP),
+		zap.String("dst", h.DstIP),
+	)
+}
@@ -35,6 +35,10 @@ type streamReader interface {
 	// DataChan returns a channel for sending stream data.
 	DataChan() chan *core.StreamData
 
+	// Feed passes data to the stream, either via the data channel
+	// or directly if no goroutine has been started to read the stream.
+	Feed(data *core.StreamData)
+
 	// DataSlice will return all gathered data fragments.
 	DataSlice() core.DataFragments
 
@@ -86,6 +90,9 @@ type streamReader interface {
 	// ServiceIdent will return the identifier of the service (serverIP:serverPort).
 	ServiceIdent() string
 
+	// ServerMAC returns the hardware address of the server, if the packets had a link layer.
+	ServerMAC() string
+
 	// SortAndMergeFragments sorts all stream fragments based on their timestamp
 	// and generate the conversation buffers.
 	SortAndMergeFragments()
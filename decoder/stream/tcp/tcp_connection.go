@@ -19,6 +19,7 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"runtime"
 	"runtime/pprof"
 	"sort"
 	"strconv"
@@ -34,11 +35,15 @@ import (
 	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
 	"github.com/dreadl0ck/netcap/decoder/core"
 	"github.com/dreadl0ck/netcap/decoder/stream"
+	"github.com/dreadl0ck/netcap/decoder/stream/secrets"
 	"github.com/dreadl0ck/netcap/decoder/stream/udp"
+	"github.com/dreadl0ck/netcap/decoder/stream/tunnel"
 	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
 	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/dpi"
 	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
 	"github.com/dreadl0ck/netcap/utils"
 )
 
@@ -57,6 +62,15 @@ func NumSavedTCPConns() int64 {
 	return streamutils.Stats.SavedTCPConnections
 }
 
+// ReassemblyStats returns a copy of the stream reassembly counters,
+// e.g. for exporting them from applications that embed netcap.
+func ReassemblyStats() streamutils.Statistics {
+	streamutils.Stats.Lock()
+	defer streamutils.Stats.Unlock()
+
+	return streamutils.Stats.Statistics
+}
+
 /*
  * TCP Connection
  */
@@ -83,11 +97,76 @@ type tcpConnection struct {
 
 	wasMerged bool
 	fsmerr    bool
+
+	// set once a FIN or RST packet has been seen for either direction
+	endSeen bool
+
+	// set once a RST packet has been seen for either direction
+	rstSeen bool
+
+	// set once the data channels of the stream readers have been closed
+	readersClosed bool
+
+	// timestamp of the last accepted packet
+	lastPacket time.Time
+
+	// timestamp of the last packet before the connection was closed,
+	// used to ignore segments arriving after the grace period
+	closedAt time.Time
+
+	// original addresses announced by a load balancer, nil if no PROXY protocol header was sent
+	proxyHeader *decoderutils.ProxyHeader
+
+	// destination hardware address of the first packet, empty if there was no link layer
+	serverMAC string
+
+	// number of bytes buffered for both directions
+	numBytes int
+
+	// set once data has been dropped because the buffered bytes reached the MaxConversationBytes limit
+	truncated bool
+
+	// set once a fragment with missing bytes before it has been kept, only used with WriteIncomplete
+	incomplete bool
+
+	// original packets of the connection and their total size, only used with DumpConnectionPcaps
+	packets     []capturedPacket
+	packetBytes int
+	linkType    layers.LinkType
 }
 
 // Accept decides whether the TCP packet should be accepted
 // start could be modified to force a start even if no SYN have been seen.
-func (t *tcpConnection) Accept(tcp *layers.TCP, dir reassembly.TCPFlowDirection, nextSeq reassembly.Sequence) bool {
+func (t *tcpConnection) Accept(tcp *layers.TCP, ci gopacket.CaptureInfo, dir reassembly.TCPFlowDirection, nextSeq reassembly.Sequence) bool {
+	// ignore stragglers like late retransmissions or delayed ACKs,
+	// that arrive long after the connection has been closed and saved.
+	if t.isStraggler(ci.Timestamp) {
+		reassemblyLog.Debug("packet rejected after close", zap.String("ident", t.ident), zap.Time("closedAt", t.closedAt))
+		streamutils.Stats.Lock()
+		streamutils.Stats.RejectClosed++
+		streamutils.Stats.Unlock()
+
+		return false
+	}
+
+	if ci.Timestamp.After(t.lastPacket) {
+		t.lastPacket = ci.Timestamp
+	}
+
+	if tcp.FIN || tcp.RST {
+		t.endSeen = true
+	}
+
+	if tcp.RST {
+		t.rstSeen = true
+	}
+
+	if n := sackBlocks(tcp); n > 0 {
+		streamutils.Stats.Lock()
+		streamutils.Stats.SACKBlocks += int64(n)
+		streamutils.Stats.Unlock()
+	}
+
 	// Finite State Machine
 	if !t.tcpstate.CheckState(tcp, dir) {
 
@@ -146,6 +225,18 @@ func (t *tcpConnection) Accept(tcp *layers.TCP, dir reassembly.TCPFlowDirection,
 	return accept
 }
 
+// sackBlocks returns the number of selective acknowledgement blocks in the options of the segment.
+// Each block consists of a 4 byte left edge and a 4 byte right edge.
+func sackBlocks(tcp *layers.TCP) (n int) {
+	for _, o := range tcp.Options {
+		if o.OptionType == layers.TCPOptionKindSACK {
+			n += len(o.OptionData) / 8
+		}
+	}
+
+	return n
+}
+
 func (t *tcpConnection) updateStats(sg reassembly.ScatterGather, skip int, length int, saved int, start bool, end bool, dir reassembly.TCPFlowDirection) {
 	sgStats := sg.Stats()
 
@@ -179,6 +270,7 @@ func (t *tcpConnection) updateStats(sg reassembly.ScatterGather, skip int, lengt
 
 	streamutils.Stats.OverlapBytes += int64(sgStats.OverlapBytes)
 	streamutils.Stats.OverlapPackets += int64(sgStats.OverlapPackets)
+	streamutils.Stats.RetransmittedSegments += int64(sgStats.RetransmittedSegments)
 	streamutils.Stats.Unlock()
 
 	var ident string
@@ -202,10 +294,21 @@ func (t *tcpConnection) updateStats(sg reassembly.ScatterGather, skip int, lengt
 	)
 }
 
-func (t *tcpConnection) feedData(dir reassembly.TCPFlowDirection, data []byte, ac reassembly.AssemblerContext) {
+// missing is the number of bytes lost before the data, -1 if unknown.
+func (t *tcpConnection) feedData(dir reassembly.TCPFlowDirection, data []byte, ac reassembly.AssemblerContext, missing int) {
 	// fmt.Println(t.ident, "feedData", ansi.White, dir, ansi.Cyan, len(data), ansi.Yellow, ac.GetCaptureInfo().Timestamp.Format("2006-02-01 15:04:05.000000"), ansi.Reset)
 	// fmt.Println(hex.Dump(data))
 
+	// the stream readers have been shut down already
+	if t.readersClosed {
+		return
+	}
+
+	// stop buffering once the connection reached the size limit
+	if t.bufferLimitReached(len(data)) {
+		return
+	}
+
 	// Copy the data before passing it to the handler
 	// Because the passed in buffer can be reused as soon as the ReassembledSG function returned
 	dataCpy := make([]byte, len(data))
@@ -219,22 +322,72 @@ func (t *tcpConnection) feedData(dir reassembly.TCPFlowDirection, data []byte, a
 
 	// pass data either to client or server
 	if dir == reassembly.TCPDirClientToServer {
-		t.client.DataChan() <- &core.StreamData{
+		t.client.Feed(&core.StreamData{
 			RawData:          dataCpy,
 			AssemblerContext: ac,
 			Dir:              dir,
-		}
+			MissingBytes:     missing,
+		})
 	} else {
-		t.server.DataChan() <- &core.StreamData{
+		t.server.Feed(&core.StreamData{
 			RawData:          dataCpy,
 			AssemblerContext: ac,
 			Dir:              dir,
-		}
+			MissingBytes:     missing,
+		})
 	}
 
 	tcpStreamFeedDataTime.WithLabelValues(dir.String()).Set(float64(time.Since(ti).Nanoseconds()))
 }
 
+// bufferLimitReached accounts for n bytes of new data and checks whether buffering them
+// would exceed the MaxConversationBytes limit. Once the limit has been reached,
+// the connection is marked as truncated and all further data is dropped.
+func (t *tcpConnection) bufferLimitReached(n int) bool {
+	limit := decoderconfig.Instance.MaxConversationBytes
+	if limit <= 0 {
+		return false
+	}
+
+	if t.truncated {
+		return true
+	}
+
+	if t.numBytes+n > limit {
+		t.truncated = true
+
+		streamutils.Stats.Lock()
+		streamutils.Stats.TruncatedTCPConns++
+		streamutils.Stats.Unlock()
+
+		reassemblyLog.Debug("connection reached the buffer limit, dropping further data",
+			zap.String("ident", t.ident),
+			zap.Int("bufferedBytes", t.numBytes),
+			zap.Int("limit", limit),
+		)
+
+		return true
+	}
+
+	t.numBytes += n
+
+	return false
+}
+
+// markIncomplete flags the connection as having gaps, the first time it is called for a connection.
+func (t *tcpConnection) markIncomplete() {
+	if t.incomplete {
+		return
+	}
+
+	t.incomplete = true
+	decoderutils.ConnectionInfos.SetIncomplete(t.net, t.transport)
+
+	streamutils.Stats.Lock()
+	streamutils.Stats.IncompleteTCPConns++
+	streamutils.Stats.Unlock()
+}
+
 // ReassembledSG is called zero or more times and delivers the data for a stream
 // The ScatterGather buffer is reused after each Reassembled call
 // so it's important to copy anything you need out of it (or use KeepFrom()).
@@ -245,11 +398,23 @@ func (t *tcpConnection) ReassembledSG(sg reassembly.ScatterGather, ac reassembly
 	// update stats
 	t.updateStats(sg, skip, length, saved, startTime, end, dir)
 
+	if decoderconfig.Instance.ConnGaps {
+		decoderutils.ConnectionInfos.AddGap(t.net, t.transport, length, skip)
+	}
+
+	var missing int
+
 	if skip == -1 && decoderconfig.Instance.AllowMissingInit {
 		// this is allowed
 	} else if skip != 0 {
-		// Missing bytes in stream: do not even try to parse it
-		return
+		// Missing bytes in stream: do not even try to parse it,
+		// unless incomplete connections should be kept on a best effort basis
+		if !decoderconfig.Instance.WriteIncomplete {
+			return
+		}
+
+		missing = skip
+		t.markIncomplete()
 	}
 
 	data := sg.Fetch(length)
@@ -263,7 +428,7 @@ func (t *tcpConnection) ReassembledSG(sg reassembly.ScatterGather, ac reassembly
 			)
 		}
 
-		t.feedData(dir, data, ac)
+		t.feedData(dir, data, ac, missing)
 	}
 }
 
@@ -323,6 +488,7 @@ func (t *tcpConnection) ReassemblyComplete(ac reassembly.AssemblerContext, first
 		zap.Bool("clientSaved:", t.client.Saved()),
 		zap.Bool("serverIsNil", t.server == nil),
 		zap.Bool("serverSaved:", t.server.Saved()),
+		zap.Bool("truncated", t.truncated),
 	)
 
 	ti := time.Now()
@@ -331,19 +497,44 @@ func (t *tcpConnection) ReassemblyComplete(ac reassembly.AssemblerContext, first
 	if t.server != nil && !t.client.Saved() {
 		t.client.MarkSaved()
 
+		// streams without a FIN or RST are either truncated or have been abandoned
+		closeReason := t.closeReason(reason)
+		closed := closeReason != types.CloseReason_CloseTimeout
+
+		t.addCloseReason(closeReason)
+
 		t.sortAndMergeFragments()
 
+		// remove a PROXY protocol header from the start of the client data
+		t.stripProxyHeader()
+
+		if decoderconfig.Instance.ConnEntropy {
+			t.addPayloadEntropy()
+		}
+
+		if decoderconfig.Instance.ConnSNI {
+			t.addSNI()
+		}
+
 		// save the full conversation to disk if enabled
 		err := streamutils.SaveConversation("TCP", t.merged, t.client.Ident(), t.client.FirstPacket(), t.client.Transport())
 		if err != nil {
 			reassemblyLog.Error("failed to save stream", zap.Error(err), zap.String("ident", t.client.Ident()))
 		}
+
+		// search the conversation for cleartext secrets if enabled
+		secrets.Scan(t.merged, t.client.Ident(), t.client.FirstPacket(), t.client.Network(), t.client.Transport())
+
 		tcpStreamProcessingTime.WithLabelValues(reassembly.TCPDirClientToServer.String()).Set(float64(time.Since(ti).Nanoseconds()))
 
 		// decode the actual conversation.
 		// this needs to be invoked only once, and since ReassemblyComplete is invoked for each side of the connection
 		// decode should be called either when processing the client or the server stream
-		t.decode()
+		if closed || !decoderconfig.Instance.IgnoreUnclosedStreams {
+			t.decode()
+		} else {
+			reassemblyLog.Debug("ignoring stream without FIN or RST", zap.String("ident", t.ident), zap.String("reason", reason))
+		}
 	}
 
 	if t.server != nil && !t.server.Saved() {
@@ -356,6 +547,24 @@ func (t *tcpConnection) ReassemblyComplete(ac reassembly.AssemblerContext, first
 		tcpStreamProcessingTime.WithLabelValues(reassembly.TCPDirServerToClient.String()).Set(float64(time.Since(ti).Nanoseconds()))
 	}
 
+	// both sides have been processed, stop the stream reader goroutines
+	if t.server != nil && t.client.Saved() && t.server.Saved() && !t.readersClosed {
+		t.readersClosed = true
+		t.closedAt = t.lastPacket
+
+		close(t.client.DataChan())
+		close(t.server.DataChan())
+
+		StreamFactory.connectionClosed()
+
+		if decoderconfig.Instance.DumpConnectionPcaps {
+			err := t.writePcap()
+			if err != nil {
+				reassemblyLog.Error("failed to write connection pcap", zap.Error(err), zap.String("ident", t.ident))
+			}
+		}
+	}
+
 	reassemblyLog.Debug("stream closed",
 		zap.String("ident", t.ident),
 	)
@@ -364,6 +573,13 @@ func (t *tcpConnection) ReassemblyComplete(ac reassembly.AssemblerContext, first
 	return decoderconfig.Instance.RemoveClosedStreams
 }
 
+// isStraggler checks whether a segment arrived after the grace period for the closed connection has elapsed.
+func (t *tcpConnection) isStraggler(ts time.Time) bool {
+	return !t.closedAt.IsZero() &&
+		decoderconfig.Instance.ClosedGracePeriod > 0 &&
+		ts.Sub(t.closedAt) > decoderconfig.Instance.ClosedGracePeriod
+}
+
 func (t *tcpConnection) decode() {
 
 	t.Lock()
@@ -384,10 +600,37 @@ func (t *tcpConnection) decode() {
 		ServerIP:          t.client.Network().Dst().String(),
 		ClientPort:        utils.DecodePort(t.client.Transport().Src().Raw()),
 		ServerPort:        utils.DecodePort(t.client.Transport().Dst().Raw()),
+		Incomplete:        t.incomplete,
+	}
+
+	// attribute the conversation to the original client, when forwarded by a load balancer
+	if t.proxyHeader != nil && t.proxyHeader.SrcIP != "" {
+		conv.ClientIP = t.proxyHeader.SrcIP
+		conv.ClientPort = t.proxyHeader.SrcPort
+	}
+
+	// check the configured payload signatures against the start of the conversation,
+	// inspecting more than the first segment catches messages that have been split
+	cHead, sHead := cr, sr
+	if size := decoderconfig.Instance.SignatureSize; size > 0 {
+		cHead, sHead = t.client.DataSlice().Head(size), t.server.DataSlice().Head(size)
+	}
+
+	if sd := stream.MatchSignatures(cHead, sHead, core.TCP); sd != nil {
+		t.decoder = sd.GetReaderFactory().New(conv)
+		found = true
+	}
+
+	// use the protocols identified by DPI for the connection, to handle protocols on non-standard ports
+	if protocols := dpi.ConsumeFlowProtocols(t.client.Network(), t.client.Transport()); !found && len(protocols) > 0 {
+		if sd := stream.MatchDPIProtocols(protocols, core.TCP); sd != nil {
+			t.decoder = sd.GetReaderFactory().New(conv)
+			found = true
+		}
 	}
 
-	// make a good first guess based on the destination port of the connection
-	if sd, exists := stream.DefaultStreamDecoders[utils.DecodePort(t.server.Transport().Dst().Raw())]; exists {
+	// if no signature matched, make a good first guess based on the destination port of the connection
+	if sd, exists := stream.DefaultStreamDecoders[utils.DecodePort(t.server.Transport().Dst().Raw())]; !found && exists {
 		if sd.Transport() == core.TCP || sd.Transport() == core.All {
 			if sd.GetReaderFactory() != nil && sd.CanDecodeStream(cr, sr) {
 				t.decoder = sd.GetReaderFactory().New(conv)
@@ -424,6 +667,18 @@ var aMu sync.Mutex
 
 // ReassemblePacket takes care of submitting a TCP / UDP packet to the reassembly.
 func ReassemblePacket(packet gopacket.Packet, assembler *reassembly.Assembler) {
+	original := packet
+
+	// strip the headers of GRE and VXLAN tunnels, so the inner connection is reassembled
+	inner, tun := tunnel.Decapsulate(packet)
+	if inner != nil {
+		packet = inner
+	}
+
+	// fragments do not carry a transport layer, reassemble them first
+	if !defragment(packet) {
+		return
+	}
 
 	// TODO: make transport layer reassembler configurable
 	// prevent passing any non TCP packets in here
@@ -432,8 +687,12 @@ func ReassemblePacket(packet gopacket.Packet, assembler *reassembly.Assembler) {
 
 		// handle UDP stream reconstruction
 		udpLayer := packet.Layer(layers.LayerTypeUDP)
-		if udpLayer != nil {
-			udp.Streams.HandleUDP(packet, udpLayer)
+		if udpLayer != nil && udp.Streams.HandleUDP(packet, udpLayer) && tun != nil {
+			tun.Timestamp = packet.Metadata().Timestamp.UnixNano()
+			tun.Flow = utils.CreateFlowIdentFromLayerFlows(packet.NetworkLayer().NetworkFlow(), packet.TransportLayer().TransportFlow())
+			tun.Transport = "UDP"
+
+			tunnel.WriteTunnel(tun)
 		}
 
 		return
@@ -445,41 +704,6 @@ func ReassemblePacket(packet gopacket.Packet, assembler *reassembly.Assembler) {
 	streamutils.Stats.DataBytes += int64(len(packet.Data()))
 	streamutils.Stats.Unlock()
 
-	// defrag the IPv4 packet if desired
-	ip4Layer := packet.Layer(layers.LayerTypeIPv4)
-	if ip4Layer != nil && decoderconfig.Instance.DefragIPv4 {
-
-		var (
-			ip4         = ip4Layer.(*layers.IPv4)
-			l           = ip4.Length
-			newip4, err = StreamFactory.defragger.DefragIPv4(ip4)
-		)
-
-		if err != nil {
-			log.Fatalln("error while de-fragmenting", err)
-		} else if newip4 == nil {
-			reassemblyLog.Debug("fragment received...")
-
-			return
-		}
-
-		if newip4.Length != l {
-			streamutils.Stats.IPdefrag++
-
-			reassemblyLog.Debug("decoding re-assembled packet", zap.String("layer", newip4.NextLayerType().String()))
-
-			pb, ok := packet.(gopacket.PacketBuilder)
-			if !ok {
-				panic("Not a PacketBuilder")
-			}
-
-			nextDecoder := newip4.NextLayerType()
-			if err = nextDecoder.Decode(newip4.Payload, pb); err != nil {
-				fmt.Println("failed to decode ipv4:", err)
-			}
-		}
-	}
-
 	tcp := tcpLayer.(*layers.TCP)
 
 	if decoderconfig.Instance.Checksum {
@@ -489,42 +713,114 @@ func ReassemblePacket(packet gopacket.Packet, assembler *reassembly.Assembler) {
 		}
 	}
 
+	// apply backpressure once the limit for open connections has been reached
+	if !admitConnection(packet.NetworkLayer().NetworkFlow(), tcp) {
+		return
+	}
+
 	streamutils.Stats.Lock()
 	streamutils.Stats.Totalsz += int64(len(tcp.Payload))
 	streamutils.Stats.Unlock()
 
 	// for debugging:
 	// assembleWithContextTimeout(packet, assembler, tcp)
-	aMu.Lock()
-	assembler.AssembleWithContext(packet.NetworkLayer().NetworkFlow(), tcp, &context{
+	ctx := &context{
 		CaptureInfo: packet.Metadata().CaptureInfo,
-	})
+		Tunnel:      tun,
+	}
+
+	if ll := packet.LinkLayer(); ll != nil {
+		ctx.DstMAC = ll.LinkFlow().Dst().String()
+	}
+
+	if decoderconfig.Instance.DumpConnectionPcaps {
+		ctx.Data = original.Data()
+		ctx.LinkType = linkType(original)
+	}
+
+	aMu.Lock()
+	assembler.AssembleWithContext(packet.NetworkLayer().NetworkFlow(), tcp, ctx)
 	aMu.Unlock()
 
-	// TODO: refactor and use a ticker model in a goroutine, similar to progress reporting
+	// flush connections in interval
+	ref := packet.Metadata().CaptureInfo.Timestamp
+	if flushDue(ref) {
+		closeCutoff := ref.Add(-decoderconfig.Instance.CloseInactiveTimeOut)
+
+		aMu.Lock()
+		flushed, closed := assembler.FlushWithOptions(
+			reassembly.FlushOptions{
+				T:  ref.Add(-decoderconfig.Instance.ClosePendingTimeOut),
+				TC: closeCutoff,
+			},
+		)
+
+		// forget the DPI results of flows that have been inactive since the previous flush,
+		// their connections have been closed and decoded, or have never been reassembled.
+		expiredFlows := dpi.ExpireFlowProtocols(layers.LayerTypeTCP, lastCloseCutoff)
+		lastCloseCutoff = closeCutoff
+		aMu.Unlock()
+
+		// forget fragments of datagrams that will never be completed
+		discarded := StreamFactory.defragger.discardOlderThan(ref.Add(-fragmentTimeout))
+
+		numGoroutines := int64(runtime.NumGoroutine())
+
+		streamutils.Stats.Lock()
+		if numGoroutines > streamutils.Stats.PeakGoroutines {
+			streamutils.Stats.PeakGoroutines = numGoroutines
+		}
+		streamutils.Stats.Unlock()
+
+		reassemblyLog.Debug("forced flush",
+			zap.Int("flushed", flushed),
+			zap.Int("closed", closed),
+			zap.Int("discardedFragments", discarded),
+			zap.Int("expiredFlows", expiredFlows),
+			zap.Time("ref", ref),
+			zap.Int64("goroutines", numGoroutines),
+			zap.Int64("streamReaders", StreamFactory.numActiveReaders()),
+		)
+	}
+}
+
+var (
+	lastFlush   time.Time
+	lastFlushMu sync.Mutex
+
+	// close cutoff of the previous flush, guarded by aMu
+	lastCloseCutoff time.Time
+)
+
+// flushDue checks if the assembler should be flushed, either because FlushEvery packets have been processed,
+// or because more than FlushInterval has passed since the last flush.
+// The capture timestamp of the packet is used as reference, so replaying a pcap behaves like the live capture.
+func flushDue(ts time.Time) bool {
+	var due bool
+
 	if decoderconfig.Instance.FlushEvery > 0 {
 		streamutils.Stats.Lock()
-		doFlush := streamutils.Stats.Count%int64(decoderconfig.Instance.FlushEvery) == 0
+		due = streamutils.Stats.Count%int64(decoderconfig.Instance.FlushEvery) == 0
 		streamutils.Stats.Unlock()
+	}
 
-		// flush connections in interval
-		if doFlush {
-			ref := packet.Metadata().CaptureInfo.Timestamp
-			aMu.Lock()
-			flushed, closed := assembler.FlushWithOptions(
-				reassembly.FlushOptions{
-					T:  ref.Add(-decoderconfig.Instance.ClosePendingTimeOut),
-					TC: ref.Add(-decoderconfig.Instance.CloseInactiveTimeOut),
-				},
-			)
-			aMu.Unlock()
-			reassemblyLog.Debug("forced flush",
-				zap.Int("flushed", flushed),
-				zap.Int("closed", closed),
-				zap.Time("ref", ref),
-			)
-		}
+	if decoderconfig.Instance.FlushInterval <= 0 {
+		return due
 	}
+
+	lastFlushMu.Lock()
+	defer lastFlushMu.Unlock()
+
+	switch {
+	case lastFlush.IsZero():
+		// start counting from the first packet
+		lastFlush = ts
+	case due || ts.Sub(lastFlush) >= decoderconfig.Instance.FlushInterval:
+		lastFlush = ts
+		due = true
+	}
+
+	return due
 }
 
 // assembleWithContextTimeout is a function that times out with a log message after a specified interval
@@ -649,14 +945,22 @@ func CleanupReassembly(wait bool, assemblers []*reassembly.Assembler) {
 		// print configuration as table
 		tui.Table(reassemblyLogFileHandle, []string{"Reassembly Setting", "Value"}, [][]string{
 			{"FlushEvery", strconv.Itoa(decoderconfig.Instance.FlushEvery)},
+			{"FlushInterval", decoderconfig.Instance.FlushInterval.String()},
 			{"CloseInactiveTimeout", decoderconfig.Instance.CloseInactiveTimeOut.String()},
 			{"ClosePendingTimeout", decoderconfig.Instance.ClosePendingTimeOut.String()},
+			{"FlushCoalesceWindow", decoderconfig.Instance.FlushCoalesceWindow.String()},
+			{"ClosedGracePeriod", decoderconfig.Instance.ClosedGracePeriod.String()},
 			{"AllowMissingInit", strconv.FormatBool(decoderconfig.Instance.AllowMissingInit)},
 			{"IgnoreFsmErr", strconv.FormatBool(decoderconfig.Instance.IgnoreFSMerr)},
 			{"NoOptCheck", strconv.FormatBool(decoderconfig.Instance.NoOptCheck)},
 			{"Checksum", strconv.FormatBool(decoderconfig.Instance.Checksum)},
 			{"DefragIPv4", strconv.FormatBool(decoderconfig.Instance.DefragIPv4)},
+			{"DefragPolicy", decoderconfig.Instance.DefragPolicy},
 			{"WriteIncomplete", strconv.FormatBool(decoderconfig.Instance.WriteIncomplete)},
+			{"IgnoreUnclosedStreams", strconv.FormatBool(decoderconfig.Instance.IgnoreUnclosedStreams)},
+			{"MaxStreamReaders", strconv.Itoa(decoderconfig.Instance.MaxStreamReaders)},
+			{"MaxConversationBytes", strconv.Itoa(decoderconfig.Instance.MaxConversationBytes)},
+			{"UDPStreamTimeout", decoderconfig.Instance.UDPStreamTimeout.String()},
 		})
 
 		printProgress(1, 1)
@@ -666,6 +970,8 @@ func CleanupReassembly(wait bool, assemblers []*reassembly.Assembler) {
 		var rows [][]string
 		if decoderconfig.Instance.DefragIPv4 {
 			rows = append(rows, []string{"IPv4 defragmentation", strconv.FormatInt(streamutils.Stats.IPdefrag, 10)})
+			rows = append(rows, []string{"IPv4 fragment overlaps", strconv.FormatInt(streamutils.Stats.IPFragmentOverlaps, 10)})
+			rows = append(rows, []string{"IPv4 fragment overlaps with differing data", strconv.FormatInt(streamutils.Stats.IPFragmentConflicts, 10)})
 		}
 
 		rows = append(rows,
@@ -673,6 +979,7 @@ func CleanupReassembly(wait bool, assemblers []*reassembly.Assembler) {
 			[]string{"total packets", strconv.FormatInt(streamutils.Stats.Pkt, 10)},
 			[]string{"rejected FSM", strconv.FormatInt(streamutils.Stats.RejectFsm, 10)},
 			[]string{"rejected Options", strconv.FormatInt(streamutils.Stats.RejectOpt, 10)},
+			[]string{"rejected after close (stragglers)", strconv.FormatInt(streamutils.Stats.RejectClosed, 10)},
 			[]string{"reassembled bytes", strconv.FormatInt(streamutils.Stats.Sz, 10)},
 			[]string{"total TCP bytes", strconv.FormatInt(streamutils.Stats.Totalsz, 10)},
 			[]string{"connection rejected FSM", strconv.FormatInt(streamutils.Stats.RejectConnFsm, 10)},
@@ -683,8 +990,21 @@ func CleanupReassembly(wait bool, assemblers []*reassembly.Assembler) {
 			[]string{"biggest-chunk bytes", strconv.FormatInt(streamutils.Stats.BiggestChunkBytes, 10)},
 			[]string{"overlap packets", strconv.FormatInt(streamutils.Stats.OverlapPackets, 10)},
 			[]string{"overlap bytes", strconv.FormatInt(streamutils.Stats.OverlapBytes, 10)},
+			[]string{"retransmitted segments", strconv.FormatInt(streamutils.Stats.RetransmittedSegments, 10)},
+			[]string{"SACK blocks", strconv.FormatInt(streamutils.Stats.SACKBlocks, 10)},
 			[]string{"saved TCP connections", strconv.FormatInt(streamutils.Stats.SavedTCPConnections, 10)},
 			[]string{"saved UDP conversations", strconv.FormatInt(streamutils.Stats.SavedUDPConnections, 10)},
+			[]string{"expired UDP conversations (idle timeout)", strconv.FormatInt(streamutils.Stats.ExpiredUDPStreams, 10)},
+			[]string{"gracefully closed TCP connections (FIN)", strconv.FormatInt(streamutils.Stats.GracefulTCPConns, 10)},
+			[]string{"reset TCP connections (RST)", strconv.FormatInt(streamutils.Stats.ResetTCPConns, 10)},
+			[]string{"timed out TCP connections (no FIN or RST)", strconv.FormatInt(streamutils.Stats.TimedOutTCPConns, 10)},
+			[]string{"truncated TCP connections (buffer limit reached)", strconv.FormatInt(streamutils.Stats.TruncatedTCPConns, 10)},
+			[]string{"incomplete TCP connections (kept with missing bytes)", strconv.FormatInt(streamutils.Stats.IncompleteTCPConns, 10)},
+			[]string{"peak goroutines", strconv.FormatInt(streamutils.Stats.PeakGoroutines, 10)},
+			[]string{"peak stream reader goroutines", strconv.FormatInt(streamutils.Stats.PeakStreamReaders, 10)},
+			[]string{"streams read without goroutine (limit reached)", strconv.FormatInt(streamutils.Stats.InlineTCPStreams, 10)},
+			[]string{"dropped TCP connections (connection limit reached)", strconv.FormatInt(streamutils.Stats.DroppedConnections, 10)},
+			[]string{"dropped TCP packets (connection limit reached)", strconv.FormatInt(streamutils.Stats.DroppedConnPackets, 10)},
 			[]string{"numSoftware", strconv.FormatInt(streamutils.Stats.NumSoftware, 10)},
 			[]string{"numServices", strconv.FormatInt(streamutils.Stats.NumServices, 10)},
 		)
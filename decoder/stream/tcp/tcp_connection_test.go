@@ -0,0 +1,343 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	decoderutils "github.com/dreadl0ck/netcap/decoder/utils"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestIsStraggler(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{ClosedGracePeriod: time.Minute}
+
+	var (
+		closedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		conn     = &tcpConnection{}
+	)
+
+	if conn.isStraggler(closedAt.Add(time.Hour)) {
+		t.Fatal("open connection must not reject segments")
+	}
+
+	conn.closedAt = closedAt
+
+	if conn.isStraggler(closedAt.Add(30 * time.Second)) {
+		t.Fatal("segment within grace period must be accepted")
+	}
+
+	if !conn.isStraggler(closedAt.Add(2 * time.Minute)) {
+		t.Fatal("segment after grace period must be rejected")
+	}
+
+	decoderconfig.Instance.ClosedGracePeriod = 0
+
+	if conn.isStraggler(closedAt.Add(2 * time.Minute)) {
+		t.Fatal("segments must be accepted if the grace period is disabled")
+	}
+}
+
+func TestBufferLimitReached(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{}
+
+	conn := &tcpConnection{}
+
+	if conn.bufferLimitReached(1 << 20) {
+		t.Fatal("data must be buffered if no limit is configured")
+	}
+
+	decoderconfig.Instance.MaxConversationBytes = 100
+	conn = &tcpConnection{}
+
+	if conn.bufferLimitReached(60) || conn.bufferLimitReached(40) {
+		t.Fatal("data within the limit must be buffered")
+	}
+
+	if !conn.bufferLimitReached(1) || !conn.truncated {
+		t.Fatal("data exceeding the limit must be dropped and the connection marked as truncated")
+	}
+
+	// once truncated, smaller fragments are dropped as well
+	if !conn.bufferLimitReached(0) {
+		t.Fatal("data after truncation must be dropped")
+	}
+
+	if conn.numBytes != 100 {
+		t.Fatal("expected 100 buffered bytes, got", conn.numBytes)
+	}
+}
+
+// gapScatterGather delivers a single chunk of data, with the given number of bytes missing before it.
+type gapScatterGather struct {
+	data []byte
+	skip int
+}
+
+func (sg *gapScatterGather) Lengths() (int, int) {
+	return len(sg.data), 0
+}
+
+func (sg *gapScatterGather) Fetch(length int) []byte {
+	return sg.data[:length]
+}
+
+func (sg *gapScatterGather) KeepFrom(int) {}
+
+func (sg *gapScatterGather) CaptureInfo(int) gopacket.CaptureInfo {
+	return gopacket.CaptureInfo{}
+}
+
+func (sg *gapScatterGather) Info() (reassembly.TCPFlowDirection, bool, bool, int) {
+	return reassembly.TCPDirServerToClient, false, false, sg.skip
+}
+
+func (sg *gapScatterGather) Stats() reassembly.TCPAssemblyStats {
+	return reassembly.TCPAssemblyStats{Packets: 1, Chunks: 1}
+}
+
+// feedRecorder collects the data fed to a stream reader.
+type feedRecorder struct {
+	streamReader
+	fed []*core.StreamData
+}
+
+func (r *feedRecorder) Feed(data *core.StreamData) {
+	r.fed = append(r.fed, data)
+}
+
+func TestReassembledSGGap(t *testing.T) {
+	defer decoderutils.ConnectionInfos.Disable()
+
+	var (
+		netFlow   = gopacket.NewFlow(layers.EndpointIPv4, []byte{192, 168, 1, 1}, []byte{10, 0, 0, 1})
+		transport = gopacket.NewFlow(layers.EndpointTCPPort, []byte{0x10, 0x92}, []byte{0, 80})
+		sg        = &gapScatterGather{data: []byte("HTTP/1.1 200 OK\r\n"), skip: 1460}
+	)
+
+	for _, writeIncomplete := range []bool{false, true} {
+		decoderconfig.Instance = &decoderconfig.Config{WriteIncomplete: writeIncomplete}
+		decoderutils.ConnectionInfos.Enable()
+
+		var (
+			server = &feedRecorder{}
+			conn   = &tcpConnection{
+				net:       netFlow,
+				transport: transport,
+				client:    &feedRecorder{},
+				server:    server,
+			}
+		)
+
+		conn.ReassembledSG(sg, nil)
+
+		info, _ := decoderutils.ConnectionInfos.Consume(netFlow.FastHash(), transport.FastHash())
+
+		if !writeIncomplete {
+			if len(server.fed) != 0 || conn.incomplete || info.Incomplete {
+				t.Fatal("data after a gap must be dropped unless incomplete connections are kept")
+			}
+
+			continue
+		}
+
+		if len(server.fed) != 1 || string(server.fed[0].Raw()) != string(sg.data) {
+			t.Fatal("expected the data after the gap to be kept, got", server.fed)
+		}
+
+		if server.fed[0].Gap() != sg.skip {
+			t.Fatal("expected", sg.skip, "missing bytes before the data, got", server.fed[0].Gap())
+		}
+
+		if !conn.incomplete || !info.Incomplete {
+			t.Fatal("connection must be marked as incomplete")
+		}
+	}
+}
+
+func TestConversationEntropy(t *testing.T) {
+	if e := conversationEntropy(nil); e != 0 {
+		t.Fatal("expected zero entropy without data, got", e)
+	}
+
+	if e := conversationEntropy(core.DataFragments{&core.StreamData{RawData: []byte("aaaa")}}); e != 0 {
+		t.Fatal("expected zero entropy for a single repeated byte, got", e)
+	}
+
+	// all byte values spread over both directions
+	var client, server []byte
+	for i := 0; i < 256; i++ {
+		if i%2 == 0 {
+			client = append(client, byte(i))
+		} else {
+			server = append(server, byte(i))
+		}
+	}
+
+	e := conversationEntropy(core.DataFragments{
+		&core.StreamData{RawData: client},
+		&core.StreamData{RawData: server},
+	})
+	if math.Abs(e-8) > 1e-9 {
+		t.Fatal("expected an entropy of 8 bits, got", e)
+	}
+}
+
+func TestFlushDue(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{FlushInterval: 10 * time.Second}
+	lastFlush = time.Time{}
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if flushDue(start) {
+		t.Fatal("first packet must not trigger a flush")
+	}
+
+	if flushDue(start.Add(5 * time.Second)) {
+		t.Fatal("flush must not be triggered within the interval")
+	}
+
+	if !flushDue(start.Add(11 * time.Second)) {
+		t.Fatal("flush must be triggered once the interval has passed")
+	}
+
+	if flushDue(start.Add(15 * time.Second)) {
+		t.Fatal("interval must be measured from the last flush")
+	}
+
+	decoderconfig.Instance.FlushInterval = 0
+
+	if flushDue(start.Add(time.Hour)) {
+		t.Fatal("flush must not be triggered if both modes are disabled")
+	}
+}
+
+func TestReassemblyStats(t *testing.T) {
+	streamutils.Stats.Lock()
+	streamutils.Stats.MissedBytes = 10
+	streamutils.Stats.OverlapBytes = 20
+	streamutils.Stats.Unlock()
+
+	stats := ReassemblyStats()
+	if stats.MissedBytes != 10 || stats.OverlapBytes != 20 {
+		t.Fatal("unexpected stats", stats.MissedBytes, stats.OverlapBytes)
+	}
+
+	// the copy must not be affected by later updates
+	streamutils.Stats.Lock()
+	streamutils.Stats.MissedBytes++
+	streamutils.Stats.Unlock()
+
+	if stats.MissedBytes != 10 {
+		t.Fatal("expected a copy of the stats, got", stats.MissedBytes)
+	}
+}
+
+func TestCloseReason(t *testing.T) {
+	for _, c := range []struct {
+		conn     *tcpConnection
+		reason   string
+		expected types.CloseReason
+	}{
+		{&tcpConnection{endSeen: true}, reassembly.ReasonEndSignal, types.CloseReason_CloseGraceful},
+		// a FIN for the other half of the connection, while this half has been flushed
+		{&tcpConnection{endSeen: true}, reassembly.ReasonNoBytesSaved, types.CloseReason_CloseGraceful},
+		{&tcpConnection{endSeen: true, rstSeen: true}, reassembly.ReasonEndSignal, types.CloseReason_CloseReset},
+		{&tcpConnection{}, reassembly.ReasonNoBytesSaved, types.CloseReason_CloseTimeout},
+		{&tcpConnection{}, reassembly.ReasonForceFlushed, types.CloseReason_CloseTimeout},
+	} {
+		if r := c.conn.closeReason(c.reason); r != c.expected {
+			t.Fatal("expected", c.expected, "for", c.reason, "got", r)
+		}
+	}
+}
+
+func TestAdmitConnection(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{MaxConcurrentConnections: 1}
+
+	open := StreamFactory.numOpenConnections()
+	defer atomic.StoreInt64(&StreamFactory.numOpen, open)
+
+	var (
+		netFlow = gopacket.NewFlow(layers.EndpointIPv4, []byte{192, 168, 1, 1}, []byte{10, 0, 0, 1})
+		syn     = &layers.TCP{SYN: true, SrcPort: 4242, DstPort: 80}
+	)
+
+	atomic.StoreInt64(&StreamFactory.numOpen, 0)
+
+	if !admitConnection(netFlow, syn) {
+		t.Fatal("packet must be admitted below the limit")
+	}
+
+	atomic.StoreInt64(&StreamFactory.numOpen, 1)
+
+	streamutils.Stats.Lock()
+	before := streamutils.Stats.DroppedConnections
+	streamutils.Stats.Unlock()
+
+	if admitConnection(netFlow, syn) {
+		t.Fatal("packet of a new connection must be dropped once the limit has been reached")
+	}
+
+	streamutils.Stats.Lock()
+	defer streamutils.Stats.Unlock()
+
+	if n := streamutils.Stats.DroppedConnections - before; n != 1 {
+		t.Fatal("expected one dropped connection, got", n)
+	}
+}
+
+func TestAdmitConnectionWait(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{MaxConcurrentConnections: 1, ConnectionLimitWait: time.Hour}
+
+	open := StreamFactory.numOpenConnections()
+	defer atomic.StoreInt64(&StreamFactory.numOpen, open)
+	defer EnableConnectionLimitWait(false)
+
+	var (
+		netFlow = gopacket.NewFlow(layers.EndpointIPv4, []byte{192, 168, 1, 1}, []byte{10, 0, 0, 1})
+		syn     = &layers.TCP{SYN: true, SrcPort: 4243, DstPort: 80}
+	)
+
+	atomic.StoreInt64(&StreamFactory.numOpen, 1)
+
+	// without waiting, e.g. when reading a pcap file, the packet is dropped right away
+	EnableConnectionLimitWait(false)
+
+	if admitConnection(netFlow, syn) {
+		t.Fatal("packet of a new connection must be dropped once the limit has been reached")
+	}
+
+	// while waiting, another worker closes a connection
+	EnableConnectionLimitWait(true)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		StreamFactory.connectionClosed()
+	}()
+
+	if !admitConnection(netFlow, syn) {
+		t.Fatal("packet must be admitted once a connection has been closed")
+	}
+}
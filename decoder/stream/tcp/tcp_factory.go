@@ -15,12 +15,17 @@ package tcp
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/dreadl0ck/gopacket"
-	"github.com/dreadl0ck/gopacket/ip4defrag"
+	"github.com/dreadl0ck/gopacket/layers"
 	"go.uber.org/zap"
 
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/stream/tunnel"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
 	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
 	"github.com/dreadl0ck/netcap/utils"
 )
 
@@ -29,7 +34,7 @@ var StreamFactory = newStreamFactory()
 
 func newStreamFactory() *connectionFactory {
 	f := &connectionFactory{
-		defragger:  ip4defrag.NewIPv4Defragmenter(),
+		defragger:  newIPv4Defragmenter(),
 		FSMOptions: reassembly.TCPSimpleFSMOptions{},
 	}
 	f.StreamPool = reassembly.NewStreamPool(f)
@@ -37,6 +42,11 @@ func newStreamFactory() *connectionFactory {
 	return f
 }
 
+// SetDefragPolicy sets the policy used to resolve overlapping IPv4 fragments: first, last, bsd or linux.
+func SetDefragPolicy(policy string) error {
+	return StreamFactory.defragger.setPolicy(policy)
+}
+
 // GetStreamPool returns the stream pool.
 func GetStreamPool() *reassembly.StreamPool {
 	return StreamFactory.StreamPool
@@ -52,10 +62,18 @@ type connectionFactory struct {
 	sync.Mutex
 	streamReaders []streamReader
 	numActive     int64
-	defragger     *ip4defrag.IPv4Defragmenter
+	defragger     *ipv4Defragmenter
 	StreamPool    *reassembly.StreamPool
 	wg            sync.WaitGroup
 	FSMOptions    reassembly.TCPSimpleFSMOptions
+
+	// limits the number of concurrently running stream reader goroutines,
+	// nil if no limit has been configured
+	readerSlots chan struct{}
+	initSlots   sync.Once
+
+	// number of connections that have not been closed yet
+	numOpen int64
 }
 
 // New handles a new stream received from the assembler
@@ -77,13 +95,25 @@ func (factory *connectionFactory) New(net, transport gopacket.Flow, ac reassembl
 		firstPacket: ac.GetCaptureInfo().Timestamp,
 	}
 
+	if c, ok := ac.(*context); ok {
+		str.serverMAC = c.DstMAC
+
+		if c.Tunnel != nil {
+			c.Tunnel.Timestamp = str.firstPacket.UnixNano()
+			c.Tunnel.Flow = str.ident
+			c.Tunnel.Transport = "TCP"
+
+			tunnel.WriteTunnel(c.Tunnel)
+		}
+	}
+
 	str.decoder = &tcpReader{
 		parent: str,
 	}
-	str.client = str.newTCPStreamReader(true)
-	str.server = str.newTCPStreamReader(false)
 
-	factory.wg.Add(2)
+	atomic.AddInt64(&factory.numOpen, 1)
+	client, server := str.newTCPStreamReader(true), str.newTCPStreamReader(false)
+	str.client, str.server = client, server
 
 	factory.Lock()
 	factory.streamReaders = append(
@@ -91,9 +121,34 @@ func (factory *connectionFactory) New(net, transport gopacket.Flow, ac reassembl
 		str.client,
 		str.server,
 	)
+	factory.Unlock()
+
+	// if the limit for stream reader goroutines has been reached,
+	// the data is collected on the assembler goroutine instead of spawning new ones.
+	if !factory.acquireReaderSlots() {
+		client.inline = true
+		server.inline = true
+
+		streamutils.Stats.Lock()
+		streamutils.Stats.InlineTCPStreams++
+		streamutils.Stats.Unlock()
+
+		return str
+	}
+
+	factory.wg.Add(2)
+
+	factory.Lock()
 	factory.numActive += 2
+	active := factory.numActive
 	factory.Unlock()
 
+	streamutils.Stats.Lock()
+	if active > streamutils.Stats.PeakStreamReaders {
+		streamutils.Stats.PeakStreamReaders = active
+	}
+	streamutils.Stats.Unlock()
+
 	// launch stream readers
 	go str.client.Run(factory)
 	go str.server.Run(factory)
@@ -101,6 +156,56 @@ func (factory *connectionFactory) New(net, transport gopacket.Flow, ac reassembl
 	return str
 }
 
+// acquireReaderSlots reserves the slots for the client and server reader goroutines of a new stream.
+// It returns false without blocking if the configured limit has been reached.
+func (factory *connectionFactory) acquireReaderSlots() bool {
+	factory.initSlots.Do(func() {
+		if decoderconfig.Instance.MaxStreamReaders > 0 {
+			factory.readerSlots = make(chan struct{}, decoderconfig.Instance.MaxStreamReaders)
+		}
+	})
+
+	if factory.readerSlots == nil {
+		return true
+	}
+
+	select {
+	case factory.readerSlots <- struct{}{}:
+	default:
+		return false
+	}
+
+	select {
+	case factory.readerSlots <- struct{}{}:
+		return true
+	default:
+		// only one slot was available, release it again
+		<-factory.readerSlots
+
+		return false
+	}
+}
+
+// releaseReaderSlot frees the slot of a stream reader goroutine that has finished.
+func (factory *connectionFactory) releaseReaderSlot() {
+	if factory.readerSlots != nil {
+		<-factory.readerSlots
+	}
+}
+
+// numActiveReaders returns the number of currently running stream reader goroutines.
+func (factory *connectionFactory) numActiveReaders() int64 {
+	factory.Lock()
+	defer factory.Unlock()
+
+	return factory.numActive
+}
+
+// NumActiveStreamReaders returns the number of currently running TCP stream reader goroutines.
+func NumActiveStreamReaders() int64 {
+	return StreamFactory.numActiveReaders()
+}
+
 // waitGoRoutines waits until the goroutines launched to process TCP streams are done
 // this will block forever if there are streams that are never shutdown (via RST or FIN flags).
 func (factory *connectionFactory) waitGoRoutines() {
@@ -114,6 +219,16 @@ func (factory *connectionFactory) waitGoRoutines() {
 // context is the assembler context.
 type context struct {
 	CaptureInfo gopacket.CaptureInfo
+
+	// outer packet of the tunnel, if the packet has been decapsulated
+	Tunnel *types.Tunnel
+
+	// destination hardware address, empty if the packet has no link layer
+	DstMAC string
+
+	// original packet bytes as read from the capture and their link type, only set with DumpConnectionPcaps
+	Data     []byte
+	LinkType layers.LinkType
 }
 
 // GetCaptureInfo returns the gopacket.CaptureInfo from the context.
@@ -0,0 +1,133 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+)
+
+func TestAcquireReaderSlots(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		expected []bool
+		used     int
+	}{
+		{name: "no limit", limit: 0, expected: []bool{true, true, true}},
+		{name: "even limit", limit: 4, expected: []bool{true, true, false}, used: 4},
+		{name: "odd limit", limit: 3, expected: []bool{true, false, false}, used: 2},
+		{name: "single slot", limit: 1, expected: []bool{false}, used: 0},
+	}
+
+	for _, test := range tests {
+		decoderconfig.Instance = &decoderconfig.Config{MaxStreamReaders: test.limit}
+		factory := newStreamFactory()
+
+		for i, expected := range test.expected {
+			if ok := factory.acquireReaderSlots(); ok != expected {
+				t.Fatal(test.name, ": expected", expected, "for stream", i, "got", ok)
+			}
+		}
+
+		if len(factory.readerSlots) != test.used {
+			t.Fatal(test.name, ": expected", test.used, "used slots, got", len(factory.readerSlots))
+		}
+	}
+}
+
+// newTestStream creates a new stream via the factory for the given client port.
+func newTestStream(factory *connectionFactory, port byte) *tcpConnection {
+	return factory.New(
+		gopacket.NewFlow(layers.EndpointIPv4, net.IP{10, 0, 0, 1}, net.IP{10, 0, 0, 2}),
+		gopacket.NewFlow(layers.EndpointTCPPort, []byte{0xc0, port}, []byte{0, 80}),
+		&context{CaptureInfo: gopacket.CaptureInfo{Timestamp: time.Now()}},
+	).(*tcpConnection)
+}
+
+func TestStreamReaderLimit(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{MaxStreamReaders: 2, StreamDecoderBufSize: 10}
+	factory := newStreamFactory()
+
+	streamutils.Stats.Lock()
+	inline := streamutils.Stats.InlineTCPStreams
+	streamutils.Stats.Unlock()
+
+	first := newTestStream(factory, 1)
+	if first.client.(*tcpStreamReader).inline || first.server.(*tcpStreamReader).inline {
+		t.Fatal("expected goroutines for the first stream")
+	}
+
+	if n := factory.numActiveReaders(); n != 2 {
+		t.Fatal("expected 2 active stream readers, got", n)
+	}
+
+	// the limit has been reached, the second stream is read without goroutines
+	second := newTestStream(factory, 2)
+	if !second.client.(*tcpStreamReader).inline || !second.server.(*tcpStreamReader).inline {
+		t.Fatal("expected the second stream to be read inline")
+	}
+
+	if n := factory.numActiveReaders(); n != 2 {
+		t.Fatal("expected 2 active stream readers, got", n)
+	}
+
+	streamutils.Stats.Lock()
+	if streamutils.Stats.InlineTCPStreams != inline+1 {
+		t.Fatal("expected", inline+1, "inline streams, got", streamutils.Stats.InlineTCPStreams)
+	}
+	streamutils.Stats.Unlock()
+
+	// data for an inline stream is collected directly, without using the data channel
+	second.client.Feed(&core.StreamData{RawData: []byte("hello")})
+	second.client.Feed(&core.StreamData{RawData: []byte("world")})
+
+	if len(second.client.DataChan()) != 0 {
+		t.Fatal("expected no data in the channel of an inline stream")
+	}
+
+	if n := len(second.client.DataSlice()); n != 2 {
+		t.Fatal("expected 2 data fragments, got", n)
+	}
+
+	if n := second.client.(*tcpStreamReader).numBytes; n != 10 {
+		t.Fatal("expected 10 bytes, got", n)
+	}
+
+	// once the goroutines of the first stream are done, their slots can be used again
+	close(first.client.DataChan())
+	close(first.server.DataChan())
+	factory.wg.Wait()
+
+	if n := factory.numActiveReaders(); n != 0 {
+		t.Fatal("expected no active stream readers, got", n)
+	}
+
+	third := newTestStream(factory, 3)
+	if third.client.(*tcpStreamReader).inline {
+		t.Fatal("expected goroutines for the third stream")
+	}
+
+	close(third.client.DataChan())
+	close(third.server.DataChan())
+	factory.wg.Wait()
+}
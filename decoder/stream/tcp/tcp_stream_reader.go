@@ -51,6 +51,10 @@ type tcpStreamReader struct {
 	hexdump            bool
 	isClient           bool
 	saved              bool
+
+	// set if the stream is read without a dedicated goroutine,
+	// because the configured limit for stream reader goroutines has been reached
+	inline bool
 }
 
 func (t *tcpConnection) newTCPStreamReader(client bool) *tcpStreamReader {
@@ -86,6 +90,21 @@ func (t *tcpStreamReader) DataChan() chan *core.StreamData {
 	return t.dataChan
 }
 
+// Feed passes data to the stream, either via the data channel
+// or directly if no goroutine has been started to read the stream.
+func (t *tcpStreamReader) Feed(data *core.StreamData) {
+	if !t.inline {
+		t.dataChan <- data
+
+		return
+	}
+
+	t.parent.Lock()
+	t.data = append(t.data, data)
+	t.numBytes += len(data.RawData)
+	t.parent.Unlock()
+}
+
 // Cleanup will tear down the stream processing.
 func (t *tcpStreamReader) Cleanup(f *connectionFactory) {
 	// signal wait group
@@ -93,6 +112,8 @@ func (t *tcpStreamReader) Cleanup(f *connectionFactory) {
 	f.Lock()
 	f.numActive--
 	f.Unlock()
+
+	f.releaseReaderSlot()
 }
 
 // DataSlice will return all gathered data fragments.
@@ -210,6 +231,11 @@ func (t *tcpStreamReader) ServiceIdent() string {
 	return filepath.Clean(fmt.Sprintf("%s:%s", t.parent.server.Network().Dst(), t.parent.server.Transport().Dst()))
 }
 
+// ServerMAC returns the hardware address of the server, if the packets had a link layer.
+func (t *tcpStreamReader) ServerMAC() string {
+	return t.parent.serverMAC
+}
+
 // ServiceBanner will return the banner received from the server.
 func (t *tcpStreamReader) ServiceBanner() []byte {
 	t.parent.Lock()
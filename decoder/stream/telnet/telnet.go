@@ -0,0 +1,93 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package telnet
+
+import (
+	"regexp"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var telnetLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_Telnet,
+	Name:        serviceTelnet,
+	Description: "Telnet provides a bidirectional interactive text-oriented communication, the login credentials and commands are transferred in cleartext",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		telnetLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"telnet",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isNegotiation(server) || isNegotiation(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return telnetLog.Sync()
+	},
+	Factory: &telnetReader{},
+	Typ:     core.TCP,
+}
+
+const serviceTelnet = "Telnet"
+
+// Telnet commands, see RFC 854.
+const (
+	cmdSE   = 240
+	cmdEC   = 247
+	cmdEL   = 248
+	cmdSB   = 250
+	cmdWILL = 251
+	cmdWONT = 252
+	cmdDO   = 253
+	cmdDONT = 254
+	cmdIAC  = 255
+)
+
+// control characters used to edit the typed input.
+const (
+	charBackspace = 0x08
+	charDelete    = 0x7f
+	charEraseLine = 0x15 // ctrl-u
+	charEraseWord = 0x17 // ctrl-w
+	charInterrupt = 0x03 // ctrl-c
+)
+
+// limits for the data kept in the audit records.
+const (
+	maxBannerSize = 512
+	maxCommands   = 1000
+)
+
+var (
+	reLoginPrompt    = regexp.MustCompile(`(?i)(^|\s)(login|user ?name|user)\s*:\s*$`)
+	rePasswordPrompt = regexp.MustCompile(`(?i)(^|\s)pass(word|code)?\s*:\s*$`)
+	reLoginFailed    = regexp.MustCompile(`(?i)(incorrect|fail|denied|invalid|bad password)`)
+)
+
+// isNegotiation checks if the data starts with a Telnet option negotiation, e.g. IAC DO ECHO.
+func isNegotiation(data []byte) bool {
+	return len(data) >= 3 && data[0] == cmdIAC && data[1] >= cmdWILL && data[1] <= cmdDONT
+}
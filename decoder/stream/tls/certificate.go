@@ -0,0 +1,234 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// TLS record and handshake message types.
+const (
+	recordTypeChangeCipherSpec = 20
+	recordTypeHandshake        = 22
+
+	handshakeTypeCertificate = 11
+
+	recordHeaderLength    = 5
+	handshakeHeaderLength = 4
+)
+
+// certificate anomalies.
+const (
+	anomalySelfSigned    = "SelfSigned"
+	anomalyExpired       = "Expired"
+	anomalyNotYetValid   = "NotYetValid"
+	anomalyShortValidity = "ShortValidity"
+	anomalyWildcardAbuse = "WildcardAbuse"
+	anomalySNIMismatch   = "SNIMismatch"
+)
+
+// anomaly describes a suspicious property of a certificate.
+type anomaly struct {
+	name        string
+	description string
+}
+
+// handshakeMessages collects the payload of all cleartext handshake records at the start of the stream.
+// Parsing stops at the ChangeCipherSpec record, or at the first record that is not a handshake record,
+// since everything afterwards is encrypted.
+func handshakeMessages(data []byte) []byte {
+	var out []byte
+
+	for len(data) >= recordHeaderLength {
+		var (
+			typ    = data[0]
+			length = int(data[3])<<8 | int(data[4])
+		)
+
+		if typ != recordTypeHandshake {
+			break
+		}
+
+		data = data[recordHeaderLength:]
+
+		// the record might be truncated if the stream was not captured completely
+		if length > len(data) {
+			length = len(data)
+		}
+
+		out = append(out, data[:length]...)
+		data = data[length:]
+	}
+
+	return out
+}
+
+// certificateChain extracts the DER encoded certificates from the first Certificate handshake message.
+// The leaf certificate of the server is the first item in the chain.
+func certificateChain(data []byte) (certs [][]byte) {
+	hs := handshakeMessages(data)
+
+	for len(hs) >= handshakeHeaderLength {
+		var (
+			typ    = hs[0]
+			length = int(hs[1])<<16 | int(hs[2])<<8 | int(hs[3])
+		)
+
+		hs = hs[handshakeHeaderLength:]
+		if length > len(hs) {
+			if typ != handshakeTypeCertificate {
+				return nil
+			}
+
+			// the end of the chain is missing, return the certificates that have been captured completely
+			length = len(hs)
+		}
+
+		if typ != handshakeTypeCertificate {
+			hs = hs[length:]
+
+			continue
+		}
+
+		// certificate list, prefixed with a 3 byte length
+		msg := hs[:length]
+		if len(msg) < 3 {
+			return nil
+		}
+
+		msg = msg[3:]
+
+		for len(msg) >= 3 {
+			certLen := int(msg[0])<<16 | int(msg[1])<<8 | int(msg[2])
+			msg = msg[3:]
+
+			if certLen > len(msg) {
+				break
+			}
+
+			certs = append(certs, msg[:certLen])
+			msg = msg[certLen:]
+		}
+
+		return certs
+	}
+
+	return nil
+}
+
+// checkCertificate applies heuristics to the certificate and returns all anomalies found.
+// The validity is checked against the time the certificate was observed, not the current time,
+// and the sni is the server name requested by the client, if any.
+func checkCertificate(cert *x509.Certificate, sni string, seen time.Time, shortValidity time.Duration) (res []anomaly) {
+	if bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		res = append(res, anomaly{
+			name:        anomalySelfSigned,
+			description: "issuer and subject are identical: " + cert.Subject.String(),
+		})
+	}
+
+	if seen.After(cert.NotAfter) {
+		res = append(res, anomaly{
+			name:        anomalyExpired,
+			description: "certificate expired at " + cert.NotAfter.UTC().String(),
+		})
+	}
+
+	if seen.Before(cert.NotBefore) {
+		res = append(res, anomaly{
+			name:        anomalyNotYetValid,
+			description: "certificate is valid from " + cert.NotBefore.UTC().String(),
+		})
+	}
+
+	if validity := cert.NotAfter.Sub(cert.NotBefore); shortValidity > 0 && validity < shortValidity {
+		res = append(res, anomaly{
+			name:        anomalyShortValidity,
+			description: fmt.Sprintf("certificate is only valid for %s", validity),
+		})
+	}
+
+	for _, name := range certificateNames(cert) {
+		if reason := wildcardAbuse(name); reason != "" {
+			res = append(res, anomaly{
+				name:        anomalyWildcardAbuse,
+				description: reason + ": " + name,
+			})
+		}
+	}
+
+	if sni != "" {
+		if err := cert.VerifyHostname(sni); err != nil {
+			res = append(res, anomaly{
+				name:        anomalySNIMismatch,
+				description: "requested server name " + sni + " is not covered by the certificate: " + strings.Join(certificateNames(cert), ", "),
+			})
+		}
+	}
+
+	return res
+}
+
+// certificateNames returns the DNS names from the subject alternative names,
+// or the common name if the certificate has no DNS names.
+func certificateNames(cert *x509.Certificate) []string {
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames
+	}
+
+	if cert.Subject.CommonName != "" {
+		return []string{cert.Subject.CommonName}
+	}
+
+	return nil
+}
+
+// wildcardAbuse checks a certificate name for wildcards that are invalid or match too broadly
+// and returns the reason, or an empty string if the name is fine.
+func wildcardAbuse(name string) string {
+	if !strings.Contains(name, "*") {
+		return ""
+	}
+
+	if strings.Count(name, "*") > 1 {
+		return "multiple wildcards"
+	}
+
+	if !strings.HasPrefix(name, "*.") {
+		return "wildcard is not the leftmost label"
+	}
+
+	// a wildcard directly in front of a public suffix, like *.com or *.co.uk matches every domain below it
+	domain := strings.TrimPrefix(name, "*.")
+	if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain {
+		return "wildcard for public suffix"
+	}
+
+	return ""
+}
+
+// fingerprint returns the hex encoded SHA256 hash of the DER encoded certificate.
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+
+	return hex.EncodeToString(sum[:])
+}
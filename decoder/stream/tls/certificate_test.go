@@ -0,0 +1,204 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func createCertificate(t *testing.T, subject, issuer string, notBefore, notAfter time.Time, names ...string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		template = &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: subject},
+			NotBefore:    notBefore,
+			NotAfter:     notAfter,
+			DNSNames:     names,
+		}
+		parent = template
+	)
+
+	if issuer != subject {
+		parent = &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: issuer},
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+// serverHandshake wraps the certificates into a Certificate handshake message,
+// preceded by a dummy server hello and split over two TLS records.
+func serverHandshake(chain ...[]byte) []byte {
+	var (
+		u24 = func(n int) []byte {
+			return []byte{byte(n >> 16), byte(n >> 8), byte(n)}
+		}
+		serverHello = append([]byte{2}, u24(2)...)
+		certs       []byte
+	)
+
+	for _, der := range chain {
+		certs = append(certs, u24(len(der))...)
+		certs = append(certs, der...)
+	}
+
+	certMsg := append([]byte{handshakeTypeCertificate}, u24(len(certs)+3)...)
+	certMsg = append(certMsg, u24(len(certs))...)
+	certMsg = append(certMsg, certs...)
+
+	serverHello = append(serverHello, 0x03, 0x03)
+
+	var (
+		hs    = append(serverHello, certMsg...)
+		split = len(hs) / 2
+		out   []byte
+	)
+
+	for _, fragment := range [][]byte{hs[:split], hs[split:]} {
+		out = append(out, recordTypeHandshake, 0x03, 0x03, byte(len(fragment)>>8), byte(len(fragment)))
+		out = append(out, fragment...)
+	}
+
+	// encrypted data after the change cipher spec must be ignored
+	return append(out, recordTypeChangeCipherSpec, 0x03, 0x03, 0x00, 0x01, 0x01)
+}
+
+func anomalyNames(anomalies []anomaly) map[string]bool {
+	m := make(map[string]bool)
+	for _, a := range anomalies {
+		m[a.name] = true
+	}
+
+	return m
+}
+
+func TestCertificateChain(t *testing.T) {
+	now := time.Now()
+	der := createCertificate(t, "example.com", "Example CA", now.Add(-time.Hour), now.Add(365*24*time.Hour), "example.com")
+
+	chain := certificateChain(serverHandshake(der))
+	if len(chain) != 1 {
+		t.Fatal("expected one certificate, got: ", len(chain))
+	}
+
+	if _, err := x509.ParseCertificate(chain[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// truncated handshake
+	if chain = certificateChain(serverHandshake(der)[:100]); len(chain) != 0 {
+		t.Fatal("expected no certificate for a truncated handshake, got: ", len(chain))
+	}
+
+	// the end of the chain is missing, the certificate of the server has been captured completely
+	var (
+		ca = createCertificate(t, "Example CA", "Example Root CA", now.Add(-time.Hour), now.Add(365*24*time.Hour))
+		hs = serverHandshake(der, ca)
+	)
+
+	if chain = certificateChain(hs[:len(hs)-len(ca)/2]); len(chain) != 1 {
+		t.Fatal("expected the certificate of the server from a truncated chain, got: ", len(chain))
+	}
+
+	if chain = certificateChain(hs); len(chain) != 2 {
+		t.Fatal("expected two certificates, got: ", len(chain))
+	}
+}
+
+func TestCheckCertificate(t *testing.T) {
+	var (
+		now  = time.Now()
+		year = 365 * 24 * time.Hour
+		week = 7 * 24 * time.Hour
+	)
+
+	tests := []struct {
+		name     string
+		der      []byte
+		sni      string
+		expected []string
+	}{
+		{
+			name: "valid",
+			der:  createCertificate(t, "example.com", "Example CA", now.Add(-time.Hour), now.Add(year), "example.com", "*.example.com"),
+			sni:  "www.example.com",
+		},
+		{
+			name:     "self signed",
+			der:      createCertificate(t, "example.com", "example.com", now.Add(-time.Hour), now.Add(year), "example.com"),
+			sni:      "example.com",
+			expected: []string{anomalySelfSigned},
+		},
+		{
+			name:     "expired",
+			der:      createCertificate(t, "example.com", "Example CA", now.Add(-2*year), now.Add(-year), "example.com"),
+			expected: []string{anomalyExpired},
+		},
+		{
+			name:     "not yet valid and short",
+			der:      createCertificate(t, "example.com", "Example CA", now.Add(time.Hour), now.Add(2*time.Hour), "example.com"),
+			expected: []string{anomalyNotYetValid, anomalyShortValidity},
+		},
+		{
+			name:     "wildcard",
+			der:      createCertificate(t, "example.com", "Example CA", now.Add(-time.Hour), now.Add(year), "*.co.uk", "a*.example.com"),
+			expected: []string{anomalyWildcardAbuse},
+		},
+		{
+			name:     "sni mismatch",
+			der:      createCertificate(t, "example.com", "Example CA", now.Add(-time.Hour), now.Add(year), "example.com"),
+			sni:      "bank.com",
+			expected: []string{anomalySNIMismatch},
+		},
+	}
+
+	for _, test := range tests {
+		cert, err := x509.ParseCertificate(test.der)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found := anomalyNames(checkCertificate(cert, test.sni, now, week))
+		if len(found) != len(test.expected) {
+			t.Fatal(test.name, ": expected ", test.expected, " got: ", found)
+		}
+
+		for _, e := range test.expected {
+			if !found[e] {
+				t.Fatal(test.name, ": expected ", e, " got: ", found)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tls
+
+import (
+	"crypto/x509"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// CertificateDecoder writes the certificate chains served during the TLS handshake.
+// The certificates are extracted by the TLS stream decoder.
+var CertificateDecoder = &decoder.AbstractDecoder{
+	Type:           types.Type_NC_TLSServerCertificate,
+	Name:           "TLSServerCertificate",
+	Description:    "The certificate chain served by the server during a Transport Layer Security handshake",
+	StreamDecoders: []string{Decoder.Name},
+}
+
+// newServerCertificate creates the audit record for the certificate at the given position in the chain.
+func newServerCertificate(cert *x509.Certificate, index int) *types.TLSServerCertificate {
+	ips := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+
+	return &types.TLSServerCertificate{
+		ChainIndex:         int32(index),
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		DNSNames:           cert.DNSNames,
+		IPAddresses:        ips,
+		NotBefore:          cert.NotBefore.UnixNano(),
+		NotAfter:           cert.NotAfter.UnixNano(),
+		Fingerprint:        fingerprint(cert),
+		SerialNumber:       cert.SerialNumber.String(),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		IsCA:               cert.IsCA,
+	}
+}
+
+// writeServerCertificate writes the audit record and updates the metrics if enabled.
+func writeServerCertificate(c *types.TLSServerCertificate) {
+	if decoderconfig.Instance.ExportMetrics {
+		c.Inc()
+	}
+
+	atomic.AddInt64(&CertificateDecoder.NumRecordsWritten, 1)
+
+	err := CertificateDecoder.Writer.Write(c)
+	if err != nil {
+		tlsLog.Error("failed to write server certificate audit record", zap.Error(err))
+	}
+}
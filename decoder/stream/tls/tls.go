@@ -0,0 +1,55 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tls
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var tlsLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_CertAnomaly,
+	Name:        "CertAnomaly",
+	Description: "Suspicious properties of certificates served during a Transport Layer Security handshake",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		tlsLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"tls",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return bytes.HasPrefix(client, tlsHandshakeRecord) && bytes.HasPrefix(server, tlsHandshakeRecord)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return tlsLog.Sync()
+	},
+	Factory: &tlsReader{},
+	Typ:     core.TCP,
+}
+
+// tlsHandshakeRecord is the start of a TLS record with content type handshake and major version 3.
+var tlsHandshakeRecord = []byte{recordTypeHandshake, 0x03}
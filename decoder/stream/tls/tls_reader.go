@@ -0,0 +1,182 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"sync/atomic"
+	"time"
+
+	"github.com/dreadl0ck/tlsx"
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+/*
+ * TLS - Transport Layer Security
+ */
+
+const (
+	// the client hello is usually sent in the first segment, but can be larger when many extensions are present
+	maxClientHelloSize = 16 * 1024
+
+	// the certificate chain follows the server hello, 64k should be enough even for long chains
+	maxServerHandshakeSize = 64 * 1024
+)
+
+type tlsReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new TLS reader.
+func (h *tlsReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &tlsReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the cleartext part of the TLS handshake, writes the certificate chain served by the server
+// and checks the certificate of the server for anomalies.
+func (h *tlsReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil && CertificateDecoder.Writer == nil {
+		return
+	}
+
+	var (
+		serverBuf bytes.Buffer
+		clientBuf bytes.Buffer
+	)
+
+	for _, d := range h.conversation.Data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			if clientBuf.Len() < maxClientHelloSize {
+				clientBuf.Write(d.Raw())
+			}
+		} else if serverBuf.Len() < maxServerHandshakeSize {
+			serverBuf.Write(d.Raw())
+		}
+	}
+
+	chain := certificateChain(serverBuf.Bytes())
+	if len(chain) == 0 {
+		// TLS 1.3 encrypts the certificate message, there is nothing to inspect
+		return
+	}
+
+	var hello tlsx.ClientHelloBasic
+
+	// the client hello might be missing, if the capture started after the handshake
+	// in that case the SNI check is skipped
+	_ = hello.Unmarshal(clientBuf.Bytes())
+
+	if CertificateDecoder.Writer != nil {
+		h.writeChain(chain, hello.SNI)
+	}
+
+	if Decoder.Writer == nil {
+		return
+	}
+
+	cert, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		tlsLog.Error("failed to parse certificate",
+			zap.Error(err),
+			zap.String("ident", h.conversation.Ident),
+		)
+
+		return
+	}
+
+	anomalies := checkCertificate(
+		cert,
+		hello.SNI,
+		h.conversation.FirstServerPacket,
+		time.Duration(decoderconfig.Instance.CertShortValidityDays)*24*time.Hour,
+	)
+
+	for _, a := range anomalies {
+		writeCertAnomaly(&types.CertAnomaly{
+			Timestamp:   h.conversation.FirstServerPacket.UnixNano(),
+			Flow:        h.conversation.Ident,
+			SrcIP:       h.conversation.ClientIP,
+			SrcPort:     h.conversation.ClientPort,
+			DstIP:       h.conversation.ServerIP,
+			DstPort:     h.conversation.ServerPort,
+			Anomaly:     a.name,
+			Description: a.description,
+			SNI:         hello.SNI,
+			Subject:     cert.Subject.String(),
+			Issuer:      cert.Issuer.String(),
+			DNSNames:    cert.DNSNames,
+			NotBefore:   cert.NotBefore.UnixNano(),
+			NotAfter:    cert.NotAfter.UnixNano(),
+			Fingerprint: fingerprint(cert),
+		})
+	}
+
+	if len(anomalies) > 0 {
+		tlsLog.Info("certificate anomalies found",
+			zap.String("ident", h.conversation.Ident),
+			zap.String("subject", cert.Subject.String()),
+			zap.Int("anomalies", len(anomalies)),
+		)
+	}
+}
+
+// writeChain writes an audit record for every certificate in the chain.
+func (h *tlsReader) writeChain(chain [][]byte, sni string) {
+	for i, der := range chain {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			tlsLog.Error("failed to parse certificate",
+				zap.Error(err),
+				zap.String("ident", h.conversation.Ident),
+				zap.Int("index", i),
+			)
+
+			continue
+		}
+
+		c := newServerCertificate(cert, i)
+		c.Timestamp = h.conversation.FirstServerPacket.UnixNano()
+		c.Flow = h.conversation.Ident
+		c.SrcIP = h.conversation.ClientIP
+		c.SrcPort = h.conversation.ClientPort
+		c.DstIP = h.conversation.ServerIP
+		c.DstPort = h.conversation.ServerPort
+		c.SNI = sni
+
+		writeServerCertificate(c)
+	}
+}
+
+// writeCertAnomaly writes the audit record and updates the metrics if enabled.
+func writeCertAnomaly(a *types.CertAnomaly) {
+	if decoderconfig.Instance.ExportMetrics {
+		a.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(a)
+	if err != nil {
+		tlsLog.Error("failed to write cert anomaly audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tns
+
+import (
+	"encoding/binary"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var tnsLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_TNS,
+	Name:        "TNS",
+	Description: "The Transparent Network Substrate protocol is used by clients to connect to Oracle databases",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		tnsLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"tns",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		return isConnectPacket(client)
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return tnsLog.Sync()
+	},
+	Factory: &tnsReader{},
+	Typ:     core.TCP,
+}
+
+const (
+	// every TNS packet starts with a header:
+	// packet length (2 bytes), packet checksum (2 bytes), packet type (1 byte),
+	// reserved (1 byte) and header checksum (2 bytes).
+	headerSize = 8
+
+	// fixed part of a connect packet, up to and including the offset of the connect data
+	connectHeaderSize = 28
+
+	// oldest and newest protocol versions that are accepted as valid
+	minVersion = 300
+	maxVersion = 400
+)
+
+// TNS packet types.
+const (
+	packetConnect  = 1
+	packetAccept   = 2
+	packetRefuse   = 4
+	packetRedirect = 5
+	packetResend   = 11
+)
+
+// isConnectPacket checks whether the data starts with a valid TNS connect packet.
+func isConnectPacket(data []byte) bool {
+	if len(data) < connectHeaderSize || data[4] != packetConnect {
+		return false
+	}
+
+	var (
+		length  = int(binary.BigEndian.Uint16(data[0:2]))
+		version = binary.BigEndian.Uint16(data[8:10])
+		compat  = binary.BigEndian.Uint16(data[10:12])
+	)
+
+	return length >= connectHeaderSize &&
+		version >= minVersion && version <= maxVersion &&
+		compat >= minVersion && compat <= version
+}
@@ -0,0 +1,246 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"regexp"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// limits the amount of data that is buffered for parsing in each direction,
+// only the connection establishment is of interest.
+const maxBufferSize = 64 * 1024
+
+// responses of the server to a connect packet.
+var responses = map[byte]string{
+	packetAccept:   "Accept",
+	packetRefuse:   "Refuse",
+	packetRedirect: "Redirect",
+}
+
+var (
+	// the CID contains information about the client: (CID=(PROGRAM=sqlplus)(HOST=workstation)(USER=alice))
+	reCID = regexp.MustCompile(`(?i)\(\s*CID\s*=\s*((?:\([^()]*\)\s*)*)\)`)
+
+	reServiceName = connectParameter("SERVICE_NAME")
+	reSID         = connectParameter("SID")
+	reProgram     = connectParameter("PROGRAM")
+	reHost        = connectParameter("HOST")
+	reUser        = connectParameter("USER")
+	reError       = connectParameter("ERR")
+)
+
+// connectParameter compiles a regular expression that matches the value for key in a connect descriptor.
+// Keywords are case insensitive and whitespace is allowed around the equal sign.
+func connectParameter(key string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\(\s*` + key + `\s*=\s*([^()]*?)\s*\)`)
+}
+
+type tnsReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new TNS reader.
+func (h *tnsReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &tnsReader{
+		conversation: conversation,
+	}
+}
+
+// Decode parses the connect packet sent by the client and the response of the server,
+// and writes an audit record with the database service that has been accessed.
+func (h *tnsReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	var clientBuf, serverBuf bytes.Buffer
+
+	for _, d := range h.conversation.Data {
+		if d.Direction() == reassembly.TCPDirClientToServer {
+			if clientBuf.Len() < maxBufferSize {
+				clientBuf.Write(d.Raw())
+			}
+		} else if serverBuf.Len() < maxBufferSize {
+			serverBuf.Write(d.Raw())
+		}
+	}
+
+	t := parseConnect(clientBuf.Bytes())
+	if t == nil {
+		return
+	}
+
+	t.Timestamp = h.conversation.FirstClientPacket.UnixNano()
+	t.Flow = h.conversation.Ident
+	t.SrcIP = h.conversation.ClientIP
+	t.SrcPort = h.conversation.ClientPort
+	t.DstIP = h.conversation.ServerIP
+	t.DstPort = h.conversation.ServerPort
+
+	parseResponse(serverBuf.Bytes(), t)
+
+	if t.Response == responses[packetRefuse] {
+		tnsLog.Info("connection refused",
+			zap.String("ident", h.conversation.Ident),
+			zap.String("service", t.ServiceName),
+			zap.String("sid", t.SID),
+			zap.String("error", t.Error),
+		)
+	}
+
+	writeTNS(t)
+}
+
+// nextPacket returns the type and the contents of the first TNS packet in data, and the remaining data.
+// A truncated packet is returned with the available data.
+func nextPacket(data []byte) (typ byte, packet, rest []byte, ok bool) {
+	if len(data) < headerSize {
+		return 0, nil, nil, false
+	}
+
+	length := int(binary.BigEndian.Uint16(data[0:2]))
+	if length < headerSize {
+		return 0, nil, nil, false
+	}
+
+	if length > len(data) {
+		length = len(data)
+	}
+
+	return data[4], data[:length], data[length:], true
+}
+
+// parseConnect searches the client data for a connect packet and extracts the connect descriptor.
+// The client sends the connect packet again if the server requested a resend,
+// the first connect packet that carries connect data is used.
+func parseConnect(data []byte) *types.TNS {
+	var t *types.TNS
+
+	for len(data) > 0 {
+		typ, packet, rest, ok := nextPacket(data)
+		if !ok {
+			break
+		}
+
+		data = rest
+
+		if typ != packetConnect || !isConnectPacket(packet) {
+			continue
+		}
+
+		if t == nil {
+			t = &types.TNS{
+				Version: int32(binary.BigEndian.Uint16(packet[8:10])),
+			}
+		}
+
+		var (
+			length = int(binary.BigEndian.Uint16(packet[24:26]))
+			offset = int(binary.BigEndian.Uint16(packet[26:28]))
+			cd     []byte
+		)
+
+		switch {
+		case length == 0:
+			continue
+		case offset >= connectHeaderSize && offset+length <= len(packet):
+			cd = packet[offset : offset+length]
+		case len(packet) <= offset && length <= len(rest):
+			// large connect data is sent directly after the connect packet
+			cd = rest[:length]
+			data = rest[length:]
+		default:
+			continue
+		}
+
+		setConnectData(t, string(cd))
+
+		return t
+	}
+
+	return t
+}
+
+// setConnectData sets the service and client information from the connect descriptor on the audit record.
+func setConnectData(t *types.TNS, descriptor string) {
+	t.ConnectData = descriptor
+	t.ServiceName = connectValue(reServiceName, descriptor)
+	t.SID = connectValue(reSID, descriptor)
+
+	// the HOST parameter is also used for the server address, only use it from the client identifier
+	if m := reCID.FindStringSubmatch(descriptor); len(m) > 1 {
+		t.Program = connectValue(reProgram, m[1])
+		t.ClientHost = connectValue(reHost, m[1])
+		t.ClientUser = connectValue(reUser, m[1])
+	}
+}
+
+// parseResponse looks for the response of the server to the connect packet.
+func parseResponse(data []byte, t *types.TNS) {
+	for len(data) > 0 {
+		typ, packet, rest, ok := nextPacket(data)
+		if !ok {
+			return
+		}
+
+		data = rest
+
+		res, ok := responses[typ]
+		if !ok {
+			continue
+		}
+
+		t.Response = res
+
+		if typ == packetRefuse {
+			t.Error = connectValue(reError, string(packet[headerSize:]))
+		}
+
+		return
+	}
+}
+
+// connectValue returns the value of the parameter matched by re in the connect descriptor.
+func connectValue(re *regexp.Regexp, descriptor string) string {
+	if m := re.FindStringSubmatch(descriptor); len(m) > 1 {
+		return m[1]
+	}
+
+	return ""
+}
+
+// writeTNS writes the audit record and updates the metrics if enabled.
+func writeTNS(t *types.TNS) {
+	if decoderconfig.Instance.ExportMetrics {
+		t.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(t)
+	if err != nil {
+		tnsLog.Error("failed to write TNS audit record", zap.Error(err))
+	}
+}
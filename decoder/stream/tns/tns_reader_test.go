@@ -0,0 +1,92 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tns
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+const testDescriptor = "(DESCRIPTION=(CONNECT_DATA=(SERVICE_NAME=orcl.example.com)(CID=(PROGRAM=C:\\app\\sqlplus.exe)(HOST=WORKSTATION1)(USER=alice)))(ADDRESS=(PROTOCOL=TCP)(HOST=10.0.0.5)(PORT=1521)))"
+
+func packet(typ byte, body []byte) []byte {
+	p := make([]byte, headerSize, headerSize+len(body))
+	binary.BigEndian.PutUint16(p[0:2], uint16(headerSize+len(body)))
+	p[4] = typ
+
+	return append(p, body...)
+}
+
+// connectPacket creates a connect packet, if inline is false the connect data is appended after the packet.
+func connectPacket(descriptor string, inline bool) []byte {
+	body := make([]byte, 50)
+	binary.BigEndian.PutUint16(body[0:2], 314) // version
+	binary.BigEndian.PutUint16(body[2:4], 300) // compatible version
+	binary.BigEndian.PutUint16(body[16:18], uint16(len(descriptor)))
+	binary.BigEndian.PutUint16(body[18:20], uint16(headerSize+len(body)))
+
+	if inline {
+		return packet(packetConnect, append(body, descriptor...))
+	}
+
+	return append(packet(packetConnect, body), descriptor...)
+}
+
+func TestParseConnect(t *testing.T) {
+	for _, inline := range []bool{true, false} {
+		data := connectPacket(testDescriptor, inline)
+
+		if !isConnectPacket(data) {
+			t.Fatal("expected valid connect packet")
+		}
+
+		r := parseConnect(data)
+		if r == nil {
+			t.Fatal("no connect packet found")
+		}
+
+		if r.Version != 314 || r.ConnectData != testDescriptor {
+			t.Fatal("unexpected connect data", r.Version, r.ConnectData)
+		}
+
+		if r.ServiceName != "orcl.example.com" || r.SID != "" {
+			t.Fatal("unexpected service", r.ServiceName, r.SID)
+		}
+
+		if r.Program != "C:\\app\\sqlplus.exe" || r.ClientHost != "WORKSTATION1" || r.ClientUser != "alice" {
+			t.Fatal("unexpected client identifier", r.Program, r.ClientHost, r.ClientUser)
+		}
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	r := parseConnect(connectPacket("(DESCRIPTION=(CONNECT_DATA=(SID = XE)))", true))
+	if r == nil || r.SID != "XE" {
+		t.Fatal("expected SID XE, got", r)
+	}
+
+	refuse := append([]byte{0x01, 0x00, 0x00, 0x00}, "(DESCRIPTION=(TMP=)(VSNNUM=0)(ERR=12505)(ERROR_STACK=(ERROR=(CODE=12505)(EMFI=4))))"...)
+	server := append(packet(packetResend, nil), packet(packetRefuse, refuse)...)
+
+	parseResponse(server, r)
+
+	if r.Response != "Refuse" || r.Error != "12505" {
+		t.Fatal("unexpected response", r.Response, r.Error)
+	}
+
+	// data packet
+	if isConnectPacket(packet(6, []byte("SELECT * FROM dual"))) {
+		t.Fatal("data packet must not be detected as connect packet")
+	}
+}
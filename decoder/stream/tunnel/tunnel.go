@@ -0,0 +1,132 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tunnel
+
+import (
+	"log"
+	"sync/atomic"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.AbstractDecoder{
+	Type:        types.Type_NC_Tunnel,
+	Name:        "Tunnel",
+	Description: "A connection that has been decapsulated from a GRE or VXLAN tunnel",
+}
+
+// tunnel types.
+const (
+	typeGRE   = "GRE"
+	typeVXLAN = "VXLAN"
+)
+
+// maxDepth limits the number of nested tunnels that are stripped from a packet.
+const maxDepth = 4
+
+// Decapsulate strips the tunnel headers that are enabled in the configuration from the packet,
+// and decodes the encapsulated packet again, so the inner connection can be reassembled.
+// The returned tunnel describes the outer packet of the outermost stripped tunnel.
+// If the packet is not tunneled, nil is returned for both values.
+func Decapsulate(p gopacket.Packet) (gopacket.Packet, *types.Tunnel) {
+	if !decoderconfig.Instance.DecapGRE && !decoderconfig.Instance.DecapVXLAN {
+		return nil, nil
+	}
+
+	var t *types.Tunnel
+
+	for i := 0; i < maxDepth; i++ {
+		payload, next, outer := tunnelLayer(p)
+		if outer == nil {
+			break
+		}
+
+		if t == nil {
+			t = outer
+		}
+
+		inner := gopacket.NewPacket(payload, next, gopacket.NoCopy)
+		*inner.Metadata() = *p.Metadata()
+		p = inner
+	}
+
+	if t == nil {
+		return nil, nil
+	}
+
+	return p, t
+}
+
+// tunnelLayer searches the packet for the first enabled tunnel layer,
+// and returns the encapsulated data, the type of its first layer and the outer addresses of the tunnel.
+func tunnelLayer(p gopacket.Packet) ([]byte, gopacket.LayerType, *types.Tunnel) {
+	t := new(types.Tunnel)
+
+	for _, l := range p.Layers() {
+		switch layer := l.(type) {
+		case *layers.IPv4:
+			t.SrcIP, t.DstIP = layer.SrcIP.String(), layer.DstIP.String()
+			t.SrcPort, t.DstPort = 0, 0
+		case *layers.IPv6:
+			t.SrcIP, t.DstIP = layer.SrcIP.String(), layer.DstIP.String()
+			t.SrcPort, t.DstPort = 0, 0
+		case *layers.UDP:
+			t.SrcPort, t.DstPort = int32(layer.SrcPort), int32(layer.DstPort)
+		case *layers.GRE:
+			if decoderconfig.Instance.DecapGRE {
+				t.Type = typeGRE
+
+				if layer.KeyPresent {
+					t.Key = layer.Key
+				}
+
+				return layer.Payload, layer.NextLayerType(), t
+			}
+		case *layers.VXLAN:
+			if decoderconfig.Instance.DecapVXLAN {
+				t.Type = typeVXLAN
+				t.Key = layer.VNI
+
+				return layer.Payload, layers.LayerTypeEthernet, t
+			}
+		}
+	}
+
+	return nil, gopacket.LayerTypeZero, nil
+}
+
+// WriteTunnel writes the tunnel of a new inner connection, if the decoder has been initialized.
+func WriteTunnel(t *types.Tunnel) {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	if decoderconfig.Instance.ExportMetrics {
+		t.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(t)
+	if err != nil {
+		log.Fatal("failed to write proto: ", err)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tunnel
+
+import (
+	"net"
+	"testing"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func serialize(t *testing.T, l ...gopacket.SerializableLayer) gopacket.Packet {
+	t.Helper()
+
+	buf := gopacket.NewSerializeBuffer()
+
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, l...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+}
+
+func ethernet(next layers.EthernetType) *layers.Ethernet {
+	return &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 5},
+		DstMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 6},
+		EthernetType: next,
+	}
+}
+
+func ipv4(src, dst string, next layers.IPProtocol) *layers.IPv4 {
+	return &layers.IPv4{
+		Version:  4,
+		IHL:      5,
+		TTL:      64,
+		Protocol: next,
+		SrcIP:    net.ParseIP(src),
+		DstIP:    net.ParseIP(dst),
+	}
+}
+
+var innerTCP = &layers.TCP{SrcPort: 51000, DstPort: 80, SYN: true}
+
+func TestDecapsulate(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{}
+
+	var (
+		gre = serialize(t,
+			ethernet(layers.EthernetTypeIPv4),
+			ipv4("10.0.0.1", "10.0.0.2", layers.IPProtocolGRE),
+			&layers.GRE{Protocol: layers.EthernetTypeIPv4, KeyPresent: true, Key: 7},
+			ipv4("192.168.1.1", "192.168.1.2", layers.IPProtocolTCP),
+			innerTCP,
+		)
+		vxlan = serialize(t,
+			ethernet(layers.EthernetTypeIPv4),
+			ipv4("10.0.0.1", "10.0.0.2", layers.IPProtocolUDP),
+			&layers.UDP{SrcPort: 40000, DstPort: 4789},
+			&layers.VXLAN{ValidIDFlag: true, VNI: 42},
+			ethernet(layers.EthernetTypeIPv4),
+			ipv4("192.168.1.1", "192.168.1.2", layers.IPProtocolTCP),
+			innerTCP,
+		)
+	)
+
+	// decapsulation is disabled by default
+	if inner, tun := Decapsulate(gre); inner != nil || tun != nil {
+		t.Fatal("expected no decapsulation")
+	}
+
+	decoderconfig.Instance.DecapGRE = true
+
+	if inner, _ := Decapsulate(vxlan); inner != nil {
+		t.Fatal("expected VXLAN to be skipped")
+	}
+
+	decoderconfig.Instance.DecapVXLAN = true
+
+	for _, tt := range []struct {
+		packet   gopacket.Packet
+		expected types.Tunnel
+	}{
+		{gre, types.Tunnel{Type: typeGRE, SrcIP: "10.0.0.1", DstIP: "10.0.0.2", Key: 7}},
+		{vxlan, types.Tunnel{Type: typeVXLAN, SrcIP: "10.0.0.1", SrcPort: 40000, DstIP: "10.0.0.2", DstPort: 4789, Key: 42}},
+	} {
+		inner, tun := Decapsulate(tt.packet)
+		if inner == nil || tun == nil {
+			t.Fatal("expected", tt.expected.Type, "to be decapsulated")
+		}
+
+		if *tun != tt.expected {
+			t.Errorf("expected tunnel %+v, got %+v", tt.expected, *tun)
+		}
+
+		if flow := inner.NetworkLayer().NetworkFlow().String(); flow != "192.168.1.1->192.168.1.2" {
+			t.Errorf("%s: unexpected inner network flow %s", tun.Type, flow)
+		}
+
+		if inner.Layer(layers.LayerTypeTCP) == nil {
+			t.Errorf("%s: inner TCP layer missing", tun.Type)
+		}
+	}
+}
@@ -20,12 +20,14 @@ import (
 	"time"
 
 	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
 
 	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
 	"github.com/dreadl0ck/netcap/decoder/core"
 	"github.com/dreadl0ck/netcap/decoder/stream"
 	"github.com/dreadl0ck/netcap/decoder/stream/service"
 	streamutils "github.com/dreadl0ck/netcap/decoder/stream/utils"
+	"github.com/dreadl0ck/netcap/dpi"
 	"github.com/dreadl0ck/netcap/reassembly"
 	"github.com/dreadl0ck/netcap/resolvers"
 	"github.com/dreadl0ck/netcap/utils"
@@ -41,12 +43,24 @@ type udpStream struct {
 	sync.Mutex
 	data    core.DataFragments
 	decoder core.StreamDecoderInterface
+
+	// destination hardware address of the first packet, empty if there was no link layer
+	serverMAC string
+
+	// capture timestamp of the last packet seen for the stream
+	lastSeen time.Time
 }
 
 // udpStreamPool holds a pool of UDP streams.
 type udpStreamPool struct {
 	sync.Mutex
 	streams map[uint64]*udpStream
+
+	// capture timestamp of the last check for idle streams
+	lastExpiry time.Time
+
+	// streams idle since this time have been expired by the last check
+	lastCutoff time.Time
 }
 
 func newUDPStreamPool() *udpStreamPool {
@@ -63,7 +77,29 @@ func (u *udpStreamPool) size() int {
 }
 
 // HandleUDP takes an UDP packet and tracks the data seen for the conversation.
-func (u *udpStreamPool) HandleUDP(packet gopacket.Packet, udpLayer gopacket.Layer) {
+// It returns true if the packet started a new conversation.
+// Streams that have been idle for longer than the UDPStreamTimeout are processed and evicted afterwards.
+func (u *udpStreamPool) HandleUDP(packet gopacket.Packet, udpLayer gopacket.Layer) bool {
+	ts := packet.Metadata().Timestamp
+	isNew := u.add(packet, udpLayer)
+
+	// process streams that have not seen any packets for a while, to keep the memory usage bounded
+	if expired := u.expiredStreams(ts); len(expired) > 0 {
+		streamutils.Stats.Lock()
+		streamutils.Stats.ExpiredUDPStreams += int64(len(expired))
+		streamutils.Stats.Unlock()
+
+		processUDPStreams(expired, false)
+	}
+
+	return isNew
+}
+
+// add appends the payload of the packet to its stream, or creates a new stream.
+// It returns true if the packet started a new conversation.
+func (u *udpStreamPool) add(packet gopacket.Packet, udpLayer gopacket.Layer) bool {
+	ts := packet.Metadata().Timestamp
+
 	u.Lock()
 	if s, ok := u.streams[packet.TransportLayer().TransportFlow().FastHash()]; ok {
 		u.Unlock()
@@ -75,24 +111,81 @@ func (u *udpStreamPool) HandleUDP(packet gopacket.Packet, udpLayer gopacket.Laye
 			Trans:              packet.TransportLayer().TransportFlow(),
 			Net:                packet.NetworkLayer().NetworkFlow(),
 		})
+		if ts.After(s.lastSeen) {
+			s.lastSeen = ts
+		}
 		s.Unlock()
-	} else {
-		// add new
-		str := new(udpStream)
-		str.data = append(str.data, &core.StreamData{
-			RawData:            udpLayer.LayerPayload(),
-			CaptureInformation: packet.Metadata().CaptureInfo,
-			Trans:              packet.TransportLayer().TransportFlow(),
-			Net:                packet.NetworkLayer().NetworkFlow(),
-		})
-		u.streams[packet.TransportLayer().TransportFlow().FastHash()] = str
-		u.Unlock()
+
+		return false
 	}
+
+	// add new
+	str := &udpStream{
+		lastSeen: ts,
+	}
+	if ll := packet.LinkLayer(); ll != nil {
+		str.serverMAC = ll.LinkFlow().Dst().String()
+	}
+
+	str.data = append(str.data, &core.StreamData{
+		RawData:            udpLayer.LayerPayload(),
+		CaptureInformation: packet.Metadata().CaptureInfo,
+		Trans:              packet.TransportLayer().TransportFlow(),
+		Net:                packet.NetworkLayer().NetworkFlow(),
+	})
+	u.streams[packet.TransportLayer().TransportFlow().FastHash()] = str
+	u.Unlock()
+
+	return true
+}
+
+// expiredStreams removes and returns all streams that have been idle for longer than the UDPStreamTimeout.
+// The capture timestamp of the current packet is used as reference, so replaying a pcap behaves like the live capture.
+// To avoid walking the pool for every packet, the check runs at most once per timeout interval.
+func (u *udpStreamPool) expiredStreams(ts time.Time) []*udpStream {
+	timeout := decoderconfig.Instance.UDPStreamTimeout
+	if timeout <= 0 {
+		return nil
+	}
+
+	u.Lock()
+	defer u.Unlock()
+
+	switch {
+	case u.lastExpiry.IsZero():
+		// start counting from the first packet
+		u.lastExpiry = ts
+		return nil
+	case ts.Sub(u.lastExpiry) < timeout:
+		return nil
+	}
+
+	u.lastExpiry = ts
+
+	// forget the DPI results of flows that have been idle since the previous check,
+	// the streams that expired back then have already been decoded, or were never collected.
+	dpi.ExpireFlowProtocols(layers.LayerTypeUDP, u.lastCutoff)
+	u.lastCutoff = ts.Add(-timeout)
+
+	var expired []*udpStream
+
+	for hash, s := range u.streams {
+		s.Lock()
+		idle := ts.Sub(s.lastSeen) > timeout
+		s.Unlock()
+
+		if idle {
+			expired = append(expired, s)
+			delete(u.streams, hash)
+		}
+	}
+
+	return expired
 }
 
 // saves the banner for a UDP service to the filesystem
 // and limits the length of the saved data to the BannerSize value from the config.
-func saveUDPServiceBanner(banner []byte, flowIdent string, serviceIdent string, firstPacket time.Time, serverBytes int, clientBytes int, net gopacket.Flow, transport gopacket.Flow) {
+func saveUDPServiceBanner(banner []byte, flowIdent string, serviceIdent string, firstPacket time.Time, serverBytes int, clientBytes int, net gopacket.Flow, transport gopacket.Flow, serverMAC string) {
 	// limit length of data
 	if len(banner) >= decoderconfig.Instance.BannerSize {
 		banner = banner[:decoderconfig.Instance.BannerSize]
@@ -115,7 +208,7 @@ func saveUDPServiceBanner(banner []byte, flowIdent string, serviceIdent string,
 	service.Store.Unlock()
 
 	// nope. lets create a new one
-	serv := service.NewService(firstPacket.UnixNano(), serverBytes, clientBytes, net.Dst().String())
+	serv := service.NewService(firstPacket.UnixNano(), serverBytes, clientBytes, net.Dst().String(), serverMAC)
 	serv.Banner = string(banner)
 	serv.IP = net.Dst().String()
 	serv.Port = utils.DecodePort(transport.Dst().Raw())
@@ -141,6 +234,21 @@ func saveUDPServiceBanner(banner []byte, flowIdent string, serviceIdent string,
 	streamutils.Stats.Unlock()
 }
 
+// signatureHeads returns up to size bytes from the start of the client and server datagrams.
+func signatureHeads(data core.DataFragments, clientTransport gopacket.Flow, size int) (client, server []byte) {
+	var c, s core.DataFragments
+
+	for _, d := range data {
+		if d.Transport() == clientTransport {
+			c = append(c, d)
+		} else {
+			s = append(s, d)
+		}
+	}
+
+	return c.Head(size), s.Head(size)
+}
+
 // TODO: ensure that only decoders of protocols are called that actually support being transported via UDP
 // TODO: specify transport protocols during decoder creation
 func (u *udpStream) decode() {
@@ -188,8 +296,28 @@ func (u *udpStream) decode() {
 		ServerPort:        utils.DecodePort(u.data[0].Transport().Dst().Raw()),
 	}
 
-	// make a good first guess based on the destination port of the connection
-	if sd, exists := stream.DefaultStreamDecoders[utils.DecodePort(u.data[0].Transport().Dst().Raw())]; exists {
+	// check the configured payload signatures against the start of the conversation,
+	// like for TCP the data of each direction is inspected up to the configured size
+	cHead, sHead := cr, sr
+	if size := decoderconfig.Instance.SignatureSize; size > 0 {
+		cHead, sHead = signatureHeads(u.data, clientTransport, size)
+	}
+
+	if sd := stream.MatchSignatures(cHead, sHead, core.UDP); sd != nil {
+		u.decoder = sd.GetReaderFactory().New(conv)
+		found = true
+	}
+
+	// use the protocols identified by DPI for the stream, to handle protocols on non-standard ports
+	if protocols := dpi.ConsumeFlowProtocols(u.data[0].Network(), u.data[0].Transport()); !found && len(protocols) > 0 {
+		if sd := stream.MatchDPIProtocols(protocols, core.UDP); sd != nil {
+			u.decoder = sd.GetReaderFactory().New(conv)
+			found = true
+		}
+	}
+
+	// if no signature matched, make a good first guess based on the destination port of the connection
+	if sd, exists := stream.DefaultStreamDecoders[utils.DecodePort(u.data[0].Transport().Dst().Raw())]; !found && exists {
 		if sd.Transport() == core.UDP || sd.Transport() == core.All {
 			if sd.GetReaderFactory() != nil && sd.CanDecodeStream(cr, sr) {
 				u.decoder = sd.GetReaderFactory().New(conv)
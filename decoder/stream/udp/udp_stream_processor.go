@@ -54,9 +54,28 @@ func init() {
 
 // FlushUDPStreams will flush all collected UDP streams to disk.
 func FlushUDPStreams() {
-	numTotal := Streams.size()
+	Streams.Lock()
+
+	streams := make([]*udpStream, 0, len(Streams.streams))
+	for _, s := range Streams.streams {
+		if s != nil { // never feed a nil stream
+			streams = append(streams, s)
+		}
+	}
+
+	Streams.Unlock()
+
+	// flush the remaining streams to disk
+	processUDPStreams(streams, !decoderconfig.Instance.Quiet)
+}
 
-	sp := new(udpStreamProcessor)
+// processUDPStreams decodes and saves the passed streams in parallel and waits until all of them are done.
+func processUDPStreams(streams []*udpStream, printProgress bool) {
+	numTotal := len(streams)
+
+	sp := &udpStreamProcessor{
+		printProgress: printProgress,
+	}
 	if numTotal < decoderconfig.Instance.NumStreamWorkers {
 		sp.initWorkers(decoderconfig.Instance.StreamBufferSize, numTotal)
 	} else {
@@ -64,17 +83,10 @@ func FlushUDPStreams() {
 	}
 	sp.numTotal = numTotal
 
-	Streams.Lock()
-
-	// flush the remaining streams to disk
-	for _, s := range Streams.streams {
-		if s != nil { // never feed a nil stream
-			sp.handleStream(s)
-		}
+	for _, s := range streams {
+		sp.handleStream(s)
 	}
 
-	Streams.Unlock()
-
 	// reassemblyLog.Info("waiting for stream processor wait group... ")
 	sp.wg.Wait()
 
@@ -95,6 +107,7 @@ type udpStreamProcessor struct {
 	numDone          int
 	numTotal         int
 	streamBufferSize int
+	printProgress    bool
 }
 
 // to process the streams in parallel
@@ -196,12 +209,13 @@ func (usp *udpStreamProcessor) streamWorker(wg *sync.WaitGroup) chan *udpStream
 				clientBytes,
 				clientNetwork,
 				clientTransport,
+				s.serverMAC,
 			)
 
 			usp.Lock()
 			usp.numDone++
 
-			if !decoderconfig.Instance.Quiet {
+			if usp.printProgress {
 				utils.ClearLine()
 				fmt.Print("processing UDP streams... ", "(", usp.numDone, "/", usp.numTotal, ")")
 			}
@@ -0,0 +1,112 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package udp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+)
+
+func TestExpiredStreams(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{
+		UDPStreamTimeout: time.Minute,
+	}
+
+	var (
+		start = time.Unix(1600000000, 0)
+		pool  = newUDPStreamPool()
+		idle  = &udpStream{lastSeen: start}
+		busy  = &udpStream{lastSeen: start.Add(90 * time.Second)}
+	)
+
+	pool.streams[1] = idle
+	pool.streams[2] = busy
+
+	// the first call only starts counting
+	if expired := pool.expiredStreams(start); len(expired) != 0 {
+		t.Fatal("expected no expired streams on the first packet, got", len(expired))
+	}
+
+	// not due yet
+	if expired := pool.expiredStreams(start.Add(30 * time.Second)); len(expired) != 0 {
+		t.Fatal("expected no expired streams before the timeout passed, got", len(expired))
+	}
+
+	expired := pool.expiredStreams(start.Add(2 * time.Minute))
+	if len(expired) != 1 || expired[0] != idle {
+		t.Fatal("expected the idle stream to expire, got", expired)
+	}
+
+	if pool.size() != 1 {
+		t.Fatal("expected the idle stream to be evicted, pool size", pool.size())
+	}
+
+	if _, ok := pool.streams[2]; !ok {
+		t.Fatal("expected the active stream to be kept")
+	}
+}
+
+func TestExpiredStreamsDisabled(t *testing.T) {
+	decoderconfig.Instance = &decoderconfig.Config{
+		UDPStreamTimeout: 0,
+	}
+
+	var (
+		start = time.Unix(1600000000, 0)
+		pool  = newUDPStreamPool()
+	)
+
+	pool.streams[1] = &udpStream{lastSeen: start}
+
+	pool.expiredStreams(start)
+	if expired := pool.expiredStreams(start.Add(24 * time.Hour)); len(expired) != 0 {
+		t.Fatal("expected no expired streams when the timeout is disabled, got", len(expired))
+	}
+}
+
+func TestSignatureHeads(t *testing.T) {
+	var (
+		client = gopacket.NewFlow(layers.EndpointUDPPort, []byte{0x9c, 0x40}, []byte{0x00, 0x35})
+		server = client.Reverse()
+		data   = core.DataFragments{
+			&core.StreamData{RawData: []byte("abcd"), Trans: client},
+			&core.StreamData{RawData: []byte("1234"), Trans: server},
+			&core.StreamData{RawData: []byte("efgh"), Trans: client},
+			&core.StreamData{RawData: []byte("5678"), Trans: server},
+		}
+	)
+
+	tests := []struct {
+		size   int
+		client string
+		server string
+	}{
+		{2, "ab", "12"},
+		{6, "abcdef", "123456"},
+		{64, "abcdefgh", "12345678"},
+	}
+
+	for _, test := range tests {
+		c, s := signatureHeads(data, client, test.size)
+		if string(c) != test.client || string(s) != test.server {
+			t.Fatal("unexpected heads for size", test.size, ":", string(c), string(s))
+		}
+	}
+}
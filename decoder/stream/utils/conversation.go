@@ -0,0 +1,142 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/mgutz/ansi"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+)
+
+// ErrInvalidConversation occurs when a conversation file does not contain any colored client or server data.
+var ErrInvalidConversation = errors.New("no client or server data found in conversation")
+
+// ConversationSegment is a chunk of data that has been sent in one direction of a saved conversation.
+type ConversationSegment struct {
+	Client bool
+	Data   []byte
+}
+
+// ConversationColors returns the color codes that enclose the client and server data of saved conversations.
+// The colors default to red for the client and blue for the server,
+// all codes are empty if colors have been disabled in the configuration.
+func ConversationColors(conf *decoderconfig.Config) (client, server, reset string) {
+	client, server = ansi.Red, ansi.Blue
+
+	if conf == nil {
+		return client, server, ansi.Reset
+	}
+
+	if conf.ConnsNoColor {
+		return "", "", ""
+	}
+
+	if conf.ConnsClientColor != "" {
+		client = ansi.ColorCode(conf.ConnsClientColor)
+	}
+
+	if conf.ConnsServerColor != "" {
+		server = ansi.ColorCode(conf.ConnsServerColor)
+	}
+
+	return client, server, ansi.Reset
+}
+
+// GapSentinel returns the marker that is written into saved conversations at the position of missing bytes.
+// The marker is placed outside of the color codes, so it is ignored when parsing the conversation.
+func GapSentinel(missing int) string {
+	if missing < 0 {
+		return "\n[netcap: unknown number of bytes missing]\n"
+	}
+
+	return "\n[netcap: " + strconv.Itoa(missing) + " bytes missing]\n"
+}
+
+// ParseConversation splits a conversation that has been saved by SaveConversation with the default colors into its segments.
+// Client data is enclosed in red and server data in blue color codes, everything outside
+// of the color codes (e.g. the timestamps added in debug mode) is ignored.
+// Note that data containing the reset color code itself will be truncated.
+func ParseConversation(data []byte) ([]ConversationSegment, error) {
+	return ParseConversationColors(data, ansi.Red, ansi.Blue)
+}
+
+// ParseConversationColors splits a conversation that has been saved with custom colors for the client and server data into its segments.
+func ParseConversationColors(data []byte, client, server string) ([]ConversationSegment, error) {
+	var (
+		segments []ConversationSegment
+		red      = []byte(client)
+		blue     = []byte(server)
+		reset    = []byte(ansi.Reset)
+	)
+
+	// the directions can not be distinguished if colors have been disabled
+	if len(red) == 0 || len(blue) == 0 || len(reset) == 0 || client == server {
+		return nil, ErrInvalidConversation
+	}
+
+	for len(data) > 0 {
+		var (
+			r = bytes.Index(data, red)
+			b = bytes.Index(data, blue)
+			s ConversationSegment
+		)
+
+		switch {
+		case r == -1 && b == -1:
+			data = nil
+
+			continue
+		case b == -1 || (r != -1 && r < b):
+			s.Client = true
+			data = data[r+len(red):]
+		default:
+			data = data[b+len(blue):]
+		}
+
+		end := bytes.Index(data, reset)
+		if end == -1 {
+			end = len(data)
+		}
+
+		s.Data = data[:end]
+		data = data[end:]
+
+		if len(data) >= len(reset) {
+			data = data[len(reset):]
+		}
+
+		if len(s.Data) == 0 {
+			continue
+		}
+
+		// merge consecutive segments from the same direction
+		if n := len(segments); n > 0 && segments[n-1].Client == s.Client {
+			segments[n-1].Data = append(segments[n-1].Data, s.Data...)
+
+			continue
+		}
+
+		segments = append(segments, s)
+	}
+
+	if len(segments) == 0 {
+		return nil, ErrInvalidConversation
+	}
+
+	return segments, nil
+}
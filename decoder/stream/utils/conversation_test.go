@@ -0,0 +1,91 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mgutz/ansi"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+)
+
+func TestParseConversation(t *testing.T) {
+	data := ansi.Red + "GET / HTTP/1.1\r\n" + ansi.Reset +
+		ansi.Red + "Host: example.com\r\n\r\n" + ansi.Reset +
+		"\n[2020-01-01 00:00:00 +0000 UTC]\n" +
+		GapSentinel(1460) +
+		ansi.Blue + "HTTP/1.1 200 OK\r\n\r\n" + ansi.Reset +
+		GapSentinel(-1) +
+		ansi.Red + "QUIT" + ansi.Reset
+
+	segments, err := ParseConversation([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ConversationSegment{
+		{Client: true, Data: []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")},
+		{Client: false, Data: []byte("HTTP/1.1 200 OK\r\n\r\n")},
+		{Client: true, Data: []byte("QUIT")},
+	}
+
+	if len(segments) != len(expected) {
+		t.Fatal("expected", len(expected), "segments, got", len(segments))
+	}
+
+	for i, s := range segments {
+		if s.Client != expected[i].Client || string(s.Data) != string(expected[i].Data) {
+			t.Fatalf("segment %d: got %v %q, expected %v %q", i, s.Client, s.Data, expected[i].Client, expected[i].Data)
+		}
+	}
+
+	if _, err = ParseConversation([]byte("plain text")); !errors.Is(err, ErrInvalidConversation) {
+		t.Fatal("expected ErrInvalidConversation, got", err)
+	}
+}
+
+func TestConversationColors(t *testing.T) {
+	client, server, reset := ConversationColors(nil)
+	if client != ansi.Red || server != ansi.Blue || reset != ansi.Reset {
+		t.Fatal("expected red and blue as default colors")
+	}
+
+	conf := &decoderconfig.Config{
+		ConnsClientColor: "yellow",
+		ConnsServerColor: "green",
+	}
+
+	client, server, reset = ConversationColors(conf)
+	if client != ansi.ColorCode("yellow") || server != ansi.ColorCode("green") {
+		t.Fatal("unexpected colors", client, server)
+	}
+
+	segments, err := ParseConversationColors([]byte(client+"ping"+reset+server+"pong"+reset), client, server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(segments) != 2 || !segments[0].Client || string(segments[1].Data) != "pong" {
+		t.Fatalf("unexpected segments: %+v", segments)
+	}
+
+	conf.ConnsNoColor = true
+
+	client, server, reset = ConversationColors(conf)
+	if client != "" || server != "" || reset != "" {
+		t.Fatal("expected no color codes")
+	}
+}
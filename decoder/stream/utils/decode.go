@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -18,9 +19,30 @@ func DecodeConversation(
 	data core.DataFragments,
 	client func(buf *bufio.Reader) error,
 	server func(buf *bufio.Reader) error,
+) {
+	DecodeConversationTimestamps(
+		ident,
+		data,
+		func(buf *bufio.Reader, _ time.Time) error {
+			return client(buf)
+		},
+		func(buf *bufio.Reader, _ time.Time) error {
+			return server(buf)
+		},
+	)
+}
+
+// DecodeConversationTimestamps works like DecodeConversation,
+// but additionally passes the capture time of the fragment in which the next message starts to the callbacks.
+func DecodeConversationTimestamps(
+	ident string,
+	data core.DataFragments,
+	client func(buf *bufio.Reader, ts time.Time) error,
+	server func(buf *bufio.Reader, ts time.Time) error,
 ) {
 	var (
 		buf         bytes.Buffer
+		fragments   []fragmentTime
 		previousDir reassembly.TCPFlowDirection
 	)
 
@@ -30,52 +52,67 @@ func DecodeConversation(
 
 	// parse conversation
 	for _, d := range data {
-		if d.Direction() == previousDir {
-			buf.Write(d.Raw())
-		} else {
-			var (
-				err error
-				b   = bufio.NewReader(&buf)
-			)
-
-			if previousDir == reassembly.TCPDirClientToServer {
-				for !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
-					err = client(b)
-				}
-			} else {
-				for !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
-					err = server(b)
-				}
-			}
-			if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
-				reassemblyLog.Error("error reading stream",
-					zap.Error(err),
-					zap.String("ident", ident),
-				)
-			}
+		if d.Direction() != previousDir {
+			decodeDirection(ident, previousDir, buf.Bytes(), fragments, client, server)
+
 			buf.Reset()
+			fragments = fragments[:0]
 			previousDir = d.Direction()
-
-			buf.Write(d.Raw())
-
-			continue
 		}
+
+		fragments = append(fragments, fragmentTime{
+			offset:    buf.Len(),
+			timestamp: d.CaptureInfo().Timestamp,
+		})
+		buf.Write(d.Raw())
 	}
 
+	decodeDirection(ident, previousDir, buf.Bytes(), fragments, client, server)
+}
+
+// fragmentTime marks the offset of a fragment in the data of one direction and its capture time.
+type fragmentTime struct {
+	offset    int
+	timestamp time.Time
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+
+	return n, err
+}
+
+// decodeDirection invokes the callback for the direction until all data has been consumed.
+func decodeDirection(
+	ident string,
+	dir reassembly.TCPFlowDirection,
+	data []byte,
+	fragments []fragmentTime,
+	client func(buf *bufio.Reader, ts time.Time) error,
+	server func(buf *bufio.Reader, ts time.Time) error,
+) {
 	var (
-		err error
-		b   = bufio.NewReader(&buf)
+		err  error
+		r    = &countingReader{r: bytes.NewReader(data)}
+		b    = bufio.NewReader(r)
+		read = server
 	)
 
-	if previousDir == reassembly.TCPDirClientToServer {
-		for !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
-			err = client(b)
-		}
-	} else {
-		for !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
-			err = server(b)
-		}
+	if dir == reassembly.TCPDirClientToServer {
+		read = client
+	}
+
+	for !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		err = read(b, timestampAt(fragments, r.n-b.Buffered()))
 	}
+
 	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
 		reassemblyLog.Error("error reading stream",
 			zap.Error(err),
@@ -83,3 +120,18 @@ func DecodeConversation(
 		)
 	}
 }
+
+// timestampAt returns the capture time of the fragment that contains the given offset.
+func timestampAt(fragments []fragmentTime, offset int) time.Time {
+	var ts time.Time
+
+	for _, f := range fragments {
+		if f.offset > offset {
+			break
+		}
+
+		ts = f.timestamp
+	}
+
+	return ts
+}
@@ -0,0 +1,78 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"bufio"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+)
+
+func fragment(dir reassembly.TCPFlowDirection, sec int64, data string) *core.StreamData {
+	return &core.StreamData{
+		RawData:            []byte(data),
+		Dir:                dir,
+		CaptureInformation: gopacket.CaptureInfo{Timestamp: time.Unix(sec, 0)},
+	}
+}
+
+func TestDecodeConversationTimestamps(t *testing.T) {
+	var (
+		requests  []int64
+		responses []int64
+		data      = core.DataFragments{
+			// the second request starts in the first fragment
+			fragment(reassembly.TCPDirClientToServer, 1, "GET /a HTTP/1.1\r\nHost: example.com\r\n\r\nGET /b HT"),
+			fragment(reassembly.TCPDirClientToServer, 2, "TP/1.1\r\nHost: example.com\r\n\r\n"),
+			fragment(reassembly.TCPDirServerToClient, 3, "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"),
+			fragment(reassembly.TCPDirServerToClient, 4, "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n"),
+		}
+	)
+
+	DecodeConversationTimestamps(
+		"test",
+		data,
+		func(b *bufio.Reader, ts time.Time) error {
+			_, err := http.ReadRequest(b)
+			if err == nil {
+				requests = append(requests, ts.Unix())
+			}
+
+			return err
+		},
+		func(b *bufio.Reader, ts time.Time) error {
+			_, err := http.ReadResponse(b, nil)
+			if err == nil {
+				responses = append(responses, ts.Unix())
+			}
+
+			return err
+		},
+	)
+
+	if !reflect.DeepEqual(requests, []int64{1, 1}) {
+		t.Fatal("unexpected request timestamps", requests)
+	}
+
+	if !reflect.DeepEqual(responses, []int64{3, 4}) {
+		t.Fatal("unexpected response timestamps", responses)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Fragment marks the offset of a data fragment in the reassembled data of one direction of a conversation.
+type Fragment struct {
+	Offset    int
+	Timestamp int64
+}
+
+// Fragments are the fragments of one direction of a conversation, ordered by their offset.
+type Fragments []Fragment
+
+// Timestamp returns the timestamp of the fragment that contains the offset.
+// Zero is returned if the offset is located before the first fragment.
+func (f Fragments) Timestamp(offset int) int64 {
+	i := sort.Search(len(f), func(i int) bool {
+		return f[i].Offset > offset
+	})
+	if i == 0 {
+		return 0
+	}
+
+	return f[i-1].Timestamp
+}
+
+// CString returns the string up to the terminating null byte and the number of consumed bytes.
+// If there is no null byte, the remaining data is returned and ok is false.
+func CString(data []byte) (s string, n int, ok bool) {
+	i := bytes.IndexByte(data, 0)
+	if i == -1 {
+		return string(data), len(data), false
+	}
+
+	return string(data[:i]), i + 1, true
+}
@@ -0,0 +1,70 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"testing"
+)
+
+func TestFragmentsTimestamp(t *testing.T) {
+	fragments := Fragments{
+		{Offset: 0, Timestamp: 1},
+		{Offset: 10, Timestamp: 2},
+		{Offset: 25, Timestamp: 3},
+	}
+
+	tests := []struct {
+		offset    int
+		timestamp int64
+	}{
+		{0, 1},
+		{9, 1},
+		{10, 2},
+		{24, 2},
+		{25, 3},
+		{100, 3},
+		{-1, 0},
+	}
+
+	for _, tt := range tests {
+		if ts := fragments.Timestamp(tt.offset); ts != tt.timestamp {
+			t.Fatal("unexpected timestamp for offset", tt.offset, ":", ts, "expected:", tt.timestamp)
+		}
+	}
+
+	if ts := Fragments(nil).Timestamp(0); ts != 0 {
+		t.Fatal("expected zero timestamp without fragments, got", ts)
+	}
+}
+
+func TestCString(t *testing.T) {
+	tests := []struct {
+		data []byte
+		s    string
+		n    int
+		ok   bool
+	}{
+		{[]byte("user\x00root\x00"), "user", 5, true},
+		{[]byte("\x00"), "", 1, true},
+		{[]byte("incomplete"), "incomplete", 10, false},
+		{nil, "", 0, false},
+	}
+
+	for _, tt := range tests {
+		s, n, ok := CString(tt.data)
+		if s != tt.s || n != tt.n || ok != tt.ok {
+			t.Fatal("unexpected result for", tt.data, ":", s, n, ok, "expected:", tt.s, tt.n, tt.ok)
+		}
+	}
+}
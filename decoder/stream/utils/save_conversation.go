@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/dreadl0ck/gopacket"
-	"github.com/mgutz/ansi"
 	"go.uber.org/zap"
 
 	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
@@ -113,18 +112,29 @@ retry:
 	// TODO: make buffer size configurable
 	w := bufio.NewWriterSize(f, 4096)
 
+	var (
+		client, server, reset = ConversationColors(decoderconfig.Instance)
+		incomplete            bool
+	)
+
 	if proto == protoTCP {
 		// create the buffer with the entire conversation
 		for _, d := range conversation {
 
+			// mark the position of missing bytes, only present if incomplete connections are kept
+			if gap := d.Gap(); gap != 0 {
+				incomplete = true
+				_, _ = w.WriteString(GapSentinel(gap))
+			}
+
 			if d.Direction() == reassembly.TCPDirClientToServer {
-				_, _ = w.WriteString(ansi.Red)
+				_, _ = w.WriteString(client)
 				_, _ = w.Write(d.Raw())
-				_, _ = w.WriteString(ansi.Reset)
+				_, _ = w.WriteString(reset)
 			} else {
-				_, _ = w.WriteString(ansi.Blue)
+				_, _ = w.WriteString(server)
 				_, _ = w.Write(d.Raw())
-				_, _ = w.WriteString(ansi.Reset)
+				_, _ = w.WriteString(reset)
 			}
 
 			if decoderconfig.Instance.Debug {
@@ -141,14 +151,14 @@ retry:
 		for _, d := range conversation {
 			if d.Transport() == clientTransport {
 				// client
-				_, _ = w.WriteString(ansi.Red)
+				_, _ = w.WriteString(client)
 				_, _ = w.Write(d.Raw())
-				_, _ = w.WriteString(ansi.Reset)
+				_, _ = w.WriteString(reset)
 			} else {
 				// server
-				_, _ = w.WriteString(ansi.Blue)
+				_, _ = w.WriteString(server)
 				_, _ = w.Write(d.Raw())
-				_, _ = w.WriteString(ansi.Reset)
+				_, _ = w.WriteString(reset)
 			}
 			if decoderconfig.Instance.Debug {
 				_, _ = w.WriteString("\n[" + d.CaptureInfo().Timestamp.String() + "]\n")
@@ -190,6 +200,7 @@ retry:
 			zap.String("proto", proto),
 			zap.String("base", base),
 			zap.String("proto", proto),
+			zap.Bool("incomplete", incomplete),
 		)
 	}
 
@@ -5,26 +5,46 @@ import "sync"
 // Stats contains statistics about the stream reassembly.
 var Stats struct {
 	sync.Mutex
+	Statistics
+}
 
-	IPdefrag            int64
-	MissedBytes         int64
-	Pkt                 int64
-	Sz                  int64
-	Totalsz             int64
-	RejectFsm           int64
-	RejectOpt           int64
-	RejectConnFsm       int64
-	Reassembled         int64
-	OutOfOrderBytes     int64
-	OutOfOrderPackets   int64
-	BiggestChunkBytes   int64
-	BiggestChunkPackets int64
-	OverlapBytes        int64
-	OverlapPackets      int64
-	SavedTCPConnections int64
-	SavedUDPConnections int64
-	NumSoftware         int64
-	NumServices         int64
+// Statistics holds the counters of the stream reassembly.
+type Statistics struct {
+	IPdefrag              int64
+	IPFragmentOverlaps    int64
+	IPFragmentConflicts   int64
+	MissedBytes           int64
+	Pkt                   int64
+	Sz                    int64
+	Totalsz               int64
+	RejectFsm             int64
+	RejectOpt             int64
+	RejectConnFsm         int64
+	RejectClosed          int64
+	Reassembled           int64
+	OutOfOrderBytes       int64
+	OutOfOrderPackets     int64
+	BiggestChunkBytes     int64
+	BiggestChunkPackets   int64
+	OverlapBytes          int64
+	OverlapPackets        int64
+	RetransmittedSegments int64
+	SACKBlocks            int64
+	SavedTCPConnections   int64
+	SavedUDPConnections   int64
+	ExpiredUDPStreams     int64
+	GracefulTCPConns      int64
+	ResetTCPConns         int64
+	TimedOutTCPConns      int64
+	TruncatedTCPConns     int64
+	IncompleteTCPConns    int64
+	InlineTCPStreams      int64
+	DroppedConnections    int64
+	DroppedConnPackets    int64
+	PeakStreamReaders     int64
+	PeakGoroutines        int64
+	NumSoftware           int64
+	NumServices           int64
 
 	Requests  int64
 	Responses int64
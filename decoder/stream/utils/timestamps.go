@@ -0,0 +1,73 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Sources for the primary timestamp of audit records
+// for protocols that transmit their own notion of time, e.g. the HTTP Date header.
+const (
+	// TimestampCapture uses the time the packets have been captured.
+	TimestampCapture = "capture"
+
+	// TimestampProtocol uses the time asserted by the protocol, if present.
+	TimestampProtocol = "protocol"
+)
+
+// errInvalidTimestampSource occurs when an unknown timestamp source has been configured.
+var errInvalidTimestampSource = errors.New("invalid timestamp source")
+
+// set to 1 if the protocol time is used as primary timestamp.
+var useProtocolTime int32
+
+// SetTimestampSource selects the primary timestamp for audit records
+// of protocols that assert their own time: capture or protocol.
+func SetTimestampSource(source string) error {
+	switch source {
+	case TimestampCapture:
+		atomic.StoreInt32(&useProtocolTime, 0)
+	case TimestampProtocol:
+		atomic.StoreInt32(&useProtocolTime, 1)
+	default:
+		return fmt.Errorf("%w: %s", errInvalidTimestampSource, source)
+	}
+
+	return nil
+}
+
+// SelectTimestamp returns the primary timestamp for an audit record.
+// The protocol time is only used if it has been selected as timestamp source and is present,
+// otherwise the capture time is returned.
+func SelectTimestamp(capture, protocol int64) int64 {
+	if protocol != 0 && atomic.LoadInt32(&useProtocolTime) == 1 {
+		return protocol
+	}
+
+	return capture
+}
+
+// ClockSkew returns the difference between the time asserted by the protocol and the capture time in nanoseconds.
+// A large value indicates replayed or old traffic, or a misconfigured clock on the sending host.
+// Zero is returned if either of the timestamps is unknown.
+func ClockSkew(capture, protocol int64) int64 {
+	if capture == 0 || protocol == 0 {
+		return 0
+	}
+
+	return protocol - capture
+}
@@ -0,0 +1,55 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelectTimestamp(t *testing.T) {
+	defer func() {
+		_ = SetTimestampSource(TimestampCapture)
+	}()
+
+	if got := SelectTimestamp(10, 20); got != 10 {
+		t.Fatal("expected capture time by default, got", got)
+	}
+
+	if err := SetTimestampSource(TimestampProtocol); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := SelectTimestamp(10, 20); got != 20 {
+		t.Fatal("expected protocol time, got", got)
+	}
+
+	if got := SelectTimestamp(10, 0); got != 10 {
+		t.Fatal("expected capture time if protocol time is missing, got", got)
+	}
+
+	if err := SetTimestampSource("server"); !errors.Is(err, errInvalidTimestampSource) {
+		t.Fatal("expected errInvalidTimestampSource, got", err)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	if skew := ClockSkew(100, 40); skew != -60 {
+		t.Fatal("expected -60, got", skew)
+	}
+
+	if skew := ClockSkew(100, 0); skew != 0 {
+		t.Fatal("expected no skew for missing protocol time, got", skew)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package wireguard
+
+import (
+	"encoding/binary"
+
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/decoder"
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	logging "github.com/dreadl0ck/netcap/logger"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var wireguardLog = zap.NewNop()
+
+// Decoder for protocol analysis and writing audit records to disk.
+var Decoder = &decoder.StreamDecoder{
+	Type:        types.Type_NC_WireGuard,
+	Name:        "WireGuard",
+	Description: "WireGuard is a VPN protocol over UDP, tunnels are identified by the unencrypted framing of their handshake messages",
+	PostInit: func(d *decoder.StreamDecoder) error {
+		var err error
+		wireguardLog, _, err = logging.InitZapLogger(
+			decoderconfig.Instance.Out,
+			"wireguard",
+			decoderconfig.Instance.Debug,
+		)
+		return err
+	},
+	CanDecode: func(client, server []byte) bool {
+		if isHandshake(client) {
+			return true
+		}
+
+		// the capture started while the tunnel was already established
+		return isTransportData(client) && messageType(server) != ""
+	},
+	DeInit: func(sd *decoder.StreamDecoder) error {
+		return wireguardLog.Sync()
+	},
+	Factory: &wireguardReader{},
+	Typ:     core.UDP,
+}
+
+// message types.
+const (
+	typeHandshakeInitiation = 1
+	typeHandshakeResponse   = 2
+	typeCookieReply         = 3
+	typeTransportData       = 4
+)
+
+// message sizes, the handshake messages have a fixed size.
+const (
+	sizeHandshakeInitiation = 148
+	sizeHandshakeResponse   = 92
+	sizeCookieReply         = 64
+
+	// header (16 bytes) and the authentication tag of the empty payload (16 bytes), used for keepalives
+	minSizeTransportData = 32
+)
+
+var messageTypes = map[byte]string{
+	typeHandshakeInitiation: "HandshakeInitiation",
+	typeHandshakeResponse:   "HandshakeResponse",
+	typeCookieReply:         "CookieReply",
+	typeTransportData:       "TransportData",
+}
+
+// messageType returns the name of the message type,
+// or an empty string if the data does not have the framing of a WireGuard message.
+// Every message starts with the type, followed by three reserved zero bytes.
+func messageType(data []byte) string {
+	if len(data) < 4 || data[1] != 0 || data[2] != 0 || data[3] != 0 {
+		return ""
+	}
+
+	switch data[0] {
+	case typeHandshakeInitiation:
+		if len(data) != sizeHandshakeInitiation {
+			return ""
+		}
+	case typeHandshakeResponse:
+		if len(data) != sizeHandshakeResponse {
+			return ""
+		}
+	case typeCookieReply:
+		if len(data) != sizeCookieReply {
+			return ""
+		}
+	case typeTransportData:
+		// the encrypted payload is padded to a multiple of 16 bytes
+		if len(data) < minSizeTransportData || len(data)%16 != 0 {
+			return ""
+		}
+	}
+
+	return messageTypes[data[0]]
+}
+
+// isHandshake checks whether the data is a handshake initiation or response.
+func isHandshake(data []byte) bool {
+	return len(data) > 0 &&
+		(data[0] == typeHandshakeInitiation || data[0] == typeHandshakeResponse) &&
+		messageType(data) != ""
+}
+
+// isTransportData checks whether the data is an encrypted transport data message.
+func isTransportData(data []byte) bool {
+	return len(data) > 0 && data[0] == typeTransportData && messageType(data) != ""
+}
+
+// indices returns the sender and receiver index of a handshake or cookie message.
+// Indices are encoded in little endian byte order.
+func indices(data []byte) (sender, receiver uint32) {
+	switch data[0] {
+	case typeHandshakeInitiation:
+		sender = binary.LittleEndian.Uint32(data[4:8])
+	case typeHandshakeResponse:
+		sender = binary.LittleEndian.Uint32(data[4:8])
+		receiver = binary.LittleEndian.Uint32(data[8:12])
+	case typeCookieReply:
+		receiver = binary.LittleEndian.Uint32(data[4:8])
+	}
+
+	return sender, receiver
+}
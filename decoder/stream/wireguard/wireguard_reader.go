@@ -0,0 +1,97 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package wireguard
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+	"github.com/dreadl0ck/netcap/decoder/core"
+	"github.com/dreadl0ck/netcap/reassembly"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+type wireguardReader struct {
+	conversation *core.ConversationInfo
+}
+
+// New returns a new wireguard reader.
+func (h *wireguardReader) New(conversation *core.ConversationInfo) core.StreamDecoderInterface {
+	return &wireguardReader{
+		conversation: conversation,
+	}
+}
+
+// Decode writes an audit record for each handshake and cookie message of the conversation.
+// The transport data is encrypted and skipped.
+func (h *wireguardReader) Decode() {
+	// prevent nil pointer access if decoder is not initialized
+	if Decoder.Writer == nil {
+		return
+	}
+
+	for _, d := range h.conversation.Data {
+		var (
+			raw = d.Raw()
+			typ = messageType(raw)
+		)
+
+		if typ == "" || raw[0] == typeTransportData {
+			continue
+		}
+
+		sender, receiver := indices(raw)
+
+		w := &types.WireGuard{
+			Timestamp:     d.CaptureInfo().Timestamp.UnixNano(),
+			Flow:          h.conversation.Ident,
+			SrcIP:         h.conversation.ClientIP,
+			SrcPort:       h.conversation.ClientPort,
+			DstIP:         h.conversation.ServerIP,
+			DstPort:       h.conversation.ServerPort,
+			MessageType:   typ,
+			SenderIndex:   sender,
+			ReceiverIndex: receiver,
+		}
+
+		if d.Direction() == reassembly.TCPDirServerToClient {
+			w.SrcIP, w.DstIP = w.DstIP, w.SrcIP
+			w.SrcPort, w.DstPort = w.DstPort, w.SrcPort
+		}
+
+		wireguardLog.Info("wireguard message",
+			zap.String("ident", h.conversation.Ident),
+			zap.String("type", typ),
+			zap.Uint32("sender", sender),
+			zap.Uint32("receiver", receiver),
+		)
+
+		writeWireGuard(w)
+	}
+}
+
+func writeWireGuard(w *types.WireGuard) {
+	if decoderconfig.Instance.ExportMetrics {
+		w.Inc()
+	}
+
+	atomic.AddInt64(&Decoder.NumRecordsWritten, 1)
+
+	err := Decoder.Writer.Write(w)
+	if err != nil {
+		wireguardLog.Error("failed to write wireguard audit record", zap.Error(err))
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package wireguard
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func message(typ byte, size int, indices ...uint32) []byte {
+	data := make([]byte, size)
+	data[0] = typ
+
+	for i, index := range indices {
+		binary.LittleEndian.PutUint32(data[4+4*i:], index)
+	}
+
+	return data
+}
+
+func TestMessageType(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected string
+	}{
+		{message(typeHandshakeInitiation, sizeHandshakeInitiation), "HandshakeInitiation"},
+		{message(typeHandshakeResponse, sizeHandshakeResponse), "HandshakeResponse"},
+		{message(typeCookieReply, sizeCookieReply), "CookieReply"},
+		{message(typeTransportData, minSizeTransportData), "TransportData"},
+		{message(typeTransportData, 96), "TransportData"},
+		{message(typeHandshakeInitiation, sizeHandshakeInitiation-1), ""},
+		{message(typeTransportData, minSizeTransportData+1), ""},
+		{message(5, 64), ""},
+		{[]byte{typeHandshakeInitiation, 1, 0, 0}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if typ := messageType(tt.data); typ != tt.expected {
+			t.Errorf("expected %q for type %v with length %d, got %q", tt.expected, tt.data, len(tt.data), typ)
+		}
+	}
+}
+
+func TestIndices(t *testing.T) {
+	sender, receiver := indices(message(typeHandshakeInitiation, sizeHandshakeInitiation, 0xdeadbeef))
+	if sender != 0xdeadbeef || receiver != 0 {
+		t.Fatal("unexpected indices for handshake initiation:", sender, receiver)
+	}
+
+	sender, receiver = indices(message(typeHandshakeResponse, sizeHandshakeResponse, 1, 0xdeadbeef))
+	if sender != 1 || receiver != 0xdeadbeef {
+		t.Fatal("unexpected indices for handshake response:", sender, receiver)
+	}
+
+	sender, receiver = indices(message(typeCookieReply, sizeCookieReply, 2))
+	if sender != 0 || receiver != 2 {
+		t.Fatal("unexpected indices for cookie reply:", sender, receiver)
+	}
+}
+
+func TestCanDecode(t *testing.T) {
+	var (
+		initiation = message(typeHandshakeInitiation, sizeHandshakeInitiation)
+		data       = message(typeTransportData, 64)
+	)
+
+	if !Decoder.CanDecode(initiation, nil) {
+		t.Fatal("expected handshake initiation to be decoded")
+	}
+
+	if !Decoder.CanDecode(data, data) {
+		t.Fatal("expected established tunnel to be decoded")
+	}
+
+	if Decoder.CanDecode(data, nil) {
+		t.Fatal("expected single transport data message to be rejected")
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"encoding/hex"
+	"net"
+	"strings"
+
+	decoderconfig "github.com/dreadl0ck/netcap/decoder/config"
+)
+
+// NormalizeIP returns the canonical textual representation of an IP address if enabled in the config,
+// so that different notations of the same address are not treated as different hosts.
+func NormalizeIP(addr string) string {
+	if decoderconfig.Instance == nil || !decoderconfig.Instance.NormalizeAddresses {
+		return addr
+	}
+
+	return CanonicalIP(addr, decoderconfig.Instance.ExpandIPv6)
+}
+
+// NormalizeMAC returns the canonical textual representation of a MAC address if enabled in the config.
+func NormalizeMAC(addr string) string {
+	if decoderconfig.Instance == nil || !decoderconfig.Instance.NormalizeAddresses {
+		return addr
+	}
+
+	return CanonicalMAC(addr)
+}
+
+// CanonicalIP renders IPv4 and IPv4-mapped IPv6 addresses in dotted decimal notation,
+// and IPv6 addresses compressed as described in RFC 5952, or fully expanded.
+// Values that are not valid IP addresses are returned unmodified.
+func CanonicalIP(addr string, expand bool) string {
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+	if ip == nil {
+		return addr
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+
+	if !expand {
+		return ip.String()
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < net.IPv6len; i += 2 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+
+		b.WriteString(hex.EncodeToString(ip[i : i+2]))
+	}
+
+	return b.String()
+}
+
+// CanonicalMAC renders MAC addresses in lowercase, with the octets separated by colons.
+// Values that are not valid MAC addresses are returned unmodified.
+func CanonicalMAC(addr string) string {
+	hw, err := net.ParseMAC(addr)
+	if err != nil {
+		return addr
+	}
+
+	return hw.String()
+}
@@ -0,0 +1,50 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import "testing"
+
+func TestCanonicalIP(t *testing.T) {
+	tests := []struct {
+		in       string
+		expand   bool
+		expected string
+	}{
+		{"1.2.3.4", false, "1.2.3.4"},
+		{"::ffff:1.2.3.4", false, "1.2.3.4"},
+		{"::FFFF:0102:0304", true, "1.2.3.4"},
+		{"2001:0DB8:0000:0000:0000:0000:0000:0001", false, "2001:db8::1"},
+		{"[2001:db8::1]", false, "2001:db8::1"},
+		{"2001:db8::1", true, "2001:0db8:0000:0000:0000:0000:0000:0001"},
+		{"unknown", false, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := CanonicalIP(tt.in, tt.expand); got != tt.expected {
+			t.Fatal("expected", tt.expected, "for", tt.in, "got", got)
+		}
+	}
+}
+
+func TestCanonicalMAC(t *testing.T) {
+	for _, in := range []string{"00:1A:2B:3C:4D:5E", "00-1a-2b-3c-4d-5e", "001a.2b3c.4d5e"} {
+		if got := CanonicalMAC(in); got != "00:1a:2b:3c:4d:5e" {
+			t.Fatal("unexpected result for", in, got)
+		}
+	}
+
+	if got := CanonicalMAC("invalid"); got != "invalid" {
+		t.Fatal("expected invalid value to be returned unmodified, got", got)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"sync"
+
+	"github.com/dreadl0ck/gopacket"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// ConnectionInfos collects information about reassembled TCP connections from the stream reassembly,
+// so it can be reported on the Connection audit records.
+// Information is only collected while the Connection decoder is active, since it is the only consumer.
+var ConnectionInfos = NewConnectionInfoMap()
+
+// streamKey identifies a bidirectional stream by the symmetric hashes of its network and transport flows.
+type streamKey struct {
+	network   uint64
+	transport uint64
+}
+
+// ConnectionInfo contains the information from the stream reassembly for a single connection.
+type ConnectionInfo struct {
+	// missing data of the reassembled streams, nil if not tracked
+	Gaps *StreamGap
+
+	// Shannon entropy of the payload of both directions, only valid if HasPayloadEntropy is set
+	PayloadEntropy    float64
+	HasPayloadEntropy bool
+
+	// server name from the TLS ClientHello of the client
+	SNI string
+
+	// how the connection ended
+	CloseReason types.CloseReason
+
+	// set if data after missing bytes has been passed to the stream decoders
+	Incomplete bool
+
+	// addresses of the original connection announced by a load balancer, nil if none has been sent
+	ProxyHeader *ProxyHeader
+}
+
+// ConnectionInfoMap maps streams to the information collected for their connection.
+type ConnectionInfoMap struct {
+	sync.Mutex
+	Items map[streamKey]*ConnectionInfo
+
+	// set while the Connection decoder is active
+	enabled bool
+}
+
+// NewConnectionInfoMap returns a new ConnectionInfoMap.
+func NewConnectionInfoMap() *ConnectionInfoMap {
+	return &ConnectionInfoMap{
+		Items: map[streamKey]*ConnectionInfo{},
+	}
+}
+
+// Enable starts collecting information, it is called when the Connection decoder has been initialized.
+func (m *ConnectionInfoMap) Enable() {
+	m.Lock()
+	m.enabled = true
+	m.Unlock()
+}
+
+// Disable stops collecting information and evicts all entries that have not been consumed,
+// it is called once the Connection decoder has written its records.
+func (m *ConnectionInfoMap) Disable() {
+	m.Lock()
+	m.enabled = false
+	m.Items = map[streamKey]*ConnectionInfo{}
+	m.Unlock()
+}
+
+// entry returns the information for the stream and creates it if necessary.
+// Nil is returned if the map is disabled. The lock must be held by the caller.
+func (m *ConnectionInfoMap) entry(net, transport gopacket.Flow) *ConnectionInfo {
+	if !m.enabled {
+		return nil
+	}
+
+	k := streamKey{network: net.FastHash(), transport: transport.FastHash()}
+
+	info, ok := m.Items[k]
+	if !ok {
+		info = new(ConnectionInfo)
+		m.Items[k] = info
+	}
+
+	return info
+}
+
+// AddGap tracks reassembled data for a stream, a non-zero skip value indicates missing data before it.
+// A skip value of -1 indicates a gap of unknown size, for example because the start of the stream was not captured.
+func (m *ConnectionInfoMap) AddGap(net, transport gopacket.Flow, length, skip int) {
+	m.Lock()
+	defer m.Unlock()
+
+	info := m.entry(net, transport)
+	if info == nil {
+		return
+	}
+
+	if info.Gaps == nil {
+		info.Gaps = new(StreamGap)
+	}
+
+	info.Gaps.add(length, skip)
+}
+
+// SetPayloadEntropy stores the payload entropy for a stream.
+func (m *ConnectionInfoMap) SetPayloadEntropy(net, transport gopacket.Flow, entropy float64) {
+	m.Lock()
+	if info := m.entry(net, transport); info != nil {
+		info.PayloadEntropy = entropy
+		info.HasPayloadEntropy = true
+	}
+	m.Unlock()
+}
+
+// SetSNI stores the server name requested by the client of a stream.
+func (m *ConnectionInfoMap) SetSNI(net, transport gopacket.Flow, sni string) {
+	m.Lock()
+	if info := m.entry(net, transport); info != nil {
+		info.SNI = sni
+	}
+	m.Unlock()
+}
+
+// SetCloseReason stores the close reason for a stream.
+func (m *ConnectionInfoMap) SetCloseReason(net, transport gopacket.Flow, reason types.CloseReason) {
+	m.Lock()
+	if info := m.entry(net, transport); info != nil {
+		info.CloseReason = reason
+	}
+	m.Unlock()
+}
+
+// SetIncomplete marks a stream as decoded despite missing bytes.
+func (m *ConnectionInfoMap) SetIncomplete(net, transport gopacket.Flow) {
+	m.Lock()
+	if info := m.entry(net, transport); info != nil {
+		info.Incomplete = true
+	}
+	m.Unlock()
+}
+
+// SetProxyHeader stores the PROXY protocol header that has been sent at the start of a stream.
+func (m *ConnectionInfoMap) SetProxyHeader(net, transport gopacket.Flow, h *ProxyHeader) {
+	m.Lock()
+	if info := m.entry(net, transport); info != nil {
+		info.ProxyHeader = h
+	}
+	m.Unlock()
+}
+
+// Consume returns the information for the stream with the given flow hashes and removes it from the map.
+func (m *ConnectionInfoMap) Consume(networkHash, transportHash uint64) (ConnectionInfo, bool) {
+	k := streamKey{network: networkHash, transport: transportHash}
+
+	m.Lock()
+	defer m.Unlock()
+
+	info, ok := m.Items[k]
+	if !ok {
+		return ConnectionInfo{}, false
+	}
+
+	delete(m.Items, k)
+
+	return *info, true
+}
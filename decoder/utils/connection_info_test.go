@@ -0,0 +1,83 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"net"
+	"testing"
+
+	"github.com/dreadl0ck/gopacket"
+	"github.com/dreadl0ck/gopacket/layers"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestConnectionInfos(t *testing.T) {
+	var (
+		m         = NewConnectionInfoMap()
+		netFlow   = gopacket.NewFlow(layers.EndpointIPv4, net.IP{10, 0, 0, 1}, net.IP{10, 0, 0, 2})
+		transport = gopacket.NewFlow(layers.EndpointTCPPort, []byte{0x1f, 0x90}, []byte{0xc3, 0x50})
+	)
+
+	// nothing is collected before the Connection decoder is active
+	m.AddGap(netFlow, transport, 100, 0)
+	m.SetCloseReason(netFlow, transport, types.CloseReason_CloseReset)
+
+	if len(m.Items) != 0 {
+		t.Fatal("expected no information to be collected while disabled, got", len(m.Items))
+	}
+
+	m.Enable()
+
+	m.AddGap(netFlow, transport, 600, 0)
+	m.AddGap(netFlow.Reverse(), transport.Reverse(), 300, 100)
+	m.AddGap(netFlow, transport, 100, -1)
+	m.SetPayloadEntropy(netFlow, transport, 7.5)
+	m.SetSNI(netFlow, transport, "example.com")
+	m.SetCloseReason(netFlow.Reverse(), transport.Reverse(), types.CloseReason_CloseGraceful)
+
+	info, ok := m.Consume(netFlow.FastHash(), transport.FastHash())
+	if !ok || info.Gaps == nil {
+		t.Fatal("expected gap information for stream")
+	}
+
+	if g := info.Gaps; g.NumGaps != 2 || g.MissingBytes != 100 || g.ReassembledBytes != 1000 {
+		t.Fatal("unexpected gap information", g)
+	}
+
+	if c := info.Gaps.Completeness(); c < 90.9 || c > 91 {
+		t.Fatal("unexpected completeness", c)
+	}
+
+	if !info.HasPayloadEntropy || info.PayloadEntropy != 7.5 || info.SNI != "example.com" || info.CloseReason != types.CloseReason_CloseGraceful || info.ProxyHeader != nil {
+		t.Fatal("unexpected connection information", info)
+	}
+
+	if _, ok = m.Consume(netFlow.FastHash(), 0); ok {
+		t.Fatal("unexpected information for unknown stream")
+	}
+
+	if _, ok = m.Consume(netFlow.FastHash(), transport.FastHash()); ok || len(m.Items) != 0 {
+		t.Fatal("expected information to be removed after it has been consumed")
+	}
+
+	// entries that are never consumed are evicted once the Connection decoder is done
+	m.SetProxyHeader(netFlow, transport, &ProxyHeader{Version: 1})
+	m.Disable()
+	m.SetCloseReason(netFlow, transport, types.CloseReason_CloseTimeout)
+
+	if len(m.Items) != 0 {
+		t.Fatal("expected entries to be evicted after disabling, got", len(m.Items))
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"github.com/dreadl0ck/gopacket/layers"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// NewDNSRecord converts a decoded DNS message into an audit record.
+// It is shared by the packet decoder for DNS over UDP and the stream decoder for DNS over TCP.
+func NewDNSRecord(dns *layers.DNS, timestamp int64) *types.DNS {
+	var questions []*types.DNSQuestion
+	for _, q := range dns.Questions {
+		questions = append(questions, &types.DNSQuestion{
+			Class: int32(q.Class),
+			Name:  string(q.Name),
+			Type:  int32(q.Type),
+		})
+	}
+
+	var answers []*types.DNSResourceRecord
+	for _, a := range dns.Answers {
+		answers = append(answers, newDNSResourceRecord(a))
+	}
+
+	var auths []*types.DNSResourceRecord
+	for _, a := range dns.Authorities {
+		auths = append(auths, newDNSResourceRecord(a))
+	}
+
+	var adds []*types.DNSResourceRecord
+	for _, a := range dns.Additionals {
+		adds = append(adds, newDNSResourceRecord(a))
+	}
+
+	return &types.DNS{
+		Timestamp:    timestamp,
+		ID:           int32(dns.ID),
+		QR:           dns.QR,
+		OpCode:       int32(dns.OpCode),
+		AA:           dns.AA,
+		TC:           dns.TC,
+		RD:           dns.RD,
+		RA:           dns.RA,
+		Z:            int32(dns.Z),
+		ResponseCode: int32(dns.ResponseCode),
+		QDCount:      int32(dns.QDCount),
+		ANCount:      int32(dns.ANCount),
+		NSCount:      int32(dns.NSCount),
+		ARCount:      int32(dns.ARCount),
+		// Entries
+		Questions:   questions,
+		Answers:     answers,
+		Authorities: auths,
+		Additionals: adds,
+	}
+}
+
+func newDNSResourceRecord(a layers.DNSResourceRecord) *types.DNSResourceRecord {
+	return &types.DNSResourceRecord{
+		Name:       string(a.Name),
+		Type:       int32(a.Type),
+		Class:      int32(a.Class),
+		TTL:        a.TTL,
+		DataLength: int32(a.DataLength),
+		Data:       a.Data,
+		IP:         a.IP.String(),
+		NS:         a.NS,
+		CNAME:      a.CNAME,
+		PTR:        a.PTR,
+		SOA: &types.DNSSOA{
+			MName:   a.SOA.MName,
+			RName:   a.SOA.RName,
+			Serial:  a.SOA.Serial,
+			Refresh: a.SOA.Refresh,
+			Retry:   a.SOA.Retry,
+			Expire:  a.SOA.Expire,
+			Minimum: a.SOA.Minimum,
+		},
+		SRV: &types.DNSSRV{
+			Priority: int32(a.SRV.Priority),
+			Weight:   int32(a.SRV.Weight),
+			Port:     int32(a.SRV.Port),
+			Name:     a.SRV.Name,
+		},
+		MX: &types.DNSMX{
+			Preference: int32(a.MX.Preference),
+			Name:       string(a.MX.Name),
+		},
+		TXTs: a.TXTs,
+	}
+}
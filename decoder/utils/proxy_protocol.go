@@ -0,0 +1,238 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// MaxProxyHeaderSize is the number of bytes at the start of a stream that are inspected for a PROXY protocol header.
+// A v1 header has at most 107 bytes, a v2 header can carry additional TLVs after the addresses,
+// headers up to the minimum TCP segment size of 536 bytes are supported, as recommended by the specification.
+const MaxProxyHeaderSize = 536
+
+const (
+	proxyV1Prefix  = "PROXY "
+	proxyV1MaxSize = 107
+
+	proxyV2HeaderSize = 16
+
+	// v2 commands
+	proxyV2Local = 0x0
+	proxyV2Proxy = 0x1
+
+	// v2 address families
+	proxyV2INET  = 0x1
+	proxyV2INET6 = 0x2
+)
+
+var proxyV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// ProxyHeader contains the addresses of the original connection, as announced by the PROXY protocol.
+// The addresses are empty if the proxy did not provide them,
+// for example for health checks of the load balancer.
+type ProxyHeader struct {
+	Version int32
+	SrcIP   string
+	SrcPort int32
+	DstIP   string
+	DstPort int32
+}
+
+// ParseProxyHeader checks for a PROXY protocol v1 or v2 header at the start of the data sent by the client.
+// It returns the parsed header and its size in bytes, so the header can be stripped before decoding the stream.
+func ParseProxyHeader(data []byte) (*ProxyHeader, int, bool) {
+	if bytes.HasPrefix(data, proxyV2Signature) {
+		return parseProxyV2(data)
+	}
+
+	if bytes.HasPrefix(data, []byte(proxyV1Prefix)) {
+		return parseProxyV1(data)
+	}
+
+	return nil, 0, false
+}
+
+// parseProxyV1 parses the human readable header, e.g: PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n.
+func parseProxyV1(data []byte) (*ProxyHeader, int, bool) {
+	if len(data) > proxyV1MaxSize {
+		data = data[:proxyV1MaxSize]
+	}
+
+	end := bytes.Index(data, []byte("\r\n"))
+	if end == -1 {
+		return nil, 0, false
+	}
+
+	var (
+		fields = strings.Fields(string(data[:end]))
+		h      = &ProxyHeader{Version: 1}
+		size   = end + 2
+	)
+
+	if len(fields) < 2 {
+		return nil, 0, false
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		// the remaining fields must be ignored
+		return h, size, true
+	case "TCP4", "TCP6":
+	default:
+		return nil, 0, false
+	}
+
+	if len(fields) != 6 {
+		return nil, 0, false
+	}
+
+	var (
+		src             = net.ParseIP(fields[2])
+		dst             = net.ParseIP(fields[3])
+		srcPort, errSrc = strconv.ParseUint(fields[4], 10, 16)
+		dstPort, errDst = strconv.ParseUint(fields[5], 10, 16)
+	)
+
+	if src == nil || dst == nil || errSrc != nil || errDst != nil {
+		return nil, 0, false
+	}
+
+	h.SrcIP = NormalizeIP(src.String())
+	h.DstIP = NormalizeIP(dst.String())
+	h.SrcPort = int32(srcPort)
+	h.DstPort = int32(dstPort)
+
+	return h, size, true
+}
+
+// parseProxyV2 parses the binary header, that starts with a 12 byte signature
+// followed by the version and command, the address family and transport protocol and the length of the addresses.
+func parseProxyV2(data []byte) (*ProxyHeader, int, bool) {
+	if len(data) < proxyV2HeaderSize || data[12]>>4 != 2 {
+		return nil, 0, false
+	}
+
+	var (
+		cmd    = data[12] & 0x0f
+		family = data[13] >> 4
+		size   = proxyV2HeaderSize + int(binary.BigEndian.Uint16(data[14:16]))
+		h      = &ProxyHeader{Version: 2}
+	)
+
+	if len(data) < size || (cmd != proxyV2Local && cmd != proxyV2Proxy) {
+		return nil, 0, false
+	}
+
+	// connections initiated by the proxy itself do not carry the original addresses
+	if cmd == proxyV2Local {
+		return h, size, true
+	}
+
+	addrs := data[proxyV2HeaderSize:size]
+
+	switch {
+	case family == proxyV2INET && len(addrs) >= 12:
+		h.SrcIP = NormalizeIP(net.IP(addrs[0:4]).String())
+		h.DstIP = NormalizeIP(net.IP(addrs[4:8]).String())
+		h.SrcPort = int32(binary.BigEndian.Uint16(addrs[8:10]))
+		h.DstPort = int32(binary.BigEndian.Uint16(addrs[10:12]))
+	case family == proxyV2INET6 && len(addrs) >= 36:
+		h.SrcIP = NormalizeIP(net.IP(addrs[0:16]).String())
+		h.DstIP = NormalizeIP(net.IP(addrs[16:32]).String())
+		h.SrcPort = int32(binary.BigEndian.Uint16(addrs[32:34]))
+		h.DstPort = int32(binary.BigEndian.Uint16(addrs[34:36]))
+	}
+
+	return h, size, true
+}
+
+// ProxyAllowList contains the proxies that are trusted to prepend PROXY protocol headers to their connections.
+// A proxy is identified by its address or network, or by the port of the backend service it connects to.
+type ProxyAllowList struct {
+	networks []*net.IPNet
+	ports    map[int32]struct{}
+}
+
+// ParseProxyAllowList parses a comma separated list of addresses, CIDRs and port numbers.
+func ParseProxyAllowList(list string) (*ProxyAllowList, error) {
+	l := &ProxyAllowList{
+		ports: make(map[int32]struct{}),
+	}
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if port, err := strconv.Atoi(entry); err == nil {
+			if port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("invalid port for trusted proxies: %s", entry)
+			}
+
+			l.ports[int32(port)] = struct{}{}
+
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address for trusted proxies: %s", entry)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+
+			entry += "/" + strconv.Itoa(bits)
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network for trusted proxies: %w", err)
+		}
+
+		l.networks = append(l.networks, network)
+	}
+
+	if len(l.networks) == 0 && len(l.ports) == 0 {
+		return nil, errors.New("no trusted proxies configured")
+	}
+
+	return l, nil
+}
+
+// Trusted checks if the PROXY protocol header sent by the client of a connection to the server port can be trusted.
+func (l *ProxyAllowList) Trusted(client net.IP, serverPort int32) bool {
+	if _, ok := l.ports[serverPort]; ok {
+		return true
+	}
+
+	for _, n := range l.networks {
+		if n.Contains(client) {
+			return true
+		}
+	}
+
+	return false
+}
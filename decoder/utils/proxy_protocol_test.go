@@ -0,0 +1,101 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestParseProxyHeader(t *testing.T) {
+	v2 := append([]byte{}, proxyV2Signature...)
+	v2 = append(v2, 0x21, 0x11, 0x00, 0x0c, 192, 168, 1, 10, 10, 0, 0, 1, 0, 0, 0, 0)
+	binary.BigEndian.PutUint16(v2[24:26], 56324)
+	binary.BigEndian.PutUint16(v2[26:28], 443)
+
+	local := append([]byte{}, proxyV2Signature...)
+	local = append(local, 0x20, 0x00, 0x00, 0x00)
+
+	tests := []struct {
+		data     string
+		expected *ProxyHeader
+		size     int
+	}{
+		{
+			data:     "PROXY TCP4 192.168.1.10 10.0.0.1 56324 443\r\nGET / HTTP/1.1\r\n",
+			expected: &ProxyHeader{Version: 1, SrcIP: "192.168.1.10", SrcPort: 56324, DstIP: "10.0.0.1", DstPort: 443},
+			size:     44,
+		},
+		{
+			data:     "PROXY UNKNOWN\r\n",
+			expected: &ProxyHeader{Version: 1},
+			size:     15,
+		},
+		{
+			data:     string(v2) + "GET / HTTP/1.1\r\n",
+			expected: &ProxyHeader{Version: 2, SrcIP: "192.168.1.10", SrcPort: 56324, DstIP: "10.0.0.1", DstPort: 443},
+			size:     28,
+		},
+		{
+			data:     string(local),
+			expected: &ProxyHeader{Version: 2},
+			size:     16,
+		},
+		{data: "PROXY TCP4 192.168.1.10 10.0.0.1 56324\r\n"},
+		{data: "PROXY TCP4 192.168.1.10 10.0.0.1 56324 443"},
+		{data: "GET / HTTP/1.1\r\n"},
+		{data: string(v2[:20])},
+	}
+
+	for _, tt := range tests {
+		h, size, ok := ParseProxyHeader([]byte(tt.data))
+		if ok != (tt.expected != nil) || size != tt.size || !reflect.DeepEqual(h, tt.expected) {
+			t.Errorf("%q: got %+v, %d, %v, want %+v, %d", tt.data, h, size, ok, tt.expected, tt.size)
+		}
+	}
+}
+
+func TestProxyAllowList(t *testing.T) {
+	l, err := ParseProxyAllowList("10.0.0.5, 192.168.0.0/16,8443,fd00::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		client  string
+		port    int32
+		trusted bool
+	}{
+		{"10.0.0.5", 80, true},
+		{"10.0.0.6", 80, false},
+		{"192.168.10.1", 80, true},
+		{"172.16.0.1", 8443, true},
+		{"fd00::1", 80, true},
+		{"fd00::2", 80, false},
+	}
+
+	for _, tt := range tests {
+		if trusted := l.Trusted(net.ParseIP(tt.client), tt.port); trusted != tt.trusted {
+			t.Errorf("%s:%d: expected trusted %v", tt.client, tt.port, tt.trusted)
+		}
+	}
+
+	for _, invalid := range []string{"", " , ", "10.0.0.300", "10.0.0.0/33", "70000"} {
+		if _, err = ParseProxyAllowList(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
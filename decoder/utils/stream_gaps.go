@@ -0,0 +1,49 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package utils
+
+// StreamGap describes the missing data of a reassembled stream.
+type StreamGap struct {
+	// number of missing byte ranges
+	NumGaps int32
+
+	// total size of all gaps in bytes, gaps with an unknown size are not included
+	MissingBytes int64
+
+	// number of bytes that have been reassembled
+	ReassembledBytes int64
+}
+
+// Completeness returns the percentage of stream data that has been captured.
+func (g StreamGap) Completeness() float64 {
+	total := g.ReassembledBytes + g.MissingBytes
+	if total == 0 {
+		return 0
+	}
+
+	return float64(g.ReassembledBytes) / float64(total) * 100
+}
+
+// add tracks reassembled data, a non-zero skip value indicates missing data before it.
+func (g *StreamGap) add(length, skip int) {
+	g.ReassembledBytes += int64(length)
+
+	if skip != 0 {
+		g.NumGaps++
+	}
+
+	if skip > 0 {
+		g.MissingBytes += int64(skip)
+	}
+}
@@ -41,6 +41,12 @@ const (
 	// ConnTimeOut will be used to set age threshold if the corresponding FlushInterval > 0.
 	ConnTimeOut = 24 * time.Hour
 
+	// UDPConnTimeout is the idle time after which a UDP pseudo connection is considered finished.
+	UDPConnTimeout = time.Minute
+
+	// UDPStreamTimeout is the idle time after which a UDP stream is processed and removed from memory.
+	UDPStreamTimeout = time.Minute
+
 	// FlowTimeOut will be used to set age threshold if the corresponding FlushInterval > 0.
 	FlowTimeOut = 24 * time.Hour
 
@@ -50,6 +56,27 @@ const (
 	// CompressionLevel is the compression level to use by default.
 	CompressionLevel = flate.BestSpeed
 
+	// VolumeBucket is the size of the time buckets used to track the outbound traffic volume of internal hosts.
+	VolumeBucket = time.Minute
+
+	// VolumeThreshold is the number of standard deviations above the baseline mean that is flagged as volume anomaly.
+	VolumeThreshold = 3.0
+
+	// VolumeWindow is the number of recent buckets that form the baseline of a host.
+	VolumeWindow = 60
+
+	// VolumeMinBytes is the minimum number of bytes within a bucket to be flagged as volume anomaly.
+	VolumeMinBytes = 1024 * 1024 * 1 // 1 MB
+
+	// PortScanPorts is the number of distinct ports contacted on a single host within the window that is flagged as port scan.
+	PortScanPorts = 100
+
+	// PortScanHosts is the number of distinct hosts contacted on a single port within the window that is flagged as port scan.
+	PortScanHosts = 50
+
+	// PortScanWindow is the time window in which the distinct targets of an address are counted.
+	PortScanWindow = time.Minute
+
 	// TCP Stream Reassembly:
 	// default settings are meant to be forgiving in terms of TCP state machine correctness
 	// in order to capture as much information as possible.
@@ -67,12 +94,28 @@ const (
 	// CloseInactiveTimeout Close inactive streams after.
 	CloseInactiveTimeout = 24 * time.Hour
 
+	// FlushCoalesceWindow extends the flush timeouts for connections with a high recent throughput, disabled by default.
+	FlushCoalesceWindow time.Duration = 0
+
+	// ClosedGracePeriod after which segments of closed connections are ignored, covers the TIME_WAIT state.
+	ClosedGracePeriod = time.Minute
+
 	// AllowMissingInit TCP State Machine.
 	AllowMissingInit = true
 
 	// DefragIPv4 controls defragmentation for IPv4.
 	DefragIPv4 = true
 
+	// DefragPolicy controls which data is used when IPv4 fragments overlap.
+	DefragPolicy = "linux"
+
+	// TimestampSource controls whether the capture time or the time asserted by a protocol
+	// is used as primary timestamp for audit records.
+	TimestampSource = "capture"
+
+	// NormalizeAddresses controls whether IP and MAC addresses are converted into a canonical form.
+	NormalizeAddresses = true
+
 	// NoOptCheck controls TCP option checking for the reassembly state machine.
 	NoOptCheck = true
 
@@ -85,6 +128,15 @@ const (
 	// FileStorage is the default location for storing extracted files.
 	FileStorage = "files"
 
+	// HTTPBodyMaxSize is the maximum size of HTTP response bodies that are written to the body storage.
+	HTTPBodyMaxSize = 1024 * 1024 * 10 // 10 MB
+
+	// HTTPMaxDecodedSize is the maximum size of HTTP bodies after removing the content encoding.
+	HTTPMaxDecodedSize = 1024 * 1024 * 100 // 100 MB
+
+	// HTTPDedupMax is the maximum number of identical HTTP requests that are collapsed into a single record.
+	HTTPDedupMax = 1000
+
 	// DirectoryPermission for all created folders.
 	DirectoryPermission = 0o777
 
@@ -97,9 +149,28 @@ const (
 	// FileExtensionCompressed of gzipped netcap files.
 	FileExtensionCompressed = ".ncap.gz"
 
+	// FileExtensionZstd of zstd compressed netcap files.
+	FileExtensionZstd = ".ncap.zst"
+
 	// ElasticLimitTotalFields is the maximum number of fields allowed per batch of audit records.
 	ElasticLimitTotalFields = 1000000
 
+	// ElasticBulkSize is the number of documents sent to elastic per batch, if no bulk size has been configured.
+	ElasticBulkSize = 1000
+
+	// ElasticIndex is the template for elastic index names,
+	// {type} is replaced with the audit record type and {date} with the day of the record.
+	ElasticIndex = "netcap-{type}"
+
+	// ElasticMaxRetries is the number of times a batch is retried when elastic is overloaded or unreachable.
+	ElasticMaxRetries = 5
+
+	// KafkaTopic is the template for kafka topic names, {type} is replaced with the audit record type.
+	KafkaTopic = "netcap-{type}"
+
+	// KafkaBatchSize is the number of messages sent to kafka per produce request, if no batch size has been configured.
+	KafkaBatchSize = 1000
+
 	// NetcapTypePrefix holds the prefix for the protobuf types
 	NetcapTypePrefix = "NC_"
 )
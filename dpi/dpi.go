@@ -87,10 +87,19 @@ func GetProtocols(packet gopacket.Packet) map[string]ClassificationResult {
 
 	// when using all modules we might receive duplicate classifications
 	// so they will be deduplicated by protocol name before counting them later
+	names := make([]string, 0, len(results))
+
 	for _, r := range results {
+		if _, ok := protocols[string(r.Protocol)]; !ok {
+			names = append(names, string(r.Protocol))
+		}
+
 		protocols[string(r.Protocol)] = r
 	}
 
+	// remember the results for the flow, to select a stream decoder when the stream is decoded
+	addFlowProtocols(packet, names)
+
 	return protocols
 }
 
@@ -0,0 +1,127 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package dpi
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dreadl0ck/gopacket"
+)
+
+// flowProtocols contains the application protocols identified for each flow,
+// so the stream reassembly can use the classification results to select a stream decoder.
+var flowProtocols = struct {
+	sync.Mutex
+	items map[string]*flowEntry
+}{
+	items: make(map[string]*flowEntry),
+}
+
+// flowEntry contains the protocols identified for a flow.
+type flowEntry struct {
+	protocols map[string]struct{}
+
+	// transport layer of the flow, to expire TCP and UDP flows with their respective timeouts
+	transport gopacket.LayerType
+
+	// capture time of the last classified packet
+	lastSeen time.Time
+}
+
+// FlowKey returns an identifier for the flow that is the same for both directions.
+func FlowKey(net, transport gopacket.Flow) string {
+	src, dst := net.Endpoints()
+	if dst.LessThan(src) || (src == dst && transport.Dst().LessThan(transport.Src())) {
+		net, transport = net.Reverse(), transport.Reverse()
+	}
+
+	return net.String() + " " + transport.String()
+}
+
+// addFlowProtocols stores the protocols identified for the flow of the packet.
+func addFlowProtocols(packet gopacket.Packet, protocols []string) {
+	if len(protocols) == 0 || packet.NetworkLayer() == nil || packet.TransportLayer() == nil {
+		return
+	}
+
+	var (
+		key = FlowKey(packet.NetworkLayer().NetworkFlow(), packet.TransportLayer().TransportFlow())
+		ts  = packet.Metadata().Timestamp
+	)
+
+	flowProtocols.Lock()
+	defer flowProtocols.Unlock()
+
+	e, ok := flowProtocols.items[key]
+	if !ok {
+		e = &flowEntry{
+			protocols: make(map[string]struct{}, len(protocols)),
+			transport: packet.TransportLayer().LayerType(),
+		}
+		flowProtocols.items[key] = e
+	}
+
+	if ts.After(e.lastSeen) {
+		e.lastSeen = ts
+	}
+
+	for _, name := range protocols {
+		e.protocols[name] = struct{}{}
+	}
+}
+
+// ConsumeFlowProtocols returns the sorted names of the protocols identified for the flow
+// and removes the flow, since the results are only needed once when the stream is decoded.
+func ConsumeFlowProtocols(net, transport gopacket.Flow) []string {
+	key := FlowKey(net, transport)
+
+	flowProtocols.Lock()
+	e, ok := flowProtocols.items[key]
+	delete(flowProtocols.items, key)
+	flowProtocols.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	names := make([]string, 0, len(e.protocols))
+	for name := range e.protocols {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ExpireFlowProtocols removes the flows of the given transport layer that have not been classified since the given time,
+// it is called when the stream reassembly flushes its connections, to evict the results for flows that are never decoded.
+// It returns the number of removed flows.
+func ExpireFlowProtocols(transport gopacket.LayerType, before time.Time) int {
+	var expired int
+
+	flowProtocols.Lock()
+	defer flowProtocols.Unlock()
+
+	for key, e := range flowProtocols.items {
+		if e.transport == transport && e.lastSeen.Before(before) {
+			delete(flowProtocols.items, key)
+			expired++
+		}
+	}
+
+	return expired
+}
@@ -27,6 +27,15 @@ const (
 	// MaltegoExploitDirectory is used to search for exploit PoC code.
 	MaltegoExploitDirectory = "NC_MALTEGO_EXPLOIT_DIRECTORY"
 
+	// MaltegoHomeNetworks is a comma separated list of CIDRs that belong to the monitored network.
+	MaltegoHomeNetworks = "NC_MALTEGO_HOME_NETWORKS"
+
+	// MaltegoConnsClientColor is the color of the client data in saved connections, it must match the -conns-client-color used for the capture.
+	MaltegoConnsClientColor = "NC_MALTEGO_CONNS_CLIENT_COLOR"
+
+	// MaltegoConnsServerColor is the color of the server data in saved connections, it must match the -conns-server-color used for the capture.
+	MaltegoConnsServerColor = "NC_MALTEGO_CONNS_SERVER_COLOR"
+
 	// MaltegoOpenTerminalCommand is the default terminal used when requesting to open a folder from Maltego.
 	MaltegoOpenTerminalCommand = "NETCAP_MALTEGO_OPEN_TERMINAL_CMD"
 
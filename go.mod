@@ -28,8 +28,9 @@ require (
 	github.com/fatih/color v1.10.0 // indirect
 	github.com/felixge/fgprof v0.9.1
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/fxamacker/cbor/v2 v2.2.0
 	github.com/glycerine/go-unsnap-stream v0.0.0-20210130063903-47dfef350d96 // indirect
-	github.com/go-echarts/go-echarts/v2 v2.2.4 // indirect
+	github.com/go-echarts/go-echarts/v2 v2.2.4
 	github.com/go-errors/errors v1.1.1
 	github.com/gogo/protobuf v1.3.2
 	github.com/golang/geo v0.0.0-20210211234256-740aa86cb551 // indirect
@@ -38,7 +39,7 @@ require (
 	github.com/gopherjs/gopherjs v0.0.0-20210202160940-bed99a852dfe // indirect
 	github.com/imdario/mergo v0.3.11 // indirect
 	github.com/kevinburke/ssh_config v0.0.0-20201106050909-4977a11b4351 // indirect
-	github.com/klauspost/compress v1.11.7 // indirect
+	github.com/klauspost/compress v1.11.7
 	github.com/klauspost/pgzip v1.2.5
 	github.com/magefile/mage v1.11.0 // indirect
 	github.com/magiconair/properties v1.8.0
@@ -54,6 +55,7 @@ require (
 	github.com/prometheus/common v0.17.0 // indirect
 	github.com/prometheus/procfs v0.6.0 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
+	github.com/segmentio/kafka-go v0.3.5
 	github.com/sirupsen/logrus v1.8.0
 	github.com/tinylib/msgp v1.1.5 // indirect
 	github.com/ua-parser/uap-go v0.0.0-20210121150957-347a3497cc39
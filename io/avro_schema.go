@@ -0,0 +1,386 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// avroNamespace is the namespace of all avro records derived from the netcap types.
+const avroNamespace = "netcap.types"
+
+var errUnsupportedAvroType = errors.New("unsupported avro type")
+
+// avroEncoder appends the avro binary encoding of v to buf.
+type avroEncoder func(buf *bytes.Buffer, v reflect.Value)
+
+// avroRecord holds the field encoders for a protobuf message struct.
+// Records are registered before their fields are resolved, to allow self referencing types.
+type avroRecord struct {
+	fields []avroField
+}
+
+// avroField maps a struct field to its encoder.
+type avroField struct {
+	index int
+	enc   avroEncoder
+}
+
+// encode writes all fields of the struct v in schema order.
+func (r *avroRecord) encode(buf *bytes.Buffer, v reflect.Value) {
+	for _, f := range r.fields {
+		f.enc(buf, v.Field(f.index))
+	}
+}
+
+// avroSchema is the avro schema derived from a protobuf message type,
+// along with the encoder for values of that type.
+type avroSchema struct {
+	typ    reflect.Type
+	schema interface{}
+	record *avroRecord
+}
+
+// newAvroSchema derives an avro record schema from the fields of the given protobuf message.
+// Repeated fields are mapped to arrays, maps to avro maps, nested messages to records
+// and singular message fields to a union of null and the record.
+// Named records are defined once and referenced by their full name afterwards.
+func newAvroSchema(msg interface{}) (*avroSchema, error) {
+	t := reflect.TypeOf(msg)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T", errUnsupportedAvroType, msg)
+	}
+
+	b := &avroSchemaBuilder{
+		records: make(map[reflect.Type]*avroRecord),
+	}
+
+	schema, err := b.record(t.Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	return &avroSchema{
+		typ:    t,
+		schema: schema,
+		record: b.records[t.Elem()],
+	}, nil
+}
+
+// encode appends the avro binary encoding of msg to buf.
+func (s *avroSchema) encode(buf *bytes.Buffer, msg interface{}) error {
+	v := reflect.ValueOf(msg)
+	if v.Type() != s.typ {
+		return fmt.Errorf("%w: expected %s, got %s", errUnsupportedAvroType, s.typ, v.Type())
+	}
+
+	if v.IsNil() {
+		v = reflect.New(s.typ.Elem())
+	}
+
+	s.record.encode(buf, v.Elem())
+
+	return nil
+}
+
+// avroSchemaBuilder keeps track of the records that have already been defined.
+type avroSchemaBuilder struct {
+	records map[reflect.Type]*avroRecord
+}
+
+// record returns the schema for a struct type.
+func (b *avroSchemaBuilder) record(t reflect.Type) (interface{}, error) {
+	if _, ok := b.records[t]; ok {
+		return avroNamespace + "." + t.Name(), nil
+	}
+
+	r := &avroRecord{}
+	b.records[t] = r
+
+	fields := make([]interface{}, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := avroFieldName(f)
+		if name == "" {
+			continue
+		}
+
+		schema, enc, err := b.typ(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", t.Name(), f.Name, err)
+		}
+
+		field := map[string]interface{}{
+			"name": name,
+			"type": schema,
+		}
+
+		// singular message fields are optional
+		if f.Type.Kind() == reflect.Ptr {
+			field["default"] = nil
+		}
+
+		fields = append(fields, field)
+		r.fields = append(r.fields, avroField{index: i, enc: enc})
+	}
+
+	return map[string]interface{}{
+		"type":      "record",
+		"name":      t.Name(),
+		"namespace": avroNamespace,
+		"fields":    fields,
+	}, nil
+}
+
+// typ returns the schema and encoder for a field type.
+func (b *avroSchemaBuilder) typ(t reflect.Type) (interface{}, avroEncoder, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", encodeAvroBool, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return "int", encodeAvroInt, nil
+	case reflect.Int, reflect.Int64:
+		return "long", encodeAvroInt, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		// avro has no unsigned types, uint64 values above math.MaxInt64 wrap around
+		return "long", encodeAvroUint, nil
+	case reflect.Float32:
+		return "float", encodeAvroFloat, nil
+	case reflect.Float64:
+		return "double", encodeAvroDouble, nil
+	case reflect.String:
+		return "string", encodeAvroString, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", encodeAvroBytes, nil
+		}
+
+		items, enc, err := b.elem(t.Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		}, encodeAvroArray(enc), nil
+	case reflect.Map:
+		values, enc, err := b.elem(t.Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return map[string]interface{}{
+			"type":   "map",
+			"values": values,
+		}, encodeAvroMap(enc), nil
+	case reflect.Struct:
+		schema, err := b.record(t)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return schema, b.structEncoder(t), nil
+	case reflect.Ptr:
+		if t.Elem().Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("%w: %s", errUnsupportedAvroType, t)
+		}
+
+		schema, err := b.record(t.Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []interface{}{"null", schema}, encodeAvroOptional(b.structEncoder(t.Elem())), nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", errUnsupportedAvroType, t)
+	}
+}
+
+// elem returns the schema and encoder for the items of repeated and map fields.
+// Message pointers are encoded as plain records, since protobuf does not allow nil elements.
+func (b *avroSchemaBuilder) elem(t reflect.Type) (interface{}, avroEncoder, error) {
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		schema, err := b.record(t.Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		enc := b.structEncoder(t.Elem())
+
+		return schema, func(buf *bytes.Buffer, v reflect.Value) {
+			if v.IsNil() {
+				v = reflect.New(t.Elem())
+			}
+			enc(buf, v)
+		}, nil
+	}
+
+	return b.typ(t)
+}
+
+// structEncoder returns an encoder for pointers to or values of the struct type t.
+func (b *avroSchemaBuilder) structEncoder(t reflect.Type) avroEncoder {
+	r := b.records[t]
+
+	return func(buf *bytes.Buffer, v reflect.Value) {
+		r.encode(buf, reflect.Indirect(v))
+	}
+}
+
+// avroFieldName returns the protobuf field name of a struct field,
+// or an empty string if the field is not part of the message.
+func avroFieldName(f reflect.StructField) string {
+	if f.PkgPath != "" || strings.HasPrefix(f.Name, "XXX_") {
+		return ""
+	}
+
+	tag, ok := f.Tag.Lookup("protobuf")
+	if !ok {
+		return ""
+	}
+
+	for _, opt := range strings.Split(tag, ",") {
+		if strings.HasPrefix(opt, "name=") {
+			return strings.TrimPrefix(opt, "name=")
+		}
+	}
+
+	return f.Name
+}
+
+// writeAvroLong writes a zig-zag encoded variable length integer.
+func writeAvroLong(buf *bytes.Buffer, i int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutVarint(b[:], i)])
+}
+
+// writeAvroBytes writes a length prefixed byte slice.
+func writeAvroBytes(buf *bytes.Buffer, data []byte) {
+	writeAvroLong(buf, int64(len(data)))
+	buf.Write(data)
+}
+
+func encodeAvroBool(buf *bytes.Buffer, v reflect.Value) {
+	if v.Bool() {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+}
+
+func encodeAvroInt(buf *bytes.Buffer, v reflect.Value) {
+	writeAvroLong(buf, v.Int())
+}
+
+func encodeAvroUint(buf *bytes.Buffer, v reflect.Value) {
+	writeAvroLong(buf, int64(v.Uint()))
+}
+
+func encodeAvroFloat(buf *bytes.Buffer, v reflect.Value) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(v.Float())))
+	buf.Write(b[:])
+}
+
+func encodeAvroDouble(buf *bytes.Buffer, v reflect.Value) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v.Float()))
+	buf.Write(b[:])
+}
+
+func encodeAvroString(buf *bytes.Buffer, v reflect.Value) {
+	writeAvroLong(buf, int64(v.Len()))
+	buf.WriteString(v.String())
+}
+
+func encodeAvroBytes(buf *bytes.Buffer, v reflect.Value) {
+	writeAvroBytes(buf, v.Bytes())
+}
+
+// encodeAvroOptional encodes a value of a union of null and a record.
+func encodeAvroOptional(enc avroEncoder) avroEncoder {
+	return func(buf *bytes.Buffer, v reflect.Value) {
+		if v.IsNil() {
+			writeAvroLong(buf, 0)
+
+			return
+		}
+
+		writeAvroLong(buf, 1)
+		enc(buf, v)
+	}
+}
+
+// encodeAvroArray writes all items in a single block, followed by the terminating empty block.
+func encodeAvroArray(enc avroEncoder) avroEncoder {
+	return func(buf *bytes.Buffer, v reflect.Value) {
+		if n := v.Len(); n > 0 {
+			writeAvroLong(buf, int64(n))
+
+			for i := 0; i < n; i++ {
+				enc(buf, v.Index(i))
+			}
+		}
+
+		writeAvroLong(buf, 0)
+	}
+}
+
+// encodeAvroMap writes all entries sorted by key in a single block, followed by the terminating empty block.
+func encodeAvroMap(enc avroEncoder) avroEncoder {
+	return func(buf *bytes.Buffer, v reflect.Value) {
+		if n := v.Len(); n > 0 {
+			keys := v.MapKeys()
+			names := make([]string, len(keys))
+
+			for i, k := range keys {
+				names[i] = fmt.Sprint(k.Interface())
+			}
+
+			sort.Sort(&avroMapKeys{keys: keys, names: names})
+
+			writeAvroLong(buf, int64(n))
+
+			for i, k := range keys {
+				writeAvroBytes(buf, []byte(names[i]))
+				enc(buf, v.MapIndex(k))
+			}
+		}
+
+		writeAvroLong(buf, 0)
+	}
+}
+
+// avroMapKeys sorts map keys by their string representation, to produce a deterministic output.
+type avroMapKeys struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (m *avroMapKeys) Len() int           { return len(m.keys) }
+func (m *avroMapKeys) Less(i, j int) bool { return m.names[i] < m.names[j] }
+func (m *avroMapKeys) Swap(i, j int) {
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+	m.names[i], m.names[j] = m.names[j], m.names[i]
+}
@@ -0,0 +1,416 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestAvroWriterRoundTrip(t *testing.T) {
+	for _, c := range []struct {
+		compress bool
+		format   Compression
+		codec    string
+	}{
+		{false, CompressionNone, avroCodecNull},
+		{true, CompressionGzip, avroCodecDeflate},
+		{true, CompressionZstd, avroCodecZstandard},
+	} {
+		dir, err := ioutil.TempDir("", "netcap-avro")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := newAvroWriter(&WriterConfig{
+			Avro:              true,
+			Name:              "TCP",
+			Type:              types.Type_NC_TCP,
+			Buffer:            true,
+			Compress:          c.compress,
+			CompressionFormat: c.format,
+			CompressionLevel:  defaults.CompressionLevel,
+			Out:               dir,
+			Source:            "unit test",
+		})
+
+		if err = w.WriteHeader(types.Type_NC_TCP); err != nil {
+			t.Fatal(err)
+		}
+
+		tcp := *tcps[0]
+		tcp.Options = []*types.TCPOption{
+			{OptionType: 2, OptionLength: 4, OptionData: []byte{0x05, 0xb4}},
+			{OptionType: 1},
+		}
+		tcp.PayloadEntropy = 3.5
+
+		records := append([]*types.TCP{&tcp}, tcps...)
+
+		for _, r := range records {
+			if err = w.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		name, _ := w.Close(int64(len(records)))
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.Base(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if filepath.Ext(name) != ".avro" {
+			t.Fatal("unexpected file name", name)
+		}
+
+		meta, values := readAvroContainer(t, data)
+
+		if meta["avro.codec"] != c.codec {
+			t.Fatal("unexpected codec", meta["avro.codec"], "expected", c.codec)
+		}
+
+		if meta["netcap.type"] != types.Type_NC_TCP.String() || meta["netcap.source"] != "unit test" {
+			t.Fatal("unexpected metadata", meta)
+		}
+
+		if len(values) != len(records) {
+			t.Fatal("expected", len(records), "records, got", len(values))
+		}
+
+		first := values[0].(map[string]interface{})
+		if first["SrcIP"] != tcp.SrcIP || first["SeqNum"] != int64(tcp.SeqNum) || first["SYN"] != true || first["PayloadEntropy"] != 3.5 {
+			t.Fatal("unexpected record", first)
+		}
+
+		opts := first["Options"].([]interface{})
+		if len(opts) != 2 {
+			t.Fatal("expected two options, got", opts)
+		}
+
+		opt := opts[0].(map[string]interface{})
+		if opt["OptionType"] != int64(2) || !bytes.Equal(opt["OptionData"].([]byte), []byte{0x05, 0xb4}) {
+			t.Fatal("unexpected option", opt)
+		}
+
+		if values[len(values)-1].(map[string]interface{})["DstIP"] != tcps[len(tcps)-1].DstIP {
+			t.Fatal("unexpected last record", values[len(values)-1])
+		}
+	}
+}
+
+func TestAvroSchemaNestedFields(t *testing.T) {
+	s, err := newAvroSchema(new(types.Dot11))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	err = s.encode(&buf, &types.Dot11{
+		Address1: "ff:ff:ff:ff:ff:ff",
+		QOS:      &types.Dot11QOS{TID: 3, EOSP: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := newAvroTestDecoder()
+	v := d.decode(t, bytes.NewReader(buf.Bytes()), roundTripAvroSchema(t, s)).(map[string]interface{})
+
+	if v["Address1"] != "ff:ff:ff:ff:ff:ff" || v["HTControl"] != nil {
+		t.Fatal("unexpected record", v)
+	}
+
+	if qos := v["QOS"].(map[string]interface{}); qos["TID"] != int64(3) || qos["EOSP"] != true {
+		t.Fatal("unexpected nested record", qos)
+	}
+
+	s, err = newAvroSchema(new(types.HTTP))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+
+	err = s.encode(&buf, &types.HTTP{
+		Host:          "example.com",
+		RequestHeader: map[string]string{"User-Agent": "curl", "Accept": "*/*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v = newAvroTestDecoder().decode(t, bytes.NewReader(buf.Bytes()), roundTripAvroSchema(t, s)).(map[string]interface{})
+
+	if h := v["RequestHeader"].(map[string]interface{}); h["User-Agent"] != "curl" || h["Accept"] != "*/*" || len(h) != 2 {
+		t.Fatal("unexpected map", h)
+	}
+
+	if err = s.encode(&buf, new(types.TCP)); err == nil {
+		t.Fatal("expected an error for a mismatching record type")
+	}
+}
+
+func TestAvroSchemaAllTypes(t *testing.T) {
+	for num, name := range types.Type_name {
+		rec := initAuditRecord(types.Type(num))
+		if rec == nil {
+			continue
+		}
+
+		s, err := newAvroSchema(rec)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		var buf bytes.Buffer
+		if err = s.encode(&buf, rec); err != nil {
+			t.Fatal(name, err)
+		}
+
+		newAvroTestDecoder().decode(t, bytes.NewReader(buf.Bytes()), roundTripAvroSchema(t, s))
+	}
+}
+
+// initAuditRecord returns nil for types that are not audit records, instead of panicking.
+func initAuditRecord(typ types.Type) (rec proto.Message) {
+	defer func() {
+		if recover() != nil {
+			rec = nil
+		}
+	}()
+
+	return InitRecord(typ)
+}
+
+// roundTripAvroSchema returns the schema as it would be parsed from the file metadata.
+func roundTripAvroSchema(t *testing.T, s *avroSchema) interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(s.schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema interface{}
+	if err = json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+// readAvroContainer parses an avro object container file and returns the metadata and all decoded records.
+func readAvroContainer(t *testing.T, data []byte) (meta map[string]string, values []interface{}) {
+	t.Helper()
+
+	if !bytes.HasPrefix(data, []byte(avroMagic)) {
+		t.Fatal("missing avro magic")
+	}
+
+	var (
+		r = bytes.NewReader(data[len(avroMagic):])
+		d = newAvroTestDecoder()
+	)
+
+	meta = make(map[string]string)
+
+	for n := d.long(t, r); n != 0; n = d.long(t, r) {
+		for i := int64(0); i < n; i++ {
+			meta[string(d.bytes(t, r))] = string(d.bytes(t, r))
+		}
+	}
+
+	var schema interface{}
+	if err := json.Unmarshal([]byte(meta["avro.schema"]), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	sync := make([]byte, avroSyncSize)
+	if _, err := r.Read(sync); err != nil {
+		t.Fatal(err)
+	}
+
+	for r.Len() > 0 {
+		var (
+			count = d.long(t, r)
+			block = make([]byte, d.long(t, r))
+		)
+
+		if _, err := r.Read(block); err != nil {
+			t.Fatal(err)
+		}
+
+		switch meta["avro.codec"] {
+		case avroCodecDeflate:
+			var err error
+
+			block, err = ioutil.ReadAll(flate.NewReader(bytes.NewReader(block)))
+			if err != nil {
+				t.Fatal(err)
+			}
+		case avroCodecZstandard:
+			dec, err := zstd.NewReader(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			block, err = dec.DecodeAll(block, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		br := bytes.NewReader(block)
+		for i := int64(0); i < count; i++ {
+			values = append(values, d.decode(t, br, schema))
+		}
+
+		if br.Len() != 0 {
+			t.Fatal("trailing data in block:", br.Len())
+		}
+
+		marker := make([]byte, avroSyncSize)
+		if _, err := r.Read(marker); err != nil || !bytes.Equal(marker, sync) {
+			t.Fatal("invalid sync marker", err)
+		}
+	}
+
+	return meta, values
+}
+
+// avroTestDecoder decodes avro binary data using a parsed JSON schema.
+type avroTestDecoder struct {
+	named map[string]interface{}
+}
+
+func newAvroTestDecoder() *avroTestDecoder {
+	return &avroTestDecoder{named: make(map[string]interface{})}
+}
+
+func (d *avroTestDecoder) long(t *testing.T, r *bytes.Reader) int64 {
+	t.Helper()
+
+	i, err := binary.ReadVarint(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return i
+}
+
+func (d *avroTestDecoder) bytes(t *testing.T, r *bytes.Reader) []byte {
+	t.Helper()
+
+	b := make([]byte, d.long(t, r))
+	if _, err := r.Read(b); err != nil && len(b) > 0 {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func (d *avroTestDecoder) fixed(t *testing.T, r *bytes.Reader, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+	if _, err := r.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func (d *avroTestDecoder) decode(t *testing.T, r *bytes.Reader, schema interface{}) interface{} {
+	t.Helper()
+
+	switch s := schema.(type) {
+	case string:
+		switch s {
+		case "null":
+			return nil
+		case "boolean":
+			return d.fixed(t, r, 1)[0] == 1
+		case "int", "long":
+			return d.long(t, r)
+		case "float":
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(d.fixed(t, r, 4))))
+		case "double":
+			return math.Float64frombits(binary.LittleEndian.Uint64(d.fixed(t, r, 8)))
+		case "string":
+			return string(d.bytes(t, r))
+		case "bytes":
+			return d.bytes(t, r)
+		default:
+			named, ok := d.named[s]
+			if !ok {
+				t.Fatal("unknown type", s)
+			}
+
+			return d.decode(t, r, named)
+		}
+	case []interface{}:
+		return d.decode(t, r, s[d.long(t, r)])
+	case map[string]interface{}:
+		switch s["type"] {
+		case "record":
+			d.named[s["namespace"].(string)+"."+s["name"].(string)] = s
+
+			rec := make(map[string]interface{})
+			for _, f := range s["fields"].([]interface{}) {
+				field := f.(map[string]interface{})
+				rec[field["name"].(string)] = d.decode(t, r, field["type"])
+			}
+
+			return rec
+		case "array":
+			var items []interface{}
+
+			for n := d.long(t, r); n != 0; n = d.long(t, r) {
+				for i := int64(0); i < n; i++ {
+					items = append(items, d.decode(t, r, s["items"]))
+				}
+			}
+
+			return items
+		case "map":
+			m := make(map[string]interface{})
+
+			for n := d.long(t, r); n != 0; n = d.long(t, r) {
+				for i := int64(0); i < n; i++ {
+					m[string(d.bytes(t, r))] = d.decode(t, r, s["values"])
+				}
+			}
+
+			return m
+		}
+	}
+
+	t.Fatal("invalid schema", schema)
+
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// Avro object container file constants.
+const (
+	avroMagic    = "Obj\x01"
+	avroSyncSize = 16
+
+	// avroBlockRecords is the maximum number of records per data block.
+	avroBlockRecords = 4096
+
+	// avroBlockSize is the size of the uncompressed block data that triggers a flush.
+	avroBlockSize = 1024 * 1024
+)
+
+// Avro block compression codecs.
+const (
+	avroCodecNull      = "null"
+	avroCodecDeflate   = "deflate"
+	avroCodecZstandard = "zstandard"
+)
+
+// avroWriter is a structure that supports writing audit records to disk as avro object container files.
+// The schema is derived from the protobuf message type, the netcap header is stored in the file metadata.
+type avroWriter struct {
+	mu      sync.Mutex
+	bWriter *bufio.Writer
+	out     io.Writer
+
+	schema *avroSchema
+	header *types.Header
+	codec  string
+	sync   [avroSyncSize]byte
+
+	// uncompressed data and number of records of the current block
+	block     bytes.Buffer
+	numBlock  int64
+	blockData []byte
+
+	flateWriter *flate.Writer
+	zstdEncoder *zstd.Encoder
+
+	file *os.File
+	wc   *WriterConfig
+}
+
+// newAvroWriter initializes and configures a new avroWriter instance.
+// Compression is applied to the data blocks inside the file, so the file extension is always .avro.
+func newAvroWriter(wc *WriterConfig) *avroWriter {
+	w := &avroWriter{}
+	w.wc = wc
+
+	if wc.MemBufferSize <= 0 {
+		wc.MemBufferSize = defaults.BufferSize
+	}
+
+	// create file
+	w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".avro")
+	ioLog.Info("create avroWriter", zap.String("base", filepath.Join(wc.Out, wc.Name)), zap.String("type", wc.Type.String()))
+
+	w.out = w.file
+
+	if wc.Buffer {
+		w.bWriter = bufio.NewWriterSize(w.file, wc.MemBufferSize)
+		w.out = w.bWriter
+	}
+
+	var err error
+
+	switch {
+	case !wc.Compress:
+		w.codec = avroCodecNull
+	case wc.CompressionFormat == CompressionZstd:
+		w.codec = avroCodecZstandard
+		w.zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdLevel(wc.CompressionLevel)))
+	default:
+		w.codec = avroCodecDeflate
+		w.flateWriter, err = flate.NewWriter(nil, wc.CompressionLevel)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err = rand.Read(w.sync[:]); err != nil {
+		panic(err)
+	}
+
+	return w
+}
+
+// Write encodes a record into the current block.
+func (w *avroWriter) Write(msg proto.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.schema == nil {
+		if err := w.writeFileHeader(msg); err != nil {
+			return err
+		}
+	}
+
+	if err := w.schema.encode(&w.block, msg); err != nil {
+		return err
+	}
+
+	w.numBlock++
+
+	if w.numBlock >= avroBlockRecords || w.block.Len() >= avroBlockSize {
+		return w.flushBlock()
+	}
+
+	return nil
+}
+
+// WriteHeader writes the container file header.
+// The schema is derived from the audit record type and the netcap header is added to the file metadata.
+func (w *avroWriter) WriteHeader(t types.Type) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.header = NewHeader(t, w.wc.Source, w.wc.Version, w.wc.IncludesPayloads, w.wc.StartTime)
+
+	return w.writeFileHeader(InitRecord(t))
+}
+
+// Close flushes and closes the writer and the associated file handles.
+func (w *avroWriter) Close(numRecords int64) (name string, size int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.flushBlock(); err != nil {
+		fmt.Println("failed to flush avro block:", err, "type", w.wc.Name)
+	}
+
+	if w.wc.Buffer {
+		flushWriters(w.bWriter)
+	}
+
+	return closeFile(w.wc.Out, w.file, w.wc.Name, numRecords)
+}
+
+// writeFileHeader writes the magic bytes, the file metadata and the sync marker.
+// It is a no-op if the header has already been written.
+func (w *avroWriter) writeFileHeader(msg proto.Message) error {
+	if w.schema != nil {
+		return nil
+	}
+
+	schema, err := newAvroSchema(msg)
+	if err != nil {
+		return err
+	}
+
+	schemaJSON, err := json.Marshal(schema.schema)
+	if err != nil {
+		return fmt.Errorf("failed to marshal avro schema: %w", err)
+	}
+
+	meta := map[string][]byte{
+		"avro.schema": schemaJSON,
+		"avro.codec":  []byte(w.codec),
+	}
+
+	if h := w.header; h != nil {
+		meta["netcap.type"] = []byte(h.Type.String())
+		meta["netcap.created"] = []byte(strconv.FormatInt(h.Created, 10))
+		meta["netcap.source"] = []byte(h.InputSource)
+		meta["netcap.version"] = []byte(h.Version)
+		meta["netcap.payloads"] = []byte(strconv.FormatBool(h.ContainsPayloads))
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(avroMagic)
+	writeAvroLong(&buf, int64(len(meta)))
+
+	for k, v := range meta {
+		writeAvroBytes(&buf, []byte(k))
+		writeAvroBytes(&buf, v)
+	}
+
+	writeAvroLong(&buf, 0)
+	buf.Write(w.sync[:])
+
+	if _, err = w.out.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write avro header: %w", err)
+	}
+
+	w.schema = schema
+
+	return nil
+}
+
+// flushBlock compresses the current block and writes it to the file, followed by the sync marker.
+func (w *avroWriter) flushBlock() error {
+	if w.numBlock == 0 {
+		return nil
+	}
+
+	data, err := w.compress(w.block.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to compress avro block: %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	writeAvroLong(&buf, w.numBlock)
+	writeAvroLong(&buf, int64(len(data)))
+
+	if _, err = w.out.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	if _, err = w.out.Write(data); err != nil {
+		return err
+	}
+
+	if _, err = w.out.Write(w.sync[:]); err != nil {
+		return err
+	}
+
+	w.block.Reset()
+	w.numBlock = 0
+
+	return nil
+}
+
+// compress applies the block codec to the given data.
+func (w *avroWriter) compress(data []byte) ([]byte, error) {
+	switch w.codec {
+	case avroCodecZstandard:
+		w.blockData = w.zstdEncoder.EncodeAll(data, w.blockData[:0])
+
+		return w.blockData, nil
+	case avroCodecDeflate:
+		var buf bytes.Buffer
+
+		w.flateWriter.Reset(&buf)
+
+		if _, err := w.flateWriter.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := w.flateWriter.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return data, nil
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// batchFlusher is embedded by the writers that queue records and send them in batches.
+// It guards the queue, flushes it periodically and keeps the error of the last periodic flush,
+// so that it can be returned by the next call to Write.
+type batchFlusher struct {
+	sync.Mutex
+
+	// error of the last periodic flush, returned by the next call to Write
+	err error
+
+	// stops the periodic flush
+	stop chan struct{}
+
+	// guards against closing the stop channel twice
+	closeOnce sync.Once
+}
+
+// flushPeriodically calls flush in the given interval, until stopFlushing is called.
+// Errors are logged with the given message and fields. A zero interval disables the periodic flush.
+func (f *batchFlusher) flushPeriodically(interval time.Duration, flush func() error, msg string, fields ...zap.Field) {
+	if interval <= 0 {
+		return
+	}
+
+	f.stop = make(chan struct{})
+
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				f.Lock()
+				if err := flush(); err != nil {
+					ioLog.Error(msg, append(fields, zap.Error(err))...)
+					f.err = err
+				}
+				f.Unlock()
+			case <-f.stop:
+				return
+			}
+		}
+	}()
+}
+
+// flushError returns and resets the error of the last periodic flush, the lock must be held by the caller.
+func (f *batchFlusher) flushError() error {
+	err := f.err
+	f.err = nil
+
+	return err
+}
+
+// stopFlushing stops the periodic flush, it is safe to call it multiple times.
+func (f *batchFlusher) stopFlushing() {
+	f.closeOnce.Do(func() {
+		if f.stop != nil {
+			close(f.stop)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BundleSeparator separates the path of a tar or zip bundle
+// from the name of an audit record file inside of it, e.g: bundle.tar.gz#HTTP.ncap.gz.
+const BundleSeparator = "#"
+
+// ErrBundleMemberNotFound is returned when a bundle does not contain the requested audit record file.
+var ErrBundleMemberNotFound = errors.New("member not found in bundle")
+
+// bundleExtensions are the file extensions of the supported archive formats.
+var bundleExtensions = []string{".tar", ".tar.gz", ".tgz", ".zip"}
+
+// SplitBundlePath splits a path to a member of a tar or zip bundle
+// into the path of the archive and the name of the member.
+// ok is false if path does not refer to a bundle member.
+func SplitBundlePath(path string) (archive, member string, ok bool) {
+	i := strings.LastIndex(path, BundleSeparator)
+	if i == -1 || i == len(path)-1 {
+		return path, "", false
+	}
+
+	archive, member = path[:i], path[i+1:]
+
+	for _, ext := range bundleExtensions {
+		if strings.HasSuffix(archive, ext) {
+			return archive, member, true
+		}
+	}
+
+	return path, "", false
+}
+
+// bundleMember is an audit record file that is streamed from within a bundle,
+// closing it releases the archive and all readers stacked on top of it.
+type bundleMember struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close the member and the underlying archive.
+func (m *bundleMember) Close() error {
+	var err error
+
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		if errClose := m.closers[i].Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}
+
+	return err
+}
+
+// matchesMember checks if the name of an archive entry refers to the requested member.
+// Members can be addressed by their full path inside the archive or by their base name.
+func matchesMember(name, member string) bool {
+	name = strings.TrimPrefix(name, "./")
+
+	return name == member || filepath.Base(name) == member
+}
+
+// openBundleMember locates the member inside the archive and returns a reader for its raw contents.
+// The member itself is not decompressed.
+func openBundleMember(archive, member string) (io.ReadCloser, error) {
+	if strings.HasSuffix(archive, ".zip") {
+		return openZipMember(archive, member)
+	}
+
+	return openTarMember(archive, member)
+}
+
+func openZipMember(archive, member string) (io.ReadCloser, error) {
+	z, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range z.File {
+		if f.FileInfo().IsDir() || !matchesMember(f.Name, member) {
+			continue
+		}
+
+		rc, errOpen := f.Open()
+		if errOpen != nil {
+			_ = z.Close()
+
+			return nil, errOpen
+		}
+
+		return &bundleMember{
+			Reader:  rc,
+			closers: []io.Closer{z, rc},
+		}, nil
+	}
+
+	_ = z.Close()
+
+	return nil, fmt.Errorf("%w: %s in %s", ErrBundleMemberNotFound, member, archive)
+}
+
+func openTarMember(archive, member string) (io.ReadCloser, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		src     io.Reader = f
+		closers           = []io.Closer{f}
+	)
+
+	if strings.HasSuffix(archive, ".gz") || strings.HasSuffix(archive, ".tgz") {
+		gr, errGzip := gzip.NewReader(f)
+		if errGzip != nil {
+			_ = f.Close()
+
+			return nil, errGzip
+		}
+
+		src = gr
+		closers = append(closers, gr)
+	}
+
+	m := &bundleMember{closers: closers}
+	tr := tar.NewReader(src)
+
+	for {
+		hdr, errNext := tr.Next()
+		if errors.Is(errNext, io.EOF) {
+			break
+		} else if errNext != nil {
+			_ = m.Close()
+
+			return nil, errNext
+		}
+
+		if !hdr.FileInfo().Mode().IsRegular() || !matchesMember(hdr.Name, member) {
+			continue
+		}
+
+		m.Reader = tr
+
+		return m, nil
+	}
+
+	_ = m.Close()
+
+	return nil, fmt.Errorf("%w: %s in %s", ErrBundleMemberNotFound, member, archive)
+}
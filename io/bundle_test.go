@@ -0,0 +1,208 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/delimited"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestSplitBundlePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		archive string
+		member  string
+		ok      bool
+	}{
+		{"bundle.tar.gz#HTTP.ncap.gz", "bundle.tar.gz", "HTTP.ncap.gz", true},
+		{"/tmp/case.zip#out/DNS.ncap", "/tmp/case.zip", "out/DNS.ncap", true},
+		{"bundle.tgz#TCP.ncap.gz", "bundle.tgz", "TCP.ncap.gz", true},
+		{"/tmp/HTTP.ncap.gz", "/tmp/HTTP.ncap.gz", "", false},
+		{"/tmp/dir#1/HTTP.ncap.gz", "/tmp/dir#1/HTTP.ncap.gz", "", false},
+		{"bundle.tar#", "bundle.tar#", "", false},
+	}
+
+	for _, tt := range tests {
+		archive, member, ok := SplitBundlePath(tt.path)
+		if archive != tt.archive || member != tt.member || ok != tt.ok {
+			t.Errorf("SplitBundlePath(%q) = %q, %q, %v, want %q, %q, %v", tt.path, archive, member, ok, tt.archive, tt.member, tt.ok)
+		}
+	}
+}
+
+func TestReadBundle(t *testing.T) {
+	var (
+		buf = new(bytes.Buffer)
+		gw  = gzip.NewWriter(buf)
+		dw  = delimited.NewWriter(gw)
+	)
+
+	for _, record := range []proto.Message{
+		&types.Header{Type: types.Type_NC_TCP},
+		&types.TCP{SrcPort: 1},
+		&types.TCP{SrcPort: 2},
+		&types.TCP{SrcPort: 3},
+	} {
+		if err := dw.PutProto(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+
+	dir, err := ioutil.TempDir("", "netcap-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		tarPath = filepath.Join(dir, "bundle.tar.gz")
+		zipPath = filepath.Join(dir, "bundle.zip")
+	)
+
+	writeTarBundle(t, tarPath, data)
+	writeZipBundle(t, zipPath, data)
+
+	for _, path := range []string{
+		tarPath + BundleSeparator + "TCP.ncap.gz",
+		tarPath + BundleSeparator + "out/TCP.ncap.gz",
+		zipPath + BundleSeparator + "TCP.ncap.gz",
+	} {
+		if count := countRecords(t, path); count != 3 {
+			t.Errorf("%s: expected 3 records, got %d", path, count)
+		}
+	}
+
+	_, err = Open(tarPath+BundleSeparator+"HTTP.ncap.gz", defaults.BufferSize)
+	if !errors.Is(err, ErrBundleMemberNotFound) {
+		t.Fatal("expected ErrBundleMemberNotFound, got", err)
+	}
+}
+
+func countRecords(t *testing.T, path string) int {
+	t.Helper()
+
+	r, err := Open(path, defaults.BufferSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	header, err := r.ReadHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Type != types.Type_NC_TCP {
+		t.Fatal("not TCP, got: ", header.Type)
+	}
+
+	var (
+		tcp   = InitRecord(header.Type)
+		count int
+	)
+
+	for {
+		err = r.Next(tcp)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+
+	return count
+}
+
+func writeTarBundle(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var (
+		gw = gzip.NewWriter(f)
+		tw = tar.NewWriter(gw)
+	)
+
+	err = tw.WriteHeader(&tar.Header{Name: "out/", Typeflag: tar.TypeDir, Mode: 0o755})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tw.WriteHeader(&tar.Header{Name: "out/TCP.ncap.gz", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(data))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = tw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZipBundle(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	w, err := zw.Create("TCP.ncap.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-errors/errors"
+	"github.com/gogo/protobuf/proto"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// CBORReader implements reading netcap audit record files in the CBOR format.
+type CBORReader struct {
+	file    *os.File
+	bReader *bufio.Reader
+	gReader *gzip.Reader
+	zReader *zstd.Decoder
+	dec     *cbor.Decoder
+}
+
+// OpenCBOR opens a netcap CBOR audit record file for reading.
+func OpenCBOR(file string, memBufSize int) (*CBORReader, error) {
+	r := &CBORReader{}
+
+	h, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if memBufSize <= 0 {
+		memBufSize = defaults.BufferSize
+	}
+
+	r.file = h
+	r.bReader = bufio.NewReaderSize(h, memBufSize)
+
+	switch filepath.Ext(file) {
+	case ".gz":
+		r.gReader, err = gzip.NewReader(r.bReader)
+		if err != nil {
+			return nil, err
+		}
+
+		r.gReader.Multistream(true)
+
+		r.dec = cbor.NewDecoder(r.gReader)
+	case ".zst":
+		r.zReader, err = zstd.NewReader(r.bReader)
+		if err != nil {
+			return nil, err
+		}
+
+		r.dec = cbor.NewDecoder(r.zReader)
+	default:
+		r.dec = cbor.NewDecoder(r.bReader)
+	}
+
+	return r, nil
+}
+
+// Close the file.
+func (r *CBORReader) Close() error {
+	if r.gReader != nil {
+		err := r.gReader.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.zReader != nil {
+		r.zReader.Close()
+	}
+
+	return r.file.Close()
+}
+
+// Next decodes the next record into msg.
+// Returns io.EOF when there are no more records.
+func (r *CBORReader) Next(msg proto.Message) error {
+	var t cbor.RawTag
+
+	err := r.dec.Decode(&t)
+	if err != nil {
+		return err
+	}
+
+	if t.Number < cborTagBase || t.Number-cborTagBase >= uint64(len(types.Type_name)) {
+		return fmt.Errorf("unexpected cbor tag number %d", t.Number)
+	}
+
+	return cbor.Unmarshal(t.Content, msg)
+}
+
+// ReadHeader reads the file header.
+func (r *CBORReader) ReadHeader() (*types.Header, error) {
+	var (
+		header = new(types.Header)
+		err    = r.Next(header)
+	)
+
+	if err != nil {
+		return nil, errors.New("invalid netcap header in file: " + r.file.Name() + ", error: " + err.Error())
+	}
+
+	return header, nil
+}
@@ -0,0 +1,103 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestCBORWriterRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "netcap-cbor")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := newCBORWriter(&WriterConfig{
+			CBOR:                 true,
+			Name:                 "TCP",
+			Type:                 types.Type_NC_TCP,
+			Buffer:               true,
+			Compress:             compress,
+			Out:                  dir,
+			CompressionBlockSize: defaults.CompressionBlockSize,
+			CompressionLevel:     defaults.CompressionLevel,
+		})
+
+		if err = w.WriteHeader(types.Type_NC_TCP); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tcp := range tcps {
+			if err = w.Write(tcp); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		name, _ := w.Close(int64(len(tcps)))
+
+		r, err := OpenCBOR(filepath.Join(dir, filepath.Base(name)), defaults.BufferSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		header, err := r.ReadHeader()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if header.Type != types.Type_NC_TCP {
+			t.Fatal("not TCP, got: ", header.Type)
+		}
+
+		var count int
+
+		for {
+			tcp := new(types.TCP)
+
+			err = r.Next(tcp)
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if !proto.Equal(tcp, tcps[count]) {
+				t.Fatal("record mismatch, expected: ", tcps[count], " got: ", tcp)
+			}
+
+			count++
+		}
+
+		if count != len(tcps) {
+			t.Fatal("expected ", len(tcps), " audit records, got: ", count)
+		}
+
+		if err = r.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		os.RemoveAll(dir)
+	}
+}
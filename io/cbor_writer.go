@@ -0,0 +1,167 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// cborTagBase is the first CBOR tag number used to mark netcap records.
+// Each record is wrapped in a tag with the number cborTagBase + types.Type,
+// the header uses cborTagBase + types.Type_NC_Header.
+// The numbers are located in the first come first served range of the IANA CBOR tag registry.
+const cborTagBase uint64 = 1313013760 // 0x4e430000, "NC"
+
+// cborWriter is a structure that supports writing CBOR audit records to disk.
+type cborWriter struct {
+	mu      sync.Mutex
+	bWriter *bufio.Writer
+	gWriter compressor
+	cWriter *cborProtoWriter
+
+	file *os.File
+	wc   *WriterConfig
+}
+
+// newCBORWriter initializes and configures a new cborWriter instance.
+func newCBORWriter(wc *WriterConfig) *cborWriter {
+	w := &cborWriter{}
+	w.wc = wc
+
+	if wc.MemBufferSize <= 0 {
+		wc.MemBufferSize = defaults.BufferSize
+	}
+
+	// create file
+	if wc.Compress {
+		w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".cbor"+wc.compressionExtension())
+	} else {
+		w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".cbor")
+	}
+	ioLog.Info("create cborWriter", zap.String("base", filepath.Join(wc.Out, wc.Name)), zap.String("type", wc.Type.String()))
+
+	var out io.Writer = w.file
+
+	if wc.Buffer {
+		w.bWriter = bufio.NewWriterSize(w.file, wc.MemBufferSize)
+		out = w.bWriter
+	}
+
+	if wc.Compress {
+		var errCompressor error
+		w.gWriter, errCompressor = newCompressor(out, wc)
+		if errCompressor != nil {
+			panic(errCompressor)
+		}
+
+		out = w.gWriter
+	}
+
+	w.cWriter = newCBORProtoWriter(out, wc.Type)
+
+	return w
+}
+
+// Write writes a CBOR record.
+func (w *cborWriter) Write(msg proto.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.cWriter.writeRecord(msg)
+}
+
+// WriteHeader writes the netcap header as the first CBOR item.
+func (w *cborWriter) WriteHeader(t types.Type) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.cWriter.writeHeader(NewHeader(t, w.wc.Source, w.wc.Version, w.wc.IncludesPayloads, w.wc.StartTime))
+}
+
+// Close flushes and closes the writer and the associated file handles.
+func (w *cborWriter) Close(numRecords int64) (name string, size int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// the gzip writer must be closed first, so the trailer ends up in the buffer before it is flushed
+	if w.wc.Compress {
+		closeCompressors(w.gWriter)
+	}
+
+	if w.wc.Buffer {
+		flushWriters(w.bWriter)
+	}
+
+	return closeFile(w.wc.Out, w.file, w.wc.Name, numRecords)
+}
+
+// cborProtoWriter implements writing audit records to disk in the CBOR format.
+// Every record is encoded as a map keyed by field name, wrapped in a tag that identifies the record type.
+type cborProtoWriter struct {
+	sync.Mutex
+	enc *cbor.Encoder
+	tag uint64
+}
+
+// newCBORProtoWriter returns a new CBOR writer instance for records of the given type.
+func newCBORProtoWriter(w io.Writer, t types.Type) *cborProtoWriter {
+	return &cborProtoWriter{
+		enc: cbor.NewEncoder(w),
+		tag: cborTagBase + uint64(t),
+	}
+}
+
+// writeHeader writes the netcap header to the underlying file.
+func (w *cborProtoWriter) writeHeader(h *types.Header) error {
+	w.Lock()
+	defer w.Unlock()
+
+	err := w.enc.Encode(cbor.Tag{
+		Number:  cborTagBase + uint64(types.Type_NC_Header),
+		Content: h,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cbor header: %w", err)
+	}
+
+	return nil
+}
+
+// writeRecord writes a protocol buffer into the CBOR writer.
+func (w *cborProtoWriter) writeRecord(msg proto.Message) error {
+	w.Lock()
+	defer w.Unlock()
+
+	err := w.enc.Encode(cbor.Tag{
+		Number:  w.tag,
+		Content: msg,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cbor record: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+)
+
+// Compression is the algorithm used to compress audit record files.
+type Compression string
+
+// Supported compression algorithms.
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+	CompressionNone Compression = "none"
+)
+
+var errUnknownCompression = errors.New("unknown compression")
+
+// ParseCompression returns the compression algorithm for the given name.
+func ParseCompression(name string) (Compression, error) {
+	switch c := Compression(name); c {
+	case CompressionGzip, CompressionZstd, CompressionNone:
+		return c, nil
+	default:
+		return "", fmt.Errorf("%w: %s", errUnknownCompression, name)
+	}
+}
+
+// compressor is implemented by the pgzip and zstd writers.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// newCompressor wraps w with the compression algorithm from the writer config.
+// gzip is used if no algorithm has been configured.
+func newCompressor(w io.Writer, wc *WriterConfig) (compressor, error) {
+	if wc.CompressionFormat == CompressionZstd {
+		zw, err := zstd.NewWriter(w,
+			zstd.WithEncoderLevel(zstdLevel(wc.CompressionLevel)),
+			zstd.WithEncoderConcurrency(runtime.GOMAXPROCS(0)),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return zw, nil
+	}
+
+	gw, err := pgzip.NewWriterLevel(w, wc.CompressionLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	// To get any performance gains, you should at least be compressing more than 1 megabyte of data at the time.
+	// You should at least have a block size of 100k and at least a number of blocks that match the number of cores
+	// you would like to utilize, but about twice the number of blocks would be the best.
+	if err = gw.SetConcurrency(wc.CompressionBlockSize, runtime.GOMAXPROCS(0)*2); err != nil {
+		log.Fatal("failed to configure compression package: ", err)
+	}
+
+	return gw, nil
+}
+
+// zstdLevel maps the gzip compression levels to the closest zstd encoder level.
+func zstdLevel(level int) zstd.EncoderLevel {
+	switch level {
+	case pgzip.BestSpeed:
+		return zstd.SpeedFastest
+	case pgzip.BestCompression:
+		return zstd.SpeedBestCompression
+	default:
+		return zstd.SpeedDefault
+	}
+}
+
+// compressionExtension returns the file extension for the configured compression algorithm,
+// or an empty string if compression is disabled.
+func (wc *WriterConfig) compressionExtension() string {
+	if !wc.Compress {
+		return ""
+	}
+
+	if wc.CompressionFormat == CompressionZstd {
+		return ".zst"
+	}
+
+	return ".gz"
+}
@@ -1,8 +1,9 @@
 package io
 
 import (
+	"bytes"
+	"encoding/csv"
 	"io"
-	"strings"
 	"sync"
 	"time"
 
@@ -25,9 +26,10 @@ type csvProtoWriter struct {
 	w io.Writer
 
 	// config
-	encode  bool
-	analyze bool
-	label   bool
+	encode    bool
+	analyze   bool
+	label     bool
+	delimiter rune
 
 	// avoid allocations by reusing these variables
 	//values []string
@@ -37,14 +39,42 @@ type csvProtoWriter struct {
 }
 
 // newCSVProtoWriter returns a new CSV writer instance.
-func newCSVProtoWriter(w io.Writer, encode bool, label bool) *csvProtoWriter {
+// If no delimiter is provided, fields are separated by a comma.
+func newCSVProtoWriter(w io.Writer, encode bool, label bool, delimiter rune) *csvProtoWriter {
+	if delimiter == 0 {
+		delimiter = defaultCSVDelimiter
+	}
+
 	return &csvProtoWriter{
-		w:      w,
-		encode: encode,
-		label:  label,
+		w:         w,
+		encode:    encode,
+		label:     label,
+		delimiter: delimiter,
 	}
 }
 
+// defaultCSVDelimiter is used to separate the fields if no delimiter has been configured.
+const defaultCSVDelimiter = ','
+
+// formatRow joins the values with the configured delimiter and terminates the row with a newline.
+// Values containing the delimiter, quotes or line breaks are quoted according to RFC 4180.
+func (w *csvProtoWriter) formatRow(values []string) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		cw  = csv.NewWriter(&buf)
+	)
+
+	cw.Comma = w.delimiter
+
+	if err := cw.Write(values); err != nil {
+		return nil, err
+	}
+
+	cw.Flush()
+
+	return buf.Bytes(), cw.Error()
+}
+
 // writeHeader writes the CSV header to the underlying file.
 func (w *csvProtoWriter) writeHeader(h *types.Header, msg proto.Message) (int, error) {
 	w.Lock()
@@ -60,12 +90,18 @@ func (w *csvProtoWriter) writeHeader(h *types.Header, msg proto.Message) (int, e
 
 	if csv, ok := msg.(types.AuditRecord); ok {
 
+		header := csv.CSVHeader()
 		if w.label {
 			// TODO: make label column name configurable
-			return w.w.Write([]byte(strings.Join(append(csv.CSVHeader(), "Category"), ",") + "\n"))
+			header = append(header, "Category")
 		}
 
-		return w.w.Write([]byte(strings.Join(csv.CSVHeader(), ",") + "\n"))
+		out, err := w.formatRow(header)
+		if err != nil {
+			return 0, err
+		}
+
+		return w.w.Write(out)
 	}
 
 	spew.Dump(msg)
@@ -100,24 +136,22 @@ func (w *csvProtoWriter) writeRecord(msg proto.Message) (int, error) {
 			record.Analyze()
 		}
 
-		var (
-			values []string
-			out    []byte
-		)
+		var values []string
 		if w.encode {
 			// encode values to numeric format and normalize
 			values = record.Encode()
-			if w.label {
-				values = append(values, labelManager.Label(record))
-			}
-			out = []byte(strings.Join(values, ",") + "\n")
 		} else {
 			// use raw values
 			values = record.CSVRecord()
-			if w.label {
-				values = append(values, labelManager.Label(record))
-			}
-			out = []byte(strings.Join(values, ",") + "\n")
+		}
+
+		if w.label {
+			values = append(values, labelManager.Label(record))
+		}
+
+		out, errFormat := w.formatRow(values)
+		if errFormat != nil {
+			return 0, errFormat
 		}
 
 		fails := 0
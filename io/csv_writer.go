@@ -16,12 +16,9 @@ package io
 import (
 	"bufio"
 	"github.com/gogo/protobuf/proto"
-	"github.com/klauspost/pgzip"
 	"go.uber.org/zap"
-	"log"
 	"os"
 	"path/filepath"
-	"runtime"
 
 	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
@@ -30,7 +27,7 @@ import (
 // csvWriter is a structure that supports writing CSV audit records to disk.
 type csvWriter struct {
 	bWriter   *bufio.Writer
-	gWriter   *pgzip.Writer
+	gWriter   compressor
 	csvWriter *csvProtoWriter
 
 	file *os.File
@@ -48,7 +45,7 @@ func newCSVWriter(wc *WriterConfig) *csvWriter {
 
 	// create file
 	if wc.Compress {
-		w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".csv.gz")
+		w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".csv"+wc.compressionExtension())
 	} else {
 		w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".csv")
 	}
@@ -58,36 +55,27 @@ func newCSVWriter(wc *WriterConfig) *csvWriter {
 		w.bWriter = bufio.NewWriterSize(w.file, wc.MemBufferSize)
 
 		if wc.Compress {
-			var errGzipWriter error
-			w.gWriter, errGzipWriter = pgzip.NewWriterLevel(w.bWriter, wc.CompressionLevel)
+			var errCompressor error
+			w.gWriter, errCompressor = newCompressor(w.bWriter, wc)
 
-			if errGzipWriter != nil {
-				panic(errGzipWriter)
+			if errCompressor != nil {
+				panic(errCompressor)
 			}
 
-			w.csvWriter = newCSVProtoWriter(w.gWriter, wc.Encode, wc.Label)
+			w.csvWriter = newCSVProtoWriter(w.gWriter, wc.Encode, wc.Label, wc.CSVDelimiter)
 		} else {
-			w.csvWriter = newCSVProtoWriter(w.bWriter, wc.Encode, wc.Label)
+			w.csvWriter = newCSVProtoWriter(w.bWriter, wc.Encode, wc.Label, wc.CSVDelimiter)
 		}
 	} else {
 		if wc.Compress {
-			var errGzipWriter error
-			w.gWriter, errGzipWriter = pgzip.NewWriterLevel(w.file, wc.CompressionLevel)
-			if errGzipWriter != nil {
-				panic(errGzipWriter)
+			var errCompressor error
+			w.gWriter, errCompressor = newCompressor(w.file, wc)
+			if errCompressor != nil {
+				panic(errCompressor)
 			}
-			w.csvWriter = newCSVProtoWriter(w.gWriter, wc.Encode, wc.Label)
+			w.csvWriter = newCSVProtoWriter(w.gWriter, wc.Encode, wc.Label, wc.CSVDelimiter)
 		} else {
-			w.csvWriter = newCSVProtoWriter(w.file, wc.Encode, wc.Label)
-		}
-	}
-
-	if w.gWriter != nil {
-		// To get any performance gains, you should at least be compressing more than 1 megabyte of data at the time.
-		// You should at least have a block size of 100k and at least a number of blocks that match the number of cores
-		// you would like to utilize, but about twice the number of blocks would be the best.
-		if err := w.gWriter.SetConcurrency(wc.CompressionBlockSize, runtime.GOMAXPROCS(0)*2); err != nil {
-			log.Fatal("failed to configure compression package: ", err)
+			w.csvWriter = newCSVProtoWriter(w.file, wc.Encode, wc.Label, wc.CSVDelimiter)
 		}
 	}
 
@@ -118,7 +106,7 @@ func (w *csvWriter) Close(numRecords int64) (name string, size int64) {
 	}
 
 	if w.wc.Compress {
-		closeGzipWriters(w.gWriter)
+		closeCompressors(w.gWriter)
 	}
 
 	return closeFile(w.wc.Out, w.file, w.wc.Name, numRecords)
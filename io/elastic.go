@@ -24,7 +24,6 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v7"
@@ -36,12 +35,22 @@ import (
 	"github.com/dreadl0ck/netcap/types"
 )
 
-const indexPrefix = "netcap-"
+const (
+	// layout for the {date} placeholder of the index name template.
+	elasticDateFormat = "2006.01.02"
+
+	// backoff before retrying a rejected bulk request, doubled for each attempt.
+	elasticInitialBackoff = 500 * time.Millisecond
+	elasticMaxBackoff     = 30 * time.Second
+)
 
 var (
 	// errElasticFailed indicates sending data to elasticsearch has failed.
 	errElasticFailed = errors.New("failed to send data to elastic")
 
+	// errElasticOverloaded indicates that elasticsearch rejected documents with 429 Too Many Requests.
+	errElasticOverloaded = errors.New("elastic is overloaded")
+
 	// errMissingAuditRecordInterface indicates the audit record is lacking methods to implement the types.AuditRecord interface.
 	errMissingAuditRecordInterface = errors.New("type does not implement the types.AuditRecord interface")
 )
@@ -66,20 +75,27 @@ type ElasticConfig struct {
 
 	// BulkSize controls the number of documents sent to elastic per batch
 	BulkSize int
+
+	// ElasticIndex is the template for the index names, {type} is replaced with the record type
+	// and {date} with the day of the record timestamp
+	ElasticIndex string
+
+	// ElasticFlushInterval sends the queued documents periodically, 0 only sends full batches
+	ElasticFlushInterval time.Duration
+
+	// ElasticMaxRetries is the number of times a batch is retried, when elastic is overloaded or not reachable
+	ElasticMaxRetries int
 }
 
 // elasticWriter is a writer that writes into an elastic database.
 type elasticWriter struct {
-	sync.Mutex
-	client     *elasticsearch.Client
-	queue      []proto.Message
-	queueIndex int
-	wc         *WriterConfig
-	meta       []byte
-	buf        bytes.Buffer
-	processed  int
-
-	indexName string
+	batchFlusher
+
+	client *elasticsearch.Client
+	wc     *WriterConfig
+
+	// bulk lines of the queued documents, each consisting of the action metadata and the document
+	queue [][]byte
 }
 
 /*
@@ -88,6 +104,9 @@ type elasticWriter struct {
 
 // newElasticWriter initializes and configures a new elasticWriter instance.
 func newElasticWriter(wc *WriterConfig) *elasticWriter {
+	if wc.Compress {
+		panic("compression cannot be activated when writing to elastic")
+	}
 
 	ioLog.Info("create elasticWriter", zap.String("type", wc.Type.String()))
 
@@ -101,13 +120,19 @@ func newElasticWriter(wc *WriterConfig) *elasticWriter {
 		log.Fatal(err)
 	}
 
-	return &elasticWriter{
-		client:    c,
-		wc:        wc,
-		queue:     make([]proto.Message, wc.BulkSize),
-		indexName: makeElasticIndexIdent(wc),
-		meta:      []byte(fmt.Sprintf(`{ "index" : { } }%s`, "\n")),
+	if wc.BulkSize <= 0 {
+		wc.BulkSize = defaults.ElasticBulkSize
+	}
+
+	w := &elasticWriter{
+		client: c,
+		wc:     wc,
+		queue:  make([][]byte, 0, wc.BulkSize),
 	}
+
+	w.flushPeriodically(wc.ElasticFlushInterval, w.flush, "failed to flush audit records to elastic", zap.String("type", wc.Name))
+
+	return w
 }
 
 // CreateElasticIndex will create and configure a single elastic database index.
@@ -128,20 +153,30 @@ func CreateElasticIndex(wc *WriterConfig) {
 	}
 
 	// create index identifier and the index
+	// indices containing the date are created on demand by elastic, so a template is registered for them instead
 	index := makeElasticIndexIdent(wc)
-	createElasticIndex(c, index)
+	timeBased := strings.Contains(index, "*")
+
+	if timeBased {
+		putElasticIndexTemplate(c, wc, index)
+	} else {
+		createElasticIndex(c, index)
+	}
+
+	// the kibana object identifier must not contain the wildcard
+	patternID := strings.Trim(strings.ReplaceAll(index, "*", ""), "-_.")
 
 	// delete index pattern to ensure all changes on the mappings are applied
-	deleteElasticIndexPattern(index, wc)
+	deleteElasticIndexPattern(patternID, wc)
 
 	// create buffer for request and add meta data
-	buf := initElasticBuffer(wc, index)
+	buf := initElasticBuffer(wc, strings.TrimSuffix(index, "*"))
 
 	// TODO: the saved_objects API does not seem to be implemented in the elastic golang client for v7
 	// passing an explicit id to prevent kibana from duplicating patterns when executing the index creation multiple times
 	r, err := http.NewRequest(
 		http.MethodPost,
-		wc.KibanaEndpoint+"/api/saved_objects/index-pattern/"+index,
+		wc.KibanaEndpoint+"/api/saved_objects/index-pattern/"+patternID,
 		&buf,
 	)
 	if err != nil {
@@ -160,25 +195,51 @@ func CreateElasticIndex(wc *WriterConfig) {
 				_ = resp.Body.Close()
 			}
 		} else {
-			fmt.Println("index pattern ", index+"* created:", resp.Status)
+			fmt.Println("index pattern ", strings.TrimSuffix(index, "*")+"* created:", resp.Status)
 			_ = resp.Body.Close()
 		}
 	}
 
 	// configure the mapping for the new index
-	configureIndex(c, wc, index)
+	if !timeBased {
+		configureIndex(c, wc, index)
+	}
 }
 
-// Write writes a record to elastic.
+// Write queues a record and sends the queue to elastic once the bulk size has been reached.
 func (w *elasticWriter) Write(msg proto.Message) error {
+	rec, ok := msg.(types.AuditRecord)
+	if !ok {
+		return fmt.Errorf("%s: %w", msg, errMissingAuditRecordInterface)
+	}
+
+	// determine the index before encoding, since JSON converts the timestamps to milliseconds
+	index := elasticIndexName(w.wc, time.Unix(0, rec.Time()))
+
+	js, err := rec.JSON()
+	if err != nil {
+		return err
+	}
+
+	line := make([]byte, 0, len(index)+len(js)+32)
+	line = append(line, `{"index":{"_index":"`...)
+	line = append(line, index...)
+	line = append(line, "\"}}\n"...)
+	line = append(line, js...)
+	line = append(line, '\n')
+
 	w.Lock()
 	defer w.Unlock()
 
-	w.queue[w.queueIndex] = msg
-	w.queueIndex++
+	// surface errors of the periodic flush
+	if err = w.flushError(); err != nil {
+		return err
+	}
+
+	w.queue = append(w.queue, line)
 
-	if w.queueIndex == w.wc.BulkSize {
-		w.sendData()
+	if len(w.queue) >= w.wc.BulkSize {
+		return w.flush()
 	}
 
 	return nil
@@ -191,12 +252,16 @@ func (w *elasticWriter) WriteHeader(_ types.Type) error {
 
 // Close flushes and closes the writer and the associated file handles.
 func (w *elasticWriter) Close(_ int64) (name string, size int64) {
+	ioLog.Info("closing elastic writer", zap.String("type", w.wc.Name))
 
-	ioLog.Info("closing elastic writer", zap.String("index", w.indexName))
+	w.stopFlushing()
 
-	err := w.sendBulk(0, 0)
+	w.Lock()
+	defer w.Unlock()
+
+	err := w.flush()
 	if err != nil {
-		ioLog.Error("failed to flush remaining audit records to elastic", zap.Error(err), zap.String("index", w.indexName))
+		ioLog.Error("failed to flush remaining audit records to elastic", zap.Error(err), zap.String("type", w.wc.Name))
 	}
 
 	return "", 0
@@ -225,52 +290,52 @@ type bulkResponse struct {
 	} `json:"items"`
 }
 
-func (w *elasticWriter) sendData() {
-	var (
-		unit = w.wc.BulkSize
-		err  error
-		half bool
-	)
-
-	for {
-		err = w.sendBulk(0, unit)
-		if err != nil {
-			fmt.Println("failed to send elastic bulk data:", err, w.wc.Name)
+// flush sends all queued documents, the lock must be held by the caller.
+// The queue is emptied even if sending fails, so a broken connection does not exhaust the memory.
+func (w *elasticWriter) flush() error {
+	if len(w.queue) == 0 {
+		return nil
+	}
 
-			if !half {
-				// half the unit and try again
-				half = true
-				unit /= 2
-				fmt.Println("half the unit from to", unit)
-			}
+	docs := w.queue
+	w.queue = make([][]byte, 0, w.wc.BulkSize)
 
-			continue
-		}
+	return w.sendBulk(docs)
+}
 
-		// if the batch was cut in half due to a previous error, send the remainder
-		if half {
-			fmt.Println("sending remaining half", unit)
+// elasticIndexName returns the index for a record of the writer, with the date of the given timestamp.
+func elasticIndexName(wc *WriterConfig, ts time.Time) string {
+	index := strings.ReplaceAll(elasticIndexTemplate(wc), "{type}", elasticTypeName(wc.Name))
 
-			err = w.sendBulk(0, unit)
-			if err != nil {
-				fmt.Println("failed to send elastic bulk data half", err, w.wc.Name)
-			}
+	if strings.Contains(index, "{date}") {
+		if ts.Unix() <= 0 {
+			ts = time.Now()
+		}
 
-			fmt.Println("items in queue after sending second batch:", len(w.queue))
+		index = strings.ReplaceAll(index, "{date}", ts.UTC().Format(elasticDateFormat))
+	}
 
-			// realloc queue
-			w.queue = make([]proto.Message, w.wc.BulkSize)
-		}
+	return index
+}
 
-		// reset queue index
-		w.queueIndex = 0
+// makeElasticIndexIdent returns the index for the writer, the date of time based indices is replaced by a wildcard.
+func makeElasticIndexIdent(wc *WriterConfig) string {
+	return strings.ReplaceAll(
+		strings.ReplaceAll(elasticIndexTemplate(wc), "{type}", elasticTypeName(wc.Name)),
+		"{date}", "*",
+	)
+}
 
-		break
+func elasticIndexTemplate(wc *WriterConfig) string {
+	if wc.ElasticIndex == "" {
+		return defaults.ElasticIndex
 	}
+
+	return wc.ElasticIndex
 }
 
-func makeElasticIndexIdent(wc *WriterConfig) string {
-	return indexPrefix + strings.ReplaceAll(strings.ToLower(wc.Name), "/", "-")
+func elasticTypeName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "/", "-")
 }
 
 func createElasticIndex(c *elasticsearch.Client, ident string) {
@@ -288,6 +353,42 @@ func createElasticIndex(c *elasticsearch.Client, ident string) {
 	}
 }
 
+// putElasticIndexTemplate registers the mapping and settings for all indices matching the pattern.
+func putElasticIndexTemplate(c *elasticsearch.Client, wc *WriterConfig, pattern string) {
+	if wc.LimitTotalFields == 0 {
+		wc.LimitTotalFields = defaults.ElasticLimitTotalFields
+	}
+
+	tpl, err := json.Marshal(map[string]interface{}{
+		"index_patterns": []string{pattern},
+		"settings": map[string]string{
+			"index.mapping.total_fields.limit": strconv.Itoa(wc.LimitTotalFields),
+		},
+		"mappings": json.RawMessage(generateMapping(wc.Type)),
+	})
+	if err != nil {
+		log.Println("failed to marshal index template:", err)
+
+		return
+	}
+
+	name := strings.Trim(strings.ReplaceAll(pattern, "*", ""), "-_.")
+
+	res, err := c.Indices.PutTemplate(name, bytes.NewReader(tpl))
+	if err != nil || res.StatusCode != http.StatusOK {
+		fmt.Println("failed to create elastic index template:", err)
+
+		if res != nil {
+			data, _ := ioutil.ReadAll(res.Body)
+			fmt.Println(string(data))
+			_ = res.Body.Close()
+		}
+	} else {
+		fmt.Println("created elastic index template:", name, res.Status())
+		_ = res.Body.Close()
+	}
+}
+
 func initElasticBuffer(wc *WriterConfig, index string) bytes.Buffer {
 	timeField := "Timestamp"
 
@@ -383,134 +484,145 @@ func configureIndex(c *elasticsearch.Client, wc *WriterConfig, index string) {
 	_ = res.Body.Close()
 }
 
-// send a bulk of audit records and metadata to the elastic database daemon.
-func (w *elasticWriter) sendBulk(start, limit int) error {
-	w.processed = 0
-	total := 0
+// sendBulk sends the documents to elastic and retries with an exponential backoff,
+// as long as elastic rejects documents with 429 Too Many Requests or can not be reached.
+func (w *elasticWriter) sendBulk(docs [][]byte) error {
+	var (
+		backoff = elasticInitialBackoff
+		failed  int
+	)
 
-	for _, qmsg := range w.queue[start:] {
-		if qmsg == nil {
-			continue
-		}
+	for attempt := 0; ; attempt++ {
+		retry, numFailed, err := w.bulk(docs)
+		failed += numFailed
 
-		if rec, ok := qmsg.(types.AuditRecord); ok {
-			// prepare the data payload: encode record to JSON
-			js, err := rec.JSON()
-			if err != nil {
-				return err
-			}
+		if err != nil && len(retry) == 0 {
+			return err
+		}
 
-			// append newline to the data payload
-			data := []byte(js)
-			data = append(data, "\n"...)
+		if len(retry) == 0 {
+			break
+		}
 
-			// append payloads to the buffer
-			w.buf.Grow(len(w.meta) + len(data))
-			_, _ = w.buf.Write(w.meta)
-			_, _ = w.buf.Write(data)
+		if attempt >= w.maxRetries() {
+			return fmt.Errorf("%w: giving up after %d retries: %s", errElasticFailed, attempt, err)
+		}
 
-			total++
+		ioLog.Warn("retrying elastic bulk request",
+			zap.Error(err),
+			zap.Int("documents", len(retry)),
+			zap.Duration("backoff", backoff),
+			zap.String("type", w.wc.Name),
+		)
 
-			// pass limit = 0 to process the entire queue
-			if limit > 0 && w.processed >= limit {
+		time.Sleep(backoff)
 
-				w.processed++
-				w.queue = w.queue[w.processed:]
-				break
-			}
-		} else {
-			return fmt.Errorf("%s: %w", qmsg, errMissingAuditRecordInterface)
+		if backoff *= 2; backoff > elasticMaxBackoff {
+			backoff = elasticMaxBackoff
 		}
+
+		docs = retry
 	}
 
-	if w.buf.Len() == 0 {
-		return nil
+	if failed > 0 {
+		return fmt.Errorf("%w: %d documents have been rejected", errElasticFailed, failed)
 	}
 
-	for {
-		// send off the bulk data
-		res, err := w.client.Bulk(bytes.NewReader(w.buf.Bytes()), w.client.Bulk.WithIndex(w.indexName))
-		if err != nil {
+	ioLog.Info("sent audit records to elastic", zap.String("type", w.wc.Name))
+
+	return nil
+}
 
-			// network error - wait a little and retry
-			dur := 500 * time.Millisecond
+// bulk sends the documents with a single bulk request.
+// It returns the documents that should be retried along with the reason, and the number of documents that failed.
+func (w *elasticWriter) bulk(docs [][]byte) (retry [][]byte, failed int, err error) {
+	var buf bytes.Buffer
+	for _, d := range docs {
+		buf.Write(d)
+	}
 
-			fmt.Println("failure indexing batch:", err, "will sleep for", dur, "and retry")
-			time.Sleep(dur)
+	res, err := w.client.Bulk(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		// network error
+		return docs, 0, err
+	}
 
-			continue
-		}
+	// close the response body, to prevent reaching the limit for goroutines or file handles
+	defer res.Body.Close()
 
-		// if the whole request failed, print error and mark all documents as failed
-		if res.IsError() {
-			var raw map[string]interface{}
-			if err = json.NewDecoder(res.Body).Decode(&raw); err != nil {
-				log.Printf("failure to parse response body: %s", err)
-			} else {
-				ioLog.Error("elastic bulk request failed",
-					zap.Int("status", res.StatusCode),
-					zap.String("type", raw["error"].(map[string]interface{})["type"].(string)),
-					zap.String("reason", raw["error"].(map[string]interface{})["reason"].(string)),
-					zap.String("index", w.indexName),
-				)
-			}
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		return docs, 0, errElasticOverloaded
+	case res.StatusCode == http.StatusRequestEntityTooLarge && len(docs) > 1:
+		// split the batch in half and try again
+		half := len(docs) / 2
 
-			// dump buffer in case of errors
-			ioLog.Debug(w.buf.String())
-			w.buf.Reset()
+		for _, part := range [][][]byte{docs[:half], docs[half:]} {
+			r, f, errPart := w.bulk(part)
+			retry = append(retry, r...)
+			failed += f
 
-			return fmt.Errorf("%w: %s %s", errElasticFailed, res.Status(), res.String())
+			if errPart != nil {
+				err = errPart
+			}
 		}
 
-		// a successful response can still contain errors for some documents
-		var blk *bulkResponse
-		if err = json.NewDecoder(res.Body).Decode(&blk); err != nil {
-			log.Printf("failure to parse response body: %s", err)
+		return retry, failed, err
+	case res.IsError():
+		// the whole request failed, dump buffer in case of errors
+		ioLog.Debug(buf.String())
 
-			// dump buffer in case of errors
-			ioLog.Debug(w.buf.String())
-			w.buf.Reset()
+		return nil, 0, fmt.Errorf("%w: %s", errElasticFailed, res.String())
+	}
 
-			return fmt.Errorf("%w: %s", errElasticFailed, err)
-		}
+	// a successful response can still contain errors for some documents
+	var blk *bulkResponse
+	if err = json.NewDecoder(res.Body).Decode(&blk); err != nil {
+		return nil, 0, fmt.Errorf("%w: failed to parse response body: %s", errElasticFailed, err)
+	}
 
-		var hadErrors bool
-
-		// log errors for HTTP status codes above 201
-		for _, d := range blk.Items {
-			if d.Index.Status > 201 {
-				hadErrors = true
-
-				ioLog.Error("error for item in elastic bulk request",
-					zap.Int("status", d.Index.Status),
-					zap.String("type", d.Index.Error.Type),
-					zap.String("reason", d.Index.Error.Reason),
-					zap.String("causeType", d.Index.Error.Cause.Type),
-					zap.String("causeReason", d.Index.Error.Cause.Reason),
-					zap.String("index", w.indexName),
-				)
-			}
+	// the items are in the order of the documents in the request
+	for i, d := range blk.Items {
+		if i >= len(docs) {
+			ioLog.Warn("elastic bulk response contains more items than documents",
+				zap.Int("items", len(blk.Items)),
+				zap.Int("documents", len(docs)),
+				zap.String("type", w.wc.Name),
+			)
+
+			break
 		}
 
-		// dump buffer in case of errors
-		if hadErrors {
-			ioLog.Debug(w.buf.String())
+		switch {
+		case d.Index.Status == http.StatusTooManyRequests:
+			retry = append(retry, docs[i])
+		case d.Index.Status > 201:
+			failed++
+
+			ioLog.Error("error for item in elastic bulk request",
+				zap.Int("status", d.Index.Status),
+				zap.String("type", d.Index.Error.Type),
+				zap.String("reason", d.Index.Error.Reason),
+				zap.String("causeType", d.Index.Error.Cause.Type),
+				zap.String("causeReason", d.Index.Error.Cause.Reason),
+				zap.String("doc", string(docs[i])),
+			)
 		}
+	}
 
-		// close the response body, to prevent reaching the limit for goroutines or file handles
-		_ = res.Body.Close()
+	if len(retry) > 0 {
+		return retry, failed, errElasticOverloaded
+	}
 
-		ioLog.Info("sent audit records to elastic",
-			zap.Int("total", total),
-			zap.String("type", w.wc.Name),
-		)
-		w.buf.Reset()
+	return nil, failed, nil
+}
 
-		// exit loop on success
-		break
+func (w *elasticWriter) maxRetries() int {
+	if w.wc.ElasticMaxRetries <= 0 {
+		return defaults.ElasticMaxRetries
 	}
 
-	return nil
+	return w.wc.ElasticMaxRetries
 }
 
 // JSON properties for elastic indices.
@@ -534,6 +646,7 @@ var typeMapping = map[string]string{
 	"TimestampFirst":     "date",
 	"TimestampLast":      "date",
 	"ReferenceTimestamp": "date",
+	"ProtocolTime":       "date",
 
 	"Duration":    "long",
 	"Bytes":       "long",
@@ -541,6 +654,7 @@ var typeMapping = map[string]string{
 	"AckNum":      "long",
 	"ReferenceID": "long",
 	"Xid":         "long",
+	"ClockSkew":   "long",
 
 	"SrcIP":        "ip",
 	"DstIP":        "ip",
@@ -0,0 +1,212 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// bulkRequest returns the index names and documents of a bulk request body.
+func bulkRequest(t *testing.T, r *http.Request) (indices, docs []string) {
+	t.Helper()
+
+	s := bufio.NewScanner(r.Body)
+	for s.Scan() {
+		meta := s.Text()
+		if !s.Scan() {
+			t.Fatal("missing document for action", meta)
+		}
+
+		indices = append(indices, strings.TrimSuffix(strings.TrimPrefix(meta, `{"index":{"_index":"`), `"}}`))
+		docs = append(docs, s.Text())
+	}
+
+	return indices, docs
+}
+
+func TestElasticWriterRetry(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests [][]string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+
+		indices, docs := bulkRequest(t, r)
+
+		mu.Lock()
+		requests = append(requests, docs)
+		n := len(requests)
+		mu.Unlock()
+
+		for _, i := range indices {
+			if i != "netcap-tcp-1970.01.01" {
+				t.Error("unexpected index", i)
+			}
+		}
+
+		switch n {
+		case 1:
+			// the whole request is rejected
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			// the second document is rejected
+			_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":429}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	w := newElasticWriter(&WriterConfig{
+		Name: "TCP",
+		Type: types.Type_NC_TCP,
+		ElasticConfig: ElasticConfig{
+			ElasticAddrs: []string{srv.URL},
+			ElasticIndex: "netcap-{type}-{date}",
+			BulkSize:     2,
+		},
+	})
+
+	for _, port := range []int32{80, 443} {
+		if err := w.Write(&types.TCP{Timestamp: int64(time.Second), DstPort: port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w.Close(0)
+
+	if len(requests) != 3 {
+		t.Fatal("expected 3 requests, got", len(requests))
+	}
+
+	if len(requests[2]) != 1 || !strings.Contains(requests[2][0], `"DstPort":443`) {
+		t.Fatal("expected only the rejected document to be retried, got", requests[2])
+	}
+}
+
+func TestElasticWriterError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`))
+	}))
+	defer srv.Close()
+
+	w := newElasticWriter(&WriterConfig{
+		Name: "TCP",
+		Type: types.Type_NC_TCP,
+		ElasticConfig: ElasticConfig{
+			ElasticAddrs: []string{srv.URL},
+			BulkSize:     1,
+		},
+	})
+	defer w.Close(0)
+
+	err := w.Write(&types.TCP{Timestamp: int64(time.Second)})
+	if !errors.Is(err, errElasticFailed) {
+		t.Fatal("expected errElasticFailed, got", err)
+	}
+}
+
+func TestElasticWriterExtraItems(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		// more items than documents in the request
+		_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400}},{"index":{"status":429}}]}`))
+	}))
+	defer srv.Close()
+
+	w := newElasticWriter(&WriterConfig{
+		Name: "TCP",
+		Type: types.Type_NC_TCP,
+		ElasticConfig: ElasticConfig{
+			ElasticAddrs: []string{srv.URL},
+			BulkSize:     1,
+		},
+	})
+
+	if err := w.Write(&types.TCP{Timestamp: int64(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// closing twice must not panic
+	w.Close(0)
+	w.Close(0)
+}
+
+func TestElasticWriterFlushInterval(t *testing.T) {
+	flushed := make(chan []string, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+
+		indices, _ := bulkRequest(t, r)
+		flushed <- indices
+
+		_, _ = w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+	}))
+	defer srv.Close()
+
+	w := newElasticWriter(&WriterConfig{
+		Name: "TCP",
+		Type: types.Type_NC_TCP,
+		ElasticConfig: ElasticConfig{
+			ElasticAddrs:         []string{srv.URL},
+			BulkSize:             100,
+			ElasticFlushInterval: 10 * time.Millisecond,
+		},
+	})
+	defer w.Close(0)
+
+	if err := w.Write(&types.TCP{Timestamp: int64(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case indices := <-flushed:
+		if len(indices) != 1 || indices[0] != "netcap-tcp" {
+			t.Fatal("unexpected indices", indices)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("incomplete batch has not been flushed")
+	}
+}
+
+func TestElasticWriterCompression(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic when compression is enabled")
+		}
+	}()
+
+	newElasticWriter(&WriterConfig{
+		Name:     "TCP",
+		Type:     types.Type_NC_TCP,
+		Compress: true,
+	})
+}
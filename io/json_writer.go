@@ -15,19 +15,15 @@ package io
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"go.uber.org/zap"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sync"
 
-	"github.com/davecgh/go-spew/spew"
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
-	"github.com/klauspost/pgzip"
 
 	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/delimited"
@@ -38,7 +34,7 @@ import (
 type jsonWriter struct {
 	mu      sync.Mutex
 	bWriter *bufio.Writer
-	gWriter *pgzip.Writer
+	gWriter compressor
 	dWriter *delimited.Writer
 	jWriter *jsonProtoWriter
 
@@ -57,7 +53,7 @@ func newJSONWriter(wc *WriterConfig) *jsonWriter {
 
 	// create file
 	if wc.Compress {
-		w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".json.gz")
+		w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".json"+wc.compressionExtension())
 	} else {
 		w.file = createFile(filepath.Join(wc.Out, w.wc.Name), ".json")
 	}
@@ -67,11 +63,11 @@ func newJSONWriter(wc *WriterConfig) *jsonWriter {
 		w.bWriter = bufio.NewWriterSize(w.file, wc.MemBufferSize)
 
 		if wc.Compress {
-			var errGzipWriter error
-			w.gWriter, errGzipWriter = pgzip.NewWriterLevel(w.bWriter, wc.CompressionLevel)
+			var errCompressor error
+			w.gWriter, errCompressor = newCompressor(w.bWriter, wc)
 
-			if errGzipWriter != nil {
-				panic(errGzipWriter)
+			if errCompressor != nil {
+				panic(errCompressor)
 			}
 
 			w.jWriter = newJSONProtoWriter(w.gWriter)
@@ -80,10 +76,10 @@ func newJSONWriter(wc *WriterConfig) *jsonWriter {
 		}
 	} else {
 		if wc.Compress {
-			var errGzipWriter error
-			w.gWriter, errGzipWriter = pgzip.NewWriterLevel(w.file, wc.CompressionLevel)
-			if errGzipWriter != nil {
-				panic(errGzipWriter)
+			var errCompressor error
+			w.gWriter, errCompressor = newCompressor(w.file, wc)
+			if errCompressor != nil {
+				panic(errCompressor)
 			}
 			w.jWriter = newJSONProtoWriter(w.gWriter)
 		} else {
@@ -91,19 +87,10 @@ func newJSONWriter(wc *WriterConfig) *jsonWriter {
 		}
 	}
 
-	if w.gWriter != nil {
-		// To get any performance gains, you should at least be compressing more than 1 megabyte of data at the time.
-		// You should at least have a block size of 100k and at least a number of blocks that match the number of cores
-		// you would like to utilize, but about twice the number of blocks would be the best.
-		if err := w.gWriter.SetConcurrency(wc.CompressionBlockSize, runtime.GOMAXPROCS(0)*2); err != nil {
-			log.Fatal("failed to configure compression package: ", err)
-		}
-	}
-
 	return w
 }
 
-// WriteCSV writes a CSV record.
+// Write writes a JSON record, followed by a newline.
 func (w *jsonWriter) Write(msg proto.Message) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -113,7 +100,7 @@ func (w *jsonWriter) Write(msg proto.Message) error {
 	return err
 }
 
-// WriteHeader writes a CSV header.
+// WriteHeader writes the netcap header as the first JSON object of the file.
 func (w *jsonWriter) WriteHeader(t types.Type) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -133,61 +120,63 @@ func (w *jsonWriter) Close(numRecords int64) (name string, size int64) {
 	}
 
 	if w.wc.Compress {
-		closeGzipWriters(w.gWriter)
+		closeCompressors(w.gWriter)
 	}
 
 	return closeFile(w.wc.Out, w.file, w.wc.Name, numRecords)
 }
 
-// jsonProtoWriter implements writing audit records to disk in the JSON format.
+// jsonProtoWriter implements writing audit records to disk in the JSON lines format:
+// every record is serialized as a JSON object on a separate line.
 type jsonProtoWriter struct {
 	sync.Mutex
-	w io.Writer
+	w         io.Writer
+	marshaler *jsonpb.Marshaler
 }
 
 // newJSONProtoWriter returns a new JSON writer instance.
 func newJSONProtoWriter(w io.Writer) *jsonProtoWriter {
 	return &jsonProtoWriter{
-		w: w,
+		w:         w,
+		marshaler: &jsonpb.Marshaler{},
 	}
 }
 
-// writeHeader writes the CSV header to the underlying file.
+// writeHeader writes the netcap header to the underlying file.
 func (w *jsonProtoWriter) writeHeader(h *types.Header) (int, error) {
-	w.Lock()
-	defer w.Unlock()
-
-	marshaled, errMarshal := json.Marshal(h)
+	marshaled, errMarshal := w.marshaler.MarshalToString(h)
 	if errMarshal != nil {
 		return 0, fmt.Errorf("failed to marshal json: %w", errMarshal)
 	}
 
-	n, err := w.w.Write(marshaled)
-	if err != nil {
-		return n, err
-	}
-
-	return w.w.Write([]byte("\n"))
+	return w.writeLine(marshaled)
 }
 
 // writeRecord writes a protocol buffer into the JSON writer.
+// Audit records use their own JSON representation, with timestamps in millisecond precision for elastic.
 func (w *jsonProtoWriter) writeRecord(msg proto.Message) (int, error) {
+	var (
+		js  string
+		err error
+	)
 
 	if j, ok := msg.(types.AuditRecord); ok {
-		js, err := j.JSON()
-		if err != nil {
-			return 0, err
-		}
+		js, err = j.JSON()
+	} else {
+		js, err = w.marshaler.MarshalToString(msg)
+	}
 
-		out := []byte(js + "\n")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal json: %w", err)
+	}
 
-		w.Lock()
-		n, err := w.w.Write(out)
-		w.Unlock()
+	return w.writeLine(js)
+}
 
-		return n, err
-	}
+// writeLine writes the JSON object terminated by a newline.
+func (w *jsonProtoWriter) writeLine(js string) (int, error) {
+	w.Lock()
+	defer w.Unlock()
 
-	spew.Dump(msg)
-	panic("can not write as JSON")
+	return w.w.Write([]byte(js + "\n"))
 }
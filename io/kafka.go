@@ -0,0 +1,244 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// encodings for the values of the kafka messages.
+const (
+	KafkaEncodingJSON  = "json"
+	KafkaEncodingProto = "proto"
+)
+
+const (
+	// number of times a batch is sent, when the partition leaders changed or the topic is being created.
+	kafkaMaxAttempts = 6
+
+	// time the client waits for more messages of a partition before sending them.
+	// The writer hands over complete batches, so waiting longer would only delay them.
+	kafkaBatchTimeout = 10 * time.Millisecond
+)
+
+// errKafkaFailed indicates publishing records to kafka has failed.
+var errKafkaFailed = errors.New("failed to publish records to kafka")
+
+// KafkaConfig configures the brokers that receive the audit records.
+type KafkaConfig struct {
+	// KafkaBrokers are the addresses of the brokers used to discover the cluster
+	KafkaBrokers []string
+
+	// KafkaTopic is the template for the topic names, {type} is replaced with the record type
+	KafkaTopic string
+
+	// KafkaEncoding of the message values: json or proto, defaults to json
+	KafkaEncoding string
+
+	// KafkaAcks is the number of acknowledgements the partition leader waits for:
+	// 1 for the leader or -1 for all in-sync replicas, defaults to 1
+	KafkaAcks int
+
+	// KafkaBatchSize is the number of messages sent per produce request
+	KafkaBatchSize int
+
+	// KafkaFlushInterval sends incomplete batches periodically, 0 only sends full batches
+	KafkaFlushInterval time.Duration
+}
+
+// kafkaProducer sends messages to the partitions of a topic, it is implemented by *kafka.Writer.
+type kafkaProducer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// kafkaWriter publishes the audit records of one type to a kafka topic.
+type kafkaWriter struct {
+	batchFlusher
+
+	wc    *WriterConfig
+	topic string
+
+	// messages waiting to be sent
+	queue []kafka.Message
+
+	producer kafkaProducer
+}
+
+// newKafkaWriter initializes and configures a new kafkaWriter instance.
+func newKafkaWriter(wc *WriterConfig) *kafkaWriter {
+	if wc.Buffer || wc.Compress {
+		panic("buffering or compression cannot be activated when writing to kafka")
+	}
+
+	switch wc.KafkaEncoding {
+	case "":
+		wc.KafkaEncoding = KafkaEncodingJSON
+	case KafkaEncodingJSON, KafkaEncodingProto:
+	default:
+		panic("invalid kafka encoding: " + wc.KafkaEncoding)
+	}
+
+	// the client waits for a response to every produce request, so sending without acknowledgements is not supported
+	switch wc.KafkaAcks {
+	case 0:
+		wc.KafkaAcks = 1
+	case 1, -1:
+	default:
+		panic(fmt.Sprint("invalid number of kafka acknowledgements: ", wc.KafkaAcks))
+	}
+
+	if wc.KafkaBatchSize <= 0 {
+		wc.KafkaBatchSize = defaults.KafkaBatchSize
+	}
+
+	topic := wc.KafkaTopic
+	if topic == "" {
+		topic = defaults.KafkaTopic
+	}
+
+	w := &kafkaWriter{
+		wc:    wc,
+		topic: strings.ReplaceAll(topic, "{type}", elasticTypeName(wc.Name)),
+		queue: make([]kafka.Message, 0, wc.KafkaBatchSize),
+	}
+
+	ioLog.Info("create kafkaWriter",
+		zap.Strings("brokers", wc.KafkaBrokers),
+		zap.String("topic", w.topic),
+		zap.String("type", wc.Type.String()),
+	)
+
+	// records with the same key are sent to the same partition, like with the default partitioner of the java client
+	w.producer = kafka.NewWriter(kafka.WriterConfig{
+		Brokers:       wc.KafkaBrokers,
+		Topic:         w.topic,
+		Balancer:      kafka.Murmur2Balancer{},
+		MaxAttempts:   kafkaMaxAttempts,
+		QueueCapacity: wc.KafkaBatchSize,
+		BatchSize:     wc.KafkaBatchSize,
+		BatchTimeout:  kafkaBatchTimeout,
+		RequiredAcks:  wc.KafkaAcks,
+		ErrorLogger:   kafka.LoggerFunc(ioLog.Sugar().Warnf),
+	})
+
+	w.flushPeriodically(wc.KafkaFlushInterval, w.flush, "failed to publish audit records to kafka", zap.String("topic", w.topic))
+
+	return w
+}
+
+// Write queues a record and publishes the queue once the batch size has been reached.
+// The source address of the record is used as message key, so that records of a host stay in order.
+func (w *kafkaWriter) Write(msg proto.Message) error {
+	var (
+		m = kafka.Message{
+			Time: time.Now(),
+		}
+		err error
+	)
+
+	rec, isRecord := msg.(types.AuditRecord)
+	if isRecord {
+		if src := rec.Src(); src != "" {
+			m.Key = []byte(src)
+		}
+
+		// read the timestamp before encoding, since JSON converts it to milliseconds
+		if ts := rec.Time(); ts > 0 {
+			m.Time = time.Unix(0, ts)
+		}
+	}
+
+	switch {
+	case w.wc.KafkaEncoding == KafkaEncodingProto:
+		m.Value, err = proto.Marshal(msg)
+	case isRecord:
+		var js string
+		js, err = rec.JSON()
+		m.Value = []byte(js)
+	default:
+		return fmt.Errorf("%s: %w", msg, errMissingAuditRecordInterface)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal record for kafka: %w", err)
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	// surface errors of the periodic flush
+	if err = w.flushError(); err != nil {
+		return err
+	}
+
+	w.queue = append(w.queue, m)
+
+	if len(w.queue) >= w.wc.KafkaBatchSize {
+		return w.flush()
+	}
+
+	return nil
+}
+
+// WriteHeader is a no-op, the topic only receives audit records.
+func (w *kafkaWriter) WriteHeader(_ types.Type) error {
+	return nil
+}
+
+// Close publishes the outstanding messages and closes the connections to the brokers.
+func (w *kafkaWriter) Close(_ int64) (name string, size int64) {
+	w.stopFlushing()
+
+	w.Lock()
+	defer w.Unlock()
+
+	if err := w.flush(); err != nil {
+		ioLog.Error("failed to publish remaining audit records to kafka", zap.Error(err), zap.String("topic", w.topic))
+	}
+
+	if err := w.producer.Close(); err != nil {
+		ioLog.Error("failed to close kafka writer", zap.Error(err), zap.String("topic", w.topic))
+	}
+
+	return w.wc.Name, 0
+}
+
+// flush publishes all queued messages, the lock must be held by the caller.
+// The queue is emptied even if publishing fails, so an unavailable cluster does not exhaust the memory.
+func (w *kafkaWriter) flush() error {
+	if len(w.queue) == 0 {
+		return nil
+	}
+
+	msgs := w.queue
+	w.queue = make([]kafka.Message, 0, w.wc.KafkaBatchSize)
+
+	if err := w.producer.WriteMessages(context.Background(), msgs...); err != nil {
+		return fmt.Errorf("%w: %s", errKafkaFailed, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// fakeKafkaProducer records the produced messages, the first call to WriteMessages fails with the given error.
+type fakeKafkaProducer struct {
+	mu sync.Mutex
+
+	firstError error
+	produced   []kafka.Message
+	requests   int
+	closed     bool
+}
+
+func (p *fakeKafkaProducer) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests++
+
+	if p.requests == 1 && p.firstError != nil {
+		return p.firstError
+	}
+
+	p.produced = append(p.produced, msgs...)
+
+	return nil
+}
+
+func (p *fakeKafkaProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+
+	return nil
+}
+
+func (p *fakeKafkaProducer) messages() []kafka.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]kafka.Message(nil), p.produced...)
+}
+
+// newTestKafkaWriter creates a writer that publishes to the fake producer.
+func newTestKafkaWriter(kc KafkaConfig, p *fakeKafkaProducer) *kafkaWriter {
+	kc.KafkaBrokers = []string{"127.0.0.1:9092"}
+
+	w := newKafkaWriter(&WriterConfig{
+		Name:        "TCP",
+		Type:        types.Type_NC_TCP,
+		KafkaConfig: kc,
+	})
+
+	// replace the client before anything has been sent
+	_ = w.producer.Close()
+	w.producer = p
+
+	return w
+}
+
+func TestKafkaWriter(t *testing.T) {
+	p := &fakeKafkaProducer{}
+	w := newTestKafkaWriter(KafkaConfig{KafkaBatchSize: 2}, p)
+
+	if w.topic != "netcap-tcp" {
+		t.Fatal("unexpected topic", w.topic)
+	}
+
+	for _, src := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"} {
+		if err := w.Write(&types.TCP{Timestamp: int64(time.Second), SrcIP: src, DstPort: 443}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the last record is sent on close
+	if n := len(p.messages()); n != 2 {
+		t.Fatal("expected 2 messages before close, got", n)
+	}
+
+	w.Close(0)
+
+	msgs := p.messages()
+	if len(msgs) != 3 {
+		t.Fatal("expected 3 messages, got", len(msgs))
+	}
+
+	for _, m := range msgs {
+		if !strings.Contains(string(m.Value), `"SrcIP":"`+string(m.Key)+`"`) || !strings.Contains(string(m.Value), `"DstPort":443`) {
+			t.Errorf("unexpected message for key %s: %s", m.Key, m.Value)
+		}
+
+		if !m.Time.Equal(time.Unix(1, 0)) {
+			t.Error("expected the record timestamp as message time, got", m.Time)
+		}
+	}
+
+	if !p.closed {
+		t.Fatal("producer has not been closed")
+	}
+}
+
+func TestKafkaWriterProto(t *testing.T) {
+	p := &fakeKafkaProducer{}
+	w := newTestKafkaWriter(KafkaConfig{
+		KafkaTopic:         "flows.{type}",
+		KafkaEncoding:      KafkaEncodingProto,
+		KafkaFlushInterval: 10 * time.Millisecond,
+	}, p)
+	defer w.Close(0)
+
+	if w.topic != "flows.tcp" {
+		t.Fatal("unexpected topic", w.topic)
+	}
+
+	if err := w.Write(&types.TCP{Timestamp: int64(time.Second), SrcIP: "10.0.0.1", SrcPort: 4242}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(p.messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	msgs := p.messages()
+	if len(msgs) != 1 {
+		t.Fatal("expected the incomplete batch to be flushed, got", len(msgs), "messages")
+	}
+
+	var rec types.TCP
+	if err := proto.Unmarshal(msgs[0].Value, &rec); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.SrcPort != 4242 || rec.Timestamp != int64(time.Second) {
+		t.Fatal("unexpected record", rec.String())
+	}
+}
+
+func TestKafkaWriterFlushError(t *testing.T) {
+	p := &fakeKafkaProducer{firstError: kafka.LeaderNotAvailable}
+	w := newTestKafkaWriter(KafkaConfig{KafkaFlushInterval: 10 * time.Millisecond}, p)
+	defer w.Close(0)
+
+	if err := w.Write(&types.TCP{Timestamp: int64(time.Second), SrcIP: "10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the error of the periodic flush is returned by the next write
+	var err error
+
+	deadline := time.Now().Add(5 * time.Second)
+	for err == nil && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+
+		err = w.Write(&types.TCP{Timestamp: int64(time.Second), SrcIP: "10.0.0.1"})
+	}
+
+	if !errors.Is(err, errKafkaFailed) {
+		t.Fatal("expected the flush error, got", err)
+	}
+
+	// the error is only reported once
+	if err = w.Write(&types.TCP{Timestamp: int64(time.Second), SrcIP: "10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// ManifestFile is the name of the index of all audit record files, that is written into the output directory on shutdown.
+const ManifestFile = "netcap.manifest.json"
+
+// Manifest lists the audit record files produced by a run,
+// so tooling can discover a dataset without opening every file.
+type Manifest struct {
+	Source    string
+	Generated time.Time
+	Files     []*ManifestEntry
+}
+
+// ManifestEntry describes a single audit record file.
+// If the file has been rotated, File is the name of the first part, Size the total size of all parts
+// and each part is listed in Parts.
+type ManifestEntry struct {
+	File    string
+	Type    string
+	Records int64
+	First   time.Time
+	Last    time.Time
+	Size    int64
+	Parts   []*ManifestPart `json:",omitempty"`
+}
+
+// ManifestPart describes a single part of a rotated audit record file.
+type ManifestPart struct {
+	File    string
+	Records int64
+	Size    int64
+}
+
+// rotatingWriter is implemented by writers that split the records into several files.
+type rotatingWriter interface {
+	// Parts returns the files written so far, or nil if the output has not been rotated.
+	Parts() []*ManifestPart
+}
+
+var (
+	manifestEntries   []*ManifestEntry
+	manifestEntriesMu sync.Mutex
+
+	// encoded size of all written records, only tracked after CountRecordBytes has been called
+	countRecordBytes   int32
+	recordBytesWritten int64
+)
+
+// CountRecordBytes enables tracking the encoded size of all audit records passed to the writers.
+// This is disabled by default, since it requires to compute the size of every record.
+func CountRecordBytes() {
+	atomic.StoreInt32(&countRecordBytes, 1)
+}
+
+// RecordBytesWritten returns the encoded size of all audit records written since CountRecordBytes has been called.
+func RecordBytesWritten() int64 {
+	return atomic.LoadInt64(&recordBytesWritten)
+}
+
+// manifestWriter passes audit records on to the wrapped writer
+// and tracks the time range of the written records for the manifest.
+type manifestWriter struct {
+	AuditRecordWriter
+
+	// output directory for the manifest and the directory the file is written to
+	out string
+	dir string
+
+	typ types.Type
+
+	// timestamps of the first and the last record in nanoseconds, updated atomically
+	first int64
+	last  int64
+}
+
+// Write updates the time range and writes the record with the wrapped writer.
+func (w *manifestWriter) Write(msg proto.Message) error {
+	// the timestamp must be read before writing, since the JSON encoding modifies it
+	if r, ok := msg.(types.AuditRecord); ok {
+		w.update(r.Time())
+	}
+
+	if atomic.LoadInt32(&countRecordBytes) == 1 {
+		atomic.AddInt64(&recordBytesWritten, int64(proto.Size(msg)))
+	}
+
+	return w.AuditRecordWriter.Write(msg)
+}
+
+func (w *manifestWriter) update(t int64) {
+	if t == 0 {
+		return
+	}
+
+	for {
+		first := atomic.LoadInt64(&w.first)
+		if (first != 0 && first <= t) || atomic.CompareAndSwapInt64(&w.first, first, t) {
+			break
+		}
+	}
+
+	for {
+		last := atomic.LoadInt64(&w.last)
+		if last >= t || atomic.CompareAndSwapInt64(&w.last, last, t) {
+			break
+		}
+	}
+}
+
+// Close closes the wrapped writer and adds the file to the manifest, if the writer produced a file.
+func (w *manifestWriter) Close(numRecords int64) (name string, size int64) {
+	name, size = w.AuditRecordWriter.Close(numRecords)
+	if name == "" || size == 0 {
+		return name, size
+	}
+
+	e := &ManifestEntry{
+		File:    w.path(name),
+		Type:    strings.TrimPrefix(w.typ.String(), defaults.NetcapTypePrefix),
+		Records: numRecords,
+		Size:    size,
+	}
+
+	if rw, ok := w.AuditRecordWriter.(rotatingWriter); ok {
+		for _, p := range rw.Parts() {
+			e.Parts = append(e.Parts, &ManifestPart{
+				File:    w.path(p.File),
+				Records: p.Records,
+				Size:    p.Size,
+			})
+		}
+	}
+
+	if first := atomic.LoadInt64(&w.first); first != 0 {
+		e.First = time.Unix(0, first).UTC()
+		e.Last = time.Unix(0, atomic.LoadInt64(&w.last)).UTC()
+	}
+
+	manifestEntriesMu.Lock()
+	manifestEntries = append(manifestEntries, e)
+	manifestEntriesMu.Unlock()
+
+	return name, size
+}
+
+// path returns the path of a file written by the wrapped writer, relative to the output directory if possible.
+func (w *manifestWriter) path(name string) string {
+	file := filepath.Join(w.dir, name)
+	if rel, err := filepath.Rel(w.out, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+
+	return file
+}
+
+// GetChan returns the channel of the wrapped writer, if it is a channel writer.
+func (w *manifestWriter) GetChan() <-chan []byte {
+	if cw, ok := w.AuditRecordWriter.(ChannelAuditRecordWriter); ok {
+		return cw.GetChan()
+	}
+
+	return nil
+}
+
+// WriteManifest writes the manifest for the audit record files that have been closed so far into the output directory,
+// and resets the collected entries. Nothing is written if no files have been produced.
+func WriteManifest(out string, source string) error {
+	manifestEntriesMu.Lock()
+	entries := manifestEntries
+	manifestEntries = nil
+	manifestEntriesMu.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].File < entries[j].File
+	})
+
+	data, err := json.MarshalIndent(&Manifest{
+		Source:    source,
+		Generated: time.Now().UTC(),
+		Files:     entries,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(out, ManifestFile), append(data, '\n'), defaults.FilePermission)
+}
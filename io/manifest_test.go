@@ -0,0 +1,195 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestWriteManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcap-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifestEntriesMu.Lock()
+	manifestEntries = nil
+	manifestEntriesMu.Unlock()
+
+	w := NewAuditRecordWriter(&WriterConfig{
+		Proto: true,
+		Name:  "TCP",
+		Type:  types.Type_NC_TCP,
+		Out:   dir,
+	})
+
+	if err = w.WriteHeader(types.Type_NC_TCP); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ts := range []int64{20, 10, 30} {
+		if err = w.Write(&types.TCP{Timestamp: ts}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name, size := w.Close(3)
+
+	// an empty file is removed and must not be listed
+	empty := NewAuditRecordWriter(&WriterConfig{
+		Proto: true,
+		Name:  "UDP",
+		Type:  types.Type_NC_UDP,
+		Out:   dir,
+	})
+	if err = empty.WriteHeader(types.Type_NC_UDP); err != nil {
+		t.Fatal(err)
+	}
+
+	empty.Close(0)
+
+	if err = WriteManifest(dir, "test.pcap"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m Manifest
+	if err = json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Source != "test.pcap" || len(m.Files) != 1 {
+		t.Fatal("unexpected manifest", string(data))
+	}
+
+	e := m.Files[0]
+	if e.File != name || e.Type != "TCP" || e.Records != 3 || e.Size != size {
+		t.Fatal("unexpected entry", e)
+	}
+
+	if !e.First.Equal(time.Unix(0, 10)) || !e.Last.Equal(time.Unix(0, 30)) {
+		t.Fatal("unexpected time range", e.First, e.Last)
+	}
+
+	// the entries are reset after writing
+	if err = os.Remove(filepath.Join(dir, ManifestFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = WriteManifest(dir, "test.pcap"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, ManifestFile)); !os.IsNotExist(err) {
+		t.Fatal("expected no manifest without files", err)
+	}
+}
+
+func TestWriteManifestRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcap-manifest-rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifestEntriesMu.Lock()
+	manifestEntries = nil
+	manifestEntriesMu.Unlock()
+
+	// rotate after every record
+	w := NewAuditRecordWriter(&WriterConfig{
+		Proto:       true,
+		Name:        "TCP",
+		Type:        types.Type_NC_TCP,
+		Out:         dir,
+		MaxFileSize: 1,
+	})
+
+	if err = w.WriteHeader(types.Type_NC_TCP); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ts := range []int64{10, 20, 30} {
+		if err = w.Write(&types.TCP{Timestamp: ts}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name, size := w.Close(3)
+
+	if err = WriteManifest(dir, "test.pcap"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m Manifest
+	if err = json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Files) != 1 {
+		t.Fatal("unexpected manifest", string(data))
+	}
+
+	e := m.Files[0]
+	if e.File != name || e.Records != 3 || e.Size != size {
+		t.Fatal("unexpected entry", e)
+	}
+
+	// the final part does only contain the header and is removed
+	expected := []string{"TCP", "TCP.part1", "TCP.part2"}
+	if len(e.Parts) != len(expected) {
+		t.Fatal("expected", len(expected), "parts, got", string(data))
+	}
+
+	var total int64
+
+	for i, p := range e.Parts {
+		if p.File != expected[i]+defaults.FileExtension || p.Records != 1 {
+			t.Fatal("unexpected part", p)
+		}
+
+		info, errStat := os.Stat(filepath.Join(dir, p.File))
+		if errStat != nil {
+			t.Fatal(errStat)
+		}
+
+		if info.Size() != p.Size {
+			t.Fatal("expected size", info.Size(), "for", p.File, "got", p.Size)
+		}
+
+		total += p.Size
+	}
+
+	if total != e.Size {
+		t.Fatal("expected the total size of all parts", total, "got", e.Size)
+	}
+}
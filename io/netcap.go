@@ -171,6 +171,52 @@ func InitRecord(typ types.Type) (record proto.Message) {
 		record = new(types.Mail)
 	case types.Type_NC_Alert:
 		record = new(types.Alert)
+	case types.Type_NC_CertAnomaly:
+		record = new(types.CertAnomaly)
+	case types.Type_NC_Memcached:
+		record = new(types.Memcached)
+	case types.Type_NC_BitTorrent:
+		record = new(types.BitTorrent)
+	case types.Type_NC_WireGuard:
+		record = new(types.WireGuard)
+	case types.Type_NC_VolumeAnomaly:
+		record = new(types.VolumeAnomaly)
+	case types.Type_NC_SecretLeak:
+		record = new(types.SecretLeak)
+	case types.Type_NC_TNS:
+		record = new(types.TNS)
+	case types.Type_NC_FTP:
+		record = new(types.FTP)
+	case types.Type_NC_Redis:
+		record = new(types.Redis)
+	case types.Type_NC_TLSServerCertificate:
+		record = new(types.TLSServerCertificate)
+	case types.Type_NC_WebSocketFrame:
+		record = new(types.WebSocketFrame)
+	case types.Type_NC_IMAP:
+		record = new(types.IMAP)
+	case types.Type_NC_Telnet:
+		record = new(types.Telnet)
+	case types.Type_NC_SMB:
+		record = new(types.SMB)
+	case types.Type_NC_Tunnel:
+		record = new(types.Tunnel)
+	case types.Type_NC_MySQLQuery:
+		record = new(types.MySQLQuery)
+	case types.Type_NC_SOCKS:
+		record = new(types.SOCKS)
+	case types.Type_NC_LDAP:
+		record = new(types.LDAP)
+	case types.Type_NC_QUIC:
+		record = new(types.QUIC)
+	case types.Type_NC_NTLM:
+		record = new(types.NTLM)
+	case types.Type_NC_MongoDB:
+		record = new(types.MongoDB)
+	case types.Type_NC_PostgresQuery:
+		record = new(types.PostgresQuery)
+	case types.Type_NC_AMQP:
+		record = new(types.AMQP)
 	default:
 		panic("InitRecord: unknown type: " + typ.String())
 	}
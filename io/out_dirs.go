@@ -0,0 +1,69 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errInvalidOutDir occurs when a mapping of an audit record type to an output directory is malformed.
+var errInvalidOutDir = errors.New("invalid output directory for audit record type")
+
+// ParseOutDirs parses a comma separated list of audit record types and the directory
+// their audit record files shall be written to, e.g: HTTP=/mnt/fast,Connection=/mnt/share.
+// Audit record types that are not listed are written into the default output directory.
+func ParseOutDirs(s string) (map[string]string, error) {
+	dirs := make(map[string]string)
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%w: %s", errInvalidOutDir, entry)
+		}
+
+		var (
+			name = strings.TrimSpace(parts[0])
+			dir  = strings.TrimSpace(parts[1])
+		)
+
+		if name == "" || dir == "" {
+			return nil, fmt.Errorf("%w: %s", errInvalidOutDir, entry)
+		}
+
+		dirs[name] = dir
+	}
+
+	return dirs, nil
+}
+
+// resolveOutDir returns a copy of the writer config with the output directory
+// that has been configured for the audit record type, or the config itself if there is none.
+func resolveOutDir(wc *WriterConfig) *WriterConfig {
+	dir, ok := wc.OutDirs[wc.Name]
+	if !ok {
+		return wc
+	}
+
+	c := *wc
+	c.Out = dir
+
+	return &c
+}
@@ -0,0 +1,90 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestParseOutDirs(t *testing.T) {
+	dirs, err := ParseOutDirs(" HTTP=/mnt/fast, Connection = /mnt/share ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"HTTP":       "/mnt/fast",
+		"Connection": "/mnt/share",
+	}
+
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Fatal("unexpected output directories", dirs)
+	}
+
+	for _, s := range []string{"HTTP", "HTTP=", "=/mnt/fast"} {
+		if _, err = ParseOutDirs(s); !errors.Is(err, errInvalidOutDir) {
+			t.Errorf("%q: expected errInvalidOutDir, got %v", s, err)
+		}
+	}
+}
+
+func TestWriterOutDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcap-out-dirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	custom := filepath.Join(dir, "custom")
+	if err = os.Mkdir(custom, defaults.DirectoryPermission); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"TCP", "UDP"} {
+		w := NewAuditRecordWriter(&WriterConfig{
+			Proto:   true,
+			Name:    name,
+			Type:    types.Type_NC_TCP,
+			Out:     dir,
+			OutDirs: map[string]string{"UDP": custom},
+		})
+
+		if err = w.WriteHeader(types.Type_NC_TCP); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = w.Write(&types.TCP{SrcPort: 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		w.Close(1)
+	}
+
+	for _, path := range []string{
+		filepath.Join(dir, "TCP"+defaults.FileExtension),
+		filepath.Join(custom, "UDP"+defaults.FileExtension),
+	} {
+		if _, err = os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+}
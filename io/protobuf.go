@@ -15,15 +15,14 @@ package io
 
 import (
 	"bufio"
+	"fmt"
 	"go.uber.org/zap"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sync"
 
 	"github.com/gogo/protobuf/proto"
-	"github.com/klauspost/pgzip"
 
 	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/delimited"
@@ -35,12 +34,34 @@ type protoWriter struct {
 	mu sync.Mutex
 
 	bWriter *bufio.Writer
-	gWriter *pgzip.Writer
+	gWriter compressor
 	dWriter *delimited.Writer
 	pWriter *delimitedProtoWriter
 
 	file *os.File
 	wc   *WriterConfig
+
+	// size rotation
+	cWriter     *countingWriter
+	typ         types.Type
+	part        int
+	partRecords int64
+	firstName   string
+	rotatedSize int64
+	parts       []*ManifestPart
+}
+
+// countingWriter counts the bytes that have been written to the underlying file.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
 }
 
 // newProtoWriter initializes and configures a new protoWriter instance.
@@ -52,56 +73,98 @@ func newProtoWriter(wc *WriterConfig) *protoWriter {
 		wc.MemBufferSize = defaults.BufferSize
 	}
 
+	w.open(wc.Name)
+
+	return w
+}
+
+// open creates the audit record file with the given name and sets up the writers.
+func (w *protoWriter) open(name string) {
+	wc := w.wc
+
 	if wc.Compress {
-		w.file = createFile(filepath.Join(wc.Out, wc.Name), defaults.FileExtensionCompressed)
+		w.file = createFile(filepath.Join(wc.Out, name), defaults.FileExtension+wc.compressionExtension())
 	} else {
-		w.file = createFile(filepath.Join(wc.Out, wc.Name), defaults.FileExtension)
+		w.file = createFile(filepath.Join(wc.Out, name), defaults.FileExtension)
 	}
-	ioLog.Info("create protoWriter", zap.String("base", filepath.Join(wc.Out, wc.Name)), zap.String("type", wc.Type.String()))
+	ioLog.Info("create protoWriter", zap.String("base", filepath.Join(wc.Out, name)), zap.String("type", wc.Type.String()))
+
+	w.cWriter = &countingWriter{w: w.file}
+	w.bWriter, w.gWriter = nil, nil
 
 	// buffer data?
 	if wc.Buffer {
 		if wc.Compress {
 			// experiment: pgzip -> file
-			var errGzipWriter error
-			w.gWriter, errGzipWriter = pgzip.NewWriterLevel(w.file, wc.CompressionLevel)
+			var errCompressor error
+			w.gWriter, errCompressor = newCompressor(w.cWriter, wc)
 
-			if errGzipWriter != nil {
-				panic(errGzipWriter)
+			if errCompressor != nil {
+				panic(errCompressor)
 			}
 			// experiment: buffer -> pgzip
 			w.bWriter = bufio.NewWriterSize(w.gWriter, wc.MemBufferSize)
 			// experiment: delimited -> buffer
 			w.dWriter = delimited.NewWriter(w.bWriter)
 		} else {
-			w.bWriter = bufio.NewWriterSize(w.file, wc.MemBufferSize)
+			w.bWriter = bufio.NewWriterSize(w.cWriter, wc.MemBufferSize)
 			w.dWriter = delimited.NewWriter(w.bWriter)
 		}
 	} else {
 		if w.wc.Compress {
-			var errGzipWriter error
-			w.gWriter, errGzipWriter = pgzip.NewWriterLevel(w.file, wc.CompressionLevel)
-			if errGzipWriter != nil {
-				panic(errGzipWriter)
+			var errCompressor error
+			w.gWriter, errCompressor = newCompressor(w.cWriter, wc)
+			if errCompressor != nil {
+				panic(errCompressor)
 			}
 			w.dWriter = delimited.NewWriter(w.gWriter)
 		} else {
-			w.dWriter = delimited.NewWriter(w.file)
+			w.dWriter = delimited.NewWriter(w.cWriter)
 		}
 	}
 
 	w.pWriter = newDelimitedProtoWriter(w.dWriter)
 
-	if w.gWriter != nil {
-		// To get any performance gains, you should at least be compressing more than 1 megabyte of data at the time.
-		// You should at least have a block size of 100k and at least a number of blocks that match the number of cores
-		// you would like to utilize, but about twice the number of blocks would be the best.
-		if err := w.gWriter.SetConcurrency(wc.CompressionBlockSize, runtime.GOMAXPROCS(0)*2); err != nil {
-			log.Fatal("failed to configure compression package: ", err)
-		}
+}
+
+// closePart flushes the writers and closes the file of the current part.
+func (w *protoWriter) closePart(numRecords int64) (name string, size int64) {
+	if w.wc.Buffer {
+		flushWriters(w.bWriter)
 	}
 
-	return w
+	if w.wc.Compress {
+		closeCompressors(w.gWriter)
+	}
+
+	return closeFile(w.wc.Out, w.file, w.wc.Name, numRecords)
+}
+
+// rotate finalizes the current file once it exceeds the configured size,
+// and continues writing into a new part that starts with the netcap header.
+func (w *protoWriter) rotate() error {
+	name, size := w.closePart(w.partRecords)
+	if w.part == 0 {
+		w.firstName = name
+	}
+
+	w.parts = append(w.parts, &ManifestPart{
+		File:    name,
+		Records: w.partRecords,
+		Size:    size,
+	})
+
+	w.rotatedSize += size
+	w.part++
+	w.partRecords = 0
+
+	w.open(fmt.Sprintf("%s.part%d", w.wc.Name, w.part))
+
+	return w.pWriter.putProto(w.header())
+}
+
+func (w *protoWriter) header() *types.Header {
+	return NewHeader(w.typ, w.wc.Source, w.wc.Version, w.wc.IncludesPayloads, w.wc.StartTime)
 }
 
 // WriteProto writes a protobuf message.
@@ -109,26 +172,58 @@ func (w *protoWriter) Write(msg proto.Message) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	return w.pWriter.putProto(msg)
+	err := w.pWriter.putProto(msg)
+	if err != nil {
+		return err
+	}
+
+	w.partRecords++
+
+	// the size of the file only grows when the buffered data has been flushed
+	if w.wc.MaxFileSize > 0 && w.cWriter.n >= w.wc.MaxFileSize {
+		return w.rotate()
+	}
+
+	return nil
 }
 
 // WriteHeader writes a netcap file header for protobuf encoded audit record files.
 func (w *protoWriter) WriteHeader(t types.Type) error {
-	return w.pWriter.putProto(NewHeader(t, w.wc.Source, w.wc.Version, w.wc.IncludesPayloads, w.wc.StartTime))
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.typ = t
+
+	return w.pWriter.putProto(w.header())
 }
 
 // Close flushes and closes the writer and the associated file handles.
+// If the file has been rotated, the name of the first part and the total size of all parts is returned.
 func (w *protoWriter) Close(numRecords int64) (name string, size int64) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if w.wc.Buffer {
-		flushWriters(w.bWriter)
+	if w.part == 0 {
+		return w.closePart(numRecords)
 	}
 
-	if w.wc.Compress {
-		closeGzipWriters(w.gWriter)
+	// the final part is removed if it does only contain the header
+	name, size = w.closePart(w.partRecords)
+	if size > 0 {
+		w.parts = append(w.parts, &ManifestPart{
+			File:    name,
+			Records: w.partRecords,
+			Size:    size,
+		})
 	}
 
-	return closeFile(w.wc.Out, w.file, w.wc.Name, numRecords)
+	return w.firstName, w.rotatedSize + size
+}
+
+// Parts returns the files written so far, or nil if the output has not been rotated.
+func (w *protoWriter) Parts() []*ManifestPart {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.parts
 }
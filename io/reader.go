@@ -15,12 +15,15 @@ package io
 
 import (
 	"bufio"
+	"bytes"
 	"compress/gzip"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/go-errors/errors"
 	"github.com/gogo/protobuf/proto"
+	"github.com/klauspost/compress/zstd"
 
 	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/delimited"
@@ -29,40 +32,145 @@ import (
 
 // Reader implements reading netcap audit record files.
 type Reader struct {
+	name    string
 	file    *os.File
+	size    int64
+	counter *countingReader
+	bundle  io.Closer
 	bReader *bufio.Reader
 	gReader *gzip.Reader
+	zReader *zstd.Decoder
 	dReader *delimited.Reader
 }
 
+// magic numbers of the compression formats, used to detect the framing of streams.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
 // Open a netcap audit record file for reading.
+// Files inside of a tar or zip bundle can be read without extracting them,
+// by appending the name of the member to the path of the archive: bundle.tar.gz#HTTP.ncap.gz.
+// If file is "-", the audit records are read from stdin.
 func Open(file string, memBufSize int) (*Reader, error) {
-	r := &Reader{}
-
-	h, err := os.Open(file)
-	if err != nil {
-		return nil, err
+	if file == "-" {
+		return OpenReader(os.Stdin)
 	}
 
+	var (
+		r   = &Reader{name: file}
+		src io.Reader
+	)
+
 	if memBufSize <= 0 {
 		memBufSize = defaults.BufferSize
 	}
 
-	r.file = h
-	r.bReader = bufio.NewReaderSize(h, memBufSize)
+	if archive, member, ok := SplitBundlePath(file); ok {
+		m, errBundle := openBundleMember(archive, member)
+		if errBundle != nil {
+			return nil, errBundle
+		}
+
+		r.bundle = m
+		src = m
+
+		// the member name determines whether the contents are compressed
+		file = member
+	} else {
+		h, errOpen := os.Open(file)
+		if errOpen != nil {
+			return nil, errOpen
+		}
+
+		if info, errStat := h.Stat(); errStat == nil {
+			r.size = info.Size()
+		}
+
+		r.file = h
+		src = h
+	}
+
+	r.counter = &countingReader{Reader: src}
+	r.bReader = bufio.NewReaderSize(r.counter, memBufSize)
+
+	var c Compression
+
+	switch filepath.Ext(file) {
+	case ".gz":
+		c = CompressionGzip
+	case ".zst":
+		c = CompressionZstd
+	default:
+		c = CompressionNone
+	}
+
+	if err := r.initDecoder(c); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// OpenReader reads netcap audit records from an arbitrary stream, e.g. stdin or an object storage download.
+// gzip and zstd compression is detected from the magic number at the start of the stream.
+// Closing the returned reader does not close src.
+func OpenReader(src io.Reader) (*Reader, error) {
+	r := &Reader{
+		name:    "stream",
+		counter: &countingReader{Reader: src},
+	}
+
+	r.bReader = bufio.NewReaderSize(r.counter, defaults.BufferSize)
+
+	// peek returns fewer bytes and an error for short streams, which is detected when reading the header
+	magic, _ := r.bReader.Peek(len(zstdMagic))
+
+	c := CompressionNone
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		c = CompressionGzip
+	case bytes.HasPrefix(magic, zstdMagic):
+		c = CompressionZstd
+	}
+
+	if err := r.initDecoder(c); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// initDecoder stacks the decompression and the delimited reader on top of the buffered reader.
+func (r *Reader) initDecoder(c Compression) error {
+	var err error
 
-	if filepath.Ext(file) == ".gz" {
+	switch c {
+	case CompressionGzip:
 		r.gReader, err = gzip.NewReader(r.bReader)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
+		// files that have been appended to or rotated can contain multiple gzip members,
+		// make sure all of them are read and not only the first one.
+		r.gReader.Multistream(true)
+
 		r.dReader = delimited.NewReader(r.gReader)
-	} else {
+	case CompressionZstd:
+		r.zReader, err = zstd.NewReader(r.bReader)
+		if err != nil {
+			return err
+		}
+
+		r.dReader = delimited.NewReader(r.zReader)
+	default:
 		r.dReader = delimited.NewReader(r.bReader)
 	}
 
-	return r, nil
+	return nil
 }
 
 // Close the file.
@@ -74,6 +182,19 @@ func (r *Reader) Close() error {
 		}
 	}
 
+	if r.zReader != nil {
+		r.zReader.Close()
+	}
+
+	if r.bundle != nil {
+		return r.bundle.Close()
+	}
+
+	// streams are owned by the caller
+	if r.file == nil {
+		return nil
+	}
+
 	err := r.file.Sync()
 	if err != nil {
 		return err
@@ -96,8 +217,33 @@ func (r *Reader) ReadHeader() (*types.Header, error) {
 	)
 
 	if err != nil {
-		return nil, errors.New("invalid netcap header in file: " + r.file.Name() + ", error: " + err.Error())
+		return nil, errors.New("invalid netcap header in file: " + r.name + ", error: " + err.Error())
 	}
 
 	return header, nil
 }
+
+// Size returns the size of the file in bytes.
+// Zero is returned if the size is unknown, e.g. for streams and members of bundles.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// BytesRead returns the number of bytes that have been read from the file so far, before decompressing them.
+// Due to buffering, the reader can be ahead of the audit records that have been returned.
+func (r *Reader) BytesRead() int64 {
+	return r.counter.n
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
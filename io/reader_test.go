@@ -14,11 +14,19 @@
 package io
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"io"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/dreadl0ck/netcap/defaults"
+	"github.com/dreadl0ck/netcap/delimited"
 	"github.com/dreadl0ck/netcap/types"
 )
 
@@ -56,3 +64,162 @@ func TestReader(t *testing.T) {
 		t.Fatal("expected 3196 audit records, got: ", count)
 	}
 }
+
+func TestReaderMultipleGzipMembers(t *testing.T) {
+	f, err := ioutil.TempFile("", "TCP-*.ncap.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(f.Name())
+
+	// write the header and two records into the first gzip member,
+	// and another two records into a second member that is appended to the file
+	for member, records := range [][]proto.Message{
+		{&types.Header{Type: types.Type_NC_TCP}, &types.TCP{SrcPort: 1}, &types.TCP{SrcPort: 2}},
+		{&types.TCP{SrcPort: 3}, &types.TCP{SrcPort: 4}},
+	} {
+		gw := gzip.NewWriter(f)
+		dw := delimited.NewWriter(gw)
+
+		for _, record := range records {
+			if err = dw.PutProto(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err = gw.Close(); err != nil {
+			t.Fatal("failed to close member", member, err)
+		}
+	}
+
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(f.Name(), defaults.BufferSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, errHeader := r.ReadHeader()
+	if errHeader != nil {
+		t.Fatal(errHeader)
+	}
+
+	if header.Type != types.Type_NC_TCP {
+		t.Fatal("not TCP, got: ", header.Type)
+	}
+
+	var (
+		tcp   = new(types.TCP)
+		count int
+	)
+
+	for {
+		err = r.Next(tcp)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		count++
+
+		if tcp.SrcPort != int32(count) {
+			t.Fatal("expected source port", count, "got:", tcp.SrcPort)
+		}
+	}
+
+	if count != 4 {
+		t.Fatal("expected 4 audit records, got: ", count)
+	}
+}
+
+func TestOpenReader(t *testing.T) {
+	var plain bytes.Buffer
+
+	dw := delimited.NewWriter(&plain)
+	for _, record := range []proto.Message{
+		&types.Header{Type: types.Type_NC_TCP},
+		&types.TCP{SrcPort: 1},
+		&types.TCP{SrcPort: 2},
+	} {
+		if err := dw.PutProto(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gz bytes.Buffer
+
+	gw := gzip.NewWriter(&gz)
+	if _, err := gw.Write(plain.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var zst bytes.Buffer
+
+	zw, err := zstd.NewWriter(&zst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = zw.Write(plain.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{
+		"none": plain.Bytes(),
+		"gzip": gz.Bytes(),
+		"zstd": zst.Bytes(),
+	} {
+		r, errOpen := OpenReader(bytes.NewReader(data))
+		if errOpen != nil {
+			t.Fatal(name, errOpen)
+		}
+
+		header, errHeader := r.ReadHeader()
+		if errHeader != nil {
+			t.Fatal(name, errHeader)
+		}
+
+		if header.Type != types.Type_NC_TCP {
+			t.Fatal(name, "not TCP, got: ", header.Type)
+		}
+
+		var (
+			tcp   = new(types.TCP)
+			count int
+		)
+
+		for {
+			err = r.Next(tcp)
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			} else if err != nil {
+				t.Fatal(name, err)
+			}
+			count++
+		}
+
+		if count != 2 {
+			t.Fatal(name, "expected 2 audit records, got: ", count)
+		}
+
+		if r.BytesRead() != int64(len(data)) {
+			t.Fatal(name, "expected ", len(data), " bytes read, got: ", r.BytesRead())
+		}
+
+		if err = r.Close(); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
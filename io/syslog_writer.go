@@ -0,0 +1,346 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/zap"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+const (
+	// syslogSeverityInfo is the RFC 5424 severity used for all audit records.
+	syslogSeverityInfo = 6
+
+	// syslogAppName is the APP-NAME field of the emitted messages.
+	syslogAppName = "netcap"
+
+	// syslogDialTimeout limits the time spent to (re)connect to the collector.
+	syslogDialTimeout = 5 * time.Second
+
+	// syslogWriteTimeout limits the time spent to send a single message.
+	syslogWriteTimeout = 5 * time.Second
+
+	// syslogMinBackoff and syslogMaxBackoff bound the delay between reconnect attempts,
+	// the delay is doubled after every failed attempt.
+	syslogMinBackoff = 100 * time.Millisecond
+	syslogMaxBackoff = 30 * time.Second
+
+	// syslogDefaultQueueSize is the number of messages queued for sending if no size has been configured.
+	syslogDefaultQueueSize = 10000
+)
+
+// syslogFacilities maps the facility names to their RFC 5424 numerical codes.
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// errSyslogBackoff is returned for messages that arrive while waiting to reconnect.
+var errSyslogBackoff = errors.New("waiting to reconnect to syslog collector")
+
+// SyslogConfig configures the collector that receives the audit records.
+type SyslogConfig struct {
+	// SyslogNetwork is the transport protocol used to reach the collector: udp, tcp or unix
+	SyslogNetwork string
+
+	// SyslogAddr is the address of the syslog collector
+	SyslogAddr string
+
+	// SyslogFacility is the facility name, e.g. local0, defaults to user
+	SyslogFacility string
+
+	// SyslogQueueSize is the number of messages queued for sending before records are dropped
+	SyslogQueueSize int
+}
+
+// parseSyslogFacility returns the numerical code for the named facility.
+func parseSyslogFacility(name string) (int, error) {
+	if name == "" {
+		return syslogFacilities["user"], nil
+	}
+
+	if f, ok := syslogFacilities[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+
+	return 0, fmt.Errorf("unknown syslog facility: %q", name)
+}
+
+// syslogWriter emits every audit record as JSON in an RFC 5424 message to a syslog collector.
+// Messages are queued and sent by a separate goroutine, when the queue is full
+// or the collector is not reachable, records are dropped so that the capture is never stalled.
+type syslogWriter struct {
+	queue chan []byte
+	done  chan struct{}
+
+	// only accessed by the sending goroutine
+	conn      net.Conn
+	backoff   time.Duration
+	nextRetry time.Time
+
+	priority int
+	hostname string
+	pid      string
+	msgID    string
+
+	marshaler *jsonpb.Marshaler
+
+	// number of records that could not be delivered to the collector
+	dropped int64
+
+	closeOnce sync.Once
+
+	wc *WriterConfig
+}
+
+// newSyslogWriter initializes and configures a new syslogWriter instance.
+func newSyslogWriter(wc *WriterConfig) *syslogWriter {
+	if wc.Buffer || wc.Compress {
+		panic("buffering or compression cannot be activated when running using syslog")
+	}
+
+	facility, err := parseSyslogFacility(wc.SyslogFacility)
+	if err != nil {
+		panic(err)
+	}
+
+	if wc.SyslogNetwork == "" {
+		wc.SyslogNetwork = "udp"
+	}
+
+	if wc.SyslogQueueSize <= 0 {
+		wc.SyslogQueueSize = syslogDefaultQueueSize
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogWriter{
+		queue:     make(chan []byte, wc.SyslogQueueSize),
+		done:      make(chan struct{}),
+		priority:  facility*8 + syslogSeverityInfo,
+		hostname:  hostname,
+		pid:       strconv.Itoa(os.Getpid()),
+		msgID:     syslogMsgID(wc.Type),
+		marshaler: &jsonpb.Marshaler{},
+		wc:        wc,
+	}
+
+	ioLog.Info("create syslogWriter",
+		zap.String("network", wc.SyslogNetwork),
+		zap.String("addr", wc.SyslogAddr),
+		zap.String("type", wc.Type.String()),
+	)
+
+	// the collector might not be reachable yet, the writer will retry after the backoff
+	if errConnect := w.connect(); errConnect != nil {
+		ioLog.Error("failed to connect to syslog collector", zap.Error(errConnect))
+	}
+
+	go w.sendQueued()
+
+	return w
+}
+
+// syslogMsgID returns the MSGID for the audit record type, e.g. HTTP for NC_HTTP.
+func syslogMsgID(t types.Type) string {
+	id := strings.TrimPrefix(t.String(), "NC_")
+
+	// RFC 5424 limits the MSGID to 32 characters
+	if len(id) > 32 {
+		id = id[:32]
+	}
+
+	return id
+}
+
+// connect (re)establishes the connection to the collector.
+// After a failed attempt, the next one is delayed by an exponentially increasing backoff.
+func (w *syslogWriter) connect() error {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	conn, err := net.DialTimeout(w.wc.SyslogNetwork, w.wc.SyslogAddr, syslogDialTimeout)
+	if err != nil {
+		if w.backoff == 0 {
+			w.backoff = syslogMinBackoff
+		} else if w.backoff *= 2; w.backoff > syslogMaxBackoff {
+			w.backoff = syslogMaxBackoff
+		}
+
+		w.nextRetry = time.Now().Add(w.backoff)
+
+		return err
+	}
+
+	w.conn = conn
+	w.backoff = 0
+
+	return nil
+}
+
+// format creates an RFC 5424 message for the given JSON payload.
+// Stream transports use octet counting framing as described in RFC 6587.
+func (w *syslogWriter) format(js string, t time.Time) []byte {
+	msg := "<" + strconv.Itoa(w.priority) + ">1 " +
+		t.UTC().Format(time.RFC3339Nano) + " " +
+		w.hostname + " " +
+		syslogAppName + " " +
+		w.pid + " " +
+		w.msgID + " - " +
+		js
+
+	if strings.HasPrefix(w.wc.SyslogNetwork, "tcp") {
+		return []byte(strconv.Itoa(len(msg)) + " " + msg)
+	}
+
+	return []byte(msg)
+}
+
+// write sends the message with a deadline, so that a stalled collector can not block the writer.
+func (w *syslogWriter) write(data []byte) error {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		return err
+	}
+
+	_, err := w.conn.Write(data)
+
+	return err
+}
+
+// send writes the message and reconnects once if the connection was lost.
+// While waiting for the backoff to expire, no connection attempts are made.
+func (w *syslogWriter) send(data []byte) error {
+	if w.conn != nil {
+		if err := w.write(data); err == nil {
+			return nil
+		}
+	}
+
+	if w.conn == nil && time.Now().Before(w.nextRetry) {
+		return errSyslogBackoff
+	}
+
+	if err := w.connect(); err != nil {
+		return err
+	}
+
+	return w.write(data)
+}
+
+// sendQueued sends the queued messages until the queue is closed.
+func (w *syslogWriter) sendQueued() {
+	defer close(w.done)
+
+	for data := range w.queue {
+		if err := w.send(data); err != nil {
+			n := atomic.AddInt64(&w.dropped, 1)
+
+			// records dropped while waiting to reconnect are only counted
+			if err != errSyslogBackoff {
+				ioLog.Error("failed to send audit record to syslog collector",
+					zap.Error(err),
+					zap.Int64("dropped", n),
+				)
+			}
+		}
+	}
+
+	if w.conn != nil {
+		if err := w.conn.Close(); err != nil {
+			ioLog.Error("failed to close syslog connection", zap.Error(err))
+		}
+	}
+}
+
+// Write marshals the record to JSON and queues it for sending to the collector.
+// Records that can not be queued are dropped, so that an unavailable collector never stops the capture.
+func (w *syslogWriter) Write(msg proto.Message) error {
+	var (
+		js  string
+		err error
+	)
+
+	if r, ok := msg.(types.AuditRecord); ok {
+		js, err = r.JSON()
+	} else {
+		js, err = w.marshaler.MarshalToString(msg)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %w", err)
+	}
+
+	select {
+	case w.queue <- w.format(js, time.Now()):
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+	}
+
+	return nil
+}
+
+// WriteHeader is a no-op, the collector only receives audit records.
+func (w *syslogWriter) WriteHeader(t types.Type) error {
+	return nil
+}
+
+// Close sends the remaining queued records and closes the connection to the collector.
+func (w *syslogWriter) Close(numRecords int64) (name string, size int64) {
+	w.closeOnce.Do(func() {
+		close(w.queue)
+		<-w.done
+
+		if n := atomic.LoadInt64(&w.dropped); n > 0 {
+			ioLog.Info("syslog records dropped", zap.String("type", w.wc.Type.String()), zap.Int64("dropped", n))
+		}
+	})
+
+	return w.wc.Name, 0
+}
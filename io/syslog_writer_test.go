@@ -0,0 +1,203 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+var rfc5424Header = regexp.MustCompile(`^<134>1 \S+ \S+ netcap \d+ TCP - `)
+
+func TestSyslogWriterUDP(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	w := NewAuditRecordWriter(&WriterConfig{
+		Syslog: true,
+		SyslogConfig: SyslogConfig{
+			SyslogNetwork:  "udp",
+			SyslogAddr:     l.LocalAddr().String(),
+			SyslogFacility: "local0",
+		},
+		Name: "TCP",
+		Type: types.Type_NC_TCP,
+	})
+
+	if err = w.Write(&types.TCP{Timestamp: int64(time.Second), SrcPort: 80}); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = l.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buf := make([]byte, 4096)
+
+	n, _, err := l.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := string(buf[:n])
+	if !rfc5424Header.MatchString(msg) || !strings.Contains(msg, `"SrcPort":80`) {
+		t.Fatal("unexpected message: ", msg)
+	}
+
+	w.Close(1)
+}
+
+func TestSyslogWriterTCPReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	w := newSyslogWriter(&WriterConfig{
+		SyslogConfig: SyslogConfig{
+			SyslogNetwork:  "tcp",
+			SyslogAddr:     l.Addr().String(),
+			SyslogFacility: "local0",
+		},
+		Name: "TCP",
+		Type: types.Type_NC_TCP,
+	})
+
+	// drop the first connection to simulate a collector restart
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+
+	// the first write after the peer closed the connection might still succeed,
+	// keep writing until the writer reconnected
+	done := make(chan string)
+
+	go func() {
+		c, errAccept := l.Accept()
+		if errAccept != nil {
+			close(done)
+
+			return
+		}
+		defer c.Close()
+
+		r := bufio.NewReader(c)
+
+		length, errRead := r.ReadString(' ')
+		if errRead != nil {
+			close(done)
+
+			return
+		}
+
+		size, _ := strconv.Atoi(strings.TrimSpace(length))
+		msg := make([]byte, size)
+
+		if _, errRead = io.ReadFull(r, msg); errRead != nil {
+			close(done)
+
+			return
+		}
+
+		done <- string(msg)
+	}()
+
+	for i := 0; i < 100; i++ {
+		if err = w.Write(&types.TCP{Timestamp: int64(time.Second), SrcPort: 80}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case msg := <-done:
+			if !rfc5424Header.MatchString(msg) {
+				t.Fatal("unexpected message: ", msg)
+			}
+
+			w.Close(1)
+
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	t.Fatal("writer did not reconnect")
+}
+
+func TestParseSyslogFacility(t *testing.T) {
+	if f, err := parseSyslogFacility(""); err != nil || f != 1 {
+		t.Fatal("expected user facility as default, got", f, err)
+	}
+
+	if f, err := parseSyslogFacility("LOCAL7"); err != nil || f != 23 {
+		t.Fatal("expected local7, got", f, err)
+	}
+
+	if _, err := parseSyslogFacility("invalid"); err == nil {
+		t.Fatal("expected error for invalid facility")
+	}
+}
+
+func TestSyslogWriterBackoff(t *testing.T) {
+	// reserve a port without a collector listening on it
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	w := newSyslogWriter(&WriterConfig{
+		SyslogConfig: SyslogConfig{
+			SyslogNetwork:   "tcp",
+			SyslogAddr:      addr,
+			SyslogQueueSize: 10,
+		},
+		Name: "TCP",
+		Type: types.Type_NC_TCP,
+	})
+
+	if w.backoff != syslogMinBackoff {
+		t.Fatal("expected backoff after failed connect, got", w.backoff)
+	}
+
+	// writes never block, even if the queue is full and the collector is unreachable
+	for i := 0; i < 100; i++ {
+		if err = w.Write(&types.TCP{Timestamp: int64(time.Second), SrcPort: 80}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w.Close(100)
+
+	if w.dropped != 100 {
+		t.Fatal("expected all records to be dropped, got", w.dropped)
+	}
+
+	if w.backoff > syslogMaxBackoff {
+		t.Fatal("backoff exceeds maximum:", w.backoff)
+	}
+}
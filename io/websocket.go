@@ -0,0 +1,247 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+const webSocketRoute = "/records"
+
+// webSocketSubscription is sent by clients to select the audit record types they want to receive.
+// An empty list of types subscribes to all audit records.
+type webSocketSubscription struct {
+	Types []string `json:"types"`
+}
+
+// webSocketClient is a connected client with its own send queue.
+type webSocketClient struct {
+	conn *websocket.Conn
+	send chan []byte
+
+	// subscribed types, nil means all types
+	mu    sync.RWMutex
+	types map[types.Type]bool
+
+	// number of records that were dropped because the send queue was full
+	dropped int64
+}
+
+// subscribed checks if the client wants to receive audit records of the given type.
+func (c *webSocketClient) subscribed(t types.Type) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.types == nil || c.types[t]
+}
+
+// subscribe updates the audit record types the client wants to receive.
+func (c *webSocketClient) subscribe(s *webSocketSubscription) {
+	var m map[types.Type]bool
+
+	for _, name := range s.Types {
+		if m == nil {
+			m = make(map[types.Type]bool)
+		}
+
+		// accept both HTTP and NC_HTTP
+		if !strings.HasPrefix(name, "NC_") {
+			name = "NC_" + name
+		}
+
+		if t, ok := types.Type_value[name]; ok {
+			m[types.Type(t)] = true
+		}
+	}
+
+	c.mu.Lock()
+	c.types = m
+	c.mu.Unlock()
+}
+
+// WebSocketServer pushes newly produced audit records as JSON to all connected websocket clients.
+// Every client has a buffered send queue, records are dropped for clients that can not keep up,
+// so that slow clients never stall the decoders.
+type WebSocketServer struct {
+	mu      sync.RWMutex
+	clients map[*webSocketClient]struct{}
+
+	bufferSize int
+}
+
+// webSocketServer is used by all audit record writers configured with the WebSocket option.
+var webSocketServer *WebSocketServer
+
+// ServeWebSocketAt starts serving audit records via websocket on the given address.
+// bufferSize is the number of records queued for each client before records are dropped.
+func ServeWebSocketAt(addr string, bufferSize int) *WebSocketServer {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+
+	s := &WebSocketServer{
+		clients:    make(map[*webSocketClient]struct{}),
+		bufferSize: bufferSize,
+	}
+	webSocketServer = s
+
+	fmt.Println("starting to serve audit records via websocket at:", "ws://"+addr+webSocketRoute)
+
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle(webSocketRoute, websocket.Server{Handler: s.handle})
+
+		log.Fatal("failed to serve websocket: ", http.ListenAndServe(addr, mux))
+	}()
+
+	return s
+}
+
+// handle registers the client, processes its subscription messages and removes it once the connection is closed.
+func (s *WebSocketServer) handle(conn *websocket.Conn) {
+	c := &webSocketClient{
+		conn: conn,
+		send: make(chan []byte, s.bufferSize),
+	}
+
+	s.mu.Lock()
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+
+	ioLog.Info("websocket client connected", zap.String("addr", conn.Request().RemoteAddr))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for msg := range c.send {
+			if err := websocket.Message.Send(conn, string(msg)); err != nil {
+				ioLog.Info("failed to send to websocket client", zap.Error(err))
+
+				return
+			}
+		}
+	}()
+
+	// read subscriptions until the client disconnects
+	for {
+		var sub webSocketSubscription
+
+		if err := websocket.JSON.Receive(conn, &sub); err != nil {
+			break
+		}
+
+		c.subscribe(&sub)
+	}
+
+	s.mu.Lock()
+	delete(s.clients, c)
+	s.mu.Unlock()
+
+	// no more broadcasts can reach the client, stop the sender
+	close(c.send)
+	<-done
+
+	ioLog.Info("websocket client disconnected",
+		zap.String("addr", conn.Request().RemoteAddr),
+		zap.Int64("dropped", atomic.LoadInt64(&c.dropped)),
+	)
+
+	_ = conn.Close()
+}
+
+// broadcast sends the audit record to all clients that are subscribed to its type.
+// The record is only serialized if there is at least one interested client.
+func (s *WebSocketServer) broadcast(t types.Type, msg proto.Message) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var data []byte
+
+	for c := range s.clients {
+		if !c.subscribed(t) {
+			continue
+		}
+
+		if data == nil {
+			data = marshalWebSocketRecord(t, msg)
+			if data == nil {
+				return
+			}
+		}
+
+		select {
+		case c.send <- data:
+		default:
+			// the client is too slow, drop the record
+			atomic.AddInt64(&c.dropped, 1)
+		}
+	}
+}
+
+// marshalWebSocketRecord serializes the audit record to JSON, together with its type.
+func marshalWebSocketRecord(t types.Type, msg proto.Message) []byte {
+	// JSON() modifies the timestamp, make sure to work on a copy
+	// that does not affect the record written by the actual writer
+	record, ok := proto.Clone(msg).(types.AuditRecord)
+	if !ok {
+		return nil
+	}
+
+	js, err := record.JSON()
+	if err != nil {
+		ioLog.Error("failed to marshal websocket record", zap.Error(err))
+
+		return nil
+	}
+
+	return []byte(`{"type":"` + strings.TrimPrefix(t.String(), "NC_") + `","record":` + js + `}`)
+}
+
+// webSocketWriter passes audit records on to the wrapped writer
+// and broadcasts a copy to the websocket clients.
+type webSocketWriter struct {
+	AuditRecordWriter
+	typ types.Type
+}
+
+// Write broadcasts the record and writes it with the wrapped writer.
+func (w *webSocketWriter) Write(msg proto.Message) error {
+	if webSocketServer != nil {
+		webSocketServer.broadcast(w.typ, msg)
+	}
+
+	return w.AuditRecordWriter.Write(msg)
+}
+
+// GetChan returns the channel of the wrapped writer, if it is a channel writer.
+func (w *webSocketWriter) GetChan() <-chan []byte {
+	if cw, ok := w.AuditRecordWriter.(ChannelAuditRecordWriter); ok {
+		return cw.GetChan()
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package io
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/dreadl0ck/netcap/types"
+)
+
+func TestWebSocketBroadcast(t *testing.T) {
+	s := &WebSocketServer{
+		clients:    make(map[*webSocketClient]struct{}),
+		bufferSize: 10,
+	}
+
+	srv := httptest.NewServer(websocket.Server{Handler: s.handle})
+	defer srv.Close()
+
+	conn, err := websocket.Dial(strings.Replace(srv.URL, "http", "ws", 1), "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err = websocket.JSON.Send(conn, &webSocketSubscription{Types: []string{"TCP"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait until the client is registered and the subscription has been processed
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var subscribed bool
+
+		s.mu.RLock()
+		for c := range s.clients {
+			subscribed = c.subscribed(types.Type_NC_TCP) && !c.subscribed(types.Type_NC_UDP)
+		}
+		s.mu.RUnlock()
+
+		if subscribed {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("subscription was not processed")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tcp := &types.TCP{Timestamp: int64(time.Second), SrcPort: 80}
+
+	s.broadcast(types.Type_NC_UDP, &types.UDP{SrcPort: 53})
+	s.broadcast(types.Type_NC_TCP, tcp)
+
+	var msg struct {
+		Type   string
+		Record map[string]interface{}
+	}
+
+	if err = websocket.JSON.Receive(conn, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Type != "TCP" || msg.Record["SrcPort"] != float64(80) {
+		data, _ := json.Marshal(msg)
+		t.Fatal("unexpected record: ", string(data))
+	}
+
+	// the broadcast must not modify the original record
+	if tcp.Timestamp != int64(time.Second) {
+		t.Fatal("timestamp of the original record was modified: ", tcp.Timestamp)
+	}
+}
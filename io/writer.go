@@ -36,15 +36,51 @@ type ChannelAuditRecordWriter interface {
 
 // NewAuditRecordWriter will return a new writer for netcap audit records.
 func NewAuditRecordWriter(wc *WriterConfig) AuditRecordWriter {
+	out := wc.Out
+	wc = resolveOutDir(wc)
+	if wc.CompressionFormat == CompressionNone {
+		c := *wc
+		c.Compress = false
+		wc = &c
+	}
+
+	var w AuditRecordWriter = &manifestWriter{
+		AuditRecordWriter: newAuditRecordWriter(wc),
+		out:               out,
+		dir:               wc.Out,
+		typ:               wc.Type,
+	}
+
+	// additionally stream the records to the websocket clients
+	if wc.WebSocket {
+		return &webSocketWriter{
+			AuditRecordWriter: w,
+			typ:               wc.Type,
+		}
+	}
+
+	return w
+}
+
+// newAuditRecordWriter creates the writer for the configured output format.
+func newAuditRecordWriter(wc *WriterConfig) AuditRecordWriter {
 	switch {
 	case wc.UnixSocket:
 		return newUnixSocketWriter(wc)
+	case wc.Syslog:
+		return newSyslogWriter(wc)
+	case wc.Kafka:
+		return newKafkaWriter(wc)
 	case wc.CSV:
 		return newCSVWriter(wc)
 	case wc.Chan:
 		return newChanWriter(wc)
 	case wc.JSON:
 		return newJSONWriter(wc)
+	case wc.CBOR:
+		return newCBORWriter(wc)
+	case wc.Avro:
+		return newAvroWriter(wc)
 	case wc.Null:
 		return newNullWriter(wc)
 	case wc.Elastic:
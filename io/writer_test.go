@@ -14,9 +14,18 @@
 package io
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/jsonpb"
+
 	"github.com/dreadl0ck/netcap"
 	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
@@ -109,6 +118,75 @@ func TestWriter(t *testing.T) {
 	}
 }
 
+func TestJSONWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcap-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := newJSONWriter(&WriterConfig{
+		JSON:          true,
+		Name:          "TCP",
+		Buffer:        true,
+		Out:           dir,
+		MemBufferSize: defaults.BufferSize,
+		Source:        "unit tests",
+		Version:       netcap.Version,
+		StartTime:     time.Now(),
+	})
+
+	if err = w.WriteHeader(types.Type_NC_TCP); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tcp := range []*types.TCP{{SrcPort: 1}, {SrcPort: 2}} {
+		if err = w.Write(tcp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// messages that are not audit records are marshaled as well
+	if err = w.Write(&types.Header{Type: types.Type_NC_UDP}); err != nil {
+		t.Fatal(err)
+	}
+
+	w.Close(3)
+
+	f, err := os.Open(filepath.Join(dir, "TCP.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var (
+		lines []string
+		sc    = bufio.NewScanner(f)
+	)
+
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+
+	if len(lines) != 4 {
+		t.Fatal("expected header and 3 records, got", len(lines))
+	}
+
+	var header types.Header
+	if err = jsonpb.UnmarshalString(lines[0], &header); err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Type != types.Type_NC_TCP || header.InputSource != "unit tests" {
+		t.Fatal("unexpected header", header.String())
+	}
+
+	var tcp types.TCP
+	if err = jsonpb.UnmarshalString(lines[2], &tcp); err != nil || tcp.SrcPort != 2 {
+		t.Fatal("unexpected record", lines[2], err)
+	}
+}
+
 func BenchmarkWriter(b *testing.B) {
 	// create a new writer
 	w := newProtoWriter(&WriterConfig{
@@ -152,3 +230,149 @@ func BenchmarkWriter(b *testing.B) {
 		b.Fatal("no data written")
 	}
 }
+
+func TestCSVProtoWriterQuoting(t *testing.T) {
+	record := &types.HTTP{
+		Method:    "GET",
+		UserAgent: `Mozilla/5.0 (KHTML, like Gecko) "quoted"`,
+		URL:       "/search?q=a;b",
+		Referer:   "line\nbreak",
+	}
+
+	for _, delimiter := range []rune{0, ';', '\t'} {
+		var buf bytes.Buffer
+
+		w := newCSVProtoWriter(&buf, false, false, delimiter)
+
+		if _, err := w.writeHeader(&types.Header{Type: types.Type_NC_HTTP}, record); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.writeRecord(record); err != nil {
+			t.Fatal(err)
+		}
+
+		r := csv.NewReader(&buf)
+		if delimiter != 0 {
+			r.Comma = delimiter
+		}
+
+		rows, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rows) != 2 {
+			t.Fatal("expected header and record, got", len(rows))
+		}
+
+		if !reflect.DeepEqual(rows[0], record.CSVHeader()) || !reflect.DeepEqual(rows[1], record.CSVRecord()) {
+			t.Fatalf("delimiter %q: values did not survive the round trip: %q", delimiter, rows[1])
+		}
+	}
+}
+
+func TestProtoWriterRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcap-rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// rotate after every record
+	w := newProtoWriter(&WriterConfig{
+		Proto:         true,
+		Name:          "TCP",
+		Out:           dir,
+		MemBufferSize: defaults.BufferSize,
+		Source:        "unit tests",
+		Version:       netcap.Version,
+		StartTime:     time.Now(),
+		MaxFileSize:   1,
+	})
+
+	if err = w.WriteHeader(types.Type_NC_TCP); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tcp := range tcps {
+		if err = w.Write(tcp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name, size := w.Close(int64(len(tcps)))
+	if name != "TCP"+defaults.FileExtension {
+		t.Fatal("expected the name of the first part, got", name)
+	}
+
+	var total int64
+
+	for _, file := range []string{"TCP", "TCP.part1", "TCP.part2"} {
+		path := filepath.Join(dir, file+defaults.FileExtension)
+
+		if count := countRecords(t, path); count != 1 {
+			t.Fatalf("%s: expected 1 record, got %d", path, count)
+		}
+
+		info, errStat := os.Stat(path)
+		if errStat != nil {
+			t.Fatal(errStat)
+		}
+
+		total += info.Size()
+	}
+
+	if size != total {
+		t.Fatal("expected the total size of all parts", total, "got", size)
+	}
+
+	// the final part does only contain the header and is removed
+	if _, err = os.Stat(filepath.Join(dir, "TCP.part3"+defaults.FileExtension)); !os.IsNotExist(err) {
+		t.Fatal("expected empty part to be removed", err)
+	}
+}
+
+func TestProtoWriterZstd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcap-zstd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, buffer := range []bool{true, false} {
+		w := NewAuditRecordWriter(&WriterConfig{
+			Proto:                true,
+			Name:                 "TCP",
+			Buffer:               buffer,
+			Compress:             true,
+			CompressionFormat:    CompressionZstd,
+			CompressionBlockSize: defaults.CompressionBlockSize,
+			CompressionLevel:     defaults.CompressionLevel,
+			Out:                  dir,
+			MemBufferSize:        defaults.BufferSize,
+			Source:               "unit tests",
+			Version:              netcap.Version,
+			StartTime:            time.Now(),
+		})
+
+		if err = w.WriteHeader(types.Type_NC_TCP); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tcp := range tcps {
+			if err = w.Write(tcp); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		name, _ := w.Close(int64(len(tcps)))
+		if name != "TCP"+defaults.FileExtensionZstd {
+			t.Fatal("expected zstd file extension, got", name)
+		}
+
+		if count := countRecords(t, filepath.Join(dir, name)); count != len(tcps) {
+			t.Fatalf("buffer %v: expected %d records, got %d", buffer, len(tcps), count)
+		}
+	}
+}
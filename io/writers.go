@@ -32,6 +32,12 @@ type WriterConfig struct {
 	// JSON writer
 	JSON bool
 
+	// CBOR writer
+	CBOR bool
+
+	// Avro writer
+	Avro bool
+
 	// Channel writer
 	Chan bool
 
@@ -44,9 +50,25 @@ type WriterConfig struct {
 	// UnixSocket writer
 	UnixSocket bool
 
+	// Syslog writer
+	Syslog bool
+
+	// SyslogConfig configures the syslog collector
+	SyslogConfig
+
+	// Kafka writer
+	Kafka bool
+
+	// KafkaConfig configures the kafka brokers and topics
+	KafkaConfig
+
 	// ElasticConfig allows to overwrite elastic defaults
 	ElasticConfig
 
+	// WebSocket additionally streams the audit records to the clients of the websocket server
+	// the server must be started with ServeWebSocketAt
+	WebSocket bool
+
 	// The Null writer will write nothing to disk and discard all data.
 	Null bool
 
@@ -58,6 +80,9 @@ type WriterConfig struct {
 	Out           string
 	MemBufferSize int
 
+	// OutDirs overwrites the output directory for the audit record types used as keys
+	OutDirs map[string]string
+
 	// Netcap header information
 	Source           string
 	Version          string
@@ -68,9 +93,19 @@ type WriterConfig struct {
 	CompressionBlockSize int
 	CompressionLevel     int
 
+	// CompressionFormat is the algorithm used for compressed output, defaults to gzip
+	CompressionFormat Compression
+
 	// Encode data on the fly
 	Encode bool
 
 	// Label data on the fly
 	Label bool
+
+	// CSVDelimiter separates the fields of CSV records, defaults to a comma
+	CSVDelimiter rune
+
+	// MaxFileSize rotates protobuf audit record files once they exceed the given number of bytes,
+	// 0 disables rotation
+	MaxFileSize int64
 }
@@ -26,8 +26,8 @@ import (
 
 	"github.com/gogo/protobuf/proto"
 
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // ARPCountFunc is a function that counts something over multiple ARP audit records.
@@ -48,10 +48,10 @@ func ARPTransform(count ARPCountFunc, transform ARPTransformationFunc, continueT
 	)
 
 	if !strings.HasPrefix(filepath.Base(path), "ARP.ncap") {
-		path = filepath.Join(filepath.Dir(path), "ARP.ncap.gz")
+		path = auditRecordPath(path, "ARP")
 	}
 
-	f, err := os.Open(path)
+	err := statFile(path)
 	if err != nil {
 		log.Println(err)
 		fmt.Println(trx.ReturnOutput())
@@ -59,8 +59,8 @@ func ARPTransform(count ARPCountFunc, transform ARPTransformationFunc, continueT
 	}
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
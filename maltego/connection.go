@@ -19,18 +19,16 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
 	"sort"
-	"strings"
 
 	"github.com/dreadl0ck/maltego"
 
 	"github.com/gogo/protobuf/proto"
 
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/resolvers"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // connCountFunc is a function that counts something over multiple conn audit records.
@@ -171,14 +169,13 @@ var countOutgoingconnPackets = func(conn *types.Connection, ipaddr string, min,
 type connTransformationFunc = func(lt maltego.LocalTransform, trx *maltego.Transform, conn *types.Connection, min, max uint64, path string, mac string, ip string, sizes *[]int)
 
 // ConnectionTransform applies a maltego transformation over types.Connection audit records.
-func ConnectionTransform(count connCountFunc, transform connTransformationFunc) {
+func ConnectionTransform(count connCountFunc, transform connTransformationFunc, continueTransform bool) {
 	var (
 		lt               = maltego.ParseLocalArguments(os.Args[3:])
 		path             = lt.Values["path"]
 		mac              = lt.Values["mac"]
 		ipaddr           = lt.Values[PropertyIpAddr]
-		dir              = filepath.Dir(path)
-		connAuditRecords = filepath.Join(dir, "Connection.ncap.gz")
+		connAuditRecords = auditRecordPath(path, "Connection")
 		trx              = maltego.Transform{}
 	)
 
@@ -186,11 +183,11 @@ func ConnectionTransform(count connCountFunc, transform connTransformationFunc)
 
 	log.Println("opening", connAuditRecords)
 
-	f, path := openFile(connAuditRecords)
+	path = openFile(connAuditRecords)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die("input file must be an audit record file, but got", f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die("input file must be an audit record file, but got", path)
 	}
 
 	r := openNetcapArchive(path)
@@ -272,6 +269,8 @@ func ConnectionTransform(count connCountFunc, transform connTransformationFunc)
 		log.Println("failed to close audit record file: ", err)
 	}
 
-	trx.AddUIMessage("completed!", maltego.UIMessageInform)
-	fmt.Println(trx.ReturnOutput())
+	if !continueTransform {
+		trx.AddUIMessage("completed!", maltego.UIMessageInform)
+		fmt.Println(trx.ReturnOutput())
+	}
 }
@@ -19,14 +19,15 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // credentialsTransformationFunc is a transformation over Credentials profiles for a selected Credentials.
@@ -47,11 +48,16 @@ func CredentialsTransform(count credentialsCountFunc, transform credentialsTrans
 
 	netio.FPrintBuildInfo(os.Stderr)
 
-	f, path := openFile(path)
+	// transforms on entities other than the credentials audit records locate the file next to their audit records
+	if !strings.HasPrefix(filepath.Base(path), "Credentials.ncap") {
+		path = auditRecordPath(path, "Credentials")
+	}
+
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -25,9 +25,9 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // CountPacketsDevices returns the lowest and highest number of packets seen for a given DeviceProfile.
@@ -89,16 +89,16 @@ func DeviceProfileTransform(count deviceProfileCountFunc, transform deviceProfil
 	)
 
 	if !strings.HasPrefix(filepath.Base(path), "DeviceProfile.ncap") {
-		path = filepath.Join(filepath.Dir(path), "DeviceProfile.ncap.gz")
+		path = auditRecordPath(path, "DeviceProfile")
 	}
 
 	netio.FPrintBuildInfo(os.Stderr)
 
-	f, path := openFile(path)
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die("input file must be an audit record file, but got", f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die("input file must be an audit record file, but got", path)
 	}
 
 	log.Println("open reader", path)
@@ -150,10 +150,7 @@ func DeviceProfileTransform(count deviceProfileCountFunc, transform deviceProfil
 			log.Println("failed to close audit record file: ", err)
 		}
 
-		r, err = netio.Open(path, defaults.BufferSize)
-		if err != nil {
-			maltego.Die(err.Error(), "failed to open file")
-		}
+		r = openNetcapArchive(path)
 
 		// read off netcap header - ignore err as it has been checked before
 		_, _ = r.ReadHeader()
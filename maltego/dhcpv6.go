@@ -25,8 +25,8 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // DHCPV6TransformationFunc is a transformation over DHCPv6 audit records.
@@ -43,10 +43,10 @@ func DHCPV6Transform(count DHCPCountFunc, transform DHCPV6TransformationFunc, co
 	)
 
 	if !strings.HasPrefix(filepath.Base(path), "DHCPv6.ncap") {
-		path = filepath.Join(filepath.Dir(path), "DHCPv6.ncap.gz")
+		path = auditRecordPath(path, "DHCPv6")
 	}
 
-	f, err := os.Open(path)
+	err := statFile(path)
 	if err != nil {
 		log.Println(err)
 		fmt.Println(trx.ReturnOutput())
@@ -54,8 +54,8 @@ func DHCPV6Transform(count DHCPCountFunc, transform DHCPV6TransformationFunc, co
 	}
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
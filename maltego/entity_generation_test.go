@@ -39,6 +39,7 @@ var maltegoEntities = []maltego.EntityCoreInfo{
 	{"PCAP", "sd_storage", "A packet capture dump file", "", []*maltego.PropertyField{maltego.NewRequiredStringField("path", "Absolute path to the PCAP file")}},
 	{"Device", "devices", "A device seen on the network", "", nil},
 	{"FileType", "insert_chart", "The type of file based on its contents", "", nil},
+	{"JA3", "fingerprint", "A JA3 fingerprint of a TLS client", "", nil},
 	{"IPAddr", "router", "An internet protocol (IP) network address", "maltego.IPv4Address", nil},
 
 	{"DNSFlagCombination", "outlined_flag", "A combination of DNS flags", "", nil},
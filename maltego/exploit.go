@@ -24,9 +24,9 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // exploitTransformationFunc is a transformation over Exploit exploits for a selected Exploit.
@@ -48,11 +48,11 @@ func ExploitTransform(count exploitCountFunc, transform exploitTransformationFun
 
 	netio.FPrintBuildInfo(os.Stderr)
 
-	f, path := openFile(path)
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
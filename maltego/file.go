@@ -19,14 +19,12 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // filesCountFunc is a function that counts something over File audit records.
@@ -36,21 +34,20 @@ type filesCountFunc func()
 type filesTransformationFunc = func(lt maltego.LocalTransform, trx *maltego.Transform, file *types.File, min, max uint64, path string, ip string)
 
 // FilesTransform applies a maltego transformation over File audit records.
-func FilesTransform(count filesCountFunc, transform filesTransformationFunc) {
+func FilesTransform(count filesCountFunc, transform filesTransformationFunc, continueTransform bool) {
 	var (
 		lt               = maltego.ParseLocalArguments(os.Args[3:])
 		path             = lt.Values["path"]
 		ipaddr           = lt.Values[PropertyIpAddr]
 		trx              = maltego.Transform{}
-		dir              = filepath.Dir(path)
-		fileAuditRecords = filepath.Join(dir, "File.ncap.gz")
+		fileAuditRecords = auditRecordPath(path, "File")
 	)
 
-	f, path := openFile(fileAuditRecords)
+	path = openFile(fileAuditRecords)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die("input file must be an audit record file, but got", f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die("input file must be an audit record file, but got", path)
 	}
 
 	r := openNetcapArchive(path)
@@ -121,6 +118,8 @@ func FilesTransform(count filesCountFunc, transform filesTransformationFunc) {
 		log.Println("failed to close audit record file: ", err)
 	}
 
-	trx.AddUIMessage("completed!", maltego.UIMessageInform)
-	fmt.Println(trx.ReturnOutput())
+	if !continueTransform {
+		trx.AddUIMessage("completed!", maltego.UIMessageInform)
+		fmt.Println(trx.ReturnOutput())
+	}
 }
@@ -19,14 +19,12 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // HTTPCountFunc is a function that counts something over multiple HTTP audit records.
@@ -42,16 +40,15 @@ func HTTPTransform(count HTTPCountFunc, transform HTTPTransformationFunc, contin
 	var (
 		lt               = maltego.ParseLocalArguments(os.Args[3:])
 		ipaddr           = lt.Values[PropertyIpAddr]
-		dir              = filepath.Dir(strings.TrimPrefix(lt.Values["path"], "file://"))
-		httpAuditRecords = filepath.Join(dir, "HTTP.ncap.gz")
+		httpAuditRecords = auditRecordPath(lt.Values["path"], "HTTP")
 		trx              = maltego.Transform{}
 	)
 
-	f, path := openFile(httpAuditRecords)
+	path := openFile(httpAuditRecords)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
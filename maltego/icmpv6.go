@@ -19,14 +19,12 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // ICMPv6CountFunc is a function that counts something over multiple ICMPv6 audit records.
@@ -43,16 +41,15 @@ func ICMPv6Transform(count ICMPv6CountFunc, transform ICMPv6TransformationFunc)
 		lt               = maltego.ParseLocalArguments(os.Args[3:])
 		path             = lt.Values["path"]
 		ipaddr           = lt.Values[PropertyIpAddr]
-		dir              = filepath.Dir(path)
-		icmpAuditRecords = filepath.Join(dir, "ICMPv6.ncap.gz")
+		icmpAuditRecords = auditRecordPath(path, "ICMPv6")
 		trx              = maltego.Transform{}
 	)
 
-	f, path := openFile(icmpAuditRecords)
+	path = openFile(icmpAuditRecords)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -25,12 +25,13 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // IPTransformationFunc is a transformation over IP profiles for a selected DeviceProfile.
+//
 //goland:noinspection GoUnnecessarilyExportedIdentifiers
 type IPTransformationFunc = func(lt maltego.LocalTransform, trx *maltego.Transform, profile *types.IPProfile, min, max uint64, path string, mac string, ip string)
 
@@ -48,6 +49,7 @@ var CountIPPackets = func(profile *types.IPProfile, mac string, min, max *uint64
 }
 
 // IPProfileTransformationFunc is a transformation over IP profiles
+//
 //goland:noinspection GoUnnecessarilyExportedIdentifiers
 type IPProfileTransformationFunc = func(lt maltego.LocalTransform, trx *maltego.Transform, profile *types.IPProfile, min, max uint64, path string, mac string, ip string)
 
@@ -62,16 +64,16 @@ func IPProfileTransform(count ipProfileCountFunc, transform IPProfileTransformat
 	)
 
 	if !strings.HasPrefix(filepath.Base(path), "IPProfile.ncap") {
-		path = filepath.Join(filepath.Dir(path), "IPProfile.ncap.gz")
+		path = auditRecordPath(path, "IPProfile")
 	}
 
 	netio.FPrintBuildInfo(os.Stderr)
 
-	f, path := openFile(path)
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -123,10 +125,7 @@ func IPProfileTransform(count ipProfileCountFunc, transform IPProfileTransformat
 		}
 	}
 
-	r, err = netio.Open(path, defaults.BufferSize)
-	if err != nil {
-		maltego.Die(err.Error(), "failed to open file")
-	}
+	r = openNetcapArchive(path)
 
 	// read netcap header - ignore err as it has been checked before
 	_, _ = r.ReadHeader()
@@ -155,7 +154,7 @@ func IPProfileTransform(count ipProfileCountFunc, transform IPProfileTransformat
 func LoadIPProfiles() map[string]*types.IPProfile {
 	var (
 		lt       = maltego.ParseLocalArguments(os.Args[3:])
-		path     = filepath.Join(filepath.Dir(strings.TrimPrefix(lt.Values["path"], "file://")), "IPProfile.ncap.gz")
+		path     = auditRecordPath(lt.Values["path"], "IPProfile")
 		profiles = make(map[string]*types.IPProfile)
 		err      error
 	)
@@ -163,10 +162,10 @@ func LoadIPProfiles() map[string]*types.IPProfile {
 	log.Println("LoadIPProfiles called")
 
 	netio.FPrintBuildInfo(os.Stderr)
-	f, path := openFile(path)
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
+	if !utils.IsNetcapFile(path) {
 		log.Fatal("input file must be an audit record file")
 	}
 
@@ -205,19 +204,23 @@ func LoadIPProfiles() map[string]*types.IPProfile {
 		}
 
 		profiles[profile.Addr] = &types.IPProfile{
-			Addr:           profile.Addr,
-			NumPackets:     profile.NumPackets,
-			Geolocation:    profile.Geolocation,
-			DNSNames:       profile.DNSNames,
-			TimestampFirst: profile.TimestampFirst,
-			TimestampLast:  profile.TimestampLast,
-			Applications:   profile.Applications,
-			Ja3Hashes:      profile.Ja3Hashes,
-			Protocols:      profile.Protocols,
-			Bytes:          profile.Bytes,
-			SrcPorts:       profile.SrcPorts,
-			DstPorts:       profile.DstPorts,
-			SNIs:           profile.SNIs,
+			Addr:               profile.Addr,
+			NumPackets:         profile.NumPackets,
+			Geolocation:        profile.Geolocation,
+			DNSNames:           profile.DNSNames,
+			TimestampFirst:     profile.TimestampFirst,
+			TimestampLast:      profile.TimestampLast,
+			Applications:       profile.Applications,
+			Ja3Hashes:          profile.Ja3Hashes,
+			Protocols:          profile.Protocols,
+			Bytes:              profile.Bytes,
+			SrcPorts:           profile.SrcPorts,
+			DstPorts:           profile.DstPorts,
+			SNIs:               profile.SNIs,
+			MacAddr:            profile.MacAddr,
+			DeviceManufacturer: profile.DeviceManufacturer,
+			Latitude:           profile.Latitude,
+			Longitude:          profile.Longitude,
 		}
 	}
 
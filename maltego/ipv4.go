@@ -25,9 +25,9 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 type ipCountFunc = func(ip string, min, max *uint64)
@@ -47,16 +47,16 @@ func IPv4Transform(count ipCountFunc, transform IPv4TransformationFunc, continue
 	)
 
 	if !strings.HasPrefix(filepath.Base(path), "IPv4.ncap") {
-		path = filepath.Join(filepath.Dir(path), "IPv4.ncap.gz")
+		path = auditRecordPath(path, "IPv4")
 	}
 
 	netio.FPrintBuildInfo(os.Stderr)
 
-	f, path := openFile(path)
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -107,10 +107,7 @@ func IPv4Transform(count ipCountFunc, transform IPv4TransformationFunc, continue
 		}
 	}
 
-	r, err = netio.Open(path, defaults.BufferSize)
-	if err != nil {
-		maltego.Die(err.Error(), "failed to open file")
-	}
+	r = openNetcapArchive(path)
 
 	// read netcap header - ignore err as it has been checked before
 	_, _ = r.ReadHeader()
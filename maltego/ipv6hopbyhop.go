@@ -25,9 +25,9 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // IPv6HopByHopTransformationFunc is a transformation over IPv6HopByHop audit records
@@ -45,16 +45,16 @@ func IPv6HopByHopTransform(count ipv6CountFunc, transform IPv6HopByHopTransforma
 	)
 
 	if !strings.HasPrefix(filepath.Base(path), "IPv6HopByHop.ncap") {
-		path = filepath.Join(filepath.Dir(path), "IPv6HopByHop.ncap.gz")
+		path = auditRecordPath(path, "IPv6HopByHop")
 	}
 
 	netio.FPrintBuildInfo(os.Stderr)
 
-	f, path := openFile(path)
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -105,10 +105,7 @@ func IPv6HopByHopTransform(count ipv6CountFunc, transform IPv6HopByHopTransforma
 		}
 	}
 
-	r, err = netio.Open(path, defaults.BufferSize)
-	if err != nil {
-		maltego.Die(err.Error(), "failed to open file")
-	}
+	r = openNetcapArchive(path)
 
 	// read netcap header - ignore err as it has been checked before
 	_, _ = r.ReadHeader()
@@ -19,15 +19,13 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // MailCountFunc is a function that counts something over multiple Mail audit records.
@@ -44,16 +42,15 @@ func MailTransform(count MailCountFunc, transform MailTransformationFunc) {
 		lt               = maltego.ParseLocalArguments(os.Args[3:])
 		path             = lt.Values["path"]
 		ipaddr           = lt.Values[PropertyIpAddr]
-		dir              = filepath.Dir(path)
-		mailAuditRecords = filepath.Join(dir, "Mail.ncap.gz")
+		mailAuditRecords = auditRecordPath(path, "Mail")
 		trx              = maltego.Transform{}
 	)
 
-	f, path := openFile(mailAuditRecords)
+	path = openFile(mailAuditRecords)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -132,16 +129,16 @@ func MailTransform(count MailCountFunc, transform MailTransformationFunc) {
 func LoadMails() map[string]*types.Mail {
 	var (
 		lt    = maltego.ParseLocalArguments(os.Args[3:])
-		path  = filepath.Join(filepath.Dir(strings.TrimPrefix(lt.Values["path"], "file://")), "Mail.ncap.gz")
+		path  = auditRecordPath(lt.Values["path"], "Mail")
 		mails = make(map[string]*types.Mail)
 	)
 
 	netio.FPrintBuildInfo(os.Stderr)
-	f, path := openFile(path)
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
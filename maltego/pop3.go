@@ -19,14 +19,12 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // POP3CountFunc is a function that counts something over multiple POP3 audit records.
@@ -43,16 +41,15 @@ func POP3Transform(count POP3CountFunc, transform POP3TransformationFunc, contin
 		lt               = maltego.ParseLocalArguments(os.Args[3:])
 		path             = lt.Values["path"]
 		ipaddr           = lt.Values[PropertyIpAddr]
-		dir              = filepath.Dir(path)
-		pop3AuditRecords = filepath.Join(dir, "POP3.ncap.gz")
+		pop3AuditRecords = auditRecordPath(path, "POP3")
 		trx              = maltego.Transform{}
 	)
 
-	f, path := openFile(pop3AuditRecords)
+	path = openFile(pop3AuditRecords)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -0,0 +1,114 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package maltego
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cheggaaa/pb"
+	"github.com/gogo/protobuf/proto"
+
+	netio "github.com/dreadl0ck/netcap/io"
+	"github.com/dreadl0ck/netcap/types"
+)
+
+// number of audit records between updates of the progress bar.
+const progressInterval = 1000
+
+// passes counts how often each audit record file has been opened,
+// transforms that collect statistics first read the file twice.
+var passes = make(map[string]int)
+
+// auditRecordReader reads audit records and displays the progress on stderr,
+// when a transform is invoked from a terminal.
+type auditRecordReader struct {
+	*netio.Reader
+	path       string
+	bar        *pb.ProgressBar
+	numRecords int
+}
+
+func newAuditRecordReader(r *netio.Reader, path string) *auditRecordReader {
+	passes[path]++
+
+	return &auditRecordReader{
+		Reader: r,
+		path:   path,
+	}
+}
+
+// ReadHeader reads the header and starts the progress bar for the contained record type.
+func (r *auditRecordReader) ReadHeader() (*types.Header, error) {
+	header, err := r.Reader.ReadHeader()
+	if err != nil || r.bar != nil || r.Size() == 0 || !isTerminal(os.Stderr) {
+		return header, err
+	}
+
+	r.bar = pb.New64(r.Size()).SetUnits(pb.U_BYTES)
+	r.bar.Prefix(strings.TrimPrefix(header.Type.String(), "NC_") + " pass " + strconv.Itoa(passes[r.path]) + " ")
+	r.bar.Output = os.Stderr
+	r.bar.Start()
+
+	return header, nil
+}
+
+// Next reads the next audit record and updates the progress periodically.
+func (r *auditRecordReader) Next(msg proto.Message) error {
+	err := r.Reader.Next(msg)
+	if r.bar == nil {
+		return err
+	}
+
+	if err != nil {
+		r.finish()
+
+		return err
+	}
+
+	r.numRecords++
+	if r.numRecords%progressInterval == 0 {
+		r.bar.Set64(r.BytesRead())
+	}
+
+	return nil
+}
+
+// Close finishes the progress bar and closes the file.
+func (r *auditRecordReader) Close() error {
+	r.finish()
+
+	return r.Reader.Close()
+}
+
+func (r *auditRecordReader) finish() {
+	if r.bar == nil {
+		return
+	}
+
+	r.bar.Set64(r.BytesRead())
+	r.bar.Finish()
+	r.bar = nil
+}
+
+// isTerminal checks if the file is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
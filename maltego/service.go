@@ -25,9 +25,9 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // serviceTransformationFunc is a transformation over Service profiles for a selected Service.
@@ -48,14 +48,14 @@ func ServiceTransform(count serviceCountFunc, transform serviceTransformationFun
 	)
 
 	if !strings.HasPrefix(filepath.Base(path), "Service.ncap") {
-		path = filepath.Join(filepath.Dir(path), "Service.ncap.gz")
+		path = auditRecordPath(path, "Service")
 	}
 
 	netio.FPrintBuildInfo(os.Stderr)
 
 	log.Println("opening", path)
 
-	f, err := os.Open(path)
+	err := statFile(path)
 	if err != nil {
 		trx.AddUIMessage("path property not set!", maltego.UIMessageFatal)
 		fmt.Println(trx.ReturnOutput())
@@ -64,8 +64,8 @@ func ServiceTransform(count serviceCountFunc, transform serviceTransformationFun
 	}
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -113,10 +113,7 @@ func ServiceTransform(count serviceCountFunc, transform serviceTransformationFun
 		}
 	}
 
-	r, err = netio.Open(path, defaults.BufferSize)
-	if err != nil {
-		maltego.Die(err.Error(), "failed to open file")
-	}
+	r = openNetcapArchive(path)
 
 	// read netcap header - ignore err as it has been checked before
 	_, _ = r.ReadHeader()
@@ -24,9 +24,9 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // SSHTransformationFunc is a transformation over SSH sshs for a selected SSH.
@@ -38,7 +38,7 @@ type SSHTransformationFunc = func(lt maltego.LocalTransform, trx *maltego.Transf
 type SSHCountFunc = func(ssh *types.SSH, mac string, min, max *uint64)
 
 // SSHTransform applies a maltego transformation over SSH sshs seen for a target SSH.
-func SSHTransform(count SSHCountFunc, transform SSHTransformationFunc) {
+func SSHTransform(count SSHCountFunc, transform SSHTransformationFunc, continueTransform bool) {
 	var (
 		lt     = maltego.ParseLocalArguments(os.Args[3:])
 		path   = strings.TrimPrefix(lt.Values["path"], "file://")
@@ -50,11 +50,11 @@ func SSHTransform(count SSHCountFunc, transform SSHTransformationFunc) {
 
 	netio.FPrintBuildInfo(os.Stderr)
 
-	f, path := openFile(path)
+	path = openFile(path)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -126,6 +126,8 @@ func SSHTransform(count SSHCountFunc, transform SSHTransformationFunc) {
 		log.Println("failed to close audit record file: ", err)
 	}
 
-	trx.AddUIMessage("completed!", maltego.UIMessageInform)
-	fmt.Println(trx.ReturnOutput())
+	if !continueTransform {
+		trx.AddUIMessage("completed!", maltego.UIMessageInform)
+		fmt.Println(trx.ReturnOutput())
+	}
 }
@@ -19,14 +19,12 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // TLSClientHelloCountFunc is a function that counts something over multiple TLSClientHello audit records.
@@ -38,21 +36,20 @@ type TLSClientHelloCountFunc func()
 type TLSClientHelloTransformationFunc = func(lt maltego.LocalTransform, trx *maltego.Transform, hello *types.TLSClientHello, min, max uint64, path string, ip string)
 
 // TLSClientHelloTransform applies a maltego transformation over TLSClientHello audit records.
-func TLSClientHelloTransform(count TLSClientHelloCountFunc, transform TLSClientHelloTransformationFunc) {
+func TLSClientHelloTransform(count TLSClientHelloCountFunc, transform TLSClientHelloTransformationFunc, continueTransform bool) {
 	var (
 		lt               = maltego.ParseLocalArguments(os.Args[2:])
 		path             = lt.Values["path"]
 		ipaddr           = lt.Values[PropertyIpAddr]
-		dir              = filepath.Dir(path)
-		pop3AuditRecords = filepath.Join(dir, "TLSClientHello.ncap.gz")
+		pop3AuditRecords = auditRecordPath(path, "TLSClientHello")
 		trx              = maltego.Transform{}
 	)
 
-	f, path := openFile(pop3AuditRecords)
+	path = openFile(pop3AuditRecords)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -123,6 +120,8 @@ func TLSClientHelloTransform(count TLSClientHelloCountFunc, transform TLSClientH
 		log.Println("failed to close audit record file: ", err)
 	}
 
-	trx.AddUIMessage("completed!", maltego.UIMessageInform)
-	fmt.Println(trx.ReturnOutput())
+	if !continueTransform {
+		trx.AddUIMessage("completed!", maltego.UIMessageInform)
+		fmt.Println(trx.ReturnOutput())
+	}
 }
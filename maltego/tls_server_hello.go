@@ -19,14 +19,12 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // TLSServerHelloCountFunc is a function that counts something over multiple TLSServerHello audit records.
@@ -43,16 +41,15 @@ func TLSServerHelloTransform(count TLSServerHelloCountFunc, transform TLSServerH
 		lt               = maltego.ParseLocalArguments(os.Args[3:])
 		path             = lt.Values["path"]
 		ipaddr           = lt.Values[PropertyIpAddr]
-		dir              = filepath.Dir(path)
-		pop3AuditRecords = filepath.Join(dir, "TLSServerHello.ncap.gz")
+		pop3AuditRecords = auditRecordPath(path, "TLSServerHello")
 		trx              = maltego.Transform{}
 	)
 
-	f, path := openFile(pop3AuditRecords)
+	path = openFile(pop3AuditRecords)
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -47,18 +47,22 @@ var transforms = []*maltego.TransformCoreInfo{
 	{"ToHeaderValues", "netcap.HTTPHeader", "Retrieve values for a given header identifier"},
 	{"ToDHCP", "netcap.IPAddr", "Fetch DHCP options for host"},
 	{"ToDNSQuestions", "netcap.IPAddr", "Show all captured DNS questions for the selected host"},
+	{"ToDNSQueries", "netcap.IPAddr", "Show the domains queried by the selected host, with the query types and the number of answers"},
+	{"ToCredentials", "netcap.IPAddr", "Show the credentials used by or presented to the selected host, with masked passwords"},
 	{"ToDestinationIPs", "netcap.Device", "Get destination hosts seen for the selected device"},
 	{"ToSourceIPs", "netcap.Device", "Get all IPs that the device has been using"},
 
 	{"ToSourcePorts", "netcap.IPAddr", "Retrieve all source ports seen for the selected host address"},
 	{"ToDestinationPorts", "netcap.IPAddr", "Retrieve all destination ports seen for the selected host address"},
 	{"ToContactedPorts", "netcap.IPAddr", "Retrieve all ports contacted by the selected host address"},
+	{"ToOpenPorts", "netcap.IPAddr", "Show the ports the selected host address was seen serving on, with the packets and bytes per port"},
 
 	{"ToFileType", "netcap.File", "Retrieve file type via unix file util"},
-	{"ToFileTypes", "netcap.IPAddr", "Get all fille types for the selected IPAddr"},
+	{"ToFileTypes", "netcap.IPAddr", "Show the MIME types of the files extracted for the selected host, with the number of files and their total size"},
 	{"ToFiles", "netcap.IPAddr", "Get all files seen from the selected IP"},
 	{"ToFilesForContentType", "netcap.ContentType", "Get all files for a given content type"},
 	{"ToGeolocation", "netcap.IPAddr", "Retrieve the geolocation of an IP address"},
+	{"ToGeolocationMap", "netcap.IPProfileAuditRecords", "Show the locations of all external ip hosts and write them to a KML file"},
 	{"ToHTTPContentTypes", "netcap.IPAddr", "Show all HTTP Content Types seen for the selected host"},
 	{"ToHTTPCookies", "netcap.IPAddr", "Retrieve HTTP cookies"},
 	{"ToHTTPHosts", "netcap.IPAddr", "Retrieve all hostnames seen via HTTP for the selected host"},
@@ -67,7 +71,10 @@ var transforms = []*maltego.TransformCoreInfo{
 	{"ToHTTPServerNames", "netcap.IPAddr", "Retrieve the server names that have been contacted by the selected host"},
 	{"ToHTTPStatusCodes", "netcap.IPAddr", "Show all HTTP status codes observed for the selected host"},
 	{"ToHTTPUserAgents", "netcap.IPAddr", "Retrieve all HTTP user agents seen from the selected host"},
+	{"ToHostsWithUserAgent", "netcap.UserAgent", "Show all hosts that presented the selected HTTP user agent"},
+	{"ToHostsWithUserAgentSubstring", "netcap.UserAgent", "Show all hosts that presented an HTTP user agent containing the selected value"},
 	{"ToIncomingConnsFiltered", "netcap.IPAddr", "Show all incoming flows filtered against the configured whitelist"},
+	{"ToInternalCommGraph", "netcap.IPAddr", "Show the internal hosts that the selected internal host communicated with"},
 	{"ToMailAuthTokens", "netcap.IPAddr", "Retrieve POP3 auth tokens"},
 	{"ToMailFrom", "netcap.IPAddr", "Retrieve all email addresses from the 'From' field"},
 	{"ToMailTo", "netcap.IPAddr", "Retrieve all email addresses from the 'To' field"},
@@ -92,21 +99,22 @@ var transforms = []*maltego.TransformCoreInfo{
 	{"ToICMPV6ControlMessages", "netcap.ICMPv6AuditRecords", "Show ICMPv6 control messages"},
 	{"ToHosts", "netcap.POP3AuditRecords", "Show hosts that used the POP3 protocol"},
 
-	{"ToFileTypes", "netcap.FileAuditRecords", "Show MIME types for extracted files"},
+	{"ToFileTypes", "netcap.FileAuditRecords", "Show the MIME types of all extracted files, with the number of files and their total size"},
 	{"ToHTTPHostnames", "netcap.HTTPAuditRecords", "Show all visited website hostnames"},
 	{"ToIANAServices", "netcap.ConnectionAuditRecords", "Show all IANA services identified by the connection destination port"},
+	{"ToMACAddresses", "netcap.ConnectionAuditRecords", "Show all MAC addresses seen in connections with the vendor resolved from the OUI"},
 	{"ToLiveAuditRecords", "netcap.Interface", "Show current state of captured traffic"},
 	{"ToLoginInformation", "netcap.CredentialsAuditRecords", "Show captured login credentials"},
 	{"ToSoftwareProducts", "netcap.SoftwareAuditRecords", "Show software products and version information"},
-	{"ToSSHClients", "netcap.SSHAuditRecords", "Show detected SSH client"},
-	{"ToSSHServers", "netcap.SSHAuditRecords", "Show all SSH server software"},
+	{"ToSSHClients", "netcap.SSHAuditRecords", "Show the SSH client versions"},
+	{"ToSSHServers", "netcap.SSHAuditRecords", "Show the SSH server versions"},
 	{"ToSoftwareExploits", "netcap.ExploitAuditRecords", "Show potential exploits "},
 	{"ToSoftwareVulnerabilities", "netcap.VulnerabilityAuditRecords", "Show all discovered vulnerable software"},
 
 	{"ToDevices", "netcap.DeviceProfileAuditRecords", "Show all discovered device audit records"},
 	{"ToIPProfiles", "netcap.IPProfileAuditRecords", "Show all discovered ip hosts"},
 	{"ToIPProfilesForSoftware", "netcap.Software", "Show all ip hosts for the selected software"},
-	{"ToJA3Hashes", "netcap.TLSClientHelloAuditRecords", "Show all discovered ja3 client hashes"},
+	{"ToJA3Hashes", "netcap.TLSClientHelloAuditRecords", "Show the distinct ja3 client hashes with the resolved client and known malware fingerprints"},
 	{"ToJA3SHashes", "netcap.TLSServerHelloAuditRecords", "Show all discovered ja3 server hashes"},
 	{"ToSMTPCommandTypes", "netcap.SMTPAuditRecords", "Show all SMTP command types"},
 	{"ToDNSOpCodes", "netcap.DNSAuditRecords", "Show all DNS op codes"},
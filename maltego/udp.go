@@ -25,8 +25,8 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/dreadl0ck/maltego"
-	"github.com/dreadl0ck/netcap/defaults"
 	"github.com/dreadl0ck/netcap/types"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 // UDPCountFunc is a function that counts something over multiple UDP audit records.
@@ -47,10 +47,10 @@ func UDPTransform(count UDPCountFunc, transform UDPTransformationFunc, continueT
 	)
 
 	if !strings.HasPrefix(filepath.Base(path), "UDP.ncap") {
-		path = filepath.Join(filepath.Dir(path), "UDP.ncap.gz")
+		path = auditRecordPath(path, "UDP")
 	}
 
-	f, err := os.Open(path)
+	err := statFile(path)
 	if err != nil {
 		log.Println(err)
 		fmt.Println(trx.ReturnOutput())
@@ -58,8 +58,8 @@ func UDPTransform(count UDPCountFunc, transform UDPTransformationFunc, continueT
 	}
 
 	// check if its an audit record file
-	if !strings.HasSuffix(f.Name(), defaults.FileExtensionCompressed) && !strings.HasSuffix(f.Name(), defaults.FileExtension) {
-		maltego.Die(errUnexpectedFileType, f.Name())
+	if !utils.IsNetcapFile(path) {
+		maltego.Die(errUnexpectedFileType, path)
 	}
 
 	r := openNetcapArchive(path)
@@ -17,12 +17,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/dreadl0ck/maltego"
 
 	"github.com/dreadl0ck/netcap/defaults"
 	netio "github.com/dreadl0ck/netcap/io"
+	"github.com/dreadl0ck/netcap/utils"
 )
 
 const (
@@ -37,16 +39,67 @@ const (
 
 	// PropertyIpAddrLabel is the label for the ip address property
 	PropertyIpAddrLabel = "IPAddress"
+
+	// PropertyPeerIpAddr is the name of maltego property that contains the IP address of the remote host
+	PropertyPeerIpAddr = "peeraddr"
+
+	// PropertyPeerIpAddrLabel is the label for the remote ip address property
+	PropertyPeerIpAddrLabel = "Peer IPAddress"
 )
 
-func openFile(path string) (*os.File, string) {
+// auditRecordPath returns the path to the audit records of the given type,
+// that are stored next to the file at path. For members of a tar or zip bundle,
+// the path refers to the member with the requested type inside the same bundle.
+// The records are expected to use the same compression as the file at path.
+func auditRecordPath(path, typ string) string {
+	path = strings.TrimPrefix(path, "file://")
+
+	if archive, member, ok := netio.SplitBundlePath(path); ok {
+		return archive + netio.BundleSeparator + typ + auditRecordExtension(member, "")
+	}
+
+	base := filepath.Join(filepath.Dir(path), typ)
+
+	return base + auditRecordExtension(path, base)
+}
+
+// auditRecordExtension returns the extension of the audit record file at path.
+// If path is not an audit record file, the extension of the existing file for base is used,
+// with the gzip compressed extension as the default.
+func auditRecordExtension(path, base string) string {
+	if ext := utils.NetcapFileExtension(path); ext != "" {
+		return ext
+	}
+
+	if base != "" {
+		if existing, _, err := utils.StatNetcapFile(base); err == nil {
+			return utils.NetcapFileExtension(existing)
+		}
+	}
+
+	return defaults.FileExtensionCompressed
+}
+
+// statFile checks if the file at path exists.
+// For bundle members, the existence of the archive is checked.
+func statFile(path string) error {
+	if archive, _, ok := netio.SplitBundlePath(path); ok {
+		path = archive
+	}
+
+	_, err := os.Stat(path)
+
+	return err
+}
+
+func openFile(path string) string {
 	log.Println("open path:", path)
-	f, err := os.Open(path)
+	err := statFile(path)
 	if err != nil {
 
 		log.Println("failed to open path", err, "trying without .gz extension...")
 
-		f, err = os.Open(strings.TrimSuffix(path, ".gz"))
+		err = statFile(strings.TrimSuffix(path, ".gz"))
 		if err != nil {
 			log.Println("failed to open path", err)
 			trx := &maltego.Transform{}
@@ -56,10 +109,11 @@ func openFile(path string) (*os.File, string) {
 		}
 	}
 
-	return f, path
+	return path
 }
 
-func openNetcapArchive(path string) *netio.Reader {
+// openNetcapArchive opens the audit record file at path and dies on failure.
+func openNetcapArchive(path string) *auditRecordReader {
 	r, err := netio.Open(path, defaults.BufferSize)
 	if err != nil {
 
@@ -72,5 +126,5 @@ func openNetcapArchive(path string) *netio.Reader {
 		}
 	}
 
-	return r
+	return newAuditRecordReader(r, path)
 }
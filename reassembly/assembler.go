@@ -42,8 +42,19 @@ type assemblerOptions struct {
 	// particular connection, the smallest sequence number will be flushed, along
 	// with any contiguous data.  If <= 0, this is ignored.
 	MaxBufferedPagesPerConnection int
+	// CoalesceWindow enables burst coalescing for the Flush* methods. The
+	// throughput of each connection is tracked over windows of this duration,
+	// and connections that have been busy recently get their flush and close
+	// deadlines extended by up to one window, proportionally to their
+	// throughput. This prevents splitting bursty transfers during short idle
+	// gaps. If <= 0, this is ignored.
+	CoalesceWindow time.Duration
 }
 
+// coalesceFullRate is the throughput in bytes per second at which a connection
+// gets the full CoalesceWindow added to its flush and close deadlines.
+const coalesceFullRate = 128 * 1024
+
 // Assembler handles reassembling TCP streams.  It is not safe for
 // concurrency... after passing a packet in via the assemble call, the caller
 // must wait for that call to return before calling assemble again.  Callers can
@@ -212,6 +223,10 @@ func (a *Assembler) AssembleWithContext(netFlow gopacket.Flow, t *layers.TCP, ac
 	}
 	half.flow = netFlow
 
+	if a.CoalesceWindow > 0 {
+		conn.activity.track(ac.GetCaptureInfo().Timestamp, len(t.Payload), a.CoalesceWindow)
+	}
+
 	// fmt.Println(netFlow, len(t.Payload), ansi.Yellow, ac.GetCaptureInfo().Timestamp.Format(tf), ansi.Green, half.firstSeen.Format(tf), ansi.Blue, half.lastSeen.Format(tf), ansi.Reset)
 
 	a.start = half.nextSeq == invalidSequence && t.SYN
@@ -222,7 +237,7 @@ func (a *Assembler) AssembleWithContext(netFlow gopacket.Flow, t *layers.TCP, ac
 		}
 	}
 
-	if !half.stream.Accept(t, half.dir, half.nextSeq) {
+	if !half.stream.Accept(t, ac.GetCaptureInfo(), half.dir, half.nextSeq) {
 		if Debug {
 			log.Printf("Ignoring packet")
 		}
@@ -230,6 +245,10 @@ func (a *Assembler) AssembleWithContext(netFlow gopacket.Flow, t *layers.TCP, ac
 		return
 	}
 
+	if r, ok := half.stream.(PacketRecorder); ok {
+		r.RecordPacket(ac, half.dir)
+	}
+
 	if half.closed {
 		// this way is closed
 		if Debug {
@@ -363,6 +382,11 @@ func (a *Assembler) checkOverlap(half *halfconnection, queue bool, ac AssemblerC
 			diffEnd   = end.difference(curEnd)
 		)
 
+		// the segment is completely covered by data that is already queued
+		if diffStart <= 0 && diffEnd >= 0 && len(bytes) > 0 {
+			half.retransmittedSegments++
+		}
+
 		// end > cur.end && start < cur.start: drop (3)
 		if diffEnd <= 0 && diffStart >= 0 {
 			if Debug {
@@ -540,6 +564,10 @@ func (a *Assembler) overlapExisting(half *halfconnection, start Sequence, bytes
 	if s >= e {
 		// Completely included in sent
 		s = e
+
+		if e != 0 {
+			half.retransmittedSegments++
+		}
 	}
 	bytes = bytes[s:]
 
@@ -594,6 +622,8 @@ func (a *Assembler) setStatsToSG(half *halfconnection) {
 	half.overlapBytes = 0
 	a.cacheSG.overlapPackets = half.overlapPackets
 	half.overlapPackets = 0
+	a.cacheSG.retransmittedSegments = half.retransmittedSegments
+	half.retransmittedSegments = 0
 }
 
 // Build the ScatterGather object, i.e. prepend saved bytes and
@@ -788,7 +818,7 @@ func (a *Assembler) sendToConnection(conn *connection, half *halfconnection) Seq
 	a.cleanSG(half, ac)
 
 	if end {
-		a.closeHalfConnection(conn, half, "END signal received (FIN or RST flag)")
+		a.closeHalfConnection(conn, half, ReasonEndSignal)
 	}
 
 	if Debug {
@@ -887,7 +917,7 @@ func (a *Assembler) skipFlush(conn *connection, half *halfconnection) {
 	// Well, it's embarrassing it there is still something in half.saved
 	// FIXME: change API to give back saved + new/no packets
 	if half.first == nil {
-		a.closeHalfConnection(conn, half, "no bytes saved")
+		a.closeHalfConnection(conn, half, ReasonNoBytesSaved)
 
 		return
 	}
@@ -957,6 +987,8 @@ func (a *Assembler) addNextFromConn(conn *halfconnection) {
 }
 
 // FlushOptions provide options for flushing connections.
+// When the assembler has a CoalesceWindow configured, T and TC are moved
+// back in time for connections that had a high throughput recently.
 type FlushOptions struct {
 	T  time.Time // If nonzero, only connections with data older than T are flushed
 	TC time.Time // If nonzero, only connections with data older than TC are closed (if no FIN/RST received)
@@ -991,12 +1023,26 @@ func (a *Assembler) FlushWithOptions(opt FlushOptions) (flushed, closed int) {
 	)
 
 	for _, conn := range conns {
-		remove := false
+		var (
+			remove = false
+			t, tc  = opt.T, opt.TC
+		)
 
 		conn.mu.Lock()
 
+		if a.CoalesceWindow > 0 {
+			extension := conn.activity.extension(conn.lastSeen(), a.CoalesceWindow)
+			if !t.IsZero() {
+				t = t.Add(-extension)
+			}
+
+			if !tc.IsZero() {
+				tc = tc.Add(-extension)
+			}
+		}
+
 		for _, half := range []*halfconnection{&conn.s2c, &conn.c2s} {
-			isFlushed, isClosed := a.flushClose(conn, half, opt.T, opt.TC)
+			isFlushed, isClosed := a.flushClose(conn, half, t, tc)
 			if isFlushed {
 				flushes++
 			}
@@ -1006,7 +1052,7 @@ func (a *Assembler) FlushWithOptions(opt FlushOptions) (flushed, closed int) {
 			}
 		}
 
-		if conn.s2c.closed && conn.c2s.closed && conn.s2c.lastSeen.Before(opt.TC) && conn.c2s.lastSeen.Before(opt.TC) {
+		if conn.s2c.closed && conn.c2s.closed && conn.s2c.lastSeen.Before(tc) && conn.c2s.lastSeen.Before(tc) {
 			remove = true
 		}
 		conn.mu.Unlock()
@@ -1115,7 +1161,7 @@ func (a *Assembler) closeConn(conn *connection) {
 		}
 
 		if !half.closed {
-			a.closeHalfConnection(conn, half, "force-flushed")
+			a.closeHalfConnection(conn, half, ReasonForceFlushed)
 		}
 	}
 	conn.mu.Unlock()
@@ -16,6 +16,50 @@ type connection struct {
 
 	ac        assemblerSimpleContext
 	firstFlow gopacket.Flow
+
+	activity activity
+}
+
+// activity tracks the payload bytes of a connection in consecutive windows,
+// to estimate its recent throughput for burst coalescing.
+type activity struct {
+	start time.Time
+	bytes int // bytes in the current window
+	prev  int // bytes in the preceding window
+}
+
+// track adds n payload bytes seen at ts.
+func (a *activity) track(ts time.Time, n int, window time.Duration) {
+	switch {
+	case a.start.IsZero() || ts.Sub(a.start) >= 2*window:
+		// first packet, or idle for more than a full window
+		a.start, a.bytes, a.prev = ts, 0, 0
+	case ts.Sub(a.start) >= window:
+		a.start, a.bytes, a.prev = a.start.Add(window), 0, a.bytes
+	}
+
+	a.bytes += n
+}
+
+// extension returns how long the flush and close deadlines should be extended
+// for a connection last seen at lastSeen. The extension grows with the
+// throughput over the last two windows, up to a single window.
+func (a *activity) extension(lastSeen time.Time, window time.Duration) time.Duration {
+	if a.start.IsZero() {
+		return 0
+	}
+
+	elapsed := lastSeen.Sub(a.start.Add(-window))
+	if elapsed < window {
+		elapsed = window
+	}
+
+	rate := float64(a.prev+a.bytes) / elapsed.Seconds()
+	if rate >= coalesceFullRate {
+		return window
+	}
+
+	return time.Duration(float64(window) * rate / coalesceFullRate)
 }
 
 func (c *connection) reset(k *key, s Stream, ts time.Time) {
@@ -31,6 +75,7 @@ func (c *connection) reset(k *key, s Stream, ts time.Time) {
 	}
 	c.c2s, c.s2c = base, base
 	c.c2s.dir, c.s2c.dir = TCPDirClientToServer, TCPDirServerToClient
+	c.activity = activity{}
 	c.mu.Unlock()
 }
 
@@ -25,9 +25,10 @@ type halfconnection struct {
 	pages        int // Number of pages used (both in first/last and saved)
 
 	// for stats
-	queuedBytes   int
-	queuedPackets int
-	closed        bool
+	queuedBytes           int
+	queuedPackets         int
+	retransmittedSegments int
+	closed                bool
 
 	dir TCPFlowDirection
 }
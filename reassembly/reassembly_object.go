@@ -18,6 +18,8 @@ type reassemblyObject struct {
 	overlapBytes   int
 	overlapPackets int
 
+	retransmittedSegments int
+
 	sync.Mutex
 }
 
@@ -99,5 +101,7 @@ func (rl *reassemblyObject) Stats() TCPAssemblyStats {
 		QueuedPackets:  rl.queuedPackets,
 		OverlapBytes:   rl.overlapBytes,
 		OverlapPackets: rl.overlapPackets,
+
+		RetransmittedSegments: rl.retransmittedSegments,
 	}
 }
@@ -15,7 +15,7 @@ import (
 //    3) Call ReassemblyComplete one time, after which the stream is dereferenced by assembly.
 type Stream interface {
 	// Accept tells whether the TCP packet should be accepted, start could be modified to force a start even if no SYN have been seen
-	Accept(tcp *layers.TCP, dir TCPFlowDirection, nextSeq Sequence) bool
+	Accept(tcp *layers.TCP, ci gopacket.CaptureInfo, dir TCPFlowDirection, nextSeq Sequence) bool
 
 	// ReassembledSG is called zero or more times.
 	// ScatterGather is reused after each Reassembled call,
@@ -33,6 +33,25 @@ type Stream interface {
 	ReassemblyComplete(ac AssemblerContext, firstFlow gopacket.Flow, reason string) bool
 }
 
+// PacketRecorder can optionally be implemented by a Stream,
+// to receive the AssemblerContext of every packet it has accepted.
+// This allows to keep the original packets of a connection.
+type PacketRecorder interface {
+	RecordPacket(ac AssemblerContext, dir TCPFlowDirection)
+}
+
+// Reasons passed to ReassemblyComplete.
+const (
+	// ReasonEndSignal indicates that the stream was closed by a FIN or RST packet.
+	ReasonEndSignal = "END signal received (FIN or RST flag)"
+
+	// ReasonNoBytesSaved indicates that the stream was flushed due to inactivity and had no data left.
+	ReasonNoBytesSaved = "no bytes saved"
+
+	// ReasonForceFlushed indicates that the stream was closed when flushing all connections.
+	ReasonForceFlushed = "force-flushed"
+)
+
 // streamFactory is used by assembly to create a new stream for each
 // new TCP session.
 type streamFactory interface {
@@ -6,6 +6,8 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/dreadl0ck/gopacket"
 )
 
 /*
@@ -144,6 +146,18 @@ func (p *StreamPool) getHalf(k *key) (*connection, *halfconnection, *halfconnect
 	return nil, nil, nil
 }
 
+// Contains returns whether the pool holds a connection for the given flows, in either direction.
+func (p *StreamPool) Contains(netFlow, tcpFlow gopacket.Flow) bool {
+	k := key{netFlow, tcpFlow}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	conn, _, _ := p.getHalf(&k)
+
+	return conn != nil
+}
+
 // getConnection returns a connection.  If end is true and a connection
 // does not already exist, returns nil.  This allows us to check for a
 // connection without actually creating one if it doesn't already exist.
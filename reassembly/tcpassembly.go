@@ -43,6 +43,8 @@ type TCPAssemblyStats struct {
 	QueuedPackets  int
 	OverlapBytes   int
 	OverlapPackets int
+	// Segments whose sequence range has been received completely before
+	RetransmittedSegments int
 }
 
 // ScatterGather is used to pass reassembled data and metadata of reassembled
@@ -51,7 +51,7 @@ func (t *testFactoryBench) New(gopacket.Flow, gopacket.Flow, AssemblerContext) S
 	return t
 }
 
-func (t *testFactoryBench) Accept(*layers.TCP, TCPFlowDirection, Sequence) bool {
+func (t *testFactoryBench) Accept(*layers.TCP, gopacket.CaptureInfo, TCPFlowDirection, Sequence) bool {
 	return true
 }
 
@@ -93,7 +93,7 @@ func (t *testFactory) ReassemblyComplete(AssemblerContext, gopacket.Flow, string
 	return true
 }
 
-func (t *testFactory) Accept(*layers.TCP, TCPFlowDirection, Sequence) bool {
+func (t *testFactory) Accept(*layers.TCP, gopacket.CaptureInfo, TCPFlowDirection, Sequence) bool {
 	return true
 }
 
@@ -106,7 +106,7 @@ func (tf *testMemoryFactory) New(_, _ gopacket.Flow, _ AssemblerContext) Stream
 	return tf
 }
 
-func (tf *testMemoryFactory) Accept(*layers.TCP, TCPFlowDirection, Sequence) bool {
+func (tf *testMemoryFactory) Accept(*layers.TCP, gopacket.CaptureInfo, TCPFlowDirection, Sequence) bool {
 	return true
 }
 
@@ -964,7 +964,7 @@ func (tkf *testKeepFactory) ReassemblyComplete(AssemblerContext, gopacket.Flow,
 	return true
 }
 
-func (tkf *testKeepFactory) Accept(*layers.TCP, TCPFlowDirection, Sequence) bool {
+func (tkf *testKeepFactory) Accept(*layers.TCP, gopacket.CaptureInfo, TCPFlowDirection, Sequence) bool {
 	return true
 }
 
@@ -1013,6 +1013,46 @@ func testKeep(t *testing.T, s []testKeepSequence) {
 	}
 }
 
+func TestFlushCoalesceBurst(t *testing.T) {
+	for _, tt := range []struct {
+		window  time.Duration
+		payload int
+		flushed int
+	}{
+		{window: 0, payload: 256 * 1024, flushed: 1},           // coalescing disabled
+		{window: time.Second, payload: 256 * 1024, flushed: 0}, // busy connection is kept
+		{window: time.Second, payload: 1024, flushed: 1},       // low throughput is flushed
+	} {
+		var (
+			fact  = &testFactory{}
+			a     = NewAssembler(NewStreamPool(fact))
+			start = time.Unix(0, 0)
+		)
+
+		a.CoalesceWindow = tt.window
+
+		for _, p := range []struct {
+			tcp    layers.TCP
+			offset time.Duration
+		}{
+			{tcp: layers.TCP{SrcPort: 1, DstPort: 2, Seq: 1000, SYN: true, BaseLayer: layers.BaseLayer{Payload: []byte{}}}},
+			{tcp: layers.TCP{SrcPort: 1, DstPort: 2, Seq: 1001, BaseLayer: layers.BaseLayer{Payload: make([]byte, tt.payload)}}},
+			// out of order segment after a gap, waits for the missing bytes
+			{tcp: layers.TCP{SrcPort: 1, DstPort: 2, Seq: uint32(1001 + tt.payload + 100), BaseLayer: layers.BaseLayer{Payload: []byte{1, 2, 3}}}, offset: 10 * time.Millisecond},
+		} {
+			ctx := assemblerSimpleContext(gopacket.CaptureInfo{Timestamp: start.Add(p.offset)})
+			a.AssembleWithContext(netFlow, &p.tcp, &ctx)
+		}
+
+		ref := start.Add(500 * time.Millisecond)
+
+		flushed, _ := a.FlushWithOptions(FlushOptions{T: ref, TC: ref})
+		if flushed != tt.flushed {
+			t.Errorf("window %v, payload %d: expected %d flushed, got %d", tt.window, tt.payload, tt.flushed, flushed)
+		}
+	}
+}
+
 func TestKeepSimpleOnBoundary(t *testing.T) {
 	testKeep(t, []testKeepSequence{
 		{
@@ -1233,7 +1273,7 @@ func (t *testFSMFactory) ReassemblyComplete(AssemblerContext, gopacket.Flow, str
 	return false
 }
 
-func (t *testFSMFactory) Accept(tcp *layers.TCP, dir TCPFlowDirection, _ Sequence) bool {
+func (t *testFSMFactory) Accept(tcp *layers.TCP, _ gopacket.CaptureInfo, dir TCPFlowDirection, _ Sequence) bool {
 	ok := t.fsm.CheckState(tcp, dir)
 	if ok {
 		t.nb++
@@ -1948,3 +1988,117 @@ func TestReassemblyObjectCaptureInfo(t *testing.T) {
 		}
 	}
 }
+
+/* For retransmission checks: sums the retransmitted segments */
+type testRetransmissionFactory struct {
+	testFactory
+	retransmitted int
+}
+
+func (tf *testRetransmissionFactory) New(gopacket.Flow, gopacket.Flow, AssemblerContext) Stream {
+	return tf
+}
+
+func (tf *testRetransmissionFactory) ReassembledSG(sg ScatterGather, _ AssemblerContext) {
+	tf.retransmitted += sg.Stats().RetransmittedSegments
+}
+
+func TestRetransmittedSegments(t *testing.T) {
+	var (
+		fact = &testRetransmissionFactory{}
+		a    = NewAssembler(NewStreamPool(fact))
+	)
+
+	for _, tcp := range []layers.TCP{
+		{SYN: true, Seq: 1000, BaseLayer: layers.BaseLayer{Payload: []byte{1, 2, 3, 4, 5, 6, 7}}},
+		// already delivered
+		{Seq: 1003, BaseLayer: layers.BaseLayer{Payload: []byte{3, 4, 5}}},
+		// partial overlap is not a retransmission
+		{Seq: 1006, BaseLayer: layers.BaseLayer{Payload: []byte{6, 7, 8, 9}}},
+		// out of order, queued
+		{Seq: 1020, BaseLayer: layers.BaseLayer{Payload: []byte{20, 21, 22}}},
+		// covered by the queued data
+		{Seq: 1021, BaseLayer: layers.BaseLayer{Payload: []byte{21}}},
+		// fill the gap
+		{Seq: 1010, BaseLayer: layers.BaseLayer{Payload: []byte{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}}},
+	} {
+		tcp.SrcPort = 1
+		tcp.DstPort = 2
+		a.assemble(netFlow, &tcp)
+	}
+
+	if fact.retransmitted != 2 {
+		t.Fatal("expected 2 retransmitted segments, got", fact.retransmitted)
+	}
+}
+
+/* For PacketRecorder: count the recorded packets per direction, packets with the URG flag are rejected */
+type testRecorderFactory struct {
+	recorded map[TCPFlowDirection]int
+}
+
+func (t *testRecorderFactory) New(gopacket.Flow, gopacket.Flow, AssemblerContext) Stream {
+	return t
+}
+
+func (t *testRecorderFactory) ReassembledSG(ScatterGather, AssemblerContext) {
+}
+
+func (t *testRecorderFactory) ReassemblyComplete(AssemblerContext, gopacket.Flow, string) bool {
+	return false
+}
+
+func (t *testRecorderFactory) Accept(tcp *layers.TCP, _ gopacket.CaptureInfo, _ TCPFlowDirection, _ Sequence) bool {
+	return !tcp.URG
+}
+
+func (t *testRecorderFactory) RecordPacket(_ AssemblerContext, dir TCPFlowDirection) {
+	t.recorded[dir]++
+}
+
+func TestPacketRecorder(t *testing.T) {
+	var (
+		fact = &testRecorderFactory{recorded: map[TCPFlowDirection]int{}}
+		a    = NewAssembler(NewStreamPool(fact))
+	)
+
+	for _, seq := range []testFSMSequence{
+		{tcp: layers.TCP{SYN: true, SrcPort: 54842, DstPort: 53, Seq: 374511116}},
+		{tcp: layers.TCP{SYN: true, ACK: true, SrcPort: 53, DstPort: 54842, Seq: 3465787765, Ack: 374511117}},
+		{tcp: layers.TCP{ACK: true, SrcPort: 54842, DstPort: 53, Seq: 374511117, Ack: 3465787766}},
+		{tcp: layers.TCP{ACK: true, URG: true, SrcPort: 53, DstPort: 54842, Seq: 3465787766, Ack: 374511117}},
+	} {
+		flow := netFlow
+		if seq.tcp.SrcPort == 53 {
+			flow = flow.Reverse()
+		}
+
+		seq.tcp.SetInternalPortsForTesting()
+		a.AssembleWithContext(flow, &seq.tcp, &seq)
+	}
+
+	// the rejected packet must not be recorded
+	if fact.recorded[TCPDirClientToServer] != 2 || fact.recorded[TCPDirServerToClient] != 1 {
+		t.Fatal("unexpected recorded packets", fact.recorded)
+	}
+}
+
+func TestStreamPoolContains(t *testing.T) {
+	var (
+		p   = NewStreamPool(&testRecorderFactory{recorded: map[TCPFlowDirection]int{}})
+		a   = NewAssembler(p)
+		seq = testFSMSequence{tcp: layers.TCP{SYN: true, SrcPort: 54842, DstPort: 53, Seq: 374511116}}
+	)
+
+	seq.tcp.SetInternalPortsForTesting()
+
+	if p.Contains(netFlow, seq.tcp.TransportFlow()) {
+		t.Fatal("connection must not exist before the first packet")
+	}
+
+	a.AssembleWithContext(netFlow, &seq.tcp, &seq)
+
+	if !p.Contains(netFlow, seq.tcp.TransportFlow()) || !p.Contains(netFlow.Reverse(), seq.tcp.TransportFlow().Reverse()) {
+		t.Fatal("connection must be found for both directions")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"strconv"
 	"sync"
 
 	"github.com/oschwald/maxminddb-golang"
@@ -25,6 +26,7 @@ import (
 
 var (
 	geolocations sync.Map
+	asns         sync.Map
 	cityReader   *maxminddb.Reader
 	asnReader    *maxminddb.Reader
 	logger       = logrus.New()
@@ -38,6 +40,10 @@ type geoRecord struct {
 	Country struct {
 		ISOCode string `maxminddb:"iso_code"`
 	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
 	ASN struct {
 		Organization string `maxminddb:"autonomous_system_organization"`
 		Number       int64  `maxminddb:"autonomous_system_number"`
@@ -67,7 +73,7 @@ func initAsnReader() (err error) {
 	return
 }
 
-func (record geoRecord) repr() (geoloc, asn string) {
+func (record geoRecord) repr() (geoloc, asn string, latitude, longitude float64) {
 	geoloc = record.Country.ISOCode
 	if city, ok := record.City.Names["en"]; ok {
 		geoloc += fmt.Sprintf(" (%s)", city)
@@ -75,24 +81,25 @@ func (record geoRecord) repr() (geoloc, asn string) {
 	if record.ASN.Number > 0 {
 		asn = fmt.Sprintf("ASN %d (%s)", record.ASN.Number, record.ASN.Organization)
 	}
-	return
+	return geoloc, asn, record.Location.Latitude, record.Location.Longitude
 }
 
-// LookupGeolocation returns all associated geolocations for a given address and db handle
+// LookupGeolocation returns all associated geolocations for a given address and db handle,
+// as well as the approximate coordinates of the address. The coordinates are zero if they are unknown.
 // results are being cached in an atomic map to avoid unnecessary lookups.
-func LookupGeolocation(addr string) (string, string) {
+func LookupGeolocation(addr string) (geoloc, asn string, latitude, longitude float64) {
 	if asnReader == nil || cityReader == nil {
-		return "", ""
+		return
 	}
 	if len(addr) == 0 {
-		return "", ""
+		return
 	}
 
 	ip := net.ParseIP(addr)
 	if ip == nil {
 		logger.WithField("addr", addr).Error("invalid IP")
 
-		return "", ""
+		return
 	}
 
 	if result, ok := geolocations.Load(ip.String()); ok {
@@ -104,7 +111,7 @@ func LookupGeolocation(addr string) (string, string) {
 	if err != nil {
 		logger.WithError(err).Error("failed to lookup city")
 
-		return "", ""
+		return
 	}
 
 	err = asnReader.Lookup(ip, &record.ASN)
@@ -116,3 +123,58 @@ func LookupGeolocation(addr string) (string, string) {
 
 	return record.repr()
 }
+
+// asnRecord is the autonomous system that announces the network of an address.
+type asnRecord struct {
+	ASN struct {
+		Number       int64  `maxminddb:"autonomous_system_number"`
+		Organization string `maxminddb:"autonomous_system_organization"`
+	}
+	Prefix string
+}
+
+// LookupASN returns the number and organization of the autonomous system for a given address,
+// as well as the announced network prefix that contains the address.
+// Empty strings are returned if the ASN database is not available or the address is unknown.
+// Results are being cached in an atomic map to avoid unnecessary lookups.
+func LookupASN(addr string) (asn, org, prefix string) {
+	if asnReader == nil || len(addr) == 0 {
+		return "", "", ""
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		logger.WithField("addr", addr).Error("invalid IP")
+
+		return "", "", ""
+	}
+
+	if result, ok := asns.Load(ip.String()); ok {
+		return result.(asnRecord).repr()
+	}
+
+	record := asnRecord{}
+
+	network, ok, err := asnReader.LookupNetwork(ip, &record.ASN)
+	if err != nil {
+		logger.WithError(err).Error("failed to lookup asn")
+
+		return "", "", ""
+	}
+
+	if ok && network != nil {
+		record.Prefix = network.String()
+	}
+
+	asns.Store(ip.String(), record)
+
+	return record.repr()
+}
+
+func (record asnRecord) repr() (asn, org, prefix string) {
+	if record.ASN.Number > 0 {
+		asn = strconv.FormatInt(record.ASN.Number, 10)
+	}
+
+	return asn, record.ASN.Organization, record.Prefix
+}
@@ -0,0 +1,147 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package resolvers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// mmdbString encodes a string for the MaxMind DB data section.
+func mmdbString(s string) []byte {
+	if len(s) < 29 {
+		return append([]byte{2<<5 | byte(len(s))}, s...)
+	}
+
+	return append([]byte{2<<5 | 29, byte(len(s) - 29)}, s...)
+}
+
+// mmdbUint32 encodes an unsigned integer for the MaxMind DB data section.
+func mmdbUint32(v uint32) []byte {
+	return []byte{6<<5 | 4, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+// mmdbMap encodes a map with the given keys and already encoded values.
+func mmdbMap(pairs ...interface{}) []byte {
+	b := []byte{7<<5 | byte(len(pairs)/2)}
+
+	for i := 0; i < len(pairs); i += 2 {
+		b = append(b, mmdbString(pairs[i].(string))...)
+		b = append(b, pairs[i+1].([]byte)...)
+	}
+
+	return b
+}
+
+// newTestASNReader builds an IPv4 MaxMind DB that maps 10.0.0.0/8 to AS64512.
+func newTestASNReader(t *testing.T) *maxminddb.Reader {
+	const (
+		// one node per bit of the prefix
+		nodeCount = 8
+		prefix    = 10
+		// records pointing to the node count are empty,
+		// records pointing beyond the search tree and the separator reference the data section
+		empty = nodeCount
+		data  = nodeCount + 16
+	)
+
+	var buf bytes.Buffer
+
+	record := func(v int) {
+		buf.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+	}
+
+	for i := 0; i < nodeCount; i++ {
+		next := i + 1
+		if next == nodeCount {
+			next = data
+		}
+
+		if prefix>>(7-i)&1 == 0 {
+			record(next)
+			record(empty)
+		} else {
+			record(empty)
+			record(next)
+		}
+	}
+
+	// data section separator
+	buf.Write(make([]byte, 16))
+
+	buf.Write(mmdbMap(
+		"autonomous_system_number", mmdbUint32(64512),
+		"autonomous_system_organization", mmdbString("Example Org"),
+	))
+
+	buf.WriteString("\xab\xcd\xefMaxMind.com")
+	buf.Write(mmdbMap(
+		"binary_format_major_version", mmdbUint32(2),
+		"binary_format_minor_version", mmdbUint32(0),
+		"database_type", mmdbString("GeoLite2-ASN"),
+		"ip_version", mmdbUint32(4),
+		"node_count", mmdbUint32(nodeCount),
+		"record_size", mmdbUint32(24),
+	))
+
+	r, err := maxminddb.FromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestLookupASN(t *testing.T) {
+	if asn, org, prefix := LookupASN("10.1.2.3"); asn != "" || org != "" || prefix != "" {
+		t.Fatal("expected empty result without database, got:", asn, org, prefix)
+	}
+
+	asnReader = newTestASNReader(t)
+
+	defer func() {
+		asnReader = nil
+	}()
+
+	tests := []struct {
+		addr   string
+		asn    string
+		org    string
+		prefix string
+	}{
+		{"10.1.2.3", "64512", "Example Org", "10.0.0.0/8"},
+		{"10.255.255.255", "64512", "Example Org", "10.0.0.0/8"},
+		{"::ffff:10.0.0.1", "64512", "Example Org", "10.0.0.0/8"},
+		{"11.1.2.3", "", "", ""},
+		{"2001:db8::1", "", "", ""},
+		{"invalid", "", "", ""},
+		{"", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		// the second lookup is served from the cache
+		for i := 0; i < 2; i++ {
+			asn, org, prefix := LookupASN(tt.addr)
+			if asn != tt.asn || org != tt.org || prefix != tt.prefix {
+				t.Fatal("unexpected result for", tt.addr, ":", asn, org, prefix, "expected:", tt.asn, tt.org, tt.prefix)
+			}
+		}
+	}
+
+	if _, ok := asns.Load("10.1.2.3"); !ok {
+		t.Fatal("expected the result to be cached")
+	}
+}
@@ -55,8 +55,8 @@ func LookupJa3(hash string) string {
 	return ""
 }
 
-// initJa3Resolver loads the JSON mac DB into a map in memory.
-func initJa3Resolver() {
+// InitJa3Resolver loads the JSON ja3 DBs into a map in memory.
+func InitJa3Resolver() {
 	// read database dir
 	files, err := ioutil.ReadDir(DataBaseFolderPath)
 	if err != nil {
@@ -0,0 +1,75 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package resolvers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ja3MalwareDBFile is the JA3 fingerprint blacklist from the abuse.ch SSL blacklist project.
+// Each line contains the hash, the first and last time it was seen, and the malware it is associated with:
+// ja3_md5,Firstseen,Lastseen,Listingreason
+const ja3MalwareDBFile = "ja3-malware.csv"
+
+// maps ja3 hashes to the listing reason
+var ja3MalwareDB = make(map[string]string)
+
+// InitJa3MalwareDB loads the JA3 fingerprints of known malware into memory.
+// The database is optional, if it is missing no hashes will be flagged.
+func InitJa3MalwareDB() {
+	data, err := ioutil.ReadFile(filepath.Join(DataBaseFolderPath, ja3MalwareDBFile))
+	if err != nil {
+		resolverLog.Info("ja3 malware database not loaded", zap.Error(err))
+
+		return
+	}
+
+	parseJa3Malware(data)
+
+	if !quiet {
+		resolverLog.Info("loaded ja3 malware fingerprints", zap.Int("total", len(ja3MalwareDB)))
+	}
+}
+
+func parseJa3Malware(data []byte) {
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+
+		// ignore comments
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		parts := strings.Split(string(line), ",")
+		if len(parts) != 4 {
+			continue
+		}
+
+		ja3MalwareDB[strings.ToLower(parts[0])] = strings.TrimSpace(parts[3])
+	}
+}
+
+// LookupJa3Malware returns the name of the malware associated with a JA3 hash,
+// and whether the hash is contained in the blacklist.
+// Like for LookupJa3, access to the underlying map is not locked.
+func LookupJa3Malware(hash string) (string, bool) {
+	reason, ok := ja3MalwareDB[hash]
+
+	return reason, ok
+}
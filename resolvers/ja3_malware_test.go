@@ -0,0 +1,42 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package resolvers
+
+import (
+	"testing"
+)
+
+func TestJa3MalwareResolver(t *testing.T) {
+	parseJa3Malware([]byte(`################################################################
+# abuse.ch SSLBL JA3 Fingerprint Blacklist (CSV)               #
+################################################################
+#
+# ja3_md5,Firstseen,Lastseen,Listingreason
+0123456789ABCDEF0123456789abcdef,2017-07-14 18:08:15,2019-07-27 20:42:54,Dridex
+fedcba9876543210fedcba9876543210,2017-07-14 19:18:25,2019-07-27 20:07:11,TrickBot
+invalid
+`))
+
+	if reason, ok := LookupJa3Malware("0123456789abcdef0123456789abcdef"); !ok || reason != "Dridex" {
+		t.Fatal("expected Dridex but got: ", reason)
+	}
+
+	if reason, ok := LookupJa3Malware("fedcba9876543210fedcba9876543210"); !ok || reason != "TrickBot" {
+		t.Fatal("expected TrickBot but got: ", reason)
+	}
+
+	if _, ok := LookupJa3Malware("5ef08bc989a9fcc18d5011f07d953c14"); ok {
+		t.Fatal("expected no match for a hash that is not blacklisted")
+	}
+}
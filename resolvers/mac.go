@@ -15,13 +15,17 @@ package resolvers
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 )
@@ -47,16 +51,29 @@ type macSummary struct {
 	CountryCode string `json:"countryCode"`
 }
 
-var macDB = make(map[string]macSummary)
+var (
+	// macDB maps the hex digits of the assigned address blocks to their summary.
+	macDB = make(map[string]macSummary)
 
-// initMACResolver loads the JSON mac DB into a map in memory.
-func initMACResolver() {
+	// vendors caches the lookup results for MAC addresses.
+	vendors sync.Map
+)
+
+// prefixLengths are the number of hex digits of the IEEE assignments,
+// ordered from the most specific MA-S (36 bit), MA-M (28 bit) to MA-L (24 bit).
+var prefixLengths = []int{9, 7, 6}
+
+// ieeeRegistries are the CSV exports of the IEEE registration authority for the MA-L, MA-M and MA-S assignments.
+// http://standards-oui.ieee.org/oui/oui.csv
+var ieeeRegistries = []string{"oui.csv", "mam.csv", "oui36.csv"}
+
+// InitMACResolver loads the JSON mac DB and the IEEE registries into a map in memory.
+func InitMACResolver() {
 	var sums int
 
 	data, err := ioutil.ReadFile(filepath.Join(DataBaseFolderPath, "macaddress.io-db.json"))
 	if err != nil {
 		log.Println(err)
-		return
 	}
 
 	for _, line := range bytes.Split(data, []byte{'\n'}) {
@@ -73,9 +90,17 @@ func initMACResolver() {
 			continue
 		}
 
-		macDB[sum.OUI] = sum
+		macDB[normalizeMAC(sum.OUI)] = sum
 		sums++
 	}
+
+	for _, name := range ieeeRegistries {
+		sums += loadIEEERegistry(name)
+	}
+
+	// discard results of lookups before the databases were loaded
+	vendors = sync.Map{}
+
 	if !quiet {
 		resolverLog.Info("loaded OUI summaries",
 			zap.Int("total", sums),
@@ -83,17 +108,123 @@ func initMACResolver() {
 	}
 }
 
+// loadIEEERegistry loads the assignments from an IEEE CSV export, if present in the database folder.
+// Blocks that are already known from the macaddress.io database are not overwritten.
+func loadIEEERegistry(name string) int {
+	f, err := os.Open(filepath.Join(DataBaseFolderPath, name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println(err)
+		}
+
+		return 0
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		log.Println("failed to read", name, err)
+
+		return 0
+	}
+
+	var num int
+
+	// Registry,Assignment,Organization Name,Organization Address
+	for _, r := range records {
+		if len(r) < 3 || r[0] == "Registry" {
+			continue
+		}
+
+		assignment := normalizeMAC(r[1])
+		if assignment == "" {
+			continue
+		}
+
+		if _, ok := macDB[assignment]; ok {
+			continue
+		}
+
+		macDB[assignment] = macSummary{
+			OUI:         r[1],
+			IsPrivate:   r[2] == "Private",
+			CompanyName: r[2],
+		}
+		num++
+	}
+
+	return num
+}
+
+// normalizeMAC strips the separators from a MAC address or prefix and returns the uppercase hex digits.
+// Colons, dashes and dots are accepted as separators, an empty string is returned for invalid input.
+func normalizeMAC(mac string) string {
+	var b strings.Builder
+
+	for _, c := range mac {
+		switch {
+		case c == ':' || c == '-' || c == '.':
+		case c >= '0' && c <= '9', c >= 'A' && c <= 'F':
+			b.WriteRune(c)
+		case c >= 'a' && c <= 'f':
+			b.WriteRune(c - 'a' + 'A')
+		default:
+			return ""
+		}
+	}
+
+	return b.String()
+}
+
+// LookupMACVendor resolves a MAC addr to the vendor name of the assigned address block.
+// The most specific MA-S and MA-M assignments take precedence over the 24 bit OUI.
+// Results are cached, an empty string is returned if the vendor is unknown.
+func LookupMACVendor(mac string) string {
+	if res, ok := vendors.Load(mac); ok {
+		return res.(string)
+	}
+
+	var (
+		digits = normalizeMAC(mac)
+		vendor string
+	)
+
+	for _, n := range prefixLengths {
+		if len(digits) < n {
+			continue
+		}
+
+		if res, ok := macDB[digits[:n]]; ok {
+			vendor = res.CompanyName
+
+			break
+		}
+	}
+
+	vendors.Store(mac, vendor)
+
+	return vendor
+}
+
 // LookupManufacturer resolves a MAC addr to the manufacturer.
 func LookupManufacturer(mac string) string {
-	if len(mac) < 8 {
-		return ""
-	}
+	return LookupMACVendor(mac)
+}
 
-	oui := strings.ToUpper(mac[:8])
+// LookupOUI resolves the organizationally unique identifier of a MAC addr to the vendor name.
+// Colons, dashes and dots are accepted as separators.
+// Locally administered addresses, such as the randomized addresses used by devices for privacy reasons,
+// do not carry a vendor OUI and are flagged as such.
+func LookupOUI(mac string) (vendor string, locallyAdministered bool) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) < 3 {
+		return "", false
+	}
 
-	if res, ok := macDB[oui]; ok {
-		return res.CompanyName
+	// second least significant bit of the first octet is set for locally administered addresses
+	if hw[0]&0x02 != 0 {
+		return "", true
 	}
 
-	return ""
+	return LookupMACVendor(mac), false
 }
@@ -0,0 +1,66 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package resolvers
+
+import (
+	"testing"
+)
+
+func TestLookupOUI(t *testing.T) {
+	macDB["08ECF5"] = macSummary{OUI: "08:EC:F5", CompanyName: "Cisco Systems, Inc"}
+
+	for _, mac := range []string{"08:ec:f5:11:22:33", "08-EC-F5-11-22-33", "08ec.f511.2233"} {
+		vendor, local := LookupOUI(mac)
+		if vendor != "Cisco Systems, Inc" || local {
+			t.Fatal("unexpected result for", mac, ":", vendor, local)
+		}
+	}
+
+	vendor, local := LookupOUI("da:a1:19:11:22:33")
+	if vendor != "" || !local {
+		t.Fatal("expected locally administered address, got:", vendor, local)
+	}
+
+	vendor, local = LookupOUI("invalid")
+	if vendor != "" || local {
+		t.Fatal("expected empty result for invalid address, got:", vendor, local)
+	}
+}
+
+func TestLookupMACVendor(t *testing.T) {
+	macDB["70B3D5"] = macSummary{OUI: "70-B3-D5", CompanyName: "IEEE Registration Authority"}
+	macDB["70B3D5F2F"] = macSummary{OUI: "70-B3-D5-F2-F", CompanyName: "MA-S Vendor"}
+	macDB["1C8259A"] = macSummary{OUI: "1C-82-59-A", CompanyName: "MA-M Vendor"}
+
+	tests := []struct {
+		mac    string
+		vendor string
+	}{
+		{"70:b3:d5:f2:f0:01", "MA-S Vendor"},
+		{"70b3.d5f2.f001", "MA-S Vendor"},
+		{"70-B3-D5-11-22-33", "IEEE Registration Authority"},
+		{"1c:82:59:a1:22:33", "MA-M Vendor"},
+		{"1c:82:59:b1:22:33", ""},
+		{"invalid", ""},
+	}
+
+	for _, tt := range tests {
+		// the second lookup is served from the cache
+		for i := 0; i < 2; i++ {
+			if vendor := LookupMACVendor(tt.mac); vendor != tt.vendor {
+				t.Fatal("unexpected vendor for", tt.mac, ":", vendor, "expected:", tt.vendor)
+			}
+		}
+	}
+}
@@ -74,10 +74,11 @@ func Init(c Config, quietMode bool) {
 	}
 
 	if c.MACDB {
-		initMACResolver()
+		InitMACResolver()
 	}
 	if c.Ja3DB {
-		initJa3Resolver()
+		InitJa3Resolver()
+		InitJa3MalwareDB()
 	}
 	if c.ServiceDB {
 		InitServiceDB()
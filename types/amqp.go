@@ -0,0 +1,150 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldChannel     = "Channel"
+	fieldVirtualHost = "VirtualHost"
+	fieldExchange    = "Exchange"
+	fieldRoutingKey  = "RoutingKey"
+	fieldQueue       = "Queue"
+	fieldConsumerTag = "ConsumerTag"
+	fieldMechanisms  = "Mechanisms"
+)
+
+var fieldsAMQP = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldChannel,
+	fieldMethod,
+	fieldVirtualHost,
+	fieldExchange,
+	fieldRoutingKey,
+	fieldQueue,
+	fieldConsumerTag,
+	fieldProduct,
+	fieldVersion,
+	fieldMechanisms,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *AMQP) CSVHeader() []string {
+	return filter(fieldsAMQP)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *AMQP) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		formatInt32(a.Channel),
+		a.Method,
+		a.VirtualHost,
+		a.Exchange,
+		a.RoutingKey,
+		a.Queue,
+		a.ConsumerTag,
+		a.Product,
+		a.Version,
+		a.Mechanisms,
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *AMQP) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *AMQP) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var amqpMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_AMQP.String()),
+		Help: Type_NC_AMQP.String() + " audit records",
+	},
+	[]string{fieldMethod},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *AMQP) Inc() {
+	amqpMetric.WithLabelValues(a.Method).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *AMQP) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *AMQP) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *AMQP) Dst() string {
+	return a.DstIP
+}
+
+var amqpEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *AMQP) Encode() []string {
+	return filter([]string{
+		amqpEncoder.Int64(fieldTimestamp, a.Timestamp),
+		amqpEncoder.String(fieldFlow, a.Flow),
+		amqpEncoder.String(fieldSrcIP, a.SrcIP),
+		amqpEncoder.Int32(fieldSrcPort, a.SrcPort),
+		amqpEncoder.String(fieldDstIP, a.DstIP),
+		amqpEncoder.Int32(fieldDstPort, a.DstPort),
+		amqpEncoder.Int32(fieldChannel, a.Channel),
+		amqpEncoder.String(fieldMethod, a.Method),
+		amqpEncoder.String(fieldVirtualHost, a.VirtualHost),
+		amqpEncoder.String(fieldExchange, a.Exchange),
+		amqpEncoder.String(fieldRoutingKey, a.RoutingKey),
+		amqpEncoder.String(fieldQueue, a.Queue),
+		amqpEncoder.String(fieldConsumerTag, a.ConsumerTag),
+		amqpEncoder.String(fieldProduct, a.Product),
+		amqpEncoder.String(fieldVersion, a.Version),
+		amqpEncoder.String(fieldMechanisms, a.Mechanisms),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *AMQP) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *AMQP) NetcapType() Type {
+	return Type_NC_AMQP
+}
@@ -0,0 +1,148 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldInfoHashes = "InfoHashes"
+	fieldPeerIDs    = "PeerIDs"
+	fieldClients    = "Clients"
+	fieldPeers      = "Peers"
+	fieldDHTQueries = "DHTQueries"
+)
+
+var fieldsBitTorrent = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldTransport,
+	fieldProtocol,
+	fieldInfoHashes,
+	fieldPeerIDs,
+	fieldClients,
+	fieldPeers,
+	fieldDHTQueries,
+	fieldBytesClient,
+	fieldBytesServer,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *BitTorrent) CSVHeader() []string {
+	return filter(fieldsBitTorrent)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *BitTorrent) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Transport,
+		a.Protocol,
+		join(a.InfoHashes...),
+		join(a.PeerIDs...),
+		join(a.Clients...),
+		join(a.Peers...),
+		join(a.DHTQueries...),
+		formatInt32(a.BytesClient),
+		formatInt32(a.BytesServer),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *BitTorrent) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *BitTorrent) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var bitTorrentMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_BitTorrent.String()),
+		Help: Type_NC_BitTorrent.String() + " audit records",
+	},
+	[]string{fieldTransport, fieldProtocol},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *BitTorrent) Inc() {
+	bitTorrentMetric.WithLabelValues(
+		a.Transport,
+		a.Protocol,
+	).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *BitTorrent) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *BitTorrent) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *BitTorrent) Dst() string {
+	return a.DstIP
+}
+
+var bitTorrentEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *BitTorrent) Encode() []string {
+	return filter([]string{
+		bitTorrentEncoder.Int64(fieldTimestamp, a.Timestamp),
+		bitTorrentEncoder.String(fieldFlow, a.Flow),
+		bitTorrentEncoder.String(fieldSrcIP, a.SrcIP),
+		bitTorrentEncoder.Int32(fieldSrcPort, a.SrcPort),
+		bitTorrentEncoder.String(fieldDstIP, a.DstIP),
+		bitTorrentEncoder.Int32(fieldDstPort, a.DstPort),
+		bitTorrentEncoder.String(fieldTransport, a.Transport),
+		bitTorrentEncoder.String(fieldProtocol, a.Protocol),
+		bitTorrentEncoder.String(fieldInfoHashes, join(a.InfoHashes...)),
+		bitTorrentEncoder.String(fieldPeerIDs, join(a.PeerIDs...)),
+		bitTorrentEncoder.String(fieldClients, join(a.Clients...)),
+		bitTorrentEncoder.String(fieldPeers, join(a.Peers...)),
+		bitTorrentEncoder.String(fieldDHTQueries, join(a.DHTQueries...)),
+		bitTorrentEncoder.Int32(fieldBytesClient, a.BytesClient),
+		bitTorrentEncoder.Int32(fieldBytesServer, a.BytesServer),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *BitTorrent) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *BitTorrent) NetcapType() Type {
+	return Type_NC_BitTorrent
+}
@@ -0,0 +1,145 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldAnomaly     = "Anomaly"
+	fieldIssuer      = "Issuer"
+	fieldNotBefore   = "NotBefore"
+	fieldNotAfter    = "NotAfter"
+	fieldFingerprint = "Fingerprint"
+)
+
+var fieldsCertAnomaly = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldAnomaly,
+	fieldDescription,
+	fieldSNI,
+	fieldSubject,
+	fieldIssuer,
+	fieldDNSNames,
+	fieldNotBefore,
+	fieldNotAfter,
+	fieldFingerprint,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *CertAnomaly) CSVHeader() []string {
+	return filter(fieldsCertAnomaly)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *CertAnomaly) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Anomaly,
+		a.Description,
+		a.SNI,
+		a.Subject,
+		a.Issuer,
+		join(a.DNSNames...),
+		formatTimestamp(a.NotBefore),
+		formatTimestamp(a.NotAfter),
+		a.Fingerprint,
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *CertAnomaly) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *CertAnomaly) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var certAnomalyMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_CertAnomaly.String()),
+		Help: Type_NC_CertAnomaly.String() + " audit records",
+	},
+	[]string{fieldAnomaly, fieldDstIP},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *CertAnomaly) Inc() {
+	certAnomalyMetric.WithLabelValues(a.Anomaly, a.DstIP).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *CertAnomaly) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *CertAnomaly) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *CertAnomaly) Dst() string {
+	return a.DstIP
+}
+
+var certAnomalyEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *CertAnomaly) Encode() []string {
+	return filter([]string{
+		certAnomalyEncoder.Int64(fieldTimestamp, a.Timestamp),
+		certAnomalyEncoder.String(fieldFlow, a.Flow),
+		certAnomalyEncoder.String(fieldSrcIP, a.SrcIP),
+		certAnomalyEncoder.Int32(fieldSrcPort, a.SrcPort),
+		certAnomalyEncoder.String(fieldDstIP, a.DstIP),
+		certAnomalyEncoder.Int32(fieldDstPort, a.DstPort),
+		certAnomalyEncoder.String(fieldAnomaly, a.Anomaly),
+		certAnomalyEncoder.String(fieldDescription, a.Description),
+		certAnomalyEncoder.String(fieldSNI, a.SNI),
+		certAnomalyEncoder.String(fieldSubject, a.Subject),
+		certAnomalyEncoder.String(fieldIssuer, a.Issuer),
+		certAnomalyEncoder.String(fieldDNSNames, join(a.DNSNames...)),
+		certAnomalyEncoder.Int64(fieldNotBefore, a.NotBefore),
+		certAnomalyEncoder.Int64(fieldNotAfter, a.NotAfter),
+		certAnomalyEncoder.String(fieldFingerprint, a.Fingerprint),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *CertAnomaly) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *CertAnomaly) NetcapType() Type {
+	return Type_NC_CertAnomaly
+}
@@ -14,6 +14,7 @@
 package types
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -46,6 +47,23 @@ const (
 	fieldNumCWRFlags         = "NumCWRFlags"
 	fieldNumNSFlags          = "NumNSFlags"
 	fieldMeanWindowSize      = "MeanWindowSize"
+	fieldRTTMin              = "RTTMin"
+	fieldRTTAvg              = "RTTAvg"
+	fieldRTTMax              = "RTTMax"
+	fieldRTTJitter           = "RTTJitter"
+	fieldNumRTTSamples       = "NumRTTSamples"
+	fieldConnState           = "ConnState"
+	fieldDstHostname         = "DstHostname"
+	fieldNumGaps             = "NumGaps"
+	fieldGapBytes            = "GapBytes"
+	fieldCompleteness        = "Completeness"
+	fieldProxyProtocol       = "ProxyProtocol"
+	fieldOriginalSrcIP       = "OriginalSrcIP"
+	fieldOriginalSrcPort     = "OriginalSrcPort"
+	fieldOriginalDstIP       = "OriginalDstIP"
+	fieldOriginalDstPort     = "OriginalDstPort"
+	fieldCloseReason         = "CloseReason"
+	fieldIncomplete          = "Incomplete"
 )
 
 var fieldsConnection = []string{
@@ -78,6 +96,25 @@ var fieldsConnection = []string{
 	fieldNumCWRFlags,
 	fieldNumNSFlags,
 	fieldMeanWindowSize,
+	fieldRTTMin,
+	fieldRTTAvg,
+	fieldRTTMax,
+	fieldRTTJitter,
+	fieldNumRTTSamples,
+	fieldConnState,
+	fieldDstHostname,
+	fieldNumGaps,
+	fieldGapBytes,
+	fieldCompleteness,
+	fieldProxyProtocol,
+	fieldOriginalSrcIP,
+	fieldOriginalSrcPort,
+	fieldOriginalDstIP,
+	fieldOriginalDstPort,
+	fieldPayloadEntropy,
+	fieldSNI,
+	fieldCloseReason,
+	fieldIncomplete,
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -117,6 +154,25 @@ func (c *Connection) CSVRecord() []string {
 		formatInt32(c.NumCWRFlags),
 		formatInt32(c.NumNSFlags),
 		formatInt32(c.MeanWindowSize),
+		formatInt64(c.RTTMin),
+		formatInt64(c.RTTAvg),
+		formatInt64(c.RTTMax),
+		formatInt64(c.RTTJitter),
+		formatInt32(c.NumRTTSamples),
+		c.ConnState,
+		c.DstHostname,
+		formatInt32(c.NumGaps),
+		formatInt64(c.GapBytes),
+		formatFloat64(c.Completeness),
+		formatInt32(c.ProxyProtocol),
+		c.OriginalSrcIP,
+		c.OriginalSrcPort,
+		c.OriginalDstIP,
+		c.OriginalDstPort,
+		formatFloat64(c.PayloadEntropy),
+		c.SNI,
+		c.CloseReason.String(),
+		strconv.FormatBool(c.Incomplete),
 	})
 }
 
@@ -260,6 +316,25 @@ func (c *Connection) Encode() []string {
 		connectionEncoder.Int32(fieldNumCWRFlags, c.NumCWRFlags),
 		connectionEncoder.Int32(fieldNumNSFlags, c.NumNSFlags),
 		connectionEncoder.Int32(fieldMeanWindowSize, c.MeanWindowSize),
+		connectionEncoder.Int64(fieldRTTMin, c.RTTMin),
+		connectionEncoder.Int64(fieldRTTAvg, c.RTTAvg),
+		connectionEncoder.Int64(fieldRTTMax, c.RTTMax),
+		connectionEncoder.Int64(fieldRTTJitter, c.RTTJitter),
+		connectionEncoder.Int32(fieldNumRTTSamples, c.NumRTTSamples),
+		connectionEncoder.String(fieldConnState, c.ConnState),
+		connectionEncoder.String(fieldDstHostname, c.DstHostname),
+		connectionEncoder.Int32(fieldNumGaps, c.NumGaps),
+		connectionEncoder.Int64(fieldGapBytes, c.GapBytes),
+		connectionEncoder.Float64(fieldCompleteness, c.Completeness),
+		connectionEncoder.Int32(fieldProxyProtocol, c.ProxyProtocol),
+		connectionEncoder.String(fieldOriginalSrcIP, c.OriginalSrcIP),
+		connectionEncoder.String(fieldOriginalSrcPort, c.OriginalSrcPort),
+		connectionEncoder.String(fieldOriginalDstIP, c.OriginalDstIP),
+		connectionEncoder.String(fieldOriginalDstPort, c.OriginalDstPort),
+		connectionEncoder.Float64(fieldPayloadEntropy, c.PayloadEntropy),
+		connectionEncoder.String(fieldSNI, c.SNI),
+		connectionEncoder.String(fieldCloseReason, c.CloseReason.String()),
+		connectionEncoder.Bool(c.Incomplete),
 	})
 }
 
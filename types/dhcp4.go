@@ -37,6 +37,7 @@ const (
 	fieldServerName   = "ServerName"
 	fieldFile         = "File"
 	fieldOptions      = "Options"
+	fieldRequestedIP  = "RequestedIP"
 )
 
 var fieldsDHCPv4 = []string{
@@ -60,6 +61,9 @@ var fieldsDHCPv4 = []string{
 	fieldDstIP,
 	fieldSrcPort,
 	fieldDstPort,
+	fieldMessageType,
+	fieldRequestedIP,
+	fieldHostname,
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -95,6 +99,9 @@ func (d *DHCPv4) CSVRecord() []string {
 		d.DstIP,
 		formatInt32(d.SrcPort),
 		formatInt32(d.DstPort),
+		d.MessageType,
+		d.RequestedIP,
+		d.Hostname,
 	})
 }
 
@@ -226,6 +233,9 @@ func (d *DHCPv4) Encode() []string {
 		dhcp4Encoder.String(fieldDstIP, d.DstIP),
 		dhcp4Encoder.Int32(fieldSrcPort, d.SrcPort),
 		dhcp4Encoder.Int32(fieldDstPort, d.DstPort),
+		dhcp4Encoder.String(fieldMessageType, d.MessageType),
+		dhcp4Encoder.String(fieldRequestedIP, d.RequestedIP),
+		dhcp4Encoder.String(fieldHostname, d.Hostname),
 	})
 }
 
@@ -33,6 +33,9 @@ const (
 	fieldFragmentNumber = "FragmentNumber"
 	fieldQOS            = "QOS"
 	fieldHTControl      = "HTControl"
+	fieldSSID           = "SSID"
+	fieldBSSID          = "BSSID"
+	fieldStationMAC     = "StationMAC"
 )
 
 var fieldsDot11 = []string{
@@ -50,6 +53,9 @@ var fieldsDot11 = []string{
 	fieldChecksum,       // uint32
 	fieldQOS,            // *Dot11QOS
 	fieldHTControl,      // *Dot11HTControl
+	fieldSSID,           // string
+	fieldBSSID,          // string
+	fieldStationMAC,     // string
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -74,6 +80,9 @@ func (d *Dot11) CSVRecord() []string {
 		formatUint32(d.Checksum),      // uint32
 		d.QOS.toString(),              // *Dot11QOS
 		d.HTControl.toString(),        // *Dot11HTControl
+		d.SSID,                        // string
+		d.BSSID,                       // string
+		d.StationMAC,                  // string
 	})
 }
 
@@ -251,6 +260,9 @@ func (d *Dot11) Encode() []string {
 		// TODO: flatten
 		dot11Encoder.String(fieldQOS, d.QOS.toString()),             // *Dot11QOS
 		dot11Encoder.String(fieldHTControl, d.HTControl.toString()), // *Dot11HTControl
+		dot11Encoder.String(fieldSSID, d.SSID),                      // string
+		dot11Encoder.String(fieldBSSID, d.BSSID),                    // string
+		dot11Encoder.String(fieldStationMAC, d.StationMAC),          // string
 	})
 }
 
@@ -0,0 +1,146 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldLoggedIn = "LoggedIn"
+	fieldFiles    = "Files"
+	fieldDataIP   = "DataIP"
+	fieldDataPort = "DataPort"
+	fieldPassive  = "Passive"
+)
+
+var fieldsFTP = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldBanner,
+	fieldUser,
+	fieldPassword,
+	fieldLoggedIn,
+	fieldCommands,
+	fieldFiles,
+	fieldDataIP,
+	fieldDataPort,
+	fieldPassive,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *FTP) CSVHeader() []string {
+	return filter(fieldsFTP)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *FTP) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Banner,
+		a.User,
+		a.Password,
+		strconv.FormatBool(a.LoggedIn),
+		join(a.Commands...),
+		join(a.Files...),
+		a.DataIP,
+		formatInt32(a.DataPort),
+		strconv.FormatBool(a.Passive),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *FTP) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *FTP) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var ftpMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_FTP.String()),
+		Help: Type_NC_FTP.String() + " audit records",
+	},
+	[]string{fieldUser, fieldLoggedIn, fieldPassive},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *FTP) Inc() {
+	ftpMetric.WithLabelValues(a.User, strconv.FormatBool(a.LoggedIn), strconv.FormatBool(a.Passive)).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *FTP) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *FTP) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *FTP) Dst() string {
+	return a.DstIP
+}
+
+var ftpEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *FTP) Encode() []string {
+	return filter([]string{
+		ftpEncoder.Int64(fieldTimestamp, a.Timestamp),
+		ftpEncoder.String(fieldFlow, a.Flow),
+		ftpEncoder.String(fieldSrcIP, a.SrcIP),
+		ftpEncoder.Int32(fieldSrcPort, a.SrcPort),
+		ftpEncoder.String(fieldDstIP, a.DstIP),
+		ftpEncoder.Int32(fieldDstPort, a.DstPort),
+		ftpEncoder.String(fieldBanner, a.Banner),
+		ftpEncoder.String(fieldUser, a.User),
+		ftpEncoder.String(fieldPassword, a.Password),
+		ftpEncoder.Bool(a.LoggedIn),
+		ftpEncoder.String(fieldCommands, join(a.Commands...)),
+		ftpEncoder.String(fieldFiles, join(a.Files...)),
+		ftpEncoder.String(fieldDataIP, a.DataIP),
+		ftpEncoder.Int32(fieldDataPort, a.DataPort),
+		ftpEncoder.Bool(a.Passive),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *FTP) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *FTP) NetcapType() Type {
+	return Type_NC_FTP
+}
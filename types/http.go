@@ -35,6 +35,15 @@ const (
 	fieldStatusCode         = "StatusCode"
 	fieldReqContentEncoding = "ReqContentEncoding"
 	fieldResContentEncoding = "ResContentEncoding"
+	fieldForwardedFor       = "ForwardedFor"
+	fieldProtocolTime       = "ProtocolTime"
+	fieldClockSkew          = "ClockSkew"
+	fieldResDecodedLength   = "ResDecodedLength"
+	fieldResBodyIncomplete  = "ResBodyIncomplete"
+	fieldResBodyHash        = "ResBodyHash"
+	fieldResBodyLocation    = "ResBodyLocation"
+	fieldRepeatCount        = "RepeatCount"
+	fieldLatencyMS          = "LatencyMS"
 )
 
 var fieldsHTTP = []string{
@@ -56,6 +65,16 @@ var fieldsHTTP = []string{
 	fieldReqContentEncoding,
 	fieldResContentEncoding,
 	fieldServerName,
+	fieldForwardedFor,
+	fieldClientIP,
+	fieldProtocolTime,
+	fieldClockSkew,
+	fieldResDecodedLength,
+	fieldResBodyIncomplete,
+	fieldResBodyHash,
+	fieldResBodyLocation,
+	fieldRepeatCount,
+	fieldLatencyMS,
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -92,6 +111,16 @@ func (h *HTTP) CSVRecord() []string {
 		h.ReqContentEncoding,
 		h.ResContentEncoding,
 		h.ServerName,
+		join(h.ForwardedFor...),
+		h.ClientIP,
+		formatTimestamp(h.ProtocolTime),
+		formatInt64(h.ClockSkew),
+		formatInt32(h.ResDecodedLength),
+		strconv.FormatBool(h.ResBodyIncomplete),
+		h.ResBodyHash,
+		h.ResBodyLocation,
+		formatInt32(h.RepeatCount),
+		formatFloat64(h.LatencyMS),
 	})
 }
 
@@ -130,6 +159,7 @@ func (h *HTTP) Time() int64 {
 func (h *HTTP) JSON() (string, error) {
 	// convert unix timestamp from nano to millisecond precision for elastic
 	h.Timestamp /= int64(time.Millisecond)
+	h.ProtocolTime /= int64(time.Millisecond)
 
 	h.RequestBody = nil  // TODO: dont kill elastic
 	h.ResponseBody = nil // TODO: dont kill elastic
@@ -186,6 +216,16 @@ func (h *HTTP) Encode() []string {
 		httpEncoder.String(fieldReqContentEncoding, h.ReqContentEncoding),
 		httpEncoder.String(fieldResContentEncoding, h.ResContentEncoding),
 		httpEncoder.String(fieldServerName, h.ServerName),
+		httpEncoder.String(fieldForwardedFor, join(h.ForwardedFor...)),
+		httpEncoder.String(fieldClientIP, h.ClientIP),
+		httpEncoder.Int64(fieldProtocolTime, h.ProtocolTime),
+		httpEncoder.Int64(fieldClockSkew, h.ClockSkew),
+		httpEncoder.Int32(fieldResDecodedLength, h.ResDecodedLength),
+		httpEncoder.Bool(h.ResBodyIncomplete),
+		httpEncoder.String(fieldResBodyHash, h.ResBodyHash),
+		httpEncoder.String(fieldResBodyLocation, h.ResBodyLocation),
+		httpEncoder.Int32(fieldRepeatCount, h.RepeatCount),
+		httpEncoder.Float64(fieldLatencyMS, h.LatencyMS),
 	})
 }
 
@@ -0,0 +1,137 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldTag        = "Tag"
+	fieldMailbox    = "Mailbox"
+	fieldStatusText = "StatusText"
+)
+
+var fieldsIMAP = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldTag,
+	fieldCommand,
+	fieldMailbox,
+	fieldUser,
+	fieldPassword,
+	fieldStatus,
+	fieldStatusText,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *IMAP) CSVHeader() []string {
+	return filter(fieldsIMAP)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *IMAP) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Tag,
+		a.Command,
+		a.Mailbox,
+		a.User,
+		a.Password,
+		a.Status,
+		a.StatusText,
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *IMAP) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *IMAP) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var imapMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_IMAP.String()),
+		Help: Type_NC_IMAP.String() + " audit records",
+	},
+	[]string{fieldCommand, fieldStatus},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *IMAP) Inc() {
+	imapMetric.WithLabelValues(a.Command, a.Status).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *IMAP) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *IMAP) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *IMAP) Dst() string {
+	return a.DstIP
+}
+
+var imapEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *IMAP) Encode() []string {
+	return filter([]string{
+		imapEncoder.Int64(fieldTimestamp, a.Timestamp),
+		imapEncoder.String(fieldFlow, a.Flow),
+		imapEncoder.String(fieldSrcIP, a.SrcIP),
+		imapEncoder.Int32(fieldSrcPort, a.SrcPort),
+		imapEncoder.String(fieldDstIP, a.DstIP),
+		imapEncoder.Int32(fieldDstPort, a.DstPort),
+		imapEncoder.String(fieldTag, a.Tag),
+		imapEncoder.String(fieldCommand, a.Command),
+		imapEncoder.String(fieldMailbox, a.Mailbox),
+		imapEncoder.String(fieldUser, a.User),
+		imapEncoder.String(fieldPassword, a.Password),
+		imapEncoder.String(fieldStatus, a.Status),
+		imapEncoder.String(fieldStatusText, a.StatusText),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *IMAP) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *IMAP) NetcapType() Type {
+	return Type_NC_IMAP
+}
@@ -19,15 +19,24 @@ import (
 )
 
 const (
-	fieldAddr         = "Addr"
-	fieldGeolocation  = "Geolocation"
-	fieldDNSNames     = "DNSNames"
-	fieldApplications = "Applications"
-	fieldJa3          = "Ja3"
-	fieldProtocols    = "Protocols"
-	fieldDstPorts     = "DstPorts"
-	fieldSrcPorts     = "SrcPorts"
-	fieldSNIs         = "SNIs"
+	fieldAddr            = "Addr"
+	fieldGeolocation     = "Geolocation"
+	fieldDNSNames        = "DNSNames"
+	fieldApplications    = "Applications"
+	fieldJa3             = "Ja3"
+	fieldProtocols       = "Protocols"
+	fieldDstPorts        = "DstPorts"
+	fieldSrcPorts        = "SrcPorts"
+	fieldSNIs            = "SNIs"
+	fieldBytesSent       = "BytesSent"
+	fieldBytesRcvd       = "BytesRcvd"
+	fieldDurationSeconds = "DurationSeconds"
+	fieldASN             = "ASN"
+	fieldASOrg           = "ASOrg"
+	fieldPortScanScore   = "PortScanScore"
+	fieldBeaconScore     = "BeaconScore"
+	fieldLatitude        = "Latitude"
+	fieldLongitude       = "Longitude"
 )
 
 var fieldsIPProfile = []string{
@@ -40,10 +49,21 @@ var fieldsIPProfile = []string{
 	fieldApplications,   // []string
 	//fieldJa3,            // map[string]string
 	//fieldProtocols,      // map[string]*Protocol
-	fieldBytes, // uint64
+	fieldBytes,              // uint64
+	fieldMacAddr,            // string
+	fieldDeviceManufacturer, // string
 	//fieldDstPorts,       // map[string]*Port
 	//fieldSrcPorts,       // map[string]*Port
 	//fieldSNIs,           // map[string]int64
+	fieldBytesSent,       // uint64
+	fieldBytesRcvd,       // uint64
+	fieldDurationSeconds, // float64
+	fieldASN,             // string
+	fieldASOrg,           // string
+	fieldPortScanScore,   // float64
+	fieldBeaconScore,     // float64
+	fieldLatitude,        // float64
+	fieldLongitude,       // float64
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -64,9 +84,20 @@ func (d *IPProfile) CSVRecord() []string {
 		// d.Ja3,
 		// d.Protocols,
 		formatUint64(d.Bytes),
+		d.MacAddr,
+		d.DeviceManufacturer,
 		// d.DstPorts,
 		// d.SrcPorts,
 		// d.SNIs,
+		formatUint64(d.BytesSent),
+		formatUint64(d.BytesRcvd),
+		formatFloat64(d.DurationSeconds),
+		d.ASN,
+		d.ASOrg,
+		formatFloat64(d.PortScanScore),
+		formatFloat64(d.BeaconScore),
+		formatFloat64(d.Latitude),
+		formatFloat64(d.Longitude),
 	})
 }
 
@@ -113,6 +144,17 @@ func (d *IPProfile) Encode() []string {
 		ipProfileEncoder.Int64(fieldTimestampLast, d.TimestampLast),
 		ipProfileEncoder.String(fieldApplications, join(d.Applications...)),
 		ipProfileEncoder.Uint64(fieldBytes, d.Bytes),
+		ipProfileEncoder.String(fieldMacAddr, d.MacAddr),
+		ipProfileEncoder.String(fieldDeviceManufacturer, d.DeviceManufacturer),
+		ipProfileEncoder.Uint64(fieldBytesSent, d.BytesSent),
+		ipProfileEncoder.Uint64(fieldBytesRcvd, d.BytesRcvd),
+		ipProfileEncoder.Float64(fieldDurationSeconds, d.DurationSeconds),
+		ipProfileEncoder.String(fieldASN, d.ASN),
+		ipProfileEncoder.String(fieldASOrg, d.ASOrg),
+		ipProfileEncoder.Float64(fieldPortScanScore, d.PortScanScore),
+		ipProfileEncoder.Float64(fieldBeaconScore, d.BeaconScore),
+		ipProfileEncoder.Float64(fieldLatitude, d.Latitude),
+		ipProfileEncoder.Float64(fieldLongitude, d.Longitude),
 	})
 }
 
@@ -0,0 +1,154 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldBindDN     = "BindDN"
+	fieldMechanism  = "Mechanism"
+	fieldBaseDN     = "BaseDN"
+	fieldScope      = "Scope"
+	fieldFilter     = "Filter"
+	fieldAttributes = "Attributes"
+	fieldResult     = "Result"
+	fieldEntries    = "Entries"
+)
+
+var fieldsLDAP = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldMessageID,
+	fieldOperation,
+	fieldBindDN,
+	fieldAuthType,
+	fieldMechanism,
+	fieldBaseDN,
+	fieldScope,
+	fieldFilter,
+	fieldAttributes,
+	fieldResult,
+	fieldEntries,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *LDAP) CSVHeader() []string {
+	return filter(fieldsLDAP)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *LDAP) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		formatInt32(a.MessageID),
+		a.Operation,
+		a.BindDN,
+		a.AuthType,
+		a.Mechanism,
+		a.BaseDN,
+		a.Scope,
+		a.Filter,
+		join(a.Attributes...),
+		a.Result,
+		formatInt32(a.Entries),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *LDAP) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *LDAP) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var ldapMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_LDAP.String()),
+		Help: Type_NC_LDAP.String() + " audit records",
+	},
+	[]string{fieldOperation, fieldAuthType, fieldResult},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *LDAP) Inc() {
+	ldapMetric.WithLabelValues(a.Operation, a.AuthType, a.Result).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *LDAP) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *LDAP) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *LDAP) Dst() string {
+	return a.DstIP
+}
+
+var ldapEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *LDAP) Encode() []string {
+	return filter([]string{
+		ldapEncoder.Int64(fieldTimestamp, a.Timestamp),
+		ldapEncoder.String(fieldFlow, a.Flow),
+		ldapEncoder.String(fieldSrcIP, a.SrcIP),
+		ldapEncoder.Int32(fieldSrcPort, a.SrcPort),
+		ldapEncoder.String(fieldDstIP, a.DstIP),
+		ldapEncoder.Int32(fieldDstPort, a.DstPort),
+		ldapEncoder.Int32(fieldMessageID, a.MessageID),
+		ldapEncoder.String(fieldOperation, a.Operation),
+		ldapEncoder.String(fieldBindDN, a.BindDN),
+		ldapEncoder.String(fieldAuthType, a.AuthType),
+		ldapEncoder.String(fieldMechanism, a.Mechanism),
+		ldapEncoder.String(fieldBaseDN, a.BaseDN),
+		ldapEncoder.String(fieldScope, a.Scope),
+		ldapEncoder.String(fieldFilter, a.Filter),
+		ldapEncoder.String(fieldAttributes, join(a.Attributes...)),
+		ldapEncoder.String(fieldResult, a.Result),
+		ldapEncoder.Int32(fieldEntries, a.Entries),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *LDAP) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *LDAP) NetcapType() Type {
+	return Type_NC_LDAP
+}
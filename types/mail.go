@@ -54,9 +54,11 @@ var fieldsMail = []string{
 	fieldContentType,     // string
 	fieldEnvelopeTo,      // string
 	//fieldBody,            // []*MailPart
-	fieldClientIP, // string
-	fieldServerIP, // string
-	fieldID,       // string
+	fieldClientIP,     // string
+	fieldServerIP,     // string
+	fieldID,           // string
+	fieldProtocolTime, // int64
+	fieldClockSkew,    // int64
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -83,9 +85,11 @@ func (d *Mail) CSVRecord() []string {
 		d.ContentType,                        // string
 		d.EnvelopeTo,                         // string
 		// d.Body,            // []*MailPart
-		d.ClientIP, // string
-		d.ServerIP, // string
-		d.ID,       // string
+		d.ClientIP,                      // string
+		d.ServerIP,                      // string
+		d.ID,                            // string
+		formatTimestamp(d.ProtocolTime), // int64
+		formatInt64(d.ClockSkew),        // int64
 	})
 }
 
@@ -98,6 +102,7 @@ func (d *Mail) Time() int64 {
 func (d *Mail) JSON() (string, error) {
 	// convert unix timestamp from nano to millisecond precision for elastic
 	d.Timestamp /= int64(time.Millisecond)
+	d.ProtocolTime /= int64(time.Millisecond)
 
 	return jsonMarshaler.MarshalToString(d)
 }
@@ -139,9 +144,11 @@ func (d *Mail) Encode() []string {
 		mailEncoder.String(fieldContentType, d.ContentType),         // string
 		mailEncoder.String(fieldEnvelopeTo, d.EnvelopeTo),           // string
 		// d.Body,            // []*MailPart
-		mailEncoder.String(fieldClientIP, d.ClientIP), // string
-		mailEncoder.String(fieldServerIP, d.ServerIP), // string
-		mailEncoder.String(fieldID, d.ID),             // string
+		mailEncoder.String(fieldClientIP, d.ClientIP),        // string
+		mailEncoder.String(fieldServerIP, d.ServerIP),        // string
+		mailEncoder.String(fieldID, d.ID),                    // string
+		mailEncoder.Int64(fieldProtocolTime, d.ProtocolTime), // int64
+		mailEncoder.Int64(fieldClockSkew, d.ClockSkew),       // int64
 	})
 }
 
@@ -0,0 +1,154 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldTransport              = "Transport"
+	fieldBinary                 = "Binary"
+	fieldKeys                   = "Keys"
+	fieldStatsExposed           = "StatsExposed"
+	fieldAmplificationFactor    = "AmplificationFactor"
+	fieldPotentialAmplification = "PotentialAmplification"
+)
+
+var fieldsMemcached = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldTransport,
+	fieldBinary,
+	fieldCommands,
+	fieldKeys,
+	fieldVersion,
+	fieldStatsExposed,
+	fieldBytesClient,
+	fieldBytesServer,
+	fieldAmplificationFactor,
+	fieldPotentialAmplification,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *Memcached) CSVHeader() []string {
+	return filter(fieldsMemcached)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *Memcached) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Transport,
+		strconv.FormatBool(a.Binary),
+		join(a.Commands...),
+		join(a.Keys...),
+		a.Version,
+		strconv.FormatBool(a.StatsExposed),
+		formatInt32(a.BytesClient),
+		formatInt32(a.BytesServer),
+		formatFloat64(a.AmplificationFactor),
+		strconv.FormatBool(a.PotentialAmplification),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *Memcached) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *Memcached) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var memcachedMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_Memcached.String()),
+		Help: Type_NC_Memcached.String() + " audit records",
+	},
+	[]string{fieldTransport, fieldStatsExposed, fieldPotentialAmplification},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *Memcached) Inc() {
+	memcachedMetric.WithLabelValues(
+		a.Transport,
+		strconv.FormatBool(a.StatsExposed),
+		strconv.FormatBool(a.PotentialAmplification),
+	).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *Memcached) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *Memcached) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *Memcached) Dst() string {
+	return a.DstIP
+}
+
+var memcachedEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *Memcached) Encode() []string {
+	return filter([]string{
+		memcachedEncoder.Int64(fieldTimestamp, a.Timestamp),
+		memcachedEncoder.String(fieldFlow, a.Flow),
+		memcachedEncoder.String(fieldSrcIP, a.SrcIP),
+		memcachedEncoder.Int32(fieldSrcPort, a.SrcPort),
+		memcachedEncoder.String(fieldDstIP, a.DstIP),
+		memcachedEncoder.Int32(fieldDstPort, a.DstPort),
+		memcachedEncoder.String(fieldTransport, a.Transport),
+		memcachedEncoder.Bool(a.Binary),
+		memcachedEncoder.String(fieldCommands, join(a.Commands...)),
+		memcachedEncoder.String(fieldKeys, join(a.Keys...)),
+		memcachedEncoder.String(fieldVersion, a.Version),
+		memcachedEncoder.Bool(a.StatsExposed),
+		memcachedEncoder.Int32(fieldBytesClient, a.BytesClient),
+		memcachedEncoder.Int32(fieldBytesServer, a.BytesServer),
+		memcachedEncoder.Float64(fieldAmplificationFactor, a.AmplificationFactor),
+		memcachedEncoder.Bool(a.PotentialAmplification),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *Memcached) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *Memcached) NetcapType() Type {
+	return Type_NC_Memcached
+}
@@ -85,4 +85,27 @@ var Metrics = []prometheus.Collector{
 	lldMetric,
 	dhcp6Metric,
 	bfdMetric,
+	certAnomalyMetric,
+	memcachedMetric,
+	bitTorrentMetric,
+	wireGuardMetric,
+	volumeAnomalyMetric,
+	secretLeakMetric,
+	tnsMetric,
+	ftpMetric,
+	redisMetric,
+	tlsServerCertificateMetric,
+	webSocketFrameMetric,
+	imapMetric,
+	telnetMetric,
+	smbMetric,
+	tunnelMetric,
+	mySQLQueryMetric,
+	socksMetric,
+	ldapMetric,
+	quicMetric,
+	ntlmMetric,
+	mongoDBMetric,
+	postgresQueryMetric,
+	amqpMetric,
 }
@@ -0,0 +1,137 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldRequestID    = "RequestID"
+	fieldCollection   = "Collection"
+	fieldNumDocuments = "NumDocuments"
+)
+
+var fieldsMongoDB = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldOpCode,
+	fieldRequestID,
+	fieldDatabase,
+	fieldCollection,
+	fieldOperation,
+	fieldKeys,
+	fieldNumDocuments,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *MongoDB) CSVHeader() []string {
+	return filter(fieldsMongoDB)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *MongoDB) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.OpCode,
+		formatInt32(a.RequestID),
+		a.Database,
+		a.Collection,
+		a.Operation,
+		join(a.Keys...),
+		formatInt32(a.NumDocuments),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *MongoDB) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *MongoDB) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var mongoDBMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_MongoDB.String()),
+		Help: Type_NC_MongoDB.String() + " audit records",
+	},
+	[]string{fieldOpCode, fieldOperation},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *MongoDB) Inc() {
+	mongoDBMetric.WithLabelValues(a.OpCode, a.Operation).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *MongoDB) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *MongoDB) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *MongoDB) Dst() string {
+	return a.DstIP
+}
+
+var mongoDBEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *MongoDB) Encode() []string {
+	return filter([]string{
+		mongoDBEncoder.Int64(fieldTimestamp, a.Timestamp),
+		mongoDBEncoder.String(fieldFlow, a.Flow),
+		mongoDBEncoder.String(fieldSrcIP, a.SrcIP),
+		mongoDBEncoder.Int32(fieldSrcPort, a.SrcPort),
+		mongoDBEncoder.String(fieldDstIP, a.DstIP),
+		mongoDBEncoder.Int32(fieldDstPort, a.DstPort),
+		mongoDBEncoder.String(fieldOpCode, a.OpCode),
+		mongoDBEncoder.Int32(fieldRequestID, a.RequestID),
+		mongoDBEncoder.String(fieldDatabase, a.Database),
+		mongoDBEncoder.String(fieldCollection, a.Collection),
+		mongoDBEncoder.String(fieldOperation, a.Operation),
+		mongoDBEncoder.String(fieldKeys, join(a.Keys...)),
+		mongoDBEncoder.Int32(fieldNumDocuments, a.NumDocuments),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *MongoDB) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *MongoDB) NetcapType() Type {
+	return Type_NC_MongoDB
+}
@@ -0,0 +1,131 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldServerVersion = "ServerVersion"
+	fieldDatabase      = "Database"
+	fieldQuery         = "Query"
+)
+
+var fieldsMySQLQuery = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldServerVersion,
+	fieldUser,
+	fieldDatabase,
+	fieldCommand,
+	fieldQuery,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *MySQLQuery) CSVHeader() []string {
+	return filter(fieldsMySQLQuery)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *MySQLQuery) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.ServerVersion,
+		a.User,
+		a.Database,
+		a.Command,
+		a.Query,
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *MySQLQuery) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *MySQLQuery) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var mySQLQueryMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_MySQLQuery.String()),
+		Help: Type_NC_MySQLQuery.String() + " audit records",
+	},
+	[]string{fieldCommand},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *MySQLQuery) Inc() {
+	mySQLQueryMetric.WithLabelValues(a.Command).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *MySQLQuery) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *MySQLQuery) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *MySQLQuery) Dst() string {
+	return a.DstIP
+}
+
+var mySQLQueryEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *MySQLQuery) Encode() []string {
+	return filter([]string{
+		mySQLQueryEncoder.Int64(fieldTimestamp, a.Timestamp),
+		mySQLQueryEncoder.String(fieldFlow, a.Flow),
+		mySQLQueryEncoder.String(fieldSrcIP, a.SrcIP),
+		mySQLQueryEncoder.Int32(fieldSrcPort, a.SrcPort),
+		mySQLQueryEncoder.String(fieldDstIP, a.DstIP),
+		mySQLQueryEncoder.Int32(fieldDstPort, a.DstPort),
+		mySQLQueryEncoder.String(fieldServerVersion, a.ServerVersion),
+		mySQLQueryEncoder.String(fieldUser, a.User),
+		mySQLQueryEncoder.String(fieldDatabase, a.Database),
+		mySQLQueryEncoder.String(fieldCommand, a.Command),
+		mySQLQueryEncoder.String(fieldQuery, a.Query),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *MySQLQuery) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *MySQLQuery) NetcapType() Type {
+	return Type_NC_MySQLQuery
+}
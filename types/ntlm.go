@@ -0,0 +1,133 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const fieldServerChallenge = "ServerChallenge"
+
+var fieldsNTLM = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldProtocol,
+	fieldDomain,
+	fieldUser,
+	fieldWorkstation,
+	fieldVersion,
+	fieldServerChallenge,
+	fieldHash,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *NTLM) CSVHeader() []string {
+	return filter(fieldsNTLM)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *NTLM) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Protocol,
+		a.Domain,
+		a.User,
+		a.Workstation,
+		a.Version,
+		a.ServerChallenge,
+		a.Hash,
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *NTLM) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *NTLM) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var ntlmMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_NTLM.String()),
+		Help: Type_NC_NTLM.String() + " audit records",
+	},
+	[]string{fieldProtocol, fieldVersion},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *NTLM) Inc() {
+	ntlmMetric.WithLabelValues(a.Protocol, a.Version).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *NTLM) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *NTLM) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *NTLM) Dst() string {
+	return a.DstIP
+}
+
+var ntlmEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *NTLM) Encode() []string {
+	return filter([]string{
+		ntlmEncoder.Int64(fieldTimestamp, a.Timestamp),
+		ntlmEncoder.String(fieldFlow, a.Flow),
+		ntlmEncoder.String(fieldSrcIP, a.SrcIP),
+		ntlmEncoder.Int32(fieldSrcPort, a.SrcPort),
+		ntlmEncoder.String(fieldDstIP, a.DstIP),
+		ntlmEncoder.Int32(fieldDstPort, a.DstPort),
+		ntlmEncoder.String(fieldProtocol, a.Protocol),
+		ntlmEncoder.String(fieldDomain, a.Domain),
+		ntlmEncoder.String(fieldUser, a.User),
+		ntlmEncoder.String(fieldWorkstation, a.Workstation),
+		ntlmEncoder.String(fieldVersion, a.Version),
+		ntlmEncoder.String(fieldServerChallenge, a.ServerChallenge),
+		ntlmEncoder.String(fieldHash, a.Hash),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *NTLM) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *NTLM) NetcapType() Type {
+	return Type_NC_NTLM
+}
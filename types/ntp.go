@@ -36,6 +36,7 @@ const (
 	fieldReceiveTimestamp   = "ReceiveTimestamp"
 	fieldTransmitTimestamp  = "TransmitTimestamp"
 	fieldExtensionBytes     = "ExtensionBytes"
+	fieldReferenceSource    = "ReferenceSource"
 )
 
 var fieldsNTP = []string{
@@ -58,6 +59,7 @@ var fieldsNTP = []string{
 	fieldDstIP,
 	fieldSrcPort,
 	fieldDstPort,
+	fieldReferenceSource,
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -87,6 +89,7 @@ func (n *NTP) CSVRecord() []string {
 		n.DstIP,
 		formatInt32(n.SrcPort),
 		formatInt32(n.DstPort),
+		n.ReferenceSource,
 	})
 }
 
@@ -159,6 +162,7 @@ func (n *NTP) Encode() []string {
 		ntpEncoder.String(fieldDstIP, n.DstIP),
 		ntpEncoder.Int32(fieldSrcPort, n.SrcPort),
 		ntpEncoder.Int32(fieldDstPort, n.DstPort),
+		ntpEncoder.String(fieldReferenceSource, n.ReferenceSource),
 	})
 }
 
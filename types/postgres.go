@@ -0,0 +1,141 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldApplication = "Application"
+	fieldStatement   = "Statement"
+	fieldEncrypted   = "Encrypted"
+)
+
+var fieldsPostgresQuery = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldServerVersion,
+	fieldUser,
+	fieldDatabase,
+	fieldApplication,
+	fieldCommand,
+	fieldStatement,
+	fieldQuery,
+	fieldEncrypted,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *PostgresQuery) CSVHeader() []string {
+	return filter(fieldsPostgresQuery)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *PostgresQuery) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.ServerVersion,
+		a.User,
+		a.Database,
+		a.Application,
+		a.Command,
+		a.Statement,
+		a.Query,
+		strconv.FormatBool(a.Encrypted),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *PostgresQuery) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *PostgresQuery) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var postgresQueryMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_PostgresQuery.String()),
+		Help: Type_NC_PostgresQuery.String() + " audit records",
+	},
+	[]string{fieldCommand, fieldEncrypted},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *PostgresQuery) Inc() {
+	postgresQueryMetric.WithLabelValues(a.Command, strconv.FormatBool(a.Encrypted)).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *PostgresQuery) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *PostgresQuery) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *PostgresQuery) Dst() string {
+	return a.DstIP
+}
+
+var postgresQueryEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *PostgresQuery) Encode() []string {
+	return filter([]string{
+		postgresQueryEncoder.Int64(fieldTimestamp, a.Timestamp),
+		postgresQueryEncoder.String(fieldFlow, a.Flow),
+		postgresQueryEncoder.String(fieldSrcIP, a.SrcIP),
+		postgresQueryEncoder.Int32(fieldSrcPort, a.SrcPort),
+		postgresQueryEncoder.String(fieldDstIP, a.DstIP),
+		postgresQueryEncoder.Int32(fieldDstPort, a.DstPort),
+		postgresQueryEncoder.String(fieldServerVersion, a.ServerVersion),
+		postgresQueryEncoder.String(fieldUser, a.User),
+		postgresQueryEncoder.String(fieldDatabase, a.Database),
+		postgresQueryEncoder.String(fieldApplication, a.Application),
+		postgresQueryEncoder.String(fieldCommand, a.Command),
+		postgresQueryEncoder.String(fieldStatement, a.Statement),
+		postgresQueryEncoder.String(fieldQuery, a.Query),
+		postgresQueryEncoder.Bool(a.Encrypted),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *PostgresQuery) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *PostgresQuery) NetcapType() Type {
+	return Type_NC_PostgresQuery
+}
@@ -0,0 +1,142 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldDCID        = "DCID"
+	fieldSCID        = "SCID"
+	fieldTokenLength = "TokenLength"
+	fieldDecrypted   = "Decrypted"
+)
+
+var fieldsQUIC = []string{
+	fieldTimestamp,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldVersion,
+	fieldDCID,
+	fieldSCID,
+	fieldTokenLength,
+	fieldNumPackets,
+	fieldDecrypted,
+	fieldSNI,
+	fieldALPNs,
+	fieldJa3,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *QUIC) CSVHeader() []string {
+	return filter(fieldsQUIC)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *QUIC) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Version,
+		a.DCID,
+		a.SCID,
+		formatInt32(a.TokenLength),
+		formatInt32(a.NumPackets),
+		strconv.FormatBool(a.Decrypted),
+		a.SNI,
+		join(a.ALPNs...),
+		a.Ja3,
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *QUIC) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *QUIC) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var quicMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_QUIC.String()),
+		Help: Type_NC_QUIC.String() + " audit records",
+	},
+	[]string{fieldVersion, fieldDecrypted},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *QUIC) Inc() {
+	quicMetric.WithLabelValues(a.Version, strconv.FormatBool(a.Decrypted)).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *QUIC) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *QUIC) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *QUIC) Dst() string {
+	return a.DstIP
+}
+
+var quicEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *QUIC) Encode() []string {
+	return filter([]string{
+		quicEncoder.Int64(fieldTimestamp, a.Timestamp),
+		quicEncoder.String(fieldSrcIP, a.SrcIP),
+		quicEncoder.Int32(fieldSrcPort, a.SrcPort),
+		quicEncoder.String(fieldDstIP, a.DstIP),
+		quicEncoder.Int32(fieldDstPort, a.DstPort),
+		quicEncoder.String(fieldVersion, a.Version),
+		quicEncoder.String(fieldDCID, a.DCID),
+		quicEncoder.String(fieldSCID, a.SCID),
+		quicEncoder.Int32(fieldTokenLength, a.TokenLength),
+		quicEncoder.Int32(fieldNumPackets, a.NumPackets),
+		quicEncoder.Bool(a.Decrypted),
+		quicEncoder.String(fieldSNI, a.SNI),
+		quicEncoder.String(fieldALPNs, join(a.ALPNs...)),
+		quicEncoder.String(fieldJa3, a.Ja3),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *QUIC) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *QUIC) NetcapType() Type {
+	return Type_NC_QUIC
+}
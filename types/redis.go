@@ -0,0 +1,139 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldNumArgs     = "NumArgs"
+	fieldReplyType   = "ReplyType"
+	fieldTransaction = "Transaction"
+	fieldInline      = "Inline"
+)
+
+var fieldsRedis = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldCommand,
+	fieldNumArgs,
+	fieldKey,
+	fieldReplyType,
+	fieldError,
+	fieldTransaction,
+	fieldInline,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *Redis) CSVHeader() []string {
+	return filter(fieldsRedis)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *Redis) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Command,
+		formatInt32(a.NumArgs),
+		a.Key,
+		a.ReplyType,
+		a.Error,
+		strconv.FormatBool(a.Transaction),
+		strconv.FormatBool(a.Inline),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *Redis) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *Redis) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var redisMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_Redis.String()),
+		Help: Type_NC_Redis.String() + " audit records",
+	},
+	[]string{fieldCommand, fieldReplyType},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *Redis) Inc() {
+	redisMetric.WithLabelValues(a.Command, a.ReplyType).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *Redis) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *Redis) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *Redis) Dst() string {
+	return a.DstIP
+}
+
+var redisEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *Redis) Encode() []string {
+	return filter([]string{
+		redisEncoder.Int64(fieldTimestamp, a.Timestamp),
+		redisEncoder.String(fieldFlow, a.Flow),
+		redisEncoder.String(fieldSrcIP, a.SrcIP),
+		redisEncoder.Int32(fieldSrcPort, a.SrcPort),
+		redisEncoder.String(fieldDstIP, a.DstIP),
+		redisEncoder.Int32(fieldDstPort, a.DstPort),
+		redisEncoder.String(fieldCommand, a.Command),
+		redisEncoder.Int32(fieldNumArgs, a.NumArgs),
+		redisEncoder.String(fieldKey, a.Key),
+		redisEncoder.String(fieldReplyType, a.ReplyType),
+		redisEncoder.String(fieldError, a.Error),
+		redisEncoder.Bool(a.Transaction),
+		redisEncoder.Bool(a.Inline),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *Redis) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *Redis) NetcapType() Type {
+	return Type_NC_Redis
+}
@@ -0,0 +1,120 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const fieldPreview = "Preview"
+
+var fieldsSecretLeak = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldType,
+	fieldPreview,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *SecretLeak) CSVHeader() []string {
+	return filter(fieldsSecretLeak)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *SecretLeak) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Type,
+		a.Preview,
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *SecretLeak) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *SecretLeak) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var secretLeakMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_SecretLeak.String()),
+		Help: Type_NC_SecretLeak.String() + " audit records",
+	},
+	[]string{fieldType},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *SecretLeak) Inc() {
+	secretLeakMetric.WithLabelValues(
+		a.Type,
+	).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *SecretLeak) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *SecretLeak) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *SecretLeak) Dst() string {
+	return a.DstIP
+}
+
+var secretLeakEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *SecretLeak) Encode() []string {
+	return filter([]string{
+		secretLeakEncoder.Int64(fieldTimestamp, a.Timestamp),
+		secretLeakEncoder.String(fieldFlow, a.Flow),
+		secretLeakEncoder.String(fieldSrcIP, a.SrcIP),
+		secretLeakEncoder.Int32(fieldSrcPort, a.SrcPort),
+		secretLeakEncoder.String(fieldDstIP, a.DstIP),
+		secretLeakEncoder.Int32(fieldDstPort, a.DstPort),
+		secretLeakEncoder.String(fieldType, a.Type),
+		secretLeakEncoder.String(fieldPreview, a.Preview),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *SecretLeak) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *SecretLeak) NetcapType() Type {
+	return Type_NC_SecretLeak
+}
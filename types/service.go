@@ -37,20 +37,22 @@ const (
 
 var fieldsService = []string{
 	fieldTimestamp,
-	fieldIP,          // string
-	fieldPort,        // int32
-	fieldName,        // string
-	fieldBanner,      // string
-	fieldProtocol,    // string
-	fieldNumFlows,    // []string
-	fieldProduct,     // string
-	fieldVendor,      // string
-	fieldVersion,     // string
-	fieldNotes,       // string
-	fieldBytesServer, // int32
-	fieldBytesClient, // int32
-	fieldHostname,    // string
-	fieldOS,          // string
+	fieldIP,                 // string
+	fieldPort,               // int32
+	fieldName,               // string
+	fieldBanner,             // string
+	fieldProtocol,           // string
+	fieldNumFlows,           // []string
+	fieldProduct,            // string
+	fieldVendor,             // string
+	fieldVersion,            // string
+	fieldNotes,              // string
+	fieldBytesServer,        // int32
+	fieldBytesClient,        // int32
+	fieldHostname,           // string
+	fieldOS,                 // string
+	fieldMacAddr,            // string
+	fieldDeviceManufacturer, // string
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -71,10 +73,13 @@ func (a *Service) CSVRecord() []string {
 		a.Product,                           // string
 		a.Vendor,                            // string
 		a.Version,                           // string
+		a.Notes,                             // string
 		formatInt32(a.BytesServer),          // int32
 		formatInt32(a.BytesClient),          // int32
 		a.Hostname,                          // string
 		a.OS,                                // string
+		a.MacAddr,                           // string
+		a.DeviceManufacturer,                // string
 	})
 }
 
@@ -155,19 +160,21 @@ var serviceEncoder = encoder.NewValueEncoder()
 func (a *Service) Encode() []string {
 	return filter([]string{
 		serviceEncoder.Int64(fieldTimestamp, a.Timestamp),
-		serviceEncoder.String(fieldIP, a.IP),                  // string
-		serviceEncoder.Int32(fieldPort, a.Port),               // int32
-		serviceEncoder.String(fieldName, a.Name),              // string
-		serviceEncoder.String(fieldBanner, a.Banner),          // string
-		serviceEncoder.String(fieldProtocol, a.Protocol),      // string
-		serviceEncoder.Int(fieldNumFlows, len(a.Flows)),       // []string
-		serviceEncoder.String(fieldProduct, a.Product),        // string
-		serviceEncoder.String(fieldVendor, a.Vendor),          // string
-		serviceEncoder.String(fieldVersion, a.Version),        // string
-		serviceEncoder.Int32(fieldBytesServer, a.BytesServer), // int32
-		serviceEncoder.Int32(fieldBytesClient, a.BytesClient), // int32
-		serviceEncoder.String(fieldHostname, a.Hostname),      // string
-		serviceEncoder.String(fieldOS, a.OS),                  // string
+		serviceEncoder.String(fieldIP, a.IP),                                 // string
+		serviceEncoder.Int32(fieldPort, a.Port),                              // int32
+		serviceEncoder.String(fieldName, a.Name),                             // string
+		serviceEncoder.String(fieldBanner, a.Banner),                         // string
+		serviceEncoder.String(fieldProtocol, a.Protocol),                     // string
+		serviceEncoder.Int(fieldNumFlows, len(a.Flows)),                      // []string
+		serviceEncoder.String(fieldProduct, a.Product),                       // string
+		serviceEncoder.String(fieldVendor, a.Vendor),                         // string
+		serviceEncoder.String(fieldVersion, a.Version),                       // string
+		serviceEncoder.Int32(fieldBytesServer, a.BytesServer),                // int32
+		serviceEncoder.Int32(fieldBytesClient, a.BytesClient),                // int32
+		serviceEncoder.String(fieldHostname, a.Hostname),                     // string
+		serviceEncoder.String(fieldOS, a.OS),                                 // string
+		serviceEncoder.String(fieldMacAddr, a.MacAddr),                       // string
+		serviceEncoder.String(fieldDeviceManufacturer, a.DeviceManufacturer), // string
 	})
 }
 
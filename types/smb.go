@@ -0,0 +1,158 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldTreeID      = "TreeID"
+	fieldTree        = "Tree"
+	fieldFilename    = "Filename"
+	fieldDialect     = "Dialect"
+	fieldDomain      = "Domain"
+	fieldWorkstation = "Workstation"
+)
+
+var fieldsSMB = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldOperation,
+	fieldStatus,
+	fieldMessageID,
+	fieldSessionID,
+	fieldTreeID,
+	fieldTree,
+	fieldFilename,
+	fieldDialect,
+	fieldDomain,
+	fieldUser,
+	fieldWorkstation,
+	fieldOffset,
+	fieldLength,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *SMB) CSVHeader() []string {
+	return filter(fieldsSMB)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *SMB) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Operation,
+		a.Status,
+		formatUint64(a.MessageID),
+		formatUint64(a.SessionID),
+		formatUint32(a.TreeID),
+		a.Tree,
+		a.Filename,
+		a.Dialect,
+		a.Domain,
+		a.User,
+		a.Workstation,
+		formatUint64(a.Offset),
+		formatUint32(a.Length),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *SMB) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *SMB) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var smbMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_SMB.String()),
+		Help: Type_NC_SMB.String() + " audit records",
+	},
+	[]string{fieldOperation, fieldStatus},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *SMB) Inc() {
+	smbMetric.WithLabelValues(a.Operation, a.Status).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *SMB) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *SMB) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *SMB) Dst() string {
+	return a.DstIP
+}
+
+var smbEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *SMB) Encode() []string {
+	return filter([]string{
+		smbEncoder.Int64(fieldTimestamp, a.Timestamp),
+		smbEncoder.String(fieldFlow, a.Flow),
+		smbEncoder.String(fieldSrcIP, a.SrcIP),
+		smbEncoder.Int32(fieldSrcPort, a.SrcPort),
+		smbEncoder.String(fieldDstIP, a.DstIP),
+		smbEncoder.Int32(fieldDstPort, a.DstPort),
+		smbEncoder.String(fieldOperation, a.Operation),
+		smbEncoder.String(fieldStatus, a.Status),
+		smbEncoder.Uint64(fieldMessageID, a.MessageID),
+		smbEncoder.Uint64(fieldSessionID, a.SessionID),
+		smbEncoder.Uint32(fieldTreeID, a.TreeID),
+		smbEncoder.String(fieldTree, a.Tree),
+		smbEncoder.String(fieldFilename, a.Filename),
+		smbEncoder.String(fieldDialect, a.Dialect),
+		smbEncoder.String(fieldDomain, a.Domain),
+		smbEncoder.String(fieldUser, a.User),
+		smbEncoder.String(fieldWorkstation, a.Workstation),
+		smbEncoder.Uint64(fieldOffset, a.Offset),
+		smbEncoder.Uint32(fieldLength, a.Length),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *SMB) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *SMB) NetcapType() Type {
+	return Type_NC_SMB
+}
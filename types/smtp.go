@@ -26,6 +26,11 @@ const (
 	fieldIsEncrypted = "IsEncrypted"
 	fieldMailIDs     = "MailIDs"
 	fieldCommands    = "Commands"
+	fieldMailFrom    = "MailFrom"
+	fieldRcptTo      = "RcptTo"
+
+	// fields up to the DstPort are used as labels for the metric
+	numSMTPMetricFields = 9
 )
 
 var fieldsSMTP = []string{
@@ -38,6 +43,11 @@ var fieldsSMTP = []string{
 	fieldDstIP,
 	fieldSrcPort,
 	fieldDstPort,
+	fieldMailFrom,
+	fieldRcptTo,     // []string
+	fieldExtensions, // []string
+	fieldUser,
+	fieldPassword,
 }
 
 // CSVHeader returns the CSV header for the audit record.
@@ -57,6 +67,11 @@ func (a *SMTP) CSVRecord() []string {
 		a.DstIP,
 		formatInt32(a.SrcPort),
 		formatInt32(a.DstPort),
+		a.MailFrom,
+		join(a.RcptTo...),
+		join(a.Extensions...),
+		a.User,
+		a.Password,
 	})
 }
 
@@ -98,12 +113,12 @@ var smtpMetric = prometheus.NewCounterVec(
 		Name: strings.ToLower(Type_NC_SMTP.String()),
 		Help: Type_NC_SMTP.String() + " audit records",
 	},
-	fieldsSMTP[1:],
+	fieldsSMTP[1:numSMTPMetricFields],
 )
 
 // Inc increments the metrics for the audit record.
 func (a *SMTP) Inc() {
-	smtpMetric.WithLabelValues(a.CSVRecord()[1:]...).Inc()
+	smtpMetric.WithLabelValues(a.CSVRecord()[1:numSMTPMetricFields]...).Inc()
 }
 
 // SetPacketContext sets the associated packet context for the audit record.
@@ -138,6 +153,11 @@ func (a *SMTP) Encode() []string {
 		smtpEncoder.String(fieldDstIP, a.DstIP),
 		smtpEncoder.Int32(fieldSrcPort, a.SrcPort),
 		smtpEncoder.Int32(fieldDstPort, a.DstPort),
+		smtpEncoder.String(fieldMailFrom, a.MailFrom),
+		smtpEncoder.String(fieldRcptTo, join(a.RcptTo...)),
+		smtpEncoder.String(fieldExtensions, join(a.Extensions...)),
+		smtpEncoder.String(fieldUser, a.User),
+		smtpEncoder.String(fieldPassword, a.Password),
 	})
 }
 
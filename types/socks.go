@@ -0,0 +1,152 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldAuthMethod = "AuthMethod"
+	fieldAuthFailed = "AuthFailed"
+	fieldReply      = "Reply"
+	fieldBindHost   = "BindHost"
+	fieldBindPort   = "BindPort"
+)
+
+var fieldsSOCKS = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldVersion,
+	fieldAuthMethod,
+	fieldUser,
+	fieldPassword,
+	fieldAuthFailed,
+	fieldCommand,
+	fieldHost,
+	fieldPort,
+	fieldReply,
+	fieldBindHost,
+	fieldBindPort,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *SOCKS) CSVHeader() []string {
+	return filter(fieldsSOCKS)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *SOCKS) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		formatInt32(a.Version),
+		a.AuthMethod,
+		a.User,
+		a.Password,
+		strconv.FormatBool(a.AuthFailed),
+		a.Command,
+		a.Host,
+		formatInt32(a.Port),
+		a.Reply,
+		a.BindHost,
+		formatInt32(a.BindPort),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *SOCKS) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *SOCKS) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var socksMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_SOCKS.String()),
+		Help: Type_NC_SOCKS.String() + " audit records",
+	},
+	[]string{fieldVersion, fieldCommand, fieldReply},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *SOCKS) Inc() {
+	socksMetric.WithLabelValues(formatInt32(a.Version), a.Command, a.Reply).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *SOCKS) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *SOCKS) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *SOCKS) Dst() string {
+	return a.DstIP
+}
+
+var socksEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *SOCKS) Encode() []string {
+	return filter([]string{
+		socksEncoder.Int64(fieldTimestamp, a.Timestamp),
+		socksEncoder.String(fieldFlow, a.Flow),
+		socksEncoder.String(fieldSrcIP, a.SrcIP),
+		socksEncoder.Int32(fieldSrcPort, a.SrcPort),
+		socksEncoder.String(fieldDstIP, a.DstIP),
+		socksEncoder.Int32(fieldDstPort, a.DstPort),
+		socksEncoder.Int32(fieldVersion, a.Version),
+		socksEncoder.String(fieldAuthMethod, a.AuthMethod),
+		socksEncoder.String(fieldUser, a.User),
+		socksEncoder.String(fieldPassword, a.Password),
+		socksEncoder.Bool(a.AuthFailed),
+		socksEncoder.String(fieldCommand, a.Command),
+		socksEncoder.String(fieldHost, a.Host),
+		socksEncoder.Int32(fieldPort, a.Port),
+		socksEncoder.String(fieldReply, a.Reply),
+		socksEncoder.String(fieldBindHost, a.BindHost),
+		socksEncoder.Int32(fieldBindPort, a.BindPort),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *SOCKS) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *SOCKS) NetcapType() Type {
+	return Type_NC_SOCKS
+}
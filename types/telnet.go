@@ -0,0 +1,134 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldLoginFailed   = "LoginFailed"
+	fieldLoginAttempts = "LoginAttempts"
+)
+
+var fieldsTelnet = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldBanner,
+	fieldUser,
+	fieldPassword,
+	fieldLoginFailed,
+	fieldLoginAttempts,
+	fieldCommands,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *Telnet) CSVHeader() []string {
+	return filter(fieldsTelnet)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *Telnet) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.Banner,
+		a.User,
+		a.Password,
+		strconv.FormatBool(a.LoginFailed),
+		formatInt32(a.LoginAttempts),
+		join(a.Commands...),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *Telnet) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *Telnet) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var telnetMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_Telnet.String()),
+		Help: Type_NC_Telnet.String() + " audit records",
+	},
+	[]string{fieldLoginFailed},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *Telnet) Inc() {
+	telnetMetric.WithLabelValues(strconv.FormatBool(a.LoginFailed)).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *Telnet) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *Telnet) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *Telnet) Dst() string {
+	return a.DstIP
+}
+
+var telnetEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *Telnet) Encode() []string {
+	return filter([]string{
+		telnetEncoder.Int64(fieldTimestamp, a.Timestamp),
+		telnetEncoder.String(fieldFlow, a.Flow),
+		telnetEncoder.String(fieldSrcIP, a.SrcIP),
+		telnetEncoder.Int32(fieldSrcPort, a.SrcPort),
+		telnetEncoder.String(fieldDstIP, a.DstIP),
+		telnetEncoder.Int32(fieldDstPort, a.DstPort),
+		telnetEncoder.String(fieldBanner, a.Banner),
+		telnetEncoder.String(fieldUser, a.User),
+		telnetEncoder.String(fieldPassword, a.Password),
+		telnetEncoder.Bool(a.LoginFailed),
+		telnetEncoder.Int32(fieldLoginAttempts, a.LoginAttempts),
+		telnetEncoder.String(fieldCommands, join(a.Commands...)),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *Telnet) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *Telnet) NetcapType() Type {
+	return Type_NC_Telnet
+}
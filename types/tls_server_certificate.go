@@ -0,0 +1,154 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldChainIndex         = "ChainIndex"
+	fieldSerialNumber       = "SerialNumber"
+	fieldSignatureAlgorithm = "SignatureAlgorithm"
+	fieldIsCA               = "IsCA"
+)
+
+var fieldsTLSServerCertificate = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldSNI,
+	fieldChainIndex,
+	fieldSubject,
+	fieldIssuer,
+	fieldDNSNames,
+	fieldIPAddresses,
+	fieldNotBefore,
+	fieldNotAfter,
+	fieldFingerprint,
+	fieldSerialNumber,
+	fieldSignatureAlgorithm,
+	fieldIsCA,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *TLSServerCertificate) CSVHeader() []string {
+	return filter(fieldsTLSServerCertificate)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *TLSServerCertificate) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.SNI,
+		formatInt32(a.ChainIndex),
+		a.Subject,
+		a.Issuer,
+		join(a.DNSNames...),
+		join(a.IPAddresses...),
+		formatTimestamp(a.NotBefore),
+		formatTimestamp(a.NotAfter),
+		a.Fingerprint,
+		a.SerialNumber,
+		a.SignatureAlgorithm,
+		strconv.FormatBool(a.IsCA),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *TLSServerCertificate) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *TLSServerCertificate) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var tlsServerCertificateMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_TLSServerCertificate.String()),
+		Help: Type_NC_TLSServerCertificate.String() + " audit records",
+	},
+	[]string{fieldIssuer},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *TLSServerCertificate) Inc() {
+	tlsServerCertificateMetric.WithLabelValues(a.Issuer).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *TLSServerCertificate) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *TLSServerCertificate) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *TLSServerCertificate) Dst() string {
+	return a.DstIP
+}
+
+var tlsServerCertificateEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *TLSServerCertificate) Encode() []string {
+	return filter([]string{
+		tlsServerCertificateEncoder.Int64(fieldTimestamp, a.Timestamp),
+		tlsServerCertificateEncoder.String(fieldFlow, a.Flow),
+		tlsServerCertificateEncoder.String(fieldSrcIP, a.SrcIP),
+		tlsServerCertificateEncoder.Int32(fieldSrcPort, a.SrcPort),
+		tlsServerCertificateEncoder.String(fieldDstIP, a.DstIP),
+		tlsServerCertificateEncoder.Int32(fieldDstPort, a.DstPort),
+		tlsServerCertificateEncoder.String(fieldSNI, a.SNI),
+		tlsServerCertificateEncoder.Int32(fieldChainIndex, a.ChainIndex),
+		tlsServerCertificateEncoder.String(fieldSubject, a.Subject),
+		tlsServerCertificateEncoder.String(fieldIssuer, a.Issuer),
+		tlsServerCertificateEncoder.String(fieldDNSNames, join(a.DNSNames...)),
+		tlsServerCertificateEncoder.String(fieldIPAddresses, join(a.IPAddresses...)),
+		tlsServerCertificateEncoder.Int64(fieldNotBefore, a.NotBefore),
+		tlsServerCertificateEncoder.Int64(fieldNotAfter, a.NotAfter),
+		tlsServerCertificateEncoder.String(fieldFingerprint, a.Fingerprint),
+		tlsServerCertificateEncoder.String(fieldSerialNumber, a.SerialNumber),
+		tlsServerCertificateEncoder.String(fieldSignatureAlgorithm, a.SignatureAlgorithm),
+		tlsServerCertificateEncoder.Bool(a.IsCA),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *TLSServerCertificate) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *TLSServerCertificate) NetcapType() Type {
+	return Type_NC_TLSServerCertificate
+}
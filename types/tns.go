@@ -0,0 +1,147 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldServiceName = "ServiceName"
+	fieldSID         = "SID"
+	fieldProgram     = "Program"
+	fieldClientHost  = "ClientHost"
+	fieldClientUser  = "ClientUser"
+	fieldConnectData = "ConnectData"
+	fieldError       = "Error"
+)
+
+var fieldsTNS = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldVersion,
+	fieldServiceName,
+	fieldSID,
+	fieldProgram,
+	fieldClientHost,
+	fieldClientUser,
+	fieldConnectData,
+	fieldResponse,
+	fieldError,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *TNS) CSVHeader() []string {
+	return filter(fieldsTNS)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *TNS) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		formatInt32(a.Version),
+		a.ServiceName,
+		a.SID,
+		a.Program,
+		a.ClientHost,
+		a.ClientUser,
+		a.ConnectData,
+		a.Response,
+		a.Error,
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *TNS) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *TNS) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var tnsMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_TNS.String()),
+		Help: Type_NC_TNS.String() + " audit records",
+	},
+	[]string{fieldServiceName, fieldSID, fieldResponse},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *TNS) Inc() {
+	tnsMetric.WithLabelValues(a.ServiceName, a.SID, a.Response).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *TNS) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *TNS) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *TNS) Dst() string {
+	return a.DstIP
+}
+
+var tnsEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *TNS) Encode() []string {
+	return filter([]string{
+		tnsEncoder.Int64(fieldTimestamp, a.Timestamp),
+		tnsEncoder.String(fieldFlow, a.Flow),
+		tnsEncoder.String(fieldSrcIP, a.SrcIP),
+		tnsEncoder.Int32(fieldSrcPort, a.SrcPort),
+		tnsEncoder.String(fieldDstIP, a.DstIP),
+		tnsEncoder.Int32(fieldDstPort, a.DstPort),
+		tnsEncoder.Int32(fieldVersion, a.Version),
+		tnsEncoder.String(fieldServiceName, a.ServiceName),
+		tnsEncoder.String(fieldSID, a.SID),
+		tnsEncoder.String(fieldProgram, a.Program),
+		tnsEncoder.String(fieldClientHost, a.ClientHost),
+		tnsEncoder.String(fieldClientUser, a.ClientUser),
+		tnsEncoder.String(fieldConnectData, a.ConnectData),
+		tnsEncoder.String(fieldResponse, a.Response),
+		tnsEncoder.String(fieldError, a.Error),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *TNS) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *TNS) NetcapType() Type {
+	return Type_NC_TNS
+}
@@ -0,0 +1,119 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+var fieldsTunnel = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldTransport,
+	fieldType,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldKey,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *Tunnel) CSVHeader() []string {
+	return filter(fieldsTunnel)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *Tunnel) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.Transport,
+		a.Type,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		formatUint32(a.Key),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *Tunnel) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *Tunnel) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var tunnelMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_Tunnel.String()),
+		Help: Type_NC_Tunnel.String() + " audit records",
+	},
+	[]string{fieldType, fieldTransport},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *Tunnel) Inc() {
+	tunnelMetric.WithLabelValues(a.Type, a.Transport).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *Tunnel) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *Tunnel) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *Tunnel) Dst() string {
+	return a.DstIP
+}
+
+var tunnelEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *Tunnel) Encode() []string {
+	return filter([]string{
+		tunnelEncoder.Int64(fieldTimestamp, a.Timestamp),
+		tunnelEncoder.String(fieldFlow, a.Flow),
+		tunnelEncoder.String(fieldTransport, a.Transport),
+		tunnelEncoder.String(fieldType, a.Type),
+		tunnelEncoder.String(fieldSrcIP, a.SrcIP),
+		tunnelEncoder.Int32(fieldSrcPort, a.SrcPort),
+		tunnelEncoder.String(fieldDstIP, a.DstIP),
+		tunnelEncoder.Int32(fieldDstPort, a.DstPort),
+		tunnelEncoder.Uint32(fieldKey, a.Key),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *Tunnel) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *Tunnel) NetcapType() Type {
+	return Type_NC_Tunnel
+}
@@ -0,0 +1,128 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldMean       = "Mean"
+	fieldStdDev     = "StdDev"
+	fieldScore      = "Score"
+	fieldNumBuckets = "NumBuckets"
+)
+
+var fieldsVolumeAnomaly = []string{
+	fieldTimestamp,
+	fieldSrcIP,
+	fieldDuration,
+	fieldBytes,
+	fieldNumPackets,
+	fieldMean,
+	fieldStdDev,
+	fieldScore,
+	fieldNumBuckets,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *VolumeAnomaly) CSVHeader() []string {
+	return filter(fieldsVolumeAnomaly)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *VolumeAnomaly) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.SrcIP,
+		formatInt64(a.Duration),
+		formatUint64(a.Bytes),
+		formatInt64(a.NumPackets),
+		formatFloat64(a.Mean),
+		formatFloat64(a.StdDev),
+		formatFloat64(a.Score),
+		formatInt32(a.NumBuckets),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *VolumeAnomaly) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *VolumeAnomaly) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var volumeAnomalyMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_VolumeAnomaly.String()),
+		Help: Type_NC_VolumeAnomaly.String() + " audit records",
+	},
+	[]string{fieldSrcIP},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *VolumeAnomaly) Inc() {
+	volumeAnomalyMetric.WithLabelValues(
+		a.SrcIP,
+	).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *VolumeAnomaly) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *VolumeAnomaly) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *VolumeAnomaly) Dst() string {
+	return ""
+}
+
+var volumeAnomalyEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *VolumeAnomaly) Encode() []string {
+	return filter([]string{
+		volumeAnomalyEncoder.Int64(fieldTimestamp, a.Timestamp),
+		volumeAnomalyEncoder.String(fieldSrcIP, a.SrcIP),
+		volumeAnomalyEncoder.Int64(fieldDuration, a.Duration),
+		volumeAnomalyEncoder.Uint64(fieldBytes, a.Bytes),
+		volumeAnomalyEncoder.Int64(fieldNumPackets, a.NumPackets),
+		volumeAnomalyEncoder.Float64(fieldMean, a.Mean),
+		volumeAnomalyEncoder.Float64(fieldStdDev, a.StdDev),
+		volumeAnomalyEncoder.Float64(fieldScore, a.Score),
+		volumeAnomalyEncoder.Int32(fieldNumBuckets, a.NumBuckets),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *VolumeAnomaly) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *VolumeAnomaly) NetcapType() Type {
+	return Type_NC_VolumeAnomaly
+}
@@ -0,0 +1,144 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldFromClient    = "FromClient"
+	fieldFin           = "Fin"
+	fieldMasked        = "Masked"
+	fieldPayloadLength = "PayloadLength"
+	fieldCloseCode     = "CloseCode"
+)
+
+var fieldsWebSocketFrame = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldFromClient,
+	fieldOpCode,
+	fieldMessageType,
+	fieldFin,
+	fieldMasked,
+	fieldPayloadLength,
+	fieldPreview,
+	fieldCloseCode,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *WebSocketFrame) CSVHeader() []string {
+	return filter(fieldsWebSocketFrame)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *WebSocketFrame) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		strconv.FormatBool(a.FromClient),
+		formatInt32(a.OpCode),
+		a.MessageType,
+		strconv.FormatBool(a.Fin),
+		strconv.FormatBool(a.Masked),
+		formatInt64(a.PayloadLength),
+		hex.EncodeToString(a.Preview),
+		formatInt32(a.CloseCode),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *WebSocketFrame) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *WebSocketFrame) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var webSocketFrameMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_WebSocketFrame.String()),
+		Help: Type_NC_WebSocketFrame.String() + " audit records",
+	},
+	[]string{fieldMessageType, fieldFromClient},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *WebSocketFrame) Inc() {
+	webSocketFrameMetric.WithLabelValues(a.MessageType, strconv.FormatBool(a.FromClient)).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *WebSocketFrame) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *WebSocketFrame) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *WebSocketFrame) Dst() string {
+	return a.DstIP
+}
+
+var webSocketFrameEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *WebSocketFrame) Encode() []string {
+	return filter([]string{
+		webSocketFrameEncoder.Int64(fieldTimestamp, a.Timestamp),
+		webSocketFrameEncoder.String(fieldFlow, a.Flow),
+		webSocketFrameEncoder.String(fieldSrcIP, a.SrcIP),
+		webSocketFrameEncoder.Int32(fieldSrcPort, a.SrcPort),
+		webSocketFrameEncoder.String(fieldDstIP, a.DstIP),
+		webSocketFrameEncoder.Int32(fieldDstPort, a.DstPort),
+		webSocketFrameEncoder.Bool(a.FromClient),
+		webSocketFrameEncoder.Int32(fieldOpCode, a.OpCode),
+		webSocketFrameEncoder.String(fieldMessageType, a.MessageType),
+		webSocketFrameEncoder.Bool(a.Fin),
+		webSocketFrameEncoder.Bool(a.Masked),
+		webSocketFrameEncoder.Int64(fieldPayloadLength, a.PayloadLength),
+		webSocketFrameEncoder.String(fieldPreview, hex.EncodeToString(a.Preview)),
+		webSocketFrameEncoder.Int32(fieldCloseCode, a.CloseCode),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *WebSocketFrame) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *WebSocketFrame) NetcapType() Type {
+	return Type_NC_WebSocketFrame
+}
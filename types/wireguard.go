@@ -0,0 +1,127 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017-2020 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreadl0ck/netcap/encoder"
+)
+
+const (
+	fieldMessageType   = "MessageType"
+	fieldSenderIndex   = "SenderIndex"
+	fieldReceiverIndex = "ReceiverIndex"
+)
+
+var fieldsWireGuard = []string{
+	fieldTimestamp,
+	fieldFlow,
+	fieldSrcIP,
+	fieldSrcPort,
+	fieldDstIP,
+	fieldDstPort,
+	fieldMessageType,
+	fieldSenderIndex,
+	fieldReceiverIndex,
+}
+
+// CSVHeader returns the CSV header for the audit record.
+func (a *WireGuard) CSVHeader() []string {
+	return filter(fieldsWireGuard)
+}
+
+// CSVRecord returns the CSV record for the audit record.
+func (a *WireGuard) CSVRecord() []string {
+	return filter([]string{
+		formatTimestamp(a.Timestamp),
+		a.Flow,
+		a.SrcIP,
+		formatInt32(a.SrcPort),
+		a.DstIP,
+		formatInt32(a.DstPort),
+		a.MessageType,
+		formatUint32(a.SenderIndex),
+		formatUint32(a.ReceiverIndex),
+	})
+}
+
+// Time returns the timestamp associated with the audit record.
+func (a *WireGuard) Time() int64 {
+	return a.Timestamp
+}
+
+// JSON returns the JSON representation of the audit record.
+func (a *WireGuard) JSON() (string, error) {
+	// convert unix timestamp from nano to millisecond precision for elastic
+	a.Timestamp /= int64(time.Millisecond)
+
+	return jsonMarshaler.MarshalToString(a)
+}
+
+var wireGuardMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: strings.ToLower(Type_NC_WireGuard.String()),
+		Help: Type_NC_WireGuard.String() + " audit records",
+	},
+	[]string{fieldMessageType},
+)
+
+// Inc increments the metrics for the audit record.
+func (a *WireGuard) Inc() {
+	wireGuardMetric.WithLabelValues(
+		a.MessageType,
+	).Inc()
+}
+
+// SetPacketContext sets the associated packet context for the audit record.
+func (a *WireGuard) SetPacketContext(*PacketContext) {}
+
+// Src returns the source address of the audit record.
+func (a *WireGuard) Src() string {
+	return a.SrcIP
+}
+
+// Dst returns the destination address of the audit record.
+func (a *WireGuard) Dst() string {
+	return a.DstIP
+}
+
+var wireGuardEncoder = encoder.NewValueEncoder()
+
+// Encode will encode categorical values and normalize according to configuration
+func (a *WireGuard) Encode() []string {
+	return filter([]string{
+		wireGuardEncoder.Int64(fieldTimestamp, a.Timestamp),
+		wireGuardEncoder.String(fieldFlow, a.Flow),
+		wireGuardEncoder.String(fieldSrcIP, a.SrcIP),
+		wireGuardEncoder.Int32(fieldSrcPort, a.SrcPort),
+		wireGuardEncoder.String(fieldDstIP, a.DstIP),
+		wireGuardEncoder.Int32(fieldDstPort, a.DstPort),
+		wireGuardEncoder.String(fieldMessageType, a.MessageType),
+		wireGuardEncoder.Uint32(fieldSenderIndex, a.SenderIndex),
+		wireGuardEncoder.Uint32(fieldReceiverIndex, a.ReceiverIndex),
+	})
+}
+
+// Analyze will invoke the configured analyzer for the audit record and return a score.
+func (a *WireGuard) Analyze() {}
+
+// NetcapType returns the type of the current audit record
+func (a *WireGuard) NetcapType() Type {
+	return Type_NC_WireGuard
+}
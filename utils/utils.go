@@ -176,6 +176,10 @@ func GetBaseLayer(value string) (t gopacket.LayerType) {
 		t = layers.LayerTypeIPv6
 	case "usb":
 		t = layers.LayerTypeUSB
+	case "dot11":
+		t = layers.LayerTypeDot11
+	case "radiotap":
+		t = layers.LayerTypeRadioTap
 	default:
 		log.Fatal("invalid baseLayer:", value)
 	}
@@ -245,9 +249,51 @@ func Progress(current, total int64) string {
 // 	return string(b)
 // }
 
+// netcapFileExtensions are the extensions of netcap audit record files,
+// the compressed ones are checked first since they end with a different suffix.
+var netcapFileExtensions = []string{
+	defaults.FileExtensionCompressed,
+	defaults.FileExtensionZstd,
+	defaults.FileExtension,
+}
+
+// NetcapFileExtension returns the extension of a netcap audit record file including the compression suffix,
+// e.g: .ncap.gz, .ncap.zst or .ncap. An empty string is returned for files with other extensions.
+func NetcapFileExtension(file string) string {
+	for _, ext := range netcapFileExtensions {
+		if strings.HasSuffix(file, ext) {
+			return ext
+		}
+	}
+
+	return ""
+}
+
+// IsNetcapFile checks if the file has the extension of a compressed or uncompressed netcap audit record file.
+func IsNetcapFile(file string) bool {
+	return NetcapFileExtension(file) != ""
+}
+
+// StatNetcapFile returns the path and file info of the first existing audit record file
+// for base with one of the netcap file extensions, e.g: HTTP.ncap.gz for the base HTTP.
+func StatNetcapFile(base string) (string, os.FileInfo, error) {
+	var err error
+
+	for _, ext := range netcapFileExtensions {
+		var stat os.FileInfo
+
+		stat, err = os.Stat(base + ext)
+		if err == nil {
+			return base + ext, stat, nil
+		}
+	}
+
+	return "", nil, err
+}
+
 // TrimFileExtension returns the netcap file name without file extension.
 func TrimFileExtension(file string) string {
-	return strings.TrimSuffix(strings.TrimSuffix(file, ".gz"), defaults.FileExtension)
+	return strings.TrimSuffix(file, NetcapFileExtension(file))
 }
 
 // TimeToUTC returns a time string in netcap format to a UTC string.
@@ -128,3 +128,32 @@ func BenchmarkProgress(b *testing.B) {
 		Progress(int64(n), int64(b.N))
 	}
 }
+
+func TestNetcapFileExtension(t *testing.T) {
+	tests := []struct {
+		file    string
+		ext     string
+		trimmed string
+	}{
+		{"HTTP.ncap.gz", ".ncap.gz", "HTTP"},
+		{"HTTP.ncap.zst", ".ncap.zst", "HTTP"},
+		{"HTTP.ncap", ".ncap", "HTTP"},
+		{"bundle.tar.gz#TCP.ncap.zst", ".ncap.zst", "bundle.tar.gz#TCP"},
+		{"dump.pcap.gz", "", "dump.pcap.gz"},
+		{"HTTP.ncap.bz2", "", "HTTP.ncap.bz2"},
+	}
+
+	for _, test := range tests {
+		if ext := NetcapFileExtension(test.file); ext != test.ext {
+			t.Errorf("%s: expected extension %q, got %q", test.file, test.ext, ext)
+		}
+
+		if IsNetcapFile(test.file) != (test.ext != "") {
+			t.Errorf("%s: unexpected result for IsNetcapFile", test.file)
+		}
+
+		if trimmed := TrimFileExtension(test.file); trimmed != test.trimmed {
+			t.Errorf("%s: expected %q after trimming the extension, got %q", test.file, test.trimmed, trimmed)
+		}
+	}
+}